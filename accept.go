@@ -0,0 +1,87 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ConfigAcceptor is a callback that decides whether a new configuration
+// version from a watching source should be installed (see
+// [Params].AcceptConfig). It returns nil to accept newConfig, or an error to
+// reject it. ctx is canceled if the version is superseded by a newer one
+// before the callback returns.
+type ConfigAcceptor[T any] func(ctx context.Context, oldConfig, newConfig *T) error
+
+// ErrConfigSuperseded is wrapped by the error returned from
+// BlockingReportNewValue when the configuration containing the new value was
+// superseded by a newer one while awaiting acceptance (see
+// [Params].AcceptConfig).
+var ErrConfigSuperseded = errors.New("configuration superseded before being accepted")
+
+// pendingConfig is a new configuration version awaiting acceptance.
+type pendingConfig[T any] struct {
+	cfg       *T
+	oldConfig *T
+	sources   []sourceValue
+	updates   []*valueUpdate
+	cause     VersionCause
+	// unverified is true if cfg wasn't verified
+	unverified bool
+	cancel     context.CancelFunc
+}
+
+// acceptResult reports the result of calling AcceptConfig to the monitor
+// goroutine.
+type acceptResult[T any] struct {
+	p   *pendingConfig[T]
+	err error
+}
+
+func (*acceptResult[T]) isStatusReport() {}
+
+// propose calls AcceptConfig for newVers (composed from sources) on a new
+// goroutine, reporting the result on c.
+func (d *Dials[T]) propose(
+	ctx context.Context,
+	newVers *T,
+	sources []sourceValue,
+	updates []*valueUpdate,
+	cause VersionCause,
+	unverified bool,
+	c chan<- watchStatusUpdate,
+) *pendingConfig[T] {
+	acceptCtx, cancel := context.WithCancel(ctx)
+	p := &pendingConfig[T]{
+		cfg:        newVers,
+		oldConfig:  d.View(),
+		sources:    sources,
+		updates:    updates,
+		cause:      cause,
+		unverified: unverified,
+		cancel:     cancel,
+	}
+	go func() {
+		res := &acceptResult[T]{p: p, err: d.params.AcceptConfig(acceptCtx, p.oldConfig, p.cfg)}
+		select {
+		case <-ctx.Done():
+		case c <- res:
+		}
+	}()
+	return p
+}
+
+// supersede abandons p in favor of a newer version.
+func (p *pendingConfig[T]) supersede() {
+	p.cancel()
+	notifyInstalled(p.updates, ErrConfigSuperseded)
+}
+
+// rejected reports the rejection of p by AcceptConfig.
+func (d *Dials[T]) rejected(ctx context.Context, p *pendingConfig[T], err error) {
+	rejectErr := fmt.Errorf("configuration rejected: %w", err)
+	d.submitEvent(ctx, &watchErrorEvent[T]{
+		err: rejectErr, oldConfig: d.View(), newConfig: p.cfg,
+	})
+	notifyInstalled(p.updates, rejectErr)
+}
@@ -0,0 +1,85 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type acceptConfig struct {
+	Addr string
+}
+
+type ptrifiedAcceptConfig struct {
+	Addr *string
+}
+
+func acceptValue(addr string) reflect.Value {
+	return reflect.ValueOf(ptrifiedAcceptConfig{Addr: &addr})
+}
+
+type acceptCall struct {
+	ctx      context.Context
+	old, new *acceptConfig
+	resp     chan error
+}
+
+func TestAcceptConfig(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan acceptCall)
+	errs := make(chan error, 1)
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedAcceptConfig{}}}
+	d, err := Params[acceptConfig]{
+		AcceptConfig: func(ctx context.Context, oldConfig, newConfig *acceptConfig) error {
+			c := acceptCall{ctx: ctx, old: oldConfig, new: newConfig, resp: make(chan error)}
+			calls <- c
+			return <-c.resp
+		},
+		OnWatchedError: func(_ context.Context, err error, _, _ *acceptConfig) {
+			errs <- err
+		},
+	}.Config(ctx, &acceptConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	// accepted
+	w.send(ctx, acceptValue("b"))
+	c := <-calls
+	assert.Equal(t, "a", c.old.Addr)
+	assert.Equal(t, "b", c.new.Addr)
+	assert.Equal(t, "a", d.View().Addr, "installed before acceptance")
+	c.resp <- nil
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+	assert.Equal(t, "b", d.View().Addr)
+
+	// rejected
+	errProbe := errors.New("probe failed")
+	w.send(ctx, acceptValue("c"))
+	c = <-calls
+	c.resp <- errProbe
+	assert.ErrorIs(t, <-errs, errProbe)
+	assert.Equal(t, "b", d.View().Addr)
+
+	// superseded
+	reported := make(chan error, 1)
+	go func() {
+		reported <- w.args.BlockingReportNewValue(ctx, acceptValue("d").Convert(w.t.t))
+	}()
+	first := <-calls
+	w.send(ctx, acceptValue("e"))
+	second := <-calls
+	<-first.ctx.Done()
+	assert.ErrorIs(t, <-reported, ErrConfigSuperseded)
+	assert.Equal(t, "e", second.new.Addr)
+	second.resp <- nil
+	assert.Equal(t, "e", (<-d.Events()).Addr)
+	// the abandoned call's result is ignored
+	first.resp <- nil
+	assert.Equal(t, "e", d.View().Addr)
+}
@@ -0,0 +1,74 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuditRecord describes an installed configuration version for an audit log
+// (see [AuditSink]).
+type AuditRecord struct {
+	// Generation identifies the version (see [CfgSerial.Generation]).
+	Generation uint64 `json:"generation"`
+	// Installed is when the version was installed.
+	Installed time.Time    `json:"installed"`
+	Cause     VersionCause `json:"cause"`
+	// Sources contains the types of the sources that triggered the
+	// version, as in [HistoryEntry].Sources.
+	Sources []string `json:"sources,omitempty"`
+	// Changes contains the fields that differ from the previous version
+	// (every field, for the initial version), with the values of secret
+	// fields (see [SecretTag] and [Params].Redact) replaced by
+	// RedactedValue.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// AuditSink records installed configuration versions, for environments that
+// must show what changed the configuration and when. See [Params].AuditSink.
+type AuditSink interface {
+	// Audit records rec. It's called synchronously before the version is
+	// published, so it should return promptly.
+	Audit(ctx context.Context, rec AuditRecord) error
+}
+
+// MarshalText encodes c as its String.
+func (c VersionCause) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// audit passes the record of the installed version vc, which replaced the
+// configuration old (nil for the initial version), to Params.AuditSink (if
+// set).
+func (d *Dials[T]) audit(ctx context.Context, old *T, vc *versionedConfig[T]) {
+	sink := d.params.AuditSink
+	if sink == nil {
+		return
+	}
+	changes := Diff(old, vc.cfg)
+	for i := range changes {
+		changes[i].Secret = changes[i].Secret || redactedPath(d.params.Redact, changes[i].Path)
+		if changes[i].Secret {
+			if old != nil {
+				changes[i].Old = RedactedValue
+			}
+			changes[i].New = RedactedValue
+		}
+	}
+	rec := AuditRecord{
+		Generation: vc.serial,
+		Installed:  vc.installed,
+		Cause:      vc.cause,
+		Changes:    changes,
+	}
+	for _, s := range vc.triggers {
+		rec.Sources = append(rec.Sources, sourceName(s))
+	}
+	if err := sink.Audit(ctx, rec); err != nil {
+		d.params.Logger.Error("dials: failed to audit configuration", "generation", vc.serial, "error", err)
+		d.warnings.report(ctx, Warning{
+			Kind:    WarningAuditFailed,
+			Message: fmt.Sprintf("failed to audit configuration generation %d: %s", vc.serial, err),
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package audit provides implementations of dials.AuditSink, which record
+// every installed configuration version (see dials.Params.AuditSink) to a
+// file, a logger or an HTTP endpoint.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/vimeo/dials"
+)
+
+// WriterSink writes each record to an io.Writer as a line of JSON.
+type WriterSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ dials.AuditSink = (*WriterSink)(nil)
+
+// NewWriterSink returns a WriterSink writing to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(w)}
+}
+
+// Audit writes rec as a line of JSON. Writes are serialized, so concurrent
+// calls don't interleave their lines.
+func (s *WriterSink) Audit(_ context.Context, rec dials.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(rec); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// FileSink appends each record to a file as a line of JSON, syncing the
+// file after each one, so records survive a crash.
+type FileSink struct {
+	WriterSink
+	f *os.File
+}
+
+var _ dials.AuditSink = (*FileSink)(nil)
+
+// OpenFile opens (or creates) the file at path for appending records to.
+// Files are created with permissions that only allow the owner to read them.
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{WriterSink: WriterSink{enc: json.NewEncoder(f)}, f: f}, nil
+}
+
+// Audit appends rec to the file, and syncs it.
+func (s *FileSink) Audit(ctx context.Context, rec dials.AuditRecord) error {
+	if err := s.WriterSink.Audit(ctx, rec); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// LoggerSink logs each record at the Info level.
+type LoggerSink struct {
+	Logger dials.Logger
+}
+
+var _ dials.AuditSink = LoggerSink{}
+
+// Audit logs rec, with the changes described by their String methods (which
+// redact secret values).
+func (s LoggerSink) Audit(_ context.Context, rec dials.AuditRecord) error {
+	changes := make([]string, len(rec.Changes))
+	for i, c := range rec.Changes {
+		changes[i] = c.String()
+	}
+	s.Logger.Info("dials: configuration installed",
+		"generation", rec.Generation,
+		"installed", rec.Installed,
+		"cause", rec.Cause.String(),
+		"sources", strings.Join(rec.Sources, ", "),
+		"changes", strings.Join(changes, "; "))
+	return nil
+}
+
+// HTTPSink POSTs each record to URL as JSON.
+type HTTPSink struct {
+	URL string
+	// Client sends the requests (http.DefaultClient if nil). Since
+	// records are sent as configuration versions are installed, it should
+	// have a timeout.
+	Client *http.Client
+	// Header contains additional headers to send with each request (e.g.
+	// Authorization).
+	Header http.Header
+}
+
+var _ dials.AuditSink = (*HTTPSink)(nil)
+
+// Audit POSTs rec to s.URL, failing unless the response has a 2xx status.
+func (s *HTTPSink) Audit(ctx context.Context, rec dials.AuditRecord) error {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create audit request: %w", err)
+	}
+	for k, vs := range s.Header {
+		req.Header[k] = append(req.Header[k], vs...)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit record: %w", err)
+	}
+	defer resp.Body.Close()
+	// drain the body, so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit endpoint returned %s", resp.Status)
+	}
+	return nil
+}
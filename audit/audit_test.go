@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
+)
+
+type config struct {
+	Addr     string
+	Password string `dialssecret:"true"`
+}
+
+// audited configures a Dials with sink, pushes an update, and waits for it.
+func audited(t *testing.T, sink dials.AuditSink) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	src := dialstest.NewSource(&config{Addr: "a", Password: "hunter2"})
+	d, err := dials.New(ctx, &config{}, dials.WithSources(src), dials.WithAuditSink(sink))
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	require.NoError(t, src.Push(ctx, &config{Addr: "b", Password: "hunter3"}))
+	_, err = dialstest.Await(ctx, d, func(c *config) bool { return c.Addr == "b" })
+	require.NoError(t, err)
+}
+
+// record is the JSON encoding of a dials.AuditRecord.
+type record struct {
+	Generation uint64
+	Cause      string
+	Sources    []string
+	Changes    []struct {
+		Path     []string
+		Old, New interface{}
+		Secret   bool
+	}
+}
+
+func checkRecords(t *testing.T, recs []record) {
+	t.Helper()
+	require.Len(t, recs, 2)
+	assert.Equal(t, uint64(0), recs[0].Generation)
+	assert.Equal(t, "initial", recs[0].Cause)
+	assert.Equal(t, uint64(1), recs[1].Generation)
+	assert.Equal(t, "update", recs[1].Cause)
+	assert.Equal(t, []string{"*dialstest.Source[github.com/vimeo/dials/audit.config]"}, recs[1].Sources)
+	require.Len(t, recs[1].Changes, 2)
+	assert.Equal(t, "a", recs[1].Changes[0].Old)
+	assert.Equal(t, "b", recs[1].Changes[0].New)
+	assert.Equal(t, dials.RedactedValue, recs[1].Changes[1].New)
+	assert.True(t, recs[1].Changes[1].Secret)
+}
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := OpenFile(path)
+	require.NoError(t, err)
+	audited(t, sink)
+	require.NoError(t, sink.Close())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	recs := []record{}
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		assert.NotContains(t, sc.Text(), "hunter")
+		rec := record{}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+		recs = append(recs, rec)
+	}
+	checkRecords(t, recs)
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Parallel()
+	recs := make(chan record, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		rec := record{}
+		assert.NoError(t, json.Unmarshal(body, &rec))
+		recs <- rec
+	}))
+	defer srv.Close()
+
+	audited(t, &HTTPSink{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}})
+	checkRecords(t, []record{<-recs, <-recs})
+}
+
+func TestHTTPSinkError(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	sink := &HTTPSink{URL: srv.URL}
+	err := sink.Audit(context.Background(), dials.AuditRecord{})
+	assert.EqualError(t, err, "audit endpoint returned 403 Forbidden")
+}
+
+type logLine struct {
+	msg  string
+	args []interface{}
+}
+
+type fakeLogger struct {
+	lines chan logLine
+}
+
+func (l *fakeLogger) Debug(string, ...interface{}) {}
+func (l *fakeLogger) Info(msg string, args ...interface{}) {
+	l.lines <- logLine{msg: msg, args: args}
+}
+func (l *fakeLogger) Warn(string, ...interface{})  {}
+func (l *fakeLogger) Error(string, ...interface{}) {}
+
+func TestLoggerSink(t *testing.T) {
+	t.Parallel()
+	l := fakeLogger{lines: make(chan logLine, 2)}
+	audited(t, LoggerSink{Logger: &l})
+	<-l.lines
+	line := <-l.lines
+	assert.Equal(t, "dials: configuration installed", line.msg)
+	require.Len(t, line.args, 10)
+	assert.Equal(t, []interface{}{"generation", uint64(1)}, line.args[:2])
+	assert.Equal(t, []interface{}{"cause", "update"}, line.args[4:6])
+	assert.Equal(t, []interface{}{"changes", "Addr: a -> b; Password: <redacted> -> <redacted>"}, line.args[8:])
+}
@@ -0,0 +1,110 @@
+package dials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditConfig struct {
+	Addr  string
+	Token string `dialssecret:"true"`
+	Port  int
+}
+
+type ptrifiedAuditConfig struct {
+	Addr  *string
+	Token *string `dialssecret:"true"`
+	Port  *int
+}
+
+type recordingAuditSink struct {
+	mu   sync.Mutex
+	recs []AuditRecord
+	err  error
+}
+
+func (s *recordingAuditSink) Audit(_ context.Context, rec AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs = append(s.recs, rec)
+	return s.err
+}
+
+func (s *recordingAuditSink) records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditRecord(nil), s.recs...)
+}
+
+func TestAuditSink(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	sink := recordingAuditSink{}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedAuditConfig{}}}
+	d, err := New(ctx, &auditConfig{Addr: "a", Token: "s3cret", Port: 1},
+		WithSources(&w),
+		WithAuditSink(&sink),
+		WithRedact(func(path []string) bool { return path[0] == "Port" }))
+	require.NoError(t, err)
+
+	addr, token := "b", "hunter2"
+	w.send(ctx, reflect.ValueOf(ptrifiedAuditConfig{Addr: &addr, Token: &token}))
+	<-d.Events()
+
+	recs := sink.records()
+	require.Len(t, recs, 2)
+	assert.Equal(t, uint64(0), recs[0].Generation)
+	assert.Equal(t, VersionInitial, recs[0].Cause)
+	assert.Empty(t, recs[0].Sources)
+	assert.Equal(t, []FieldChange{
+		{Path: []string{"Addr"}, New: "a"},
+		{Path: []string{"Token"}, New: RedactedValue, Secret: true},
+		{Path: []string{"Port"}, New: RedactedValue, Secret: true},
+	}, recs[0].Changes)
+
+	assert.Equal(t, uint64(1), recs[1].Generation)
+	assert.Equal(t, VersionUpdate, recs[1].Cause)
+	assert.False(t, recs[1].Installed.Before(start))
+	assert.Equal(t, []string{"*dials.fakeWatchingSource"}, recs[1].Sources)
+	assert.Equal(t, []FieldChange{
+		{Path: []string{"Addr"}, Old: "a", New: "b"},
+		{Path: []string{"Token"}, Old: RedactedValue, New: RedactedValue, Secret: true},
+	}, recs[1].Changes)
+
+	js, err := json.Marshal(recs[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(js), `"generation":1,`)
+	assert.Contains(t, string(js), `"cause":"update","sources":["*dials.fakeWatchingSource"]`)
+	assert.NotContains(t, string(js), "hunter2")
+}
+
+func TestAuditSinkFailure(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := recordingAuditSink{err: errors.New("disk full")}
+	warnings := make(chan Warning, 1)
+	d, err := Params[auditConfig]{
+		AuditSink: &sink,
+		OnWarning: func(_ context.Context, w Warning) { warnings <- w },
+	}.Config(ctx, &auditConfig{Addr: "a"}, &fakeSource{outVal: ptrifiedAuditConfig{}})
+	// the configuration is installed regardless
+	require.NoError(t, err)
+	assert.Equal(t, "a", d.View().Addr)
+
+	w := <-warnings
+	assert.Equal(t, WarningAuditFailed, w.Kind)
+	assert.Equal(t, "audit failed: failed to audit configuration generation 0: disk full", w.String())
+}
@@ -0,0 +1,160 @@
+// Package bytesize provides a configuration type for sizes in bytes that
+// accepts human-friendly values like "512MiB" or "2GB" from every source.
+package bytesize
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a number of bytes. It implements encoding.TextUnmarshaler, so
+// flags, environment variables and string values in config files may be
+// written with a unit suffix (see Parse). Numeric values in config files
+// (e.g. `size: 1024` in YAML) are plain byte counts.
+type ByteSize int64
+
+// Decimal (SI) units.
+const (
+	B  ByteSize = 1
+	KB ByteSize = 1000 * B
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+)
+
+// Binary (IEC) units.
+const (
+	KiB ByteSize = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+type unit struct {
+	suffix string
+	size   ByteSize
+}
+
+// binaryUnits and decimalUnits are ordered from largest to smallest.
+var (
+	binaryUnits = []unit{
+		{"EiB", EiB}, {"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	}
+	decimalUnits = []unit{
+		{"EB", EB}, {"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	}
+)
+
+// suffixes maps the lower-cased unit suffixes accepted by Parse to their
+// sizes. The short forms follow the Kubernetes convention: "K" is decimal and
+// "Ki" is binary.
+var suffixes = map[string]ByteSize{"": B, "b": B}
+
+func init() {
+	for _, u := range append(binaryUnits, decimalUnits...) {
+		s := strings.ToLower(u.suffix)
+		suffixes[s] = u.size
+		suffixes[strings.TrimSuffix(s, "b")] = u.size
+	}
+}
+
+// Parse parses a size, which is a non-negative number (possibly with a
+// fractional part) followed by an optional unit: B, KB, MB, GB, TB, PB or EB
+// for powers of 1000, or KiB, MiB, GiB, TiB, PiB or EiB for powers of 1024.
+// Units are case-insensitive, the trailing "B" may be omitted (e.g. "64Ki"),
+// and whitespace is allowed between the number and the unit. Fractional
+// sizes are rounded down to a whole number of bytes.
+func Parse(s string) (ByteSize, error) {
+	trimmed := strings.TrimSpace(s)
+	numEnd := strings.IndexFunc(trimmed, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if numEnd < 0 {
+		numEnd = len(trimmed)
+	}
+	num, suffix := trimmed[:numEnd], strings.TrimSpace(trimmed[numEnd:])
+	if num == "" {
+		return 0, fmt.Errorf("invalid byte size %q: missing number", s)
+	}
+	mult, ok := suffixes[strings.ToLower(suffix)]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, suffix)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q: %w", s, err)
+		}
+		if n > math.MaxInt64/int64(mult) {
+			return 0, fmt.Errorf("invalid byte size %q: overflows int64", s)
+		}
+		return ByteSize(n) * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, err)
+	}
+	bytes := f * float64(mult)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: overflows int64", s)
+	}
+	return ByteSize(bytes), nil
+}
+
+// String formats b with the largest unit that represents it exactly (e.g.
+// "512MiB", "2GB" or "1023B"), so the result parses back to the same value.
+func (b ByteSize) String() string {
+	best := unit{"B", B}
+	if b != 0 {
+		for _, units := range [...][]unit{binaryUnits, decimalUnits} {
+			for _, u := range units {
+				if b%u.size == 0 && u.size > best.size {
+					best = u
+					break
+				}
+			}
+		}
+	}
+	return strconv.FormatInt(int64(b/best.size), 10) + best.suffix
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting both numbers of bytes
+// and strings (which are parsed with Parse).
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("invalid byte size %s: %w", data, err)
+		}
+		return b.UnmarshalText([]byte(s))
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid byte size %s: %w", data, err)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
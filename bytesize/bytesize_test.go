@@ -0,0 +1,105 @@
+package bytesize_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/bytesize"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/static"
+)
+
+func TestParse(t *testing.T) {
+	for _, itbl := range []struct {
+		input    string
+		expected bytesize.ByteSize
+		expErr   bool
+	}{
+		{input: "0", expected: 0},
+		{input: "1024", expected: bytesize.KiB},
+		{input: "512MiB", expected: 512 * bytesize.MiB},
+		{input: "2GB", expected: 2 * bytesize.GB},
+		{input: "2 gb", expected: 2 * bytesize.GB},
+		{input: "64Ki", expected: 64 * bytesize.KiB},
+		{input: "10k", expected: 10 * bytesize.KB},
+		{input: "1.5KiB", expected: 1536},
+		{input: "0.5B", expected: 0},
+		{input: "8EiB", expErr: true},
+		{input: "7EiB", expected: 7 * bytesize.EiB},
+		{input: "", expErr: true},
+		{input: "MiB", expErr: true},
+		{input: "12 parsecs", expErr: true},
+		{input: "-1KB", expErr: true},
+		{input: "1..5MB", expErr: true},
+	} {
+		tbl := itbl
+		t.Run(tbl.input, func(t *testing.T) {
+			got, err := bytesize.Parse(tbl.input)
+			if tbl.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tbl.expected, got)
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tbl := range []struct {
+		size     bytesize.ByteSize
+		expected string
+	}{
+		{size: 0, expected: "0B"},
+		{size: 1023, expected: "1023B"},
+		{size: 1536, expected: "1536B"},
+		{size: bytesize.KiB, expected: "1KiB"},
+		{size: 1000 * bytesize.KiB, expected: "1000KiB"},
+		{size: 512 * bytesize.MiB, expected: "512MiB"},
+		{size: 3 * bytesize.TiB, expected: "3TiB"},
+		{size: 7 * bytesize.EiB, expected: "7EiB"},
+		{size: 5 * bytesize.KB, expected: "5KB"},
+		{size: 2 * bytesize.GB, expected: "2GB"},
+		{size: 1500 * bytesize.MB, expected: "1500MB"},
+		{size: bytesize.GB + 1, expected: "1000000001B"},
+	} {
+		assert.Equal(t, tbl.expected, tbl.size.String(), "%d bytes", int64(tbl.size))
+		// the output parses back to the same value
+		parsed, err := bytesize.Parse(tbl.expected)
+		require.NoError(t, err)
+		assert.Equal(t, tbl.size, parsed)
+	}
+}
+
+type config struct {
+	Limit  bytesize.ByteSize
+	Buffer bytesize.ByteSize
+}
+
+func TestSources(t *testing.T) {
+	ctx := context.Background()
+	expected := &config{Limit: 512 * bytesize.MiB, Buffer: 64 * bytesize.KiB}
+
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &config{}, []string{"-limit=512MiB", "-buffer=64KiB"})
+	require.NoError(t, err)
+	envSrc := &env.Source{LookupEnv: env.MapLookup(map[string]string{"LIMIT": "512MiB", "BUFFER": "64KiB"})}
+
+	for name, src := range map[string]dials.Source{
+		"flag": fset,
+		"env":  envSrc,
+		"json": &static.StringSource{Data: `{"Limit": "512MiB", "Buffer": 65536}`, Decoder: &json.Decoder{}},
+		"yaml": &static.StringSource{Data: "limit: 512MiB\nbuffer: 65536\n", Decoder: &yaml.Decoder{}},
+		"toml": &static.StringSource{Data: "Limit = \"512MiB\"\nBuffer = 65536\n", Decoder: &toml.Decoder{}},
+	} {
+		d, err := dials.Config(ctx, &config{}, src)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, d.View(), name)
+	}
+}
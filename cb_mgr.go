@@ -7,6 +7,8 @@ import (
 
 type callbackMgr[T any] struct {
 	p *Params[T]
+	// initial is the version installed by Config (with serial 0)
+	initial *T
 
 	ch <-chan userCallbackEvent
 }
@@ -36,6 +38,16 @@ func (*watchErrorEvent[T]) isUserCallbackEvent() {}
 
 var _ userCallbackEvent = (*watchErrorEvent[struct{}])(nil)
 
+// circuitStateEvent sends the arguments to an OnCircuitStateChange callback.
+type circuitStateEvent struct {
+	source Source
+	state  CircuitState
+}
+
+func (*circuitStateEvent) isUserCallbackEvent() {}
+
+var _ userCallbackEvent = (*circuitStateEvent)(nil)
+
 type userCallbackHandle[T any] struct {
 	cb        NewConfigHandler[T]
 	minSerial uint64
@@ -50,6 +62,17 @@ func (*userCallbackRegistration[T]) isUserCallbackEvent() {}
 
 var _ userCallbackEvent = (*userCallbackRegistration[struct{}])(nil)
 
+// userCallbackSubscription registers a callback after replaying up to replay
+// of the most recent versions to it.
+type userCallbackSubscription[T any] struct {
+	handle *userCallbackHandle[T]
+	replay int
+}
+
+func (*userCallbackSubscription[T]) isUserCallbackEvent() {}
+
+var _ userCallbackEvent = (*userCallbackSubscription[struct{}])(nil)
+
 type userCallbackUnregister[T any] struct {
 	// handle describes the relevant callback, and is the key in the newCfgCBs set tracked by
 	// runCBs.
@@ -67,6 +90,13 @@ func (cbm *callbackMgr[T]) runCBs(ctx context.Context) {
 	newCfgCBs := make([]*userCallbackHandle[T], 0)
 	lastSerial := uint64(0)
 	lastVersion := (*T)(nil)
+	// history holds the most recent versions, oldest first.
+	historyLen := cbm.p.ReplayBufferSize
+	if historyLen < 1 {
+		historyLen = 1
+	}
+	history := make([]*T, 1, historyLen)
+	history[0] = cbm.initial
 	for ev := range cbm.ch {
 		switch e := ev.(type) {
 		case *watchErrorEvent[T]:
@@ -76,9 +106,22 @@ func (cbm *callbackMgr[T]) runCBs(ctx context.Context) {
 		case *newConfigEvent[T]:
 			lastSerial = e.serial
 			lastVersion = e.newConfig
+			if len(history) == historyLen {
+				copy(history, history[1:])
+				history = history[:len(history)-1]
+			}
+			history = append(history, e.newConfig)
 			if cbm.p.OnNewConfig != nil && !e.globalCBsSuppressed {
 				cbm.p.OnNewConfig(ctx, e.oldConfig, e.newConfig)
 			}
+			if cbm.p.OnConfigChange != nil && !e.globalCBsSuppressed {
+				if changes := Diff(e.oldConfig, e.newConfig); len(changes) > 0 {
+					for i := range changes {
+						changes[i].Secret = changes[i].Secret || redactedPath(cbm.p.Redact, changes[i].Path)
+					}
+					cbm.p.OnConfigChange(ctx, e.oldConfig, e.newConfig, changes)
+				}
+			}
 			for _, cbh := range newCfgCBs {
 				if cbh.minSerial >= e.serial {
 					// Skip the callback if it was registered with a serial for
@@ -87,6 +130,10 @@ func (cbm *callbackMgr[T]) runCBs(ctx context.Context) {
 				}
 				cbh.cb(ctx, e.oldConfig, e.newConfig)
 			}
+		case *circuitStateEvent:
+			if cbm.p.OnCircuitStateChange != nil {
+				cbm.p.OnCircuitStateChange(ctx, e.source, e.state)
+			}
 		case *userCallbackRegistration[T]:
 			// Serial values are assigned sequentially, so make sure we don't deliver an
 			// older config if we've fallen behind.
@@ -95,6 +142,20 @@ func (cbm *callbackMgr[T]) runCBs(ctx context.Context) {
 			}
 			// add this callback to the set of callbacks
 			newCfgCBs = append(newCfgCBs, e.handle)
+		case *userCallbackSubscription[T]:
+			start := len(history) - e.replay
+			if start < 0 {
+				start = 0
+			}
+			for i := start; i < len(history); i++ {
+				prev := (*T)(nil)
+				if i > 0 {
+					prev = history[i-1]
+				}
+				e.handle.cb(ctx, prev, history[i])
+			}
+			e.handle.minSerial = lastSerial
+			newCfgCBs = append(newCfgCBs, e.handle)
 		case *userCallbackUnregister[T]:
 			removed := make([]*userCallbackHandle[T], 0, len(newCfgCBs)-1)
 			for _, cb := range newCfgCBs {
@@ -0,0 +1,118 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerParams configures circuit-breakers that are attached to each
+// watching source.
+//
+// Once a watching source reports Threshold consecutive errors (without a new
+// value in between), its breaker opens for CoolDown. While a breaker is open,
+// errors reported by that source are suppressed, and ReportError returns a
+// [*CircuitOpenError] so the Watcher can pause whatever it's doing (e.g.
+// polling a remote backend) until the cool-down expires.
+//
+// After the cool-down, the next error re-opens the breaker immediately, while
+// the next new value closes it.
+type CircuitBreakerParams struct {
+	// Threshold is the number of consecutive errors after which the
+	// breaker opens. A non-positive Threshold disables circuit-breaking.
+	Threshold int
+	// CoolDown is the period for which the breaker stays open.
+	CoolDown time.Duration
+}
+
+// CircuitState is the state of a watching source's circuit-breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed indicates that the source is healthy.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen indicates that the source has reported too many
+	// consecutive errors, and should back off.
+	CircuitOpen
+)
+
+func (c CircuitState) String() string {
+	switch c {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(c))
+	}
+}
+
+// CircuitStateChangeHandler is a callback that's called when a watching
+// source's circuit-breaker changes state.
+type CircuitStateChangeHandler func(ctx context.Context, source Source, state CircuitState)
+
+// CircuitOpenError is returned by [WatchArgs].ReportError when the
+// reporting source's circuit-breaker is open. Watchers should refrain from
+// contacting their backend until Until.
+type CircuitOpenError struct {
+	Until time.Time
+	// Err is the error that tripped the breaker.
+	Err error
+}
+
+func (c *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit-breaker open until %s (tripped by: %s)",
+		c.Until.Format(time.RFC3339Nano), c.Err)
+}
+
+func (c *CircuitOpenError) Unwrap() error {
+	return c.Err
+}
+
+type circuitBreaker struct {
+	params CircuitBreakerParams
+
+	mu          sync.Mutex
+	consecutive int
+	state       CircuitState
+	openErr     *CircuitOpenError
+}
+
+// recordError records a newly reported error, and returns a non-nil
+// CircuitOpenError if the breaker is open. opened indicates that this error
+// tripped the breaker, suppressed that the error should not be propagated.
+func (c *circuitBreaker) recordError(err error) (openErr *CircuitOpenError, opened, suppressed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.openErr != nil && now.Before(c.openErr.Until) {
+		return c.openErr, false, true
+	}
+	c.consecutive++
+	if c.consecutive < c.params.Threshold {
+		return nil, false, false
+	}
+	c.openErr = &CircuitOpenError{Until: now.Add(c.params.CoolDown), Err: err}
+	opened = c.state != CircuitOpen
+	c.state = CircuitOpen
+	return c.openErr, opened, false
+}
+
+// recordSuccess resets the breaker, returning true if it was open.
+func (c *circuitBreaker) recordSuccess() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consecutive = 0
+	c.openErr = nil
+	wasOpen := c.state == CircuitOpen
+	c.state = CircuitClosed
+	return wasOpen
+}
+
+type circuitStateReport struct {
+	source Source
+	state  CircuitState
+}
+
+func (*circuitStateReport) isStatusReport() {}
@@ -0,0 +1,63 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 4)
+	stateCh := make(chan CircuitState, 4)
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Params[testConfig]{
+		OnWatchedError: func(_ context.Context, err error, _, _ *testConfig) { errCh <- err },
+		OnCircuitStateChange: func(_ context.Context, src Source, state CircuitState) {
+			assert.Equal(t, &w, src)
+			stateCh <- state
+		},
+		CircuitBreaker: CircuitBreakerParams{Threshold: 2, CoolDown: time.Hour},
+	}.Config(ctx, &testConfig{Foo: "foo"}, &w)
+	require.NoError(t, err)
+
+	backendErr := errors.New("backend unavailable")
+	assert.NoError(t, w.args.ReportError(ctx, backendErr))
+	assert.ErrorIs(t, <-errCh, backendErr)
+
+	// the second consecutive error trips the breaker
+	tripErr := w.args.ReportError(ctx, backendErr)
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(tripErr, &openErr), "unexpected error: %v", tripErr)
+	assert.ErrorIs(t, openErr, backendErr)
+	assert.True(t, openErr.Until.After(time.Now()))
+	assert.ErrorIs(t, <-errCh, backendErr)
+	assert.Equal(t, CircuitOpen, <-stateCh)
+
+	// while open, errors are suppressed
+	assert.ErrorIs(t, w.args.ReportError(ctx, errors.New("suppressed")), backendErr)
+
+	// a new value closes the breaker
+	barStr := "bar"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &barStr}))
+	assert.Equal(t, "bar", (<-d.Events()).Foo)
+	assert.Equal(t, CircuitClosed, <-stateCh)
+	assert.NoError(t, w.args.ReportError(ctx, backendErr))
+	assert.ErrorIs(t, <-errCh, backendErr)
+	assert.Empty(t, errCh)
+}
@@ -0,0 +1,163 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClosed is returned by [Dials.EnableVerification] when the Dials
+// instance's watching goroutines have stopped (e.g. because it was closed).
+var ErrClosed = errors.New("dials instance closed")
+
+// StoppableWatcher is implemented by Watchers that hold resources (e.g.
+// goroutines, file-descriptors or network connections) that should be
+// released when the Dials instance using them is closed. Since StopWatch
+// tears down the source's watch, a StoppableWatcher should only be passed to
+// one call to Config.
+type StoppableWatcher interface {
+	Watcher
+	// StopWatch stops watching for changes and releases the resources
+	// associated with the watch, returning once they've been released
+	// or ctx expires. It's called by [Dials.Close] after the context
+	// passed to Watch is canceled. Once StopWatch returns, the source
+	// must not call any methods on its WatchArgs.
+	StopWatch(ctx context.Context) error
+}
+
+// closeState tracks the goroutines and sources that [Dials.Close] tears down.
+type closeState struct {
+	once sync.Once
+	// closing is closed when Close is first called
+	closing chan struct{}
+	// cancel cancels the context passed to the watching sources and the
+	// monitor and callback goroutines
+	cancel context.CancelFunc
+	// stoppable contains the watching sources implementing
+	// StoppableWatcher. mu guards it (and closing being closed), as
+	// AddSource and RemoveSource modify it.
+	mu        sync.Mutex
+	stoppable []StoppableWatcher
+	// done is closed once the monitor and callback goroutines have
+	// exited. It's nil if there are no watching sources.
+	done chan struct{}
+	// stopErr is the result of stopping the sources
+	stopErr error
+	// watches are the stopped watches, whose goroutines Close awaits
+	watches []*watch
+}
+
+func newCloseState(cancel context.CancelFunc) *closeState {
+	return &closeState{
+		closing: make(chan struct{}),
+		cancel:  cancel,
+	}
+}
+
+// addStoppable adds sw to the sources to stop on Close, returning false if
+// Close has already been called.
+func (c *closeState) addStoppable(sw StoppableWatcher) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosing() {
+		return false
+	}
+	c.stoppable = append(c.stoppable, sw)
+	return true
+}
+
+// removeStoppable removes s from the sources to stop on Close, returning
+// false if it's not among them, or if Close has already been called (and so
+// is stopping all of them, including s).
+func (c *closeState) removeStoppable(s Source) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosing() {
+		return false
+	}
+	for i, sw := range c.stoppable {
+		if interface{}(sw) == s {
+			c.stoppable = append(c.stoppable[:i:i], c.stoppable[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// monitorExited returns true if the monitor goroutine has exited without
+// Close having been called, because every watching source called Done. The
+// Dials instance then behaves as if no sources were watching, installing
+// versions itself (see restackUnwatched).
+func (d *Dials[T]) monitorExited() bool {
+	if d.closer.done == nil || d.closer.isClosing() {
+		return false
+	}
+	select {
+	case <-d.closer.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// isClosing returns true once Close has been called.
+func (c *closeState) isClosing() bool {
+	select {
+	case <-c.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops watching for configuration changes: it cancels the context
+// passed to each watching source's Watch method, calls StopWatch on those
+// implementing [StoppableWatcher], and waits for the goroutines that install
+// new configurations and run callbacks to exit (along with those the sources
+// started with [WatchGo]), after which the channel returned by Events
+// and those of every [Subscription] are closed. The configuration returned
+// by View remains available.
+//
+// Close returns early (with an error wrapping ctx.Err()) if ctx expires
+// first, in which case it may be called again to resume waiting. Otherwise,
+// it returns the first error returned by a source's StopWatch method.
+// Callbacks can no longer be registered once Close has been called.
+func (d *Dials[T]) Close(ctx context.Context) error {
+	c := d.closer
+	c.once.Do(func() {
+		c.mu.Lock()
+		close(c.closing)
+		stoppable := c.stoppable
+		c.mu.Unlock()
+		c.cancel()
+		c.watches = d.sources.closeWatches()
+		_, statuses := d.sources.snapshot()
+		for _, s := range statuses {
+			s.setWatching(false)
+		}
+		for _, s := range stoppable {
+			if err := s.StopWatch(ctx); err != nil && c.stopErr == nil {
+				c.stopErr = fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+			}
+		}
+	})
+	if c.done != nil {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return fmt.Errorf("context expired while awaiting shutdown: %w", ctx.Err())
+		}
+	}
+	for _, w := range c.watches {
+		if err := w.wait(ctx); err != nil {
+			return fmt.Errorf("context expired while awaiting shutdown: %w", ctx.Err())
+		}
+	}
+	// the monitor goroutine (the only publisher) has exited, so it's safe
+	// to close the subscriptions' channels.
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	d.subs.close()
+	return c.stopErr
+}
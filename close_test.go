@@ -0,0 +1,179 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stoppableWatchingSource runs a goroutine from Watch until its context is
+// canceled, and records calls to StopWatch.
+type stoppableWatchingSource struct {
+	fakeSource
+	wg      sync.WaitGroup
+	stopped int
+	stopErr error
+}
+
+func (s *stoppableWatchingSource) Watch(ctx context.Context, _ *Type, args WatchArgs) error {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+	}()
+	return nil
+}
+
+func (s *stoppableWatchingSource) StopWatch(ctx context.Context) error {
+	s.stopped++
+	s.wg.Wait()
+	return s.stopErr
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	sw := stoppableWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	newCfgCalled := make(chan struct{}, 1)
+	d, err := Params[testConfig]{
+		OnNewConfig: func(context.Context, *testConfig, *testConfig) {
+			newCfgCalled <- struct{}{}
+		},
+	}.Config(ctx, &testConfig{Foo: "foo"}, &w, &sw)
+	require.NoError(t, err)
+
+	bar := "bar"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &bar}))
+	<-d.Events()
+	<-newCfgCalled
+
+	require.NoError(t, d.Close(ctx))
+	assert.Equal(t, 1, sw.stopped)
+
+	// the events channel is closed
+	_, ok := <-d.Events()
+	assert.False(t, ok)
+	// the last configuration is still available
+	assert.Equal(t, "bar", d.View().Foo)
+
+	// callbacks can no longer be registered
+	_, serial := d.ViewVersion()
+	assert.Nil(t, d.RegisterCallback(ctx, serial, func(context.Context, *testConfig, *testConfig) {}))
+
+	// closing again is a no-op
+	require.NoError(t, d.Close(ctx))
+	assert.Equal(t, 1, sw.stopped)
+}
+
+func TestCloseStopError(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopErr := errors.New("stop failed")
+	sw := stoppableWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}, stopErr: stopErr}
+	d, err := Config(ctx, &testConfig{}, &sw)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, d.Close(ctx), stopErr)
+}
+
+func TestCloseNoWatchers(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx := context.Background()
+	d, err := Config(ctx, &testConfig{Foo: "foo"}, &fakeSource{outVal: ptrifiedConfig{}})
+	require.NoError(t, err)
+
+	require.NoError(t, d.Close(ctx))
+	_, ok := <-d.Events()
+	assert.False(t, ok)
+	assert.Equal(t, "foo", d.View().Foo)
+}
+
+func TestMonitorExitedWithoutClose(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	foo := "foo"
+	static := &fakeSource{outVal: ptrifiedConfig{Foo: &foo}}
+	w := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{}, static, w)
+	require.NoError(t, err)
+
+	// once the only watching source is done, the monitor goroutine exits,
+	// but the instance remains usable
+	w.args.Done(ctx)
+	<-d.closer.done
+
+	bar := "bar"
+	static.outVal = ptrifiedConfig{Foo: &bar}
+	cfg, _, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", cfg.Foo)
+
+	cfg, _, err = d.Rollback(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", cfg.Foo)
+
+	cfg, _, err = d.DisableSource(ctx, static)
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.Foo)
+
+	baz := "baz"
+	cfg, _, err = d.AddSource(ctx, &fakeSource{outVal: ptrifiedConfig{Foo: &baz}})
+	require.NoError(t, err)
+	assert.Equal(t, "baz", cfg.Foo)
+	_, _, err = d.AddSource(ctx, &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}})
+	assert.ErrorContains(t, err, "every watching source has stopped watching")
+
+	// callbacks are never called, so they can't be registered, and
+	// subscriptions only receive the replay
+	_, serial := d.ViewVersion()
+	assert.Nil(t, d.RegisterCallback(ctx, serial, func(context.Context, *testConfig, *testConfig) {}))
+	replayed := []string{}
+	unsubscribe := d.Subscribe(ctx, 1, func(_ context.Context, _, nc *testConfig) {
+		replayed = append(replayed, nc.Foo)
+	})
+	require.NotNil(t, unsubscribe)
+	assert.Equal(t, []string{"baz"}, replayed)
+
+	require.NoError(t, d.Close(ctx))
+	_, _, err = d.Refresh(ctx)
+	assert.ErrorIs(t, err, ErrClosed)
+	_, _, err = d.Rollback(ctx)
+	assert.ErrorIs(t, err, ErrClosed)
+}
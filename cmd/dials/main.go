@@ -0,0 +1,125 @@
+// Command dials validates config files against a config struct, prints the
+// configuration composed from a set of sources, and generates the struct's
+// JSON Schema and documentation, and the code replacing the reflective
+// composition of its values:
+//
+//	dials -pkg example.com/myapp/config -type Config validate -file config.yaml
+//	dials -pkg example.com/myapp/config -type Config print -file base.yaml -file prod.yaml -env -explain
+//	dials -pkg example.com/myapp/config -type Config schema -format yaml
+//	dials -pkg example.com/myapp/config -type Config -defaults NewConfig docs -file-format yaml
+//	dials -pkg example.com/myapp/config -type Config generate -o config_dials.go
+//
+// Since the config type is only known at run time, dials writes a small
+// program that passes it to dialscli.Run, and runs it with "go run". It must
+// be run from within a module that can import both the config package and
+// github.com/vimeo/dials (e.g. the one containing the config package), and
+// file paths are relative to the working directory.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/vimeo/dials/dialscli"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// the generated program has already reported the error
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("dials", flag.ContinueOnError)
+	pkg := fs.String("pkg", "", "import path of the package containing the config type")
+	typ := fs.String("type", "", "name of the config struct type")
+	defaults := fs.String("defaults", "", "name of a function in -pkg returning a *T holding the default configuration (optional)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: dials -pkg importpath -type name [-defaults func] command [flags]")
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), "\n", dialscli.Usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pkg == "" || *typ == "" {
+		fs.Usage()
+		return errors.New("-pkg and -type are required")
+	}
+	src, err := mainSource(*pkg, *typ, *defaults)
+	if err != nil {
+		return err
+	}
+
+	// the program must be inside the current module to import the config
+	// package, so it can't go in os.TempDir
+	dir, err := os.MkdirTemp(".", ".dialscli-")
+	if err != nil {
+		return fmt.Errorf("failed to create a directory for the program: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src, 0o644); err != nil {
+		return fmt.Errorf("failed to write the program: %w", err)
+	}
+
+	// the dialsgen tag excludes generated code (see the codegen package),
+	// which may not compile until it's regenerated
+	cmd := exec.Command("go", append([]string{"run", "-tags", "dialsgen", "./" + filepath.ToSlash(dir)}, fs.Args()...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+var mainTmpl = template.Must(template.New("main").Parse(`// Code generated by the dials command. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vimeo/dials/dialscli"
+	config {{.Package}}
+)
+
+func main() {
+	template := {{if .Defaults}}config.{{.Defaults}}(){{else}}&config.{{.Type}}{}{{end}}
+	if err := dialscli.Run(context.Background(), template, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// mainSource returns the source of the program running dialscli for the type
+// typ in the package pkg.
+func mainSource(pkg, typ, defaults string) ([]byte, error) {
+	if !token.IsIdentifier(typ) || !token.IsExported(typ) {
+		return nil, fmt.Errorf("-type %q isn't the name of an exported type", typ)
+	}
+	if defaults != "" && (!token.IsIdentifier(defaults) || !token.IsExported(defaults)) {
+		return nil, fmt.Errorf("-defaults %q isn't the name of an exported function", defaults)
+	}
+	b := bytes.Buffer{}
+	if err := mainTmpl.Execute(&b, struct {
+		Package, Type, Defaults string
+	}{Package: strconv.Quote(pkg), Type: typ, Defaults: defaults}); err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
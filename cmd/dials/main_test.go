@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMainSource(t *testing.T) {
+	src, err := mainSource("example.com/app/config", "Config", "")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `config "example.com/app/config"`)
+	assert.Contains(t, string(src), "template := &config.Config{}")
+
+	src, err = mainSource("example.com/app/config", "Config", "Defaults")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "template := config.Defaults()")
+
+	_, err = mainSource("example.com/app/config", "config", "")
+	assert.ErrorContains(t, err, "exported type")
+	_, err = mainSource("example.com/app/config", "Config", "New()")
+	assert.ErrorContains(t, err, "exported function")
+}
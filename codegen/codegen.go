@@ -0,0 +1,278 @@
+// Package codegen generates the code that replaces the reflective composition
+// of a config struct type's values (see dials.GeneratedFuncs), for programs
+// that need to minimize their startup time and the allocations made each time
+// a large configuration is recomposed. It's usually run with the dials
+// command's generate subcommand, from a go:generate directive in the config
+// type's package:
+//
+//	//go:generate go run github.com/vimeo/dials/cmd/dials -pkg example.com/myapp/config -type Config generate -o config_dials.go
+//
+// The generated file has a "!dialsgen" build constraint, and the dials
+// command builds its program with the dialsgen tag, so a generated file that
+// no longer compiles after the config type changes doesn't prevent its
+// regeneration. Flag registration (see sources/flag) still uses reflection.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// the packages the generated code imports itself
+const (
+	dialsPkg   = "github.com/vimeo/dials"
+	fmtPkg     = "fmt"
+	reflectPkg = "reflect"
+)
+
+// Generate returns the source of a file in the package of the config struct
+// type pointed to by template, which registers generated functions for the
+// type with dials.RegisterGenerated.
+func Generate(template interface{}) ([]byte, error) {
+	t := reflect.TypeOf(template)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("template must be a pointer to a struct, not %v", t)
+	}
+	t = t.Elem()
+	pkgName, _, _ := strings.Cut(t.String(), ".")
+	if t.Name() == "" || strings.Contains(t.Name(), "[") {
+		return nil, fmt.Errorf("cannot generate code for %s, which isn't a named, non-generic type", t)
+	}
+	g := &generator{
+		pkgPath: t.PkgPath(),
+		imports: map[string]string{dialsPkg: "dials", reflectPkg: "reflect"},
+		names:   map[string]string{"dials": dialsPkg, "fmt": fmtPkg, "reflect": reflectPkg},
+		funcs:   map[funcKey]string{},
+	}
+	overlay, deepCopy := g.funcName("dialsOverlay", t), g.funcName("dialsDeepCopy", t)
+	for len(g.queue) > 0 {
+		k := g.queue[0]
+		g.queue = g.queue[1:]
+		if k.prefix == "dialsOverlay" {
+			g.writeOverlay(k.t)
+		} else {
+			g.writeDeepCopy(k.t)
+		}
+	}
+
+	// fmt is only used to wrap the errors from nested struct fields
+	if g.usesFmt {
+		g.imports[fmtPkg] = "fmt"
+	}
+	out := bytes.Buffer{}
+	fmt.Fprintf(&out, "// Code generated by the dials command. DO NOT EDIT.\n\n//go:build !dialsgen\n\npackage %s\n\nimport (\n", pkgName)
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if name := g.imports[p]; name != path.Base(p) {
+			fmt.Fprintf(&out, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", p)
+		}
+	}
+	fmt.Fprintf(&out, ")\n\nfunc init() {\n\tdials.RegisterGenerated(dials.GeneratedFuncs[%s]{\n\t\tOverlay: %s,\n\t\tDeepCopy: %s,\n\t})\n}\n",
+		t.Name(), overlay, deepCopy)
+	out.Write(g.body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+type generator struct {
+	// pkgPath is the path of the package the code is generated in
+	pkgPath string
+	// imports maps the paths of the imported packages to their names, and
+	// names maps them back
+	imports, names map[string]string
+	// funcs contains the names of the functions that are generated, and
+	// queue the ones that haven't been written yet
+	funcs   map[funcKey]string
+	queue   []funcKey
+	body    bytes.Buffer
+	usesFmt bool
+}
+
+// typeName returns the name t is referred to by in the generated code,
+// importing its package if necessary, or false if it can't be referred to by
+// name.
+func (g *generator) typeName(t reflect.Type) (string, bool) {
+	if t.Name() == "" || strings.Contains(t.Name(), "[") {
+		// unnamed and generic types would have to be spelled out
+		return "", false
+	}
+	switch t.PkgPath() {
+	case "":
+		return t.Name(), true
+	case g.pkgPath:
+		return t.Name(), true
+	}
+	if !token.IsExported(t.Name()) {
+		return "", false
+	}
+	name, _, _ := strings.Cut(t.String(), ".")
+	if p, ok := g.names[name]; ok && p != t.PkgPath() {
+		return "", false
+	}
+	g.imports[t.PkgPath()] = name
+	g.names[name] = t.PkgPath()
+	return name + "." + t.Name(), true
+}
+
+// funcKey identifies a generated function by the prefix of its name
+// ("dialsOverlay" or "dialsDeepCopy") and the struct type it handles.
+type funcKey struct {
+	prefix string
+	t      reflect.Type
+}
+
+// funcName returns the name of the function with the given prefix generated
+// for the struct type t, queueing it for generation.
+func (g *generator) funcName(prefix string, t reflect.Type) string {
+	k := funcKey{prefix: prefix, t: t}
+	if name, ok := g.funcs[k]; ok {
+		return name
+	}
+	name := prefix + t.Name()
+	if t.PkgPath() != g.pkgPath {
+		name = prefix + "_" + strings.ReplaceAll(t.String(), ".", "_")
+	}
+	g.funcs[k] = name
+	g.queue = append(g.queue, k)
+	return name
+}
+
+// fieldKind classifies the struct fields of a config type by how the
+// generated code overlays them.
+type fieldKind int
+
+const (
+	// fallbackField fields are overlaid with the reflective implementation
+	fallbackField fieldKind = iota
+	// leafField fields are assigned the pointee of the pointerified field
+	leafField
+	// nestedField fields are structs whose fields are overlaid by their
+	// own generated functions
+	nestedField
+)
+
+// classify returns how the field sf is overlaid, and the name of its type for
+// leaf fields.
+func (g *generator) classify(sf reflect.StructField) (fieldKind, string) {
+	if _, ok := sf.Tag.Lookup(dials.SliceMergeTag); ok {
+		return fallbackField, ""
+	}
+	ft := sf.Type
+	switch ft.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return fallbackField, ""
+	case reflect.Struct:
+		if !ptrify.IsScalarStruct(ft) {
+			if _, ok := g.typeName(ft); !ok {
+				return fallbackField, ""
+			}
+			return nestedField, ""
+		}
+	}
+	if !isFlat(ft) {
+		return fallbackField, ""
+	}
+	name, ok := g.typeName(ft)
+	if !ok {
+		return fallbackField, ""
+	}
+	return leafField, name
+}
+
+// writeOverlay writes the function overlaying a pointerified value onto the
+// struct type t.
+func (g *generator) writeOverlay(t reflect.Type) {
+	name, _ := g.typeName(t)
+	fn := bytes.Buffer{}
+	usesValue := false
+	for i, j := 0, 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) || sf.Type.Kind() == reflect.Chan || sf.Type.Kind() == reflect.Func {
+			// these aren't in the pointerified type
+			continue
+		}
+		switch kind, typ := g.classify(sf); kind {
+		case leafField:
+			fmt.Fprintf(&fn, "\tif f := overlay.Field(%d); !f.IsNil() {\n\t\tbase.%s = *f.Interface().(*%s)\n\t}\n",
+				j, sf.Name, typ)
+		case nestedField:
+			g.usesFmt = true
+			fmt.Fprintf(&fn, "\tif f := overlay.Field(%d); !f.IsNil() {\n\t\tif err := %s(&base.%s, f.Elem(), r); err != nil {\n"+
+				"\t\t\treturn fmt.Errorf(\"failed to set field %%q (number %%d): %%s\", %q, %d, err)\n\t\t}\n\t}\n",
+				j, g.funcName("dialsOverlay", sf.Type), sf.Name, sf.Name, i)
+		default:
+			usesValue = true
+			fmt.Fprintf(&fn, "\tif err := r.OverlayField(v, overlay, %d); err != nil {\n\t\treturn err\n\t}\n", i)
+		}
+		j++
+	}
+	fmt.Fprintf(&g.body, "\nfunc %s(base *%s, overlay reflect.Value, r *dials.Reflective) error {\n", g.funcs[funcKey{"dialsOverlay", t}], name)
+	if usesValue {
+		g.body.WriteString("\tv := reflect.ValueOf(base).Elem()\n")
+	}
+	g.body.Write(fn.Bytes())
+	g.body.WriteString("\treturn nil\n}\n")
+}
+
+// writeDeepCopy writes the function replacing the memory a shallow copy of a
+// value of the struct type t shares with the original with copies.
+func (g *generator) writeDeepCopy(t reflect.Type) {
+	name, _ := g.typeName(t)
+	fn := bytes.Buffer{}
+	usesValue := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		// unexported fields aren't deep-copied
+		if !token.IsExported(sf.Name) || isFlat(sf.Type) {
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			if _, ok := g.typeName(sf.Type); ok {
+				fmt.Fprintf(&fn, "\t%s(&dst.%s, &src.%s, r)\n", g.funcName("dialsDeepCopy", sf.Type), sf.Name, sf.Name)
+				continue
+			}
+		}
+		usesValue = true
+		fmt.Fprintf(&fn, "\tr.CopyField(dv, sv, %d)\n", i)
+	}
+	fmt.Fprintf(&g.body, "\nfunc %s(dst, src *%s, r *dials.Reflective) {\n", g.funcs[funcKey{"dialsDeepCopy", t}], name)
+	if usesValue {
+		g.body.WriteString("\tdv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()\n")
+	}
+	g.body.Write(fn.Bytes())
+	g.body.WriteString("}\n")
+}
+
+// isFlat returns true if values of type t don't reference memory that dials
+// deep-copies, so assigning them makes a deep copy (like the dials package's
+// own isFlat).
+func isFlat(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return false
+	case reflect.Array:
+		return isFlat(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if token.IsExported(sf.Name) && !isFlat(sf.Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package codegen
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/codegen/internal/testconfig"
+)
+
+func TestGenerate(t *testing.T) {
+	// the generated code is checked in, and tested, by the testconfig
+	// package
+	src, err := Generate(&testconfig.Config{})
+	require.NoError(t, err)
+	want, err := os.ReadFile("internal/testconfig/testconfig_dials.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(src), "run go generate ./codegen/internal/testconfig")
+}
+
+type generic[T any] struct {
+	V T
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate(testconfig.Config{})
+	assert.ErrorContains(t, err, "must be a pointer to a struct")
+	_, err = Generate(&struct{ A int }{})
+	assert.ErrorContains(t, err, "isn't a named, non-generic type")
+	_, err = Generate(&generic[int]{})
+	assert.ErrorContains(t, err, "isn't a named, non-generic type")
+}
@@ -0,0 +1,47 @@
+// Package testconfig contains a config type with each kind of field the
+// generated code handles, and the code generated for it.
+package testconfig
+
+import (
+	"net/url"
+	"time"
+)
+
+//go:generate go run github.com/vimeo/dials/cmd/dials -pkg github.com/vimeo/dials/codegen/internal/testconfig -type Config generate -o testconfig_dials.go
+
+// Config is the config type the code is generated for.
+type Config struct {
+	Name     string            `dials:"name"`
+	Port     int               `dials:"port"`
+	Ratio    float64           `dials:"ratio"`
+	Level    Level             `dials:"level"`
+	Timeout  time.Duration     `dials:"timeout"`
+	Started  time.Time         `dials:"started"`
+	Server   Server            `dials:"server"`
+	Replaced Server            `dials:"replaced" dialsmerge:"replace"`
+	Tags     []string          `dials:"tags" dialsmerge:"append"`
+	Labels   map[string]string `dials:"labels"`
+	Backup   *Server           `dials:"backup"`
+	Endpoint url.URL           `dials:"endpoint"`
+	Anon     struct{ On bool } `dials:"anon"`
+	Ignored  string            `dials:"-"`
+	Hook     func()
+	internal *int
+}
+
+// Level is a named scalar type.
+type Level string
+
+// Server is a nested struct.
+type Server struct {
+	Host  string   `dials:"host"`
+	Port  int      `dials:"port"`
+	TLS   TLS      `dials:"tls"`
+	Hosts []string `dials:"hosts"`
+}
+
+// TLS is a nested struct with only flat fields.
+type TLS struct {
+	Cert     string `dials:"cert"`
+	Insecure bool   `dials:"insecure"`
+}
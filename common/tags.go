@@ -3,3 +3,34 @@ package common
 
 // DialsTagName is the name of the dials tag.
 const DialsTagName = "dials"
+
+// TimeLayoutTagName is the name of the tag listing the layouts accepted for
+// a time.Time field, separated by "|" (layouts may contain commas). Each is
+// either a layout string for time.Parse or the name of one of the time
+// package's layout constants (e.g. "RFC1123"). The first layout is used for
+// formatting. Without the tag, fields use time.RFC3339Nano (which also
+// accepts RFC3339 values without fractional seconds).
+const TimeLayoutTagName = "dialstimelayout"
+
+// UnitTagName is the name of the tag marking an integer field as a quantity
+// with units, so string values with unit suffixes are accepted for it. The
+// only supported value is "bytes", for sizes such as "512MiB" (see the
+// bytesize package). time.Duration fields accept strings like "30s"
+// without the tag.
+const UnitTagName = "dialsunit"
+
+// EmbedTagName is the name of the tag controlling how the fields of an
+// embedded struct are named. With "inline", they're promoted into the
+// enclosing struct's namespace for every source (flags, environment variables
+// and config file keys), as Go promotes them; with "nested", the embedded
+// struct is treated like a field named after its type (or its `dials` tag).
+// Without the tag, each source follows its own convention.
+const EmbedTagName = "dialsembed"
+
+// AliasTagName is the name of the tag listing (comma-separated) alternate
+// keys accepted for a field in config files, such as its old names during a
+// migration. Like the decoder-specific tags (e.g. `yaml`), each alias names
+// the field within its enclosing struct. If the field's own key is absent
+// and an alias is present, the alias's value is used and a
+// dials.WarningDeprecated warning is reported.
+const AliasTagName = "dialsalias"
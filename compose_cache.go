@@ -0,0 +1,101 @@
+package dials
+
+import (
+	"reflect"
+)
+
+// composeCache retains the composition of the sources below the lowest one
+// whose value last changed, so when a watching source reports a new value,
+// only it and the sources above it are overlaid again, reusing the
+// composition of the (possibly expensive) sources below it. Only a single
+// partial composition is retained, so each restack makes at most one deep
+// copy more than composing from scratch (to refresh it). It's owned by the
+// monitor goroutine.
+type composeCache struct {
+	template   interface{}
+	sliceMerge SliceMerge
+	// prefix, if valid, is a pointer to the composition of the template
+	// with the values of the first prefixLen sources, which haven't
+	// changed since it was composed. It's never shared with the
+	// configurations that are returned.
+	prefix    reflect.Value
+	prefixLen int
+	// target is the number of sources prefix should cover: those below
+	// the lowest source that changed before the last call to compose (or
+	// -1 until compose is called).
+	target int
+	// changed is the index of the lowest source whose value has changed
+	// since the last call to compose, or -1 if none has.
+	changed int
+}
+
+func newComposeCache(sliceMerge SliceMerge) *composeCache {
+	return &composeCache{sliceMerge: sliceMerge, target: -1, changed: -1}
+}
+
+// apply sets the values of sourceValues from updates (like applyUpdates),
+// discarding the partial composition if it includes the updated sources.
+func (c *composeCache) apply(sourceValues []sourceValue, updates []*valueUpdate) {
+	applyUpdates(sourceValues, updates)
+	if c == nil {
+		return
+	}
+	for _, u := range updates {
+		for i, sv := range sourceValues {
+			if u.source == sv.source {
+				c.invalidate(i)
+				break
+			}
+		}
+	}
+}
+
+// invalidate records that the value of the i'th source has changed,
+// discarding the partial composition if it includes that value.
+func (c *composeCache) invalidate(i int) {
+	if c.changed < 0 || i < c.changed {
+		c.changed = i
+	}
+	if i < c.prefixLen {
+		c.prefix, c.prefixLen = reflect.Value{}, 0
+	}
+}
+
+// compose is equivalent to compose(t, sources, sliceMerge), but reuses the
+// composition of the sources below those whose values have changed since the
+// last call.
+func (c *composeCache) compose(t interface{}, sources []sourceValue) (interface{}, error) {
+	if c.template != t || c.prefixLen > len(sources) {
+		c.template = t
+		c.prefix, c.prefixLen = reflect.Value{}, 0
+	}
+	switch {
+	case c.changed >= 0:
+		c.target = c.changed
+	case c.target < 0:
+		// until a source changes, assume it'll be the top one
+		c.target = len(sources) - 1
+	}
+	if c.target > len(sources) {
+		c.target = len(sources)
+	}
+	c.changed = -1
+
+	start := 0
+	var value reflect.Value
+	if c.prefix.IsValid() && c.prefixLen <= c.target {
+		start = c.prefixLen
+		value = deepCopyValue(c.prefix).Elem()
+	} else {
+		value = realDeepCopy(t).Elem()
+	}
+	for i := start; i < len(sources); i++ {
+		if i == c.target && i > 0 && (!c.prefix.IsValid() || c.prefixLen != i) {
+			c.prefix, c.prefixLen = deepCopyValue(value.Addr()), i
+		}
+		if overlayErr := overlaySource(value, sources[i], c.sliceMerge); overlayErr != nil {
+			return nil, overlayErr
+		}
+	}
+	return value.Addr().Interface(), nil
+}
@@ -0,0 +1,136 @@
+package dials
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeCache(t *testing.T) {
+	t.Parallel()
+	type layered struct {
+		A, B  string
+		Hosts []string `dialsmerge:"append"`
+	}
+	type ptrifiedLayered struct {
+		A, B  *string
+		Hosts []string `dialsmerge:"append"`
+	}
+	layer := func(a string, hosts ...string) reflect.Value {
+		return reflect.ValueOf(&ptrifiedLayered{A: &a, Hosts: hosts})
+	}
+
+	tmpl := &layered{B: "b"}
+	sourceValues := []sourceValue{
+		{source: &fakeSource{}, value: layer("1", "h1")},
+		{source: &fakeSource{}, value: layer("2", "h2")},
+		{source: &fakeSource{}, value: layer("3")},
+	}
+	c := newComposeCache(SliceMergeReplace)
+	check := func() {
+		t.Helper()
+		expected, err := compose(tmpl, sourceValues, SliceMergeReplace)
+		require.NoError(t, err)
+		cfg, err := c.compose(tmpl, sourceValues)
+		require.NoError(t, err)
+		assert.Equal(t, expected, cfg)
+		// the returned configuration doesn't share the retained prefixes
+		cfg.(*layered).Hosts[0] = "modified"
+	}
+
+	// until a source changes, the top one is assumed to be the one that
+	// will
+	check()
+	assert.Equal(t, 2, c.prefixLen)
+	assert.Equal(t, &layered{A: "2", B: "b", Hosts: []string{"h1", "h2"}}, c.prefix.Interface())
+
+	// changing a lower source discards the prefix, and retains the
+	// composition below it instead
+	c.apply(sourceValues, []*valueUpdate{{source: sourceValues[1].source, value: layer("two", "h2", "h3")}})
+	assert.False(t, c.prefix.IsValid())
+	check()
+	assert.Equal(t, 1, c.prefixLen)
+	assert.Equal(t, &layered{A: "1", B: "b", Hosts: []string{"h1"}}, c.prefix.Interface())
+
+	// which is reused when higher sources change
+	prefix := c.prefix
+	c.apply(sourceValues, []*valueUpdate{{source: sourceValues[2].source, value: layer("three")}})
+	check()
+	assert.Equal(t, 2, c.prefixLen)
+	assert.Equal(t, &layered{A: "two", B: "b", Hosts: []string{"h1", "h2", "h3"}}, c.prefix.Interface())
+	c.apply(sourceValues, []*valueUpdate{{source: sourceValues[2].source, value: layer("3")}})
+	prefix = c.prefix
+	check()
+	assert.Equal(t, prefix, c.prefix)
+
+	// changing the bottom source retains nothing
+	c.apply(sourceValues, []*valueUpdate{{source: sourceValues[0].source, value: layer("one")}})
+	check()
+	assert.False(t, c.prefix.IsValid())
+
+	// a different template discards the prefix
+	c.apply(sourceValues, []*valueUpdate{{source: sourceValues[2].source, value: layer("three")}})
+	check()
+	tmpl = &layered{B: "bee"}
+	check()
+	assert.Equal(t, "bee", c.prefix.Interface().(*layered).B)
+}
+
+func BenchmarkComposeCache(b *testing.B) {
+	type inner struct {
+		Name  string
+		Ports []int
+		Tags  map[string]string
+	}
+	type config struct {
+		Services []inner
+		Limits   map[string]int
+		Top      string
+	}
+	type ptrifiedConfig struct {
+		Services []inner
+		Limits   map[string]int
+		Top      *string
+	}
+	services := make([]inner, 50)
+	for i := range services {
+		services[i] = inner{Name: "svc", Ports: []int{80, 443}, Tags: map[string]string{"a": "b", "c": "d"}}
+	}
+	limits := map[string]int{}
+	for i := 0; i < 50; i++ {
+		limits[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	tmpl := &config{}
+	sourceValues := make([]sourceValue, 8)
+	for i := range sourceValues {
+		sourceValues[i] = sourceValue{
+			source: &fakeSource{},
+			value:  reflect.ValueOf(&ptrifiedConfig{Services: services, Limits: limits}),
+		}
+	}
+	top := sourceValues[len(sourceValues)-1].source
+	update := func(i int) []*valueUpdate {
+		s := string(rune('a' + i%26))
+		return []*valueUpdate{{source: top, value: reflect.ValueOf(&ptrifiedConfig{Top: &s})}}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			applyUpdates(sourceValues, update(i))
+			if _, err := compose(tmpl, sourceValues, SliceMergeReplace); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		c := newComposeCache(SliceMergeReplace)
+		for i := 0; i < b.N; i++ {
+			c.apply(sourceValues, update(i))
+			if _, err := c.compose(tmpl, sourceValues); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package dials
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ComposeValues composes values of the pointerified type described by t
+// (such as those returned by Decode) into one, like Config composes the
+// values of its sources: each field holds the value from the last of vals
+// that set it. Slices are replaced, unless tagged to be appended with
+// SliceMergeTag, and the `dialsmerge` tags on maps and nested structs are
+// honored. This lets a Source that reads several parts (e.g. a config file
+// and the files it includes) return a single value. The result may share
+// memory with vals.
+func ComposeValues(t *Type, vals ...reflect.Value) (reflect.Value, error) {
+	out := reflect.New(t.t).Elem()
+	for i, v := range vals {
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Ptr || !v.IsValid() {
+			continue
+		}
+		if v.Type() != t.t {
+			return reflect.Value{}, fmt.Errorf("value %d has type %s, not %s", i, v.Type(), t.t)
+		}
+		if err := composeStruct(out, v); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return out, nil
+}
+
+// composeStruct overlays the set fields of the pointerified struct overlay
+// onto base (of the same type). Values already in base are never modified:
+// structs are copied before their fields are set, and merged slices and
+// maps are built afresh.
+func composeStruct(base, overlay reflect.Value) error {
+	t := base.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		bf, of := base.Field(i), overlay.Field(i)
+		switch sf.Type.Kind() {
+		case reflect.Slice:
+			if of.IsNil() {
+				continue
+			}
+			mode, err := fieldSliceMerge(sf, SliceMergeReplace)
+			if err != nil {
+				return err
+			}
+			if mode == SliceMergeAppend && !bf.IsNil() {
+				bf.Set(appendSlices(bf, of))
+				continue
+			}
+			bf.Set(of)
+		case reflect.Map:
+			if of.IsNil() {
+				continue
+			}
+			merge, err := fieldMapMerge(sf)
+			if err != nil {
+				return err
+			}
+			if !merge || bf.IsNil() {
+				bf.Set(of)
+				continue
+			}
+			merged, err := composeMaps(bf, of)
+			if err != nil {
+				return fmt.Errorf("failed to merge field %q: %w", sf.Name, err)
+			}
+			bf.Set(merged)
+		case reflect.Ptr:
+			if of.IsNil() {
+				continue
+			}
+			if bf.IsNil() || !isNestedStruct(sf.Type) {
+				bf.Set(of)
+				continue
+			}
+			replace, err := fieldStructReplace(sf)
+			if err != nil {
+				return err
+			}
+			if replace {
+				if anySet(of) {
+					bf.Set(of)
+				}
+				continue
+			}
+			merged := reflect.New(sf.Type.Elem())
+			merged.Elem().Set(bf.Elem())
+			if err := composeStruct(merged.Elem(), of.Elem()); err != nil {
+				return fmt.Errorf("failed to merge field %q: %w", sf.Name, err)
+			}
+			bf.Set(merged)
+		case reflect.Interface:
+			if !of.IsNil() {
+				bf.Set(of)
+			}
+		default:
+			if !of.IsZero() {
+				bf.Set(of)
+			}
+		}
+	}
+	return nil
+}
+
+// composeMaps returns a new map with the entries of base and overlay, merging
+// the pointerified struct values of entries with the same key.
+func composeMaps(base, overlay reflect.Value) (reflect.Value, error) {
+	out := reflect.MakeMapWithSize(base.Type(), base.Len()+overlay.Len())
+	iter := base.MapRange()
+	for iter.Next() {
+		out.SetMapIndex(iter.Key(), iter.Value())
+	}
+	elem := base.Type().Elem()
+	iter = overlay.MapRange()
+	for iter.Next() {
+		k, v := iter.Key(), iter.Value()
+		bv := out.MapIndex(k)
+		if elem.Kind() != reflect.Ptr || !isNestedStruct(elem) ||
+			!bv.IsValid() || bv.IsNil() || v.IsNil() {
+			out.SetMapIndex(k, v)
+			continue
+		}
+		merged := reflect.New(elem.Elem())
+		merged.Elem().Set(bv.Elem())
+		if err := composeStruct(merged.Elem(), v.Elem()); err != nil {
+			return reflect.Value{}, fmt.Errorf("entry %v: %w", k, err)
+		}
+		out.SetMapIndex(k, merged)
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package dials
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/ptrify"
+)
+
+func TestComposeValues(t *testing.T) {
+	t.Parallel()
+	type pool struct {
+		Size    int
+		Timeout int
+	}
+	type testConfig struct {
+		Name     string
+		Hosts    []string
+		Plugins  []string `dialsmerge:"append"`
+		Labels   map[string]string
+		Limits   map[string]int `dialsmerge:"merge"`
+		Pool     pool
+		Fallback pool `dialsmerge:"replace"`
+	}
+	typ := NewType(ptrify.Pointerify(reflect.TypeOf(testConfig{}), reflect.Value{}))
+	ptrified := func(cfg testConfig, set ...string) reflect.Value {
+		t.Helper()
+		v, err := ptrify.PointerifyValue(typ.Type(), reflect.ValueOf(cfg))
+		require.NoError(t, err)
+		// unset the fields not listed
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			keep := false
+			for _, s := range set {
+				keep = keep || s == name
+			}
+			if !keep {
+				v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+			}
+		}
+		return v
+	}
+	setPoolSize := func(v reflect.Value, field string, size int) {
+		p := reflect.New(typ.Type().Field(5).Type.Elem())
+		p.Elem().Field(0).Set(reflect.ValueOf(&size))
+		v.FieldByName(field).Set(p)
+	}
+
+	first := ptrified(testConfig{
+		Name:     "first",
+		Hosts:    []string{"a"},
+		Plugins:  []string{"p1"},
+		Labels:   map[string]string{"env": "dev"},
+		Limits:   map[string]int{"cpu": 1, "mem": 2},
+		Pool:     pool{Size: 1, Timeout: 10},
+		Fallback: pool{Size: 2, Timeout: 20},
+	}, "Name", "Hosts", "Plugins", "Labels", "Limits", "Pool", "Fallback")
+	second := ptrified(testConfig{
+		Hosts:   []string{"b"},
+		Plugins: []string{"p2"},
+		Labels:  map[string]string{"team": "core"},
+		Limits:  map[string]int{"mem": 4},
+	}, "Hosts", "Plugins", "Labels", "Limits")
+	setPoolSize(second, "Pool", 3)
+	setPoolSize(second, "Fallback", 4)
+
+	composed, err := ComposeValues(typ, first, second)
+	require.NoError(t, err)
+
+	out := reflect.New(reflect.TypeOf(testConfig{}))
+	require.NoError(t, newOverlayer().overlayStruct(out.Elem(), composed))
+	assert.Equal(t, testConfig{
+		Name:     "first",
+		Hosts:    []string{"b"},
+		Plugins:  []string{"p1", "p2"},
+		Labels:   map[string]string{"team": "core"},
+		Limits:   map[string]int{"cpu": 1, "mem": 4},
+		Pool:     pool{Size: 3, Timeout: 10},
+		Fallback: pool{Size: 4},
+	}, out.Elem().Interface())
+
+	// the inputs aren't modified
+	assert.Equal(t, 10, *first.FieldByName("Pool").Elem().Field(1).Interface().(*int))
+	assert.Len(t, first.FieldByName("Limits").Interface(), 2)
+
+	_, err = ComposeValues(typ, reflect.ValueOf(testConfig{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,245 @@
+// Package conditional lets a config file carry sections that only apply when
+// a condition holds, e.g. on particular hosts or in particular regions.
+// Decoder composes each section whose condition is true over the rest of the
+// file, in order:
+//
+//	region: us-east-1
+//	timeout: 5s
+//	conditionals:
+//	  - when: hostname =~ "^canary-"
+//	    set:
+//	      log_level: debug
+//	  - when: region in ["eu-west-1", "eu-central-1"] && timeout < 10
+//	    set:
+//	      timeout: 10s
+//
+// Conditions are written in a small expression language (see Expr), and may
+// refer to variables supplied by the program (see Decoder.Vars and HostVars)
+// and to the fields of the file composed so far.
+package conditional
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+)
+
+// DefaultSection is the key holding the conditional sections if
+// Decoder.Section is empty.
+const DefaultSection = "conditionals"
+
+// Decoder wraps another decoder, composing the conditional sections whose
+// conditions hold over the rest of the decoded file. Each section is a "when"
+// condition and a "set" struct of the configuration's type. The configuration
+// struct must not have a field named like the Section key.
+//
+// Identifiers in conditions name an entry in Vars or, failing that, a field
+// of the configuration, as a dot-separated path of the names fields are
+// decoded with (e.g. db.host). Fields hold the values composed from the top
+// level of the file and from the preceding sections that applied; values from
+// other sources aren't visible, as they're only composed after the file is
+// decoded. Unset fields have their type's zero value, durations are in
+// seconds, and other fields must be strings, booleans or numbers. An
+// identifier naming neither a variable nor a field is an error, so typos
+// don't silently disable a section.
+type Decoder struct {
+	// Inner decodes the file.
+	Inner dials.Decoder
+	// Vars are the variables available to conditions. Values must be
+	// strings, booleans or numbers.
+	Vars map[string]interface{}
+	// Section is the key holding the list of conditional sections
+	// (DefaultSection if empty).
+	Section string
+	// OnEvaluate, if non-nil, is called with each condition evaluated and
+	// whether it held, e.g. to log which sections applied.
+	OnEvaluate func(cond string, held bool)
+}
+
+var _ dials.ContextDecoder = (*Decoder)(nil)
+
+// NewDecoder returns a Decoder composing the conditional sections of the files
+// inner decodes, with vars available to the conditions.
+func NewDecoder(inner dials.Decoder, vars map[string]interface{}) *Decoder {
+	return &Decoder{Inner: inner, Vars: vars}
+}
+
+// HostVars returns variables describing the host: hostname, as reported by
+// os.Hostname. Callers can add their own (e.g. region) before passing them to
+// NewDecoder.
+func HostVars() (map[string]interface{}, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	return map[string]interface{}{"hostname": hostname}, nil
+}
+
+// Decode decodes the file read from r with Inner, composing the conditional
+// sections whose conditions hold over the rest of it.
+func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but passes ctx to the inner decoder (see
+// dials.ContextDecoder).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("error reading raw bytes: %w", err)
+	}
+	val, err := dials.Decode(ctx, d.Inner, bytes.NewReader(data), t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	section := d.Section
+	if section == "" {
+		section = DefaultSection
+	}
+	// decode just the conditional sections, each holding a struct of the
+	// configuration's type
+	cond := reflect.StructOf([]reflect.StructField{
+		{Name: "When", Type: reflect.TypeOf((*string)(nil)), Tag: tag("when")},
+		{Name: "Set", Type: reflect.PtrTo(t.Type()), Tag: tag("set")},
+	})
+	sections := reflect.StructOf([]reflect.StructField{{
+		Name: "Conditionals",
+		Type: reflect.SliceOf(cond),
+		Tag:  tag(section),
+	}})
+	sv, err := dials.Decode(ctx, d.Inner, bytes.NewReader(data), dials.NewType(sections))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode the conditional sections: %w", err)
+	}
+
+	conds := sv.Field(0)
+	for i := 0; i < conds.Len(); i++ {
+		when, set := conds.Index(i).Field(0), conds.Index(i).Field(1)
+		if when.IsNil() {
+			return reflect.Value{}, fmt.Errorf("%s[%d] has no condition", section, i)
+		}
+		expr, err := Parse(when.Elem().String())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s[%d]: %w", section, i, err)
+		}
+		held, err := expr.Eval(d.lookup(val))
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s[%d]: failed to evaluate %q: %w", section, i, expr, err)
+		}
+		if d.OnEvaluate != nil {
+			d.OnEvaluate(expr.String(), held)
+		}
+		if !held || set.IsNil() {
+			continue
+		}
+		if val, err = dials.ComposeValues(t, val, set.Elem()); err != nil {
+			return reflect.Value{}, fmt.Errorf("%s[%d]: %w", section, i, err)
+		}
+	}
+	return val, nil
+}
+
+func tag(name string) reflect.StructTag {
+	return reflect.StructTag(fmt.Sprintf("%s:%q", common.DialsTagName, name))
+}
+
+// lookup returns a function resolving identifiers against d.Vars and the
+// fields of val.
+func (d *Decoder) lookup(val reflect.Value) func(string) (interface{}, error) {
+	return func(name string) (interface{}, error) {
+		if v, ok := d.Vars[name]; ok {
+			s, err := scalar(reflect.ValueOf(v))
+			if err != nil {
+				return nil, fmt.Errorf("variable %q: %w", name, err)
+			}
+			return s, nil
+		}
+		v := val
+		for _, part := range strings.Split(name, ".") {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					v = reflect.Zero(v.Type().Elem())
+					continue
+				}
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("unknown variable or field %q", name)
+			}
+			f, ok := fieldByName(v, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown variable or field %q", name)
+			}
+			v = f
+		}
+		s, err := scalar(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+		return s, nil
+	}
+}
+
+// fieldByName returns the field of the struct v decoded with name: the one
+// tagged with that name, or, for untagged fields, with a Go name matching it
+// case-insensitively.
+func fieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if tagName, ok := sf.Tag.Lookup(common.DialsTagName); ok {
+			if tagName == name {
+				return v.Field(i), true
+			}
+			continue
+		}
+		if strings.EqualFold(sf.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// scalar converts v to a value of the expression language.
+func scalar(v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Interface {
+				return nil, fmt.Errorf("nil value")
+			}
+			v = reflect.Zero(v.Type().Elem())
+			continue
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil value")
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).Seconds(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s; only strings, booleans and numbers can be used in conditions", v.Type())
+	}
+}
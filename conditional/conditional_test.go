@@ -0,0 +1,232 @@
+package conditional
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+	vars := map[string]interface{}{
+		"hostname": "canary-3.example.com",
+		"region":   "eu-west-1",
+		"replicas": 3.0,
+		"debug":    true,
+	}
+	lookup := func(name string) (interface{}, error) {
+		if v, ok := vars[name]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown variable or field %q", name)
+	}
+	for src, want := range map[string]bool{
+		`region == "eu-west-1"`:                           true,
+		`region != 'eu-west-1'`:                           false,
+		`hostname =~ "^canary-[0-9]+\\."`:                 true,
+		`region in ["us-east-1", "eu-west-1"]`:            true,
+		`replicas in [1, 2]`:                              false,
+		`replicas >= 3 && replicas < 3.5`:                 true,
+		`replicas > -1`:                                   true,
+		`"a" < "b"`:                                       true,
+		`debug`:                                           true,
+		`!debug || region == "x"`:                         false,
+		`!(debug && region == "x")`:                       true,
+		`debug == true && false == false`:                 true,
+		`region == "us-east-1" || replicas <= 3 && debug`: true,
+		// the right operand isn't evaluated once the result is known
+		`!debug && missing == 1`: false,
+		`debug || missing == 1`:  true,
+	} {
+		expr, err := Parse(src)
+		require.NoError(t, err, src)
+		got, err := expr.Eval(lookup)
+		require.NoError(t, err, src)
+		assert.Equal(t, want, got, src)
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	t.Parallel()
+	lookup := func(name string) (interface{}, error) {
+		switch name {
+		case "region":
+			return "eu-west-1", nil
+		case "replicas":
+			return 3.0, nil
+		}
+		return nil, fmt.Errorf("unknown variable or field %q", name)
+	}
+	for src, wantErr := range map[string]string{
+		`region ==`:                 `invalid condition "region ==" at offset 9: unexpected "end of condition"`,
+		`region == "eu`:             `invalid condition "region == \"eu" at offset 10: unterminated string`,
+		`(region == "x"`:            `expected ")", found "end of condition"`,
+		`region = "x"`:              `unexpected character '='`,
+		`region in "x"`:             `expected "[", found "\"x\""`,
+		`region in [replicas]`:      `lists may only contain literals, found "replicas"`,
+		`region =~ replicas`:        `the right operand of =~ must be a string literal`,
+		`region =~ "("`:             `invalid regular expression`,
+		`region "x"`:                `unexpected "\"x\""`,
+		`region == 1`:               `cannot compare eu-west-1 (string) == 1 (float64)`,
+		`replicas =~ "3"`:           `left operand of =~ is 3 (float64), not a string`,
+		`region && true`:            `operand of && is eu-west-1 (string), not a boolean`,
+		`region`:                    `condition "region" produced eu-west-1 (string), not a boolean`,
+		`true < false`:              `cannot compare true (bool) < false (bool)`,
+		`regoin == "eu-west-1"`:     `unknown variable or field "regoin"`,
+		`replicas > 1 || !replicas`: ``,
+	} {
+		expr, err := Parse(src)
+		if err == nil {
+			_, err = expr.Eval(lookup)
+		}
+		if wantErr == "" {
+			assert.NoError(t, err, src)
+			continue
+		}
+		assert.ErrorContains(t, err, wantErr, src)
+	}
+}
+
+type config struct {
+	Region   string        `dials:"region"`
+	Timeout  time.Duration `dials:"timeout"`
+	LogLevel string        `dials:"log_level"`
+	DB       struct {
+		Host     string `dials:"host"`
+		Replicas int    `dials:"replicas"`
+	} `dials:"db"`
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+	for name, tbl := range map[string]struct {
+		data string
+		dec  dials.Decoder
+	}{
+		"yaml": {dec: &yaml.Decoder{}, data: `
+region: us-east-1
+timeout: 5s
+db: {host: localhost}
+conditionals:
+  - when: hostname =~ "^canary-"
+    set:
+      log_level: debug
+  - when: region in ["eu-west-1", "eu-central-1"] && timeout < 10
+    set:
+      timeout: 10s
+      db: {replicas: 2}
+  - when: db.replicas > 1
+    set:
+      db: {host: db.eu}
+`},
+		"json": {dec: &json.Decoder{}, data: `{
+			"region": "us-east-1", "timeout": "5s", "db": {"host": "localhost"},
+			"conditionals": [
+				{"when": "hostname =~ \"^canary-\"", "set": {"log_level": "debug"}},
+				{"when": "region in [\"eu-west-1\", \"eu-central-1\"] && timeout < 10", "set": {"timeout": "10s", "db": {"replicas": 2}}},
+				{"when": "db.replicas > 1", "set": {"db": {"host": "db.eu"}}}
+			]
+		}`},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			for _, c := range []struct {
+				vars     map[string]interface{}
+				expected func(*config)
+			}{
+				{vars: map[string]interface{}{"hostname": "web-1"}, expected: func(*config) {}},
+				{vars: map[string]interface{}{"hostname": "canary-1"}, expected: func(c *config) { c.LogLevel = "debug" }},
+				// a variable takes precedence over a field of the same name, and
+				// later conditions see the fields set by earlier sections
+				{vars: map[string]interface{}{"hostname": "web-1", "region": "eu-west-1"}, expected: func(c *config) {
+					c.Timeout, c.DB.Replicas, c.DB.Host = 10*time.Second, 2, "db.eu"
+				}},
+			} {
+				want := &config{Region: "us-east-1", Timeout: 5 * time.Second}
+				want.DB.Host = "localhost"
+				c.expected(want)
+
+				var evaluated []string
+				dec := NewDecoder(tbl.dec, c.vars)
+				dec.OnEvaluate = func(cond string, held bool) { evaluated = append(evaluated, fmt.Sprint(cond, " ", held)) }
+				d, err := dials.Config(ctx, &config{}, &static.StringSource{Data: tbl.data, Decoder: dec})
+				require.NoError(t, err, c.vars)
+				assert.Equal(t, want, d.View(), c.vars)
+				assert.Len(t, evaluated, 3)
+			}
+		})
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for data, wantErr := range map[string]string{
+		"conditionals: [{set: {region: x}}]":                        "conditionals[0] has no condition",
+		"conditionals: [{when: 'region ==', set: {region: x}}]":     `conditionals[0]: invalid condition "region =="`,
+		"conditionals: [{when: 'zone == \"a\"', set: {region: x}}]": `conditionals[0]: failed to evaluate "zone == \"a\"": unknown variable or field "zone"`,
+		"conditionals: [{when: 'db == \"a\"', set: {region: x}}]":   `field "db": unsupported type`,
+	} {
+		_, err := dials.Config(ctx, &config{}, &static.StringSource{Data: data, Decoder: NewDecoder(&yaml.Decoder{}, nil)})
+		assert.ErrorContains(t, err, wantErr, data)
+	}
+}
+
+func TestDecoderSection(t *testing.T) {
+	t.Parallel()
+	dec := NewDecoder(&yaml.Decoder{}, map[string]interface{}{"env": "prod"})
+	dec.Section = "overrides"
+	d, err := dials.Config(context.Background(), &config{}, &static.StringSource{
+		Data:    "region: x\noverrides: [{when: env == \"prod\" && region == \"x\", set: {log_level: warn}}]",
+		Decoder: dec,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "warn", d.View().LogLevel)
+}
+
+func TestHostVars(t *testing.T) {
+	t.Parallel()
+	vars, err := HostVars()
+	require.NoError(t, err)
+	assert.NotEmpty(t, vars["hostname"])
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		`region == "eu-west-1" && !debug`,
+		`hostname =~ "^canary-[0-9]+" || replicas >= 3`,
+		`(region in ['a', "b"]) != false`,
+		`db.replicas < -1.5`,
+	} {
+		f.Add(seed)
+	}
+	lookup := func(name string) (interface{}, error) {
+		switch name {
+		case "debug":
+			return true, nil
+		case "replicas":
+			return 3.0, nil
+		}
+		return name, nil
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		expr, err := Parse(src)
+		if err != nil {
+			return
+		}
+		if expr.String() != src {
+			t.Fatalf("parsed %q, but String returned %q", src, expr.String())
+		}
+		expr.Eval(lookup)
+	})
+}
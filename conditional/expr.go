@@ -0,0 +1,501 @@
+package conditional
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed condition.
+//
+// The expression language is deliberately small, so conditions are easy to
+// audit and can't have side effects or loop: it has string (single- or
+// double-quoted), number and boolean (true and false) literals, identifiers
+// naming variables or fields (see Decoder), parentheses, and these operators,
+// from lowest to highest precedence:
+//
+//	||                         logical or
+//	&&                         logical and
+//	!                          logical not
+//	== != < <= > >= =~ in      comparisons
+//
+// =~ matches a string against a regular expression (RE2 syntax), and in tests
+// whether a value is in a list of literals, e.g. region in ["us-east-1",
+// "us-west-2"]. Operands of comparisons must have the same type, and the
+// operands of logical operators must be booleans; anything else is an error
+// rather than being coerced.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a condition.
+func Parse(src string) (*Expr, error) {
+	p := parser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression, which must produce a boolean, with lookup
+// resolving identifiers to strings, float64s or bools.
+func (e *Expr) Eval(lookup func(name string) (interface{}, error)) (bool, error) {
+	v, err := e.root.eval(lookup)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition %q produced %v (%T), not a boolean", e.src, v, v)
+	}
+	return b, nil
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokString
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	// val is the value of string and number literals
+	val interface{}
+	pos int
+}
+
+type parser struct {
+	src  string
+	toks []token
+	next int
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid condition %q at offset %d: %s", p.src, tok.pos, fmt.Sprintf(format, args...))
+}
+
+// twoCharOps are the operators of two characters, which are lexed before
+// their one-character prefixes.
+var twoCharOps = []string{"||", "&&", "==", "!=", "<=", ">=", "=~"}
+
+func (p *parser) lex() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			var sb strings.Builder
+			for ; end < len(s) && s[end] != c; end++ {
+				if s[end] == '\\' && end+1 < len(s) {
+					end++
+				}
+				sb.WriteByte(s[end])
+			}
+			if end >= len(s) {
+				return p.errorf(token{pos: i}, "unterminated string")
+			}
+			p.toks = append(p.toks, token{kind: tokString, text: s[i : end+1], val: sb.String(), pos: i})
+			i = end + 1
+		case c >= '0' && c <= '9' || (c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9'):
+			end := i + 1
+			for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+				end++
+			}
+			f, err := strconv.ParseFloat(s[i:end], 64)
+			if err != nil {
+				return p.errorf(token{pos: i}, "invalid number %q", s[i:end])
+			}
+			p.toks = append(p.toks, token{kind: tokNumber, text: s[i:end], val: f, pos: i})
+			i = end
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := i + 1
+			for end < len(s) && isIdentByte(s[end]) {
+				end++
+			}
+			p.toks = append(p.toks, token{kind: tokIdent, text: s[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range twoCharOps {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" && strings.ContainsRune("!<>()[],", rune(c)) {
+				op = string(c)
+			}
+			if op == "" {
+				return p.errorf(token{pos: i}, "unexpected character %q", c)
+			}
+			p.toks = append(p.toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.toks = append(p.toks, token{kind: tokEOF, text: "end of condition", pos: len(s)})
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.next]
+}
+
+func (p *parser) take() token {
+	tok := p.toks[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) isOp(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == text
+}
+
+func (p *parser) expectOp(text string) error {
+	if !p.isOp(text) {
+		return p.errorf(p.peek(), "expected %q, found %q", text, p.peek().text)
+	}
+	p.take()
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.take()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") {
+		p.take()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = map[string]struct{}{
+	"==": {}, "!=": {}, "<": {}, "<=": {}, ">": {}, ">=": {}, "=~": {},
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind == tokIdent && tok.text == "in" {
+		p.take()
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{left: left, list: list}, nil
+	}
+	if _, ok := comparisonOps[tok.text]; !ok || tok.kind != tokOp {
+		return left, nil
+	}
+	p.take()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if tok.text == "=~" {
+		lit, ok := right.(*literalNode)
+		re := ""
+		if ok {
+			re, ok = lit.val.(string)
+		}
+		if !ok {
+			return nil, p.errorf(tok, "the right operand of =~ must be a string literal")
+		}
+		compiled, err := regexp.Compile(re)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid regular expression: %s", err)
+		}
+		return &matchNode{left: left, re: compiled}, nil
+	}
+	return &comparisonNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseList() ([]interface{}, error) {
+	if err := p.expectOp("["); err != nil {
+		return nil, err
+	}
+	list := []interface{}{}
+	for !p.isOp("]") {
+		if len(list) > 0 {
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+		tok := p.take()
+		lit := literal(tok)
+		if lit == nil {
+			return nil, p.errorf(tok, "lists may only contain literals, found %q", tok.text)
+		}
+		list = append(list, lit.val)
+	}
+	p.take()
+	return list, nil
+}
+
+// literal returns the literal node for tok, or nil if it's not a literal.
+func literal(tok token) *literalNode {
+	switch tok.kind {
+	case tokString, tokNumber:
+		return &literalNode{val: tok.val}
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{val: true}
+		case "false":
+			return &literalNode{val: false}
+		}
+	}
+	return nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.take()
+	if lit := literal(tok); lit != nil {
+		return lit, nil
+	}
+	switch {
+	case tok.kind == tokIdent && tok.text != "in":
+		return &identNode{name: tok.text}, nil
+	case tok.kind == tokOp && tok.text == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	default:
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+}
+
+type node interface {
+	eval(lookup func(string) (interface{}, error)) (interface{}, error)
+}
+
+type literalNode struct {
+	val interface{}
+}
+
+func (n *literalNode) eval(func(string) (interface{}, error)) (interface{}, error) {
+	return n.val, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	return lookup(n.name)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	b, err := evalBool(n.operand, lookup, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	or          bool
+	left, right node
+}
+
+func (n *logicalNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	op := "&&"
+	if n.or {
+		op = "||"
+	}
+	l, err := evalBool(n.left, lookup, op)
+	if err != nil {
+		return nil, err
+	}
+	// short-circuit, like Go
+	if l == n.or {
+		return l, nil
+	}
+	return evalBool(n.right, lookup, op)
+}
+
+func evalBool(n node, lookup func(string) (interface{}, error), op string) (bool, error) {
+	v, err := n.eval(lookup)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("operand of %s is %v (%T), not a boolean", op, v, v)
+	}
+	return b, nil
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (n *comparisonNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	switch lv := l.(type) {
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, mismatch(n.op, l, r)
+		}
+		return compare(n.op, strings.Compare(lv, rv))
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, mismatch(n.op, l, r)
+		}
+		c := 0
+		if lv < rv {
+			c = -1
+		} else if lv > rv {
+			c = 1
+		}
+		return compare(n.op, c)
+	case bool:
+		rv, ok := r.(bool)
+		if !ok || (n.op != "==" && n.op != "!=") {
+			return nil, mismatch(n.op, l, r)
+		}
+		return (lv == rv) == (n.op == "=="), nil
+	default:
+		return nil, mismatch(n.op, l, r)
+	}
+}
+
+func compare(op string, c int) (bool, error) {
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func mismatch(op string, l, r interface{}) error {
+	return fmt.Errorf("cannot compare %v (%T) %s %v (%T)", l, l, op, r, r)
+}
+
+type matchNode struct {
+	left node
+	re   *regexp.Regexp
+}
+
+func (n *matchNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := l.(string)
+	if !ok {
+		return nil, fmt.Errorf("left operand of =~ is %v (%T), not a string", l, l)
+	}
+	return n.re.MatchString(s), nil
+}
+
+type inNode struct {
+	left node
+	list []interface{}
+}
+
+func (n *inNode) eval(lookup func(string) (interface{}, error)) (interface{}, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range n.list {
+		if v == l {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,32 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// reportConflicts reports a WarningConflict for each conflicting value in
+// exps that isn't in prev, returning the set of conflicts in exps.
+func reportConflicts(ctx context.Context, exps []FieldExplanation, prev map[string]struct{}) map[string]struct{} {
+	current := map[string]struct{}{}
+	for _, exp := range exps {
+		if !exp.Conflict {
+			continue
+		}
+		for _, o := range exp.Overridden {
+			if o.Source == nil || reflect.DeepEqual(o.Value, exp.Value) {
+				continue
+			}
+			msg := fmt.Sprintf("field %s: %s (%s) overridden by %s (%s)",
+				exp.Path, exp.formatValue(o.Value), describeSource(o.Source),
+				exp.formatValue(exp.Value), describeSource(exp.Source))
+			current[msg] = struct{}{}
+			if _, reported := prev[msg]; reported {
+				continue
+			}
+			ReportWarning(ctx, Warning{Kind: WarningConflict, Source: exp.Source, Message: msg})
+		}
+	}
+	return current
+}
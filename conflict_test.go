@@ -0,0 +1,59 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name    string
+		Port    int
+		Plugins []string `dialsmerge:"append"`
+	}
+	type ptrifiedConfig struct {
+		Name    *string
+		Port    *int
+		Plugins []string
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	name, port, otherPort := "svc", 80, 8080
+	file := &fakeSource{outVal: ptrifiedConfig{Name: &name, Port: &port, Plugins: []string{"a"}}}
+	env := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{
+		Name: &name, Port: &otherPort, Plugins: []string{"b"},
+	}}}
+
+	d, err := Params[testConfig]{DetectConflicts: true}.Config(ctx, &testConfig{Port: 1}, file, env)
+	require.NoError(t, err)
+
+	// the same name from both sources, the default port and the appended
+	// plugins aren't conflicts
+	assert.Equal(t, Warning{
+		Kind:    WarningConflict,
+		Source:  env,
+		Message: "field Port: 80 (from *dials.fakeSource) overridden by 8080 (from *dials.fakeWatchingSource)",
+	}, <-d.Warnings())
+	assert.Empty(t, d.Warnings())
+
+	exps := d.Explain()
+	require.Len(t, exps, 3)
+	assert.False(t, exps[0].Conflict)
+	assert.True(t, exps[1].Conflict)
+	assert.False(t, exps[2].Conflict)
+
+	// the same conflict isn't reported again, but a new one is
+	otherName := "other"
+	env.send(ctx, reflect.ValueOf(ptrifiedConfig{Name: &otherName, Port: &otherPort}))
+	<-d.Events()
+	w := <-d.Warnings()
+	assert.Equal(t, `field Name: "svc" (from *dials.fakeSource) overridden by "other" (from *dials.fakeWatchingSource)`,
+		w.Message)
+	assert.Empty(t, d.Warnings())
+}
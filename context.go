@@ -0,0 +1,50 @@
+package dials
+
+import "context"
+
+// dialsCtxKey and configCtxKey are the context keys of the values stored by
+// NewContext and NewConfigContext. Being generic, they're distinct for each
+// config type, so a context can carry the configurations of several types
+// (e.g. a service's and a library's).
+type (
+	dialsCtxKey[T any]  struct{}
+	configCtxKey[T any] struct{}
+)
+
+// NewContext returns a copy of ctx carrying d, which request handlers (and
+// anything else passed the context) can retrieve with FromContext or
+// ConfigFromContext, rather than having d threaded through to them.
+func NewContext[T any](ctx context.Context, d *Dials[T]) context.Context {
+	return context.WithValue(ctx, dialsCtxKey[T]{}, d)
+}
+
+// FromContext returns the Dials instance for the config type T carried by
+// ctx (see NewContext), and whether there is one.
+func FromContext[T any](ctx context.Context) (*Dials[T], bool) {
+	d, ok := ctx.Value(dialsCtxKey[T]{}).(*Dials[T])
+	return d, ok && d != nil
+}
+
+// NewConfigContext returns a copy of ctx carrying cfg, a configuration (e.g.
+// the one returned by View when a request arrived), so everything handling
+// the request sees the same version even if a new one is installed midway.
+// ConfigFromContext returns cfg, rather than the current configuration of a
+// Dials instance carried by ctx.
+func NewConfigContext[T any](ctx context.Context, cfg *T) context.Context {
+	return context.WithValue(ctx, configCtxKey[T]{}, cfg)
+}
+
+// ConfigFromContext returns the configuration of type T carried by ctx: the
+// one passed to NewConfigContext if there is one, and otherwise the current
+// configuration (see View) of the Dials instance passed to NewContext. It
+// returns false if ctx carries neither. As with View, the configuration must
+// not be modified.
+func ConfigFromContext[T any](ctx context.Context) (*T, bool) {
+	if cfg, ok := ctx.Value(configCtxKey[T]{}).(*T); ok && cfg != nil {
+		return cfg, true
+	}
+	if d, ok := FromContext[T](ctx); ok {
+		return d.View(), true
+	}
+	return nil, false
+}
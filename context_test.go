@@ -0,0 +1,61 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type otherConfig struct {
+		Bar int
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, ok := FromContext[testConfig](ctx)
+	assert.False(t, ok)
+	_, ok = ConfigFromContext[testConfig](ctx)
+	assert.False(t, ok)
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{Foo: "foo"}, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	dCtx := NewContext(ctx, d)
+	got, ok := FromContext[testConfig](dCtx)
+	require.True(t, ok)
+	assert.Same(t, d, got)
+	// the keys are distinct for each config type
+	_, ok = FromContext[otherConfig](dCtx)
+	assert.False(t, ok)
+
+	snapshot := d.View()
+	snapCtx := NewConfigContext(dCtx, snapshot)
+
+	bar := "bar"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &bar}))
+	<-d.Events()
+
+	// the Dials instance's configuration is current, and the one stored
+	// with NewConfigContext is fixed
+	cfg, ok := ConfigFromContext[testConfig](dCtx)
+	require.True(t, ok)
+	assert.Equal(t, "bar", cfg.Foo)
+	cfg, ok = ConfigFromContext[testConfig](snapCtx)
+	require.True(t, ok)
+	assert.Same(t, snapshot, cfg)
+	assert.Equal(t, "foo", cfg.Foo)
+}
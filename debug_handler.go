@@ -0,0 +1,122 @@
+package dials
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// debugPage is rendered by the handler returned by Dials.DebugHandler.
+type debugPage struct {
+	Generation uint64                 `json:"generation"`
+	Config     map[string]interface{} `json:"config"`
+	Fields     []debugField           `json:"fields"`
+	History    []debugVersion         `json:"history"`
+}
+
+type debugField struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+type debugVersion struct {
+	Generation uint64    `json:"generation"`
+	Installed  time.Time `json:"installed"`
+	Cause      string    `json:"cause"`
+	Sources    []string  `json:"sources,omitempty"`
+}
+
+var debugTemplate = template.Must(template.New("dials").Funcs(template.FuncMap{
+	"value": formatExplainValue,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Configuration</title></head>
+<body>
+<h1>Configuration (generation {{.Generation}})</h1>
+<table>
+<tr><th>Field</th><th>Value</th><th>Source</th></tr>
+{{- range .Fields}}
+<tr><td>{{.Path}}</td><td>{{value .Value}}</td><td>{{.Source}}</td></tr>
+{{- end}}
+</table>
+<h2>History</h2>
+<table>
+<tr><th>Generation</th><th>Installed</th><th>Cause</th><th>Sources</th></tr>
+{{- range .History}}
+<tr><td>{{.Generation}}</td><td>{{.Installed}}</td><td>{{.Cause}}</td><td>{{range $i, $s := .Sources}}{{if $i}}, {{end}}{{$s}}{{end}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// DebugHandler returns an [http.Handler] that renders the current
+// configuration for operators, to be mounted on an internal admin mux: its
+// generation, the value of every field with the source that supplied it, and
+// the retained versions (see [Dials.History]). It responds with JSON (which
+// also includes the configuration as a nested object) if the "format" query
+// parameter is "json" or the Accept header includes "application/json", and
+// with an HTML page otherwise.
+//
+// The values of secret fields (see [SecretTag]) and those for which
+// [Params].Redact returns true are replaced by RedactedValue. The handler doesn't authenticate requests.
+func (d *Dials[T]) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := d.debugPage()
+		if r.URL.Query().Get("format") == "json" ||
+			strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(page); err != nil {
+				http.Error(w, fmt.Sprintf("failed to encode configuration: %s", err), http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := debugTemplate.Execute(w, page); err != nil {
+			http.Error(w, fmt.Sprintf("failed to render configuration: %s", err), http.StatusInternalServerError)
+		}
+	})
+}
+
+// debugPage describes the current version (loaded once, so every part of the
+// page describes the same version) and the retained versions.
+func (d *Dials[T]) debugPage() *debugPage {
+	vc := d.loadVersion()
+	page := &debugPage{
+		Generation: vc.serial,
+		Config:     redactedConfig(derefConfig(reflect.ValueOf(vc.cfg).Elem()), nil, d.redacts),
+	}
+	for _, exp := range explain(reflect.ValueOf(vc.cfg), reflect.ValueOf(vc.template), vc.sources, d.params.SliceMerge, d.params.Redact) {
+		f := debugField{Path: exp.Path, Value: exp.Value, Source: sourceDescription(exp.Source)}
+		if exp.Secret {
+			f.Value = RedactedValue
+		}
+		page.Fields = append(page.Fields, f)
+	}
+	for _, h := range d.History() {
+		v := debugVersion{Generation: h.Version.Generation(), Installed: h.Installed, Cause: h.Cause.String()}
+		for _, s := range h.Sources {
+			v.Sources = append(v.Sources, sourceDescription(s))
+		}
+		page.History = append(page.History, v)
+	}
+	return page
+}
+
+// sourceDescription names s (nil for the default from the template).
+func sourceDescription(s Source) string {
+	if s == nil {
+		return "default"
+	}
+	if str, ok := s.(fmt.Stringer); ok {
+		return str.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
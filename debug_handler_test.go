@@ -0,0 +1,53 @@
+package dials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	type config struct {
+		Addr   string
+		Secret string
+	}
+	type ptrifiedConfig struct {
+		Addr   *string
+		Secret *string
+	}
+
+	addr := "b"
+	src := &fakeSource{outVal: ptrifiedConfig{Addr: &addr}}
+	d, err := Params[config]{
+		Redact: func(fieldPath []string) bool { return fieldPath[0] == "Secret" },
+	}.Config(ctx, &config{Addr: "a", Secret: "hunter2"}, src)
+	require.NoError(t, err)
+	h := d.DebugHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config?format=json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	page := debugPage{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, map[string]interface{}{"Addr": "b", "Secret": RedactedValue}, page.Config)
+	assert.Equal(t, []debugField{
+		{Path: "Addr", Value: "b", Source: "*dials.fakeSource"},
+		{Path: "Secret", Value: RedactedValue, Source: "default"},
+	}, page.Fields)
+	require.Len(t, page.History, 1)
+	assert.Equal(t, "initial", page.History[0].Cause)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	assert.Contains(t, rec.Body.String(), "<td>Addr</td><td>&#34;b&#34;</td><td>*dials.fakeSource</td>")
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+}
@@ -0,0 +1,53 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// decodeHookSet indexes the hooks set by Params.DecodeHooks by their Target
+// types, checking that each is complete, and that it can be used for the
+// configuration type: a struct Target must already be treated as a single
+// value when the configuration's pointerified type is computed, so only
+// globally registered hooks can target structs that otherwise aren't.
+func decodeHookSet(hooks []parse.DecodeHook) (map[reflect.Type]parse.DecodeHook, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	set := make(map[reflect.Type]parse.DecodeHook, len(hooks))
+	for _, h := range hooks {
+		if h.Target == nil || h.Convert == nil {
+			return nil, fmt.Errorf("incomplete DecodeHook for %v in Params.DecodeHooks", h.Target)
+		}
+		if h.Target.Kind() == reflect.Struct && !ptrify.IsScalarStruct(h.Target) {
+			return nil, fmt.Errorf("DecodeHook for struct type %s in Params.DecodeHooks: "+
+				"hooks for structs with fields must be registered with parse.RegisterDecodeHook", h.Target)
+		}
+		set[h.Target] = h
+	}
+	return set, nil
+}
+
+// DecodeHookLookup returns a function finding the decode hook for a type,
+// preferring those set by Params.DecodeHooks for the Dials instance
+// populating the configuration over those registered with
+// parse.RegisterDecodeHook. Decoders pass it to transform.DecodeHookMangler,
+// with the context passed to their DecodeContext method; with any other
+// context, only the registered hooks are found.
+func DecodeHookLookup(ctx context.Context) func(t reflect.Type) (parse.DecodeHook, bool) {
+	sink, ok := ctx.Value(warningSinkCtxKey{}).(*warningSink)
+	if !ok || len(sink.decodeHooks) == 0 {
+		return parse.LookupDecodeHook
+	}
+	hooks := sink.decodeHooks
+	return func(t reflect.Type) (parse.DecodeHook, bool) {
+		if h, ok := hooks[t]; ok {
+			return h, true
+		}
+		return parse.LookupDecodeHook(t)
+	}
+}
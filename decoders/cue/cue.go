@@ -1,6 +1,7 @@
 package cue
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"reflect"
@@ -17,8 +18,17 @@ import (
 type Decoder struct{}
 
 // Decode is a decoder that decodes the Cue config from an io.Reader into the
-// appropriate struct.
+// appropriate struct. Invalid CUE is reported with a *dials.DecodeError.
 func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but reports a dials.WarningDeprecated warning
+// (see dials.ReportWarning) with ctx when a key listed in a field's
+// `dialsalias` tag is used instead of the field's own key, and a
+// dials.WarningUnknownKey warning for each key that doesn't correspond to any
+// field if ctx requests it (see dials.DetectingUnusedConfig).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
 	raw, readErr := io.ReadAll(r)
 	if readErr != nil {
 		return reflect.Value{}, fmt.Errorf("error reading raw bytes: %w", readErr)
@@ -26,22 +36,45 @@ func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
 
 	const jsonTagName = "json"
 
+	deprecated := func(alias, name string) {
+		dials.ReportWarning(ctx, dials.Warning{
+			Kind:    dials.WarningDeprecated,
+			Message: fmt.Sprintf("CUE key %q is deprecated; use %q instead", alias, name),
+		})
+	}
 	// If there aren't any json tags, copy over from any dials tags.
 	tfmr := transform.NewTransformer(t.Type(),
+		&transform.EmbedMangler{},
 		&tagformat.TagCopyingMangler{
-			SrcTag: common.DialsTagName, NewTag: jsonTagName})
+			SrcTag: common.DialsTagName, NewTag: jsonTagName},
+		transform.NewAliasMangler(jsonTagName, deprecated),
+		&transform.DecodeHookMangler{Lookup: dials.DecodeHookLookup(ctx)},
+		&transform.TimeLayoutMangler{},
+		&transform.StringScalarMangler{},
+		&transform.UnitMangler{})
 	reflVal, tfmErr := tfmr.Translate()
 	if tfmErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to convert tags: %s", tfmErr)
+		return reflect.Value{}, fmt.Errorf("failed to convert tags: %w", tfmErr)
 	}
 
 	cctxt := cuecontext.New()
 	val := cctxt.CompileBytes(raw)
 	if compileErr := val.Err(); compileErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to compile cue blob: %w", compileErr)
+		return reflect.Value{}, &dials.DecodeError{Format: "CUE", Err: compileErr}
 	}
 	if decErr := val.Decode(reflVal.Addr().Interface()); decErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to decode cue value into dials struct: %w", decErr)
+		return reflect.Value{}, &dials.DecodeError{Format: "CUE", Err: decErr}
+	}
+	if dials.DetectingUnusedConfig(ctx) {
+		var generic interface{}
+		if val.Decode(&generic) == nil {
+			for _, k := range transform.UnknownKeys(reflVal.Type(), jsonTagName, generic) {
+				dials.ReportWarning(ctx, dials.Warning{
+					Kind:    dials.WarningUnknownKey,
+					Message: fmt.Sprintf("CUE key %q doesn't correspond to any field", k),
+				})
+			}
+		}
 	}
-	return reflVal, nil
+	return tfmr.ReverseTranslate(reflVal)
 }
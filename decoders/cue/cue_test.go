@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
 	"github.com/vimeo/dials/sources/static"
 )
 
@@ -195,3 +196,14 @@ _foobar: {
 	assert.Equal(t, "something", c.Val1)
 	assert.Equal(t, 42, c.Val2)
 }
+
+func FuzzDecode(f *testing.F) {
+	dialstest.FuzzDecoder(f, &Decoder{},
+		`string: "s", int: -1, int8: 127, uint16: 65535, float: 1.5e300, bool: true`,
+		`duration: "1h2m", time: "2006-01-02T15:04:05Z", pointer: "p"`,
+		`strings: ["a", "b"], appended: [1, 2], set: ["x"], map: {a: 1}`,
+		`nested: {name: "n", count: 1, inner: {On: true}, tags: {k: "v"}}`,
+		`structs: [{name: "a"}, {}], merged: {a: {count: 2}}`,
+		`x: 1, int: x + 1, string: "\(x)"`,
+	)
+}
@@ -1,6 +1,8 @@
 package json
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,29 +20,70 @@ const JSONTagName = "json"
 
 // Decoder is a decoder that knows how to work with text encoded in JSON
 type Decoder struct {
+	// DisallowUnknownFields makes Decode fail on keys that don't correspond
+	// to any field, rather than ignoring them.
+	DisallowUnknownFields bool
 }
 
 // Decode is a decoder that decodes the JSON from an io.Reader into the
-// appropriate struct.
+// appropriate struct. Invalid JSON is reported with a *dials.DecodeError.
 func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but reports a dials.WarningDeprecated warning
+// (see dials.ReportWarning) with ctx when a key listed in a field's
+// `dialsalias` tag is used instead of the field's own key, and a
+// dials.WarningUnknownKey warning for each key that doesn't correspond to any
+// field if ctx requests it (see dials.DetectingUnusedConfig).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
 	jsonBytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return reflect.Value{}, fmt.Errorf("error reading JSON: %s", err)
 	}
 
+	deprecated := func(alias, name string) {
+		dials.ReportWarning(ctx, dials.Warning{
+			Kind:    dials.WarningDeprecated,
+			Message: fmt.Sprintf("JSON key %q is deprecated; use %q instead", alias, name),
+		})
+	}
 	// If there aren't any json tags, copy over from any dials tags.
 	tfmr := transform.NewTransformer(t.Type(),
+		&transform.EmbedMangler{},
 		&tagformat.TagCopyingMangler{
-			SrcTag: common.DialsTagName, NewTag: JSONTagName})
+			SrcTag: common.DialsTagName, NewTag: JSONTagName},
+		transform.NewAliasMangler(JSONTagName, deprecated),
+		&transform.DecodeHookMangler{Lookup: dials.DecodeHookLookup(ctx)},
+		&transform.TimeLayoutMangler{},
+		&transform.StringScalarMangler{},
+		&transform.UnitMangler{})
 	val, tfmErr := tfmr.Translate()
 	if tfmErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to convert tags: %s", tfmErr)
+		return reflect.Value{}, fmt.Errorf("failed to convert tags: %w", tfmErr)
 	}
 	// Get a pointer to our value, so we can pass that.
 	instance := val.Addr().Interface()
-	err = json.Unmarshal(jsonBytes, instance)
+	if d.DisallowUnknownFields {
+		dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(instance)
+	} else {
+		err = json.Unmarshal(jsonBytes, instance)
+	}
 	if err != nil {
-		return reflect.Value{}, err
+		return reflect.Value{}, &dials.DecodeError{Format: "JSON", Err: err}
+	}
+	if dials.DetectingUnusedConfig(ctx) {
+		var generic interface{}
+		if json.Unmarshal(jsonBytes, &generic) == nil {
+			for _, k := range transform.UnknownKeys(val.Type(), JSONTagName, generic) {
+				dials.ReportWarning(ctx, dials.Warning{
+					Kind:    dials.WarningUnknownKey,
+					Message: fmt.Sprintf("JSON key %q doesn't correspond to any field", k),
+				})
+			}
+		}
 	}
 
 	unmangledVal, unmangleErr := tfmr.ReverseTranslate(val)
@@ -2,12 +2,16 @@ package json
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
 	"github.com/vimeo/dials/sources/static"
 )
 
@@ -36,6 +40,53 @@ func TestJSON(t *testing.T) {
 	assert.Equal(t, 42, c.Val2)
 }
 
+func TestJSONDisallowUnknownFields(t *testing.T) {
+	type testConfig struct {
+		Val1 string
+		Val2 int `dials:"value_2"`
+	}
+	jsonData := `{"val1": "something", "val_2": 42}`
+
+	_, err := dials.Config(context.Background(), &testConfig{},
+		&static.StringSource{Data: jsonData, Decoder: &Decoder{DisallowUnknownFields: true}})
+	assert.ErrorContains(t, err, `unknown field "val_2"`)
+
+	d, err := dials.Config(context.Background(), &testConfig{},
+		&static.StringSource{Data: `{"val1": "something", "value_2": 42}`, Decoder: &Decoder{DisallowUnknownFields: true}})
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Val1: "something", Val2: 42}, d.View())
+}
+
+func TestJSONSyntaxError(t *testing.T) {
+	type testConfig struct {
+		Val1 string
+	}
+	src := &static.StringSource{Data: `{"val1": "something"`, Decoder: &Decoder{}}
+
+	_, err := dials.Config(context.Background(), &testConfig{}, src)
+	decErr := &dials.DecodeError{}
+	require.True(t, errors.As(err, &decErr))
+	assert.Equal(t, "JSON", decErr.Format)
+	syntaxErr := &json.SyntaxError{}
+	assert.True(t, errors.As(err, &syntaxErr))
+	ce := &dials.ConfigError{}
+	require.True(t, errors.As(err, &ce))
+	assert.Same(t, src, ce.Source)
+}
+
+func TestJSONUnknownKeys(t *testing.T) {
+	type testConfig struct {
+		Val1 string
+		Val2 int `dials:"value_2" dialsalias:"val2"`
+	}
+	jsonData := `{"val1": "something", "val2": 42, "val_2": 43}`
+
+	d, err := dials.Params[testConfig]{DetectUnusedConfig: true}.Config(context.Background(), &testConfig{},
+		&static.StringSource{Data: jsonData, Decoder: &Decoder{}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`JSON key "val_2" doesn't correspond to any field`}, d.UnusedConfig().UnknownKeys)
+}
+
 func TestShallowlyNestedJSON(t *testing.T) {
 	type testConfig struct {
 		DatabaseName    string `dials:"database_name"`
@@ -166,3 +217,62 @@ func TestMoreDeeplyNestedJSON(t *testing.T) {
 	assert.Equal(t, net.IPv4(123, 10, 11, 121), c.DatabaseUser.OtherStuff.Something.IPAddress)
 
 }
+
+// opaqueLevel has no exported fields, so it's only settable by decoding it
+// as a whole.
+type opaqueLevel struct{ level int }
+
+func (o *opaqueLevel) UnmarshalJSON(b []byte) error {
+	o.level = len(b)
+	return nil
+}
+
+func TestJSONOpaqueStruct(t *testing.T) {
+	type testConfig struct {
+		Level     opaqueLevel
+		Untouched opaqueLevel
+	}
+
+	d, err := dials.Config(
+		context.Background(),
+		&testConfig{Level: opaqueLevel{level: 1}, Untouched: opaqueLevel{level: 2}},
+		&static.StringSource{Data: `{"Level": "four"}`, Decoder: &Decoder{}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{
+		Level:     opaqueLevel{level: len(`"four"`)},
+		Untouched: opaqueLevel{level: 2},
+	}, d.View())
+}
+
+func TestJSONTime(t *testing.T) {
+	type testConfig struct {
+		Start time.Time
+		End   time.Time `dialstimelayout:"DateOnly"`
+	}
+	jsonData := `{
+        "Start": "2020-01-02T03:04:05Z",
+        "End": "2021-06-07"
+    }`
+
+	d, err := dials.Config(
+		context.Background(),
+		&testConfig{},
+		&static.StringSource{Data: jsonData, Decoder: &Decoder{}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{
+		Start: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		End:   time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC),
+	}, d.View())
+}
+
+func FuzzDecode(f *testing.F) {
+	dialstest.FuzzDecoder(f, &Decoder{},
+		`{"string": "s", "int": -1, "int8": 127, "uint16": 65535, "float": 1.5e300, "bool": true}`,
+		`{"duration": "1h2m", "time": "2006-01-02T15:04:05Z", "pointer": "p"}`,
+		`{"strings": ["a", "b"], "appended": [1, 2], "set": ["x"], "map": {"a": 1}}`,
+		`{"nested": {"name": "n", "count": 1, "inner": {"On": true}, "tags": {"k": "v"}}}`,
+		`{"structs": [{"name": "a"}, {}], "merged": {"a": {"count": 2}, "b": null}}`,
+	)
+}
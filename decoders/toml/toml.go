@@ -1,6 +1,7 @@
 package toml
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -22,28 +23,61 @@ type Decoder struct {
 }
 
 // Decode will read from `r` and parse it as TOML depositing the relevant values
-// in `t`.
+// in `t`. Invalid TOML is reported with a *dials.DecodeError.
 func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but reports a dials.WarningDeprecated warning
+// (see dials.ReportWarning) with ctx when a key listed in a field's
+// `dialsalias` tag is used instead of the field's own key, and a
+// dials.WarningUnknownKey warning for each key that doesn't correspond to any
+// field if ctx requests it (see dials.DetectingUnusedConfig).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
 	tomlBytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return reflect.Value{}, fmt.Errorf("error reading TOML: %s", err)
 	}
 
+	deprecated := func(alias, name string) {
+		dials.ReportWarning(ctx, dials.Warning{
+			Kind:    dials.WarningDeprecated,
+			Message: fmt.Sprintf("TOML key %q is deprecated; use %q instead", alias, name),
+		})
+	}
 	// Use the TagCopyingMangler to copy over TOML tags from dials tags if TOML
 	// tags aren't specified.
 	tfmr := transform.NewTransformer(t.Type(),
+		&transform.EmbedMangler{},
 		&tagformat.TagCopyingMangler{
-			SrcTag: common.DialsTagName, NewTag: TOMLTagName})
+			SrcTag: common.DialsTagName, NewTag: TOMLTagName},
+		transform.NewAliasMangler(TOMLTagName, deprecated),
+		&transform.DecodeHookMangler{Lookup: dials.DecodeHookLookup(ctx)},
+		&transform.TimeLayoutMangler{},
+		&transform.StringScalarMangler{},
+		&transform.RawJSONMangler{},
+		&transform.UnitMangler{})
 	val, tfmErr := tfmr.Translate()
 	if tfmErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to convert tags: %s", tfmErr)
+		return reflect.Value{}, fmt.Errorf("failed to convert tags: %w", tfmErr)
 	}
 
 	// Get a pointer to our value, so we can pass that.
 	instance := val.Addr().Interface()
 	err = tomlparser.Unmarshal(tomlBytes, instance)
 	if err != nil {
-		return reflect.Value{}, err
+		return reflect.Value{}, &dials.DecodeError{Format: "TOML", Err: err}
+	}
+	if dials.DetectingUnusedConfig(ctx) {
+		generic := map[string]interface{}{}
+		if tomlparser.Unmarshal(tomlBytes, &generic) == nil {
+			for _, k := range transform.UnknownKeys(val.Type(), TOMLTagName, generic) {
+				dials.ReportWarning(ctx, dials.Warning{
+					Kind:    dials.WarningUnknownKey,
+					Message: fmt.Sprintf("TOML key %q doesn't correspond to any field", k),
+				})
+			}
+		}
 	}
 
 	unmangledVal, unmangleErr := tfmr.ReverseTranslate(val)
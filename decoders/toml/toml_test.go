@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
 	"github.com/vimeo/dials/sources/static"
 
 	"github.com/stretchr/testify/assert"
@@ -167,3 +168,13 @@ func TestDecoderBadMarkup(t *testing.T) {
 	)
 	require.Error(t, err)
 }
+
+func FuzzDecode(f *testing.F) {
+	dialstest.FuzzDecoder(f, &Decoder{},
+		"string = \"s\"\nint = -1\nint8 = 127\nuint16 = 65535\nfloat = 1.5e300\nbool = true",
+		"duration = \"1h2m\"\ntime = 2006-01-02T15:04:05Z\npointer = \"p\"",
+		"strings = [\"a\", \"b\"]\nappended = [1, 2]\nset = [\"x\"]\n[map]\na = 1",
+		"[nested]\nname = \"n\"\ncount = 1\n[nested.inner]\nOn = true\n[nested.tags]\nk = \"v\"",
+		"[[structs]]\nname = \"a\"\n[[structs]]\n[merged.a]\ncount = 2",
+	)
+}
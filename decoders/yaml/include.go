@@ -0,0 +1,91 @@
+package yaml
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/vimeo/dials"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// IncludeTag is the YAML tag replacing a value with the contents of another
+// YAML file, e.g. `database: !include database.yaml`. Relative names are
+// resolved against the directory of the including file, and included files
+// may include others. It's only supported for files read by a source that
+// tells the decoder which file it's decoding (see dials.ContextWithFile),
+// such as the file source with Includes enabled.
+const IncludeTag = "!include"
+
+// resolveIncludes replaces the values tagged with IncludeTag in yamlBytes
+// with the contents of the files they name (see dials.OpenIncluded), and
+// returns the re-encoded YAML. yamlBytes is returned unchanged if it doesn't
+// use the tag.
+func resolveIncludes(ctx context.Context, yamlBytes []byte) ([]byte, error) {
+	if !bytes.Contains(yamlBytes, []byte(IncludeTag)) {
+		return yamlBytes, nil
+	}
+	doc := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(yamlBytes, &doc); err != nil {
+		return nil, err
+	}
+	found, err := expandIncludes(ctx, &doc)
+	if err != nil || !found {
+		return yamlBytes, err
+	}
+	return yamlv3.Marshal(&doc)
+}
+
+// expandIncludes replaces the nodes tagged with IncludeTag within n (or n
+// itself) with the contents of the files they name, returning whether there
+// were any.
+func expandIncludes(ctx context.Context, n *yamlv3.Node) (bool, error) {
+	if n.Tag == IncludeTag {
+		if n.Kind != yamlv3.ScalarNode {
+			return false, fmt.Errorf("line %d: %s must be applied to a file name", n.Line, IncludeTag)
+		}
+		inc, err := includeNode(ctx, n.Value)
+		if err != nil {
+			return false, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		*n = *inc
+		return true, nil
+	}
+	found := false
+	for _, c := range n.Content {
+		f, err := expandIncludes(ctx, c)
+		if err != nil {
+			return false, err
+		}
+		found = found || f
+	}
+	return found, nil
+}
+
+// includeNode reads the file included as name, and returns the node of its
+// value, with its own includes expanded.
+func includeNode(ctx context.Context, name string) (*yamlv3.Node, error) {
+	f, incCtx, err := dials.OpenIncluded(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", name, err)
+	}
+	doc := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", name, err)
+	}
+	if len(doc.Content) == 0 {
+		// an empty file is a null value
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	node := doc.Content[0]
+	if _, err := expandIncludes(incCtx, node); err != nil {
+		return nil, fmt.Errorf("in %q: %w", name, err)
+	}
+	return node, nil
+}
@@ -0,0 +1,63 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/transform"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var (
+	nodeType           = reflect.TypeOf(yamlv3.Node{})
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// nodeMangler changes yaml.v3 Node fields (and pointers to them) to
+// interface{}, so they can be decoded with yaml.v2, and converts the decoded
+// values back into Nodes when unmangling.
+type nodeMangler struct{}
+
+// Mangle changes the type of the provided StructField to interface{} if it's
+// a yaml.v3 Node (or a pointer to one).
+func (*nodeMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if isNode(sf.Type) {
+		sf.Type = emptyInterfaceType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle re-encodes the decoded value of a mangled field, and decodes it
+// into a yaml.v3 Node.
+func (*nodeMangler) Unmangle(sf reflect.StructField, vs []transform.FieldValueTuple) (reflect.Value, error) {
+	if !isNode(sf.Type) {
+		return vs[0].Value, nil
+	}
+	if vs[0].Value.IsNil() {
+		return reflect.Zero(sf.Type), nil
+	}
+	b, err := yaml.Marshal(vs[0].Value.Interface())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to re-encode field %s: %w", sf.Name, err)
+	}
+	doc := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(b, &doc); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode field %s into a yaml.Node: %w", sf.Name, err)
+	}
+	// Unmarshal produces a document node wrapping the value's node
+	node := doc.Content[0]
+	if sf.Type == nodeType {
+		return reflect.ValueOf(*node), nil
+	}
+	return reflect.ValueOf(node), nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*nodeMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+func isNode(t reflect.Type) bool {
+	return t == nodeType || t == reflect.PtrTo(nodeType)
+}
@@ -1,6 +1,7 @@
 package yaml
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -22,26 +23,66 @@ type Decoder struct {
 }
 
 // Decode reads from `r` and decodes what is read as YAML depositing the
-// relevant values into `t`.
+// relevant values into `t`. Invalid YAML is reported with a *dials.DecodeError.
 func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but reports a dials.WarningDeprecated warning
+// (see dials.ReportWarning) with ctx when a key listed in a field's
+// `dialsalias` tag is used instead of the field's own key, expands values
+// tagged with IncludeTag if ctx describes the file being decoded (see
+// dials.ContextWithFile), and reports a dials.WarningUnknownKey warning for
+// each key that doesn't correspond to any field if ctx requests it (see
+// dials.DetectingUnusedConfig).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
 	yamlBytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return reflect.Value{}, fmt.Errorf("error reading YAML: %s", err)
 	}
+	yamlBytes, err = resolveIncludes(ctx, yamlBytes)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to resolve %s tags: %w", IncludeTag, err)
+	}
 
+	deprecated := func(alias, name string) {
+		dials.ReportWarning(ctx, dials.Warning{
+			Kind:    dials.WarningDeprecated,
+			Message: fmt.Sprintf("YAML key %q is deprecated; use %q instead", alias, name),
+		})
+	}
 	tfmr := transform.NewTransformer(t.Type(),
+		&transform.EmbedMangler{},
 		&tagformat.TagCopyingMangler{
 			SrcTag: common.DialsTagName, NewTag: YAMLTagName},
+		transform.NewAliasMangler(YAMLTagName, deprecated),
+		&transform.DecodeHookMangler{Lookup: dials.DecodeHookLookup(ctx)},
+		&transform.TimeLayoutMangler{},
+		&transform.StringScalarMangler{},
+		&transform.RawJSONMangler{},
+		&nodeMangler{},
+		&transform.UnitMangler{},
 	)
 	val, tfmErr := tfmr.Translate()
 	if tfmErr != nil {
-		return reflect.Value{}, fmt.Errorf("failed to convert tags: %s", tfmErr)
+		return reflect.Value{}, fmt.Errorf("failed to convert tags: %w", tfmErr)
 	}
 
 	instance := val.Addr().Interface()
 	err = yaml.Unmarshal(yamlBytes, instance)
 	if err != nil {
-		return reflect.Value{}, err
+		return reflect.Value{}, &dials.DecodeError{Format: "YAML", Err: err}
+	}
+	if dials.DetectingUnusedConfig(ctx) {
+		var generic interface{}
+		if yaml.Unmarshal(yamlBytes, &generic) == nil {
+			for _, k := range transform.UnknownKeys(val.Type(), YAMLTagName, generic) {
+				dials.ReportWarning(ctx, dials.Warning{
+					Kind:    dials.WarningUnknownKey,
+					Message: fmt.Sprintf("YAML key %q doesn't correspond to any field", k),
+				})
+			}
+		}
 	}
 
 	unmangledVal, unmangleErr := tfmr.ReverseTranslate(val)
@@ -2,14 +2,20 @@ package yaml
 
 import (
 	"context"
+	"encoding/json"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
+	"github.com/vimeo/dials/sources/file"
 	"github.com/vimeo/dials/sources/static"
+	"gopkg.in/yaml.v3"
 )
 
 func TestYAML(t *testing.T) {
@@ -164,3 +170,109 @@ func TestDecoderBadMarkup(t *testing.T) {
 	)
 	require.Error(t, err)
 }
+
+func TestYAMLPassThrough(t *testing.T) {
+	type testConfig struct {
+		Kind   string          `dials:"kind"`
+		Plugin yaml.Node       `dials:"plugin"`
+		Raw    json.RawMessage `dials:"raw"`
+		Any    interface{}     `dials:"any"`
+	}
+	yamlData := `---
+        kind: redis
+        plugin:
+            addr: localhost:6379
+            db: 2
+        raw: [1, {a: b}]
+        any: {c: d}
+`
+
+	d, err := dials.Config(
+		context.Background(),
+		&testConfig{},
+		&static.StringSource{Data: yamlData, Decoder: &Decoder{}},
+	)
+	require.NoError(t, err)
+	c := d.View()
+	assert.Equal(t, "redis", c.Kind)
+
+	// the plugin's config can be decoded once its type is known
+	plugin := struct {
+		Addr string `yaml:"addr"`
+		DB   int    `yaml:"db"`
+	}{}
+	require.NoError(t, c.Plugin.Decode(&plugin))
+	assert.Equal(t, "localhost:6379", plugin.Addr)
+	assert.Equal(t, 2, plugin.DB)
+
+	assert.JSONEq(t, `[1, {"a": "b"}]`, string(c.Raw))
+	assert.Equal(t, map[interface{}]interface{}{"c": "d"}, c.Any)
+}
+
+func TestYAMLIncludeTag(t *testing.T) {
+	t.Parallel()
+	type dbConfig struct {
+		Host string
+		Port int
+	}
+	type testConfig struct {
+		Name    string
+		DB      dbConfig
+		Servers []string
+	}
+	dir := t.TempDir()
+	writeFile := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+	// includes are relative to the including file
+	writeFile("db/db.yaml", "host: db.example.com\nport: !include port.yaml\n")
+	writeFile("db/port.yaml", "5432\n")
+	writeFile("servers.yaml", "- a\n- b\n")
+	main := writeFile("config.yaml", "name: svc\ndb: !include db/db.yaml\nservers: !include servers.yaml\n")
+
+	src, err := file.NewSource(main, &Decoder{})
+	require.NoError(t, err)
+	src.Includes = true
+	d, err := dials.Config(context.Background(), &testConfig{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, testConfig{
+		Name:    "svc",
+		DB:      dbConfig{Host: "db.example.com", Port: 5432},
+		Servers: []string{"a", "b"},
+	}, *d.View())
+
+	// without Includes, the tag is an error
+	src, err = file.NewSource(main, &Decoder{})
+	require.NoError(t, err)
+	_, err = dials.Config(context.Background(), &testConfig{}, src)
+	assert.ErrorContains(t, err, "the including file is unknown")
+
+	writeFile("db/port.yaml", "!include ../../"+filepath.Base(dir)+"/db/db.yaml\n")
+	src, err = file.NewSource(main, &Decoder{})
+	require.NoError(t, err)
+	src.Includes = true
+	_, err = dials.Config(context.Background(), &testConfig{}, src)
+	cycleErr := &dials.IncludeCycleError{}
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "db", "db.yaml"),
+		filepath.Join(dir, "db", "port.yaml"),
+		filepath.Join(dir, "db", "db.yaml"),
+	}, cycleErr.Chain)
+}
+
+func FuzzDecode(f *testing.F) {
+	dialstest.FuzzDecoder(f, &Decoder{},
+		"string: s\nint: -1\nint8: 127\nuint16: 65535\nfloat: 1.5e300\nbool: true",
+		"duration: 1h2m\ntime: 2006-01-02T15:04:05Z\npointer: p",
+		"strings: [a, b]\nappended: [1, 2]\nset: [x]\nmap: {a: 1}",
+		"nested:\n  name: n\n  count: 1\n  inner: {On: true}\n  tags: {k: v}",
+		"structs:\n- name: a\n- {}\nmerged:\n  a: {count: 2}\n  b: ~",
+		"base: &b {name: a}\nnested: *b\nstructs: [*b, *b]",
+		"nested: !include other.yaml",
+	)
+}
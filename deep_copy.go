@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/token"
 	"reflect"
+	"sync"
 )
 
 // takes a concrete value for in and returns a concrete deep copy Value
@@ -13,6 +14,11 @@ func realDeepCopy(in interface{}) reflect.Value {
 }
 
 func deepCopyValue(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if g := lookupGenerated(v.Type().Elem()); g != nil {
+			return g.deepCopy(v)
+		}
+	}
 	d := newDeepCopier()
 	return d.deepCopyValue(v)
 }
@@ -65,6 +71,10 @@ func (d *deepCopier) deepCopy(in, out reflect.Value) {
 	}
 
 	d.registerPair(in, out)
+	if out.CanSet() && isFlat(in.Type()) {
+		// Set copied everything that deepCopy would have.
+		return
+	}
 
 	switch in.Kind() {
 	case reflect.Struct:
@@ -217,3 +227,32 @@ func (d *deepCopier) deepCopyMap(in, out reflect.Value) {
 		out.SetMapIndex(newKey, newVal)
 	}
 }
+
+// flatTypes caches the results of isFlat.
+var flatTypes sync.Map
+
+// isFlat returns true if values of type t don't (transitively) reference any
+// memory that deepCopy would copy, so assigning them makes a deep copy:
+// scalars, channels and functions (which deepCopy doesn't copy either), and
+// arrays and structs whose elements or exported fields are flat.
+func isFlat(t reflect.Type) bool {
+	if f, ok := flatTypes.Load(t); ok {
+		return f.(bool)
+	}
+	flat := true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		flat = false
+	case reflect.Array:
+		flat = isFlat(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && flat; i++ {
+			sf := t.Field(i)
+			// deepCopy doesn't copy unexported fields either
+			flat = !token.IsExported(sf.Name) || isFlat(sf.Type)
+		}
+	default:
+	}
+	flatTypes.Store(t, flat)
+	return flat
+}
@@ -420,3 +420,31 @@ func verifyDifferentPointers(t testing.TB, seenPtrs map[uintptr]struct{}, fname
 		}
 	}
 }
+
+func TestIsFlat(t *testing.T) {
+	type flatStruct struct {
+		A int
+		B [2]string
+		C chan struct{}
+		p *int
+	}
+	for _, tc := range []struct {
+		v    interface{}
+		flat bool
+	}{
+		{v: 1, flat: true},
+		{v: "s", flat: true},
+		{v: flatStruct{}, flat: true},
+		{v: [3]flatStruct{}, flat: true},
+		{v: struct{ F flatStruct }{}, flat: true},
+		{v: &flatStruct{}, flat: false},
+		{v: []int{}, flat: false},
+		{v: map[string]int{}, flat: false},
+		{v: struct{ I interface{} }{}, flat: false},
+		{v: [1]struct{ P *int }{}, flat: false},
+	} {
+		if flat := isFlat(reflect.TypeOf(tc.v)); flat != tc.flat {
+			t.Errorf("isFlat(%T) = %t, expected %t", tc.v, flat, tc.flat)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package dials
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// DefaultTag is the name of the struct tag providing a field's default value
+// (e.g. `dialsdefault:"8080"`), as an alternative to populating the template
+// passed to Config. Defaults are applied to the template's zero fields before
+// any source is overlaid, so they have the lowest precedence, and fields the
+// template sets explicitly keep their values.
+//
+// The tag's value is parsed like an environment variable's: numbers,
+// booleans, durations, comma-separated slices (e.g. `dialsdefault:"a,b"`),
+// maps (e.g. `dialsdefault:"k1:v1,k2:v2"`), times (using any
+// dialstimelayout tag) and types implementing encoding.TextUnmarshaler are
+// supported. A nil pointer to a struct containing defaults is allocated so
+// they can be applied.
+const DefaultTag = "dialsdefault"
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// applyDefaults sets the zero fields of the struct v (whose fields are at
+// path) that have a DefaultTag to the tag's value, returning an error for
+// each invalid tag.
+func applyDefaults(v reflect.Value, path []string) []*ConfigError {
+	errs := []*ConfigError{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		f := v.Field(i)
+		tag, hasDefault := sf.Tag.Lookup(DefaultTag)
+		if !hasDefault {
+			errs = append(errs, applyNestedDefaults(f, fieldPath)...)
+			continue
+		}
+		if !f.IsZero() {
+			continue
+		}
+		def, err := parseDefault(tag, sf)
+		if err != nil {
+			errs = append(errs, &ConfigError{
+				Path: strings.Join(fieldPath, "."),
+				Err:  fmt.Errorf("invalid %s tag %q: %w", DefaultTag, tag, err),
+			})
+			continue
+		}
+		f.Set(def)
+	}
+	return errs
+}
+
+// applyNestedDefaults applies the defaults within f if it's a (non-scalar)
+// struct, or a pointer to one, which is allocated if it's nil and the struct
+// has any defaults.
+func applyNestedDefaults(f reflect.Value, path []string) []*ConfigError {
+	ft := f.Type()
+	switch {
+	case ft.Kind() == reflect.Struct && !ptrify.IsScalarStruct(ft):
+		return applyDefaults(f, path)
+	case ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct && !ptrify.IsScalarStruct(ft.Elem()):
+		if f.IsNil() {
+			if !hasDefaults(ft.Elem()) {
+				return nil
+			}
+			f.Set(reflect.New(ft.Elem()))
+		}
+		return applyDefaults(f.Elem(), path)
+	default:
+		return nil
+	}
+}
+
+// hasDefaults returns true if any field of the struct type t (or the structs
+// within it) has a DefaultTag.
+func hasDefaults(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		if _, ok := sf.Tag.Lookup(DefaultTag); ok {
+			return true
+		}
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if st.Kind() == reflect.Struct && !ptrify.IsScalarStruct(st) && hasDefaults(st) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDefault parses tag as a value for the field sf.
+func parseDefault(tag string, sf reflect.StructField) (reflect.Value, error) {
+	target := sf.Type
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	var parsed reflect.Value
+	switch {
+	case reflect.PtrTo(target).Implements(textUnmarshalerType):
+		parsed = reflect.New(target)
+		if err := parsed.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(tag)); err != nil {
+			return reflect.Value{}, err
+		}
+	case target == timeType:
+		ts, err := parse.Time(tag, parse.TimeLayouts(sf.Tag.Get(common.TimeLayoutTagName)))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		parsed = reflect.ValueOf(&ts)
+	default:
+		var err error
+		if parsed, err = parse.String(tag, target); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	// parse returns pointers to scalars, but slices and maps directly
+	if parsed.Kind() == reflect.Ptr && target.Kind() != reflect.Ptr {
+		parsed = parsed.Elem()
+	}
+	parsed = parsed.Convert(target)
+	if sf.Type.Kind() != reflect.Ptr {
+		return parsed, nil
+	}
+	ptr := reflect.New(target)
+	ptr.Elem().Set(parsed)
+	return ptr, nil
+}
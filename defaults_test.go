@@ -0,0 +1,58 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTag(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	type serverConfig struct {
+		Port    int           `dialsdefault:"8080"`
+		Timeout time.Duration `dialsdefault:"5s"`
+		Hosts   []string      `dialsdefault:"a,b"`
+	}
+	type config struct {
+		Name    string `dialsdefault:"svc"`
+		Debug   *bool  `dialsdefault:"true"`
+		Server  serverConfig
+		Backup  *serverConfig
+		Labels  map[string]string `dialsdefault:"env:prod"`
+		Started time.Time         `dialsdefault:"2024-01-02T03:04:05Z"`
+	}
+
+	d, err := Config(ctx, &config{Server: serverConfig{Port: 9090}})
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, "svc", cfg.Name)
+	require.NotNil(t, cfg.Debug)
+	assert.True(t, *cfg.Debug)
+	// the template's value wins over the tag
+	assert.Equal(t, serverConfig{Port: 9090, Timeout: 5 * time.Second, Hosts: []string{"a", "b"}}, cfg.Server)
+	require.NotNil(t, cfg.Backup)
+	assert.Equal(t, 8080, cfg.Backup.Port)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.Labels)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), cfg.Started)
+}
+
+func TestDefaultTagInvalid(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Port    int           `dialsdefault:"http"`
+		Timeout time.Duration `dialsdefault:"soon"`
+	}
+	_, err := Config(context.Background(), &config{})
+	require.Error(t, err)
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 2)
+	assert.Equal(t, "Port", ce.Errors[0].Path)
+	assert.Equal(t, "Timeout", ce.Errors[1].Path)
+}
@@ -0,0 +1,155 @@
+// Package deferred provides Section, a config field whose contents are kept
+// as raw JSON until the application knows what type to decode them into (e.g.
+// a plugin's configuration, whose type depends on which plugin another field
+// selects), and functions decoding it on demand.
+//
+// Unlike a json.RawMessage field, which holds the value from the
+// highest-precedence source that set it, a Section keeps each source's
+// contents, so Decode composes them like Config composes its sources:
+//
+//	type Config struct {
+//		Plugin   string           `dials:"plugin"`
+//		Settings deferred.Section `dials:"settings"`
+//	}
+//
+//	tmpl := plugins[cfg.Plugin].Template() // e.g. &redis.Config{}
+//	settings, err := deferred.DecodeValue(ctx, cfg.Settings, reflect.ValueOf(tmpl))
+package deferred
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vimeo/dials"
+	dialsjson "github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/static"
+)
+
+// Section holds the raw contents of a config section from each source that
+// set it, as JSON, in increasing order of precedence. Sources' values are
+// appended to it unless it's tagged `dialsmerge:"replace"`, in which case it
+// only holds the highest-precedence one. File decoders for other formats
+// re-encode the section's contents as JSON, and an environment variable's
+// value must be JSON. Flags can't set it.
+type Section []json.RawMessage
+
+var (
+	_ json.Unmarshaler = (*Section)(nil)
+	_ json.Marshaler   = Section(nil)
+)
+
+// UnmarshalJSON sets s to a single layer holding a copy of b.
+func (s *Section) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*s = nil
+		return nil
+	}
+	*s = Section{append(json.RawMessage(nil), b...)}
+	return nil
+}
+
+// MarshalJSON encodes s's layers merged into one: objects' members are
+// merged recursively, and other values replace lower-precedence ones. The
+// merged value approximates what Decode composes, but doesn't honor
+// `dialsmerge` tags, since the type isn't known.
+func (s Section) MarshalJSON() ([]byte, error) {
+	var merged interface{}
+	for i, l := range s {
+		var v interface{}
+		if err := json.Unmarshal(l, &v); err != nil {
+			return nil, fmt.Errorf("invalid layer %d: %w", i, err)
+		}
+		merged = mergeJSON(merged, v)
+	}
+	return json.Marshal(merged)
+}
+
+// mergeJSON merges the generic JSON value upper over lower.
+func mergeJSON(lower, upper interface{}) interface{} {
+	lm, lok := lower.(map[string]interface{})
+	um, uok := upper.(map[string]interface{})
+	if !lok || !uok {
+		return upper
+	}
+	for k, v := range um {
+		lm[k] = mergeJSON(lm[k], v)
+	}
+	return lm
+}
+
+// Equal indicates whether s and o hold the same layers.
+func (s Section) Equal(o Section) bool {
+	if len(s) != len(o) {
+		return false
+	}
+	for i := range s {
+		if !bytes.Equal(s[i], o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sources returns a static source decoding each of s's layers.
+func (s Section) sources() []dials.Source {
+	srcs := make([]dials.Source, len(s))
+	for i, l := range s {
+		srcs[i] = &static.StringSource{Data: string(l), Decoder: &dialsjson.Decoder{}}
+	}
+	return srcs
+}
+
+// Decode decodes s into a new T, composing its layers over the defaults in
+// template (which isn't modified) like Config composes its sources (fields
+// are named by their `dials` tags, and `dialsmerge` tags are honored), and
+// verifying the result if T implements dials.VerifiedConfig.
+func Decode[T any](ctx context.Context, s Section, template *T) (*T, error) {
+	if template == nil {
+		template = new(T)
+	}
+	d, err := dials.Config(ctx, template, s.sources()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode deferred section: %w", err)
+	}
+	defer d.Close(ctx)
+	return d.View(), nil
+}
+
+// DecodeValue is like Decode, for types chosen at run time: template must be
+// a pointer to a struct holding the defaults, and the result is a pointer to
+// a new struct of the same type. Like dials.DynamicConfig, it doesn't verify
+// the result.
+func DecodeValue(ctx context.Context, s Section, template reflect.Value) (reflect.Value, error) {
+	d, err := dials.DynamicConfig(ctx, dials.Params[reflect.Value]{}, template, s.sources()...)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode deferred section: %w", err)
+	}
+	defer d.Close(ctx)
+	return *d.View(), nil
+}
+
+// Watch decodes the Section that section returns for each configuration
+// installed in d, starting with the current one, with Decode, and passes the
+// result (or the error) to cb whenever the section's contents change. Like
+// d.Subscribe, cb isn't called again for configurations installed after the
+// returned function is called.
+func Watch[T, S any](ctx context.Context, d *dials.Dials[T], section func(*T) Section, template *S, cb func(ctx context.Context, s *S, err error)) dials.UnregisterCBFunc {
+	mu := sync.Mutex{}
+	var last Section
+	first := true
+	return d.Subscribe(ctx, 1, func(ctx context.Context, _, newCfg *T) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := section(newCfg)
+		if !first && s.Equal(last) {
+			return
+		}
+		first, last = false, s
+		decoded, err := Decode(ctx, s, template)
+		cb(ctx, decoded, err)
+	})
+}
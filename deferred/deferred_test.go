@@ -0,0 +1,128 @@
+package deferred
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	dialsjson "github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/dialstest"
+	"github.com/vimeo/dials/sources/env"
+	dialsflag "github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type config struct {
+	Plugin   string  `dials:"plugin"`
+	Settings Section `dials:"settings"`
+	Replaced Section `dials:"replaced" dialsmerge:"replace"`
+}
+
+type pluginConfig struct {
+	Addr string   `dials:"addr"`
+	Pool int      `dials:"pool"`
+	Tags []string `dials:"tags" dialsmerge:"append"`
+}
+
+func (c *pluginConfig) Verify() error {
+	if c.Pool < 0 {
+		return errors.New("pool must not be negative")
+	}
+	return nil
+}
+
+func TestDecode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Setenv("SETTINGS", `{"addr": "env"}`)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags, err := dialsflag.NewSetWithFlagSet(dialsflag.DefaultFlagNameConfig(), &config{}, fs)
+	require.NoError(t, err)
+	require.NoError(t, fs.Parse(nil))
+	assert.Nil(t, fs.Lookup("settings"))
+
+	d, err := dials.Config(ctx, &config{},
+		&static.StringSource{Data: "plugin: redis\nsettings: {addr: a, pool: 2, tags: [x]}\nreplaced: {addr: r1}", Decoder: &yaml.Decoder{}},
+		&static.StringSource{Data: `{"settings": {"addr": "b", "tags": ["y"]}, "replaced": {"pool": 3}}`, Decoder: &dialsjson.Decoder{}},
+		&static.StringSource{Data: "[settings]\ntags = [\"z\"]", Decoder: &toml.Decoder{}},
+		&static.StringSource{Data: `settings: pool: 4`, Decoder: &cue.Decoder{}},
+		&env.Source{}, flags)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	cfg := d.View()
+	assert.Equal(t, "redis", cfg.Plugin)
+	assert.Len(t, cfg.Settings, 5)
+	assert.Len(t, cfg.Replaced, 1)
+
+	p, err := Decode(ctx, cfg.Settings, &pluginConfig{Addr: "default", Tags: []string{"w"}})
+	require.NoError(t, err)
+	assert.Equal(t, &pluginConfig{Addr: "env", Pool: 4, Tags: []string{"w", "x", "y", "z"}}, p)
+
+	r, err := Decode[pluginConfig](ctx, cfg.Replaced, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &pluginConfig{Pool: 3}, r)
+
+	v, err := DecodeValue(ctx, cfg.Settings, reflect.ValueOf(&pluginConfig{}))
+	require.NoError(t, err)
+	assert.Equal(t, &pluginConfig{Addr: "env", Pool: 4, Tags: []string{"x", "y", "z"}}, v.Interface())
+
+	_, err = Decode(ctx, Section{json.RawMessage(`{"pool": -1}`)}, &pluginConfig{})
+	assert.ErrorContains(t, err, "pool must not be negative")
+	_, err = Decode(ctx, Section{json.RawMessage(`{"pool": "many"}`)}, &pluginConfig{})
+	assert.ErrorContains(t, err, "failed to decode deferred section")
+}
+
+func TestSectionJSON(t *testing.T) {
+	t.Parallel()
+	s := Section{json.RawMessage(`{"a": {"b": 1, "c": [1]}, "d": 1}`), json.RawMessage(`{"a": {"c": [2]}}`)}
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a": {"b": 1, "c": [2]}, "d": 1}`, string(b))
+
+	out := Section{}
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.Len(t, out, 1)
+	assert.JSONEq(t, string(b), string(out[0]))
+	require.NoError(t, json.Unmarshal([]byte("null"), &out))
+	assert.Nil(t, out)
+
+	assert.True(t, s.Equal(Section{s[0], s[1]}))
+	assert.False(t, s.Equal(s[:1]))
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	src := dialstest.NewSource(&config{Plugin: "redis", Settings: Section{json.RawMessage(`{"addr": "a"}`)}})
+	d, err := dials.Config(ctx, &config{}, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	decoded := make(chan *pluginConfig, 3)
+	unreg := Watch(ctx, d, func(c *config) Section { return c.Settings }, &pluginConfig{Pool: 1},
+		func(_ context.Context, p *pluginConfig, err error) {
+			assert.NoError(t, err)
+			decoded <- p
+		})
+	defer unreg(ctx)
+	assert.Equal(t, &pluginConfig{Addr: "a", Pool: 1}, <-decoded)
+
+	// a change to another field doesn't decode the section again
+	require.NoError(t, src.Push(ctx, &config{Plugin: "memcache", Settings: Section{json.RawMessage(`{"addr": "a"}`)}}))
+	require.NoError(t, src.Push(ctx, &config{Plugin: "memcache", Settings: Section{json.RawMessage(`{"addr": "b"}`)}}))
+	assert.Equal(t, &pluginConfig{Addr: "b", Pool: 1}, <-decoded)
+	assert.Empty(t, decoded)
+}
@@ -2,10 +2,16 @@ package dials
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/vimeo/dials/parse"
 	"github.com/vimeo/dials/ptrify"
 )
 
@@ -29,6 +35,7 @@ type Params[T any] struct {
 	//  - One of the Sources implementing the Watcher interface reports an error
 	//  - a Verify() method fails after re-stacking when a new version is
 	//    provided by a watching source
+	//  - AcceptConfig rejects a new version
 	OnWatchedError WatchedErrorHandler[T]
 
 	// SkipInitialVerification skips the initial call to `Verify()` on any
@@ -51,6 +58,12 @@ type Params[T any] struct {
 	// may be dropped.
 	OnNewConfig NewConfigHandler[T]
 
+	// OnConfigChange is called after OnNewConfig with the fields that
+	// differ between the old and new configurations (see [Diff]), if
+	// any, so consumers can react to only the fields that changed.
+	// It's subject to the same ordering and suppression as OnNewConfig.
+	OnConfigChange ConfigChangeHandler[T]
+
 	// DelayInitialVerification skips calls to Verify() until the EnableVerification()
 	// method is called.
 	//
@@ -70,6 +83,173 @@ type Params[T any] struct {
 	//  - DelayInitialVerification was set to true when Config was called
 	//  - EnableVerification has not been called (without it returning an error)
 	CallGlobalCallbacksAfterVerificationEnabled bool
+
+	// VerificationTimeout bounds each call to Verify() (or
+	// VerifyWithContext() for configurations implementing
+	// [ContextVerifiedConfig]). Calls that run longer are treated as
+	// verification failures wrapping [ErrVerificationTimeout], so a hung
+	// Verify() cannot stall the installation of later configurations.
+	//
+	// A non-positive value (the default) disables the timeout.
+	VerificationTimeout time.Duration
+
+	// CircuitBreaker configures a circuit-breaker for each watching
+	// source, which suppresses errors and signals the source to back off
+	// after too many consecutive failures. Disabled by default.
+	// See [CircuitBreakerParams] for details.
+	CircuitBreaker CircuitBreakerParams
+
+	// OnCircuitStateChange is called when a watching source's
+	// circuit-breaker opens or closes. It runs on the same "callback"
+	// goroutine as OnWatchedError and OnNewConfig.
+	OnCircuitStateChange CircuitStateChangeHandler
+
+	// ReplayBufferSize is the number of recent configuration versions
+	// retained for replay to callbacks registered with
+	// [Dials.Subscribe]. Values less than 1 retain only the current
+	// version.
+	ReplayBufferSize int
+
+	// OnWarning is called with each non-fatal [Warning] reported by
+	// dials or a source (see [ReportWarning]). Unlike the other
+	// callbacks, it's called synchronously on the reporting goroutine
+	// (possibly concurrently), so it must not block. Warnings are also
+	// available from [Dials.Warnings].
+	OnWarning WarningHandler
+
+	// SlowSourceThreshold is the duration after which a source's Value
+	// method is reported as slow with a [WarningSlowSource] warning.
+	// A non-positive value (the default) disables the check.
+	SlowSourceThreshold time.Duration
+
+	// UpdateGroups lists groups of watching sources whose updates should
+	// be installed together (e.g. the sources for a TLS certificate and
+	// its key), so no configuration combining a new value from one member
+	// with a stale value from another is ever installed. Once a member of
+	// a group reports a new value, the value is held until every other
+	// member has also reported (or stopped watching), after which all the
+	// new values are composed at once.
+	//
+	// Each source may appear in at most one group, and every member must
+	// be passed to Config.
+	UpdateGroups [][]Source
+
+	// ConcurrentSourceFetch makes Config (and Refresh) call the Value
+	// methods of all the sources in parallel, rather than one at a time,
+	// so startup with several remote sources only waits for the slowest
+	// of them. The values are still composed in the order the sources
+	// were passed. Sources' Value methods must not depend on each other
+	// (e.g. on the order in which they're called) to enable this.
+	ConcurrentSourceFetch bool
+
+	// OptionalSources lists sources (which must also be passed to
+	// Config) whose failures don't prevent Config from succeeding: if
+	// an optional source's Value (or Watch) method fails, the
+	// configuration is composed from the other sources, as if the
+	// optional source set no fields, and a [WarningSourceFailed]
+	// warning is reported (see OnWarning). Refresh similarly keeps an
+	// optional source's previous value if it fails. By default, every
+	// source is required.
+	OptionalSources []Source
+
+	// UpdateGroupTimeout bounds how long a new value from a member of one
+	// of the UpdateGroups is held while waiting for the other members.
+	// Once it expires, the values reported so far are installed. A
+	// non-positive value (the default) waits indefinitely.
+	UpdateGroupTimeout time.Duration
+
+	// SliceMerge controls how slice values from higher-precedence sources
+	// combine with those from lower-precedence sources: by replacing them
+	// (the default) or by appending to them. Fields with a
+	// [SliceMergeTag] override it.
+	SliceMerge SliceMerge
+
+	// DetectConflicts enables reporting a [WarningConflict] warning for
+	// each field that a source sets to a different value than a
+	// lower-precedence source (e.g. an environment variable silently
+	// overriding a reviewed config file). Each conflict is reported once,
+	// when a configuration containing it is first installed. The
+	// warnings' messages include both values.
+	DetectConflicts bool
+
+	// DetectUnusedConfig enables the analysis reported by
+	// [Dials.UnusedConfig]: the fields that no source has set in any
+	// installed configuration, and the keys in sources that don't
+	// correspond to any field (reported by sources as [WarningUnknownKey]
+	// warnings, which they only look for when it's set; see
+	// [DetectingUnusedConfig]). It's meant for finding dead
+	// configuration to prune, as looking for unknown keys makes decoding
+	// slower.
+	DetectUnusedConfig bool
+
+	// AcceptConfig, if non-nil, is offered each new configuration version
+	// produced by a watching source (after verification), and only the
+	// versions it accepts are installed and published on Events. It's
+	// called on its own goroutine, so it may take time to decide (e.g.
+	// probing a new backend address) without blocking other updates. A
+	// version that's still awaiting acceptance when a newer one arrives
+	// is abandoned, and the context passed to AcceptConfig is canceled.
+	// Rejections are passed to OnWatchedError.
+	AcceptConfig ConfigAcceptor[T]
+
+	// UpdateRateLimit limits how frequently new versions from watching
+	// sources are installed and published, protecting services whose
+	// reconfiguration is expensive from a source that changes too often.
+	// Values reported while the limit is exceeded are combined into a
+	// single version once it allows. Disabled by default. See
+	// [RateLimitParams] for details.
+	UpdateRateLimit RateLimitParams
+
+	// VersionHistorySize is the number of recently installed
+	// configuration versions retained for [Dials.Rollback],
+	// [Dials.PinVersion] and [Dials.History]. Non-positive values use
+	// DefaultVersionHistorySize.
+	VersionHistorySize int
+
+	// ExplainOutput is where Config writes the explanation of the
+	// configuration (see [WriteExplanation]) when a source implementing
+	// [ExplainRequester] requests one, before returning
+	// [ErrExplainRequested]. Defaults to os.Stdout.
+	ExplainOutput io.Writer
+
+	// Metrics, if non-nil, receives measurements such as restack
+	// durations and source errors for export to a monitoring system.
+	// See [Metrics].
+	Metrics Metrics
+
+	// Tracer, if non-nil, starts spans covering Config, each call to a
+	// source's Value method, and each restack (e.g. with
+	// OpenTelemetry). See [Tracer].
+	Tracer Tracer
+
+	// Logger, if non-nil, receives log messages describing the sources
+	// being watched, watch events, restack outcomes and verification
+	// failures. A *slog.Logger may be used directly. See [Logger].
+	Logger Logger
+
+	// Redact, if non-nil, is called with the path of Go field names
+	// leading to fields of the configuration (e.g. ["DB", "Password"]),
+	// and fields for which it returns true (or that are within a struct
+	// for which it does) are redacted like those tagged with
+	// [SecretTag].
+	Redact func(fieldPath []string) bool
+
+	// AuditSink, if non-nil, records every installed configuration
+	// version, with the sources that triggered it and the (redacted)
+	// fields that changed. It's called synchronously as each version is
+	// installed, so no version goes unrecorded; failures are logged and
+	// reported as [WarningAuditFailed] warnings, but don't prevent the
+	// installation. See [AuditSink].
+	AuditSink AuditSink
+
+	// DecodeHooks are used by the decoders in the decoders package like
+	// those registered with parse.RegisterDecodeHook, but only for this
+	// configuration, taking precedence over registered hooks for the same
+	// types. Hooks for struct types are only allowed if the type is already
+	// treated as a single value (e.g. it has a registered hook, or
+	// implements encoding.TextUnmarshaler); Config returns an error
+	// otherwise.
+	DecodeHooks []parse.DecodeHook
 }
 
 // Config populates the passed in config struct by reading the values from the
@@ -90,29 +270,114 @@ type Params[T any] struct {
 //
 // More complicated verification/initialization should be done by
 // consuming from the channel returned by `Events()`.
+//
+// If any sources fail, or fields are missing or invalid (see [RequiredTag]
+// and [ValidateTag]), the returned error is a [*ConfigErrors] describing
+// every problem.
 func (p Params[T]) Config(ctx context.Context, t *T, sources ...Source) (*Dials[T], error) {
-
-	watcherChan := make(chan watchStatusUpdate)
-	computed := make([]sourceValue, len(sources))
-
 	typeOfT := reflect.TypeOf(t)
 	if typeOfT.Kind() != reflect.Ptr {
 		return nil, fmt.Errorf("config type %T is not a pointer", t)
 	}
 
-	tVal := realDeepCopy(t)
+	return p.config(ctx, realDeepCopy(t), func(v interface{}) *T {
+		cfg, _ := v.(*T)
+		return cfg
+	}, sources...)
+}
+
+// config implements Config, with the configuration type described by tVal
+// (a pointer to a struct), and each composed configuration version (a
+// pointer of the same type as tVal) converted to a *T by wrap.
+func (p Params[T]) config(
+	ctx context.Context,
+	tVal reflect.Value,
+	wrap func(interface{}) *T,
+	sources ...Source,
+) (_ *Dials[T], err error) {
+	if p.Metrics == nil {
+		p.Metrics = nopMetrics{}
+	}
+	if p.Tracer == nil {
+		p.Tracer = nopTracer{}
+	}
+	if p.Logger == nil {
+		p.Logger = nopLogger{}
+	}
+	spanCtx, endSpan := p.Tracer.Start(ctx, SpanConfig)
+	defer func() { endSpan(err) }()
+
+	if err := validateUpdateGroups(p.UpdateGroups, sources); err != nil {
+		return nil, err
+	}
+	optional, err := validateOptionalSources(p.OptionalSources, sources)
+	if err != nil {
+		return nil, err
+	}
+	if err := ptrify.CheckCycles(tVal.Type().Elem()); err != nil {
+		return nil, err
+	}
+	if err := checkTenants(tVal.Type().Elem()); err != nil {
+		return nil, err
+	}
+	if defaultErrs := applyDefaults(tVal.Elem(), nil); len(defaultErrs) > 0 {
+		return nil, &ConfigErrors{Errors: defaultErrs}
+	}
+
+	decodeHooks, err := decodeHookSet(p.DecodeHooks)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := newWarningSink(p.OnWarning)
+	if p.DetectUnusedConfig {
+		warnings.unused = newUnusedTracker()
+	}
+	warnings.decodeHooks = decodeHooks
+	ctx = context.WithValue(ctx, warningSinkCtxKey{}, warnings)
+
+	watcherChan := make(chan watchStatusUpdate)
+	computed := make([]sourceValue, len(sources))
+
+	typeOfT := tVal.Type()
 
-	valueCtx, cancelValues := context.WithCancel(ctx)
+	// the sources' Value spans are children of the Config span
+	valueCtx, cancelValues := context.WithCancel(context.WithValue(spanCtx, warningSinkCtxKey{}, warnings))
 	defer cancelValues()
 
+	// watchCtx is canceled by Close (or if Config fails)
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	closer := newCloseState(cancelWatch)
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			cancelWatch()
+		}
+	}()
+
 	typeInstance := &Type{ptrify.Pointerify(typeOfT.Elem(), tVal.Elem())}
 	someoneWatching := false
+	// collect the errors from every source, rather than just the first
+	sourceErrs := []*ConfigError{}
+	set := newSourceSet(sources)
+	statuses := set.statuses
+	fetched := p.fetchValues(valueCtx, sources, typeInstance)
 	for i, source := range sources {
 		s := source
 
-		v, err := source.Value(valueCtx, typeInstance)
+		_, isOptional := optional[source]
+		v, err := fetched[i].value, fetched[i].err
 		if err != nil {
-			return nil, err
+			p.Metrics.SourceError(source, err)
+			statuses[i].recordError(err)
+			if !isOptional {
+				sourceErrs = append(sourceErrs, sourceErrors(source, err)...)
+				continue
+			}
+			optionalSourceFailed(ctx, warnings, source, err)
+			v = emptyValue(typeInstance)
+		} else {
+			statuses[i].recordUpdate()
 		}
 		computed[i] = sourceValue{
 			source:   s,
@@ -121,35 +386,94 @@ func (p Params[T]) Config(ctx context.Context, t *T, sources ...Source) (*Dials[
 		}
 
 		if w, ok := source.(Watcher); ok {
+			sw := newWatch(watchCtx)
+			wa := p.newWatchArgs(watcherChan, source, statuses[i], sw)
+			err = w.Watch(sw.ctx, typeInstance, wa)
+			if err != nil {
+				sw.stop()
+				p.Metrics.SourceError(source, err)
+				statuses[i].recordError(err)
+				if isOptional {
+					optionalSourceFailed(ctx, warnings, source, err)
+				} else {
+					sourceErrs = append(sourceErrs, sourceErrors(source, err)...)
+				}
+				continue
+			}
 			someoneWatching = true
 			computed[i].watching = true
-			wa := watchArgs{c: watcherChan, s: source}
-			err = w.Watch(ctx, typeInstance, &wa)
-			if err != nil {
-				return nil, err
+			statuses[i].setWatching(true)
+			set.watches[source] = sw
+			p.Logger.Debug("dials: watching source", "source", sourceName(source))
+			if sw, ok := w.(StoppableWatcher); ok {
+				closer.stoppable = append(closer.stoppable, sw)
 			}
 		}
 	}
 
-	newValue, err := compose(tVal.Interface(), computed)
+	if len(sourceErrs) > 0 {
+		return nil, &ConfigErrors{Errors: sourceErrs}
+	}
+
+	newValue, err := compose(tVal.Interface(), computed, p.SliceMerge)
 	if err != nil {
 		return nil, err
 	}
 
-	nv, _ := newValue.(*T)
+	if explainRequested(sources) {
+		out := p.ExplainOutput
+		if out == nil {
+			out = os.Stdout
+		}
+		exps := explain(reflect.ValueOf(newValue), tVal, computed, p.SliceMerge, p.Redact)
+		if err := WriteExplanation(out, exps); err != nil {
+			return nil, fmt.Errorf("failed to write configuration explanation: %w", err)
+		}
+		return nil, ErrExplainRequested
+	}
+
+	nv := wrap(newValue)
 
 	d := &Dials[T]{
-		updatesChan: make(chan *T, 1),
-		params:      p,
+		params:   p,
+		wrap:     wrap,
+		warnings: warnings,
+		unused:   warnings.unused,
+		closer:   closer,
+		sources:  set,
+		typ:      typeInstance,
+		subs:     newSubscriptions[T](),
+	}
+	d.events = d.subs.add(1, DropNewest)
+	initial := &versionedConfig[T]{
+		serial: 0, cfg: nv, template: tVal.Interface(), sources: snapshotSources(computed),
+		installed: time.Now(), cause: VersionInitial,
+		unverified: p.SkipInitialVerification || p.DelayInitialVerification,
+	}
+	d.value.Store(initial)
+	d.recordVersion(initial)
+	if p.DetectConflicts {
+		d.conflicts = reportConflicts(ctx, d.Explain(), nil)
+	}
+	if d.unused != nil {
+		d.unused.recordInstalled(d.Explain())
 	}
-	d.value.Store(&versionedConfig[T]{serial: 0, cfg: nv})
 
 	// Verify that the configuration is valid if a Verify() method is present.
-	if vf, ok := newValue.(VerifiedConfig); ok && !p.SkipInitialVerification && !p.DelayInitialVerification {
-		if vfErr := vf.Verify(); vfErr != nil {
-			return nil, fmt.Errorf("initial configuration verification failed: %w", vfErr)
+	if !p.SkipInitialVerification && !p.DelayInitialVerification {
+		vfErr := checkFields(newValue, computed)
+		if vfErr == nil {
+			vfErr = verifyConfig(ctx, newValue, p.VerificationTimeout)
+		}
+		if vfErr != nil {
+			p.Metrics.VerificationFailed(vfErr)
+			p.Logger.Error("dials: initial configuration failed verification", "error", vfErr)
+			return nil, &VerificationError{Initial: true, Err: vfErr}
 		}
 	}
+	d.audit(ctx, nil, initial)
+	p.Metrics.Installed(initial.serial)
+	p.Logger.Info("dials: installed initial configuration", "sources", len(sources), "watching", someoneWatching)
 
 	// After this point, computed is owned by the monitor goroutine
 	if someoneWatching {
@@ -159,15 +483,28 @@ func (p Params[T]) Config(ctx context.Context, t *T, sources ...Source) (*Dials[
 		cbch := make(chan userCallbackEvent, 64)
 		d.cbch = cbch
 		cbmgr := callbackMgr[T]{
-			p:  &p,
-			ch: cbch,
+			p:       &p,
+			initial: nv,
+			ch:      cbch,
 		}
-		go cbmgr.runCBs(ctx)
+		closer.done = make(chan struct{})
+		go func() {
+			defer close(closer.done)
+			// runCBs returns after the monitor goroutine closes
+			// cbch on exit.
+			cbmgr.runCBs(watchCtx)
+		}()
 
 		monCtl := make(chan verifyEnable[T], 3)
 		d.monCtl = monCtl
-		go d.monitor(ctx, tVal.Interface().(*T), computed, watcherChan, monCtl)
+		versionCtl := make(chan versionReq[T])
+		d.versionCtl = versionCtl
+		set.watchCtx, set.watcherChan = watchCtx, watcherChan
+		go d.monitor(watchCtx, tVal.Interface(), computed, watcherChan, monCtl, versionCtl)
+	} else {
+		cancelWatch()
 	}
+	succeeded = true
 	return d, nil
 }
 
@@ -203,6 +540,24 @@ type Decoder interface {
 	Decode(io.Reader, *Type) (reflect.Value, error)
 }
 
+// ContextDecoder is implemented by Decoders that can make use of the context
+// passed to the Source calling them (e.g. to report warnings with
+// [ReportWarning]). Sources should decode with [Decode], which calls
+// DecodeContext when it's available.
+type ContextDecoder interface {
+	Decoder
+	DecodeContext(context.Context, io.Reader, *Type) (reflect.Value, error)
+}
+
+// Decode decodes r with dec, passing ctx along if dec implements
+// [ContextDecoder].
+func Decode(ctx context.Context, dec Decoder, r io.Reader, t *Type) (reflect.Value, error) {
+	if cd, ok := dec.(ContextDecoder); ok {
+		return cd.DecodeContext(ctx, r, t)
+	}
+	return dec.Decode(r, t)
+}
+
 type valueUpdate struct {
 	source    Source
 	value     reflect.Value
@@ -228,9 +583,37 @@ type watchStatusUpdate interface {
 	isStatusReport()
 }
 
+// newWatchArgs returns the watchArgs for the watching source s, which
+// reports to c, with its status tracked by status, and its goroutines by w.
+func (p *Params[T]) newWatchArgs(c chan watchStatusUpdate, s Source, status *sourceStatus, w *watch) *watchArgs {
+	wa := watchArgs{c: c, s: s, status: status, watch: w}
+	if p.CircuitBreaker.Threshold > 0 {
+		wa.breaker = &circuitBreaker{params: p.CircuitBreaker}
+	}
+	return &wa
+}
+
 type watchArgs struct {
 	s Source
 	c chan watchStatusUpdate
+	// breaker is nil if circuit-breaking is disabled
+	breaker *circuitBreaker
+	status  *sourceStatus
+	watch   *watch
+}
+
+// recordSuccess resets the circuit-breaker (if any), reporting a state-change
+// if it was open.
+func (w *watchArgs) recordSuccess(ctx context.Context) {
+	w.status.recordUpdate()
+	if w.breaker == nil || !w.breaker.recordSuccess() {
+		return
+	}
+	w.status.setCircuit(CircuitClosed)
+	select {
+	case <-ctx.Done():
+	case w.c <- &circuitStateReport{source: w.s, state: CircuitClosed}:
+	}
 }
 
 // ReportNewValue reports a new value. Returns an error if the internal
@@ -240,6 +623,7 @@ func (w *watchArgs) ReportNewValue(ctx context.Context, val reflect.Value) error
 	case <-ctx.Done():
 		return ctx.Err()
 	case w.c <- &valueUpdate{source: w.s, value: val}:
+		w.recordSuccess(ctx)
 		return nil
 	}
 }
@@ -266,6 +650,7 @@ func (w *watchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Valu
 		if err != nil {
 			return fmt.Errorf("stacking failed: %w", err)
 		}
+		w.recordSuccess(ctx)
 		return nil
 	case <-ctx.Done():
 		return fmt.Errorf("context expired while awaiting restack: %w", ctx.Err())
@@ -275,6 +660,7 @@ func (w *watchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Valu
 // Done indicates that this watcher has stopped and will not send any
 // more updates.
 func (w *watchArgs) Done(ctx context.Context) {
+	w.status.setWatching(false)
 	select {
 	case <-ctx.Done():
 	case w.c <- &watcherDone{source: w.s}:
@@ -283,17 +669,49 @@ func (w *watchArgs) Done(ctx context.Context) {
 
 // ReportError reports a problem in the watcher. Returns an error if
 // the internal reporting channel is full and the context
-// expires/is-canceled.
+// expires/is-canceled, or a [*CircuitOpenError] if the source's
+// circuit-breaker is open.
 func (w *watchArgs) ReportError(ctx context.Context, err error) error {
+	w.status.recordError(err)
+	var openErr *CircuitOpenError
+	opened := false
+	if w.breaker != nil {
+		suppressed := false
+		openErr, opened, suppressed = w.breaker.recordError(err)
+		if openErr != nil {
+			w.status.setCircuit(CircuitOpen)
+		}
+		if suppressed {
+			return openErr
+		}
+	}
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	case w.c <- &watchErrorReport{source: w.s, err: err}:
-		return nil
 	}
+	if opened {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case w.c <- &circuitStateReport{source: w.s, state: CircuitOpen}:
+		}
+	}
+	if openErr != nil {
+		return openErr
+	}
+	return nil
 }
 
-var _ WatchArgs = (*watchArgs)(nil)
+// Go runs f on a new goroutine that's stopped with the watch.
+func (w *watchArgs) Go(f func(ctx context.Context)) {
+	w.watch.goroutine(f)
+}
+
+var (
+	_ WatchArgs = (*watchArgs)(nil)
+	_ WatchGoer = (*watchArgs)(nil)
+)
 
 // WatchArgs provides methods for a Watcher implementation to update the state
 // of a Dials instance.
@@ -309,6 +727,9 @@ type WatchArgs interface {
 	// ReportError reports a problem in the watcher. Returns an error if
 	// the internal reporting channel is full and the context
 	// expires/is-canceled.
+	// If circuit-breaking is enabled, a [*CircuitOpenError] is returned
+	// once the source has reported too many consecutive errors, in which
+	// case the Watcher should back off until its Until time.
 	ReportError(ctx context.Context, err error) error
 
 	// BlockingReportNewValue reports a new value. Returns an error if the internal
@@ -321,12 +742,41 @@ type WatchArgs interface {
 	BlockingReportNewValue(ctx context.Context, val reflect.Value) error
 }
 
+// WatchGoer is implemented by the WatchArgs that Dials passes to Watch. It's
+// separate from WatchArgs so existing implementations of WatchArgs (e.g. test
+// fakes) needn't implement it, but WatchArgs wrappers should, forwarding to
+// WatchGo with the WatchArgs they wrap.
+type WatchGoer interface {
+	// Go runs f on a new goroutine belonging to the watch. The context
+	// passed to f (the one passed to Watch) is canceled when the watch is
+	// stopped, by [Dials.Close] or [Dials.RemoveSource], which then wait
+	// for f to return. f isn't run if the watch has already been
+	// stopped.
+	Go(f func(ctx context.Context))
+}
+
+// WatchGo runs f on a new goroutine belonging to the watch args reports to,
+// with its Go method if it implements [WatchGoer] (as the WatchArgs passed
+// to Watch by Dials do). Otherwise, f is run on a new goroutine with ctx,
+// which should be the context passed to Watch. Watchers should start their
+// goroutines with WatchGo, rather than a go statement, so they don't outlive
+// the Dials instance.
+func WatchGo(ctx context.Context, args WatchArgs, f func(ctx context.Context)) {
+	if g, ok := args.(WatchGoer); ok {
+		g.Go(f)
+		return
+	}
+	go f(ctx)
+}
+
 // Watcher should be implemented by Sources that allow their configuration to be
 // watched for changes.
 type Watcher interface {
 	// Watch will be called in the primary goroutine calling Config(). If
 	// Watcher implementations need a persistent goroutine, they should
-	// spawn it themselves.
+	// start it with WatchGo, so it's stopped
+	// deterministically. The context is canceled when the watch is
+	// stopped.
 	Watch(context.Context, *Type, WatchArgs) error
 }
 
@@ -345,6 +795,24 @@ type VerifiedConfig interface {
 type versionedConfig[T any] struct {
 	serial uint64
 	cfg    *T
+	// template is the pointer to the default values the sources were
+	// composed onto
+	template interface{}
+	// sources contains the values (in order of precedence) that were
+	// composed into cfg, for Dials.Provenance and Dials.Explain
+	sources []sourceValue
+	// installed is when the version was installed, for Dials.History
+	installed time.Time
+	cause     VersionCause
+	// triggers contains the sources whose updates triggered the version
+	triggers []Source
+	// rollbackTo is the serial of the version Dials.Rollback reinstalls
+	// (if canRollback)
+	rollbackTo  uint64
+	canRollback bool
+	// unverified is true if cfg wasn't verified (see
+	// Params.DelayInitialVerification)
+	unverified bool
 }
 
 // CfgSerial is an opaque object unique to a config-version
@@ -353,10 +821,29 @@ type CfgSerial[T any] struct {
 	cfg *T
 }
 
+// Generation returns the generation number of the configuration version
+// identified by this CfgSerial. The configuration returned by Config has
+// generation 0, and each subsequently installed configuration increments it
+// by one, so a larger generation always indicates a newer configuration from
+// the same Dials instance.
+func (c CfgSerial[T]) Generation() uint64 {
+	return c.s
+}
+
+// Version returns the generation (see [CfgSerial.Generation]) of the
+// currently installed configuration, so consumers can cheaply check whether
+// anything has changed since they last looked. Use ViewVersion to load the
+// configuration along with its version.
+func (d *Dials[T]) Version() uint64 {
+	return d.loadVersion().serial
+}
+
 // Events returns a channel that will get a message every time the configuration
-// is updated.
+// is updated. It buffers a single configuration, and drops newer ones until
+// that's received; consumers that need their own buffering should use
+// SubscribeEvents.
 func (d *Dials[T]) Events() <-chan *T {
-	return d.updatesChan
+	return d.events.Events()
 }
 
 // Fill populates the passed struct with the current value of the configuration.
@@ -426,67 +913,197 @@ func (d *Dials[T]) RegisterCallback(ctx context.Context, serial CfgSerial[T], cb
 	return tok.unregister
 }
 
+// Subscribe registers the callback cb to receive notifications whenever a new
+// configuration is installed, after first replaying up to replay of the most
+// recent versions (oldest first). A replay of 1 delivers just the currently
+// installed version, while larger values are bounded by
+// [Params].ReplayBufferSize. The replay is delivered on the callback goroutine
+// in order with other notifications, so no versions are missed between the
+// replay and subsequent notifications.
+//
+// For replayed versions, oldConfig is the preceding version, or nil for the
+// version installed by Config (or the oldest version that is no longer
+// retained).
+//
+// If there are no watching sources, callbacks are never called (the
+// configuration only changes with Refresh), so the current version is
+// delivered synchronously (if replay is positive) and the returned
+// UnregisterCBFunc is a no-op.
+//
+// May return a nil [UnregisterCBFunc] if the context expires.
+func (d *Dials[T]) Subscribe(ctx context.Context, replay int, cb NewConfigHandler[T]) UnregisterCBFunc {
+	if d.cbch == nil || d.monitorExited() {
+		if replay > 0 {
+			cb(ctx, nil, d.View())
+		}
+		return func(context.Context) bool { return true }
+	}
+	handle := userCallbackHandle[T]{cb: cb}
+	submitted := d.submitEventBlocking(ctx, &userCallbackSubscription[T]{
+		handle: &handle,
+		replay: replay,
+	})
+	if !submitted {
+		return nil
+	}
+	tok := userCallbackUnregisterToken[T]{
+		d: d,
+		h: &handle,
+	}
+	return tok.unregister
+}
+
 // returns the new value (if any)
 func (d *Dials[T]) updateSourceValue(
 	ctx context.Context,
-	t *T,
+	cache *composeCache,
+	t interface{},
 	skipVerify bool,
 	sourceValues []sourceValue,
-	watchTab *valueUpdate,
+	updates []*valueUpdate,
+	cause VersionCause,
 ) *T {
-	for i, sv := range sourceValues {
-		if watchTab.source == sv.source {
-			sourceValues[i].value = watchTab.value
-			break
-		}
+	newVers := d.candidateValue(ctx, cache, t, skipVerify, sourceValues, updates)
+	if newVers == nil {
+		return nil
 	}
-	newInterface, stackErr := compose(t, sourceValues)
+	return d.installValue(ctx, &versionedConfig[T]{
+		cfg: newVers, template: t, sources: snapshotSources(sourceValues),
+		cause: cause, triggers: updateSources(updates), unverified: skipVerify,
+	}, updates)
+}
+
+// candidateValue applies updates to sourceValues, and composes (reusing the
+// partial compositions in cache, if non-nil) and verifies the resulting
+// configuration, returning nil (after reporting the error) if that fails.
+func (d *Dials[T]) candidateValue(
+	ctx context.Context,
+	cache *composeCache,
+	t interface{},
+	skipVerify bool,
+	sourceValues []sourceValue,
+	updates []*valueUpdate,
+) *T {
+	cache.apply(sourceValues, updates)
+	newInterface, stackErr, vfErr := d.restack(ctx, cache, t, skipVerify, sourceValues)
 	if stackErr != nil {
 		oldVal := d.View()
-		newVal, _ := newInterface.(*T)
+		newVal := (*T)(nil)
+		if newInterface != nil {
+			newVal = d.wrap(newInterface)
+		}
 		d.submitEvent(ctx, &watchErrorEvent[T]{
 			err: stackErr, oldConfig: oldVal, newConfig: newVal,
 		})
-		if watchTab.installed != nil {
-			watchTab.installed <- stackErr
-		}
+		notifyInstalled(updates, stackErr)
 		return nil
 	}
 
-	// Verify that the configuration is valid if a Verify() method is present.
-	if vf, ok := newInterface.(VerifiedConfig); ok && !skipVerify {
-		if vfErr := vf.Verify(); vfErr != nil {
-			oldVal := d.View()
+	if vfErr != nil {
+		oldVal := d.View()
 
-			newVal := newInterface.(*T)
+		newVal := d.wrap(newInterface)
 
-			d.submitEvent(ctx, &watchErrorEvent[T]{
-				err: vfErr, oldConfig: oldVal, newConfig: newVal,
-			})
+		d.submitEvent(ctx, &watchErrorEvent[T]{
+			err: vfErr, oldConfig: oldVal, newConfig: newVal,
+		})
 
-			if watchTab.installed != nil {
-				watchTab.installed <- vfErr
-			}
-			return nil
+		notifyInstalled(updates, vfErr)
+		return nil
+	}
+
+	return d.wrap(newInterface)
+}
+
+// restack composes a configuration from sourceValues (using cache, if
+// non-nil) and (unless skipVerify is set) verifies it within a SpanRestack
+// span, reporting the outcome to Params.Metrics. stackErr is set if
+// composing fails, and vfErr if verification does.
+func (d *Dials[T]) restack(
+	ctx context.Context,
+	cache *composeCache,
+	t interface{},
+	skipVerify bool,
+	sourceValues []sourceValue,
+) (newInterface interface{}, stackErr, vfErr error) {
+	ctx, endSpan := d.params.Tracer.Start(ctx, SpanRestack)
+	start := time.Now()
+	if cache != nil {
+		newInterface, stackErr = cache.compose(t, sourceValues)
+	} else {
+		newInterface, stackErr = compose(t, sourceValues, d.params.SliceMerge)
+	}
+	d.params.Metrics.Restacked(time.Since(start), stackErr)
+	if stackErr != nil {
+		d.params.Logger.Error("dials: failed to compose configuration", "error", stackErr)
+		endSpan(stackErr)
+		return newInterface, stackErr, nil
+	}
+
+	// Verify that the configuration is valid if a Verify() method is present.
+	if !skipVerify {
+		vfErr = checkFields(newInterface, sourceValues)
+		if vfErr == nil {
+			vfErr = verifyConfig(ctx, newInterface, d.params.VerificationTimeout)
+		}
+		if vfErr != nil {
+			vfErr = &VerificationError{Err: vfErr}
+			d.params.Metrics.VerificationFailed(vfErr)
+			d.params.Logger.Warn("dials: configuration failed verification", "error", vfErr)
 		}
 	}
+	endSpan(vfErr)
+	return newInterface, nil, vfErr
+}
 
-	newVers := newInterface.(*T)
+// applyUpdates sets the values of sourceValues from updates.
+func applyUpdates(sourceValues []sourceValue, updates []*valueUpdate) {
+	// apply the updates in the order they were reported, so later values
+	// from the same source win.
+	for _, u := range updates {
+		for i, sv := range sourceValues {
+			if u.source == sv.source {
+				sourceValues[i].value = u.value
+				break
+			}
+		}
+	}
+}
 
-	_, oldSerial := d.ViewVersion()
+// installValue installs vc (with its serial, installation time and rollback
+// target filled in) as the current configuration, notifying the senders of
+// updates.
+func (d *Dials[T]) installValue(
+	ctx context.Context,
+	vc *versionedConfig[T],
+	updates []*valueUpdate,
+) *T {
+	old := d.loadVersion()
+	vc.serial = old.serial + 1
+	vc.installed = time.Now()
+	if vc.cause != VersionRollback {
+		// rolling back from this version returns to the one it replaced
+		vc.rollbackTo, vc.canRollback = old.serial, true
+	}
 
 	// We can do a blind-store here because this goroutine (monitor()) has
 	// exclusive ownership of writes to this atomic-value
-	d.value.Store(&versionedConfig[T]{serial: oldSerial.s + 1, cfg: newVers})
-	select {
-	case d.updatesChan <- newVers:
-	default:
+	d.value.Store(vc)
+	d.recordVersion(vc)
+	d.audit(ctx, old.cfg, vc)
+	d.params.Metrics.Installed(vc.serial)
+	d.params.Logger.Info("dials: installed configuration", "generation", vc.serial, "cause", vc.cause.String())
+	if d.params.DetectConflicts {
+		d.conflicts = reportConflicts(ctx, d.Explain(), d.conflicts)
 	}
-
-	// If there's an installed channel, poke it.
-	if watchTab.installed != nil {
-		watchTab.installed <- nil
+	if d.unused != nil {
+		d.unused.recordInstalled(d.Explain())
 	}
+	newVers := vc.cfg
+	d.subs.publish(vc)
+
+	// Poke the installed channels of any blocking reports.
+	notifyInstalled(updates, nil)
 
 	return newVers
 }
@@ -517,7 +1134,14 @@ func (d *Dials[T]) markSourceDone(
 
 func (d *Dials[T]) submitEventBlocking(ctx context.Context, ev userCallbackEvent) bool {
 	// don't panic
-	if d.cbch == nil {
+	if d.cbch == nil || d.closer.isClosing() {
+		return false
+	}
+	// the monitor goroutine closes cbch when it exits, which it may do
+	// before Close is called
+	d.cbMu.RLock()
+	defer d.cbMu.RUnlock()
+	if d.cbClosed {
 		return false
 	}
 	select {
@@ -578,12 +1202,8 @@ func (d *Dials[T]) EnableVerification(ctx context.Context) (*T, CfgSerial[T], er
 		// this is a noop, since we never disabled verification
 		cfg, tok := d.ViewVersion()
 		return cfg, tok, nil
-	} else if d.monCtl == nil {
-		cfg, tok := d.ViewVersion()
-		if vc, ok := any(cfg).(VerifiedConfig); ok {
-			return nil, CfgSerial[T]{}, vc.Verify()
-		}
-		return cfg, tok, nil
+	} else if d.monCtl == nil || d.monitorExited() {
+		return d.enableVerificationUnwatched(ctx)
 	}
 	// must have capacity 1
 	resp := make(chan verifyEnableResp[T], 1)
@@ -596,25 +1216,55 @@ func (d *Dials[T]) EnableVerification(ctx context.Context) (*T, CfgSerial[T], er
 	select {
 	case r := <-resp:
 		return r.v, r.tok, r.err
+	case <-d.closer.done:
+		// the monitor may have responded before exiting
+		select {
+		case r := <-resp:
+			return r.v, r.tok, r.err
+		default:
+		}
+		if d.closer.isClosing() {
+			return nil, CfgSerial[T]{}, ErrClosed
+		}
+		return d.enableVerificationUnwatched(ctx)
 	case <-ctx.Done():
 		return nil, CfgSerial[T]{}, fmt.Errorf("context expired while awaiting response: %w", ctx.Err())
 	}
 
 }
 
-func (d *Dials[T]) monitorEnableVerify(ve verifyEnable[T]) bool {
-	vt, serial := d.ViewVersion()
-	if vf, ok := any(vt).(VerifiedConfig); ok {
-		if vfErr := vf.Verify(); vfErr != nil {
-			ve.resp <- verifyEnableResp[T]{
-				err: vfErr,
-				v:   nil,
-				tok: CfgSerial[T]{},
-			}
+// enableVerificationUnwatched implements EnableVerification when there's no
+// monitor goroutine.
+func (d *Dials[T]) enableVerificationUnwatched(ctx context.Context) (*T, CfgSerial[T], error) {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	cfg, tok := d.ViewVersion()
+	if err := d.verifyInstalled(ctx); err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	atomic.StoreUint32(&d.verifyEnabled, 1)
+	return cfg, tok, nil
+}
 
-			return false
+// verificationDelayed returns true if Verify() is still being skipped
+// because of Params.DelayInitialVerification, i.e. EnableVerification
+// hasn't succeeded yet.
+func (d *Dials[T]) verificationDelayed() bool {
+	return d.params.DelayInitialVerification && atomic.LoadUint32(&d.verifyEnabled) == 0
+}
+
+func (d *Dials[T]) monitorEnableVerify(ctx context.Context, ve verifyEnable[T]) bool {
+	vt, serial := d.ViewVersion()
+	if vfErr := d.verifyInstalled(ctx); vfErr != nil {
+		ve.resp <- verifyEnableResp[T]{
+			err: vfErr,
+			v:   nil,
+			tok: CfgSerial[T]{},
 		}
+
+		return false
 	}
+	atomic.StoreUint32(&d.verifyEnabled, 1)
 	ve.resp <- verifyEnableResp[T]{
 		err: nil,
 		v:   vt,
@@ -625,13 +1275,68 @@ func (d *Dials[T]) monitorEnableVerify(ve verifyEnable[T]) bool {
 
 func (d *Dials[T]) monitor(
 	ctx context.Context,
-	t *T,
+	t interface{},
 	sourceValues []sourceValue,
 	watcherChan chan watchStatusUpdate,
 	monCtl <-chan verifyEnable[T],
+	versionCtl <-chan versionReq[T],
 ) {
-	defer close(d.cbch)
+	defer func() {
+		d.cbMu.Lock()
+		defer d.cbMu.Unlock()
+		d.cbClosed = true
+		close(d.cbch)
+	}()
 	skipVerify := d.params.DelayInitialVerification
+	groups := newUpdateGroups(d.params.UpdateGroups, sourceValues)
+	cache := newComposeCache(d.params.SliceMerge)
+	installed := func(oldConfig *T, oldSerial CfgSerial[T], newConfig *T) {
+		if newConfig != nil {
+			d.submitEvent(ctx, &newConfigEvent[T]{
+				oldConfig: oldConfig,
+				newConfig: newConfig,
+				serial:    oldSerial.s + 1,
+				globalCBsSuppressed: skipVerify &&
+					d.params.CallGlobalCallbacksAfterVerificationEnabled,
+			})
+		}
+	}
+	// pending is the version awaiting acceptance (if AcceptConfig is set)
+	var pending *pendingConfig[T]
+	// pinned is true while a version is pinned by PinVersion
+	pinned := false
+	install := func(updates []*valueUpdate, cause VersionCause) {
+		if pinned {
+			cache.apply(sourceValues, updates)
+			notifyInstalled(updates, ErrVersionPinned)
+			return
+		}
+		if d.params.AcceptConfig == nil {
+			oldConfig, oldSerial := d.ViewVersion()
+			installed(oldConfig, oldSerial, d.updateSourceValue(ctx, cache, t, skipVerify, sourceValues, updates, cause))
+			return
+		}
+		newConfig := d.candidateValue(ctx, cache, t, skipVerify, sourceValues, updates)
+		if newConfig == nil {
+			return
+		}
+		if pending != nil {
+			pending.supersede()
+		}
+		pending = d.propose(ctx, newConfig, snapshotSources(sourceValues), updates, cause, skipVerify, watcherChan)
+	}
+	limiter := newUpdateLimiter(d.params.UpdateRateLimit)
+	// installLimited installs updates from watching sources, subject to
+	// Params.UpdateRateLimit
+	installLimited := func(updates []*valueUpdate) {
+		if limiter == nil {
+			install(updates, VersionUpdate)
+			return
+		}
+		if held := limiter.add(ctx, updates, watcherChan); len(held) > 0 {
+			install(held, VersionUpdate)
+		}
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -648,22 +1353,102 @@ func (d *Dials[T]) monitor(
 				}
 				continue
 			}
-			skipVerify = !d.monitorEnableVerify(v)
+			skipVerify = !d.monitorEnableVerify(ctx, v)
+		case req := <-versionCtl:
+			if req.kind == versionRefresh {
+				install(req.updates, VersionRefresh)
+				req.resp <- versionResp[T]{}
+				continue
+			}
+			if req.kind == versionEditSources {
+				edited, changed, err := req.edit(snapshotSources(sourceValues))
+				if err != nil || !changed || req.noInstall {
+					if err == nil && changed {
+						sourceValues = edited
+						cache = newComposeCache(d.params.SliceMerge)
+					}
+					req.installed <- nil
+					req.resp <- versionResp[T]{err: err}
+					continue
+				}
+				sourceValues = edited
+				// the sources' positions may have changed
+				cache = newComposeCache(d.params.SliceMerge)
+				install(editUpdates(sourceValues, req.triggers, req.installed), req.cause)
+				req.resp <- versionResp[T]{}
+				continue
+			}
+			if req.kind == versionUnpin {
+				if pinned {
+					pinned = false
+					install(nil, VersionUpdate)
+				}
+				req.resp <- versionResp[T]{}
+				continue
+			}
+			vc, err := d.retainedVersion(req)
+			if err != nil {
+				req.resp <- versionResp[T]{err: err}
+				continue
+			}
+			if pending != nil {
+				pending.supersede()
+				pending = nil
+			}
+			pinned = req.kind == versionPin
+			oldConfig, oldSerial := d.ViewVersion()
+			installed(oldConfig, oldSerial, d.installValue(ctx, reinstallation(vc, pinned), nil))
+			cfg, tok := d.ViewVersion()
+			req.resp <- versionResp[T]{v: cfg, tok: tok}
 		case watchTab := <-watcherChan:
 			switch v := watchTab.(type) {
 			case *valueUpdate:
+				if sourceIndex(sourceValues, v.source) < 0 {
+					// removed by RemoveSource
+					notifyInstalled([]*valueUpdate{v}, ErrUnknownSource)
+					continue
+				}
+				d.params.Logger.Debug("dials: new value reported", "source", sourceName(v.source))
+				g, grouped := groups[v.source]
+				if !grouped {
+					installLimited([]*valueUpdate{v})
+					continue
+				}
+				if g.add(v) {
+					installLimited(g.flush())
+				} else {
+					g.startTimer(ctx, d.params.UpdateGroupTimeout, watcherChan)
+				}
+			case *acceptResult[T]:
+				if v.p != pending {
+					// superseded by a newer version
+					continue
+				}
+				pending = nil
+				if v.err != nil {
+					d.rejected(ctx, v.p, v.err)
+					continue
+				}
 				oldConfig, oldSerial := d.ViewVersion()
-				newConfig := d.updateSourceValue(ctx, t, skipVerify, sourceValues, v)
-				if newConfig != nil {
-					d.submitEvent(ctx, &newConfigEvent[T]{
-						oldConfig: oldConfig,
-						newConfig: newConfig,
-						serial:    oldSerial.s + 1,
-						globalCBsSuppressed: skipVerify &&
-							d.params.CallGlobalCallbacksAfterVerificationEnabled,
-					})
+				installed(oldConfig, oldSerial, d.installValue(ctx, &versionedConfig[T]{
+					cfg: v.p.cfg, template: t, sources: v.p.sources,
+					cause: v.p.cause, triggers: updateSources(v.p.updates),
+					unverified: v.p.unverified,
+				}, v.p.updates))
+			case *rateLimitTick:
+				if held := limiter.tick(ctx, watcherChan); len(held) > 0 {
+					install(held, VersionUpdate)
+				}
+			case *updateGroupTimeout:
+				if v.gen == v.g.gen && len(v.g.pending) > 0 {
+					installLimited(v.g.flush())
 				}
 			case *watchErrorReport:
+				if sourceIndex(sourceValues, v.source) < 0 {
+					continue
+				}
+				d.params.Metrics.SourceError(v.source, v.err)
+				d.params.Logger.Warn("dials: watching source reported an error", "source", sourceName(v.source), "error", v.err)
 				if !skipVerify && !d.params.CallGlobalCallbacksAfterVerificationEnabled {
 					d.submitEvent(ctx, &watchErrorEvent[T]{
 						err: fmt.Errorf("error reported by source of type %T: %w",
@@ -672,7 +1457,16 @@ func (d *Dials[T]) monitor(
 						newConfig: nil,
 					})
 				}
+			case *circuitStateReport:
+				d.submitEvent(ctx, &circuitStateEvent{source: v.source, state: v.state})
 			case *watcherDone:
+				if sourceIndex(sourceValues, v.source) < 0 {
+					continue
+				}
+				d.params.Logger.Debug("dials: source stopped watching", "source", sourceName(v.source))
+				if g, grouped := groups[v.source]; grouped && g.markDone(v.source) {
+					installLimited(g.flush())
+				}
 				if !d.markSourceDone(ctx, sourceValues, v) {
 					// if there are no watching sources, just exit.
 					return
@@ -684,30 +1478,60 @@ func (d *Dials[T]) monitor(
 	}
 }
 
-func compose(t interface{}, sources []sourceValue) (interface{}, error) {
+func compose(t interface{}, sources []sourceValue, sliceMerge SliceMerge) (interface{}, error) {
 	copyValuePtr := realDeepCopy(t)
 	value := copyValuePtr.Elem()
 	for _, source := range sources {
-		// automatically dereference pointers that may be in the value
-		s := source.value
-		if s.Kind() == reflect.Ptr {
-			s = s.Elem()
-		}
-		o := newOverlayer()
-		sv := o.dc.deepCopyValue(s)
-		if overlayErr := o.overlayStruct(value, sv); overlayErr != nil {
+		if overlayErr := overlaySource(value, source, sliceMerge); overlayErr != nil {
 			return nil, overlayErr
 		}
-
 	}
 
 	return value.Addr().Interface(), nil
 }
 
+// overlaySource overlays the value of source (unless it's disabled) onto
+// value. The parts of the source's value that are used are copied, so they're
+// never shared with the composed configuration.
+func overlaySource(value reflect.Value, source sourceValue, sliceMerge SliceMerge) error {
+	if source.disabled {
+		return nil
+	}
+	// automatically dereference pointers that may be in the value
+	s := source.value
+	if s.Kind() == reflect.Ptr {
+		s = s.Elem()
+	}
+	if g := lookupGenerated(value.Type()); g != nil {
+		if ok, err := g.overlay(value, s, sliceMerge); ok {
+			return newComposeError(source.source, err)
+		}
+	}
+	o := newOverlayer()
+	o.sliceMerge = sliceMerge
+	return newComposeError(source.source, o.overlayStruct(value, s))
+}
+
+// newComposeError wraps err, from overlaying the value of source, in a
+// ComposeError, or returns nil if err is nil.
+func newComposeError(source Source, err error) error {
+	if err == nil {
+		return nil
+	}
+	ce := &ComposeError{Source: source, Err: err}
+	var fpe FieldPathError
+	if errors.As(err, &fpe) {
+		ce.Path = strings.Join(fpe.FieldPath(), ".")
+	}
+	return ce
+}
+
 type sourceValue struct {
 	source   Source
 	value    reflect.Value
 	watching bool
+	// disabled is set by Dials.DisableSource
+	disabled bool
 }
 
 // Type is a wrapper for a reflect.Type.
@@ -3,16 +3,66 @@
 package dials
 
 import (
+	"sync"
 	"sync/atomic"
 )
 
 // Dials is the main access point for your configuration.
 type Dials[T any] struct {
-	value       atomic.Value
-	updatesChan chan *T
-	params      Params[T]
-	cbch        chan<- userCallbackEvent
-	monCtl      chan<- verifyEnable[T]
+	value      atomic.Value
+	params     Params[T]
+	cbch       chan<- userCallbackEvent
+	monCtl     chan<- verifyEnable[T]
+	versionCtl chan<- versionReq[T]
+	// wrap converts a composed configuration to a *T
+	wrap func(interface{}) *T
+	// warnings receives the warnings reported while populating the
+	// configuration
+	warnings *warningSink
+	// closer tracks the state torn down by Close
+	closer *closeState
+	// conflicts contains the conflicts reported for the installed
+	// configuration if Params.DetectConflicts is set. It's owned by the
+	// monitor goroutine.
+	conflicts map[string]struct{}
+	// unused accumulates the analysis reported by UnusedConfig, if
+	// Params.DetectUnusedConfig is set.
+	unused *unusedTracker
+	// history contains the retained versions for Rollback, PinVersion
+	// and History, oldest first. It's only modified by the monitor
+	// goroutine (or Config, before it starts).
+	historyMu sync.Mutex
+	history   []*versionedConfig[T]
+	// sources holds the sources (initially those passed to Config),
+	// for Refresh, SourceStatus and AddSource, and typ is the Type
+	// passed to them
+	sources *sourceSet
+	typ     *Type
+	// subs contains the Subscriptions that receive installed
+	// configurations, including events (backing Events)
+	subs   *subscriptions[T]
+	events *Subscription[T]
+	// refreshMu serializes Refresh calls (and Close) when there's no
+	// monitor goroutine
+	refreshMu sync.Mutex
+	// cbMu guards sends on cbch from outside the monitor goroutine,
+	// which closes it (setting cbClosed) when it exits
+	cbMu     sync.RWMutex
+	cbClosed bool
+	// verifyEnabled is set to 1 (atomically) once EnableVerification
+	// succeeds, so versions installed without the monitor goroutine are
+	// verified from then on (see verificationDelayed)
+	verifyEnabled uint32
+	// tenants caches the views returned by ForTenant
+	tenants tenantViews[T]
+}
+
+// loadVersion returns the currently installed configuration version.
+func (d *Dials[T]) loadVersion() *versionedConfig[T] {
+	v, _ := d.value.Load().(*versionedConfig[T])
+	// v cannot be nil because we initialize this value immediately after
+	// creating the the Dials object
+	return v
 }
 
 // View returns the configuration struct populated.
@@ -23,7 +73,17 @@ func (d *Dials[T]) View() *T {
 	return v.cfg
 }
 
-// View returns the configuration struct populated, and an opaque token.
+// ViewVersion returns the configuration struct populated, and an opaque token
+// identifying that version. The token's Generation method returns a counter
+// that increases with every installed configuration.
+//
+// The configuration and token are loaded atomically, so the generation always
+// describes the returned configuration. Configurations are fully constructed
+// before they're installed, and the installation happens-before any
+// ViewVersion (or View) call that observes it, so the returned configuration
+// may be read without further synchronization. Consumers that must not mix
+// configuration versions within an operation can compare the generation at
+// the start of the operation with a later call to ViewVersion.
 func (d *Dials[T]) ViewVersion() (*T, CfgSerial[T]) {
 	v, _ := d.value.Load().(*versionedConfig[T])
 	// v cannot be nil because we initialize this value immediately after
@@ -0,0 +1,105 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// AsSource returns a Source (implementing Watcher) that provides d's current
+// configuration, and reports every configuration d subsequently installs.
+// This allows layering Dials instances: e.g. a platform library can own a
+// base configuration, on top of which an application stacks its own sources
+// by passing the returned Source (usually first) to Config.
+//
+// Since d's configuration is complete, every field it provides overrides
+// lower-precedence sources, except for nil maps, slices, pointers and
+// interfaces.
+//
+// The returned Source may be used with multiple calls to Config.
+func (d *Dials[T]) AsSource() Source {
+	return &dialsSource[T]{d: d}
+}
+
+type dialsSource[T any] struct {
+	d *Dials[T]
+
+	mu sync.Mutex
+	// serials records the version returned by the last call to Value
+	// with each Type, so Watch can catch up on anything installed in
+	// between.
+	serials map[*Type]CfgSerial[T]
+	// registered is the number of callbacks registered with d by watches
+	// that haven't stopped
+	registered int
+}
+
+func (s *dialsSource[T]) Value(_ context.Context, t *Type) (reflect.Value, error) {
+	cfg, serial := s.d.ViewVersion()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.serials == nil {
+		s.serials = map[*Type]CfgSerial[T]{}
+	}
+	s.serials[t] = serial
+	return ptrify.PointerifyValue(t.Type(), reflect.ValueOf(cfg))
+}
+
+func (s *dialsSource[T]) Watch(ctx context.Context, t *Type, args WatchArgs) error {
+	s.mu.Lock()
+	serial := s.serials[t]
+	delete(s.serials, t)
+	s.mu.Unlock()
+
+	unreg := s.d.RegisterCallback(ctx, serial, func(cbCtx context.Context, _, newCfg *T) {
+		if ctx.Err() != nil {
+			return
+		}
+		v, err := ptrify.PointerifyValue(t.Type(), reflect.ValueOf(newCfg))
+		if err != nil {
+			args.ReportError(ctx, err)
+			return
+		}
+		args.ReportNewValue(ctx, v)
+	})
+	if unreg == nil {
+		// d has no watching sources, so its configuration will never
+		// change. (Watch is called before the monitor goroutine
+		// starts, so Done must be called on another goroutine)
+		WatchGo(ctx, args, args.Done)
+		return nil
+	}
+	s.mu.Lock()
+	s.registered++
+	s.mu.Unlock()
+	// unregister the callback once the watch stops, so d doesn't keep
+	// calling it for the rest of its life
+	WatchGo(ctx, args, func(ctx context.Context) {
+		<-ctx.Done()
+		s.unregister(unreg)
+	})
+	return nil
+}
+
+// unregister calls unreg, giving up if d is closed (or its callback
+// goroutine exits) first, in which case the callback won't be called again
+// anyway.
+func (s *dialsSource[T]) unregister(unreg UnregisterCBFunc) {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.registered--
+	}()
+	unregCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.d.closer.done:
+			cancel()
+		case <-unregCtx.Done():
+		}
+	}()
+	unreg(unregCtx)
+}
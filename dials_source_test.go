@@ -0,0 +1,98 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialsAsSource(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Base string
+		App  string
+		Tags []string
+	}
+	type ptrifiedConfig struct {
+		Base *string
+		App  *string
+		Tags []string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	platformSrc := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	platform, err := Config(ctx, &testConfig{Base: "platform", Tags: []string{"p"}}, &platformSrc)
+	require.NoError(t, err)
+
+	appStr := "app"
+	appSrc := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{App: &appStr}}}
+	app, err := Config(ctx, &testConfig{}, platform.AsSource(), &appSrc)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Base: "platform", App: "app", Tags: []string{"p"}}, app.View())
+
+	// updates to the platform configuration propagate, with the
+	// application's sources still layered on top
+	newBase := "updated"
+	platformSrc.send(ctx, reflect.ValueOf(ptrifiedConfig{Base: &newBase}))
+	assert.Equal(t, &testConfig{Base: "updated", App: "app", Tags: []string{"p"}}, <-app.Events())
+}
+
+func TestDialsAsSourceStatic(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Base string
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	platform, err := Config(ctx, &testConfig{Base: "platform"})
+	require.NoError(t, err)
+	app, err := Config(ctx, &testConfig{}, platform.AsSource())
+	require.NoError(t, err)
+	assert.Equal(t, "platform", app.View().Base)
+}
+
+func TestDialsAsSourceUnregisters(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Base string
+	}
+	type ptrifiedConfig struct {
+		Base *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	platformSrc := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	platform, err := Config(ctx, &testConfig{Base: "platform"}, &platformSrc)
+	require.NoError(t, err)
+	defer platform.Close(ctx)
+
+	src := platform.AsSource().(*dialsSource[testConfig])
+	registered := func() int {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return src.registered
+	}
+	app1, err := Config(ctx, &testConfig{}, src)
+	require.NoError(t, err)
+	app2, err := Config(ctx, &testConfig{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, 2, registered())
+
+	// Close waits for the goroutine unregistering the callback
+	require.NoError(t, app1.Close(ctx))
+	assert.Equal(t, 1, registered())
+	require.NoError(t, app2.Close(ctx))
+	assert.Zero(t, registered())
+
+	src.mu.Lock()
+	assert.Empty(t, src.serials)
+	src.mu.Unlock()
+}
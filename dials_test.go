@@ -372,7 +372,7 @@ func TestConfigWithDelayInitialVerifyFailWatchlobalCBSuppress(t *testing.T) {
 	))
 	select {
 	case e := <-errCBCh:
-		if e != errFailVerifier {
+		if !errors.Is(e, errFailVerifier) {
 			t.Errorf("unexpected error on invalid config: %s", e)
 		}
 	case c := <-newCfg:
@@ -964,3 +964,131 @@ func ExampleDials_RegisterCallback() {
 	// Output:
 	// Foo: foozle
 }
+
+func TestSubscribeReplay(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+
+	// setup a cancelable context so the monitor goroutine gets shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newConf := make(chan *testConfig)
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	p := Params[testConfig]{
+		OnNewConfig: func(ctx context.Context, oldConfig, newConfig *testConfig) {
+			newConf <- newConfig
+		},
+		ReplayBufferSize: 3,
+	}
+	d, err := p.Config(ctx, &testConfig{Foo: "foo"}, &w)
+	require.NoError(t, err)
+
+	// Subscribing before any updates replays just the initial version
+	type transition struct{ old, new string }
+	fooOf := func(c *testConfig) string {
+		if c == nil {
+			return "<nil>"
+		}
+		return c.Foo
+	}
+	initCh := make(chan transition, 4)
+	unregInit := d.Subscribe(ctx, 5, func(_ context.Context, oldCfg, newCfg *testConfig) {
+		initCh <- transition{fooOf(oldCfg), fooOf(newCfg)}
+	})
+	require.NotNil(t, unregInit)
+	assert.Equal(t, transition{"<nil>", "foo"}, <-initCh)
+	assert.True(t, unregInit(ctx))
+
+	for _, v := range []string{"a", "b", "c"} {
+		v := v
+		w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &v}))
+		assert.Equal(t, v, (<-newConf).Foo)
+	}
+
+	// Only the 3 most recent versions are retained
+	allCh := make(chan transition, 8)
+	unregAll := d.Subscribe(ctx, 5, func(_ context.Context, oldCfg, newCfg *testConfig) {
+		allCh <- transition{fooOf(oldCfg), fooOf(newCfg)}
+	})
+	require.NotNil(t, unregAll)
+	lastCh := make(chan transition, 8)
+	unregLast := d.Subscribe(ctx, 1, func(_ context.Context, oldCfg, newCfg *testConfig) {
+		lastCh <- transition{fooOf(oldCfg), fooOf(newCfg)}
+	})
+	require.NotNil(t, unregLast)
+
+	dStr := "d"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &dStr}))
+	assert.Equal(t, "d", (<-newConf).Foo)
+
+	assert.True(t, unregAll(ctx))
+	assert.True(t, unregLast(ctx))
+	close(allCh)
+	close(lastCh)
+
+	all := []transition{}
+	for tr := range allCh {
+		all = append(all, tr)
+	}
+	assert.Equal(t, []transition{{"<nil>", "a"}, {"a", "b"}, {"b", "c"}, {"c", "d"}}, all)
+	last := []transition{}
+	for tr := range lastCh {
+		last = append(last, tr)
+	}
+	assert.Equal(t, []transition{{"b", "c"}, {"c", "d"}}, last)
+}
+
+func TestSubscribeNoWatchers(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	d, err := Config(context.Background(), &testConfig{Foo: "foo"})
+	require.NoError(t, err)
+
+	var got *testConfig
+	unreg := d.Subscribe(context.Background(), 1, func(_ context.Context, _, newCfg *testConfig) {
+		got = newCfg
+	})
+	assert.Equal(t, "foo", got.Foo)
+	assert.True(t, unreg(context.Background()))
+}
+
+func TestViewVersionGeneration(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+
+	// setup a cancelable context so the monitor goroutine gets shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{Foo: "foo"}, &w)
+	require.NoError(t, err)
+
+	cfg, serial := d.ViewVersion()
+	assert.Equal(t, "foo", cfg.Foo)
+	assert.Equal(t, uint64(0), serial.Generation())
+
+	for i, v := range []string{"a", "b"} {
+		v := v
+		w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &v}))
+		<-d.Events()
+		cfg, serial = d.ViewVersion()
+		assert.Equal(t, v, cfg.Foo)
+		assert.Equal(t, uint64(i+1), serial.Generation())
+	}
+}
@@ -0,0 +1,317 @@
+// Package dialscli implements the subcommands of the dials command (see
+// cmd/dials) for a particular config type, so config files can be checked in
+// CI pipelines, and operators can see the configuration a set of sources
+// produces, without starting the program that uses it.
+//
+// Programs may also call Run themselves (e.g. from a hidden "config"
+// subcommand), which avoids the dials command's need to build a program
+// importing the config type.
+package dialscli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/codegen"
+	"github.com/vimeo/dials/docs"
+	"github.com/vimeo/dials/ez"
+	"github.com/vimeo/dials/jsonschema"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/file"
+)
+
+// Usage describes the subcommands accepted by Run.
+const Usage = `usage: <command> [flags]
+
+commands:
+  validate -file path... [-env] [-env-prefix prefix]
+        compose the files (and environment variables), in order of increasing
+        precedence, and verify the resulting configuration
+  print [-file path]... [-env] [-env-prefix prefix] [-explain]
+        print the configuration composed from the sources, with secrets
+        redacted; -explain shows the source of each field's value
+  unused [-file path]... [-env] [-env-prefix prefix]
+        list the fields none of the sources set, and the keys in the files
+        (and variables with the prefix) that don't correspond to any field
+  schema [-format json|yaml|toml]
+        print the JSON Schema for config files of the given format
+  docs [-format markdown|html] [-file-format json|yaml|toml] [-env-prefix prefix]
+        print a table documenting the config fields
+  generate [-o path]
+        print (or write to path) the code replacing the reflective composition
+        of the config type's values (see the codegen package)
+`
+
+// Run runs the subcommand in args (e.g. []string{"validate", "-file",
+// "config.yaml"}) for the config type T, whose defaults are in template (which
+// isn't modified). Results are written to stdout, and warnings and usage
+// messages to stderr. File decoders are chosen by the files' extensions (see
+// ez.DecoderFromExtension).
+func Run[T any](ctx context.Context, template *T, args []string, stdout, stderr io.Writer) error {
+	if template == nil {
+		template = new(T)
+	}
+	if len(args) == 0 {
+		io.WriteString(stderr, Usage)
+		return errors.New("no command specified")
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "validate":
+		return runValidate(ctx, template, args, stdout, stderr)
+	case "print":
+		return runPrint(ctx, template, args, stdout, stderr)
+	case "unused":
+		return runUnused(ctx, template, args, stdout, stderr)
+	case "schema":
+		return runSchema(template, args, stdout, stderr)
+	case "docs":
+		return runDocs(template, args, stdout, stderr)
+	case "generate":
+		return runGenerate(template, args, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		_, err := io.WriteString(stdout, Usage)
+		return err
+	default:
+		io.WriteString(stderr, Usage)
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// stringsFlag is a flag.Value accumulating the values of a repeated flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// sourceFlags are the flags selecting the sources for validate, print and
+// unused.
+type sourceFlags struct {
+	files     stringsFlag
+	env       bool
+	envPrefix string
+}
+
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.files, "file", "config file to read (may be repeated, with later files taking precedence)")
+	fs.BoolVar(&f.env, "env", false, "read environment variables, with precedence over the files")
+	fs.StringVar(&f.envPrefix, "env-prefix", "", "prefix of the environment variables (implies -env)")
+}
+
+func (f *sourceFlags) sources() ([]dials.Source, error) {
+	srcs := make([]dials.Source, 0, len(f.files)+1)
+	for _, path := range f.files {
+		dec := ez.DecoderFromExtension(path)
+		if dec == nil {
+			return nil, fmt.Errorf("no decoder for the extension of %q", path)
+		}
+		src, err := file.NewSource(path, dec)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, src)
+	}
+	if f.env || f.envPrefix != "" {
+		srcs = append(srcs, &env.Source{Prefix: f.envPrefix})
+	}
+	return srcs, nil
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %q", fs.Name(), fs.Args())
+	}
+	return nil
+}
+
+// compose composes the configuration from the sources selected by sf,
+// writing any warnings to stderr. If detectUnused is set, unused
+// configuration is detected (see dials.Params.DetectUnusedConfig), and the
+// unknown keys are left to the caller to report.
+func compose[T any](ctx context.Context, template *T, sf *sourceFlags, detectUnused bool, stderr io.Writer) (*dials.Dials[T], error) {
+	srcs, err := sf.sources()
+	if err != nil {
+		return nil, err
+	}
+	cfg := *template
+	p := dials.Params[T]{
+		OnWarning: func(_ context.Context, w dials.Warning) {
+			if detectUnused && w.Kind == dials.WarningUnknownKey {
+				return
+			}
+			fmt.Fprintf(stderr, "warning: %s\n", w)
+		},
+		DetectUnusedConfig: detectUnused,
+	}
+	return p.Config(ctx, &cfg, srcs...)
+}
+
+func runValidate[T any](ctx context.Context, template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", stderr)
+	sf := sourceFlags{}
+	sf.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if len(sf.files) == 0 {
+		return errors.New("validate: at least one -file is required")
+	}
+	if _, err := compose(ctx, template, &sf, false, stderr); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	_, err := fmt.Fprintf(stdout, "%s: OK\n", strings.Join(sf.files, ", "))
+	return err
+}
+
+func runPrint[T any](ctx context.Context, template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print", stderr)
+	sf := sourceFlags{}
+	sf.register(fs)
+	explain := fs.Bool("explain", false, "show the source of each field's value, and the values it overrides")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	d, err := compose(ctx, template, &sf, false, stderr)
+	if err != nil {
+		return err
+	}
+	if *explain {
+		return dials.WriteExplanation(stdout, d.Explain())
+	}
+	_, err = fmt.Fprintln(stdout, dials.RedactedString(d.View()))
+	return err
+}
+
+func runUnused[T any](ctx context.Context, template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("unused", stderr)
+	sf := sourceFlags{}
+	sf.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	d, err := compose(ctx, template, &sf, true, stderr)
+	if err != nil {
+		return err
+	}
+	defer d.Close(ctx)
+	unused := d.UnusedConfig()
+	for _, path := range unused.UnsetFields {
+		if _, err := fmt.Fprintf(stdout, "unset field: %s\n", path); err != nil {
+			return err
+		}
+	}
+	for _, msg := range unused.UnknownKeys {
+		if _, err := fmt.Fprintf(stdout, "unknown key: %s\n", msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileFormats maps the names accepted by -format to schema formats.
+var fileFormats = map[string]jsonschema.Format{
+	"json": jsonschema.JSON,
+	"yaml": jsonschema.YAML,
+	"toml": jsonschema.TOML,
+}
+
+func fileFormat(name string) (jsonschema.Format, error) {
+	f, ok := fileFormats[strings.ToLower(name)]
+	if !ok {
+		return jsonschema.Format{}, fmt.Errorf("unknown file format %q (must be json, yaml or toml)", name)
+	}
+	return f, nil
+}
+
+func runSchema[T any](template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("schema", stderr)
+	format := fs.String("format", "yaml", "format of the config files described by the schema: json, yaml or toml")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	f, err := fileFormat(*format)
+	if err != nil {
+		return err
+	}
+	s, err := jsonschema.Generate(template, f)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func runDocs[T any](template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("docs", stderr)
+	format := fs.String("format", "markdown", "output format: markdown or html")
+	fileFmt := fs.String("file-format", "", "add a column with the fields' keys in config files of this format: json, yaml or toml")
+	envPrefix := fs.String("env-prefix", "", "add a column with the fields' environment variables, with this prefix (\"-\" for none)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts := docs.Options{}
+	if *fileFmt != "" {
+		f, err := fileFormat(*fileFmt)
+		if err != nil {
+			return err
+		}
+		opts.FileFormat = &f
+	}
+	switch *envPrefix {
+	case "":
+	case "-":
+		opts.Env = &env.Source{}
+	default:
+		opts.Env = &env.Source{Prefix: *envPrefix}
+	}
+	fields, err := docs.Fields(template, opts)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(*format) {
+	case "markdown", "md":
+		return docs.WriteMarkdown(stdout, fields)
+	case "html":
+		return docs.WriteHTML(stdout, fields)
+	default:
+		return fmt.Errorf("unknown docs format %q (must be markdown or html)", *format)
+	}
+}
+
+func runGenerate[T any](template *T, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("generate", stderr)
+	out := fs.String("o", "", "path of the file to write the code to, instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	src, err := codegen.Generate(template)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
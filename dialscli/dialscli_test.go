@@ -0,0 +1,138 @@
+package dialscli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Name     string `dials:"name" dialsdesc:"the service name"`
+	Port     int    `dials:"port"`
+	Password string `dials:"password" dialssecret:"true"`
+}
+
+func (c *testConfig) Verify() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	err := Run(context.Background(), &testConfig{Port: 8080}, args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestValidate(t *testing.T) {
+	good := writeFile(t, "good.yaml", "name: svc\nport: 9000\n")
+	out, err := run(t, "validate", "-file", good)
+	require.NoError(t, err)
+	assert.Equal(t, good+": OK\n", out)
+
+	bad := writeFile(t, "bad.json", `{"port": -1}`)
+	_, err = run(t, "validate", "-file", good, "-file", bad)
+	assert.ErrorContains(t, err, "port must be positive")
+
+	malformed := writeFile(t, "malformed.toml", "port = \n")
+	_, err = run(t, "validate", "-file", malformed)
+	assert.Error(t, err)
+
+	_, err = run(t, "validate", "-file", writeFile(t, "config.ini", ""))
+	assert.ErrorContains(t, err, "no decoder")
+	_, err = run(t, "validate")
+	assert.ErrorContains(t, err, "at least one -file")
+}
+
+func TestPrint(t *testing.T) {
+	t.Setenv("SVC_NAME", "from-env")
+	path := writeFile(t, "config.yaml", "name: svc\npassword: hunter2\n")
+
+	out, err := run(t, "print", "-file", path)
+	require.NoError(t, err)
+	assert.Equal(t, "{Name:svc Port:8080 Password:<redacted>}\n", out)
+
+	out, err = run(t, "print", "-file", path, "-env-prefix", "SVC", "-explain")
+	require.NoError(t, err)
+	assert.Contains(t, out, `Name = "from-env" (from *env.Source)`)
+	assert.Contains(t, out, `overrides "svc" (from *file.Source)`)
+	assert.Contains(t, out, "Port = 8080 (default)")
+	assert.NotContains(t, out, "hunter2")
+}
+
+func TestUnused(t *testing.T) {
+	t.Setenv("SVC_NAME", "from-env")
+	t.Setenv("SVC_PORTT", "1")
+	path := writeFile(t, "config.yaml", "port: 9000\nlegacy: {timeout: 1s}\n")
+
+	out, err := run(t, "unused", "-file", path, "-env-prefix", "SVC")
+	require.NoError(t, err)
+	assert.Equal(t, "unset field: Password\n"+
+		"unknown key: YAML key \"legacy\" doesn't correspond to any field\n"+
+		"unknown key: environment variable SVC_PORTT doesn't correspond to any field\n", out)
+}
+
+func TestSchema(t *testing.T) {
+	out, err := run(t, "schema", "-format", "json")
+	require.NoError(t, err)
+	schema := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(out), &schema))
+	props := schema["properties"].(map[string]interface{})
+	assert.Equal(t, "the service name", props["name"].(map[string]interface{})["description"])
+
+	_, err = run(t, "schema", "-format", "ini")
+	assert.ErrorContains(t, err, "unknown file format")
+}
+
+func TestDocs(t *testing.T) {
+	out, err := run(t, "docs", "-file-format", "yaml", "-env-prefix", "SVC")
+	require.NoError(t, err)
+	assert.Contains(t, out, "| `Port` | `int` | `8080` |")
+	assert.Contains(t, out, "`SVC_PORT`")
+
+	out, err = run(t, "docs", "-format", "html")
+	require.NoError(t, err)
+	assert.Contains(t, out, "<table>")
+}
+
+func TestGenerate(t *testing.T) {
+	out, err := run(t, "generate")
+	require.NoError(t, err)
+	assert.Contains(t, out, "package dialscli")
+	assert.Contains(t, out, "dials.RegisterGenerated(dials.GeneratedFuncs[testConfig]{")
+
+	path := filepath.Join(t.TempDir(), "config_dials.go")
+	_, err = run(t, "generate", "-o", path)
+	require.NoError(t, err)
+	src, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, out, string(src))
+}
+
+func TestUsage(t *testing.T) {
+	_, err := run(t)
+	assert.ErrorContains(t, err, "no command")
+	_, err = run(t, "frobnicate")
+	assert.ErrorContains(t, err, `unknown command "frobnicate"`)
+	_, err = run(t, "print", "extra")
+	assert.ErrorContains(t, err, "unexpected arguments")
+	out, err := run(t, "help")
+	require.NoError(t, err)
+	assert.Equal(t, Usage, out)
+}
@@ -0,0 +1,135 @@
+// Package dialstest provides helpers for testing code that consumes
+// configuration from dials, including watched updates.
+package dialstest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Source is a dials.Source and dials.Watcher whose value is controlled by the
+// test. Its value is a complete *T, so every field it provides overrides the
+// corresponding field in lower-precedence sources (including zero-values),
+// except for nil maps, slices, pointers and interfaces, which are treated as
+// unset. A nil value provides nothing.
+//
+// Like sourcewrap.Blank, a Source cannot be reused across calls to
+// dials.Config.
+type Source[T any] struct {
+	mu      sync.Mutex
+	initial *T
+	t       *dials.Type
+	args    dials.WatchArgs
+}
+
+var _ dials.Source = (*Source[struct{}])(nil)
+var _ dials.Watcher = (*Source[struct{}])(nil)
+
+// NewSource constructs a Source with the initial value initial (which may be
+// nil).
+func NewSource[T any](initial *T) *Source[T] {
+	return &Source[T]{initial: initial}
+}
+
+// Value implements dials.Source, returning the initial value.
+func (s *Source[T]) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+	return pointerifiedValue(t, s.initial)
+}
+
+// Watch implements dials.Watcher.
+func (s *Source[T]) Watch(_ context.Context, t *dials.Type, args dials.WatchArgs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.args != nil {
+		return fmt.Errorf("dialstest.Source has already been used, with type %s", s.t.Type())
+	}
+	s.t = t
+	s.args = args
+	return nil
+}
+
+func (s *Source[T]) watchArgs() (*dials.Type, dials.WatchArgs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.args == nil {
+		return nil, nil, fmt.Errorf("dialstest.Source has not been passed to dials.Config")
+	}
+	return s.t, s.args, nil
+}
+
+// Push replaces the Source's value with v, blocking until the new
+// configuration has been installed. After Push returns successfully, View()
+// on the Dials instance reflects v. (callbacks may not have run yet; see
+// Await)
+//
+// If stacking or verification fails, the error is returned and the previous
+// configuration remains installed.
+func (s *Source[T]) Push(ctx context.Context, v *T) error {
+	t, args, err := s.watchArgs()
+	if err != nil {
+		return err
+	}
+	val, err := pointerifiedValue(t, v)
+	if err != nil {
+		return err
+	}
+	return args.BlockingReportNewValue(ctx, val)
+}
+
+// ReportError reports err as though the Source encountered an error while
+// watching. It's delivered to the OnWatchedError callback (if any).
+func (s *Source[T]) ReportError(ctx context.Context, err error) error {
+	_, args, waErr := s.watchArgs()
+	if waErr != nil {
+		return waErr
+	}
+	return args.ReportError(ctx, err)
+}
+
+// Done signals that the Source will not provide any more updates.
+func (s *Source[T]) Done(ctx context.Context) error {
+	_, args, err := s.watchArgs()
+	if err != nil {
+		return err
+	}
+	args.Done(ctx)
+	return nil
+}
+
+// Await blocks until the installed configuration satisfies cond (which may be
+// the configuration installed when Await is called), and all callbacks for
+// that version have been delivered. It returns the first version satisfying
+// cond, or an error if ctx expires first.
+func Await[T any](ctx context.Context, d *dials.Dials[T], cond func(*T) bool) (*T, error) {
+	ch := make(chan *T, 1)
+	unreg := d.Subscribe(ctx, 1, func(_ context.Context, _, newCfg *T) {
+		if !cond(newCfg) {
+			return
+		}
+		select {
+		case ch <- newCfg:
+		default:
+		}
+	})
+	if unreg == nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", ctx.Err())
+	}
+	defer unreg(ctx)
+
+	select {
+	case cfg := <-ch:
+		return cfg, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("context expired awaiting configuration: %w", ctx.Err())
+	}
+}
+
+// pointerifiedValue converts v to the pointerified type described by t.
+func pointerifiedValue[T any](t *dials.Type, v *T) (reflect.Value, error) {
+	return ptrify.PointerifyValue(t.Type(), reflect.ValueOf(v))
+}
@@ -0,0 +1,110 @@
+package dialstest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type backend struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+type config struct {
+	Name     string
+	Backend  backend
+	Fallback *backend
+	Tags     []string
+}
+
+var errNoName = errors.New("name must be set")
+
+func (c *config) Verify() error {
+	if c.Name == "" {
+		return errNoName
+	}
+	return nil
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	base := static.StringSource{Data: `{"Name": "base", "Tags": ["a"]}`, Decoder: &json.Decoder{}}
+	src := NewSource[config](nil)
+	errs := make(chan error, 1)
+	d, err := dials.Params[config]{
+		OnWatchedError: func(_ context.Context, err error, _, _ *config) { errs <- err },
+	}.Config(ctx, &config{}, &base, src)
+	require.NoError(t, err)
+	assert.Equal(t, &config{Name: "base", Tags: []string{"a"}}, d.View())
+
+	pushed := config{
+		Name:     "pushed",
+		Backend:  backend{Addr: "localhost:80", Timeout: time.Second},
+		Fallback: &backend{Addr: "localhost:81"},
+	}
+	require.NoError(t, src.Push(ctx, &pushed))
+	// nil slices are treated as unset
+	pushed.Tags = []string{"a"}
+	// Push is synchronous with respect to View
+	assert.Equal(t, &pushed, d.View())
+
+	// Await blocks until callbacks have seen the version
+	cfg, err := Await(ctx, d, func(c *config) bool { return c.Name == "pushed" })
+	require.NoError(t, err)
+	assert.Equal(t, &pushed, cfg)
+
+	// verification failures are returned
+	assert.ErrorIs(t, src.Push(ctx, &config{}), errNoName)
+	<-errs
+	assert.Equal(t, &pushed, d.View())
+
+	// a nil value falls back to the lower-precedence sources
+	require.NoError(t, src.Push(ctx, nil))
+	assert.Equal(t, &config{Name: "base", Tags: []string{"a"}}, d.View())
+
+	reportedErr := errors.New("backend unavailable")
+	require.NoError(t, src.ReportError(ctx, reportedErr))
+	assert.ErrorIs(t, <-errs, reportedErr)
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d, err := dials.Config(ctx, &config{Name: "initial"}, NewSource(&config{Name: "src"}))
+	require.NoError(t, err)
+	assert.Equal(t, "src", d.View().Name)
+
+	awaitCtx, awaitCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer awaitCancel()
+	_, err = Await(awaitCtx, d, func(c *config) bool { return c.Name == "never" })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSourceUnused(t *testing.T) {
+	t.Parallel()
+	assert.Error(t, NewSource[config](nil).Push(context.Background(), &config{}))
+}
+
+func FuzzSeedFiles(f *testing.F) {
+	dir := f.TempDir()
+	require.NoError(f, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"string": "a"}`), 0600))
+	require.NoError(f, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"map": {"b": 2}}`), 0600))
+	require.NoError(f, AddSeedFiles(f, filepath.Join(dir, "*.json")))
+	assert.Error(f, AddSeedFiles(f, "["))
+	FuzzDecoder(f, &json.Decoder{})
+}
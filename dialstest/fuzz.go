@@ -0,0 +1,110 @@
+package dialstest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// FuzzConfig is the configuration FuzzDecoder decodes into. It has a field of
+// each kind decoders handle, so fuzzed input can reach every code path of a
+// decoder (and of the composition of its output).
+type FuzzConfig struct {
+	String   string                 `dials:"string"`
+	Int      int                    `dials:"int"`
+	Int8     int8                   `dials:"int8"`
+	Uint16   uint16                 `dials:"uint16"`
+	Float    float64                `dials:"float"`
+	Bool     bool                   `dials:"bool"`
+	Duration time.Duration          `dials:"duration"`
+	Time     time.Time              `dials:"time"`
+	Strings  []string               `dials:"strings"`
+	Appended []int                  `dials:"appended" dialsmerge:"append"`
+	Set      map[string]struct{}    `dials:"set"`
+	Map      map[string]int         `dials:"map"`
+	Pointer  *string                `dials:"pointer"`
+	Nested   FuzzNested             `dials:"nested"`
+	Structs  []FuzzNested           `dials:"structs"`
+	Merged   map[string]*FuzzNested `dials:"merged" dialsmerge:"merge"`
+}
+
+// FuzzNested is nested within FuzzConfig.
+type FuzzNested struct {
+	Name  string            `dials:"name"`
+	Count *int              `dials:"count"`
+	Inner struct{ On bool } `dials:"inner"`
+	Tags  map[string]string `dials:"tags"`
+}
+
+// FuzzDecoder runs a fuzz test of dec: arbitrary input may make it fail, but
+// mustn't make it panic, or return a value of the wrong type. Values it
+// decodes successfully are then composed (with dials.ComposeValues, and by
+// dials.Config from a source providing them, which overlays them onto a
+// FuzzConfig), which mustn't panic either. The seeds are added to the
+// corpus, along with the files in f's testdata/fuzz directory that the go
+// tool adds itself; AddSeedFiles adds others.
+//
+// Call it from a fuzz test in the decoder's package:
+//
+//	func FuzzDecode(f *testing.F) {
+//		dialstest.FuzzDecoder(f, &json.Decoder{}, `{"string": "s", "int": 1}`)
+//	}
+func FuzzDecoder(f *testing.F, dec dials.Decoder, seeds ...string) {
+	f.Helper()
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	typ := dials.NewType(ptrify.Pointerify(reflect.TypeOf(FuzzConfig{}), reflect.Value{}))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx := context.Background()
+		v, err := dials.Decode(ctx, dec, bytes.NewReader(data), typ)
+		if err != nil {
+			return
+		}
+		if v.Type() != typ.Type() {
+			t.Fatalf("decoded a %s, not a %s", v.Type(), typ.Type())
+		}
+		if _, err := dials.ComposeValues(typ, v, v); err != nil {
+			t.Fatalf("failed to compose decoded value with itself: %s", err)
+		}
+		// Config may reject the value (e.g. an invalid dialsmerge map
+		// entry), but mustn't panic
+		if d, err := dials.Config(ctx, &FuzzConfig{}, valueSource{v}); err == nil {
+			d.View()
+		}
+	})
+}
+
+// AddSeedFiles adds the contents of the files matching pattern (see
+// filepath.Glob), e.g. sample config files, to f's corpus.
+func AddSeedFiles(f *testing.F, pattern string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read seed file: %w", err)
+		}
+		f.Add(data)
+	}
+	return nil
+}
+
+// valueSource is a dials.Source providing a fixed value.
+type valueSource struct {
+	v reflect.Value
+}
+
+func (s valueSource) Value(context.Context, *dials.Type) (reflect.Value, error) {
+	return s.v, nil
+}
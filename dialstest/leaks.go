@@ -0,0 +1,78 @@
+package dialstest
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout bounds how long CheckGoroutines waits for goroutines to exit.
+// It's a variable so tests can shorten it.
+var leakTimeout = 5 * time.Second
+
+// modulePath prefixes the functions of the packages whose goroutines
+// CheckGoroutines tracks.
+const modulePath = "github.com/vimeo/dials"
+
+// CheckGoroutines fails t if goroutines running code from the dials module
+// (including the test's own packages) that weren't running when it was
+// called are still running when t's cleanup functions run, after waiting a
+// few seconds for them to exit. Goroutines that outlive a test usually mean a
+// watching source's goroutine wasn't stopped by Dials.Close (see
+// dials.WatchGo). Dials instances must be closed before the test
+// returns (e.g. with a deferred call, which runs before the cleanup
+// functions). As it can't tell which test started a goroutine, it mustn't
+// be used by parallel tests, or tests running alongside them.
+func CheckGoroutines(t testing.TB) {
+	t.Helper()
+	before := map[string]struct{}{}
+	for id := range moduleGoroutines() {
+		before[id] = struct{}{}
+	}
+	t.Cleanup(func() {
+		deadline := time.Now().Add(leakTimeout)
+		for {
+			leaked := []string{}
+			for id, stack := range moduleGoroutines() {
+				if _, ok := before[id]; !ok {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+// moduleGoroutines returns the stacks of the goroutines running code from the
+// dials module, other than those running tests, keyed by their IDs.
+func moduleGoroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := map[string]string{}
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		s := string(stack)
+		header, _, _ := strings.Cut(s, "\n")
+		id, _, _ := strings.Cut(strings.TrimPrefix(header, "goroutine "), " ")
+		if !strings.Contains(s, modulePath) || strings.Contains(s, "testing.tRunner") {
+			continue
+		}
+		stacks[id] = s
+	}
+	return stacks
+}
@@ -0,0 +1,50 @@
+package dialstest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTB records the cleanup functions and errors of a test.
+type recordingTB struct {
+	testing.TB
+	cleanups []func()
+	errs     []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestCheckGoroutines(t *testing.T) {
+	defer func(timeout time.Duration) { leakTimeout = timeout }(leakTimeout)
+	leakTimeout = 50 * time.Millisecond
+
+	// a goroutine that exits before the cleanup isn't a leak
+	tb := &recordingTB{TB: t}
+	CheckGoroutines(tb)
+	done := make(chan struct{})
+	go func() { <-done }()
+	close(done)
+	require.Len(t, tb.cleanups, 1)
+	tb.cleanups[0]()
+	assert.Empty(t, tb.errs)
+
+	tb = &recordingTB{TB: t}
+	CheckGoroutines(tb)
+	release := make(chan struct{})
+	defer close(release)
+	go func() { <-release }()
+	tb.cleanups[0]()
+	require.Len(t, tb.errs, 1)
+	assert.Contains(t, tb.errs[0], "1 goroutines leaked")
+	assert.Contains(t, tb.errs[0], "dialstest.TestCheckGoroutines")
+}
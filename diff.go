@@ -0,0 +1,147 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// FieldChange describes a field whose value differs between two
+// configuration versions.
+type FieldChange struct {
+	// Path contains the Go names of the fields leading to the changed
+	// field, starting with a field of the configuration struct.
+	Path []string
+	// Old and New are the field's values in the old and new
+	// configurations. (Old is nil if there was no old configuration)
+	Old, New interface{}
+	// Secret is true if the field is secret (see [SecretTag]), or (for
+	// the changes passed to [Params].OnConfigChange) Params.Redact
+	// returns true for it.
+	Secret bool
+}
+
+// String describes the change, with the values of secret fields replaced by
+// RedactedValue.
+func (c FieldChange) String() string {
+	if c.Secret {
+		return fmt.Sprintf("%s: %s -> %s", strings.Join(c.Path, "."), RedactedValue, RedactedValue)
+	}
+	return fmt.Sprintf("%s: %v -> %v", strings.Join(c.Path, "."), c.Old, c.New)
+}
+
+// ConfigChangeHandler is a callback that's called after a new config is
+// installed with the fields that differ from the previous version.
+type ConfigChangeHandler[T any] func(ctx context.Context, oldConfig, newConfig *T, changes []FieldChange)
+
+var reflectValueType = reflect.TypeOf(reflect.Value{})
+
+// Diff returns the fields whose values differ between oldConfig and
+// newConfig, in field order. Nested structs (and non-nil pointers to
+// structs) are compared field-by-field, while other fields (including maps,
+// slices and scalar structs like time.Time) are compared as a whole with
+// reflect.DeepEqual. Unexported fields are ignored.
+//
+// If oldConfig is nil, every field of newConfig is reported as changed.
+// Configurations from [DynamicConfig] are compared by the struct they
+// point to.
+func Diff[T any](oldConfig, newConfig *T) []FieldChange {
+	if newConfig == nil {
+		return nil
+	}
+	newVal := derefConfig(reflect.ValueOf(newConfig).Elem())
+	oldVal := reflect.Value{}
+	if oldConfig != nil {
+		oldVal = derefConfig(reflect.ValueOf(oldConfig).Elem())
+	}
+	if newVal.Kind() != reflect.Struct {
+		if !oldVal.IsValid() || !reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+			return []FieldChange{{Old: valueInterface(oldVal), New: newVal.Interface()}}
+		}
+		return nil
+	}
+	return diffStruct(nil, nil, false, oldVal, newVal)
+}
+
+// derefConfig unwraps the reflect.Value (and pointer) holding a dynamic
+// configuration.
+func derefConfig(v reflect.Value) reflect.Value {
+	if v.Type() != reflectValueType {
+		return v
+	}
+	return reflect.Indirect(v.Interface().(reflect.Value))
+}
+
+func valueInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// diffStruct appends the changes between the structs oldVal (which may be
+// invalid) and newVal, whose fields are at path (and are secret if secret is
+// set), to changes.
+func diffStruct(changes []FieldChange, path []string, secret bool, oldVal, newVal reflect.Value) []FieldChange {
+	t := newVal.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		fieldSecret := secret || secretTag(sf)
+		nf := newVal.Field(i)
+		of := reflect.Value{}
+		if oldVal.IsValid() {
+			of = oldVal.Field(i)
+		}
+		if s, ok := structOf(of, nf); ok {
+			changes = diffStruct(changes, fieldPath, fieldSecret, s.old, s.new)
+			continue
+		}
+		if of.IsValid() && reflect.DeepEqual(of.Interface(), nf.Interface()) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Path:   fieldPath,
+			Old:    valueInterface(of),
+			New:    nf.Interface(),
+			Secret: fieldSecret,
+		})
+	}
+	return changes
+}
+
+type structPair struct {
+	old, new reflect.Value
+}
+
+// structOf returns the structs to compare field-by-field if nf is a
+// (non-scalar) struct, or a pointer to one that's non-nil in both versions.
+func structOf(of, nf reflect.Value) (structPair, bool) {
+	switch nf.Kind() {
+	case reflect.Struct:
+		if ptrify.IsScalarStruct(nf.Type()) {
+			return structPair{}, false
+		}
+		return structPair{old: of, new: nf}, true
+	case reflect.Ptr:
+		if nf.IsNil() || nf.Type().Elem().Kind() != reflect.Struct ||
+			ptrify.IsScalarStruct(nf.Type().Elem()) {
+			return structPair{}, false
+		}
+		if !of.IsValid() {
+			return structPair{new: nf.Elem()}, true
+		}
+		if of.IsNil() {
+			return structPair{}, false
+		}
+		return structPair{old: of.Elem(), new: nf.Elem()}, true
+	default:
+		return structPair{}, false
+	}
+}
@@ -0,0 +1,142 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type diffInner struct {
+	Port int
+	Tags []string
+}
+
+type diffConfig struct {
+	Name     string
+	Inner    diffInner
+	InnerPtr *diffInner
+	Deadline time.Time
+	Labels   map[string]string
+	hidden   int
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	deadline := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := diffConfig{
+		Name:     "a",
+		Inner:    diffInner{Port: 80, Tags: []string{"x"}},
+		InnerPtr: &diffInner{Port: 1},
+		Deadline: deadline,
+		Labels:   map[string]string{"k": "v"},
+		hidden:   1,
+	}
+
+	for _, tbl := range []struct {
+		name   string
+		modify func(c *diffConfig)
+		want   []FieldChange
+	}{
+		{
+			name:   "unchanged",
+			modify: func(c *diffConfig) { c.hidden = 2 },
+			want:   nil,
+		},
+		{
+			name: "nested_fields",
+			modify: func(c *diffConfig) {
+				c.Name = "b"
+				c.Inner.Port = 8080
+				c.InnerPtr = &diffInner{Port: 2}
+			},
+			want: []FieldChange{
+				{Path: []string{"Name"}, Old: "a", New: "b"},
+				{Path: []string{"Inner", "Port"}, Old: 80, New: 8080},
+				{Path: []string{"InnerPtr", "Port"}, Old: 1, New: 2},
+			},
+		},
+		{
+			name: "whole_values",
+			modify: func(c *diffConfig) {
+				c.Inner.Tags = []string{"x", "y"}
+				c.InnerPtr = nil
+				c.Deadline = deadline.Add(time.Second)
+				c.Labels = map[string]string{"k": "w"}
+			},
+			want: []FieldChange{
+				{Path: []string{"Inner", "Tags"}, Old: []string{"x"}, New: []string{"x", "y"}},
+				{Path: []string{"InnerPtr"}, Old: &diffInner{Port: 1}, New: (*diffInner)(nil)},
+				{Path: []string{"Deadline"}, Old: deadline, New: deadline.Add(time.Second)},
+				{Path: []string{"Labels"}, Old: map[string]string{"k": "v"}, New: map[string]string{"k": "w"}},
+			},
+		},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			newCfg := base
+			newCfg.Inner.Tags = []string{"x"}
+			tbl.modify(&newCfg)
+			assert.Equal(t, tbl.want, Diff(&base, &newCfg))
+		})
+	}
+}
+
+func TestDiffNoOldConfig(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		A int
+		B struct{ C string }
+	}
+	newCfg := config{A: 1}
+	newCfg.B.C = "c"
+	assert.Equal(t, []FieldChange{
+		{Path: []string{"A"}, Old: nil, New: 1},
+		{Path: []string{"B", "C"}, Old: nil, New: "c"},
+	}, Diff(nil, &newCfg))
+	assert.Equal(t, "B.C: <nil> -> c", Diff(nil, &newCfg)[1].String())
+}
+
+func TestDiffDynamic(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		A int
+	}
+	oldVal, newVal := reflect.ValueOf(&config{A: 1}), reflect.ValueOf(&config{A: 2})
+	assert.Equal(t, []FieldChange{{Path: []string{"A"}, Old: 1, New: 2}}, Diff(&oldVal, &newVal))
+}
+
+func TestOnConfigChange(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+		Bar int
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+		Bar *int
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changesCh := make(chan []FieldChange, 2)
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Params[testConfig]{
+		OnConfigChange: func(_ context.Context, _, _ *testConfig, changes []FieldChange) {
+			changesCh <- changes
+		},
+	}.Config(ctx, &testConfig{Foo: "foo", Bar: 1}, &w)
+	require.NoError(t, err)
+
+	// an identical config doesn't trigger the callback
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{}))
+	<-d.Events()
+
+	bar := 2
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Bar: &bar}))
+	assert.Equal(t, []FieldChange{{Path: []string{"Bar"}, Old: 1, New: 2}}, <-changesCh)
+	assert.Empty(t, changesCh)
+}
@@ -0,0 +1,282 @@
+// Package docs generates reference documentation for config structs, listing
+// each field's type, default value and description along with the flag,
+// environment variable and config-file key that set it, so documentation
+// can be regenerated whenever the config struct changes rather than
+// drifting from it.
+package docs
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/jsonschema"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// DescriptionTag is the name of the struct tag whose value is used as a
+// field's description. (it's shared with the flag sources' help text)
+const DescriptionTag = "dialsdesc"
+
+// FieldNamer is implemented by sources that can report the name they read
+// each field from (e.g. *flag.Set and *env.Source).
+type FieldNamer interface {
+	// FieldNames returns the names for the leaf fields of the
+	// (pointerified) config type t, keyed by the path of Go field names
+	// joined with dots (e.g. "Database.Port").
+	FieldNames(t *dials.Type) (map[string]string, error)
+}
+
+// Options configures the columns included in the documentation.
+type Options struct {
+	// Flags, if non-nil, adds a column with each field's flag.
+	Flags FieldNamer
+	// Env, if non-nil, adds a column with each field's environment
+	// variable.
+	Env FieldNamer
+	// FileFormat, if non-nil, adds a column with each field's key in
+	// config files of that format (e.g. jsonschema.YAML), with the keys
+	// of nested fields joined with dots.
+	FileFormat *jsonschema.Format
+}
+
+// Field documents one leaf field of a config struct.
+type Field struct {
+	// Path contains the Go names of the fields leading to this field,
+	// joined with dots (e.g. "Database.Port").
+	Path string
+	// Type is the Go type of the field.
+	Type string
+	// Default is the field's value in the template, or empty if it's
+	// the zero value.
+	Default string
+	// Description is the value of the field's DescriptionTag.
+	Description string
+	// Flag, EnvVar and FileKey are the names the field is set by, or
+	// empty if the corresponding Options field is unset (or the field
+	// can't be set that way).
+	Flag, EnvVar, FileKey string
+}
+
+// Fields returns the documentation for the leaf fields of the config struct
+// pointed to by template (whose fields hold the defaults), in field order.
+// Nested structs are described by their fields.
+func Fields(template interface{}, opts Options) ([]Field, error) {
+	v := reflect.ValueOf(template)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("template must be a non-nil pointer to a struct, got %T", template)
+	}
+	if err := ptrify.CheckCycles(v.Elem().Type()); err != nil {
+		return nil, err
+	}
+	t := dials.NewType(ptrify.Pointerify(v.Elem().Type(), v.Elem()))
+
+	var flags, envVars map[string]string
+	if opts.Flags != nil {
+		var err error
+		if flags, err = opts.Flags.FieldNames(t); err != nil {
+			return nil, fmt.Errorf("failed to get flag names: %w", err)
+		}
+	}
+	if opts.Env != nil {
+		var err error
+		if envVars, err = opts.Env.FieldNames(t); err != nil {
+			return nil, fmt.Errorf("failed to get environment variable names: %w", err)
+		}
+	}
+
+	fields := []Field{}
+	walkFields(v.Elem().Type(), v.Elem(), nil, nil, opts.FileFormat, func(sf reflect.StructField, fv reflect.Value, path, keys []string) {
+		p := strings.Join(path, ".")
+		fields = append(fields, Field{
+			Path:        p,
+			Type:        sf.Type.String(),
+			Default:     formatDefault(fv),
+			Description: sf.Tag.Get(DescriptionTag),
+			Flag:        flags[p],
+			EnvVar:      envVars[p],
+			FileKey:     strings.Join(keys, "."),
+		})
+	})
+	return fields, nil
+}
+
+// walkFields calls leaf for each leaf field of the struct type t, with its
+// value in v (if valid), its Go path and its path of keys in format f (nil if
+// f is nil or the field is skipped by it).
+func walkFields(t reflect.Type, v reflect.Value, path, keys []string, f *jsonschema.Format,
+	leaf func(sf reflect.StructField, fv reflect.Value, path, keys []string)) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		switch sf.Type.Kind() {
+		case reflect.Chan, reflect.Func:
+			continue
+		default:
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		fieldKeys := keys
+		if f != nil && (len(path) == 0 || keys != nil) {
+			key, inline, skip := f.FieldKey(sf)
+			switch {
+			case skip:
+				fieldKeys = nil
+			case inline:
+				fieldKeys = keys[:len(keys):len(keys)]
+				if fieldKeys == nil {
+					fieldKeys = []string{}
+				}
+			default:
+				fieldKeys = append(keys[:len(keys):len(keys)], key)
+			}
+		}
+
+		ft, fv := sf.Type, reflect.Value{}
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		nested := ft
+		nv := fv
+		for nested.Kind() == reflect.Ptr {
+			nested = nested.Elem()
+			if nv.IsValid() {
+				if nv.IsNil() {
+					nv = reflect.Value{}
+				} else {
+					nv = nv.Elem()
+				}
+			}
+		}
+		if nested.Kind() == reflect.Struct && !ptrify.IsScalarStruct(nested) {
+			walkFields(nested, nv, fieldPath, fieldKeys, f, leaf)
+			continue
+		}
+		leaf(sf, fv, fieldPath, fieldKeys)
+	}
+}
+
+// formatDefault formats a default value from the template, returning an empty
+// string for zero values.
+func formatDefault(v reflect.Value) string {
+	if !v.IsValid() || v.IsZero() {
+		return ""
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if s, ok := parse.Format(v); ok {
+		return s
+	}
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.String())
+	}
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// column describes a column included in the generated tables.
+type column struct {
+	Header string
+	value  func(Field) string
+	// code indicates that values are formatted as code
+	code bool
+}
+
+func columns(fields []Field) []column {
+	cols := []column{
+		{Header: "Field", value: func(f Field) string { return f.Path }, code: true},
+		{Header: "Type", value: func(f Field) string { return f.Type }, code: true},
+		{Header: "Default", value: func(f Field) string { return f.Default }, code: true},
+	}
+	optional := []column{
+		{Header: "Flag", value: func(f Field) string { return f.Flag }, code: true},
+		{Header: "Environment variable", value: func(f Field) string { return f.EnvVar }, code: true},
+		{Header: "Config file key", value: func(f Field) string { return f.FileKey }, code: true},
+	}
+	// only include the optional columns if some field has a value
+	for _, c := range optional {
+		for _, f := range fields {
+			if c.value(f) != "" {
+				cols = append(cols, c)
+				break
+			}
+		}
+	}
+	return append(cols, column{Header: "Description", value: func(f Field) string { return f.Description }})
+}
+
+// WriteMarkdown writes fields (as returned by Fields) to w as a Markdown
+// table.
+func WriteMarkdown(w io.Writer, fields []Field) error {
+	cols := columns(fields)
+	b := strings.Builder{}
+	b.WriteString("|")
+	for _, c := range cols {
+		b.WriteString(" " + c.Header + " |")
+	}
+	b.WriteString("\n|")
+	for range cols {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, f := range fields {
+		b.WriteString("|")
+		for _, c := range cols {
+			val := markdownEscape(c.value(f))
+			if c.code && val != "" {
+				val = "`" + val + "`"
+			}
+			b.WriteString(" " + val + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscape escapes characters that would break out of a table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "`", "'").Replace(s)
+}
+
+var htmlTmpl = template.Must(template.New("docs").Parse(`<table>
+<thead>
+<tr>{{range .Columns}}<th>{{.Header}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{if .Code}}{{if .Value}}<code>{{.Value}}</code>{{end}}{{else}}{{.Value}}{{end}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+`))
+
+type htmlCell struct {
+	Value string
+	Code  bool
+}
+
+// WriteHTML writes fields (as returned by Fields) to w as an HTML table.
+func WriteHTML(w io.Writer, fields []Field) error {
+	cols := columns(fields)
+	rows := make([][]htmlCell, 0, len(fields))
+	for _, f := range fields {
+		row := make([]htmlCell, 0, len(cols))
+		for _, c := range cols {
+			row = append(row, htmlCell{Value: c.value(f), Code: c.code})
+		}
+		rows = append(rows, row)
+	}
+	return htmlTmpl.Execute(w, struct {
+		Columns []column
+		Rows    [][]htmlCell
+	}{Columns: cols, Rows: rows})
+}
@@ -0,0 +1,98 @@
+package docs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/jsonschema"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+)
+
+type database struct {
+	Host string `dialsdesc:"database hostname"`
+	Port int    `dials:"port_number" dialsdesc:"database port"`
+}
+
+type config struct {
+	Name     string        `dialsdesc:"the service's name"`
+	Timeout  time.Duration `dialsdesc:"request timeout"`
+	Database database
+	Secret   string `dials:"-"`
+	Verbose  bool   `dialsflag:"v" dialsenv:"VERBOSITY" yaml:"verbosity"`
+}
+
+func testTemplate() *config {
+	return &config{
+		Name:     "svc",
+		Timeout:  3 * time.Second,
+		Database: database{Port: 5432},
+	}
+}
+
+func TestFields(t *testing.T) {
+	tmpl := testTemplate()
+	fs, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), tmpl, nil)
+	require.NoError(t, err)
+
+	fields, err := Fields(tmpl, Options{Flags: fs, Env: &env.Source{Prefix: "APP"}, FileFormat: &jsonschema.YAML})
+	require.NoError(t, err)
+	assert.Equal(t, []Field{
+		{
+			Path: "Name", Type: "string", Default: `"svc"`, Description: "the service's name",
+			Flag: "-name", EnvVar: "APP_NAME", FileKey: "name",
+		},
+		{
+			Path: "Timeout", Type: "time.Duration", Default: "3s", Description: "request timeout",
+			Flag: "-timeout", EnvVar: "APP_TIMEOUT", FileKey: "timeout",
+		},
+		{
+			Path: "Database.Host", Type: "string", Description: "database hostname",
+			Flag: "-database-host", EnvVar: "APP_DATABASE_HOST", FileKey: "database.host",
+		},
+		{
+			Path: "Database.Port", Type: "int", Default: "5432", Description: "database port",
+			Flag: "-database-port_number", EnvVar: "APP_DATABASE_PORT_NUMBER", FileKey: "database.port_number",
+		},
+		{
+			Path: "Verbose", Type: "bool",
+			Flag: "-v", EnvVar: "APP_VERBOSITY", FileKey: "verbosity",
+		},
+	}, fields)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	fields, err := Fields(testTemplate(), Options{FileFormat: &jsonschema.TOML})
+	require.NoError(t, err)
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(&buf, fields[:2]))
+	assert.Equal(t, "| Field | Type | Default | Config file key | Description |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `Name` | `string` | `\"svc\"` | `Name` | the service's name |\n"+
+		"| `Timeout` | `time.Duration` | `3s` | `Timeout` | request timeout |\n",
+		buf.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	fields, err := Fields(testTemplate(), Options{})
+	require.NoError(t, err)
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteHTML(&buf, fields[:1]))
+	assert.Equal(t, `<table>
+<thead>
+<tr><th>Field</th><th>Type</th><th>Default</th><th>Description</th></tr>
+</thead>
+<tbody>
+<tr><td><code>Name</code></td><td><code>string</code></td><td><code>&#34;svc&#34;</code></td><td>the service&#39;s name</td></tr>
+</tbody>
+</table>
+`, buf.String())
+}
+
+func TestFieldsInvalidTemplate(t *testing.T) {
+	_, err := Fields(config{}, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// DynamicConfig is like [Params.Config], but for configuration types that are
+// only known at runtime (e.g. built with reflect.StructOf; see the
+// [github.com/vimeo/dials/dynamic] package). template must be a pointer to a
+// struct, whose fields hold the default values.
+//
+// Each configuration version is a reflect.Value holding a pointer to a struct
+// of the same type as template, so View returns a *reflect.Value.
+// Configuration types built at runtime cannot have methods, so no
+// verification is done.
+func DynamicConfig(ctx context.Context, p Params[reflect.Value], template reflect.Value, sources ...Source) (*Dials[reflect.Value], error) {
+	if template.Kind() != reflect.Ptr || template.Type().Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dynamic config template must be a pointer to a struct; got %s", template.Type())
+	}
+	if template.IsNil() {
+		return nil, fmt.Errorf("dynamic config template of type %s is nil", template.Type())
+	}
+	return p.config(ctx, deepCopyValue(template), func(v interface{}) *reflect.Value {
+		rv := reflect.ValueOf(v)
+		return &rv
+	}, sources...)
+}
@@ -0,0 +1,126 @@
+// Package dynamic supports configuration whose schema isn't known until
+// runtime (e.g. because it's described by a plugin's manifest).
+//
+// A Schema builds a struct type from a list of Fields, which is then used
+// with any dials Source through Config, just like a statically-defined
+// configuration struct. Configuration versions are reflect.Values, which can
+// be converted to maps with Map.
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"reflect"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Field describes a single field in a Schema.
+type Field struct {
+	// Name is the field's name. It must be an exported Go identifier. Use
+	// a `dials` tag to populate the field from keys (or flags, etc.) that
+	// are not valid Go identifiers.
+	Name string
+	// Type is the field's type, which may be the Type of another Schema
+	// for nested configuration.
+	Type reflect.Type
+	// Tag holds the field's struct tags, (e.g. `dials:"max_conns"`)
+	Tag reflect.StructTag
+	// Default is the field's default value. It must be assignable or
+	// convertible to Type. A nil Default leaves the zero value.
+	Default interface{}
+}
+
+// Schema describes a configuration struct type built at runtime.
+type Schema struct {
+	typ    reflect.Type
+	fields []Field
+}
+
+// NewSchema builds a Schema from the passed fields.
+func NewSchema(fields ...Field) (*Schema, error) {
+	seen := make(map[string]struct{}, len(fields))
+	structFields := make([]reflect.StructField, len(fields))
+	for i, f := range fields {
+		if !token.IsIdentifier(f.Name) || !token.IsExported(f.Name) {
+			return nil, fmt.Errorf("field name %q is not an exported Go identifier", f.Name)
+		}
+		if _, dup := seen[f.Name]; dup {
+			return nil, fmt.Errorf("duplicate field name %q", f.Name)
+		}
+		seen[f.Name] = struct{}{}
+		if f.Type == nil {
+			return nil, fmt.Errorf("field %q has a nil type", f.Name)
+		}
+		if f.Default != nil && !reflect.TypeOf(f.Default).ConvertibleTo(f.Type) {
+			return nil, fmt.Errorf("default value of type %T for field %q is not convertible to %s",
+				f.Default, f.Name, f.Type)
+		}
+		structFields[i] = reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag}
+	}
+	return &Schema{
+		typ:    reflect.StructOf(structFields),
+		fields: append([]Field(nil), fields...),
+	}, nil
+}
+
+// Type returns the struct type described by the Schema.
+func (s *Schema) Type() reflect.Type {
+	return s.typ
+}
+
+// New returns a pointer to a new struct of the Schema's type, populated with
+// the default values of its fields.
+func (s *Schema) New() reflect.Value {
+	v := reflect.New(s.typ)
+	for i, f := range s.fields {
+		if f.Default == nil {
+			continue
+		}
+		v.Elem().Field(i).Set(reflect.ValueOf(f.Default).Convert(f.Type))
+	}
+	return v
+}
+
+// Config populates a configuration of the Schema's type from the passed
+// sources (starting with the Schema's default values). See
+// [dials.DynamicConfig] for details.
+func Config(ctx context.Context, p dials.Params[reflect.Value], s *Schema, sources ...dials.Source) (*dials.Dials[reflect.Value], error) {
+	return dials.DynamicConfig(ctx, p, s.New(), sources...)
+}
+
+// Map converts a configuration version (a pointer to a struct, as returned by
+// View on the Dials returned by Config) into a map keyed by field name.
+// Nested structs are converted recursively, except for those implementing
+// encoding.TextUnmarshaler (e.g. time.Time).
+func Map(v reflect.Value) map[string]interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	out := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		// leave structs like time.Time that are scalars in configs alone
+		if ft := fv.Type(); !ptrify.IsScalarStruct(ft) &&
+			(ft.Kind() == reflect.Struct || (ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct)) {
+			if m := Map(fv); m != nil {
+				out[sf.Name] = m
+				continue
+			}
+		}
+		out[sf.Name] = fv.Interface()
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package dynamic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/dialstest"
+	"github.com/vimeo/dials/sources/static"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool, err := NewSchema(
+		Field{Name: "MaxConns", Type: reflect.TypeOf(0), Tag: `dials:"max_conns"`, Default: 4},
+		Field{Name: "IdleTimeout", Type: reflect.TypeOf(time.Duration(0)), Tag: `dials:"idle_timeout"`},
+	)
+	require.NoError(t, err)
+	schema, err := NewSchema(
+		Field{Name: "Name", Type: reflect.TypeOf(""), Tag: `dials:"name"`, Default: "plugin"},
+		Field{Name: "Enabled", Type: reflect.TypeOf(false), Tag: `dials:"enabled"`},
+		Field{Name: "Pool", Type: pool.Type(), Tag: `dials:"pool"`},
+	)
+	require.NoError(t, err)
+
+	src := static.StringSource{
+		Data:    `{"enabled": true, "pool": {"max_conns": 16}}`,
+		Decoder: &json.Decoder{},
+	}
+	d, err := Config(ctx, dials.Params[reflect.Value]{}, schema, &src)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"Name":    "plugin",
+		"Enabled": true,
+		"Pool": map[string]interface{}{
+			"MaxConns":    16,
+			"IdleTimeout": time.Duration(0),
+		},
+	}, Map(*d.View()))
+	assert.Equal(t, "plugin", d.View().Elem().FieldByName("Name").String())
+}
+
+func TestConfigWatching(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	schema, err := NewSchema(Field{Name: "Name", Type: reflect.TypeOf(""), Default: "plugin"})
+	require.NoError(t, err)
+
+	type staticEquivalent struct{ Name string }
+	src := dialstest.NewSource[staticEquivalent](nil)
+	d, err := Config(ctx, dials.Params[reflect.Value]{}, schema, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "plugin"}, Map(*d.View()))
+
+	// Values can also be reported as pointerified versions of equivalent
+	// static types.
+	require.NoError(t, src.Push(ctx, &staticEquivalent{Name: "updated"}))
+	assert.Equal(t, map[string]interface{}{"Name": "updated"}, Map(*d.View()))
+}
+
+func TestMapTextUnmarshalerStructs(t *testing.T) {
+	t.Parallel()
+	started := time.Date(2023, time.July, 24, 0, 0, 0, 0, time.UTC)
+	type inner struct{ A int }
+	v := struct {
+		Started time.Time
+		Inner   *inner
+		Nil     *inner
+	}{Started: started, Inner: &inner{A: 1}}
+	assert.Equal(t, map[string]interface{}{
+		"Started": started,
+		"Inner":   map[string]interface{}{"A": 1},
+		"Nil":     (*inner)(nil),
+	}, Map(reflect.ValueOf(&v)))
+}
+
+func TestNewSchemaErrors(t *testing.T) {
+	t.Parallel()
+	for name, fields := range map[string][]Field{
+		"unexported":    {{Name: "name", Type: reflect.TypeOf("")}},
+		"not_ident":     {{Name: "Max-Conns", Type: reflect.TypeOf(0)}},
+		"duplicate":     {{Name: "A", Type: reflect.TypeOf(0)}, {Name: "A", Type: reflect.TypeOf("")}},
+		"nil_type":      {{Name: "A"}},
+		"wrong_default": {{Name: "A", Type: reflect.TypeOf(0), Default: "four"}},
+	} {
+		fields := fields
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSchema(fields...)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package encrypted
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// AESGCM is a KeyProvider using AES-GCM with a symmetric key (16, 24 or 32
+// bytes, for AES-128, AES-192 or AES-256). The ciphertext is the random nonce
+// followed by the sealed plaintext.
+type AESGCM struct {
+	Key []byte
+}
+
+func (a *AESGCM) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(a.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Decrypt implements KeyProvider.
+func (a *AESGCM) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+// Encrypt returns the encrypted value (formatted with Format) holding
+// plaintext, which Decrypt reverses.
+func (a *AESGCM) Encrypt(plaintext string) (string, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return Format(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
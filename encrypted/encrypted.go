@@ -0,0 +1,228 @@
+// Package encrypted decrypts inline encrypted values in the configuration
+// provided by any source, so individual secrets can be committed encrypted
+// inside an otherwise plain YAML (or JSON, TOML, ...) file:
+//
+//	db:
+//	  user: admin
+//	  password: ENC[AAECAwQFBgcICQoL...]
+//
+// Encrypted values are string values of the form ENC[<ciphertext>], where
+// the ciphertext is encoded with standard base64. Sources wrapped with
+// NewSource decrypt every such string (in string fields, and in slices,
+// arrays and maps of strings) with a KeyProvider before the value is composed
+// with other sources.
+package encrypted
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials"
+)
+
+const (
+	// Prefix and Suffix delimit an encrypted value.
+	Prefix = "ENC["
+	Suffix = "]"
+)
+
+// KeyProvider decrypts the ciphertext of encrypted values. Implementations
+// may hold a key (like AESGCM), or delegate to an external service (e.g. a
+// cloud KMS) or library (e.g. age).
+type KeyProvider interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to the KeyProvider interface.
+type KeyProviderFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+// Decrypt implements KeyProvider.
+func (k KeyProviderFunc) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return k(ctx, ciphertext)
+}
+
+// IsEncrypted returns true if s has the form of an encrypted value.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, Prefix) && strings.HasSuffix(s, Suffix) && len(s) >= len(Prefix)+len(Suffix)
+}
+
+// Format returns the encrypted value holding ciphertext, for tools that
+// encrypt configuration values.
+func Format(ciphertext []byte) string {
+	return Prefix + base64.StdEncoding.EncodeToString(ciphertext) + Suffix
+}
+
+// DecryptError is returned when an encrypted value cannot be decrypted.
+type DecryptError struct {
+	// Path contains the Go names of the fields leading to the value (with
+	// "[i]" or "[key]" elements for slice/array indices and map keys).
+	Path []string
+	Err  error
+}
+
+func (d *DecryptError) Error() string {
+	return fmt.Sprintf("failed to decrypt value of field %q: %s", strings.Join(d.Path, "."), d.Err)
+}
+
+func (d *DecryptError) Unwrap() error {
+	return d.Err
+}
+
+// FieldPath implements dials.FieldPathError.
+func (d *DecryptError) FieldPath() []string {
+	return d.Path
+}
+
+// Decrypt walks v (a value produced by a dials.Source), replacing every
+// encrypted string with its plaintext.
+func Decrypt(ctx context.Context, kp KeyProvider, v reflect.Value) error {
+	return decrypt(ctx, kp, v, nil)
+}
+
+func decrypt(ctx context.Context, kp KeyProvider, v reflect.Value, path []string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return decrypt(ctx, kp, v.Elem(), path)
+	case reflect.String:
+		if !IsEncrypted(v.String()) {
+			return nil
+		}
+		if !v.CanSet() {
+			return &DecryptError{Path: path, Err: fmt.Errorf("value is not settable")}
+		}
+		plaintext, err := decryptString(ctx, kp, v.String())
+		if err != nil {
+			return &DecryptError{Path: path, Err: err}
+		}
+		v.SetString(plaintext)
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := decrypt(ctx, kp, v.Index(i), append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		// map elements aren't addressable, so only string elements (or
+		// pointers to strings) are decrypted, by replacing them
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := decryptMapElem(ctx, kp, v, iter.Key(), iter.Value(), path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if err := decrypt(ctx, kp, v.Field(i), append(path[:len(path):len(path)], sf.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// decryptMapElem decrypts the element elem of the map m at key, if it's an
+// encrypted string.
+func decryptMapElem(ctx context.Context, kp KeyProvider, m, key, elem reflect.Value, path []string) error {
+	if elem.Kind() == reflect.Ptr {
+		// the pointed-to string is settable
+		return decrypt(ctx, kp, elem, append(path[:len(path):len(path)], fmt.Sprintf("[%v]", key)))
+	}
+	if elem.Kind() != reflect.String || !IsEncrypted(elem.String()) {
+		return nil
+	}
+	plaintext, err := decryptString(ctx, kp, elem.String())
+	if err != nil {
+		return &DecryptError{Path: append(path[:len(path):len(path)], fmt.Sprintf("[%v]", key)), Err: err}
+	}
+	m.SetMapIndex(key, reflect.ValueOf(plaintext).Convert(elem.Type()))
+	return nil
+}
+
+// decryptString decrypts the encrypted value s.
+func decryptString(ctx context.Context, kp KeyProvider, s string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(s[len(Prefix) : len(s)-len(Suffix)])
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext encoding: %w", err)
+	}
+	plaintext, err := kp.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NewSource wraps inner, decrypting the encrypted values in the values it
+// returns (or reports, if it implements dials.Watcher) with kp.
+func NewSource(inner dials.Source, kp KeyProvider) dials.Source {
+	s := source{inner: inner, kp: kp}
+	if w, ok := inner.(dials.Watcher); ok {
+		return &watchingSource{source: s, watcher: w}
+	}
+	return &s
+}
+
+type source struct {
+	inner dials.Source
+	kp    KeyProvider
+}
+
+func (s *source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	v, err := s.inner.Value(ctx, t)
+	if err != nil {
+		return v, err
+	}
+	if decryptErr := Decrypt(ctx, s.kp, v); decryptErr != nil {
+		return reflect.Value{}, decryptErr
+	}
+	return v, nil
+}
+
+type watchingSource struct {
+	source
+	watcher dials.Watcher
+}
+
+func (w *watchingSource) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	return w.watcher.Watch(ctx, t, &decryptingWatchArgs{WatchArgs: args, ctx: ctx, kp: w.kp})
+}
+
+type decryptingWatchArgs struct {
+	dials.WatchArgs
+	// ctx is the context passed to Watch
+	ctx context.Context
+	kp  KeyProvider
+}
+
+// Go starts f with the wrapped WatchArgs (see dials.WatchGo).
+func (d *decryptingWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(d.ctx, d.WatchArgs, f)
+}
+
+func (d *decryptingWatchArgs) ReportNewValue(ctx context.Context, val reflect.Value) error {
+	if err := Decrypt(ctx, d.kp, val); err != nil {
+		return d.WatchArgs.ReportError(ctx, err)
+	}
+	return d.WatchArgs.ReportNewValue(ctx, val)
+}
+
+func (d *decryptingWatchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Value) error {
+	if err := Decrypt(ctx, d.kp, val); err != nil {
+		return err
+	}
+	return d.WatchArgs.BlockingReportNewValue(ctx, val)
+}
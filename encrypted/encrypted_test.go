@@ -0,0 +1,73 @@
+package encrypted
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type dbConfig struct {
+	User     string
+	Password string
+}
+
+type config struct {
+	DB     dbConfig
+	Tokens []string
+	Keys   map[string]string
+}
+
+func TestDecryptingSource(t *testing.T) {
+	t.Parallel()
+	kp := &AESGCM{Key: []byte("0123456789abcdef0123456789abcdef")}
+	password, err := kp.Encrypt("hunter2")
+	require.NoError(t, err)
+	token, err := kp.Encrypt("t0k3n")
+	require.NoError(t, err)
+	key, err := kp.Encrypt("s3cr3t")
+	require.NoError(t, err)
+
+	src := static.StringSource{
+		Data: fmt.Sprintf(`
+db:
+  user: admin
+  password: %s
+tokens:
+  - plain
+  - %s
+keys:
+  api: %s
+`, password, token, key),
+		Decoder: &yaml.Decoder{},
+	}
+	d, err := dials.Config(context.Background(), &config{}, NewSource(&src, kp))
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, dbConfig{User: "admin", Password: "hunter2"}, cfg.DB)
+	assert.Equal(t, []string{"plain", "t0k3n"}, cfg.Tokens)
+	assert.Equal(t, map[string]string{"api": "s3cr3t"}, cfg.Keys)
+
+	// the wrong key fails, reporting the field
+	wrongKey := &AESGCM{Key: []byte("fedcba9876543210fedcba9876543210")}
+	_, err = dials.Config(context.Background(), &config{}, NewSource(&src, wrongKey))
+	require.Error(t, err)
+	ce := &dials.ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 1)
+	assert.Equal(t, "DB.Password", ce.Errors[0].Path)
+}
+
+func TestIsEncrypted(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsEncrypted("ENC[]"))
+	assert.True(t, IsEncrypted(Format([]byte{1, 2, 3})))
+	assert.False(t, IsEncrypted("ENC["))
+	assert.False(t, IsEncrypted("hunter2"))
+}
@@ -0,0 +1,228 @@
+package dials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConfigError is a single problem encountered while loading a configuration.
+type ConfigError struct {
+	// Source is the source whose value had the problem, or nil for
+	// problems with the composed configuration (e.g. a missing required
+	// field).
+	Source Source
+	// Path contains the Go names of the fields leading to the field with
+	// the problem, joined with dots (e.g. "Database.Port"), or is empty if
+	// the problem isn't specific to a field.
+	Path string
+	Err  error
+}
+
+func (c *ConfigError) Error() string {
+	msg := c.Err.Error()
+	if c.Path != "" {
+		msg = c.Path + ": " + msg
+	}
+	return msg
+}
+
+func (c *ConfigError) Unwrap() error {
+	return c.Err
+}
+
+// ConfigErrors is returned by Config when loading the configuration fails,
+// collecting every problem found rather than just the first: errors from each
+// source (per field, for sources that report them, see [FieldPathError]), and
+// missing required fields and failed validation rules (see [RequiredTag] and
+// [ValidateTag]). It's also passed to OnWatchedError for updates with missing
+// or invalid fields.
+//
+// errors.Is and errors.As match any of the individual errors.
+type ConfigErrors struct {
+	Errors []*ConfigError
+}
+
+func (c *ConfigErrors) Error() string {
+	if len(c.Errors) == 1 {
+		return c.Errors[0].Error()
+	}
+	// with several errors, note which source each came from
+	msgs := make([]string, len(c.Errors))
+	for i, e := range c.Errors {
+		msgs[i] = e.Error()
+		if e.Source != nil {
+			msgs[i] += " (" + describeSource(e.Source) + ")"
+		}
+	}
+	return fmt.Sprintf("%d configuration errors: %s", len(c.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual errors.
+func (c *ConfigErrors) Unwrap() []error {
+	errs := make([]error, len(c.Errors))
+	for i, e := range c.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Is returns true if any of the individual errors matches target.
+func (c *ConfigErrors) Is(target error) bool {
+	for _, e := range c.Errors {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the individual errors matching target.
+func (c *ConfigErrors) As(target interface{}) bool {
+	for _, e := range c.Errors {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldPathError may be implemented by errors returned from a Source's Value
+// method that concern a specific field (e.g. a value that failed to parse),
+// so Config can report the field's path. Sources may report several such
+// errors by returning an error with an `Unwrap() []error` method.
+type FieldPathError interface {
+	error
+	// FieldPath returns the Go names of the fields leading to the field.
+	FieldPath() []string
+}
+
+// sourceErrors splits err (from source) into ConfigErrors, one for each
+// error it aggregates.
+func sourceErrors(source Source, err error) []*ConfigError {
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []*ConfigError{}
+		for _, e := range multi.Unwrap() {
+			errs = append(errs, sourceErrors(source, e)...)
+		}
+		return errs
+	}
+	if ce, ok := err.(*ConfigError); ok {
+		if ce.Source != nil {
+			return []*ConfigError{ce}
+		}
+		return []*ConfigError{{Source: source, Path: ce.Path, Err: ce.Err}}
+	}
+	ce := &ConfigError{Source: source, Err: err}
+	var fpe FieldPathError
+	if errors.As(err, &fpe) {
+		ce.Path = strings.Join(fpe.FieldPath(), ".")
+	}
+	return []*ConfigError{ce}
+}
+
+// DecodeError is returned by the bundled decoders when the data they're
+// given can't be decoded into the configuration type: it's malformed, or has
+// a value of the wrong type for a field. Err is the decoding library's error
+// (e.g. a *json.SyntaxError), which may be matched with errors.As for
+// details such as the offset of the problem.
+type DecodeError struct {
+	// Format names the data's format (e.g. "JSON").
+	Format string
+	Err    error
+}
+
+func (d *DecodeError) Error() string {
+	return "failed to decode " + d.Format + ": " + d.Err.Error()
+}
+
+func (d *DecodeError) Unwrap() error {
+	return d.Err
+}
+
+// UnavailableError is returned by sources that couldn't reach the data
+// they're reading (e.g. a missing file, or a server that couldn't be
+// connected to), as opposed to having read data they couldn't decode (see
+// [DecodeError]). It may be a transient problem, so callers may wish to
+// retry, or fall back to other configuration (see
+// [Params.OptionalSources]).
+type UnavailableError struct {
+	Err error
+}
+
+func (u *UnavailableError) Error() string {
+	return "source unavailable: " + u.Err.Error()
+}
+
+func (u *UnavailableError) Unwrap() error {
+	return u.Err
+}
+
+// VerificationError is returned by Config when the initial configuration
+// fails verification (missing required fields, failed validation rules or a
+// failing Verify method, see [VerifiedConfig]), and passed to
+// OnWatchedError when a new version does, with Err the cause.
+type VerificationError struct {
+	// Initial is set for the initial configuration, passed to Config.
+	Initial bool
+	Err     error
+}
+
+func (v *VerificationError) Error() string {
+	if v.Initial {
+		return "initial configuration verification failed: " + v.Err.Error()
+	}
+	// updates have always been reported with the cause's message alone
+	return v.Err.Error()
+}
+
+func (v *VerificationError) Unwrap() error {
+	return v.Err
+}
+
+// ComposeError is returned when a source's value can't be overlaid onto the
+// configuration (e.g. it has a field that can't be set).
+type ComposeError struct {
+	// Source is the source whose value couldn't be overlaid.
+	Source Source
+	// Path contains the Go names of the fields leading to the field that
+	// couldn't be set, joined with dots, or is empty if it isn't known.
+	Path string
+	Err  error
+}
+
+func (c *ComposeError) Error() string {
+	return "failed to compose the value " + describeSource(c.Source) + ": " + c.Err.Error()
+}
+
+func (c *ComposeError) Unwrap() error {
+	return c.Err
+}
+
+// overlayFieldError is returned by an overlayer when it fails to set a
+// struct field, with err the cause, which may be another overlayFieldError
+// for a field within it.
+type overlayFieldError struct {
+	name   string
+	number int
+	err    error
+}
+
+func (o *overlayFieldError) Error() string {
+	return fmt.Sprintf("failed to set field %q (number %d): %s", o.name, o.number, o.err)
+}
+
+func (o *overlayFieldError) Unwrap() error {
+	return o.err
+}
+
+// FieldPath returns the names of the field and those within it leading to
+// the one that couldn't be set.
+func (o *overlayFieldError) FieldPath() []string {
+	path := []string{o.name}
+	var inner FieldPathError
+	if errors.As(o.err, &inner) {
+		path = append(path, inner.FieldPath()...)
+	}
+	return path
+}
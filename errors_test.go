@@ -0,0 +1,184 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pathError struct {
+	path []string
+	err  error
+}
+
+func (p *pathError) Error() string       { return p.err.Error() }
+func (p *pathError) FieldPath() []string { return p.path }
+func (p *pathError) Unwrap() error       { return p.err }
+
+type multiError []error
+
+func (m multiError) Error() string   { return "multiple errors" }
+func (m multiError) Unwrap() []error { return m }
+
+// failingSource returns err from Value
+type failingSource struct {
+	err error
+}
+
+func (f *failingSource) Value(context.Context, *Type) (reflect.Value, error) {
+	return reflect.Value{}, f.err
+}
+
+// rawSource returns val from Value, without converting it to the type it's
+// passed.
+type rawSource struct {
+	val interface{}
+}
+
+func (r *rawSource) Value(context.Context, *Type) (reflect.Value, error) {
+	return reflect.ValueOf(r.val), nil
+}
+
+func TestConfigErrorsAggregated(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name  string
+		Inner struct {
+			Port int
+		}
+	}
+
+	errUnavailable := errors.New("unavailable")
+	errParse := errors.New("invalid syntax")
+	first := &failingSource{err: errUnavailable}
+	second := &failingSource{err: multiError{
+		&pathError{path: []string{"Name"}, err: errParse},
+		&pathError{path: []string{"Inner", "Port"}, err: errParse},
+	}}
+
+	_, err := Config(context.Background(), &testConfig{}, first, second)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.ErrorIs(t, err, errParse)
+
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 3)
+	assert.Equal(t, &ConfigError{Source: first, Err: errUnavailable}, ce.Errors[0])
+	assert.Equal(t, []string{"", "Name", "Inner.Port"},
+		[]string{ce.Errors[0].Path, ce.Errors[1].Path, ce.Errors[2].Path})
+	assert.Same(t, second, ce.Errors[2].Source)
+	assert.Equal(t, "3 configuration errors: unavailable (from *dials.failingSource); "+
+		"Name: invalid syntax (from *dials.failingSource); Inner.Port: invalid syntax (from *dials.failingSource)",
+		err.Error())
+
+	pe := &pathError{}
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, []string{"Name"}, pe.path)
+}
+
+func TestConfigErrorsSingle(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name string
+	}
+
+	errUnavailable := errors.New("unavailable")
+	_, err := Config(context.Background(), &testConfig{}, &failingSource{err: errUnavailable})
+	assert.EqualError(t, err, "unavailable")
+	assert.ErrorIs(t, err, errUnavailable)
+}
+
+func TestVerificationError(t *testing.T) {
+	t.Parallel()
+	type ptrifiedConfig struct {
+		Valid *bool
+		Foo   *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := Config(ctx, &configurableVerifier{})
+	assert.EqualError(t, err, "initial configuration verification failed: fail")
+	vErr := &VerificationError{}
+	require.True(t, errors.As(err, &vErr))
+	assert.True(t, vErr.Initial)
+	assert.ErrorIs(t, err, errFailVerifier)
+
+	// failing updates are reported with non-initial VerificationErrors
+	errCh := make(chan error, 1)
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Params[configurableVerifier]{
+		OnWatchedError: func(_ context.Context, err error, _, _ *configurableVerifier) { errCh <- err },
+	}.Config(ctx, &configurableVerifier{Valid: true}, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	falseVal := false
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Valid: &falseVal}))
+	err = <-errCh
+	assert.EqualError(t, err, "fail")
+	require.True(t, errors.As(err, &vErr))
+	assert.False(t, vErr.Initial)
+}
+
+func TestComposeError(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Inner struct {
+			When time.Time
+		}
+	}
+	type notTime struct {
+		Sec int
+	}
+	type badConfig struct {
+		Inner *struct {
+			When notTime
+		}
+	}
+
+	src := &rawSource{val: badConfig{Inner: &struct{ When notTime }{When: notTime{Sec: 1}}}}
+	_, err := Config(context.Background(), &testConfig{}, src)
+	require.Error(t, err)
+	ce := &ComposeError{}
+	require.True(t, errors.As(err, &ce))
+	assert.Same(t, src, ce.Source)
+	assert.Equal(t, "Inner.When", ce.Path)
+	assert.Contains(t, err.Error(), "failed to compose the value from *dials.rawSource")
+}
+
+func TestDecodeAndUnavailableErrors(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name string
+	}
+
+	syntaxErr := &DecodeError{Format: "JSON", Err: errors.New("unexpected end of input")}
+	unreachable := &UnavailableError{Err: errors.New("connection refused")}
+	bad := &failingSource{err: syntaxErr}
+	down := &failingSource{err: unreachable}
+	_, err := Config(context.Background(), &testConfig{}, bad, down)
+	require.Error(t, err)
+
+	// each problem is matchable along with the source reporting it
+	dErr := &DecodeError{}
+	require.True(t, errors.As(err, &dErr))
+	assert.Same(t, syntaxErr, dErr)
+	uErr := &UnavailableError{}
+	require.True(t, errors.As(err, &uErr))
+	assert.Same(t, unreachable, uErr)
+
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 2)
+	assert.Same(t, bad, ce.Errors[0].Source)
+	assert.Same(t, down, ce.Errors[1].Source)
+	assert.EqualError(t, ce.Errors[0], "failed to decode JSON: unexpected end of input")
+	assert.EqualError(t, ce.Errors[1], "source unavailable: connection refused")
+}
@@ -0,0 +1,205 @@
+package dials
+
+import "sync"
+
+// OverflowPolicy determines which configuration a [Subscription] discards
+// when a new configuration is installed while its buffer is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the newly installed configuration, so the
+	// subscriber receives the configurations that were already buffered.
+	// This is the policy of the channel returned by [Dials.Events].
+	DropNewest OverflowPolicy = iota
+	// DropOldest discards the oldest buffered configuration, so the
+	// subscriber always receives the latest configuration.
+	DropOldest
+)
+
+// Subscription delivers every newly installed configuration to a single
+// consumer through its own buffered channel. Subscriptions are independent: a
+// slow consumer only loses configurations from its own buffer (according to
+// its OverflowPolicy), never those destined for other subscriptions.
+type Subscription[T any] struct {
+	c      chan *T
+	policy OverflowPolicy
+	reg    *subscriptions[T]
+}
+
+// Events returns the subscription's channel. It's closed by Unsubscribe, or
+// by [Dials.Close] once no more configurations can be installed.
+func (s *Subscription[T]) Events() <-chan *T {
+	return s.c
+}
+
+// Unsubscribe stops delivery to the subscription and closes its channel. It
+// may be called more than once.
+func (s *Subscription[T]) Unsubscribe() {
+	s.reg.remove(s)
+}
+
+func (s *Subscription[T]) deliver(vc *versionedConfig[T]) {
+	send(s.c, vc.cfg, s.policy)
+}
+
+func (s *Subscription[T]) closeChan() {
+	close(s.c)
+}
+
+// Update is a newly installed configuration, along with the token
+// identifying its version (whose Generation distinguishes it from other
+// versions).
+type Update[T any] struct {
+	Config  *T
+	Version CfgSerial[T]
+}
+
+// UpdateSubscription is like [Subscription], but delivers each configuration
+// as an [Update], so consumers know its version without racing a separate
+// call to [Dials.ViewVersion].
+type UpdateSubscription[T any] struct {
+	c      chan Update[T]
+	policy OverflowPolicy
+	reg    *subscriptions[T]
+}
+
+// Updates returns the subscription's channel. It's closed by Unsubscribe, or
+// by [Dials.Close] once no more configurations can be installed.
+func (s *UpdateSubscription[T]) Updates() <-chan Update[T] {
+	return s.c
+}
+
+// Unsubscribe stops delivery to the subscription and closes its channel. It
+// may be called more than once.
+func (s *UpdateSubscription[T]) Unsubscribe() {
+	s.reg.remove(s)
+}
+
+func (s *UpdateSubscription[T]) deliver(vc *versionedConfig[T]) {
+	send(s.c, Update[T]{Config: vc.cfg, Version: CfgSerial[T]{s: vc.serial, cfg: vc.cfg}}, s.policy)
+}
+
+func (s *UpdateSubscription[T]) closeChan() {
+	close(s.c)
+}
+
+// send delivers e on c without blocking, applying policy if the buffer is
+// full. The registry's lock must be held, so this is the only sender.
+func send[E any](c chan E, e E, policy OverflowPolicy) {
+	for {
+		select {
+		case c <- e:
+			return
+		default:
+		}
+		if policy != DropOldest {
+			return
+		}
+		// the buffer has at least one slot, so after this there's room
+		// (either we or the consumer emptied one)
+		select {
+		case <-c:
+		default:
+		}
+	}
+}
+
+// subscriber is implemented by Subscription and UpdateSubscription.
+type subscriber[T any] interface {
+	deliver(vc *versionedConfig[T])
+	closeChan()
+}
+
+// subscriptions is the registry of a Dials instance's subscribers.
+type subscriptions[T any] struct {
+	mu     sync.Mutex
+	subs   map[subscriber[T]]struct{}
+	closed bool
+}
+
+func newSubscriptions[T any]() *subscriptions[T] {
+	return &subscriptions[T]{subs: map[subscriber[T]]struct{}{}}
+}
+
+// add registers a new subscription. If the registry is closed, its channel is
+// closed immediately.
+func (r *subscriptions[T]) add(buffer int, policy OverflowPolicy) *Subscription[T] {
+	s := &Subscription[T]{c: make(chan *T, bufferSize(buffer)), policy: policy, reg: r}
+	r.register(s)
+	return s
+}
+
+// addUpdates is add for UpdateSubscriptions.
+func (r *subscriptions[T]) addUpdates(buffer int, policy OverflowPolicy) *UpdateSubscription[T] {
+	s := &UpdateSubscription[T]{c: make(chan Update[T], bufferSize(buffer)), policy: policy, reg: r}
+	r.register(s)
+	return s
+}
+
+func bufferSize(buffer int) int {
+	if buffer < 1 {
+		return 1
+	}
+	return buffer
+}
+
+func (r *subscriptions[T]) register(s subscriber[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		s.closeChan()
+		return
+	}
+	r.subs[s] = struct{}{}
+}
+
+// remove unregisters s and closes its channel, unless that's already
+// happened.
+func (r *subscriptions[T]) remove(s subscriber[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[s]; ok {
+		delete(r.subs, s)
+		s.closeChan()
+	}
+}
+
+// publish delivers the configuration version vc to every subscription.
+func (r *subscriptions[T]) publish(vc *versionedConfig[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for s := range r.subs {
+		s.deliver(vc)
+	}
+}
+
+// close closes every subscription's channel. Subscriptions added later are
+// closed immediately.
+func (r *subscriptions[T]) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for s := range r.subs {
+		delete(r.subs, s)
+		s.closeChan()
+	}
+}
+
+// SubscribeEvents registers a new Subscription, which receives every
+// configuration installed after this call through a channel holding up to
+// buffer configurations (at least 1) that the consumer hasn't received yet.
+// When the buffer is full, policy determines which configuration is
+// discarded. Unlike the single channel returned by Events, any number of
+// consumers may each have their own subscription.
+func (d *Dials[T]) SubscribeEvents(buffer int, policy OverflowPolicy) *Subscription[T] {
+	return d.subs.add(buffer, policy)
+}
+
+// SubscribeUpdates is like SubscribeEvents, but the subscription delivers
+// each configuration along with its version.
+func (d *Dials[T]) SubscribeUpdates(buffer int, policy OverflowPolicy) *UpdateSubscription[T] {
+	return d.subs.addUpdates(buffer, policy)
+}
@@ -0,0 +1,74 @@
+package dials
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	latest := d.SubscribeEvents(2, DropOldest)
+	oldest := d.SubscribeEvents(2, DropNewest)
+	gone := d.SubscribeEvents(1, DropNewest)
+	gone.Unsubscribe()
+	gone.Unsubscribe()
+	_, ok := <-gone.Events()
+	assert.False(t, ok)
+
+	// the others don't read until all three have been installed
+	installed := d.SubscribeEvents(1, DropNewest)
+	for _, addr := range []string{"b", "c", "d"} {
+		w.send(ctx, rollbackValue(addr))
+		assert.Equal(t, addr, (<-installed.Events()).Addr)
+	}
+	assert.Equal(t, "c", (<-latest.Events()).Addr)
+	assert.Equal(t, "d", (<-latest.Events()).Addr)
+	assert.Equal(t, "b", (<-oldest.Events()).Addr)
+	assert.Equal(t, "c", (<-oldest.Events()).Addr)
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+
+	require.NoError(t, d.Close(ctx))
+	_, ok = <-latest.Events()
+	assert.False(t, ok)
+	_, ok = <-d.SubscribeEvents(1, DropOldest).Events()
+	assert.False(t, ok)
+}
+
+func TestSubscribeUpdates(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+	assert.Zero(t, d.Version())
+
+	updates := d.SubscribeUpdates(2, DropOldest)
+	for _, addr := range []string{"b", "c"} {
+		w.send(ctx, rollbackValue(addr))
+	}
+	for i, addr := range []string{"b", "c"} {
+		u := <-updates.Updates()
+		assert.Equal(t, addr, u.Config.Addr)
+		assert.Equal(t, uint64(i+1), u.Version.Generation())
+	}
+	cfg, tok := d.ViewVersion()
+	assert.Equal(t, uint64(2), d.Version())
+	assert.Equal(t, tok.Generation(), d.Version())
+	assert.Equal(t, "c", cfg.Addr)
+
+	updates.Unsubscribe()
+	_, ok := <-updates.Updates()
+	assert.False(t, ok)
+}
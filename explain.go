@@ -0,0 +1,289 @@
+package dials
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// FieldOrigin is a value supplied for a field.
+type FieldOrigin struct {
+	// Source is the source that supplied Value, or nil for the default
+	// from the template passed to Config.
+	Source Source
+	Value  interface{}
+}
+
+// FieldExplanation describes how a field's value in a configuration was
+// determined.
+type FieldExplanation struct {
+	// Path contains the Go names of the fields leading to this field,
+	// joined with dots (e.g. "Database.Port").
+	Path string
+	// Value is the field's value in the configuration.
+	Value interface{}
+	// Source is the highest-precedence source that set the field, or nil
+	// if the field has its default value.
+	Source Source
+	// Overridden contains the lower-precedence values (starting with the
+	// default) that were overridden, in order of precedence.
+	Overridden []FieldOrigin
+	// Conflict is true if a lower-precedence source set the field to a
+	// different value, which was overridden. (slice fields whose values
+	// are appended, and map fields whose entries are merged, never
+	// conflict; see [SliceMergeTag])
+	Conflict bool
+	// Secret is true if the field is secret (see [SecretTag]) or
+	// [Params].Redact returns true for it, so its values must not be
+	// displayed.
+	Secret bool
+}
+
+// ExplainRequester may be implemented by a Source (e.g. the flag source, with
+// an "explain" flag) to request that Config print an explanation of the
+// composed configuration (see [Dials.Explain]) and return
+// ErrExplainRequested, rather than a Dials instance.
+type ExplainRequester interface {
+	ExplainRequested() bool
+}
+
+// ErrExplainRequested is returned by Config after it has written the
+// explanation of the configuration requested by an [ExplainRequester] to
+// [Params].ExplainOutput. Callers will usually exit the process (see
+// [github.com/vimeo/dials/sources/flag.ExitIfExplained]).
+var ErrExplainRequested = errors.New("configuration explanation requested")
+
+// Explain describes how the value of every leaf field of the current
+// configuration was determined: its value, the source that supplied it, and
+// the lower-precedence values it overrode. Fields are listed in field order,
+// with nested structs described by their fields.
+func (d *Dials[T]) Explain() []FieldExplanation {
+	v := d.loadVersion()
+	return explain(reflect.ValueOf(v.cfg), reflect.ValueOf(v.template), v.sources, d.params.SliceMerge, d.params.Redact)
+}
+
+// explain implements Explain for the composed configuration cfg (a pointer
+// to a struct, or a pointer to a reflect.Value holding one), with redact
+// (which may be nil) from Params.Redact.
+func explain(cfg, template reflect.Value, sources []sourceValue, sliceMerge SliceMerge, redact func(fieldPath []string) bool) []FieldExplanation {
+	root := derefConfig(cfg.Elem())
+	exps := []FieldExplanation{}
+	walkLeaves(nil, root, func(sf reflect.StructField, path []string, final reflect.Value) {
+		exp := FieldExplanation{
+			Path:   strings.Join(path, "."),
+			Value:  final.Interface(),
+			Secret: secretPath(root.Type(), path) || redactedPath(redact, path),
+		}
+		prev := FieldOrigin{Value: originValue(fieldByPath(template, path, false), final.Type())}
+		for _, sv := range sources {
+			if sv.disabled {
+				continue
+			}
+			val := fieldByPath(sv.value, path, true)
+			if !val.IsValid() {
+				if !replacedByPath(sv.value, path) {
+					continue
+				}
+				// the field is zeroed by a nested struct that
+				// the source replaces as a whole
+				exp.Overridden = append(exp.Overridden, prev)
+				prev = FieldOrigin{Source: sv.source, Value: reflect.Zero(final.Type()).Interface()}
+				continue
+			}
+			exp.Overridden = append(exp.Overridden, prev)
+			prev = FieldOrigin{Source: sv.source, Value: originValue(val, final.Type())}
+		}
+		exp.Source = prev.Source
+		if !mergedField(sf, final, sliceMerge) {
+			for _, o := range exp.Overridden {
+				if o.Source != nil && !reflect.DeepEqual(o.Value, exp.Value) {
+					exp.Conflict = true
+				}
+			}
+		}
+		exps = append(exps, exp)
+	})
+	return exps
+}
+
+// mergedField returns true if the values of the field sf (with composed value
+// final) from different sources are combined (see SliceMergeTag), rather
+// than overriding each other, so they never conflict.
+func mergedField(sf reflect.StructField, final reflect.Value, sliceMerge SliceMerge) bool {
+	switch final.Kind() {
+	case reflect.Slice:
+		mode, _ := fieldSliceMerge(sf, sliceMerge)
+		return mode == SliceMergeAppend
+	case reflect.Map:
+		merge, _ := fieldMapMerge(sf)
+		return merge
+	default:
+		return false
+	}
+}
+
+// walkLeaves calls leaf with the StructField, path and value of each leaf
+// field of the struct v, in field order.
+func walkLeaves(path []string, v reflect.Value, leaf func(sf reflect.StructField, path []string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		switch sf.Type.Kind() {
+		case reflect.Chan, reflect.Func:
+			continue
+		default:
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		f := v.Field(i)
+		if s, ok := structOf(reflect.Value{}, f); ok {
+			walkLeaves(fieldPath, s.new, leaf)
+			continue
+		}
+		leaf(sf, fieldPath, f)
+	}
+}
+
+// fieldByPath follows the field names in path from v (a struct or a pointer
+// to one), returning an invalid Value if any field is missing, or (if
+// nilUnset is true) any pointer, map, slice or interface along the way
+// (including the field itself) is nil.
+func fieldByPath(v reflect.Value, path []string, nilUnset bool) reflect.Value {
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return v
+		}
+	}
+	if nilUnset && kindNilable(v.Kind()) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// replacedByPath indicates whether any of the nested structs leading to the
+// field at path in the pointerified value v is replaced as a whole (see
+// SliceMergeTag) with v, because it sets some of its fields.
+func replacedByPath(v reflect.Value, path []string) bool {
+	for _, name := range path[:len(path)-1] {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return false
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return false
+		}
+		sf, ok := v.Type().FieldByName(name)
+		if !ok {
+			return false
+		}
+		v = v.FieldByIndex(sf.Index)
+		if replace, _ := fieldStructReplace(sf); replace && anySet(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// originValue returns the value of v as it would appear in a field of type
+// t, dereferencing pointers added by pointerification.
+func originValue(v reflect.Value, t reflect.Type) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Map && t.Kind() == reflect.Map && v.Type() != t {
+		// convert the pointerified values of a map of structs
+		out := reflect.New(t).Elem()
+		if err := newOverlayer().overlayMap(out, v, false); err == nil {
+			return out.Interface()
+		}
+	}
+	if v.Kind() == reflect.Ptr && v.Type() != t {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// WriteExplanation writes a human-readable description of exps (as returned
+// by [Dials.Explain]) to w, with one line per field followed by an indented
+// line for each overridden value. Fields with conflicting values from
+// different sources are marked with "[conflict]". The values of secret fields
+// are replaced by RedactedValue.
+func WriteExplanation(w io.Writer, exps []FieldExplanation) error {
+	for _, exp := range exps {
+		conflict := ""
+		if exp.Conflict {
+			conflict = " [conflict]"
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s (%s)%s\n",
+			exp.Path, exp.formatValue(exp.Value), describeSource(exp.Source), conflict); err != nil {
+			return err
+		}
+		for i := len(exp.Overridden) - 1; i >= 0; i-- {
+			o := exp.Overridden[i]
+			if _, err := fmt.Fprintf(w, "\toverrides %s (%s)\n",
+				exp.formatValue(o.Value), describeSource(o.Source)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func describeSource(s Source) string {
+	if s == nil {
+		return "default"
+	}
+	return "from " + sourceDescription(s)
+}
+
+// formatValue formats v (one of exp's values) for display, redacting it if
+// exp is secret.
+func (exp *FieldExplanation) formatValue(v interface{}) string {
+	if exp.Secret {
+		return RedactedValue
+	}
+	return formatExplainValue(v)
+}
+
+func formatExplainValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		v = rv.Elem().Interface()
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// explainRequested returns true if any of sources implements
+// ExplainRequester and requested an explanation.
+func explainRequested(sources []Source) bool {
+	for _, s := range sources {
+		if er, ok := s.(ExplainRequester); ok && er.ExplainRequested() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package dials
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type explainConfig struct {
+	Name  string
+	Inner struct {
+		Port int
+		Host string
+	}
+	Count int
+}
+
+type ptrifiedExplainConfig struct {
+	Name  *string
+	Inner *struct {
+		Port *int
+		Host *string
+	}
+	Count *int
+}
+
+// explainingSource requests an explanation if requested is set
+type explainingSource struct {
+	fakeSource
+	requested bool
+}
+
+func (e *explainingSource) ExplainRequested() bool { return e.requested }
+
+func (*explainingSource) String() string { return "explainer" }
+
+func explainSources() (*fakeSource, *explainingSource) {
+	name, host, port := "first", "example.com", 80
+	firstVal := ptrifiedExplainConfig{Name: &name}
+	firstVal.Inner = &struct {
+		Port *int
+		Host *string
+	}{Port: &port, Host: &host}
+
+	name2, port2 := "second", 8080
+	secondVal := ptrifiedExplainConfig{Name: &name2}
+	secondVal.Inner = &struct {
+		Port *int
+		Host *string
+	}{Port: &port2}
+	return &fakeSource{outVal: firstVal}, &explainingSource{fakeSource: fakeSource{outVal: secondVal}}
+}
+
+func TestExplain(t *testing.T) {
+	first, second := explainSources()
+	tmpl := explainConfig{Name: "default", Count: 3}
+	tmpl.Inner.Port = 1
+
+	d, err := Config(context.Background(), &tmpl, first, second)
+	require.NoError(t, err)
+
+	assert.Equal(t, []FieldExplanation{
+		{
+			Path: "Name", Value: "second", Source: second,
+			Overridden: []FieldOrigin{{Value: "default"}, {Source: first, Value: "first"}},
+			Conflict:   true,
+		},
+		{
+			Path: "Inner.Port", Value: 8080, Source: second,
+			Overridden: []FieldOrigin{{Value: 1}, {Source: first, Value: 80}},
+			Conflict:   true,
+		},
+		{
+			Path: "Inner.Host", Value: "example.com", Source: first,
+			Overridden: []FieldOrigin{{Value: ""}},
+		},
+		{Path: "Count", Value: 3},
+	}, d.Explain())
+
+	// Config returns (rather than exiting) once it has written the
+	// requested explanation
+	second.requested = true
+	out := bytes.Buffer{}
+	d, err = Params[explainConfig]{ExplainOutput: &out}.Config(context.Background(), &tmpl, first, second)
+	assert.ErrorIs(t, err, ErrExplainRequested)
+	assert.Nil(t, d)
+	assert.Equal(t, `Name = "second" (from explainer) [conflict]
+	overrides "first" (from *dials.fakeSource)
+	overrides "default" (default)
+Inner.Port = 8080 (from explainer) [conflict]
+	overrides 80 (from *dials.fakeSource)
+	overrides 1 (default)
+Inner.Host = "example.com" (from *dials.fakeSource)
+	overrides "" (default)
+Count = 3 (default)
+`, out.String())
+}
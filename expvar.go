@@ -0,0 +1,65 @@
+package dials
+
+import (
+	"expvar"
+	"reflect"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// RedactedValue replaces the values of redacted fields (see [SecretTag] and
+// [Params].Redact) when dials renders a configuration.
+const RedactedValue = "<redacted>"
+
+// ExpvarFunc returns an [expvar.Func] describing the currently installed
+// configuration: its generation and its fields (keyed by Go field name, with
+// nested structs as nested objects). Secret fields (see [SecretTag]) and
+// those for which [Params].Redact returns true are replaced by
+// RedactedValue. The configuration is read
+// whenever the variable is, so it's always current.
+func (d *Dials[T]) ExpvarFunc() expvar.Func {
+	return func() interface{} {
+		cfg, serial := d.ViewVersion()
+		return map[string]interface{}{
+			"generation": serial.Generation(),
+			"config":     redactedConfig(derefConfig(reflect.ValueOf(cfg).Elem()), nil, d.redacts),
+		}
+	}
+}
+
+// PublishExpvar publishes ExpvarFunc under name (e.g. "config"), so the
+// configuration a process is running with appears in /debug/vars. Like
+// [expvar.Publish], it panics if name is already registered.
+func (d *Dials[T]) PublishExpvar(name string) {
+	expvar.Publish(name, d.ExpvarFunc())
+}
+
+// redactedConfig returns the struct v as a map from field name to value
+// (recursing into nested structs), replacing the fields for which redact
+// (if non-nil) returns true with RedactedValue.
+func redactedConfig(v reflect.Value, path []string, redact func(fieldPath []string) bool) map[string]interface{} {
+	out := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		switch sf.Type.Kind() {
+		case reflect.Chan, reflect.Func:
+			continue
+		default:
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		f := v.Field(i)
+		switch s, isStruct := structOf(reflect.Value{}, f); {
+		case redact != nil && redact(fieldPath):
+			out[sf.Name] = RedactedValue
+		case isStruct:
+			out[sf.Name] = redactedConfig(s.new, fieldPath, redact)
+		default:
+			out[sf.Name] = f.Interface()
+		}
+	}
+	return out
+}
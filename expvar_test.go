@@ -0,0 +1,50 @@
+package dials
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpvarFunc(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	type dbConfig struct {
+		User     string
+		Password string
+	}
+	type config struct {
+		Addr   string
+		DB     dbConfig
+		hidden string
+	}
+	type ptrifiedConfig struct {
+		Addr *string
+		DB   *struct {
+			User     *string
+			Password *string
+		}
+	}
+
+	src := &fakeSource{outVal: ptrifiedConfig{}}
+	d, err := Params[config]{
+		Redact: func(fieldPath []string) bool {
+			return len(fieldPath) == 2 && fieldPath[1] == "Password"
+		},
+	}.Config(ctx, &config{Addr: "a", DB: dbConfig{User: "u", Password: "hunter2"}}, src)
+	require.NoError(t, err)
+
+	d.PublishExpvar("dials-test-config")
+	assert.JSONEq(t,
+		`{"generation": 0, "config": {"Addr": "a", "DB": {"User": "u", "Password": "<redacted>"}}}`,
+		expvar.Get("dials-test-config").String())
+
+	out, err := json.Marshal(d.ExpvarFunc().Value())
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "hunter2")
+}
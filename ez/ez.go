@@ -2,7 +2,10 @@ package ez
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/vimeo/dials/decoders/json"
 	"github.com/vimeo/dials/decoders/toml"
 	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/profile"
 	"github.com/vimeo/dials/sources/env"
 	"github.com/vimeo/dials/sources/file"
 	"github.com/vimeo/dials/sources/flag"
@@ -38,6 +42,12 @@ type Params[T any] struct {
 	// watching file source.
 	WatchConfigFile bool
 
+	// ConfigFileBaseDir is the directory that a relative config file path
+	// is resolved against, instead of the working directory. `~` and
+	// environment variables in the path are expanded either way (see
+	// file.ExpandPath).
+	ConfigFileBaseDir string
+
 	// FlagConfig sets the flag NameConfig
 	FlagConfig *flag.NameConfig
 
@@ -82,6 +92,13 @@ type Params[T any] struct {
 	// Note that this does not affect the flags or environment variable
 	// naming.  To manipulate flag naming, see [Params.FlagConfig].
 	FileFieldNameEncoder caseconversion.EncodeCasingFunc
+
+	// Profile selects a profile (e.g. "prod"; see [profile.Select]): the
+	// config file's section for it (under the "profiles" key) is composed
+	// over the rest of the file, and FileEnvFlag also reads the profile's
+	// own file next to the config file (see [profile.Path]), if it
+	// exists, after the config file.
+	Profile string
 }
 
 // DecoderFactory should return the appropriate decoder based on the config file
@@ -99,15 +116,19 @@ type ConfigWithConfigPath[T any] interface {
 	ConfigPath() (string, bool)
 }
 
-func fileSource(cfgPath string, decoder dials.Decoder, watch bool) (dials.Source, error) {
+func fileSource(cfgPath string, decoder dials.Decoder, watch bool, baseDir string) (dials.Source, error) {
 	if watch {
-		fileSrc, fileErr := file.NewWatchingSource(cfgPath, decoder)
+		fileSrc, fileErr := file.NewWatchingSource(cfgPath, decoder, file.WithBaseDir(baseDir))
 		if fileErr != nil {
 			return nil, fmt.Errorf("invalid configuration path %q: %s", cfgPath, fileErr)
 		}
 		return fileSrc, nil
 	}
-	fsrc, fileErr := file.NewSource(cfgPath, decoder)
+	absPath, expandErr := file.ExpandPath(cfgPath, baseDir)
+	if expandErr != nil {
+		return nil, fmt.Errorf("invalid configuration path %q: %s", cfgPath, expandErr)
+	}
+	fsrc, fileErr := file.NewSource(absPath, decoder)
 	if fileErr != nil {
 		return nil, fmt.Errorf("invalid configuration path %q: %s", cfgPath, fileErr)
 	}
@@ -127,18 +148,9 @@ func fileSource(cfgPath string, decoder dials.Decoder, watch bool) (dials.Source
 func ConfigFileEnvFlag[T any, TP ConfigWithConfigPath[T]](ctx context.Context, cfg TP, df DecoderFactory, params Params[T]) (*dials.Dials[T], error) {
 	blank := sourcewrap.Blank{}
 
-	flagSrc := params.FlagSource
-	if flagSrc == nil {
-		flagNameCfg := params.FlagConfig
-		if flagNameCfg == nil {
-			flagNameCfg = flag.DefaultFlagNameConfig()
-		}
-		// flag source isn't substituted so use the flag source
-		fset, flagErr := flag.NewCmdLineSet(flagNameCfg, cfg)
-		if flagErr != nil {
-			return nil, fmt.Errorf("failed to register commandline flags: %s", flagErr)
-		}
-		flagSrc = fset
+	flagSrc, flagErr := flagSource((*T)(cfg), params)
+	if flagErr != nil {
+		return nil, flagErr
 	}
 
 	// If file-watching is not enabled, we should shutdown the monitor
@@ -177,7 +189,7 @@ func ConfigFileEnvFlag[T any, TP ConfigWithConfigPath[T]](ctx context.Context, c
 		// method is never run by `dials.Config`.
 
 		if _, _, vfErr := d.EnableVerification(ctx); vfErr != nil {
-			return nil, fmt.Errorf("initial configuration verification failed: %w", vfErr)
+			return nil, initialVerificationError(vfErr)
 		}
 		// The callback indicated that we shouldn't read any config
 		// file after all.
@@ -189,6 +201,60 @@ func ConfigFileEnvFlag[T any, TP ConfigWithConfigPath[T]](ctx context.Context, c
 		return nil, fmt.Errorf("decoderFactory provided a nil decoder for path: %s", cfgPath)
 	}
 
+	fileSrc, fileErr := fileSource(cfgPath, fileDecoder(decoder, params), params.WatchConfigFile, params.ConfigFileBaseDir)
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	// SetSource blocks until the new config is re-stacked. It will fail if
+	// the file source fails.
+	blankErr := blank.SetSource(ctx, fileSrc)
+	if blankErr != nil {
+		return nil, fmt.Errorf("failed to integrate file source: %w", blankErr)
+	}
+
+	// Enable configuration verification and enable global callbacks.
+	if _, _, vfErr := d.EnableVerification(ctx); vfErr != nil {
+		return nil, initialVerificationError(vfErr)
+	}
+
+	// Drain the event from the events channel so users of that interface
+	// don't see the intermediate config.
+	<-d.Events()
+
+	return d, nil
+}
+
+// initialVerificationError reports err, from verifying the initial
+// configuration with EnableVerification, as Config would have reported it.
+func initialVerificationError(err error) error {
+	var vErr *dials.VerificationError
+	if errors.As(err, &vErr) {
+		err = vErr.Err
+	}
+	return &dials.VerificationError{Initial: true, Err: err}
+}
+
+// flagSource returns params.FlagSource, or if it's nil, a flag source
+// registering flags for cfg with the standard library's flag package.
+func flagSource[T any](cfg *T, params Params[T]) (dials.Source, error) {
+	if params.FlagSource != nil {
+		return params.FlagSource, nil
+	}
+	flagNameCfg := params.FlagConfig
+	if flagNameCfg == nil {
+		flagNameCfg = flag.DefaultFlagNameConfig()
+	}
+	// flag source isn't substituted so use the flag source
+	fset, flagErr := flag.NewCmdLineSet(flagNameCfg, cfg)
+	if flagErr != nil {
+		return nil, fmt.Errorf("failed to register commandline flags: %s", flagErr)
+	}
+	return fset, nil
+}
+
+// fileDecoder wraps decoder with the manglers that params call for.
+func fileDecoder[T any](decoder dials.Decoder, params Params[T]) dials.Decoder {
 	manglers := make([]transform.Mangler, 0, 2)
 
 	if params.FileFieldNameEncoder != nil {
@@ -215,29 +281,99 @@ func ConfigFileEnvFlag[T any, TP ConfigWithConfigPath[T]](ctx context.Context, c
 			manglers...,
 		)
 	}
+	if params.Profile != "" {
+		decoder = profile.NewDecoder(decoder, params.Profile)
+	}
+	return decoder
+}
 
-	fileSrc, fileErr := fileSource(cfgPath, decoder, params.WatchConfigFile)
-	if fileErr != nil {
-		return nil, fileErr
+// FileEnvFlag reads the configuration file at path with the decoder df
+// returns for it, so the common case of a config file whose path is known
+// up front needs a single call. Configuration values provided by the
+// returned Dials are the result of stacking the sources in the following
+// order:
+//   - the contents of cfg (the defaults)
+//   - configuration file (omitted if path is empty)
+//   - environment variables
+//   - flags it registers with the standard library flags package (or
+//     params.FlagSource)
+//
+// The file is watched for changes if params.WatchConfigFile is set. Use
+// ConfigFileEnvFlag instead if the path is itself configured (e.g. by a
+// flag).
+func FileEnvFlag[T any](ctx context.Context, cfg *T, path string, df DecoderFactory, params Params[T]) (*dials.Dials[T], error) {
+	flagSrc, flagErr := flagSource(cfg, params)
+	if flagErr != nil {
+		return nil, flagErr
 	}
 
-	// SetSource blocks until the new config is re-stacked. It will fail if
-	// the file source fails.
-	blankErr := blank.SetSource(ctx, fileSrc)
-	if blankErr != nil {
-		return nil, fmt.Errorf("failed to integrate file source: %w", blankErr)
+	sources := make([]dials.Source, 0, 3)
+	if path != "" {
+		decoder := df(path)
+		if decoder == nil {
+			return nil, fmt.Errorf("decoderFactory provided a nil decoder for path: %s", path)
+		}
+		fileSrc, fileErr := fileSource(path, fileDecoder(decoder, params), params.WatchConfigFile, params.ConfigFileBaseDir)
+		if fileErr != nil {
+			return nil, fileErr
+		}
+		sources = append(sources, fileSrc)
+		if params.Profile != "" {
+			profileSrc, profileErr := profileFileSource(path, decoder, params)
+			if profileErr != nil {
+				return nil, profileErr
+			}
+			if profileSrc != nil {
+				sources = append(sources, profileSrc)
+			}
+		}
 	}
+	sources = append(sources, &env.Source{}, flagSrc)
 
-	// Enable configuration verification and enable global callbacks.
-	if _, _, vfErr := d.EnableVerification(ctx); vfErr != nil {
-		return nil, fmt.Errorf("initial configuration verification failed: %w", vfErr)
+	dp := dials.Params[T]{
+		OnNewConfig:    params.OnNewConfig,
+		OnWatchedError: params.OnWatchedError,
+	}
+	return dp.Config(ctx, cfg, sources...)
+}
+
+// profileFileSource returns a source for the file holding the overrides for
+// params.Profile next to the config file at path, or nil if there's no such
+// file.
+func profileFileSource[T any](path string, decoder dials.Decoder, params Params[T]) (dials.Source, error) {
+	profilePath := profile.Path(path, params.Profile)
+	absPath, expandErr := file.ExpandPath(profilePath, params.ConfigFileBaseDir)
+	if expandErr != nil {
+		return nil, fmt.Errorf("invalid configuration path %q: %s", profilePath, expandErr)
+	}
+	if _, statErr := os.Stat(absPath); errors.Is(statErr, fs.ErrNotExist) {
+		return nil, nil
 	}
+	return fileSource(profilePath, fileDecoder(decoder, params), params.WatchConfigFile, params.ConfigFileBaseDir)
+}
 
-	// Drain the event from the events channel so users of that interface
-	// don't see the intermediate config.
-	<-d.Events()
+// YAMLFileEnvFlag thinly wraps FileEnvFlag with the decoder statically set
+// to YAML.
+func YAMLFileEnvFlag[T any](ctx context.Context, cfg *T, path string, params Params[T]) (*dials.Dials[T], error) {
+	return FileEnvFlag(ctx, cfg, path, func(string) dials.Decoder { return &yaml.Decoder{} }, params)
+}
 
-	return d, nil
+// JSONFileEnvFlag thinly wraps FileEnvFlag with the decoder statically set
+// to JSON.
+func JSONFileEnvFlag[T any](ctx context.Context, cfg *T, path string, params Params[T]) (*dials.Dials[T], error) {
+	return FileEnvFlag(ctx, cfg, path, func(string) dials.Decoder { return &json.Decoder{} }, params)
+}
+
+// CueFileEnvFlag thinly wraps FileEnvFlag with the decoder statically set
+// to Cue.
+func CueFileEnvFlag[T any](ctx context.Context, cfg *T, path string, params Params[T]) (*dials.Dials[T], error) {
+	return FileEnvFlag(ctx, cfg, path, func(string) dials.Decoder { return &cue.Decoder{} }, params)
+}
+
+// TOMLFileEnvFlag thinly wraps FileEnvFlag with the decoder statically set
+// to TOML.
+func TOMLFileEnvFlag[T any](ctx context.Context, cfg *T, path string, params Params[T]) (*dials.Dials[T], error) {
+	return FileEnvFlag(ctx, cfg, path, func(string) dials.Decoder { return &toml.Decoder{} }, params)
 }
 
 // YAMLConfigEnvFlag takes advantage of the ConfigWithConfigPath cfg, thinly
@@ -291,3 +427,19 @@ func DecoderFromExtension(path string) dials.Decoder {
 func FileExtensionDecoderConfigEnvFlag[T any, TP ConfigWithConfigPath[T]](ctx context.Context, cfg TP, params Params[T]) (*dials.Dials[T], error) {
 	return ConfigFileEnvFlag(ctx, cfg, DecoderFromExtension, params)
 }
+
+// FileExtensionDecoderFileEnvFlag thinly wraps FileEnvFlag, choosing the
+// dials.Decoder used when handling the file contents based on the file
+// extension (see DecoderFromExtension).
+func FileExtensionDecoderFileEnvFlag[T any](ctx context.Context, cfg *T, path string, params Params[T]) (*dials.Dials[T], error) {
+	return FileEnvFlag(ctx, cfg, path, DecoderFromExtension, params)
+}
+
+// Must wraps a call to one of the functions in this package, panicking if it
+// fails to load the configuration (see dials.Must), so main functions don't
+// need any error handling:
+//
+//	d := ez.Must(ez.YAMLConfigEnvFlag(ctx, cfg, ez.Params[Config]{}))
+func Must[T any](d *dials.Dials[T], err error) *dials.Dials[T] {
+	return dials.Must(d, err)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vimeo/dials/sources/flag"
 	"github.com/vimeo/dials/tagformat/caseconversion"
 )
 
@@ -258,3 +259,107 @@ func TestJSONConfigEnvFlagWithNewConfigCallback(t *testing.T) {
 	assert.EqualValues(t, expectedFinalConfig, *finalViewEventCfg)
 	assert.EqualValues(t, expectedFinalConfig, *view.View())
 }
+
+type fileEnvFlagConfig struct {
+	Val1 int                 `dials:"Val1"`
+	Val2 string              `dials:"Val2"`
+	Set  map[string]struct{} `dials:"Set"`
+}
+
+func TestYAMLFileEnvFlag(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fileEnvFlagConfig{Val2: "default"}
+	fset, flagErr := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), c, []string{"--Val2=from-flag"})
+	require.NoError(t, flagErr)
+	d, dialsErr := YAMLFileEnvFlag(ctx, c, "../testhelper/testconfig.yaml", Params[fileEnvFlagConfig]{FlagSource: fset})
+	require.NoError(t, dialsErr)
+
+	// Val1 and Set come from the config file, and the flag overrides Val2
+	assert.EqualValues(t, fileEnvFlagConfig{
+		Val1: 456,
+		Val2: "from-flag",
+		Set:  map[string]struct{}{"Keith": {}, "Gary": {}, "Jack": {}},
+	}, *d.View())
+}
+
+func TestYAMLFileEnvFlagBaseDir(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fileEnvFlagConfig{}
+	fset, flagErr := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), c, []string{})
+	require.NoError(t, flagErr)
+	d, dialsErr := YAMLFileEnvFlag(ctx, c, "testconfig.yaml",
+		Params[fileEnvFlagConfig]{FlagSource: fset, ConfigFileBaseDir: "../testhelper"})
+	require.NoError(t, dialsErr)
+	assert.Equal(t, 456, d.View().Val1)
+}
+
+func TestFileEnvFlagWithoutPath(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fileEnvFlagConfig{Val1: 3}
+	fset, flagErr := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), c, []string{})
+	require.NoError(t, flagErr)
+	d, dialsErr := FileExtensionDecoderFileEnvFlag(ctx, c, "", Params[fileEnvFlagConfig]{FlagSource: fset})
+	require.NoError(t, dialsErr)
+	assert.EqualValues(t, fileEnvFlagConfig{Val1: 3}, *d.View())
+
+	_, dialsErr = FileExtensionDecoderFileEnvFlag(ctx, c, "config.ini", Params[fileEnvFlagConfig]{FlagSource: fset})
+	assert.EqualError(t, dialsErr, "decoderFactory provided a nil decoder for path: config.ini")
+}
+
+func TestJSONFileEnvFlagWatching(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "cfg.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Val1": 1}`), 0660))
+
+	c := &fileEnvFlagConfig{}
+	fset, flagErr := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), c, []string{})
+	require.NoError(t, flagErr)
+	d, dialsErr := JSONFileEnvFlag(ctx, c, path, Params[fileEnvFlagConfig]{FlagSource: fset, WatchConfigFile: true})
+	require.NoError(t, dialsErr)
+	assert.Equal(t, 1, d.View().Val1)
+
+	tmpPath := filepath.Join(tmpDir, "_tmp_cfg.json")
+	require.NoError(t, os.WriteFile(tmpPath, []byte(`{"Val1": 2}`), 0660))
+	require.NoError(t, os.Rename(tmpPath, path))
+
+	newCfg := <-d.Events()
+	assert.Equal(t, 2, newCfg.Val1)
+}
+
+func TestYAMLFileEnvFlagProfile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("Val1: 1\nVal2: base\nprofiles:\n  prod:\n    Val1: 2\n"), 0660))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.prod.yaml"), []byte("Val2: prod-file\n"), 0660))
+
+	for name, tbl := range map[string]struct {
+		profile  string
+		expected fileEnvFlagConfig
+	}{
+		"none":    {expected: fileEnvFlagConfig{Val1: 1, Val2: "base"}},
+		"prod":    {profile: "prod", expected: fileEnvFlagConfig{Val1: 2, Val2: "prod-file"}},
+		"staging": {profile: "staging", expected: fileEnvFlagConfig{Val1: 1, Val2: "base"}},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			c := &fileEnvFlagConfig{}
+			fset, flagErr := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), c, []string{})
+			require.NoError(t, flagErr)
+			d, dialsErr := YAMLFileEnvFlag(ctx, c, path, Params[fileEnvFlagConfig]{FlagSource: fset, Profile: tbl.profile})
+			require.NoError(t, dialsErr)
+			assert.EqualValues(t, tbl.expected, *d.View())
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package featureflag evaluates feature flags defined in a section of a dials
+// configuration, so flags are rolled out (and rolled back) by the same
+// sources, watching and verification as the rest of the configuration.
+//
+// A flag is boolean (on or off for everyone), a percentage (on for a fraction
+// of units, e.g. users or accounts), or a variant (each unit is assigned one of
+// several weighted variants, e.g. for an experiment). Units are assigned by
+// hashing their key along with the flag's salt, so a unit's assignment is
+// stable across processes and restarts, and raising a percentage only adds
+// units to those the flag was already on for.
+package featureflag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/parse"
+)
+
+// Kind is the kind of a flag.
+type Kind string
+
+const (
+	// KindBoolean flags are on for every unit when Enabled, and off
+	// otherwise.
+	KindBoolean Kind = "boolean"
+	// KindPercentage flags are on for Percentage percent of units.
+	KindPercentage Kind = "percentage"
+	// KindVariant flags assign each unit one of their Variants, in
+	// proportion to the variants' weights.
+	KindVariant Kind = "variant"
+)
+
+// The variants reported for boolean and percentage flags.
+const (
+	VariantOn  = "on"
+	VariantOff = "off"
+)
+
+// ErrUnknownFlag is returned (wrapped) when evaluating a flag that isn't
+// defined.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Flag defines a feature flag.
+type Flag struct {
+	// Kind is the kind of flag, KindBoolean if empty.
+	Kind Kind
+	// Enabled turns a KindBoolean flag on.
+	Enabled bool
+	// Percentage is the percentage of units (0 to 100) a KindPercentage flag
+	// is on for.
+	Percentage float64
+	// Variants are the variants a KindVariant flag assigns units.
+	Variants []Variant `dialsflag:"-" dialspflag:"-"`
+	// Salt is hashed along with unit keys to assign them, so units are
+	// assigned independently for each flag. It defaults to the flag's
+	// name; changing it reshuffles the units.
+	Salt string
+}
+
+// Variant is one of the variants of a KindVariant flag.
+type Variant struct {
+	Name string
+	// Weight is the variant's share of the units, relative to the
+	// weights of the flag's other variants.
+	Weight float64
+	// Value is an optional payload, parsed by Value into the type
+	// requested.
+	Value string
+}
+
+// Set is a configuration section defining feature flags, keyed by their names.
+type Set struct {
+	Flags map[string]Flag `dialsflag:"-" dialspflag:"-"`
+}
+
+// Validate returns an error describing the first invalid flag (sorted by
+// name), if any. It's suitable for calling from a configuration's Verify()
+// method.
+func (s *Set) Validate() error {
+	names := make([]string, 0, len(s.Flags))
+	for name := range s.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := s.Flags[name]
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("feature flag %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the flag is of an unknown kind, if its
+// percentage is out of range, or if a variant flag has no variants with
+// positive weights (or has several variants of the same name).
+func (f *Flag) Validate() error {
+	switch f.Kind {
+	case "", KindBoolean:
+	case KindPercentage:
+		if f.Percentage < 0 || f.Percentage > 100 {
+			return fmt.Errorf("percentage %g is not between 0 and 100", f.Percentage)
+		}
+	case KindVariant:
+		total := 0.0
+		seen := make(map[string]struct{}, len(f.Variants))
+		for _, v := range f.Variants {
+			if v.Weight < 0 {
+				return fmt.Errorf("variant %q has negative weight %g", v.Name, v.Weight)
+			}
+			if _, dup := seen[v.Name]; dup {
+				return fmt.Errorf("duplicate variant %q", v.Name)
+			}
+			seen[v.Name] = struct{}{}
+			total += v.Weight
+		}
+		if total <= 0 {
+			return errors.New("variant flag has no variants with positive weights")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q (expected %q, %q or %q)", f.Kind, KindBoolean, KindPercentage, KindVariant)
+	}
+	return nil
+}
+
+// Evaluation is the result of evaluating a flag for a unit.
+type Evaluation struct {
+	Flag    string
+	UnitKey string
+	// On reports whether the flag is on for the unit (always true for
+	// variant flags).
+	On bool
+	// Variant is the variant the unit was assigned: VariantOn or
+	// VariantOff for boolean and percentage flags.
+	Variant string
+	// Value is the assigned variant's Value (empty for boolean and
+	// percentage flags).
+	Value string
+}
+
+// Evaluate evaluates the flag for the unit identified by unitKey.
+func (f *Flag) Evaluate(name, unitKey string) (Evaluation, error) {
+	if err := f.Validate(); err != nil {
+		return Evaluation{}, fmt.Errorf("feature flag %q: %w", name, err)
+	}
+	ev := Evaluation{Flag: name, UnitKey: unitKey}
+	salt := f.Salt
+	if salt == "" {
+		salt = name
+	}
+	switch f.Kind {
+	case "", KindBoolean:
+		ev.On = f.Enabled
+	case KindPercentage:
+		ev.On = Bucket(salt, unitKey) < f.Percentage/100
+	case KindVariant:
+		v := f.pickVariant(Bucket(salt, unitKey))
+		ev.On, ev.Variant, ev.Value = true, v.Name, v.Value
+		return ev, nil
+	}
+	ev.Variant = VariantOff
+	if ev.On {
+		ev.Variant = VariantOn
+	}
+	return ev, nil
+}
+
+// pickVariant returns the variant whose share of [0, 1) (laid out in order)
+// contains bucket.
+func (f *Flag) pickVariant(bucket float64) Variant {
+	total := 0.0
+	for _, v := range f.Variants {
+		total += v.Weight
+	}
+	point := bucket * total
+	last := 0
+	for i, v := range f.Variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+		last = i
+	}
+	// rounding may leave point just past the last variant's share
+	return f.Variants[last]
+}
+
+// Bucket hashes key along with salt into a number in [0, 1), which is
+// uniformly distributed over keys, and depends only on its arguments. A key is
+// in the first p of the units if its bucket is less than p.
+func Bucket(salt, key string) float64 {
+	h := sha256.New()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	sum := h.Sum(nil)
+	// the top 53 bits fill a float64's mantissa exactly
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// EvaluationHandler is called with each evaluation (e.g. to record which
+// units were exposed to an experiment).
+type EvaluationHandler func(ctx context.Context, ev Evaluation)
+
+// Evaluator evaluates the flags in the current version of a dials
+// configuration, so flag changes reported by watching sources take effect
+// immediately.
+type Evaluator[T any] struct {
+	d   *dials.Dials[T]
+	sel func(*T) *Set
+	// OnEvaluate, if non-nil, is called with the result of each
+	// successful evaluation. It must be set before the Evaluator is used.
+	OnEvaluate EvaluationHandler
+}
+
+// New returns an Evaluator for the flags selected from d's configuration by
+// sel.
+func New[T any](d *dials.Dials[T], sel func(*T) *Set) *Evaluator[T] {
+	return &Evaluator[T]{d: d, sel: sel}
+}
+
+// Evaluate evaluates the flag named flagName for the unit identified by
+// unitKey, returning an error wrapping ErrUnknownFlag if there's no such flag
+// in the current configuration.
+func (e *Evaluator[T]) Evaluate(ctx context.Context, flagName, unitKey string) (Evaluation, error) {
+	set := e.sel(e.d.View())
+	if set == nil {
+		return Evaluation{}, fmt.Errorf("%w %q", ErrUnknownFlag, flagName)
+	}
+	f, ok := set.Flags[flagName]
+	if !ok {
+		return Evaluation{}, fmt.Errorf("%w %q", ErrUnknownFlag, flagName)
+	}
+	ev, err := f.Evaluate(flagName, unitKey)
+	if err != nil {
+		return Evaluation{}, err
+	}
+	if e.OnEvaluate != nil {
+		e.OnEvaluate(ctx, ev)
+	}
+	return ev, nil
+}
+
+// Bool reports whether the flag is on for the unit, and false if it can't be
+// evaluated.
+func (e *Evaluator[T]) Bool(ctx context.Context, flagName, unitKey string) bool {
+	ev, err := e.Evaluate(ctx, flagName, unitKey)
+	return err == nil && ev.On
+}
+
+// Variant returns the variant the unit is assigned, or fallback if the flag
+// can't be evaluated.
+func (e *Evaluator[T]) Variant(ctx context.Context, flagName, unitKey, fallback string) string {
+	ev, err := e.Evaluate(ctx, flagName, unitKey)
+	if err != nil {
+		return fallback
+	}
+	return ev.Variant
+}
+
+// Value returns the Value of the variant the unit is assigned, parsed into a
+// V (like values from environment variables, see parse.String), or fallback if
+// the flag can't be evaluated, the variant has no value, or the value can't be
+// parsed.
+func Value[V, T any](ctx context.Context, e *Evaluator[T], flagName, unitKey string, fallback V) V {
+	ev, err := e.Evaluate(ctx, flagName, unitKey)
+	if err != nil || ev.Value == "" {
+		return fallback
+	}
+	t := reflect.TypeOf(&fallback).Elem()
+	v, err := parse.String(ev.Value, t)
+	if err != nil {
+		return fallback
+	}
+	// scalars are parsed into pointers
+	if v.Type() != t {
+		v = v.Elem()
+	}
+	return v.Interface().(V)
+}
@@ -0,0 +1,151 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+func TestBucketIsStableAndUniform(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, Bucket("salt", "user-1"), Bucket("salt", "user-1"))
+	assert.NotEqual(t, Bucket("salt", "user-1"), Bucket("other", "user-1"))
+
+	const n = 10000
+	under := 0
+	for i := 0; i < n; i++ {
+		b := Bucket("salt", fmt.Sprintf("user-%d", i))
+		require.True(t, b >= 0 && b < 1, "bucket %g out of range", b)
+		if b < 0.25 {
+			under++
+		}
+	}
+	assert.InDelta(t, 0.25, float64(under)/n, 0.02)
+}
+
+func TestFlagEvaluate(t *testing.T) {
+	t.Parallel()
+	ev, err := (&Flag{Enabled: true}).Evaluate("bool", "u")
+	require.NoError(t, err)
+	assert.Equal(t, Evaluation{Flag: "bool", UnitKey: "u", On: true, Variant: VariantOn}, ev)
+
+	// raising a percentage keeps the units the flag was on for
+	low, high := &Flag{Kind: KindPercentage, Percentage: 10}, &Flag{Kind: KindPercentage, Percentage: 50}
+	onLow, onHigh := 0, 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		evLow, lowErr := low.Evaluate("pct", key)
+		require.NoError(t, lowErr)
+		evHigh, highErr := high.Evaluate("pct", key)
+		require.NoError(t, highErr)
+		if evLow.On {
+			onLow++
+			assert.True(t, evHigh.On, key)
+		}
+		if evHigh.On {
+			onHigh++
+		}
+	}
+	assert.InDelta(t, 100, onLow, 40)
+	assert.InDelta(t, 500, onHigh, 60)
+
+	variants := &Flag{Kind: KindVariant, Variants: []Variant{
+		{Name: "control", Weight: 1},
+		{Name: "unused", Weight: 0},
+		{Name: "treatment", Weight: 3, Value: "42"},
+	}}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		ev, err := variants.Evaluate("exp", fmt.Sprintf("user-%d", i))
+		require.NoError(t, err)
+		assert.True(t, ev.On)
+		counts[ev.Variant]++
+		if ev.Variant == "treatment" {
+			assert.Equal(t, "42", ev.Value)
+		}
+	}
+	assert.Zero(t, counts["unused"])
+	assert.InDelta(t, 250, counts["control"], 60)
+	assert.InDelta(t, 750, counts["treatment"], 60)
+}
+
+func TestFlagValidate(t *testing.T) {
+	t.Parallel()
+	for name, f := range map[string]Flag{
+		"unknown_kind":     {Kind: "sometimes"},
+		"percentage_range": {Kind: KindPercentage, Percentage: 101},
+		"no_variants":      {Kind: KindVariant},
+		"negative_weight":  {Kind: KindVariant, Variants: []Variant{{Name: "a", Weight: -1}, {Name: "b", Weight: 2}}},
+		"duplicate":        {Kind: KindVariant, Variants: []Variant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}},
+	} {
+		f := f
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, f.Validate())
+			_, err := f.Evaluate(name, "u")
+			assert.Error(t, err)
+		})
+	}
+	s := Set{Flags: map[string]Flag{"ok": {}, "bad": {Kind: KindPercentage, Percentage: -1}}}
+	assert.ErrorContains(t, s.Validate(), `feature flag "bad"`)
+}
+
+type config struct {
+	Features Set
+}
+
+func (c *config) Verify() error {
+	return c.Features.Validate()
+}
+
+func TestEvaluatorFromConfig(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	src := &static.StringSource{
+		Data: `
+features:
+  flags:
+    new-ui:
+      enabled: true
+    fast-path:
+      kind: percentage
+      percentage: 100
+    timeout:
+      kind: variant
+      variants:
+        - name: long
+          weight: 1
+          value: 30s
+`,
+		Decoder: &yaml.Decoder{},
+	}
+	d, err := dials.Config(ctx, &config{}, src)
+	require.NoError(t, err)
+
+	var evaluated []string
+	e := New(d, func(c *config) *Set { return &c.Features })
+	e.OnEvaluate = func(_ context.Context, ev Evaluation) {
+		evaluated = append(evaluated, ev.Flag+"="+ev.Variant)
+	}
+
+	assert.True(t, e.Bool(ctx, "new-ui", "user-1"))
+	assert.True(t, e.Bool(ctx, "fast-path", "user-1"))
+	assert.Equal(t, "long", e.Variant(ctx, "timeout", "user-1", "short"))
+	assert.Equal(t, 30*time.Second, Value(ctx, e, "timeout", "user-1", time.Second))
+	assert.Equal(t, []string{"new-ui=on", "fast-path=on", "timeout=long", "timeout=long"}, evaluated)
+
+	// unknown flags (and values that don't parse) fall back
+	_, err = e.Evaluate(ctx, "missing", "user-1")
+	assert.ErrorIs(t, err, ErrUnknownFlag)
+	assert.False(t, e.Bool(ctx, "missing", "user-1"))
+	assert.Equal(t, "short", e.Variant(ctx, "missing", "user-1", "short"))
+	assert.Equal(t, 7, Value(ctx, e, "timeout", "user-1", 7))
+	assert.Equal(t, []string{"a"}, Value(ctx, e, "new-ui", "user-1", []string{"a"}))
+}
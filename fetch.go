@@ -0,0 +1,40 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// fetchResult is the result of a source's Value method.
+type fetchResult struct {
+	value reflect.Value
+	err   error
+}
+
+// fetchValues calls Value on each of sources (in parallel if
+// Params.ConcurrentSourceFetch is set), returning the results in the same
+// order.
+func (p Params[T]) fetchValues(ctx context.Context, sources []Source, t *Type) []fetchResult {
+	results := make([]fetchResult, len(sources))
+	fetch := func(i int) {
+		v, err := tracedValue(ctx, p.Tracer, sources[i], t, p.SlowSourceThreshold)
+		results[i] = fetchResult{value: v, err: err}
+	}
+	if !p.ConcurrentSourceFetch || len(sources) < 2 {
+		for i := range sources {
+			fetch(i)
+		}
+		return results
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(len(sources))
+	for i := range sources {
+		go func(i int) {
+			defer wg.Done()
+			fetch(i)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
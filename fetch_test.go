@@ -0,0 +1,50 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rendezvousSource's Value method only returns once every source sharing its
+// WaitGroup has been called.
+type rendezvousSource struct {
+	fakeSource
+	wg *sync.WaitGroup
+}
+
+func (r *rendezvousSource) Value(ctx context.Context, t *Type) (reflect.Value, error) {
+	r.wg.Done()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return r.fakeSource.Value(ctx, t)
+	case <-time.After(time.Second):
+		return reflect.Value{}, errors.New("called sequentially")
+	}
+}
+
+func TestConcurrentSourceFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	a, b := "a", "b"
+	first := &rendezvousSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{Addr: &a}}, wg: &wg}
+	second := &rendezvousSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{Addr: &b}}, wg: &wg}
+	d, err := Params[rollbackConfig]{ConcurrentSourceFetch: true}.Config(ctx, &rollbackConfig{}, first, second)
+	require.NoError(t, err)
+	// the last source still takes precedence
+	assert.Equal(t, "b", d.View().Addr)
+}
@@ -0,0 +1,135 @@
+package dials
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// GeneratedFuncs are the functions the dials command's generate subcommand
+// generates for a config struct type T (see cmd/dials), which replace the
+// reflective deep-copying of T's values and overlaying of sources' values
+// onto them when the configuration is composed, so configurations are
+// composed with a constant number of allocations rather than a few per
+// field. The generated code registers them with RegisterGenerated.
+//
+// The generated functions handle scalar and nested struct fields
+// themselves, and hand the other fields (slices, maps, pointers, interfaces,
+// and fields with a SliceMergeTag) to the Reflective passed to them.
+type GeneratedFuncs[T any] struct {
+	// Overlay overlays overlay, a value of the pointerified type of T (as
+	// returned by sources) onto base.
+	Overlay func(base *T, overlay reflect.Value, r *Reflective) error
+	// DeepCopy replaces the memory that dst, a shallow copy of src,
+	// shares with src with copies.
+	DeepCopy func(dst, src *T, r *Reflective)
+}
+
+// Reflective is passed to GeneratedFuncs to handle the fields they don't
+// handle themselves with the reflective implementation.
+type Reflective struct {
+	sliceMerge SliceMerge
+	// root is the value being copied, whose copy is rootCopy
+	root, rootCopy reflect.Value
+	o              *overlayer
+}
+
+// overlayer returns the overlayer used by r, creating it if this is the
+// first field that falls back to it.
+func (r *Reflective) overlayer() *overlayer {
+	if r.o == nil {
+		r.o = newOverlayer()
+		r.o.sliceMerge = r.sliceMerge
+		if r.root.IsValid() {
+			// pointers to the root are pointed at its copy, as
+			// deepCopyPtr does
+			r.o.dc.ptrMap[ptrKey{ptr: r.root.Pointer(), typ: r.root.Type()}] = r.rootCopy
+		}
+	}
+	return r.o
+}
+
+// OverlayField overlays the field of the pointerified struct overlay that
+// corresponds to the i'th field of the struct base onto it.
+func (r *Reflective) OverlayField(base, overlay reflect.Value, i int) error {
+	o := r.overlayer()
+	plan := planOverlay(base.Type(), o.sliceMerge)
+	if plan.err != nil {
+		return plan.err
+	}
+	j := sort.Search(len(plan.fields), func(j int) bool { return plan.fields[j].base >= i })
+	if j == len(plan.fields) || plan.fields[j].base != i {
+		return nil
+	}
+	return o.overlayPlannedField(base, overlay, plan.fields[j])
+}
+
+// CopyField replaces the i'th field of the struct dst, a shallow copy of the
+// struct src, with a deep copy of src's.
+func (r *Reflective) CopyField(dst, src reflect.Value, i int) {
+	r.overlayer().dc.deepCopy(src.Field(i), dst.Field(i))
+}
+
+// generated is the type-erased form of the GeneratedFuncs for a type.
+type generated interface {
+	// overlay overlays overlay onto base (a struct), returning false if
+	// overlay isn't the pointerified type the functions were generated
+	// for.
+	overlay(base, overlay reflect.Value, sliceMerge SliceMerge) (bool, error)
+	// deepCopy returns a deep copy of the non-nil pointer v.
+	deepCopy(v reflect.Value) reflect.Value
+}
+
+type generatedFuncs[T any] struct {
+	funcs GeneratedFuncs[T]
+	// ptrType is the pointerified type of T, which is computed on first
+	// use, since types that change how T is pointerified may be
+	// registered by init functions after the generated one runs.
+	ptrTypeOnce sync.Once
+	ptrType     reflect.Type
+}
+
+func (g *generatedFuncs[T]) overlay(base, overlay reflect.Value, sliceMerge SliceMerge) (bool, error) {
+	g.ptrTypeOnce.Do(func() {
+		g.ptrType = ptrify.Pointerify(reflect.TypeOf((*T)(nil)).Elem(), reflect.Value{})
+	})
+	// Pointerify devirtualizes interface fields that are set in the
+	// template, so sources' values may not have the type the code was
+	// generated for.
+	if g.funcs.Overlay == nil || overlay.Type() != g.ptrType {
+		return false, nil
+	}
+	r := Reflective{sliceMerge: sliceMerge}
+	return true, g.funcs.Overlay(base.Addr().Interface().(*T), overlay, &r)
+}
+
+func (g *generatedFuncs[T]) deepCopy(v reflect.Value) reflect.Value {
+	src := v.Interface().(*T)
+	dst := new(T)
+	*dst = *src
+	if g.funcs.DeepCopy != nil {
+		r := Reflective{root: v, rootCopy: reflect.ValueOf(dst)}
+		g.funcs.DeepCopy(dst, src, &r)
+	}
+	return reflect.ValueOf(dst)
+}
+
+// generatedTypes maps config types to their registered generated.
+var generatedTypes sync.Map
+
+// RegisterGenerated registers the functions generated for the config type T,
+// which are used for configurations of that type from then on. It's called
+// by the init function of the generated code.
+func RegisterGenerated[T any](funcs GeneratedFuncs[T]) {
+	generatedTypes.Store(reflect.TypeOf((*T)(nil)).Elem(), &generatedFuncs[T]{funcs: funcs})
+}
+
+// lookupGenerated returns the functions registered for the type t, or nil.
+func lookupGenerated(t reflect.Type) generated {
+	if g, ok := generatedTypes.Load(t); ok {
+		return g.(generated)
+	}
+	return nil
+}
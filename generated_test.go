@@ -0,0 +1,81 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type generatedConfig struct {
+	Name  string      `dials:"name"`
+	Hosts []string    `dials:"hosts" dialsmerge:"append"`
+	Any   interface{} `dials:"any"`
+}
+
+// the number of calls to the functions registered for generatedConfig
+var generatedOverlays, generatedCopies int32
+
+// the functions are written as the dials command generates them
+func init() {
+	RegisterGenerated(GeneratedFuncs[generatedConfig]{
+		Overlay: func(base *generatedConfig, overlay reflect.Value, r *Reflective) error {
+			atomic.AddInt32(&generatedOverlays, 1)
+			v := reflect.ValueOf(base).Elem()
+			if f := overlay.Field(0); !f.IsNil() {
+				base.Name = *f.Interface().(*string)
+			}
+			if err := r.OverlayField(v, overlay, 1); err != nil {
+				return err
+			}
+			return r.OverlayField(v, overlay, 2)
+		},
+		DeepCopy: func(dst, src *generatedConfig, r *Reflective) {
+			atomic.AddInt32(&generatedCopies, 1)
+			dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+			r.CopyField(dv, sv, 1)
+			r.CopyField(dv, sv, 2)
+		},
+	})
+}
+
+func TestGenerated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type ptrifiedConfig struct {
+		Name  *string
+		Hosts []string
+		Any   interface{}
+	}
+	name := "src"
+	tmpl := &generatedConfig{Name: "tmpl", Hosts: []string{"a"}}
+	d, err := Config(ctx, tmpl, &fakeSource{outVal: ptrifiedConfig{Name: &name, Hosts: []string{"b"}, Any: 1}})
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, &generatedConfig{Name: "src", Hosts: []string{"a", "b"}, Any: 1}, d.View())
+	assert.NotZero(t, atomic.LoadInt32(&generatedOverlays))
+	assert.NotZero(t, atomic.LoadInt32(&generatedCopies))
+	// the template isn't shared with the configuration
+	d.View().Hosts[0] = "changed"
+	assert.Equal(t, []string{"a"}, tmpl.Hosts)
+
+	// the template's interface value is devirtualized, so the pointerified
+	// type differs from the one the functions were generated for, and the
+	// sources' values are overlaid reflectively
+	type devirtualizedConfig struct {
+		Name  *string
+		Hosts []string
+		Any   []string
+	}
+	overlays := atomic.LoadInt32(&generatedOverlays)
+	d2, err := Config(ctx, &generatedConfig{Any: []string{"x"}}, &fakeSource{outVal: devirtualizedConfig{Name: &name}})
+	require.NoError(t, err)
+	defer d2.Close(ctx)
+	assert.Equal(t, &generatedConfig{Name: "src", Any: []string{"x"}}, d2.View())
+	assert.Equal(t, overlays, atomic.LoadInt32(&generatedOverlays))
+}
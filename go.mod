@@ -11,6 +11,7 @@ require (
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/text v0.11.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -22,5 +23,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/net v0.12.0 // indirect
 	golang.org/x/sys v0.10.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
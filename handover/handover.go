@@ -0,0 +1,133 @@
+// Package handover passes the configuration of a process to its replacement
+// during a graceful restart, so the replacement can start serving with the
+// same configuration without waiting for slow (e.g. remote) sources.
+//
+// The outgoing process writes its configuration with Write (e.g. to a pipe
+// passed to the replacement with exec.Cmd's ExtraFiles) or WriteFile:
+//
+//	r, w, err := os.Pipe()
+//	cmd.ExtraFiles = []*os.File{r} // fd 3 in the child
+//	err = cmd.Start()
+//	go func() {
+//		defer w.Close()
+//		handover.Write(w, d)
+//	}()
+//
+// The replacement reads it with ReadFD (or Read, or ReadFile), and passes
+// the Source as its lowest-precedence source, wrapping its slow sources in a
+// sourcewrap.Blank whose source is set once Config returns:
+//
+//	prev, err := handover.ReadFD(3)
+//	remote := &sourcewrap.Blank{}
+//	d, err := dials.Config(ctx, &cfg, prev, fileSrc, envSrc, remote)
+//	go remote.SetSource(ctx, remoteSrc)
+//
+// Values are handed over as JSON, so fields that are omitted from JSON
+// encoding (e.g. `json:"-"`) are not handed over.
+package handover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/vimeo/dials"
+)
+
+// envelope is the encoding of a handed-over configuration.
+type envelope struct {
+	Generation uint64          `json:"generation"`
+	Config     json.RawMessage `json:"config"`
+}
+
+// Write writes the configuration currently installed in d, and its
+// generation, to w.
+func Write[T any](w io.Writer, d *dials.Dials[T]) error {
+	cfg, tok := d.ViewVersion()
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := json.NewEncoder(w).Encode(envelope{Generation: tok.Generation(), Config: raw}); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the configuration currently installed in d, and its
+// generation, to the file at path, replacing it atomically so the
+// replacement never reads a partial file. The file is only readable by its
+// owner.
+func WriteFile[T any](path string, d *dials.Dials[T]) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(tmp, d); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to install %q: %w", path, err)
+	}
+	return nil
+}
+
+// Source provides the configuration handed over by the previous process.
+type Source struct {
+	// Generation is the generation of the configuration in the previous
+	// process.
+	Generation uint64
+	config     json.RawMessage
+}
+
+var _ dials.Source = (*Source)(nil)
+
+// Read reads a configuration written by Write from r.
+func Read(r io.Reader) (*Source, error) {
+	env := envelope{}
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("failed to read handed over configuration: %w", err)
+	}
+	return &Source{Generation: env.Generation, config: env.Config}, nil
+}
+
+// ReadFile reads a configuration written by WriteFile (or Write) from the file
+// at path.
+func ReadFile(path string) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open handed over configuration: %w", err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// ReadFD reads a configuration written by Write from the inherited file
+// descriptor fd (e.g. 3, for the first of exec.Cmd's ExtraFiles), and closes
+// it.
+func ReadFD(fd uintptr) (*Source, error) {
+	f := os.NewFile(fd, "handover")
+	if f == nil {
+		return nil, fmt.Errorf("invalid handover file descriptor %d", fd)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Value implements dials.Source, returning the handed-over configuration.
+func (s *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+	v := reflect.New(t.Type())
+	if err := json.Unmarshal(s.config, v.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode handed over configuration: %w", err)
+	}
+	return v.Elem(), nil
+}
@@ -0,0 +1,85 @@
+package handover
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/dialstest"
+	"github.com/vimeo/dials/sources/static"
+	"github.com/vimeo/dials/sourcewrap"
+)
+
+type config struct {
+	Addr    string        `dials:"addr"`
+	Timeout time.Duration `dials:"timeout"`
+	Backend string        `dials:"backend"`
+	Skipped string        `dials:"skipped" json:"-"`
+}
+
+// outgoing returns a Dials that has installed a few versions.
+func outgoing(ctx context.Context, t *testing.T) *dials.Dials[config] {
+	t.Helper()
+	src := dialstest.NewSource(&config{Addr: "a", Timeout: time.Second, Backend: "remote-1", Skipped: "x"})
+	d, err := dials.Config(ctx, &config{}, src)
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close(ctx) })
+	require.NoError(t, src.Push(ctx, &config{Addr: "a", Timeout: time.Second, Backend: "remote-2", Skipped: "x"}))
+	_, err = dialstest.Await(ctx, d, func(c *config) bool { return c.Backend == "remote-2" })
+	require.NoError(t, err)
+	return d
+}
+
+func TestHandover(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	buf := bytes.Buffer{}
+	require.NoError(t, Write(&buf, outgoing(ctx, t)))
+	prev, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), prev.Generation)
+
+	// the replacement starts with the handed over configuration, overridden
+	// by its fast sources, while its slow source is fetched later
+	file := &static.StringSource{Data: "addr: b", Decoder: &yaml.Decoder{}}
+	remote := &sourcewrap.Blank{}
+	d, err := dials.Config(ctx, &config{}, prev, file, remote)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, &config{Addr: "b", Timeout: time.Second, Backend: "remote-2"}, d.View())
+
+	require.NoError(t, remote.SetSource(ctx, &static.StringSource{Data: "backend: remote-3", Decoder: &yaml.Decoder{}}))
+	assert.Equal(t, &config{Addr: "b", Timeout: time.Second, Backend: "remote-3"}, d.View())
+}
+
+func TestHandoverFile(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "handover.json")
+	require.NoError(t, WriteFile(path, outgoing(ctx, t)))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	prev, err := ReadFile(path)
+	require.NoError(t, err)
+	d, err := dials.Config(ctx, &config{}, prev)
+	require.NoError(t, err)
+	assert.Equal(t, &config{Addr: "a", Timeout: time.Second, Backend: "remote-2"}, d.View())
+
+	_, err = ReadFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = Read(bytes.NewBufferString("not json"))
+	assert.ErrorContains(t, err, "failed to read handed over configuration")
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package handover
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandoverFD(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	// ReadFD takes ownership of the descriptor, as a child process would
+	fd, err := syscall.Dup(int(r.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	d := outgoing(ctx, t)
+	go func() {
+		defer w.Close()
+		assert.NoError(t, Write(w, d))
+	}()
+	prev, err := ReadFD(uintptr(fd))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), prev.Generation)
+}
@@ -0,0 +1,96 @@
+package dials
+
+import (
+	"fmt"
+	"time"
+)
+
+// VersionCause describes why a configuration version was installed.
+type VersionCause int
+
+const (
+	// VersionInitial is the version installed by Config.
+	VersionInitial VersionCause = iota
+	// VersionUpdate is a version composed after watching sources reported
+	// new values (or after Unpin).
+	VersionUpdate
+	// VersionRollback is an earlier version reinstalled by
+	// [Dials.Rollback].
+	VersionRollback
+	// VersionPin is an earlier version reinstalled by [Dials.PinVersion].
+	VersionPin
+	// VersionRefresh is a version composed after [Dials.Refresh] called
+	// Value on every source again.
+	VersionRefresh
+	// VersionSourcesChanged is a version composed after the sources were
+	// changed at runtime (e.g. by [Dials.DisableSource]).
+	VersionSourcesChanged
+)
+
+func (c VersionCause) String() string {
+	switch c {
+	case VersionInitial:
+		return "initial"
+	case VersionUpdate:
+		return "update"
+	case VersionRollback:
+		return "rollback"
+	case VersionPin:
+		return "pin"
+	case VersionRefresh:
+		return "refresh"
+	case VersionSourcesChanged:
+		return "sources changed"
+	default:
+		return fmt.Sprintf("VersionCause(%d)", int(c))
+	}
+}
+
+// HistoryEntry describes an installed configuration version.
+type HistoryEntry[T any] struct {
+	Config  *T
+	Version CfgSerial[T]
+	// Installed is when the version was installed.
+	Installed time.Time
+	Cause     VersionCause
+	// Sources contains the watching sources whose new values triggered
+	// the version (for VersionUpdate) in the order they reported them,
+	// every source (for VersionRefresh), or the sources that were changed
+	// (for VersionSourcesChanged).
+	Sources []Source
+}
+
+// History returns the retained configuration versions (see
+// [Params].VersionHistorySize), oldest first, ending with the current
+// version. Comparing consecutive entries (e.g. with [Diff]) shows when a value
+// changed, and which source triggered the change.
+func (d *Dials[T]) History() []HistoryEntry[T] {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+	entries := make([]HistoryEntry[T], len(d.history))
+	for i, vc := range d.history {
+		entries[i] = HistoryEntry[T]{
+			Config:    vc.cfg,
+			Version:   CfgSerial[T]{s: vc.serial, cfg: vc.cfg},
+			Installed: vc.installed,
+			Cause:     vc.cause,
+			Sources:   vc.triggers,
+		}
+	}
+	return entries
+}
+
+// updateSources returns the distinct sources of updates, in order.
+func updateSources(updates []*valueUpdate) []Source {
+	var sources []Source
+OUTER:
+	for _, u := range updates {
+		for _, s := range sources {
+			if s == u.source {
+				continue OUTER
+			}
+		}
+		sources = append(sources, u.source)
+	}
+	return sources
+}
@@ -0,0 +1,64 @@
+package dials
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{VersionHistorySize: 4}.Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	for _, addr := range []string{"b", "c"} {
+		w.send(ctx, rollbackValue(addr))
+		<-d.Events()
+	}
+	_, _, err = d.Rollback(ctx)
+	require.NoError(t, err)
+
+	h := d.History()
+	require.Len(t, h, 4)
+	addrs := []string{}
+	causes := []VersionCause{}
+	for i, e := range h {
+		addrs = append(addrs, e.Config.Addr)
+		causes = append(causes, e.Cause)
+		assert.False(t, e.Installed.Before(start))
+		if i > 0 {
+			assert.False(t, e.Installed.Before(h[i-1].Installed))
+		}
+	}
+	// the rollback reinstalled "b"
+	assert.Equal(t, []string{"a", "b", "c", "b"}, addrs)
+	assert.Equal(t, []VersionCause{VersionInitial, VersionUpdate, VersionUpdate, VersionRollback}, causes)
+	for i, e := range h {
+		assert.Equal(t, uint64(i), e.Version.Generation())
+	}
+	assert.Empty(t, h[0].Sources)
+	assert.Equal(t, []Source{&w}, h[1].Sources)
+	assert.Empty(t, h[3].Sources)
+
+	_, tok := d.ViewVersion()
+	assert.Equal(t, tok, h[3].Version)
+
+	// only VersionHistorySize versions are retained
+	for _, addr := range []string{"d", "e"} {
+		w.send(ctx, rollbackValue(addr))
+		<-d.Events()
+	}
+	h = d.History()
+	require.Len(t, h, 4)
+	assert.Equal(t, "c", h[0].Config.Addr)
+	assert.Equal(t, "rollback", h[1].Cause.String())
+	assert.Equal(t, "e", h[3].Config.Addr)
+}
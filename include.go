@@ -0,0 +1,81 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeCycleError is returned by OpenIncluded when a file includes itself,
+// directly or through other files.
+type IncludeCycleError struct {
+	// Chain lists the files leading from the first file back to itself
+	// (which is both the first and last element).
+	Chain []string
+}
+
+func (e *IncludeCycleError) Error() string {
+	return "include cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// includeCtx describes the file being decoded for OpenIncluded.
+type includeCtx struct {
+	// chain holds the absolute paths of the file being decoded and the
+	// files that (transitively) included it, outermost first
+	chain []string
+	open  func(path string) (io.ReadCloser, error)
+}
+
+type includeCtxKey struct{}
+
+// ContextWithFile returns a context for decoding the file at path, with which
+// OpenIncluded resolves relative includes against path's directory, and
+// opens the files with open (os.Open if nil). Sources reading files pass it
+// to Decode, so decoders that support includes (such as the YAML decoder's
+// `!include` tag) can find the included files, and so the source can observe
+// (e.g. watch and checksum) the files that are read.
+func ContextWithFile(ctx context.Context, path string, open func(path string) (io.ReadCloser, error)) context.Context {
+	if open == nil {
+		open = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	}
+	return context.WithValue(ctx, includeCtxKey{}, &includeCtx{
+		chain: []string{filepath.Clean(path)},
+		open:  open,
+	})
+}
+
+// OpenIncluded opens the file named name, included by the file being decoded
+// with ctx (see ContextWithFile). Relative names are resolved against the
+// directory of the including file. It returns the file, and the context to
+// decode it with, so its own includes are resolved relative to it. Including
+// a file that's already being decoded returns an *IncludeCycleError, and
+// without a file in ctx, OpenIncluded returns an error.
+func OpenIncluded(ctx context.Context, name string) (io.ReadCloser, context.Context, error) {
+	ic, ok := ctx.Value(includeCtxKey{}).(*includeCtx)
+	if !ok {
+		return nil, nil, fmt.Errorf("can't include %q: the including file is unknown", name)
+	}
+	cur := ic.chain[len(ic.chain)-1]
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(cur), path)
+	}
+	path = filepath.Clean(path)
+	for i, p := range ic.chain {
+		if p == path {
+			chain := append(append([]string{}, ic.chain[i:]...), path)
+			return nil, nil, &IncludeCycleError{Chain: chain}
+		}
+	}
+	f, err := ic.open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %q, included by %q: %w", path, cur, err)
+	}
+	return f, context.WithValue(ctx, includeCtxKey{}, &includeCtx{
+		chain: append(ic.chain[:len(ic.chain):len(ic.chain)], path),
+		open:  ic.open,
+	}), nil
+}
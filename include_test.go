@@ -0,0 +1,52 @@
+package dials
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenIncluded(t *testing.T) {
+	t.Parallel()
+	root := string(filepath.Separator) + filepath.Join("etc", "svc", "config.yaml")
+	opened := []string{}
+	ctx := ContextWithFile(context.Background(), root, func(path string) (io.ReadCloser, error) {
+		opened = append(opened, path)
+		return ioutil.NopCloser(strings.NewReader(path)), nil
+	})
+
+	f, dbCtx, err := OpenIncluded(ctx, "conf.d/db.yaml")
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	dbPath := filepath.Join(filepath.Dir(root), "conf.d", "db.yaml")
+	assert.Equal(t, dbPath, string(b))
+
+	// includes of included files are relative to them
+	_, _, err = OpenIncluded(dbCtx, "pool.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{dbPath, filepath.Join(filepath.Dir(dbPath), "pool.yaml")}, opened)
+
+	_, _, err = OpenIncluded(dbCtx, "../config.yaml")
+	cycleErr := &IncludeCycleError{}
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{root, dbPath, root}, cycleErr.Chain)
+
+	// the same file may be included more than once, if not in a cycle
+	_, _, err = OpenIncluded(ctx, "conf.d/db.yaml")
+	assert.NoError(t, err)
+
+	_, _, err = OpenIncluded(context.Background(), "db.yaml")
+	assert.Error(t, err)
+
+	missing := ContextWithFile(context.Background(), filepath.Join(t.TempDir(), "config.yaml"), nil)
+	_, _, err = OpenIncluded(missing, "missing.yaml")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
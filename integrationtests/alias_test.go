@@ -0,0 +1,78 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aliasConfig struct {
+	ListenAddr string `dials:"listen_addr" dialsalias:"addr"`
+	Database   struct {
+		Host string `dials:"host" dialsalias:"hostname,server"`
+		Port int    `dials:"port"`
+	} `dials:"database" dialsalias:"db"`
+}
+
+func TestAliases(t *testing.T) {
+	ctx := context.Background()
+	for name, src := range map[string]dials.Source{
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"addr": ":8080",
+			"db": {"server": "db1", "port": 5432}
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+addr: ":8080"
+db:
+  server: db1
+  port: 5432
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+addr = ":8080"
+[db]
+server = "db1"
+port = 5432
+`},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{}, Data: `
+addr: ":8080"
+db: server: "db1"
+db: port: 5432
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			var deprecated []string
+			d, err := dials.Params[aliasConfig]{
+				OnWarning: func(_ context.Context, w dials.Warning) {
+					if w.Kind == dials.WarningDeprecated {
+						deprecated = append(deprecated, w.Message)
+					}
+				},
+			}.Config(ctx, &aliasConfig{}, src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, ":8080", c.ListenAddr)
+			assert.Equal(t, "db1", c.Database.Host)
+			assert.Equal(t, 5432, c.Database.Port)
+			assert.Len(t, deprecated, 3)
+			assert.Contains(t, deprecated[0], `key "addr" is deprecated; use "listen_addr" instead`)
+		})
+	}
+
+	// the current keys take precedence over aliases
+	d, err := dials.Config(ctx, &aliasConfig{}, &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+		"listen_addr": ":9090",
+		"addr": ":8080"
+	}`})
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", d.View().ListenAddr)
+}
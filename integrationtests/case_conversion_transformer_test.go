@@ -24,12 +24,10 @@ func TestReformatDialsTags(t *testing.T) {
 		IPAddress       net.IP `dials:"ip_address"`
 	}
 
-	// TODO: remove the ignoreIPv4 field once TextMarshal mangler is created and inserted into TOML decoder
 	testCases := []struct {
 		description string
 		decoder     dials.Decoder
 		data        string
-		ignoreIPv4  bool // needed to skip the IPv4 check for toml because toml currently only supports primitives
 	}{
 		{
 			description: "JSON",
@@ -55,8 +53,8 @@ func TestReformatDialsTags(t *testing.T) {
 			data: `
 				databaseName = "something"
 				databaseAddress = "127.0.0.1"
+				ipAddress = "127.0.0.1"
 			`,
-			ignoreIPv4: true,
 		},
 	}
 
@@ -76,9 +74,7 @@ func TestReformatDialsTags(t *testing.T) {
 			c := d.View()
 			assert.Equal(t, "something", c.DatabaseName)
 			assert.Equal(t, "127.0.0.1", c.DatabaseAddress)
-			if !tc.ignoreIPv4 {
-				assert.Equal(t, net.IPv4(127, 0, 0, 1), c.IPAddress)
-			}
+			assert.Equal(t, net.IPv4(127, 0, 0, 1), c.IPAddress)
 		})
 	}
 }
@@ -99,12 +95,10 @@ func TestReformatDialsTagsInNestedStruct(t *testing.T) {
 		} `dials:"database_user"`
 	}
 
-	// TODO: remove the ignoreIPv4 field once TextMarshal mangler is created and inserted into TOML decoder
 	testCases := []struct {
 		description string
 		decoder     dials.Decoder
 		data        string
-		ignoreIPv4  bool
 	}{
 		{
 			description: "JSON",
@@ -153,8 +147,8 @@ func TestReformatDialsTagsInNestedStruct(t *testing.T) {
 					password = "password"
 					[databaseUser.otherStuff.something]
 						anotherField = "asdf"
+						ipAddress = "127.0.0.1"
 		`,
-			ignoreIPv4: true,
 		},
 	}
 
@@ -176,9 +170,7 @@ func TestReformatDialsTagsInNestedStruct(t *testing.T) {
 			assert.Equal(t, "test", c.DatabaseUser.Username)
 			assert.Equal(t, "password", c.DatabaseUser.Password)
 			assert.Equal(t, "asdf", c.DatabaseUser.OtherStuff.Something.AnotherField)
-			if !tc.ignoreIPv4 {
-				assert.Equal(t, net.IPv4(127, 0, 0, 1), c.DatabaseUser.OtherStuff.Something.IPAddress)
-			}
+			assert.Equal(t, net.IPv4(127, 0, 0, 1), c.DatabaseUser.OtherStuff.Something.IPAddress)
 		})
 	}
 }
@@ -0,0 +1,43 @@
+package integrationtests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/sources/env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvConfigErrors(t *testing.T) {
+	type database struct {
+		Port int
+	}
+	type config struct {
+		Workers  int
+		Debug    bool
+		Name     string
+		Database database
+	}
+
+	vars := map[string]string{
+		"WORKERS":       "many",
+		"DEBUG":         "true",
+		"DATABASE_PORT": "http",
+	}
+	src := &env.Source{LookupEnv: env.MapLookup(vars)}
+
+	_, err := dials.Config(context.Background(), &config{}, src)
+	require.Error(t, err)
+	ce := &dials.ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 2)
+	assert.Equal(t, "Workers", ce.Errors[0].Path)
+	assert.Equal(t, "Database.Port", ce.Errors[1].Path)
+	for _, e := range ce.Errors {
+		assert.Same(t, src, e.Source)
+	}
+}
@@ -0,0 +1,215 @@
+package integrationtests
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logLevel int
+
+const (
+	levelInfo logLevel = iota
+	levelDebug
+	levelError
+)
+
+// hostPort is set from either a "host:port" string or a {host, port} table
+// in config files.
+type hostPort struct {
+	Host string
+	Port int
+}
+
+func init() {
+	parse.RegisterDecodeHook(
+		parse.NewDecodeHook(func(v interface{}) (logLevel, error) {
+			s, ok := v.(string)
+			if !ok {
+				return 0, fmt.Errorf("log level must be a string, not %T", v)
+			}
+			switch strings.ToLower(s) {
+			case "info":
+				return levelInfo, nil
+			case "debug":
+				return levelDebug, nil
+			case "error":
+				return levelError, nil
+			default:
+				return 0, fmt.Errorf("unknown log level %q", s)
+			}
+		}),
+		parse.NewDecodeHook(func(v interface{}) (hostPort, error) {
+			switch tv := v.(type) {
+			case string:
+				hp := hostPort{}
+				host, port, ok := strings.Cut(tv, ":")
+				if !ok {
+					return hp, fmt.Errorf("missing port in %q", tv)
+				}
+				hp.Host = host
+				_, err := fmt.Sscan(port, &hp.Port)
+				return hp, err
+			case map[string]interface{}:
+				hp := hostPort{}
+				hp.Host, _ = tv["host"].(string)
+				// the type of numbers depends on the decoder
+				_, err := fmt.Sscan(fmt.Sprint(tv["port"]), &hp.Port)
+				return hp, err
+			default:
+				return hostPort{}, fmt.Errorf("unsupported address %v", v)
+			}
+		}),
+	)
+}
+
+type decodeHooksConfig struct {
+	Name    string
+	Level   logLevel
+	Primary hostPort
+	Replica *hostPort
+}
+
+func TestDecodeHooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for name, src := range map[string]dials.Source{
+		"json": &static.StringSource{Decoder: &json.Decoder{},
+			Data: `{"Name": "svc", "Level": "debug", "Primary": "db1:5432", "Replica": {"host": "db2", "port": 6432}}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{},
+			Data: "name: svc\nlevel: DEBUG\nprimary: db1:5432\nreplica:\n  host: db2\n  port: 6432\n"},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{},
+			Data: "Name = \"svc\"\nLevel = \"debug\"\nPrimary = \"db1:5432\"\n[Replica]\nhost = \"db2\"\nport = 6432\n"},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{}, Data: `
+Name: "svc"
+Level: "debug"
+Primary: "db1:5432"
+Replica: host: "db2"
+Replica: port: 6432
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, err := dials.Config(ctx, &decodeHooksConfig{Level: levelError}, src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, "svc", c.Name)
+			assert.Equal(t, levelDebug, c.Level)
+			assert.Equal(t, hostPort{Host: "db1", Port: 5432}, c.Primary)
+			assert.Equal(t, &hostPort{Host: "db2", Port: 6432}, c.Replica)
+		})
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+		tmpl := &decodeHooksConfig{Level: levelError, Primary: hostPort{Host: "localhost", Port: 1}}
+		d, err := dials.Config(ctx, tmpl, &static.StringSource{Decoder: &json.Decoder{}, Data: `{"Name": "svc"}`})
+		require.NoError(t, err)
+		assert.Equal(t, levelError, d.View().Level)
+		assert.Equal(t, hostPort{Host: "localhost", Port: 1}, d.View().Primary)
+		assert.Nil(t, d.View().Replica)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := dials.Config(ctx, &decodeHooksConfig{},
+			&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Level": "verbose"}`})
+		assert.ErrorContains(t, err, `unknown log level "verbose"`)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		t.Parallel()
+		// flags aren't registered for hooked structs, which can't be
+		// parsed from strings
+		fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &decodeHooksConfig{}, []string{"--level=2"})
+		require.NoError(t, err)
+		assert.Nil(t, fset.Flags.Lookup("primary"))
+		d, err := dials.Config(ctx, &decodeHooksConfig{}, fset)
+		require.NoError(t, err)
+		assert.Equal(t, levelError, d.View().Level)
+	})
+}
+
+// priority has no registered hook, so it's only decoded with the hooks set
+// in Params.DecodeHooks.
+type priority int
+
+func TestPerConfigDecodeHooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	type config struct {
+		Priority priority
+		Level    logLevel
+	}
+	named := parse.NewDecodeHook(func(v interface{}) (priority, error) {
+		switch v {
+		case "low":
+			return 1, nil
+		case "high":
+			return 10, nil
+		default:
+			return 0, fmt.Errorf("unknown priority %v", v)
+		}
+	})
+	inverted := parse.NewDecodeHook(func(v interface{}) (priority, error) {
+		n, ok := v.(float64)
+		if !ok {
+			return 0, fmt.Errorf("priority must be a number, not %T", v)
+		}
+		return priority(-n), nil
+	})
+	// replaces the registered hook for this configuration only
+	numericLevel := parse.NewDecodeHook(func(v interface{}) (logLevel, error) {
+		n, ok := v.(float64)
+		if !ok {
+			return 0, fmt.Errorf("log level must be a number, not %T", v)
+		}
+		return logLevel(n), nil
+	})
+
+	d1, err := dials.Params[config]{DecodeHooks: []parse.DecodeHook{named}}.Config(ctx, &config{},
+		&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Priority": "high", "Level": "debug"}`})
+	require.NoError(t, err)
+	assert.Equal(t, &config{Priority: 10, Level: levelDebug}, d1.View())
+
+	d2, err := dials.New(ctx, &config{},
+		dials.WithDecodeHooks(inverted, numericLevel),
+		dials.WithSources(&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Priority": 3, "Level": 2}`}))
+	require.NoError(t, err)
+	assert.Equal(t, &config{Priority: -3, Level: levelError}, d2.View())
+
+	// without a hook, the field is decoded directly
+	d3, err := dials.Config(ctx, &config{},
+		&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Priority": 3}`})
+	require.NoError(t, err)
+	assert.Equal(t, priority(3), d3.View().Priority)
+
+	_, err = dials.Params[config]{DecodeHooks: []parse.DecodeHook{{Target: reflect.TypeOf(priority(0))}}}.Config(
+		ctx, &config{}, &static.StringSource{Decoder: &json.Decoder{}, Data: `{}`})
+	assert.ErrorContains(t, err, "incomplete DecodeHook")
+
+	type address struct {
+		Host string
+		Port int
+	}
+	type addrConfig struct{ Addr address }
+	_, err = dials.Params[addrConfig]{DecodeHooks: []parse.DecodeHook{
+		parse.NewDecodeHook(func(v interface{}) (address, error) { return address{}, nil }),
+	}}.Config(ctx, &addrConfig{}, &static.StringSource{Decoder: &json.Decoder{}, Data: `{}`})
+	assert.ErrorContains(t, err, "must be registered with parse.RegisterDecodeHook")
+}
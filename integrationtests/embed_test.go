@@ -0,0 +1,112 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type InlinedServer struct {
+	Host string
+	Port int
+}
+
+type NestedDB struct {
+	Host string
+}
+
+type embedConfig struct {
+	InlinedServer `dialsembed:"inline"`
+	NestedDB      `dialsembed:"nested"`
+	Name          string
+}
+
+func TestEmbedTag(t *testing.T) {
+	ctx := context.Background()
+	flagArgs := []string{"--host=web1", "--port=80", "--nested-db-host=db1", "--name=svc"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &embedConfig{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &embedConfig{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"HOST":           "web1",
+			"PORT":           "80",
+			"NESTED_DB_HOST": "db1",
+			"NAME":           "svc",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"Host": "web1",
+			"Port": 80,
+			"NestedDB": {"Host": "db1"},
+			"Name": "svc"
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+host: web1
+port: 80
+nesteddb:
+  host: db1
+name: svc
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+Host = "web1"
+Port = 80
+Name = "svc"
+[NestedDB]
+Host = "db1"
+`},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{}, Data: `
+Host: "web1"
+Port: 80
+NestedDB: Host: "db1"
+Name: "svc"
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &embedConfig{}, src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, InlinedServer{Host: "web1", Port: 80}, c.InlinedServer)
+			assert.Equal(t, NestedDB{Host: "db1"}, c.NestedDB)
+			assert.Equal(t, "svc", c.Name)
+		})
+	}
+}
+
+func TestEmbedTagInvalid(t *testing.T) {
+	type badValue struct {
+		NestedDB `dialsembed:"sideways"`
+	}
+	type notEmbedded struct {
+		DB NestedDB `dialsembed:"inline"`
+	}
+	jsonSrc := &static.StringSource{Decoder: &json.Decoder{}, Data: `{}`}
+	envSrc := &env.Source{LookupEnv: env.MapLookup(nil)}
+	ctx := context.Background()
+
+	_, err := dials.Config(ctx, &badValue{}, jsonSrc)
+	assert.ErrorContains(t, err, `invalid dialsembed tag "sideways"`)
+	_, err = dials.Config(ctx, &badValue{}, envSrc)
+	assert.ErrorContains(t, err, `invalid dialsembed tag "sideways"`)
+
+	_, err = dials.Config(ctx, &notEmbedded{}, jsonSrc)
+	assert.ErrorContains(t, err, "isn't an embedded struct")
+	_, err = dials.Config(ctx, &notEmbedded{}, envSrc)
+	assert.ErrorContains(t, err, "isn't an embedded struct")
+}
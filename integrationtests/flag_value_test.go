@@ -0,0 +1,104 @@
+package integrationtests
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verbosity is a custom flag type, like those found in existing command-line tools.
+type verbosity int
+
+func (l *verbosity) Set(s string) error {
+	for i, name := range []string{"debug", "info", "warn"} {
+		if s == name {
+			*l = verbosity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown log level %q", s)
+}
+
+func (l *verbosity) String() string {
+	return []string{"debug", "info", "warn"}[*l]
+}
+
+// upstreamAddr is a struct-typed flag.Value, which is set as a whole rather than
+// field by field.
+type upstreamAddr struct {
+	Host string
+	Port int
+}
+
+func (h *upstreamAddr) Set(s string) error {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("%q isn't host:port", s)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	h.Host, h.Port = host, p
+	return nil
+}
+
+func (h *upstreamAddr) String() string {
+	return fmt.Sprintf("%s:%d", h.Host, h.Port)
+}
+
+type flagValueConfig struct {
+	Level    verbosity
+	Upstream upstreamAddr
+	Name     string
+}
+
+func TestFlagValueFields(t *testing.T) {
+	ctx := context.Background()
+	expected := &flagValueConfig{Level: 2, Upstream: upstreamAddr{Host: "example.com", Port: 443}, Name: "svc"}
+	template := func() *flagValueConfig {
+		return &flagValueConfig{Level: 1, Name: "svc"}
+	}
+
+	envSrc := &env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"LEVEL":    "warn",
+		"UPSTREAM": "example.com:443",
+	})}
+	d, err := dials.Config(ctx, template(), envSrc)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d.View())
+
+	fs, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), template(),
+		[]string{"-level=warn", "-upstream=example.com:443"})
+	require.NoError(t, err)
+	d, err = dials.Config(ctx, template(), fs)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d.View())
+
+	pfs, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), template(),
+		[]string{"--level=warn", "--upstream=example.com:443"})
+	require.NoError(t, err)
+	d, err = dials.Config(ctx, template(), pfs)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d.View())
+
+	// unset flags leave the template's values alone
+	fs, err = flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), template(), nil)
+	require.NoError(t, err)
+	d, err = dials.Config(ctx, template(), fs)
+	require.NoError(t, err)
+	assert.Equal(t, verbosity(1), d.View().Level)
+
+	_, err = dials.Config(ctx, template(), &env.Source{LookupEnv: env.MapLookup(map[string]string{"LEVEL": "loud"})})
+	assert.ErrorContains(t, err, `unknown log level "loud"`)
+}
@@ -0,0 +1,138 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type BackendOpts struct {
+	Host string
+	Port int
+}
+
+type twoOf[A, B any] struct {
+	First  A
+	Second B
+}
+
+type genericConfig[T any] struct {
+	Backend  T
+	Fallback *T
+	Pairs    twoOf[string, T]
+	Named    map[string]T
+	Limits   twoOf[int, *int]
+}
+
+func TestGenericConfig(t *testing.T) {
+	ctx := context.Background()
+	flagArgs := []string{
+		"--backend-host=db1", "--fallback-port=6432", "--pairs-second-port=5",
+		"--named=primary.Host:db2", "--limits-second=7",
+	}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &genericConfig[BackendOpts]{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &genericConfig[BackendOpts]{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"BACKEND_HOST":      "db1",
+			"FALLBACK_PORT":     "6432",
+			"PAIRS_SECOND_PORT": "5",
+			"NAMED":             "primary.Host:db2",
+			"LIMITS_SECOND":     "7",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"Backend": {"Host": "db1"},
+			"Fallback": {"Port": 6432},
+			"Pairs": {"Second": {"Port": 5}},
+			"Named": {"primary": {"Host": "db2"}},
+			"Limits": {"Second": 7}
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+backend: {host: db1}
+fallback: {port: 6432}
+pairs: {second: {port: 5}}
+named: {primary: {host: db2}}
+limits: {second: 7}
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+[Backend]
+Host = "db1"
+[Fallback]
+Port = 6432
+[Pairs.Second]
+Port = 5
+[Named.primary]
+Host = "db2"
+[Limits]
+Second = 7
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &genericConfig[BackendOpts]{
+				Backend: BackendOpts{Port: 5432},
+			}, src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, BackendOpts{Host: "db1", Port: 5432}, c.Backend)
+			require.NotNil(t, c.Fallback)
+			assert.Equal(t, 6432, c.Fallback.Port)
+			assert.Equal(t, BackendOpts{Port: 5}, c.Pairs.Second)
+			assert.Equal(t, map[string]BackendOpts{"primary": {Host: "db2"}}, c.Named)
+			require.NotNil(t, c.Limits.Second)
+			assert.Equal(t, 7, *c.Limits.Second)
+		})
+	}
+}
+
+func TestGenericPointerInstantiation(t *testing.T) {
+	ctx := context.Background()
+	// *T fields are pointers to pointers when T is a pointer type
+	type config = genericConfig[*BackendOpts]
+	flagArgs := []string{"--backend-host=db1", "--fallback-port=6432"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &config{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &config{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"BACKEND_HOST":  "db1",
+			"FALLBACK_PORT": "6432",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"Backend": {"Host": "db1"},
+			"Fallback": {"Port": 6432}
+		}`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &config{}, src)
+			require.NoError(t, err)
+			c := d.View()
+			require.NotNil(t, c.Backend)
+			assert.Equal(t, "db1", c.Backend.Host)
+			require.NotNil(t, c.Fallback)
+			require.NotNil(t, *c.Fallback)
+			assert.Equal(t, 6432, (*c.Fallback).Port)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ignoredFieldsConfig struct {
+	Name string
+	// runtime-only fields, some of types no source could set
+	Conns  chan int      `dials:"-"`
+	Hook   func() string `dials:"-"`
+	Count  int           `dials:"-"`
+	Nested struct {
+		Token string
+	} `dials:"-"`
+}
+
+func TestIgnoredFields(t *testing.T) {
+	ctx := context.Background()
+	conns := make(chan int)
+	newTemplate := func() *ignoredFieldsConfig {
+		cfg := &ignoredFieldsConfig{Conns: conns, Count: 4}
+		cfg.Nested.Token = "tmpl"
+		return cfg
+	}
+
+	flagArgs := []string{"--name=svc"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), newTemplate(), flagArgs)
+	require.NoError(t, err)
+	// no flags are registered for the ignored fields
+	assert.Nil(t, fset.Flags.Lookup("count"))
+	assert.Nil(t, fset.Flags.Lookup("nested-token"))
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), newTemplate(), flagArgs)
+	require.NoError(t, err)
+	assert.Nil(t, pset.Flags.Lookup("count"))
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"NAME":         "svc",
+			"COUNT":        "9",
+			"NESTED_TOKEN": "env",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{"Name": "svc", "Count": 9, "Nested": {"Token": "json"}}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: "name: svc\ncount: 9\nnested:\n  token: yaml\n"},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: "Name = \"svc\"\nCount = 9\n[Nested]\nToken = \"toml\"\n"},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, newTemplate(), src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, "svc", c.Name)
+			// the ignored fields keep the template's values
+			assert.Equal(t, conns, c.Conns)
+			assert.Equal(t, 4, c.Count)
+			assert.Equal(t, "tmpl", c.Nested.Token)
+		})
+	}
+}
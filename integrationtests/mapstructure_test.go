@@ -0,0 +1,49 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/static"
+	"github.com/vimeo/dials/tagformat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MapstructureCommon is exported, as mapstructure only squashes exported
+// embedded structs.
+type MapstructureCommon struct {
+	Region string `mapstructure:"region"`
+}
+
+type mapstructureConfig struct {
+	MapstructureCommon `mapstructure:",squash"`
+	Name               string `mapstructure:"service_name"`
+	Port               int    `mapstructure:"port,omitempty" dials:"listen_port"`
+	Database           struct {
+		MaxConns int `mapstructure:"max_conns"`
+	} `mapstructure:"db"`
+}
+
+func TestMapstructureTags(t *testing.T) {
+	ctx := context.Background()
+	file := &static.StringSource{
+		Data:    `{"region": "us-east", "service_name": "svc", "listen_port": 8080, "db": {"max_conns": 5}}`,
+		Decoder: tagformat.MapstructureDecoder(&json.Decoder{}),
+	}
+	envSrc := tagformat.MapstructureSource(&env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"SERVICE_NAME": "from-env",
+		"DB_MAX_CONNS": "7",
+	})})
+	d, err := dials.Config(ctx, &mapstructureConfig{}, file, envSrc)
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, "us-east", cfg.Region)
+	assert.Equal(t, "from-env", cfg.Name)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, 7, cfg.Database.MaxConns)
+}
@@ -0,0 +1,94 @@
+package integrationtests
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type netConfig struct {
+	Endpoint *url.URL
+	Proxy    url.URL
+	Addr     net.IP
+	Allowed  *net.IPNet
+}
+
+func TestNetTypes(t *testing.T) {
+	ctx := context.Background()
+	flagArgs := []string{
+		"--endpoint=https://example.com/api?x=1",
+		"--proxy=http://proxy:3128",
+		"--addr=192.0.2.1",
+		"--allowed=10.0.0.0/8",
+	}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &netConfig{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &netConfig{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"ENDPOINT": "https://example.com/api?x=1",
+			"PROXY":    "http://proxy:3128",
+			"ADDR":     "192.0.2.1",
+			"ALLOWED":  "10.0.0.0/8",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"Endpoint": "https://example.com/api?x=1",
+			"Proxy": "http://proxy:3128",
+			"Addr": "192.0.2.1",
+			"Allowed": "10.0.0.0/8"
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+endpoint: https://example.com/api?x=1
+proxy: http://proxy:3128
+addr: 192.0.2.1
+allowed: 10.0.0.0/8
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+Endpoint = "https://example.com/api?x=1"
+Proxy = "http://proxy:3128"
+Addr = "192.0.2.1"
+Allowed = "10.0.0.0/8"
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &netConfig{}, src)
+			require.NoError(t, err)
+			c := d.View()
+			require.NotNil(t, c.Endpoint)
+			assert.Equal(t, "https://example.com/api?x=1", c.Endpoint.String())
+			assert.Equal(t, "proxy:3128", c.Proxy.Host)
+			assert.True(t, net.IPv4(192, 0, 2, 1).Equal(c.Addr), "got %s", c.Addr)
+			require.NotNil(t, c.Allowed)
+			assert.Equal(t, "10.0.0.0/8", c.Allowed.String())
+		})
+	}
+}
+
+func TestNetTypesInvalid(t *testing.T) {
+	for name, vars := range map[string]map[string]string{
+		"url":  {"ENDPOINT": "http://[::1"},
+		"ip":   {"ADDR": "192.0.2.300"},
+		"cidr": {"ALLOWED": "10.0.0.0"},
+	} {
+		_, err := dials.Config(context.Background(), &netConfig{}, &env.Source{LookupEnv: env.MapLookup(vars)})
+		assert.Error(t, err, name)
+	}
+}
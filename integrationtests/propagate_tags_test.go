@@ -0,0 +1,52 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/static"
+	"github.com/vimeo/dials/tagformat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonTaggedConfig struct {
+	ListenPort int `json:"listen_port"`
+	Database   struct {
+		HostName string `json:"host_name,omitempty"`
+	} `json:"db"`
+}
+
+func TestPropagateTags(t *testing.T) {
+	ctx := context.Background()
+	for name, src := range map[string]dials.Source{
+		"yaml": tagformat.PropagateTagsSource(&static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+listen_port: 8080
+db:
+  host_name: db1
+`}, "json", "yaml"),
+		"toml": tagformat.PropagateTagsSource(&static.StringSource{Decoder: &toml.Decoder{}, Data: `
+listen_port = 8080
+[db]
+host_name = "db1"
+`}, "json", "toml"),
+		"env": tagformat.PropagateTagsSource(&env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"LISTEN_PORT":  "8080",
+			"DB_HOST_NAME": "db1",
+		})}, "json", "dials"),
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &jsonTaggedConfig{}, src)
+			require.NoError(t, err)
+			cfg := d.View()
+			assert.Equal(t, 8080, cfg.ListenPort)
+			assert.Equal(t, "db1", cfg.Database.HostName)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package integrationtests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeNode struct {
+	Path     string
+	Children []routeNode
+}
+
+type recursiveConfig struct {
+	Name   string
+	Routes routeNode
+}
+
+type ignoredRecursionConfig struct {
+	Name   string
+	Routes routeNode `dials:"-"`
+}
+
+func TestRecursiveConfigType(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	checkErr := func(t testing.TB, err error) {
+		t.Helper()
+		cycleErr := &ptrify.CycleError{}
+		require.True(t, errors.As(err, &cycleErr), "unexpected error: %v", err)
+		assert.Equal(t, reflect.TypeOf(routeNode{}), cycleErr.Type)
+		assert.Equal(t, []string{"Children"}, cycleErr.Path)
+		assert.Equal(t, []string{"Routes"}, cycleErr.Prefix)
+	}
+
+	_, err := dials.Config(ctx, &recursiveConfig{},
+		&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Name": "svc"}`})
+	checkErr(t, err)
+
+	_, err = flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &recursiveConfig{}, nil)
+	checkErr(t, err)
+	_, err = pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &recursiveConfig{}, nil)
+	checkErr(t, err)
+
+	// ignoring the recursive field makes the type usable
+	d, err := dials.Config(ctx, &ignoredRecursionConfig{},
+		&static.StringSource{Decoder: &json.Decoder{}, Data: `{"Name": "svc"}`})
+	require.NoError(t, err)
+	assert.Equal(t, "svc", d.View().Name)
+}
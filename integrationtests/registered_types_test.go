@@ -0,0 +1,127 @@
+package integrationtests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardID is a third-party-style type that doesn't implement
+// encoding.TextUnmarshaler, so it's registered with parse.RegisterType.
+type shardID struct {
+	Region string
+	Index  int
+}
+
+func init() {
+	parse.RegisterType(func(s string) (shardID, error) {
+		region, idx, ok := strings.Cut(s, "/")
+		if !ok {
+			return shardID{}, fmt.Errorf("invalid shard %q", s)
+		}
+		id := shardID{Region: region}
+		if _, err := fmt.Sscan(idx, &id.Index); err != nil {
+			return shardID{}, fmt.Errorf("invalid shard index %q: %w", idx, err)
+		}
+		return id, nil
+	}, func(id shardID) string { return fmt.Sprintf("%s/%d", id.Region, id.Index) })
+}
+
+type registeredTypesConfig struct {
+	Owner    sql.NullString
+	MaxConns sql.NullInt64
+	Ratio    *sql.NullFloat64
+	Shard    shardID
+	Fallback *shardID
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	newTemplate := func() *registeredTypesConfig {
+		return &registeredTypesConfig{Shard: shardID{Region: "us", Index: 1}}
+	}
+
+	flagArgs := []string{"--owner=ops", "--max-conns=20", "--ratio=0.5", "--shard=eu/3", "--fallback=ap/2"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), newTemplate(), flagArgs)
+	require.NoError(t, err)
+	assert.Equal(t, "us/1", fset.Flags.Lookup("shard").DefValue)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), newTemplate(), flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"OWNER":     "ops",
+			"MAX_CONNS": "20",
+			"RATIO":     "0.5",
+			"SHARD":     "eu/3",
+			"FALLBACK":  "ap/2",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{},
+			Data: `{"Owner": "ops", "MaxConns": "20", "Ratio": "0.5", "Shard": "eu/3", "Fallback": "ap/2"}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{},
+			Data: "owner: ops\nmaxconns: \"20\"\nratio: \"0.5\"\nshard: eu/3\nfallback: ap/2\n"},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{},
+			Data: "Owner = \"ops\"\nMaxConns = \"20\"\nRatio = \"0.5\"\nShard = \"eu/3\"\nFallback = \"ap/2\"\n"},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, err := dials.Config(ctx, newTemplate(), src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, sql.NullString{String: "ops", Valid: true}, c.Owner)
+			assert.Equal(t, sql.NullInt64{Int64: 20, Valid: true}, c.MaxConns)
+			assert.Equal(t, &sql.NullFloat64{Float64: 0.5, Valid: true}, c.Ratio)
+			assert.Equal(t, shardID{Region: "eu", Index: 3}, c.Shard)
+			assert.Equal(t, &shardID{Region: "ap", Index: 2}, c.Fallback)
+		})
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+		d, err := dials.Config(ctx, newTemplate(),
+			&static.StringSource{Decoder: &json.Decoder{}, Data: `{"MaxConns": ""}`})
+		require.NoError(t, err)
+		c := d.View()
+		assert.Equal(t, sql.NullString{}, c.Owner)
+		assert.Equal(t, sql.NullInt64{}, c.MaxConns)
+		assert.Nil(t, c.Ratio)
+		assert.Equal(t, shardID{Region: "us", Index: 1}, c.Shard)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := dials.Config(ctx, newTemplate(),
+			&env.Source{LookupEnv: env.MapLookup(map[string]string{"SHARD": "eu"})})
+		assert.ErrorContains(t, err, `invalid shard "eu"`)
+	})
+
+	t.Run("environ", func(t *testing.T) {
+		t.Parallel()
+		cfg := newTemplate()
+		cfg.MaxConns = sql.NullInt64{Int64: 8, Valid: true}
+		vars, err := (&env.Source{}).Environ(cfg)
+		require.NoError(t, err)
+		assert.Contains(t, vars, "MAX_CONNS=8")
+		assert.Contains(t, vars, "OWNER=")
+		assert.Contains(t, vars, "SHARD=us/1")
+	})
+}
@@ -0,0 +1,121 @@
+package integrationtests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/dialstest"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantLimits struct {
+	RPS   int `dials:"rps"`
+	Burst int `dials:"burst"`
+}
+
+type tenantConfig struct {
+	Timeout  time.Duration `dials:"timeout"`
+	Limits   tenantLimits  `dials:"limits"`
+	Features []string      `dials:"features"`
+	Beta     bool          `dials:"beta"`
+
+	Tenants map[string]*tenantOverrides `dials:"tenants" dialstenants:"true" dialsmerge:"merge"`
+}
+
+type tenantOverrides struct {
+	Timeout  time.Duration `dials:"timeout"`
+	Limits   tenantLimits  `dials:"limits"`
+	Features []string      `dials:"features"`
+	Beta     *bool         `dials:"beta"`
+}
+
+func (c *tenantConfig) Verify() error {
+	if c.Limits.Burst < c.Limits.RPS {
+		return errors.New("burst must be at least rps")
+	}
+	return nil
+}
+
+func TestForTenant(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	file := &static.StringSource{
+		Data: `
+timeout: 5s
+limits: {rps: 10, burst: 20}
+beta: true
+tenants:
+  acme:
+    timeout: 30s
+    beta: false
+  initech:
+    limits: {rps: 50}
+`,
+		Decoder: &yaml.Decoder{},
+	}
+	// the overrides for a tenant are composed from every source
+	overrides := &static.StringSource{
+		Data:    `{"tenants": {"acme": {"limits": {"burst": 100}, "features": ["export"]}}}`,
+		Decoder: &json.Decoder{},
+	}
+	d, err := dials.Config(ctx, &tenantConfig{}, file, overrides)
+	require.NoError(t, err)
+
+	acme, err := d.ForTenant(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, acme.Timeout)
+	assert.Equal(t, tenantLimits{RPS: 10, Burst: 100}, acme.Limits)
+	assert.Equal(t, []string{"export"}, acme.Features)
+	assert.False(t, acme.Beta)
+
+	again, err := d.ForTenant(ctx, "acme")
+	require.NoError(t, err)
+	assert.Same(t, acme, again)
+
+	// tenants without overrides see the configuration itself
+	other, err := d.ForTenant(ctx, "globex")
+	require.NoError(t, err)
+	assert.Same(t, d.View(), other)
+	assert.Equal(t, 5*time.Second, d.View().Timeout)
+	assert.True(t, d.View().Beta)
+
+	// a tenant's view is verified
+	_, err = d.ForTenant(ctx, "initech")
+	assert.ErrorContains(t, err, `configuration for tenant "initech" failed verification: burst must be at least rps`)
+}
+
+func TestForTenantRecomputedOnUpdate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	file := &static.StringSource{
+		Data:    "tenants: {acme: {limits: {burst: 10}}}",
+		Decoder: &yaml.Decoder{},
+	}
+	src := dialstest.NewSource(&tenantConfig{Timeout: time.Second, Limits: tenantLimits{RPS: 1, Burst: 1}})
+	d, err := dials.Config(ctx, &tenantConfig{}, file, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	acme, err := d.ForTenant(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, acme.Timeout)
+	assert.Equal(t, tenantLimits{RPS: 1, Burst: 10}, acme.Limits)
+
+	require.NoError(t, src.Push(ctx, &tenantConfig{Timeout: 3 * time.Second, Limits: tenantLimits{RPS: 5, Burst: 5}}))
+	_, err = dialstest.Await(ctx, d, func(c *tenantConfig) bool { return c.Timeout == 3*time.Second })
+	require.NoError(t, err)
+	acme, err = d.ForTenant(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, acme.Timeout)
+	assert.Equal(t, tenantLimits{RPS: 5, Burst: 10}, acme.Limits)
+}
@@ -0,0 +1,113 @@
+package integrationtests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+	"github.com/vimeo/dials/sources/urfavecli"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// level is a string-kinded TextUnmarshaler that normalizes its value.
+type level string
+
+func (l *level) UnmarshalText(b []byte) error {
+	s := strings.ToLower(string(b))
+	switch s {
+	case "debug", "info", "error":
+		*l = level(s)
+		return nil
+	}
+	return fmt.Errorf("unknown level %q", b)
+}
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte(l), nil
+}
+
+// pair is a struct-kinded TextUnmarshaler written as "key:value".
+type pair struct {
+	Key, Value string
+}
+
+func (p *pair) UnmarshalText(b []byte) error {
+	k, v, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return fmt.Errorf("invalid pair %q", b)
+	}
+	p.Key, p.Value = k, v
+	return nil
+}
+
+func (p pair) MarshalText() ([]byte, error) {
+	return []byte(p.Key + ":" + p.Value), nil
+}
+
+type textConfig struct {
+	Level level
+	Pair  pair
+	Ptr   *pair
+}
+
+type fakeCLIContext map[string]interface{}
+
+func (f fakeCLIContext) IsSet(name string) bool {
+	_, ok := f[name]
+	return ok
+}
+
+func (f fakeCLIContext) Value(name string) interface{} {
+	return f[name]
+}
+
+func TestTextUnmarshalers(t *testing.T) {
+	ctx := context.Background()
+	flagArgs := []string{"--level=DEBUG", "--pair=a:b", "--ptr=c:d"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &textConfig{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &textConfig{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"LEVEL": "DEBUG", "PAIR": "a:b", "PTR": "c:d",
+		})},
+		"urfavecli": &urfavecli.Source{Context: fakeCLIContext{
+			"level": "DEBUG", "pair": "a:b", "ptr": "c:d",
+		}},
+		"json": &static.StringSource{Decoder: &json.Decoder{},
+			Data: `{"Level": "DEBUG", "Pair": "a:b", "Ptr": "c:d"}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{},
+			Data: "level: DEBUG\npair: a:b\nptr: c:d\n"},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{},
+			Data: "Level = \"DEBUG\"\nPair = \"a:b\"\nPtr = \"c:d\"\n"},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{},
+			Data: `Level: "DEBUG", Pair: "a:b", Ptr: "c:d"`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &textConfig{}, src)
+			require.NoError(t, err)
+			assert.Equal(t, &textConfig{
+				Level: "debug",
+				Pair:  pair{Key: "a", Value: "b"},
+				Ptr:   &pair{Key: "c", Value: "d"},
+			}, d.View())
+		})
+	}
+}
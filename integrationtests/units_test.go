@@ -0,0 +1,103 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/flag"
+	"github.com/vimeo/dials/sources/pflag"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unitsConfig struct {
+	Timeout   time.Duration
+	Interval  *time.Duration
+	CacheSize int64  `dialsunit:"bytes"`
+	MaxBody   uint32 `dialsunit:"bytes"`
+}
+
+func TestUnits(t *testing.T) {
+	ctx := context.Background()
+	flagArgs := []string{"--timeout=30s", "--interval=5m", "--cache-size=1GiB", "--max-body=64KB"}
+	fset, err := flag.NewSetWithArgs(flag.DefaultFlagNameConfig(), &unitsConfig{}, flagArgs)
+	require.NoError(t, err)
+	pset, err := pflag.NewSetWithArgs(pflag.DefaultFlagNameConfig(), &unitsConfig{}, flagArgs)
+	require.NoError(t, err)
+
+	for name, src := range map[string]dials.Source{
+		"flag":  fset,
+		"pflag": pset,
+		"env": &env.Source{LookupEnv: env.MapLookup(map[string]string{
+			"TIMEOUT":    "30s",
+			"INTERVAL":   "5m",
+			"CACHE_SIZE": "1GiB",
+			"MAX_BODY":   "64KB",
+		})},
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"Timeout": "30s",
+			"Interval": "5m",
+			"CacheSize": "1GiB",
+			"MaxBody": 64000
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+timeout: 30s
+interval: 5m
+cachesize: 1GiB
+maxbody: 64KB
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+Timeout = "30s"
+Interval = "5m"
+CacheSize = 1073741824
+MaxBody = "64KB"
+`},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{}, Data: `
+Timeout: "30s"
+Interval: "5m"
+CacheSize: "1GiB"
+MaxBody: 64000
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Config(ctx, &unitsConfig{}, src)
+			require.NoError(t, err)
+			c := d.View()
+			assert.Equal(t, 30*time.Second, c.Timeout)
+			require.NotNil(t, c.Interval)
+			assert.Equal(t, 5*time.Minute, *c.Interval)
+			assert.Equal(t, int64(1<<30), c.CacheSize)
+			assert.Equal(t, uint32(64000), c.MaxBody)
+		})
+	}
+}
+
+func TestUnitsInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"duration": `{"Timeout": "30 parsecs"}`,
+		"size":     `{"CacheSize": "1 furlong"}`,
+		"overflow": `{"MaxBody": "8GiB"}`,
+		"negative": `{"MaxBody": -1}`,
+	} {
+		_, err := dials.Config(context.Background(), &unitsConfig{},
+			&static.StringSource{Decoder: &json.Decoder{}, Data: data})
+		assert.Error(t, err, name)
+	}
+
+	type badTag struct {
+		Name string `dialsunit:"bytes"`
+	}
+	_, err := dials.Config(context.Background(), &badTag{},
+		&static.StringSource{Decoder: &json.Decoder{}, Data: `{}`})
+	assert.Error(t, err)
+}
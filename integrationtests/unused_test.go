@@ -0,0 +1,80 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/cue"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unusedConfig struct {
+	ListenAddr string `dials:"listen_addr" dialsalias:"addr"`
+	Database   struct {
+		Host string `dials:"host"`
+		Port int    `dials:"port"`
+	} `dials:"database"`
+	Replicas []struct {
+		Host string `dials:"host"`
+	} `dials:"replicas"`
+}
+
+func TestUnusedConfig(t *testing.T) {
+	ctx := context.Background()
+	for name, src := range map[string]dials.Source{
+		"json": &static.StringSource{Decoder: &json.Decoder{}, Data: `{
+			"addr": ":8080",
+			"database": {"host": "db1", "timeout": "1s"},
+			"replicas": [{"host": "r1", "weight": 1}],
+			"cache": {"size": 10}
+		}`},
+		"yaml": &static.StringSource{Decoder: &yaml.Decoder{}, Data: `
+addr: ":8080"
+database:
+  host: db1
+  timeout: 1s
+replicas:
+  - host: r1
+    weight: 1
+cache:
+  size: 10
+`},
+		"toml": &static.StringSource{Decoder: &toml.Decoder{}, Data: `
+addr = ":8080"
+[database]
+host = "db1"
+timeout = "1s"
+[[replicas]]
+host = "r1"
+weight = 1
+[cache]
+size = 10
+`},
+		"cue": &static.StringSource{Decoder: &cue.Decoder{}, Data: `
+addr: ":8080"
+database: host: "db1"
+database: timeout: "1s"
+replicas: [{host: "r1", weight: 1}]
+cache: size: 10
+`},
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			d, err := dials.Params[unusedConfig]{DetectUnusedConfig: true}.Config(ctx, &unusedConfig{}, src)
+			require.NoError(t, err)
+			unused := d.UnusedConfig()
+			assert.Equal(t, []string{"Database.Port"}, unused.UnsetFields)
+			require.Len(t, unused.UnknownKeys, 3)
+			assert.Contains(t, unused.UnknownKeys[0], `key "cache" doesn't correspond to any field`)
+			assert.Contains(t, unused.UnknownKeys[1], `key "database.timeout" doesn't correspond to any field`)
+			assert.Contains(t, unused.UnknownKeys[2], `key "replicas[0].weight" doesn't correspond to any field`)
+		})
+	}
+}
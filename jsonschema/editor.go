@@ -0,0 +1,50 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaStoreEntry is an entry in a JSON Schema Store catalog
+// (https://www.schemastore.org/api/json/catalog.json). Publishing one (or
+// adding it to a private catalog) lets editors apply the schema to matching
+// files automatically.
+type SchemaStoreEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// FileMatch contains glob patterns for the files the schema applies
+	// to (e.g. "myapp.yaml").
+	FileMatch []string `json:"fileMatch,omitempty"`
+	// URL is the location the schema is served from.
+	URL string `json:"url"`
+}
+
+// YAMLModeline returns the comment that associates a YAML file with the
+// schema at schemaURL in editors using the YAML language-server (including
+// VS Code's YAML extension). It should be the first line of the file.
+func YAMLModeline(schemaURL string) string {
+	return "# yaml-language-server: $schema=" + schemaURL
+}
+
+// TOMLDirective returns the comment that associates a TOML file with the
+// schema at schemaURL in editors using taplo (including VS Code's Even Better
+// TOML extension). It should be the first line of the file.
+func TOMLDirective(schemaURL string) string {
+	return "#:schema " + schemaURL
+}
+
+// TaploConfig returns the contents of a taplo configuration file (.taplo.toml)
+// that applies the schema at schemaURL to TOML files matching the include
+// globs, for projects where adding a directive to each file isn't practical.
+func TaploConfig(schemaURL string, include ...string) (string, error) {
+	if len(include) == 0 {
+		return "", fmt.Errorf("at least one include pattern is required")
+	}
+	quoted := make([]string, len(include))
+	for i, pattern := range include {
+		quoted[i] = strconv.Quote(pattern)
+	}
+	return fmt.Sprintf("[[rule]]\ninclude = [%s]\n\n[rule.schema]\npath = %s\n",
+		strings.Join(quoted, ", "), strconv.Quote(schemaURL)), nil
+}
@@ -0,0 +1,338 @@
+// Package jsonschema generates JSON Schema documents describing the config
+// files accepted by the dials file decoders, along with the small artifacts
+// editors need to associate those schemas with config files (a JSON Schema
+// Store catalog entry, a YAML language-server modeline and a taplo config for
+// TOML), so users editing config files get completion and inline validation.
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Draft is the JSON Schema dialect of generated schemas. Draft 7 is the most
+// recent draft supported by the YAML language-server and taplo.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Draft202012 is the JSON Schema 2020-12 dialect. Generated schemas only use
+// keywords whose meaning is unchanged in 2020-12, so a schema's Schema field
+// may be set to Draft202012 for validators that expect it.
+const Draft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+// DescriptionTag is the name of the struct tag whose value is used as a
+// field's description. (it's shared with the flag sources' help text)
+const DescriptionTag = "dialsdesc"
+
+// EnumTag is the name of the struct tag listing (comma-separated) the values
+// a field accepts, which are included in its schema as an enum. (it's shared
+// with the flag source, which rejects other values) On slice fields, it
+// applies to the elements.
+const EnumTag = "dialsenum"
+
+// Format describes how a decoder maps struct fields to keys.
+type Format struct {
+	// TagName is the decoder-specific struct tag consulted before the
+	// `dials` tag (e.g. "yaml").
+	TagName string
+	// DefaultKey returns the key for a field with neither a TagName nor a
+	// `dials` tag.
+	DefaultKey func(fieldName string) string
+	// InlineEmbedded indicates that the fields of embedded structs without
+	// a key are promoted into the embedding struct. (fields with an
+	// ",inline" tag option or a `dialsembed:"inline"` tag are always
+	// promoted, and ones with a `dialsembed:"nested"` tag never are)
+	InlineEmbedded bool
+}
+
+var (
+	// JSON matches the json decoder (and encoding/json).
+	JSON = Format{TagName: "json", DefaultKey: identity, InlineEmbedded: true}
+	// YAML matches the yaml decoder (and gopkg.in/yaml.v2), which lowercases
+	// untagged field names.
+	YAML = Format{TagName: "yaml", DefaultKey: strings.ToLower}
+	// TOML matches the toml decoder (and github.com/pelletier/go-toml).
+	TOML = Format{TagName: "toml", DefaultKey: identity, InlineEmbedded: true}
+)
+
+func identity(s string) string { return s }
+
+// Schema is a JSON Schema document (or subschema). Only the keywords needed to
+// describe Go types are included.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+}
+
+// Generate returns a schema for config files in format f that decode into the
+// config struct pointed to by template. Non-zero scalar fields of template are
+// included as defaults. Unknown keys are permitted, since files may be
+// layered with other sources.
+func Generate(template interface{}, f Format) (*Schema, error) {
+	v := reflect.ValueOf(template)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("template must be a non-nil pointer to a struct, got %T", template)
+	}
+	g := generator{format: f, visiting: map[reflect.Type]struct{}{}}
+	s := g.schema(v.Elem().Type(), v.Elem())
+	s.Schema = Draft
+	return s, nil
+}
+
+type generator struct {
+	format Format
+	// visiting contains the struct types currently being expanded, so
+	// recursive types terminate.
+	visiting map[reflect.Type]struct{}
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	urlType             = reflect.TypeOf(url.URL{})
+	ipNetType           = reflect.TypeOf(net.IPNet{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// schema returns the schema for t. v is either the zero Value, or a value of
+// type t from the template.
+func (g *generator) schema(t reflect.Type, v reflect.Value) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() {
+			if v.IsNil() {
+				v = reflect.Value{}
+			} else {
+				v = v.Elem()
+			}
+		}
+	}
+
+	s := &Schema{}
+	switch {
+	case t == durationType:
+		s.Type = "string"
+		s.Description = `a duration such as "1m30s"`
+	case t == timeType:
+		s.Type = "string"
+		s.Format = "date-time"
+	case t == urlType:
+		s.Type = "string"
+		s.Format = "uri-reference"
+	case t == ipNetType:
+		s.Type = "string"
+		s.Description = `a network in CIDR notation such as "192.0.2.0/24"`
+	case reflect.PtrTo(t).Implements(textUnmarshalerType), parse.Registered(t):
+		s.Type = "string"
+	case ptrify.IsPassThrough(t):
+		// raw values and deferred sections may hold anything
+		return s
+	default:
+		g.fillKind(s, t, v)
+		return s
+	}
+	s.Default = scalarDefault(v)
+	return s
+}
+
+func (g *generator) fillKind(s *Schema, t reflect.Type, v reflect.Value) {
+	switch t.Kind() {
+	case reflect.Bool:
+		s.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		s.Type = "number"
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Slice, reflect.Array:
+		s.Type = "array"
+		s.Items = g.schema(t.Elem(), reflect.Value{})
+		return
+	case reflect.Map:
+		s.Type = "object"
+		s.AdditionalProperties = g.schema(t.Elem(), reflect.Value{})
+		return
+	case reflect.Struct:
+		s.Type = "object"
+		if _, ok := g.visiting[t]; ok {
+			return
+		}
+		g.visiting[t] = struct{}{}
+		defer delete(g.visiting, t)
+		s.Properties = map[string]*Schema{}
+		g.addFields(s.Properties, t, v)
+		return
+	default:
+		// interfaces, channels and funcs can't be described usefully;
+		// leave the schema empty so anything validates.
+		return
+	}
+	s.Default = scalarDefault(v)
+}
+
+// addFields adds schemas for the fields of the struct type t to props.
+func (g *generator) addFields(props map[string]*Schema, t reflect.Type, v reflect.Value) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		key, inline, skip := g.format.FieldKey(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			ft, fval := sf.Type, fv
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+				if fval.IsValid() {
+					if fval.IsNil() {
+						fval = reflect.Value{}
+					} else {
+						fval = fval.Elem()
+					}
+				}
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(props, ft, fval)
+				continue
+			}
+		}
+		fs := g.schema(sf.Type, fv)
+		if desc, ok := sf.Tag.Lookup(DescriptionTag); ok {
+			fs.Description = desc
+		}
+		if choices, ok := sf.Tag.Lookup(EnumTag); ok && choices != "" {
+			addEnum(fs, choices)
+		}
+		props[key] = fs
+	}
+}
+
+// FieldKey returns the key for the struct field sf in config files of format
+// f, whether its fields are promoted into the enclosing struct (in which case
+// key is empty), and whether it's skipped entirely.
+func (f Format) FieldKey(sf reflect.StructField) (key string, inline, skip bool) {
+	if !sf.IsExported() && !sf.Anonymous {
+		return "", false, true
+	}
+	embed := sf.Tag.Get(common.EmbedTagName)
+	if sf.Anonymous && embed == "inline" {
+		return "", true, false
+	}
+	for _, tagName := range []string{f.TagName, common.DialsTagName} {
+		tag, ok := sf.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" && opts == "" {
+			return "", false, true
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "inline" {
+				return "", true, false
+			}
+		}
+		if name != "" {
+			return name, false, false
+		}
+	}
+	if sf.Anonymous && f.InlineEmbedded && embed != "nested" {
+		return "", true, false
+	}
+	if !sf.IsExported() {
+		return "", false, true
+	}
+	return f.DefaultKey(sf.Name), false, false
+}
+
+// addEnum sets the enum of s (or of its items, for arrays) to the
+// comma-separated choices, converted to s's type where possible.
+func addEnum(s *Schema, choices string) {
+	if s.Type == "array" && s.Items != nil {
+		s = s.Items
+	}
+	for _, c := range strings.Split(choices, ",") {
+		s.Enum = append(s.Enum, enumValue(s.Type, c))
+	}
+}
+
+func enumValue(typ, choice string) interface{} {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(choice, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(choice, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(choice); err == nil {
+			return b
+		}
+	}
+	return choice
+}
+
+// scalarDefault returns the JSON-encodable default for a leaf value from the
+// template, or nil if it's unset.
+func scalarDefault(v reflect.Value) interface{} {
+	if !v.IsValid() || v.IsZero() {
+		return nil
+	}
+	switch v.Type() {
+	case durationType:
+		return v.Interface().(time.Duration).String()
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String()
+	case ipNetType:
+		n := v.Interface().(net.IPNet)
+		return n.String()
+	}
+	if s, ok := parse.Format(v); ok {
+		return s
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return nil
+		}
+		return string(b)
+	}
+	return v.Interface()
+}
+
+// MarshalIndent returns the indented JSON encoding of s, suitable for writing
+// to a file that editors can reference.
+func (s *Schema) MarshalIndent() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,147 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/deferred"
+)
+
+type dbConfig struct {
+	Host    string `dialsdesc:"database hostname"`
+	Port    int    `yaml:"port_number"`
+	Timeout time.Duration
+}
+
+type Common struct {
+	Debug bool
+}
+
+type testConfig struct {
+	Common
+	Name     string `dials:"name"`
+	Ratio    float64
+	Tags     []string
+	Labels   map[string]string
+	Database *dbConfig
+	Ignored  string `dials:"-"`
+	Started  time.Time
+	Next     *testConfig
+	private  int
+}
+
+func TestGenerateYAML(t *testing.T) {
+	s, err := Generate(&testConfig{
+		Name:     "svc",
+		Database: &dbConfig{Port: 5432, Timeout: time.Second},
+	}, YAML)
+	require.NoError(t, err)
+
+	assert.Equal(t, Draft, s.Schema)
+	assert.Equal(t, "object", s.Type)
+	// yaml.v2 doesn't inline embedded structs without an ",inline" option
+	require.Contains(t, s.Properties, "common")
+	assert.Equal(t, "boolean", s.Properties["common"].Properties["debug"].Type)
+	assert.Equal(t, &Schema{Type: "string", Default: "svc"}, s.Properties["name"])
+	assert.Equal(t, "number", s.Properties["ratio"].Type)
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, s.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, s.Properties["labels"])
+	assert.NotContains(t, s.Properties, "ignored")
+	assert.NotContains(t, s.Properties, "private")
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, s.Properties["started"])
+
+	db := s.Properties["database"]
+	assert.Equal(t, &Schema{Type: "string", Description: "database hostname"}, db.Properties["host"])
+	assert.Equal(t, &Schema{Type: "integer", Default: 5432}, db.Properties["port_number"])
+	assert.Equal(t, "1s", db.Properties["timeout"].Default)
+
+	// the recursive field isn't expanded again
+	assert.Equal(t, &Schema{Type: "object"}, s.Properties["next"])
+}
+
+func TestGenerateJSON(t *testing.T) {
+	s, err := Generate(&testConfig{}, JSON)
+	require.NoError(t, err)
+	// encoding/json promotes embedded struct fields
+	assert.Contains(t, s.Properties, "Debug")
+	assert.NotContains(t, s.Properties, "Common")
+	assert.Contains(t, s.Properties, "name")
+	assert.Contains(t, s.Properties["Database"].Properties, "Port")
+
+	b, err := s.MarshalIndent()
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, Draft, decoded["$schema"])
+}
+
+func TestGenerateEnum(t *testing.T) {
+	type config struct {
+		Level   string   `dialsenum:"debug,info,warn"`
+		Workers int      `dialsenum:"1,2,4"`
+		Modes   []string `dialsenum:"a,b"`
+	}
+	s, err := Generate(&config{Level: "info"}, JSON)
+	require.NoError(t, err)
+	assert.Equal(t, &Schema{Type: "string", Default: "info", Enum: []interface{}{"debug", "info", "warn"}}, s.Properties["Level"])
+	assert.Equal(t, &Schema{Type: "integer", Enum: []interface{}{int64(1), int64(2), int64(4)}}, s.Properties["Workers"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string", Enum: []interface{}{"a", "b"}}}, s.Properties["Modes"])
+}
+
+func TestGeneratePassThrough(t *testing.T) {
+	type config struct {
+		Raw      json.RawMessage
+		Settings deferred.Section
+		Any      interface{}
+	}
+	s, err := Generate(&config{}, JSON)
+	require.NoError(t, err)
+	assert.Equal(t, &Schema{}, s.Properties["Raw"])
+	assert.Equal(t, &Schema{}, s.Properties["Settings"])
+	assert.Equal(t, &Schema{}, s.Properties["Any"])
+}
+
+func TestGenerateInvalidTemplate(t *testing.T) {
+	_, err := Generate(testConfig{}, JSON)
+	assert.Error(t, err)
+}
+
+func TestEditorArtifacts(t *testing.T) {
+	assert.Equal(t, "# yaml-language-server: $schema=https://example.com/app.json",
+		YAMLModeline("https://example.com/app.json"))
+	assert.Equal(t, "#:schema ./app.json", TOMLDirective("./app.json"))
+
+	cfg, err := TaploConfig("./app.json", "app.toml", "conf.d/*.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "[[rule]]\ninclude = [\"app.toml\", \"conf.d/*.toml\"]\n\n[rule.schema]\npath = \"./app.json\"\n", cfg)
+
+	_, err = TaploConfig("./app.json")
+	assert.Error(t, err)
+
+	b, err := json.Marshal(SchemaStoreEntry{Name: "app", FileMatch: []string{"app.yaml"}, URL: "https://example.com/app.json"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"app","fileMatch":["app.yaml"],"url":"https://example.com/app.json"}`, string(b))
+}
+
+func TestGenerateEmbedTag(t *testing.T) {
+	type Inlined struct {
+		Debug bool
+	}
+	type Nested struct {
+		Level int
+	}
+	type config struct {
+		Inlined `dialsembed:"inline"`
+		Nested  `dialsembed:"nested"`
+	}
+	for _, f := range []Format{YAML, JSON} {
+		s, err := Generate(&config{}, f)
+		require.NoError(t, err)
+		assert.Contains(t, s.Properties, f.DefaultKey("Debug"))
+		require.Contains(t, s.Properties, f.DefaultKey("Nested"))
+		assert.Contains(t, s.Properties[f.DefaultKey("Nested")].Properties, f.DefaultKey("Level"))
+	}
+}
@@ -0,0 +1,31 @@
+package dials
+
+import "fmt"
+
+// Logger receives structured log messages describing what dials is doing:
+// which sources are watched, new values and errors reported by watching
+// sources, failures to compose or verify new configurations, and installed
+// configuration versions. Set [Params].Logger to enable logging; dials is
+// silent by default.
+//
+// args are alternating keys (strings) and values, so a *slog.Logger (from
+// log/slog) can be used directly. Methods may be called concurrently.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger is used when Params.Logger is nil.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// sourceName returns the value logged for a source: its type.
+func sourceName(s Source) string {
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,8 @@
+//go:build go1.21
+
+package dials
+
+import "log/slog"
+
+// a *slog.Logger can be used as a Logger without an adapter
+var _ Logger = (*slog.Logger)(nil)
@@ -0,0 +1,51 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) log(level, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args...) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args...) }
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := &recordingLogger{}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{Logger: l}.Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	w.send(ctx, rollbackValue("b"))
+	<-d.Events()
+	require.NoError(t, d.Close(ctx))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.Equal(t, []string{
+		"DEBUG dials: watching source [source *dials.fakeWatchingSource]",
+		"INFO dials: installed initial configuration [sources 1 watching true]",
+		"DEBUG dials: new value reported [source *dials.fakeWatchingSource]",
+		"INFO dials: installed configuration [generation 1 cause update]",
+	}, l.lines)
+}
@@ -0,0 +1,152 @@
+package dials
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// SliceMergeTag is the name of the struct tag controlling how a slice, map or
+// nested struct field's values from different sources are combined. On slice
+// fields, its value is "replace" or "append", overriding Params.SliceMerge
+// for that field. On map fields, it's "replace" (the default), or "merge" to
+// combine the entries from every source: an entry replaces the one with the
+// same key from lower-precedence sources, and if the map's values are
+// structs, only the fields that the higher-precedence source sets are
+// replaced. On nested struct fields (and pointers to structs), it's "merge"
+// (the default), where each of the struct's fields is overridden
+// individually, or "replace" to take the struct wholesale from the
+// highest-precedence source that sets any of its fields: the fields that
+// source doesn't set are zeroed, rather than keeping the values from
+// lower-precedence sources (or the template passed to Config). It's ignored
+// on fields of other kinds.
+const SliceMergeTag = "dialsmerge"
+
+// SliceMerge controls how a slice field's value from a source combines with
+// the value from lower-precedence sources (and the default in the template
+// passed to Config).
+type SliceMerge int
+
+const (
+	// SliceMergeReplace replaces the lower-precedence value with the
+	// higher-precedence one (if it's non-nil). This is the default.
+	SliceMergeReplace SliceMerge = iota
+	// SliceMergeAppend appends the higher-precedence value to the
+	// lower-precedence one, so the composed slice contains the elements
+	// from every source, in order of precedence.
+	SliceMergeAppend
+)
+
+func (m SliceMerge) String() string {
+	switch m {
+	case SliceMergeReplace:
+		return "replace"
+	case SliceMergeAppend:
+		return "append"
+	default:
+		return fmt.Sprintf("SliceMerge(%d)", int(m))
+	}
+}
+
+func parseSliceMerge(s string) (SliceMerge, error) {
+	switch s {
+	case "replace":
+		return SliceMergeReplace, nil
+	case "append":
+		return SliceMergeAppend, nil
+	default:
+		return 0, fmt.Errorf("invalid %s tag %q (must be \"replace\" or \"append\")", SliceMergeTag, s)
+	}
+}
+
+// fieldSliceMerge returns the merge mode for the field sf, using def unless
+// it has a SliceMergeTag. Deferred sections (see ptrify.IsDeferredSection)
+// are appended by default, and other pass-through slices (see
+// ptrify.IsPassThrough) are always replaced.
+func fieldSliceMerge(sf reflect.StructField, def SliceMerge) (SliceMerge, error) {
+	tag, ok := sf.Tag.Lookup(SliceMergeTag)
+	if ptrify.IsDeferredSection(sf.Type) {
+		if !ok {
+			return SliceMergeAppend, nil
+		}
+		return parseSliceMerge(tag)
+	}
+	if ptrify.IsPassThrough(sf.Type) {
+		// json.RawMessage is a slice, but it's a single value
+		return SliceMergeReplace, nil
+	}
+	if !ok {
+		return def, nil
+	}
+	return parseSliceMerge(tag)
+}
+
+// fieldMapMerge returns whether the entries of the map field sf are merged,
+// according to its SliceMergeTag.
+func fieldMapMerge(sf reflect.StructField) (bool, error) {
+	switch tag, ok := sf.Tag.Lookup(SliceMergeTag); {
+	case !ok, tag == "replace":
+		return false, nil
+	case tag == "merge":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid %s tag %q on map field %s (must be \"replace\" or \"merge\")", SliceMergeTag, tag, sf.Name)
+	}
+}
+
+// fieldStructReplace returns whether the nested struct field sf is replaced
+// as a whole, according to its SliceMergeTag.
+func fieldStructReplace(sf reflect.StructField) (bool, error) {
+	switch tag, ok := sf.Tag.Lookup(SliceMergeTag); {
+	case !ok, tag == "merge":
+		return false, nil
+	case tag == "replace":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid %s tag %q on struct field %s (must be \"merge\" or \"replace\")", SliceMergeTag, tag, sf.Name)
+	}
+}
+
+// isNestedStruct indicates whether t is a struct (or a pointer to one) that
+// consists of fields, rather than a scalar (see ptrify.IsScalarStruct).
+func isNestedStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !ptrify.IsScalarStruct(t)
+}
+
+// anySet indicates whether any of the leaf fields of the pointerified value v
+// (as returned by a source) are set.
+func anySet(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+		return anySet(v.Elem())
+	case reflect.Interface, reflect.Map, reflect.Slice:
+		return !v.IsNil()
+	case reflect.Struct:
+		if ptrify.IsScalarStruct(v.Type()) {
+			return true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && anySet(v.Field(i)) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// appendSlices returns a new slice containing the elements of base followed
+// by those of overlay, so neither's backing array is shared with the result.
+func appendSlices(base, overlay reflect.Value) reflect.Value {
+	out := reflect.MakeSlice(base.Type(), 0, base.Len()+overlay.Len())
+	out = reflect.AppendSlice(out, base)
+	return reflect.AppendSlice(out, overlay)
+}
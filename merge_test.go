@@ -0,0 +1,236 @@
+package dials
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mergeConfig struct {
+	Hosts   []string
+	Plugins []string `dialsmerge:"append"`
+	Tags    []string `dialsmerge:"replace"`
+}
+
+func TestSliceMerge(t *testing.T) {
+	ctx := context.Background()
+	defaults := mergeConfig{Hosts: []string{"a"}, Plugins: []string{"p0"}, Tags: []string{"t0"}}
+	first := &fakeSource{outVal: mergeConfig{Hosts: []string{"b"}, Plugins: []string{"p1"}}}
+	second := &fakeSource{outVal: mergeConfig{Hosts: []string{"c"}, Plugins: []string{"p2", "p3"}, Tags: []string{"t2"}}}
+
+	for _, tbl := range []struct {
+		name string
+		mode SliceMerge
+		want *mergeConfig
+	}{
+		{
+			name: "replace_default",
+			mode: SliceMergeReplace,
+			want: &mergeConfig{Hosts: []string{"c"}, Plugins: []string{"p0", "p1", "p2", "p3"}, Tags: []string{"t2"}},
+		},
+		{
+			name: "append_default",
+			mode: SliceMergeAppend,
+			want: &mergeConfig{Hosts: []string{"a", "b", "c"}, Plugins: []string{"p0", "p1", "p2", "p3"}, Tags: []string{"t2"}},
+		},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			tmpl := defaults
+			d, err := Params[mergeConfig]{SliceMerge: tbl.mode}.Config(ctx, &tmpl, first, second)
+			require.NoError(t, err)
+			assert.Equal(t, tbl.want, d.View())
+			// the template's slices must not be modified
+			assert.Equal(t, []string{"p0"}, tmpl.Plugins)
+		})
+	}
+}
+
+func TestSliceMergeInvalidTag(t *testing.T) {
+	type config struct {
+		Hosts []string `dialsmerge:"prepend"`
+	}
+	_, err := Config(context.Background(), &config{}, &fakeSource{outVal: config{Hosts: []string{"a"}}})
+	assert.ErrorContains(t, err, `invalid dialsmerge tag "prepend"`)
+}
+
+type mergeBackend struct {
+	Host string
+	Port int
+}
+
+type mapMergeConfig struct {
+	Replaced map[string]mergeBackend
+	Merged   map[string]*mergeBackend `dialsmerge:"merge"`
+	Labels   map[string]string        `dialsmerge:"merge"`
+}
+
+// ptrifiedMapMergeConfig is the pointerified mapMergeConfig. (the values'
+// struct types must be unnamed to be convertible)
+type ptrifiedMapMergeConfig struct {
+	Replaced map[string]*struct {
+		Host *string
+		Port *int
+	}
+	Merged map[string]*struct {
+		Host *string
+		Port *int
+	}
+	Labels map[string]string
+}
+
+func TestMapMerge(t *testing.T) {
+	ctx := context.Background()
+	host, port := "db2", 6432
+	entries := func() map[string]*struct {
+		Host *string
+		Port *int
+	} {
+		return map[string]*struct {
+			Host *string
+			Port *int
+		}{"primary": {Port: &port}, "replica": {Host: &host}}
+	}
+	src := &fakeSource{outVal: ptrifiedMapMergeConfig{
+		Replaced: entries(),
+		Merged:   entries(),
+		Labels:   map[string]string{"team": "infra"},
+	}}
+	tmpl := mapMergeConfig{
+		Replaced: map[string]mergeBackend{"primary": {Host: "db1", Port: 5432}, "other": {}},
+		Merged:   map[string]*mergeBackend{"primary": {Host: "db1", Port: 5432}, "other": {}},
+		Labels:   map[string]string{"env": "dev"},
+	}
+	d, err := Config(ctx, &tmpl, src)
+	require.NoError(t, err)
+	assert.Equal(t, &mapMergeConfig{
+		Replaced: map[string]mergeBackend{"primary": {Port: 6432}, "replica": {Host: "db2"}},
+		Merged: map[string]*mergeBackend{
+			"primary": {Host: "db1", Port: 6432},
+			"replica": {Host: "db2"},
+			"other":   {},
+		},
+		Labels: map[string]string{"env": "dev", "team": "infra"},
+	}, d.View())
+	// the template's entries must not be modified
+	assert.Equal(t, &mergeBackend{Host: "db1", Port: 5432}, tmpl.Merged["primary"])
+
+	for _, exp := range d.Explain() {
+		assert.False(t, exp.Conflict, exp.Path)
+		if exp.Path == "Replaced" {
+			assert.Equal(t, map[string]mergeBackend{"primary": {Port: 6432}, "replica": {Host: "db2"}}, exp.Value)
+			require.Len(t, exp.Overridden, 1)
+		}
+	}
+}
+
+func TestMapMergeInvalidTag(t *testing.T) {
+	type config struct {
+		Labels map[string]string `dialsmerge:"append"`
+	}
+	_, err := Config(context.Background(), &config{}, &fakeSource{outVal: config{}})
+	assert.ErrorContains(t, err, `invalid dialsmerge tag "append" on map field Labels`)
+}
+
+func TestSliceMergePassThrough(t *testing.T) {
+	type config struct {
+		Plugin json.RawMessage `dialsmerge:"append"`
+		Extra  interface{}
+	}
+	first := &fakeSource{outVal: config{Plugin: json.RawMessage(`{"a":1}`), Extra: []string{"x"}}}
+	second := &fakeSource{outVal: config{Plugin: json.RawMessage(`{"b":2}`), Extra: map[string]int{"y": 2}}}
+	d, err := Params[config]{SliceMerge: SliceMergeAppend}.Config(context.Background(), &config{}, first, second)
+	require.NoError(t, err)
+	// json.RawMessage is a single value, so it's replaced, not appended
+	assert.Equal(t, &config{Plugin: json.RawMessage(`{"b":2}`), Extra: map[string]int{"y": 2}}, d.View())
+}
+
+type structMergePool struct {
+	Size, Idle int
+}
+
+type structMergeConfig struct {
+	Pool   structMergePool  `dialsmerge:"replace"`
+	Backup *structMergePool `dialsmerge:"replace"`
+	Merged structMergePool  `dialsmerge:"merge"`
+	Name   string
+}
+
+// ptrifiedStructMergeConfig is the pointerified structMergeConfig.
+type ptrifiedStructMergeConfig struct {
+	Pool   *ptrifiedStructMergePool
+	Backup *ptrifiedStructMergePool
+	Merged *ptrifiedStructMergePool
+	Name   *string
+}
+
+type ptrifiedStructMergePool = struct {
+	Size, Idle *int
+}
+
+func TestStructMerge(t *testing.T) {
+	ctx := context.Background()
+	two, three, twenty := 2, 3, 20
+	name := "svc"
+	first := &fakeSource{outVal: ptrifiedStructMergeConfig{
+		Pool:   &ptrifiedStructMergePool{Idle: &two},
+		Backup: &ptrifiedStructMergePool{Idle: &three},
+		Merged: &ptrifiedStructMergePool{Idle: &two},
+	}}
+	second := &fakeSource{outVal: ptrifiedStructMergeConfig{
+		Pool:   &ptrifiedStructMergePool{Size: &twenty},
+		Backup: &ptrifiedStructMergePool{},
+		Merged: &ptrifiedStructMergePool{Size: &twenty},
+		Name:   &name,
+	}}
+	tmpl := structMergeConfig{
+		Pool:   structMergePool{Size: 10, Idle: 5},
+		Merged: structMergePool{Size: 10, Idle: 5},
+	}
+	d, err := Config(ctx, &tmpl, first, second)
+	require.NoError(t, err)
+	assert.Equal(t, &structMergeConfig{
+		// only the highest-precedence source's fields are kept
+		Pool: structMergePool{Size: 20},
+		// the second source doesn't set any of Backup's fields, so
+		// the first one's value is kept
+		Backup: &structMergePool{Idle: 3},
+		Merged: structMergePool{Size: 20, Idle: 2},
+		Name:   "svc",
+	}, d.View())
+
+	assert.Equal(t, map[string]Source{
+		"Pool.Size":   second,
+		"Pool.Idle":   second,
+		"Backup.Size": first,
+		"Backup.Idle": first,
+		"Merged.Size": second,
+		"Merged.Idle": first,
+		"Name":        second,
+	}, d.Provenance())
+
+	explained := false
+	for _, exp := range d.Explain() {
+		if exp.Path != "Pool.Idle" {
+			continue
+		}
+		explained = true
+		assert.Equal(t, 0, exp.Value)
+		assert.Equal(t, second, exp.Source)
+		assert.Equal(t, []FieldOrigin{{Value: 5}, {Source: first, Value: 2}}, exp.Overridden)
+		assert.True(t, exp.Conflict)
+	}
+	assert.True(t, explained)
+}
+
+func TestStructMergeInvalidTag(t *testing.T) {
+	type config struct {
+		Pool structMergePool `dialsmerge:"append"`
+	}
+	src := &fakeSource{outVal: struct{ Pool *ptrifiedStructMergePool }{}}
+	_, err := Config(context.Background(), &config{}, src)
+	assert.ErrorContains(t, err, `invalid dialsmerge tag "append" on struct field Pool`)
+}
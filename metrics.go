@@ -0,0 +1,35 @@
+package dials
+
+import "time"
+
+// Metrics receives measurements of a Dials instance's operation, so they can
+// be exported to a monitoring system (e.g. as Prometheus counters, a
+// histogram and a gauge). Set [Params].Metrics to enable them.
+//
+// Methods may be called concurrently (e.g. by Refresh while a watching source
+// reports a new value), and shouldn't block.
+type Metrics interface {
+	// SourceError is called whenever a source fails to provide a value:
+	// when its Value or Watch method returns an error, or when a watching
+	// source reports one.
+	SourceError(source Source, err error)
+	// Restacked is called after each attempt to compose a new
+	// configuration from updated source values, with how long composing
+	// took and the error if it failed.
+	Restacked(elapsed time.Duration, err error)
+	// VerificationFailed is called whenever a configuration fails
+	// verification, including required fields and validation tags.
+	VerificationFailed(err error)
+	// Installed is called with the generation (see
+	// [CfgSerial.Generation]) of each installed configuration, starting
+	// with the one installed by Config.
+	Installed(generation uint64)
+}
+
+// nopMetrics is used when Params.Metrics is nil.
+type nopMetrics struct{}
+
+func (nopMetrics) SourceError(Source, error)      {}
+func (nopMetrics) Restacked(time.Duration, error) {}
+func (nopMetrics) VerificationFailed(error)       {}
+func (nopMetrics) Installed(uint64)               {}
@@ -0,0 +1,69 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMetrics struct {
+	mu          sync.Mutex
+	sourceErrs  []error
+	restacks    int
+	verifyErrs  int
+	generations []uint64
+}
+
+func (m *recordingMetrics) SourceError(_ Source, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sourceErrs = append(m.sourceErrs, err)
+}
+
+func (m *recordingMetrics) Restacked(time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restacks++
+}
+
+func (m *recordingMetrics) VerificationFailed(error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifyErrs++
+}
+
+func (m *recordingMetrics) Installed(generation uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generations = append(m.generations, generation)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := &recordingMetrics{}
+	src := &flakySource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{Metrics: m}.Config(ctx, &rollbackConfig{Addr: "a"}, src)
+	require.NoError(t, err)
+
+	_, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+
+	errBroken := errors.New("broken")
+	src.err = errBroken
+	_, _, err = d.Refresh(ctx)
+	require.Error(t, err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, []error{errBroken}, m.sourceErrs)
+	assert.Equal(t, 1, m.restacks)
+	assert.Zero(t, m.verifyErrs)
+	assert.Equal(t, []uint64{0, 1}, m.generations)
+}
@@ -0,0 +1,56 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Must is a helper that wraps a call to a function returning
+// (*Dials[T], error) (such as Config, Params.Config or the functions in the
+// ez package), and panics if the error is non-nil. It's intended for main
+// functions and examples, where the only sensible response to a
+// configuration that fails to load is to exit:
+//
+//	d := dials.Must(ez.YAMLConfigEnvFlag(ctx, &cfg, ez.Params[Config]{}))
+//
+// The panic value is an error wrapping err, whose message lists each of the
+// problems in a ConfigErrors on its own line (with the source it came from).
+func Must[T any](d *Dials[T], err error) *Dials[T] {
+	if err != nil {
+		panic(&mustError{err: err})
+	}
+	return d
+}
+
+// MustConfig is like Config, but panics if loading the configuration fails
+// (see Must).
+func MustConfig[T any](ctx context.Context, t *T, sources ...Source) *Dials[T] {
+	return Must(Config(ctx, t, sources...))
+}
+
+// mustError is the value Must panics with.
+type mustError struct {
+	err error
+}
+
+func (m *mustError) Error() string {
+	ce := &ConfigErrors{}
+	if !errors.As(m.err, &ce) || len(ce.Errors) < 2 {
+		return "failed to load configuration: " + m.err.Error()
+	}
+	b := strings.Builder{}
+	b.WriteString("failed to load configuration:")
+	for _, e := range ce.Errors {
+		b.WriteString("\n\t")
+		b.WriteString(e.Error())
+		if e.Source != nil {
+			b.WriteString(" (" + describeSource(e.Source) + ")")
+		}
+	}
+	return b.String()
+}
+
+func (m *mustError) Unwrap() error {
+	return m.err
+}
@@ -0,0 +1,46 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMust(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name string
+		Port int
+	}
+	ctx := context.Background()
+
+	d := MustConfig(ctx, &testConfig{Name: "svc"})
+	assert.Equal(t, "svc", d.View().Name)
+
+	errParse := errors.New("invalid syntax")
+	src := &failingSource{err: multiError{
+		&pathError{path: []string{"Name"}, err: errParse},
+		&pathError{path: []string{"Port"}, err: errParse},
+	}}
+	panicked := func(f func()) (v interface{}) {
+		defer func() { v = recover() }()
+		f()
+		return nil
+	}
+	v := panicked(func() { MustConfig(ctx, &testConfig{}, src) })
+	err, ok := v.(error)
+	require.True(t, ok, "unexpected panic value %v", v)
+	assert.ErrorIs(t, err, errParse)
+	assert.Equal(t, "failed to load configuration:\n"+
+		"\tName: invalid syntax (from *dials.failingSource)\n"+
+		"\tPort: invalid syntax (from *dials.failingSource)", err.Error())
+
+	errUnavailable := errors.New("unavailable")
+	v = panicked(func() { Must(Config(ctx, &testConfig{}, &failingSource{err: errUnavailable})) })
+	require.Implements(t, (*error)(nil), v)
+	assert.ErrorIs(t, v.(error), errUnavailable)
+	assert.Equal(t, "failed to load configuration: unavailable", v.(error).Error())
+}
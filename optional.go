@@ -0,0 +1,49 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// validateOptionalSources returns an error if any of optional wasn't passed
+// to Config, and otherwise the set of optional sources.
+func validateOptionalSources(optional, sources []Source) (map[Source]struct{}, error) {
+	known := make(map[Source]struct{}, len(sources))
+	for _, s := range sources {
+		known[s] = struct{}{}
+	}
+	set := make(map[Source]struct{}, len(optional))
+	for _, s := range optional {
+		if _, ok := known[s]; !ok {
+			return nil, fmt.Errorf("optional source (%T) was not passed to Config", s)
+		}
+		set[s] = struct{}{}
+	}
+	return set, nil
+}
+
+// containsSource returns true if s is in sources.
+func containsSource(sources []Source, s Source) bool {
+	for _, src := range sources {
+		if src == s {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalSourceFailed reports a WarningSourceFailed for the failure of the
+// optional source s.
+func optionalSourceFailed(ctx context.Context, sink *warningSink, s Source, err error) {
+	sink.report(ctx, Warning{
+		Kind:    WarningSourceFailed,
+		Source:  s,
+		Message: fmt.Sprintf("optional source failed; ignoring it: %s", err),
+	})
+}
+
+// emptyValue returns a value of the pointerified type t that sets no fields.
+func emptyValue(t *Type) reflect.Value {
+	return reflect.New(t.Type()).Elem()
+}
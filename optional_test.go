@@ -0,0 +1,56 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionalSources(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	addr := "b"
+	base := &fakeSource{outVal: ptrifiedRollbackConfig{Addr: &addr}}
+	errBroken := errors.New("broken")
+	broken := &flakySource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}, err: errBroken}
+
+	_, err := Config(ctx, &rollbackConfig{Addr: "a"}, base, broken)
+	require.ErrorIs(t, err, errBroken)
+
+	_, err = Params[rollbackConfig]{
+		OptionalSources: []Source{&fakeSource{}},
+	}.Config(ctx, &rollbackConfig{Addr: "a"}, base, broken)
+	assert.EqualError(t, err, "optional source (*dials.fakeSource) was not passed to Config")
+
+	warnings := []Warning{}
+	d, err := Params[rollbackConfig]{
+		OptionalSources: []Source{broken},
+		OnWarning:       func(_ context.Context, w Warning) { warnings = append(warnings, w) },
+	}.Config(ctx, &rollbackConfig{Addr: "a"}, base, broken)
+	require.NoError(t, err)
+	assert.Equal(t, "b", d.View().Addr)
+	assert.Equal(t, []Warning{{
+		Kind:    WarningSourceFailed,
+		Source:  broken,
+		Message: "optional source failed; ignoring it: broken",
+	}}, warnings)
+
+	// once it recovers, Refresh picks it up, and its value is kept if it
+	// fails again
+	override := "c"
+	broken.err = nil
+	broken.outVal = ptrifiedRollbackConfig{Addr: &override}
+	cfg, _, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", cfg.Addr)
+
+	broken.err = errBroken
+	cfg, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", cfg.Addr)
+	assert.Len(t, warnings, 2)
+}
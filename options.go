@@ -0,0 +1,247 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// Option configures the Dials constructed by New. Options are applied in the
+// order they're passed, so later options override earlier ones that set the
+// same thing. Options taking callbacks (e.g. WithOnError) are specific to a
+// configuration type; passing one to New for a different type makes New
+// return an error.
+type Option func(*options)
+
+// options accumulates the Options passed to New.
+type options struct {
+	sources []Source
+	// apply holds the functions setting Params fields, in the order their
+	// options were passed. Each is either a func(*paramsFields) or a
+	// typedApply.
+	apply []interface{}
+}
+
+// typedApply holds a func(*Params[T]) set by an Option for the config type
+// cfgType (i.e. T).
+type typedApply struct {
+	cfgType reflect.Type
+	f       interface{}
+}
+
+// paramsFields points to the fields of a Params[T] that don't depend on T,
+// so Options that set them needn't be generic.
+type paramsFields struct {
+	OptionalSources       *[]Source
+	VerificationTimeout   *time.Duration
+	CircuitBreaker        *CircuitBreakerParams
+	OnWarning             *WarningHandler
+	ConcurrentSourceFetch *bool
+	SliceMerge            *SliceMerge
+	DetectConflicts       *bool
+	DetectUnusedConfig    *bool
+	UpdateRateLimit       *RateLimitParams
+	Metrics               *Metrics
+	Tracer                *Tracer
+	Logger                *Logger
+	Redact                *func(fieldPath []string) bool
+	AuditSink             *AuditSink
+	DecodeHooks           *[]parse.DecodeHook
+}
+
+func commonOption(f func(*paramsFields)) Option {
+	return func(o *options) { o.apply = append(o.apply, f) }
+}
+
+func typedOption[T any](f func(*Params[T])) Option {
+	ta := typedApply{cfgType: reflect.TypeOf((*T)(nil)).Elem(), f: f}
+	return func(o *options) { o.apply = append(o.apply, ta) }
+}
+
+// New populates t like Params.Config, with the sources and parameters
+// configured by opts (starting from the zero Params). Unlike the Params
+// struct, the set of options can grow without affecting existing callers:
+//
+//	d, err := dials.New(ctx, &defaults,
+//		dials.WithSources(yamlSrc, envSrc, flagSrc),
+//		dials.WithOnError(func(ctx context.Context, err error, oldCfg, newCfg *Config) {
+//			log.Printf("config update failed: %s", err)
+//		}),
+//		dials.WithWatchCoalescing(dials.RateLimitParams{Interval: time.Second}),
+//	)
+//
+// Options for Params fields without a dedicated option can be set with
+// WithParams.
+func New[T any](ctx context.Context, t *T, opts ...Option) (*Dials[T], error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := Params[T]{}
+	common := paramsFields{
+		OptionalSources:       &p.OptionalSources,
+		VerificationTimeout:   &p.VerificationTimeout,
+		CircuitBreaker:        &p.CircuitBreaker,
+		OnWarning:             &p.OnWarning,
+		ConcurrentSourceFetch: &p.ConcurrentSourceFetch,
+		SliceMerge:            &p.SliceMerge,
+		DetectConflicts:       &p.DetectConflicts,
+		DetectUnusedConfig:    &p.DetectUnusedConfig,
+		UpdateRateLimit:       &p.UpdateRateLimit,
+		Metrics:               &p.Metrics,
+		Tracer:                &p.Tracer,
+		Logger:                &p.Logger,
+		Redact:                &p.Redact,
+		AuditSink:             &p.AuditSink,
+		DecodeHooks:           &p.DecodeHooks,
+	}
+	for _, a := range o.apply {
+		switch a := a.(type) {
+		case func(*paramsFields):
+			a(&common)
+		case typedApply:
+			f, ok := a.f.(func(*Params[T]))
+			if !ok {
+				return nil, fmt.Errorf("option for config type %s passed to New for config type %T",
+					a.cfgType, t)
+			}
+			f(&p)
+		}
+	}
+	return p.Config(ctx, t, o.sources...)
+}
+
+// WithSources appends sources to those New reads the configuration from. As
+// with Config, later sources take precedence over earlier ones.
+func WithSources(sources ...Source) Option {
+	return func(o *options) { o.sources = append(o.sources, sources...) }
+}
+
+// WithOptionalSources appends sources to those New reads the configuration
+// from, like WithSources, and marks them optional, so their failures don't
+// prevent New from succeeding (see Params.OptionalSources).
+func WithOptionalSources(sources ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sources...)
+		commonOption(func(p *paramsFields) {
+			*p.OptionalSources = append(*p.OptionalSources, sources...)
+		})(o)
+	}
+}
+
+// WithParams calls f with the Params used by New, so it can set fields
+// that have no dedicated Option. Fields set by earlier options are already
+// set when f is called.
+func WithParams[T any](f func(p *Params[T])) Option {
+	return typedOption(f)
+}
+
+// WithOnError sets Params.OnWatchedError, which is called when updating the
+// configuration fails.
+func WithOnError[T any](h WatchedErrorHandler[T]) Option {
+	return typedOption(func(p *Params[T]) { p.OnWatchedError = h })
+}
+
+// WithOnNewConfig sets Params.OnNewConfig, which is called when a new
+// configuration is installed.
+func WithOnNewConfig[T any](h NewConfigHandler[T]) Option {
+	return typedOption(func(p *Params[T]) { p.OnNewConfig = h })
+}
+
+// WithOnConfigChange sets Params.OnConfigChange, which is called with the
+// fields that changed when a new configuration is installed.
+func WithOnConfigChange[T any](h ConfigChangeHandler[T]) Option {
+	return typedOption(func(p *Params[T]) { p.OnConfigChange = h })
+}
+
+// WithAcceptConfig sets Params.AcceptConfig, which decides whether new
+// configuration versions from watching sources are installed.
+func WithAcceptConfig[T any](accept ConfigAcceptor[T]) Option {
+	return typedOption(func(p *Params[T]) { p.AcceptConfig = accept })
+}
+
+// WithWatchCoalescing sets Params.UpdateRateLimit, so updates from watching
+// sources arriving faster than rl allows are coalesced into a single
+// version.
+func WithWatchCoalescing(rl RateLimitParams) Option {
+	return commonOption(func(p *paramsFields) { *p.UpdateRateLimit = rl })
+}
+
+// WithVerificationTimeout sets Params.VerificationTimeout, which bounds each
+// call to Verify().
+func WithVerificationTimeout(d time.Duration) Option {
+	return commonOption(func(p *paramsFields) { *p.VerificationTimeout = d })
+}
+
+// WithCircuitBreaker sets Params.CircuitBreaker, configuring a
+// circuit-breaker for each watching source.
+func WithCircuitBreaker(cb CircuitBreakerParams) Option {
+	return commonOption(func(p *paramsFields) { *p.CircuitBreaker = cb })
+}
+
+// WithOnWarning sets Params.OnWarning, which is called with each non-fatal
+// warning.
+func WithOnWarning(h WarningHandler) Option {
+	return commonOption(func(p *paramsFields) { *p.OnWarning = h })
+}
+
+// WithConcurrentSourceFetch sets Params.ConcurrentSourceFetch, so the
+// sources' Value methods are called in parallel.
+func WithConcurrentSourceFetch() Option {
+	return commonOption(func(p *paramsFields) { *p.ConcurrentSourceFetch = true })
+}
+
+// WithSliceMerge sets Params.SliceMerge, which controls how slices from
+// different sources are combined.
+func WithSliceMerge(sm SliceMerge) Option {
+	return commonOption(func(p *paramsFields) { *p.SliceMerge = sm })
+}
+
+// WithDetectConflicts sets Params.DetectConflicts, so fields set to
+// different values by different sources are reported as warnings.
+func WithDetectConflicts() Option {
+	return commonOption(func(p *paramsFields) { *p.DetectConflicts = true })
+}
+
+// WithDetectUnusedConfig sets Params.DetectUnusedConfig, so fields no source
+// sets and keys that map to no field are reported by Dials.UnusedConfig.
+func WithDetectUnusedConfig() Option {
+	return commonOption(func(p *paramsFields) { *p.DetectUnusedConfig = true })
+}
+
+// WithMetrics sets Params.Metrics.
+func WithMetrics(m Metrics) Option {
+	return commonOption(func(p *paramsFields) { *p.Metrics = m })
+}
+
+// WithTracer sets Params.Tracer.
+func WithTracer(t Tracer) Option {
+	return commonOption(func(p *paramsFields) { *p.Tracer = t })
+}
+
+// WithLogger sets Params.Logger.
+func WithLogger(l Logger) Option {
+	return commonOption(func(p *paramsFields) { *p.Logger = l })
+}
+
+// WithRedact sets Params.Redact, which selects fields to redact by their
+// path.
+func WithRedact(redact func(fieldPath []string) bool) Option {
+	return commonOption(func(p *paramsFields) { *p.Redact = redact })
+}
+
+// WithAuditSink sets Params.AuditSink, which records every installed
+// configuration version.
+func WithAuditSink(s AuditSink) Option {
+	return commonOption(func(p *paramsFields) { *p.AuditSink = s })
+}
+
+// WithDecodeHooks appends hooks to Params.DecodeHooks, which the decoders use
+// for this configuration in addition to those registered with
+// parse.RegisterDecodeHook.
+func WithDecodeHooks(hooks ...parse.DecodeHook) Option {
+	return commonOption(func(p *paramsFields) { *p.DecodeHooks = append(*p.DecodeHooks, hooks...) })
+}
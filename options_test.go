@@ -0,0 +1,67 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOptions(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name string
+		Port int
+	}
+	type ptrifiedConfig struct {
+		Name *string
+		Port *int
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	name, port := "svc", 8080
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{Port: &port}}}
+	warnings := make(chan Warning, 4)
+	newConfigs := make(chan *testConfig, 4)
+	d, err := New(ctx, &testConfig{Port: 80},
+		WithSources(&fakeSource{outVal: ptrifiedConfig{Name: &name}}),
+		WithOptionalSources(&failingSource{err: errors.New("unavailable")}),
+		WithSources(&w),
+		WithOnWarning(func(_ context.Context, w Warning) { warnings <- w }),
+		WithOnNewConfig(func(_ context.Context, _, newConfig *testConfig) { newConfigs <- newConfig }),
+		WithVerificationTimeout(time.Minute),
+		WithParams(func(p *Params[testConfig]) {
+			assert.Equal(t, time.Minute, p.VerificationTimeout)
+			assert.Len(t, p.OptionalSources, 1)
+			p.ReplayBufferSize = 2
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, testConfig{Name: "svc", Port: 8080}, *d.View())
+	assert.Equal(t, WarningSourceFailed, (<-warnings).Kind)
+
+	port = 9090
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Port: &port}))
+	assert.Equal(t, 9090, (<-newConfigs).Port)
+}
+
+func TestNewOptionTypeMismatch(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Name string
+	}
+	type otherConfig struct {
+		Name string
+	}
+	ctx := context.Background()
+
+	_, err := New(ctx, &testConfig{},
+		WithOnError(func(context.Context, error, *otherConfig, *otherConfig) {}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "otherConfig passed to New for config type *dials.testConfig")
+}
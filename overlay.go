@@ -4,14 +4,22 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/vimeo/dials/ptrify"
 )
 
 var errCanSetField = errors.New("cannot set field")
 
+// overlayer overlays values from a source onto a base value. The overlay value
+// isn't modified, and the parts of it that are assigned into the base are
+// deep-copied (with dc) first, so the overlay isn't copied up front, and the
+// (common) pointers to scalars in pointerified values are just dereferenced.
 type overlayer struct {
 	dc *deepCopier
+	// sliceMerge is the merge mode for slice fields without a
+	// SliceMergeTag
+	sliceMerge SliceMerge
 }
 
 func newOverlayer() *overlayer {
@@ -20,6 +28,15 @@ func newOverlayer() *overlayer {
 	}
 }
 
+// adopt returns a deep copy of v (or v itself, if that's equivalent) for
+// assignment into the base.
+func (o *overlayer) adopt(v reflect.Value) reflect.Value {
+	if isFlat(v.Type()) {
+		return v
+	}
+	return o.dc.deepCopyValue(v)
+}
+
 func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 	switch overlay.Kind() {
 	case reflect.Slice, reflect.Ptr, reflect.Interface, reflect.Map:
@@ -33,12 +50,21 @@ func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 	}
 	switch base.Kind() {
 	case reflect.Ptr:
+		// pointers to pointers are pointerified as a single pointer to
+		// the innermost (pointerified) type, so allocate and descend
+		// through the base's extra levels.
+		if base.Type().Elem().Kind() == reflect.Ptr && overlay.Type() != base.Type() {
+			if base.IsNil() {
+				base.Set(reflect.New(base.Type().Elem()))
+			}
+			return o.overlayField(base.Elem(), overlay)
+		}
 		// if we're dealing with a pointer in the original field, and
 		// it's unset from lower layers, just set the pointer (after a deep-copy).
 		if base.IsNil() {
 			// make sure it's pointing to the same type
 			if base.Type().Elem() == overlay.Type().Elem() {
-				base.Set(overlay)
+				base.Set(o.adopt(overlay))
 				//  we're done here
 				return nil
 			}
@@ -48,7 +74,7 @@ func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 				return fmt.Errorf("unexpected kind for mangled pointer target: %s",
 					base.Type().Elem().Kind())
 			}
-			if ptrify.IsTextUnmarshalerStruct(base.Type().Elem()) {
+			if ptrify.IsScalarStruct(base.Type().Elem()) {
 				return fmt.Errorf("unexpected shallow-copy-struct as pointer target types: base: %s; overlay %s",
 					base.Type(), overlay.Type())
 			}
@@ -57,12 +83,12 @@ func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 			base.Set(reflect.New(base.Type().Elem()))
 			return o.overlayStruct(base.Elem(), overlay.Elem())
 		}
-		if ptrify.IsTextUnmarshalerStruct(base.Type().Elem()) {
+		if ptrify.IsScalarStruct(base.Type().Elem()) {
 			// base is not nil and we're not deep-copying, so we can overwrite the pointer.
 			if overlay.Type().AssignableTo(base.Type()) {
-				base.Set(overlay)
+				base.Set(o.adopt(overlay))
 			} else if overlay.Type().AssignableTo(base.Type().Elem()) {
-				base.Elem().Set(overlay)
+				base.Elem().Set(o.adopt(overlay))
 			}
 			//  we're done here
 			return nil
@@ -71,19 +97,24 @@ func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 		return o.overlayStruct(base.Elem(), overlay.Elem())
 	case reflect.Interface:
 		return o.overlayInterface(base, overlay)
+	case reflect.Map:
+		if overlay.Kind() == reflect.Map {
+			return o.overlayMap(base, overlay, false)
+		}
+		base.Set(o.adopt(overlay.Elem()))
 	case reflect.Struct:
-		if ptrify.IsTextUnmarshalerStruct(base.Type()) {
+		if ptrify.IsScalarStruct(base.Type()) {
 			// base is not nil and we're not deep-copying, so we can shallow-copy
 			switch overlay.Kind() {
 			case reflect.Ptr:
-				base.Set(overlay.Elem())
+				base.Set(o.adopt(overlay.Elem()))
 			case reflect.Struct:
 				if !overlay.Type().AssignableTo(base.Type()) {
 					return fmt.Errorf("struct type %s is not assignable to %s",
 						overlay.Type(), base.Type())
 				}
 				// it's assignable, just assign it.
-				base.Set(overlay)
+				base.Set(o.adopt(overlay))
 				return nil
 			default:
 				return fmt.Errorf("type %s is not assignable to %s",
@@ -104,14 +135,103 @@ func (o *overlayer) overlayField(base, overlay reflect.Value) error {
 		// pointer-ified (plus Sources can return whatever Value they
 		// want)
 		if overlay.Kind() == reflect.Ptr {
-			base.Set(overlay.Elem())
+			base.Set(o.adopt(overlay.Elem()))
 		} else {
-			base.Set(overlay)
+			base.Set(o.adopt(overlay))
 		}
 	}
 	return nil
 }
 
+// overlayFieldPlan describes a field that overlayStruct overlays.
+type overlayFieldPlan struct {
+	// base is the index of the field in the base struct, and overlay the
+	// index of the corresponding field in the pointerified overlay struct
+	// (which lacks omitted fields, channels and functions)
+	base, overlay int
+	name          string
+	// appendSlice is true for slice fields whose values are appended
+	appendSlice bool
+	// mergeMap is true for map fields whose entries are merged
+	mergeMap bool
+	// replaceStruct is true for nested struct fields that are replaced as
+	// a whole
+	replaceStruct bool
+}
+
+// overlayPlan contains the fields that overlayStruct overlays for a struct
+// type, or the error from an invalid SliceMergeTag.
+type overlayPlan struct {
+	fields []overlayFieldPlan
+	err    error
+}
+
+type overlayPlanKey struct {
+	t          reflect.Type
+	sliceMerge SliceMerge
+}
+
+// overlayPlans caches the overlayPlan for each struct type and default slice
+// merge mode, so restacks don't repeat the analysis of the struct's fields.
+var overlayPlans sync.Map
+
+// planOverlay returns the (cached) overlayPlan for the struct type t.
+func planOverlay(t reflect.Type, sliceMerge SliceMerge) *overlayPlan {
+	key := overlayPlanKey{t: t, sliceMerge: sliceMerge}
+	if p, ok := overlayPlans.Load(key); ok {
+		return p.(*overlayPlan)
+	}
+	plan := &overlayPlan{}
+	for i, j := 0, 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		switch sf.Type.Kind() {
+		// We skip channels and functions during
+		// pointerification, so we need to adjust indices
+		// appropriately and skip these fields.
+		case reflect.Chan, reflect.Func:
+			continue
+		case reflect.Slice:
+			mode, modeErr := fieldSliceMerge(sf, sliceMerge)
+			if modeErr != nil {
+				plan.err = modeErr
+				break
+			}
+			plan.fields = append(plan.fields, overlayFieldPlan{
+				base: i, overlay: j, name: sf.Name, appendSlice: mode == SliceMergeAppend,
+			})
+		case reflect.Map:
+			merge, mergeErr := fieldMapMerge(sf)
+			if mergeErr != nil {
+				plan.err = mergeErr
+				break
+			}
+			plan.fields = append(plan.fields, overlayFieldPlan{
+				base: i, overlay: j, name: sf.Name, mergeMap: merge,
+			})
+		default:
+			replace := false
+			if isNestedStruct(sf.Type) {
+				var replaceErr error
+				if replace, replaceErr = fieldStructReplace(sf); replaceErr != nil {
+					plan.err = replaceErr
+					break
+				}
+			}
+			plan.fields = append(plan.fields, overlayFieldPlan{
+				base: i, overlay: j, name: sf.Name, replaceStruct: replace,
+			})
+		}
+		// We only increment the offset into the
+		// pointerfied/source-specific value if the field was present.
+		j++
+	}
+	p, _ := overlayPlans.LoadOrStore(key, plan)
+	return p.(*overlayPlan)
+}
+
 // overlayStruct assumes that overlay is a pointerified type of the type of
 // base.
 func (o *overlayer) overlayStruct(base, overlay reflect.Value) error {
@@ -122,31 +242,110 @@ func (o *overlayer) overlayStruct(base, overlay reflect.Value) error {
 	if overlay.Kind() != reflect.Struct {
 		panic(fmt.Errorf("non-struct call: %s as overlay (%s as base)", overlay.Type(), base.Type()))
 	}
-	for i, j := 0, 0; i < base.NumField(); i++ {
-		currentField := base.Field(i)
+	plan := planOverlay(base.Type(), o.sliceMerge)
+	if plan.err != nil {
+		return plan.err
+	}
+	for _, fp := range plan.fields {
+		if err := o.overlayPlannedField(base, overlay, fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if ptrify.OmitField(base.Type().Field(i)) {
-			continue
+// overlayPlannedField overlays the field of the struct overlay described by
+// fp onto the corresponding field of the struct base.
+func (o *overlayer) overlayPlannedField(base, overlay reflect.Value, fp overlayFieldPlan) error {
+	currentField := base.Field(fp.base)
+	ov := overlay.Field(fp.overlay)
+	if fp.appendSlice && ov.Kind() == reflect.Slice {
+		if !ov.IsNil() {
+			if !currentField.CanSet() {
+				return &overlayFieldError{name: fp.name, number: fp.base, err: errCanSetField}
+			}
+			currentField.Set(appendSlices(currentField, o.adopt(ov)))
+		}
+		return nil
+	}
+	if fp.replaceStruct && anySet(ov) {
+		if !currentField.CanSet() {
+			return &overlayFieldError{name: fp.name, number: fp.base, err: errCanSetField}
 		}
+		// discard the lower-precedence values before overlaying
+		currentField.Set(reflect.Zero(currentField.Type()))
+	}
+	overlayErr := error(nil)
+	if fp.mergeMap && ov.Kind() == reflect.Map {
+		overlayErr = o.overlayMap(currentField, ov, true)
+	} else {
+		overlayErr = o.overlayField(currentField, ov)
+	}
+	if overlayErr != nil {
+		return &overlayFieldError{name: fp.name, number: fp.base, err: overlayErr}
+	}
+	return nil
+}
 
-		switch currentField.Kind() {
-		// We skip channels and functions during
-		// pointerification, so we need to adjust indices
-		// appropriately and skip these fields.
-		case reflect.Chan, reflect.Func:
+// overlayMap overlays the map overlay onto the map base, replacing it, or if
+// merge is set, only replacing the entries with keys present in overlay. If
+// base's values are structs (see ptrify.StructMapElem), overlay's values are
+// pointers to pointerified structs, which are overlaid onto a zero value, or
+// when merging, onto the value of base's entry with the same key.
+func (o *overlayer) overlayMap(base, overlay reflect.Value, merge bool) error {
+	if overlay.IsNil() {
+		return nil
+	}
+	if !base.CanSet() {
+		return errCanSetField
+	}
+	if !merge && overlay.Type() == base.Type() {
+		base.Set(o.adopt(overlay))
+		return nil
+	}
+	elemType := base.Type().Elem()
+	structType, isStructMap := ptrify.StructMapElem(base.Type())
+	isStructMap = isStructMap && overlay.Type().Elem() != elemType
+	if !isStructMap && overlay.Type().Elem() != elemType {
+		return fmt.Errorf("map type %s cannot be overlaid onto %s", overlay.Type(), base.Type())
+	}
+
+	out := reflect.MakeMapWithSize(base.Type(), overlay.Len())
+	if merge && !base.IsNil() {
+		// base is already a copy, so its entries needn't be copied again
+		iter := base.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	iter := overlay.MapRange()
+	for iter.Next() {
+		k, ov := o.adopt(iter.Key()), iter.Value()
+		if !isStructMap {
+			out.SetMapIndex(k, o.adopt(ov))
 			continue
-		default:
 		}
-		if overlayErr := o.overlayField(
-			currentField,
-			overlay.Field(j)); overlayErr != nil {
-			return fmt.Errorf("failed to set field %q (number %d): %s",
-				base.Type().Field(i).Name, i, overlayErr)
+		elem := reflect.New(structType)
+		if cur := out.MapIndex(k); cur.IsValid() {
+			if cur.Kind() == reflect.Ptr {
+				cur = cur.Elem()
+			}
+			if cur.IsValid() {
+				elem.Elem().Set(o.dc.deepCopyValue(cur))
+			}
+		}
+		if !ov.IsNil() {
+			if err := o.overlayStruct(elem.Elem(), ov.Elem()); err != nil {
+				return fmt.Errorf("failed to overlay entry %v: %s", k, err)
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			out.SetMapIndex(k, elem)
+		} else {
+			out.SetMapIndex(k, elem.Elem())
 		}
-		// We only increment the offset into the
-		// pointerfied/source-specific value if the field was present.
-		j++
 	}
+	base.Set(out)
 	return nil
 }
 
@@ -167,7 +366,7 @@ func (o *overlayer) overlayInterface(base, overlay reflect.Value) error {
 			// Since pointerification doesn't convert to a concrete
 			// type if the default value it gets is nil, this is
 			// the common case for this case
-			base.Set(overlay.Elem())
+			base.Set(o.adopt(overlay.Elem()))
 			return nil
 		}
 		if overlay.Elem().Type() == base.Elem().Type() {
@@ -182,7 +381,7 @@ func (o *overlayer) overlayInterface(base, overlay reflect.Value) error {
 		}
 		// interface values don't have the same type, and neither is
 		// nil, treat it the same way as if base is nil, and just overlay.
-		base.Set(overlay.Elem())
+		base.Set(o.adopt(overlay.Elem()))
 		return nil
 	case reflect.Ptr:
 		if overlay.IsNil() {
@@ -194,6 +393,13 @@ func (o *overlayer) overlayInterface(base, overlay reflect.Value) error {
 		if !ptrImpl && !baseImpl {
 			return fmt.Errorf("overlay-type (%s) does not implement base type %s", overlay.Type(), base.Type())
 		}
+		// a pointer to a struct of the same type as the base's is a
+		// complete value (as pointerified structs don't implement the
+		// interfaces of their originals), so it replaces the base's.
+		if !base.IsNil() && base.Elem().Type() == overlay.Type() && overlay.Type().Elem().Kind() == reflect.Struct {
+			base.Set(o.adopt(overlay))
+			return nil
+		}
 		// if we're here, we need to overlay within the same types
 		// check whether the overlay pointer-type matches the base-value's contained type
 		// or the pointee type matches the base-value's contained type
@@ -229,7 +435,7 @@ func (o *overlayer) overlayInterface(base, overlay reflect.Value) error {
 			}
 		}
 		if overlay.Type().Implements(base.Type()) {
-			base.Set(overlay)
+			base.Set(o.adopt(overlay))
 			return nil
 		}
 		if reflect.PtrTo(overlay.Type()).Implements(base.Type()) {
@@ -250,7 +456,7 @@ func (o *overlayer) overlayInterface(base, overlay reflect.Value) error {
 		// If it's a map or slice, then we can just overwrite
 		// the overlay.
 		if overlay.Type().Implements(base.Type()) {
-			base.Set(overlay)
+			base.Set(o.adopt(overlay))
 			return nil
 		}
 		return fmt.Errorf("error overlaying %s (%s) onto interface(%s); overlay doesn't implement interface-type",
@@ -13,6 +13,9 @@ func TestOverlay(t *testing.T) {
 	True := true
 	sampleChan := make(chan struct{})
 	now := time.Now()
+	threePtr := &three
+	two := &sInt{J: 2}
+	sThree := &sInt{J: 3}
 
 	for name, inst := range map[string]struct {
 		// Note: base must be a pointer-type to make it mutable
@@ -23,6 +26,22 @@ func TestOverlay(t *testing.T) {
 		"just_int_overlayed": {base: &sInt{J: 2},
 			overlay:  struct{ J *int }{J: &three},
 			expected: sInt{J: 3}},
+		"pointers_to_pointers": {base: &struct {
+			I **int
+			S **sInt
+			T **sInt
+		}{T: &two},
+			overlay: struct {
+				I *int
+				S *struct{ J *int }
+				T *struct{ J *int }
+			}{I: &three, S: &struct{ J *int }{J: &three}, T: &struct{ J *int }{J: &three}},
+			expected: struct {
+				I **int
+				S **sInt
+				T **sInt
+			}{I: &threePtr, S: &sThree, T: &sThree},
+		},
 		"just_bool_overlayed": {base: &sBool{J: false},
 			overlay:  struct{ J *bool }{J: &True},
 			expected: sBool{J: true}},
@@ -371,6 +390,11 @@ func TestOverlay(t *testing.T) {
 			overlay:  struct{ K *[1]string }{K: &[...]string{"bar"}},
 			expected: struct{ K interface{} }{K: &[...]string{"bar"}},
 		},
+		"struct_ptr_iface_same_type": {
+			base:     &struct{ K interface{} }{K: &struct{ I, J int }{I: 1, J: 2}},
+			overlay:  struct{ K interface{} }{K: &struct{ I, J int }{J: 3}},
+			expected: struct{ K interface{} }{K: &struct{ I, J int }{J: 3}},
+		},
 		"array_iface_ptr_base_nil_with_type": {
 			base:     &struct{ K interface{} }{K: (*[1]string)(nil)},
 			overlay:  struct{ K *[1]string }{K: &[...]string{"bar"}},
@@ -397,3 +421,70 @@ func TestOverlay(t *testing.T) {
 
 	}
 }
+
+func TestOverlayPlanCached(t *testing.T) {
+	type planned struct {
+		A int
+		C chan struct{}
+		S []string `dialsmerge:"append"`
+		T []string
+	}
+	typ := reflect.TypeOf(planned{})
+	plan := planOverlay(typ, SliceMergeReplace)
+	if plan.err != nil {
+		t.Fatalf("failed to plan overlay: %s", plan.err)
+	}
+	expected := []overlayFieldPlan{
+		{base: 0, overlay: 0, name: "A"},
+		{base: 2, overlay: 1, name: "S", appendSlice: true},
+		{base: 3, overlay: 2, name: "T"},
+	}
+	if !reflect.DeepEqual(plan.fields, expected) {
+		t.Errorf("got: %+v, expected: %+v", plan.fields, expected)
+	}
+	if again := planOverlay(typ, SliceMergeReplace); again != plan {
+		t.Errorf("plan for %s wasn't reused", typ)
+	}
+	if appended := planOverlay(typ, SliceMergeAppend); appended == plan || !appended.fields[2].appendSlice {
+		t.Errorf("plan for %s with append merging: %+v", typ, appended.fields)
+	}
+}
+
+func TestOverlayCopiesAdoptedValues(t *testing.T) {
+	type inner struct{ N int }
+	type adopted struct {
+		P *inner
+		S []string
+		M map[string]int
+		A []*inner `dialsmerge:"append"`
+	}
+	overlay := adopted{
+		P: &inner{N: 1},
+		S: []string{"a"},
+		M: map[string]int{"a": 1},
+		A: []*inner{{N: 2}},
+	}
+	base := adopted{A: []*inner{{N: 0}}}
+	o := newOverlayer()
+	if err := o.overlayStruct(reflect.ValueOf(&base).Elem(), reflect.ValueOf(overlay)); err != nil {
+		t.Fatalf("failed to overlay struct: %s", err)
+	}
+	expected := adopted{
+		P: &inner{N: 1},
+		S: []string{"a"},
+		M: map[string]int{"a": 1},
+		A: []*inner{{N: 0}, {N: 2}},
+	}
+	if !reflect.DeepEqual(base, expected) {
+		t.Fatalf("got: %#v, expected: %#v", base, expected)
+	}
+
+	// modifying the overlay doesn't modify the base
+	overlay.P.N = 10
+	overlay.S[0] = "b"
+	overlay.M["a"] = 10
+	overlay.A[0].N = 20
+	if !reflect.DeepEqual(base, expected) {
+		t.Errorf("base shares memory with overlay: %#v", base)
+	}
+}
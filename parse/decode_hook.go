@@ -0,0 +1,78 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// DecodeHook converts the generic values that decoders produce from config
+// files (string, bool, float64 and other numbers, []interface{} and
+// map[string]interface{}) into values of the Target type. Construct one with
+// NewDecodeHook, and register it with RegisterDecodeHook.
+type DecodeHook struct {
+	// Target is the type of the fields the hook sets.
+	Target reflect.Type
+	// Convert converts a decoded value into a value of type Target.
+	Convert func(v interface{}) (reflect.Value, error)
+}
+
+// NewDecodeHook constructs a DecodeHook for fields of type T (or *T), which
+// converts decoded values with convert. The type of the value passed to
+// convert depends on the decoder: e.g. JSON numbers are float64s, while YAML
+// integers are ints, so convert should handle every type it may be passed.
+func NewDecodeHook[T any](convert func(v interface{}) (T, error)) DecodeHook {
+	return DecodeHook{
+		Target: reflect.TypeOf((*T)(nil)).Elem(),
+		Convert: func(v interface{}) (reflect.Value, error) {
+			out, err := convert(v)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&out).Elem(), nil
+		},
+	}
+}
+
+var (
+	decodeHooksMu sync.RWMutex
+	decodeHooks   = map[reflect.Type]DecodeHook{}
+)
+
+// RegisterDecodeHook registers hooks, which the decoders in the decoders
+// package call to set fields of their Target types (or pointers to them),
+// instead of decoding into those fields directly. That way, applications can
+// convert values into types dials doesn't know about, like enums represented
+// by strings in config files, or structs represented by a single string or
+// a list. Fields of hooked struct types are treated as single values: flags
+// and environment variables can't set them unless the type is also
+// registered with RegisterType (or implements encoding.TextUnmarshaler).
+//
+// Registering a hook for a type that already has one replaces it.
+// RegisterDecodeHook is intended to be called from init functions, as the
+// set of struct types treated as single values mustn't change once
+// configuration has been read. Hooks for a single configuration can be set
+// with dials.Params.DecodeHooks instead.
+func RegisterDecodeHook(hooks ...DecodeHook) {
+	decodeHooksMu.Lock()
+	defer decodeHooksMu.Unlock()
+	for _, h := range hooks {
+		if h.Target == nil || h.Convert == nil {
+			panic(fmt.Sprintf("parse: RegisterDecodeHook called with an incomplete DecodeHook for %v", h.Target))
+		}
+		decodeHooks[h.Target] = h
+	}
+}
+
+// LookupDecodeHook returns the DecodeHook registered for the type t (see
+// RegisterDecodeHook), if there is one. The protobuf well-known types that
+// are scalars in configs (see RegisterType) have built-in hooks, so they can
+// be set from numbers as well as strings.
+func LookupDecodeHook(t reflect.Type) (DecodeHook, bool) {
+	decodeHooksMu.RLock()
+	defer decodeHooksMu.RUnlock()
+	if h, ok := decodeHooks[t]; ok {
+		return h, true
+	}
+	return protoDecodeHook(t)
+}
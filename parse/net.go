@@ -0,0 +1,29 @@
+package parse
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// URL parses s with url.Parse, rejecting the empty string.
+func URL(s string) (*url.URL, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty URL")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// IPNet parses s as a CIDR (e.g. "192.0.2.0/24" or "2001:db8::/32"),
+// returning the network it denotes.
+func IPNet(s string) (*net.IPNet, error) {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,37 @@
+package parse
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	u, err := URL("https://user@example.com:8443/path?q=1")
+	require.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+	assert.Equal(t, "example.com:8443", u.Host)
+	assert.Equal(t, "user", u.User.Username())
+
+	_, err = URL("")
+	assert.Error(t, err)
+	_, err = URL("http://[::1")
+	assert.Error(t, err)
+}
+
+func TestParseIPNet(t *testing.T) {
+	n, err := IPNet("192.0.2.17/24")
+	require.NoError(t, err)
+	// the network is returned, not the address
+	assert.Equal(t, "192.0.2.0/24", n.String())
+	assert.True(t, n.Contains(net.IPv4(192, 0, 2, 200)))
+
+	v, err := String("2001:db8::/32", ipNetType)
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::/32", v.Interface().(*net.IPNet).String())
+
+	_, err = IPNet("192.0.2.17")
+	assert.Error(t, err)
+}
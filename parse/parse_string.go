@@ -1,14 +1,54 @@
 package parse
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
+	"time"
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	urlType   = reflect.TypeOf(url.URL{})
+	ipNetType = reflect.TypeOf(net.IPNet{})
+	rawJSON   = reflect.TypeOf(json.RawMessage(nil))
 )
 
 // String casts the provided string into the provided type, returning the
-// result in a reflect.Value.
+// result in a reflect.Value. Types registered with RegisterType are parsed
+// by their registered functions.
 func String(str string, t reflect.Type) (reflect.Value, error) {
+	if c, ok := lookupConverter(t); ok {
+		return c.parse(str)
+	}
+	switch t {
+	case timeType:
+		converted, err := Time(str, nil)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&converted), nil
+	case urlType:
+		converted, err := URL(str)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(converted), nil
+	case ipNetType:
+		converted, err := IPNet(str)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(converted), nil
+	case rawJSON:
+		if !json.Valid([]byte(str)) {
+			return reflect.Value{}, fmt.Errorf("invalid JSON %q", str)
+		}
+		return reflect.ValueOf(json.RawMessage(str)), nil
+	}
 	switch t.Kind() {
 	case reflect.String:
 		return reflect.ValueOf(&str), nil
@@ -57,6 +97,9 @@ func String(str string, t reflect.Type) (reflect.Value, error) {
 			}
 			return reflect.ValueOf(converted), nil
 		default:
+			if t.Elem().Kind() == reflect.Ptr && t.Elem().Elem().Kind() == reflect.Struct {
+				return StructMap(str, t)
+			}
 			keyKind := t.Key().Kind()
 			valKind := t.Elem().Kind()
 			err := checkKindsSupported(keyKind, valKind)
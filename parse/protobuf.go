@@ -0,0 +1,213 @@
+package parse
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// The protobuf well-known types are identified by name, so parse doesn't
+// depend on google.golang.org/protobuf.
+const (
+	protoDurationPkgPath  = "google.golang.org/protobuf/types/known/durationpb"
+	protoTimestampPkgPath = "google.golang.org/protobuf/types/known/timestamppb"
+	protoWrappersPkgPath  = "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoWrapperNames are the names of the types in wrapperspb, which hold a
+// single scalar in their Value field.
+var protoWrapperNames = map[string]struct{}{
+	"DoubleValue": {},
+	"FloatValue":  {},
+	"Int64Value":  {},
+	"UInt64Value": {},
+	"Int32Value":  {},
+	"UInt32Value": {},
+	"BoolValue":   {},
+	"StringValue": {},
+	"BytesValue":  {},
+}
+
+type protoWellKnownKind int
+
+const (
+	protoNotWellKnown protoWellKnownKind = iota
+	protoDuration
+	protoTimestamp
+	protoWrapper
+)
+
+// protoWellKnown returns the kind of protobuf well-known type t is, if it's
+// one that's a scalar in configs.
+func protoWellKnown(t reflect.Type) protoWellKnownKind {
+	if t.Kind() != reflect.Struct {
+		return protoNotWellKnown
+	}
+	switch t.PkgPath() {
+	case protoDurationPkgPath:
+		if t.Name() == "Duration" {
+			return protoDuration
+		}
+	case protoTimestampPkgPath:
+		if t.Name() == "Timestamp" {
+			return protoTimestamp
+		}
+	case protoWrappersPkgPath:
+		if _, ok := protoWrapperNames[t.Name()]; ok {
+			return protoWrapper
+		}
+	}
+	return protoNotWellKnown
+}
+
+// protoConverter returns a converter for the protobuf well-known type t:
+// durationpb.Duration is parsed like time.Duration (e.g. "1.5s"),
+// timestamppb.Timestamp like time.Time (see Time), and the wrapperspb types
+// like the scalars they wrap (with BytesValue base64-encoded, as in
+// protobuf's JSON mapping).
+func protoConverter(t reflect.Type) (converter, bool) {
+	return wellKnownConverter(protoWellKnown(t), t)
+}
+
+// wellKnownConverter returns the converter for t, a protobuf well-known type
+// of the given kind.
+func wellKnownConverter(kind protoWellKnownKind, t reflect.Type) (converter, bool) {
+	switch kind {
+	case protoDuration:
+		return converter{
+			parse: func(s string) (reflect.Value, error) {
+				d, err := time.ParseDuration(s)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return newProtoSecondsNanos(t, int64(d/time.Second), int32(d%time.Second)), nil
+			},
+			format: func(v reflect.Value) string {
+				secs, nanos := protoSecondsNanos(v)
+				return (time.Duration(secs)*time.Second + time.Duration(nanos)).String()
+			},
+		}, true
+	case protoTimestamp:
+		return converter{
+			parse: func(s string) (reflect.Value, error) {
+				ts, err := Time(s, nil)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return newProtoSecondsNanos(t, ts.Unix(), int32(ts.Nanosecond())), nil
+			},
+			format: func(v reflect.Value) string {
+				secs, nanos := protoSecondsNanos(v)
+				return time.Unix(secs, int64(nanos)).UTC().Format(time.RFC3339Nano)
+			},
+		}, true
+	case protoWrapper:
+		return converter{
+			parse: func(s string) (reflect.Value, error) {
+				ptr := reflect.New(t)
+				field := ptr.Elem().FieldByName("Value")
+				if field.Kind() == reflect.Slice {
+					b, err := base64.StdEncoding.DecodeString(s)
+					if err != nil {
+						return reflect.Value{}, err
+					}
+					field.SetBytes(b)
+					return ptr, nil
+				}
+				v, err := String(s, field.Type())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				field.Set(v.Elem())
+				return ptr, nil
+			},
+			format: func(v reflect.Value) string {
+				field := v.FieldByName("Value")
+				switch field.Kind() {
+				case reflect.Slice:
+					return base64.StdEncoding.EncodeToString(field.Bytes())
+				case reflect.Float32:
+					return strconv.FormatFloat(field.Float(), 'g', -1, 32)
+				default:
+					return fmt.Sprint(field.Interface())
+				}
+			},
+		}, true
+	default:
+		return converter{}, false
+	}
+}
+
+// protoDecodeHook returns a decode hook for the protobuf well-known type t,
+// which, in addition to the strings parsed by its converter, accepts the
+// numbers (and booleans) decoders produce: a number of seconds for
+// durationpb.Duration, and the wrapped scalar for the wrapperspb types.
+func protoDecodeHook(t reflect.Type) (DecodeHook, bool) {
+	return wellKnownDecodeHook(protoWellKnown(t), t)
+}
+
+// wellKnownDecodeHook returns the decode hook for t, a protobuf well-known
+// type of the given kind.
+func wellKnownDecodeHook(kind protoWellKnownKind, t reflect.Type) (DecodeHook, bool) {
+	c, ok := wellKnownConverter(kind, t)
+	if !ok {
+		return DecodeHook{}, false
+	}
+	return DecodeHook{
+		Target: t,
+		Convert: func(v interface{}) (reflect.Value, error) {
+			if s, ok := v.(string); ok {
+				out, err := c.parse(s)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return out.Elem(), nil
+			}
+			rv := reflect.ValueOf(v)
+			switch kind {
+			case protoDuration:
+				if !isNumberKind(rv.Kind()) {
+					break
+				}
+				secs := rv.Convert(reflect.TypeOf(float64(0))).Float()
+				d := time.Duration(secs * float64(time.Second))
+				return newProtoSecondsNanos(t, int64(d/time.Second), int32(d%time.Second)).Elem(), nil
+			case protoWrapper:
+				out := reflect.New(t).Elem()
+				field := out.FieldByName("Value")
+				if (isNumberKind(rv.Kind()) && isNumberKind(field.Kind())) ||
+					(rv.Kind() == reflect.Bool && field.Kind() == reflect.Bool) {
+					field.Set(rv.Convert(field.Type()))
+					return out, nil
+				}
+			}
+			return reflect.Value{}, fmt.Errorf("cannot convert %v (type %T) to %s", v, v, t)
+		},
+	}, true
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// newProtoSecondsNanos returns a pointer to a new durationpb.Duration or
+// timestamppb.Timestamp (of type t) with the given Seconds and Nanos.
+func newProtoSecondsNanos(t reflect.Type, secs int64, nanos int32) reflect.Value {
+	ptr := reflect.New(t)
+	ptr.Elem().FieldByName("Seconds").SetInt(secs)
+	ptr.Elem().FieldByName("Nanos").SetInt(int64(nanos))
+	return ptr
+}
+
+func protoSecondsNanos(v reflect.Value) (int64, int32) {
+	return v.FieldByName("Seconds").Int(), int32(v.FieldByName("Nanos").Int())
+}
@@ -0,0 +1,99 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These mimic the well-known types, which can't be recognized by name here
+// without depending on google.golang.org/protobuf.
+type fakeDuration struct {
+	state   struct{ p *int }
+	Seconds int64
+	Nanos   int32
+}
+
+type fakeTimestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+type fakeInt64Value struct {
+	Value int64
+}
+
+type fakeBytesValue struct {
+	Value []byte
+}
+
+func TestProtoWellKnownConverters(t *testing.T) {
+	for name, tbl := range map[string]struct {
+		kind     protoWellKnownKind
+		in       string
+		expected interface{}
+		format   string
+	}{
+		"duration": {
+			kind: protoDuration, in: "1.5s",
+			expected: fakeDuration{Seconds: 1, Nanos: 500000000}, format: "1.5s",
+		},
+		"negative_duration": {
+			kind: protoDuration, in: "-90m",
+			expected: fakeDuration{Seconds: -5400}, format: "-1h30m0s",
+		},
+		"timestamp": {
+			kind: protoTimestamp, in: "2023-05-01T12:00:00.25Z",
+			expected: fakeTimestamp{Seconds: 1682942400, Nanos: 250000000}, format: "2023-05-01T12:00:00.25Z",
+		},
+		"int64_value": {
+			kind: protoWrapper, in: "-42",
+			expected: fakeInt64Value{Value: -42}, format: "-42",
+		},
+		"bytes_value": {
+			kind: protoWrapper, in: "aGVsbG8=",
+			expected: fakeBytesValue{Value: []byte("hello")}, format: "aGVsbG8=",
+		},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			c, ok := wellKnownConverter(tbl.kind, reflect.TypeOf(tbl.expected))
+			require.True(t, ok)
+			v, err := c.parse(tbl.in)
+			require.NoError(t, err)
+			assert.Equal(t, tbl.expected, v.Elem().Interface())
+			assert.Equal(t, tbl.format, c.format(v.Elem()))
+		})
+	}
+}
+
+func TestProtoWellKnownDecodeHooks(t *testing.T) {
+	durHook, ok := wellKnownDecodeHook(protoDuration, reflect.TypeOf(fakeDuration{}))
+	require.True(t, ok)
+	v, err := durHook.Convert(2.5)
+	require.NoError(t, err)
+	assert.Equal(t, fakeDuration{Seconds: 2, Nanos: 500000000}, v.Interface())
+	v, err = durHook.Convert("10ms")
+	require.NoError(t, err)
+	assert.Equal(t, fakeDuration{Nanos: 10000000}, v.Interface())
+	_, err = durHook.Convert(true)
+	assert.Error(t, err)
+
+	intHook, ok := wellKnownDecodeHook(protoWrapper, reflect.TypeOf(fakeInt64Value{}))
+	require.True(t, ok)
+	v, err = intHook.Convert(7)
+	require.NoError(t, err)
+	assert.Equal(t, fakeInt64Value{Value: 7}, v.Interface())
+	_, err = intHook.Convert([]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestProtoWellKnownByName(t *testing.T) {
+	// only the real well-known types are recognized
+	assert.Equal(t, protoNotWellKnown, protoWellKnown(reflect.TypeOf(fakeDuration{})))
+	assert.False(t, Registered(reflect.TypeOf(fakeDuration{})))
+	_, ok := LookupDecodeHook(reflect.TypeOf(fakeInt64Value{}))
+	assert.False(t, ok)
+}
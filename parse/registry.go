@@ -0,0 +1,149 @@
+package parse
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// converter parses and formats values of a registered type.
+type converter struct {
+	parse  func(string) (reflect.Value, error)
+	format func(reflect.Value) string
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[reflect.Type]converter{}
+)
+
+// RegisterType registers functions converting values of type T from and to
+// strings, so fields of type T (or *T) are treated as scalars settable from
+// strings by every source: flags and environment variables are parsed with
+// parseFn, and decoders expect strings for them (rather than objects) in
+// config files. format should return a string that parseFn parses back into
+// the same value; it's used to render defaults and current values (e.g. in
+// flag usage and env.Source.Environ).
+//
+// Types that implement encoding.TextUnmarshaler (such as uuid.UUID or
+// decimal.Decimal) are already handled that way, so this is mostly useful
+// for types from other packages that don't implement it. Registering a type
+// a second time replaces its converter. The database/sql Null types
+// (sql.NullString, sql.NullInt64, etc.) are registered by default, and the
+// protobuf well-known types durationpb.Duration, timestamppb.Timestamp and
+// the wrapperspb types are recognized by name, so generated protobuf
+// messages can be used as config types.
+//
+// RegisterType is intended to be called from init functions (or at least
+// before any configuration is read), as already-constructed sources don't
+// see types registered later.
+func RegisterType[T any](parseFn func(string) (T, error), format func(T) string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	c := converter{
+		parse: func(s string) (reflect.Value, error) {
+			v, err := parseFn(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&v), nil
+		},
+		format: func(v reflect.Value) string {
+			return format(v.Interface().(T))
+		},
+	}
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[t] = c
+}
+
+// Registered indicates whether a converter was registered for the type t
+// with RegisterType.
+func Registered(t reflect.Type) bool {
+	_, ok := lookupConverter(t)
+	return ok
+}
+
+// Format formats v with the format function registered for its type (see
+// RegisterType). The boolean is false if no converter is registered for the
+// type of v.
+func Format(v reflect.Value) (string, bool) {
+	c, ok := lookupConverter(v.Type())
+	if !ok {
+		return "", false
+	}
+	return c.format(v), true
+}
+
+func lookupConverter(t reflect.Type) (converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	if c, ok := converters[t]; ok {
+		return c, true
+	}
+	return protoConverter(t)
+}
+
+// The sql Null types are set to NULL (Valid is false) by an empty string,
+// except for sql.NullString, where the empty string is a valid value. They
+// format NULL as an empty string.
+func init() {
+	RegisterType(func(s string) (sql.NullString, error) {
+		return sql.NullString{String: s, Valid: true}, nil
+	}, func(n sql.NullString) string { return n.String })
+	RegisterType(nullParser(func(s string) (sql.NullBool, error) {
+		b, err := strconv.ParseBool(s)
+		return sql.NullBool{Bool: b, Valid: true}, err
+	}), func(n sql.NullBool) string { return nullFormat(n.Valid, strconv.FormatBool(n.Bool)) })
+	RegisterType(nullParser(func(s string) (sql.NullByte, error) {
+		b, err := strconv.ParseUint(s, 10, 8)
+		return sql.NullByte{Byte: byte(b), Valid: true}, err
+	}), func(n sql.NullByte) string { return nullFormat(n.Valid, strconv.FormatUint(uint64(n.Byte), 10)) })
+	RegisterType(nullParser(func(s string) (sql.NullInt16, error) {
+		i, err := strconv.ParseInt(s, 10, 16)
+		return sql.NullInt16{Int16: int16(i), Valid: true}, err
+	}), func(n sql.NullInt16) string { return nullFormat(n.Valid, strconv.FormatInt(int64(n.Int16), 10)) })
+	RegisterType(nullParser(func(s string) (sql.NullInt32, error) {
+		i, err := strconv.ParseInt(s, 10, 32)
+		return sql.NullInt32{Int32: int32(i), Valid: true}, err
+	}), func(n sql.NullInt32) string { return nullFormat(n.Valid, strconv.FormatInt(int64(n.Int32), 10)) })
+	RegisterType(nullParser(func(s string) (sql.NullInt64, error) {
+		i, err := strconv.ParseInt(s, 10, 64)
+		return sql.NullInt64{Int64: i, Valid: true}, err
+	}), func(n sql.NullInt64) string { return nullFormat(n.Valid, strconv.FormatInt(n.Int64, 10)) })
+	RegisterType(nullParser(func(s string) (sql.NullFloat64, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		return sql.NullFloat64{Float64: f, Valid: true}, err
+	}), func(n sql.NullFloat64) string {
+		return nullFormat(n.Valid, strconv.FormatFloat(n.Float64, 'g', -1, 64))
+	})
+	RegisterType(nullParser(func(s string) (sql.NullTime, error) {
+		t, err := Time(s, nil)
+		return sql.NullTime{Time: t, Valid: true}, err
+	}), func(n sql.NullTime) string { return nullFormat(n.Valid, n.Time.Format(time.RFC3339Nano)) })
+}
+
+// nullParser wraps parseFn so the empty string is parsed as the zero value
+// (NULL) of a sql Null type.
+func nullParser[T any](parseFn func(string) (T, error)) func(string) (T, error) {
+	return func(s string) (T, error) {
+		var zero T
+		if s == "" {
+			return zero, nil
+		}
+		v, err := parseFn(s)
+		if err != nil {
+			return zero, fmt.Errorf("failed to parse %q as %T: %w", s, zero, err)
+		}
+		return v, nil
+	}
+}
+
+func nullFormat(valid bool, s string) string {
+	if !valid {
+		return ""
+	}
+	return s
+}
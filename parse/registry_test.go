@@ -0,0 +1,97 @@
+package parse
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQLNullTypes(t *testing.T) {
+	for name, tbl := range map[string]struct {
+		in       string
+		expected interface{}
+	}{
+		"string":       {in: "foo", expected: sql.NullString{String: "foo", Valid: true}},
+		"empty_string": {in: "", expected: sql.NullString{Valid: true}},
+		"int64":        {in: "-42", expected: sql.NullInt64{Int64: -42, Valid: true}},
+		"null_int64":   {in: "", expected: sql.NullInt64{}},
+		"int32":        {in: "7", expected: sql.NullInt32{Int32: 7, Valid: true}},
+		"int16":        {in: "7", expected: sql.NullInt16{Int16: 7, Valid: true}},
+		"byte":         {in: "255", expected: sql.NullByte{Byte: 255, Valid: true}},
+		"float64":      {in: "1.5", expected: sql.NullFloat64{Float64: 1.5, Valid: true}},
+		"bool":         {in: "true", expected: sql.NullBool{Bool: true, Valid: true}},
+		"time": {
+			in:       "2023-05-01T12:00:00Z",
+			expected: sql.NullTime{Time: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), Valid: true},
+		},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			v, err := String(tbl.in, reflect.TypeOf(tbl.expected))
+			require.NoError(t, err)
+			assert.Equal(t, tbl.expected, v.Elem().Interface())
+
+			s, ok := Format(v.Elem())
+			require.True(t, ok)
+			assert.Equal(t, tbl.in, s)
+		})
+	}
+
+	_, err := String("256", reflect.TypeOf(sql.NullByte{}))
+	assert.Error(t, err)
+	_, err = String("yes please", reflect.TypeOf(sql.NullBool{}))
+	assert.Error(t, err)
+}
+
+type registryTestVersion struct {
+	Major, Minor int
+}
+
+func TestRegisterType(t *testing.T) {
+	typ := reflect.TypeOf(registryTestVersion{})
+	assert.False(t, Registered(typ))
+	// leave the global registry as it was, for later runs (e.g. with
+	// -count)
+	t.Cleanup(func() {
+		convertersMu.Lock()
+		defer convertersMu.Unlock()
+		delete(converters, typ)
+	})
+
+	RegisterType(func(s string) (registryTestVersion, error) {
+		v := registryTestVersion{}
+		if _, err := fmt.Sscanf(s, "v%d.%d", &v.Major, &v.Minor); err != nil {
+			return v, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		return v, nil
+	}, func(v registryTestVersion) string {
+		return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+	})
+	assert.True(t, Registered(typ))
+	assert.False(t, Registered(reflect.PtrTo(typ)))
+
+	v, err := String("v1.20", typ)
+	require.NoError(t, err)
+	assert.Equal(t, &registryTestVersion{Major: 1, Minor: 20}, v.Interface())
+	s, ok := Format(v.Elem())
+	require.True(t, ok)
+	assert.Equal(t, "v1.20", s)
+
+	_, err = String("1.20", typ)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), `invalid version "1.20"`))
+
+	// slices of registered types are parsed element-wise
+	sl, err := String(`"v1.2","v3.4"`, reflect.SliceOf(typ))
+	require.NoError(t, err)
+	assert.Equal(t, []registryTestVersion{{1, 2}, {3, 4}}, sl.Interface())
+
+	_, ok = Format(reflect.ValueOf(42))
+	assert.False(t, ok)
+}
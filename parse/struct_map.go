@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials/common"
+)
+
+// StructMap parses s into a new map of type mapType, whose values are
+// pointers to structs with pointer (or nil-able) fields, such as the maps of
+// structs in pointerified config types. See StructMapInto for the format.
+func StructMap(s string, mapType reflect.Type) (reflect.Value, error) {
+	m := reflect.MakeMap(mapType)
+	if err := StructMapInto(s, m); err != nil {
+		return reflect.Value{}, err
+	}
+	return m, nil
+}
+
+// StructMapInto parses s, a comma-separated list of key:value pairs in the
+// format accepted by Map, setting fields of the entries of m (a map whose
+// values are pointers to structs with pointer fields). Each key consists of
+// the key of an entry, followed by a dot and the dot-separated path of the
+// field to set within it. Fields are named by their Go names or their dials
+// tags, ignoring case. For example,
+//
+//	primary.Port:5432,primary.Host:db1,db.example.com.Port:6432
+//
+// sets the Port and Host fields of the entry with key "primary", and the
+// Port field of the entry with key "db.example.com" (keys may contain dots,
+// so the shortest key that's followed by the path of a field is used).
+// Entries that don't exist in m are added.
+func StructMapInto(s string, m reflect.Value) error {
+	mapType := m.Type()
+	if mapType.Kind() != reflect.Map || mapType.Elem().Kind() != reflect.Ptr ||
+		mapType.Elem().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported map type %s; values must be pointers to structs", mapType)
+	}
+	return splitMap(s, func(k, v string) error {
+		keyStr, fieldPath, ok := splitStructMapKey(k, mapType.Elem().Elem())
+		if !ok {
+			return fmt.Errorf("no field in key %q of type %s", k, mapType.Elem().Elem())
+		}
+		key, err := String(keyStr, mapType.Key())
+		if err != nil {
+			return fmt.Errorf("error casting map key %q: %w", keyStr, err)
+		}
+		entry := m.MapIndex(key.Elem())
+		if !entry.IsValid() || entry.IsNil() {
+			entry = reflect.New(mapType.Elem().Elem())
+			m.SetMapIndex(key.Elem(), entry)
+		}
+		if err := setStructField(entry.Elem(), fieldPath, v); err != nil {
+			return fmt.Errorf("error setting field %s of entry %q: %w",
+				strings.Join(fieldPath, "."), keyStr, err)
+		}
+		return nil
+	})
+}
+
+// splitStructMapKey splits k into an entry's key and the path of field names
+// in the struct type t that follows it.
+func splitStructMapKey(k string, t reflect.Type) (string, []string, bool) {
+	parts := strings.Split(k, ".")
+	for i := 1; i < len(parts); i++ {
+		if _, ok := structFieldByPath(t, parts[i:]); ok {
+			return strings.Join(parts[:i], "."), parts[i:], true
+		}
+	}
+	return "", nil, false
+}
+
+// structFieldByPath returns the indices of the fields named by path, in
+// the struct type t (following pointers to structs).
+func structFieldByPath(t reflect.Type, path []string) ([]int, bool) {
+	idx := make([]int, 0, len(path))
+	for _, name := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if strings.EqualFold(sf.Name, name) || strings.EqualFold(sf.Tag.Get(common.DialsTagName), name) {
+				idx = append(idx, i)
+				t = sf.Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return idx, true
+}
+
+// setStructField parses str into the field at path in the struct v,
+// allocating any nil pointers to structs along the way.
+func setStructField(v reflect.Value, path []string, str string) error {
+	idx, _ := structFieldByPath(v.Type(), path)
+	for _, i := range idx {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	target := v.Type()
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	parsed, err := String(str, target)
+	if err != nil {
+		return err
+	}
+	switch {
+	case parsed.Type() == v.Type():
+		v.Set(parsed)
+	case parsed.Kind() == reflect.Ptr && parsed.Elem().Type() == v.Type():
+		v.Set(parsed.Elem())
+	default:
+		return fmt.Errorf("cannot set %s field from %s", v.Type(), parsed.Type())
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ptrifiedBackend struct {
+	Host    *string
+	Port    *int `dials:"port_number"`
+	Timeout *time.Duration
+	Tags    []string
+	TLS     *struct {
+		Cert *string
+	}
+}
+
+func TestStructMap(t *testing.T) {
+	mapType := reflect.TypeOf(map[string]*ptrifiedBackend{})
+	v, err := String(`primary.Host:db1,primary.port_number:5432,db.example.com.timeout:"3s",replica.TLS.Cert:"c.pem",replica.Tags:"a,b"`, mapType)
+	require.NoError(t, err)
+	m := v.Interface().(map[string]*ptrifiedBackend)
+	require.Len(t, m, 3)
+
+	assert.Equal(t, "db1", *m["primary"].Host)
+	assert.Equal(t, 5432, *m["primary"].Port)
+	assert.Nil(t, m["primary"].Timeout)
+	assert.Equal(t, 3*time.Second, *m["db.example.com"].Timeout)
+	assert.Equal(t, "c.pem", *m["replica"].TLS.Cert)
+	assert.Equal(t, []string{"a", "b"}, m["replica"].Tags)
+
+	// entries accumulate across calls
+	require.NoError(t, StructMapInto("primary.Host:db2", v))
+	assert.Equal(t, "db2", *m["primary"].Host)
+	assert.Equal(t, 5432, *m["primary"].Port)
+
+	_, err = StructMap("primary.Missing:1", mapType)
+	assert.Error(t, err)
+	_, err = StructMap("primary.Port:eighty", mapType)
+	assert.Error(t, err)
+	_, err = StructMap("primary:1", reflect.TypeOf(map[string]int{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts are the layouts Time uses when none are specified.
+var DefaultTimeLayouts = []string{time.RFC3339Nano}
+
+// namedTimeLayouts maps the names of the time package's layout constants to
+// their values. (DateTime, DateOnly and TimeOnly are spelled out, since
+// they're newer than the oldest supported Go release.)
+var namedTimeLayouts = map[string]string{
+	"Layout":      time.Layout,
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// TimeLayouts parses the value of a dialstimelayout tag (see
+// common.TimeLayoutTagName) into a list of layouts, resolving the names of
+// the time package's layout constants. An empty tag yields
+// DefaultTimeLayouts.
+func TimeLayouts(tag string) []string {
+	if tag == "" {
+		return DefaultTimeLayouts
+	}
+	layouts := strings.Split(tag, "|")
+	for i, l := range layouts {
+		if named, ok := namedTimeLayouts[l]; ok {
+			layouts[i] = named
+		}
+	}
+	return layouts
+}
+
+// Time parses s with each of layouts in turn, returning the first
+// successful result. If layouts is empty, DefaultTimeLayouts are used.
+func Time(s string, layouts []string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q does not match any of the layouts %q", s, layouts)
+}
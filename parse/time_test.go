@@ -0,0 +1,70 @@
+package parse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	for _, itbl := range []struct {
+		name     string
+		input    string
+		tag      string
+		expected time.Time
+		expErr   bool
+	}{
+		{
+			name:     "default_rfc3339",
+			input:    "2020-01-02T03:04:05Z",
+			expected: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:     "default_rfc3339_nano",
+			input:    "2020-01-02T03:04:05.5Z",
+			expected: time.Date(2020, 1, 2, 3, 4, 5, 500000000, time.UTC),
+		},
+		{
+			name:   "default_date_only",
+			input:  "2020-01-02",
+			expErr: true,
+		},
+		{
+			name:     "named_layouts",
+			input:    "2020-01-02",
+			tag:      "RFC3339|DateOnly",
+			expected: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "literal_layout_with_comma",
+			input:    "Jan 2, 2020",
+			tag:      "Jan 2, 2006",
+			expected: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "no_match",
+			input:  "yesterday",
+			tag:    "DateOnly|Kitchen",
+			expErr: true,
+		},
+	} {
+		tbl := itbl
+		t.Run(tbl.name, func(t *testing.T) {
+			got, err := Time(tbl.input, TimeLayouts(tbl.tag))
+			if tbl.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tbl.expected.Equal(got), "expected %s, got %s", tbl.expected, got)
+		})
+	}
+}
+
+func TestParseStringTime(t *testing.T) {
+	v, err := String("2020-01-02T03:04:05Z", timeType)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), *v.Interface().(*time.Time))
+}
@@ -0,0 +1,60 @@
+package parse
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// StringValue parses str into a value of type t (rather than a pointer to
+// one, as String may return), for sources whose values are strings
+// regardless of the type of the field they set. Types whose pointers
+// implement encoding.TextUnmarshaler are parsed by their UnmarshalText
+// method, and string types are converted without parsing.
+func StringValue(str string, t reflect.Type) (reflect.Value, error) {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		ptr := reflect.New(t)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr.Elem(), nil
+	}
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(str).Convert(t), nil
+	}
+	v, err := String(str, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v.Kind() == reflect.Ptr && t.Kind() != reflect.Ptr {
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// ConvertValue converts v to the type t, returning an error rather than
+// panicking if it's not convertible. Numbers are convertible to strings,
+// but produce a rune rather than a decimal representation, so only strings
+// are converted to string types.
+func ConvertValue(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if !v.Type().ConvertibleTo(t) ||
+		(t.Kind() == reflect.String && v.Kind() != reflect.String) {
+		return reflect.Value{}, fmt.Errorf("value of type %s is incompatible with type %s", v.Type(), t)
+	}
+	return v.Convert(t), nil
+}
+
+// SetField sets field, a field of a pointerified configuration type (and
+// so usually a pointer), to v, which has the type field points to (or the
+// type of field, if it's not a pointer).
+func SetField(field, v reflect.Value) {
+	if field.Kind() != reflect.Ptr {
+		field.Set(v)
+		return
+	}
+	ptr := reflect.New(field.Type().Elem())
+	ptr.Elem().Set(v)
+	field.Set(ptr)
+}
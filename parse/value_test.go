@@ -0,0 +1,56 @@
+package parse
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringValue(t *testing.T) {
+	type name string
+	v, err := StringValue("bob", reflect.TypeOf(name("")))
+	require.NoError(t, err)
+	assert.Equal(t, name("bob"), v.Interface())
+
+	v, err = StringValue("42", reflect.TypeOf(int16(0)))
+	require.NoError(t, err)
+	assert.Equal(t, int16(42), v.Interface())
+
+	// net.IP implements encoding.TextUnmarshaler
+	v, err = StringValue("10.0.0.1", reflect.TypeOf(net.IP{}))
+	require.NoError(t, err)
+	assert.Equal(t, net.IPv4(10, 0, 0, 1), v.Interface())
+
+	_, err = StringValue("not-an-ip", reflect.TypeOf(net.IP{}))
+	assert.Error(t, err)
+	_, err = StringValue("forty-two", reflect.TypeOf(0))
+	assert.Error(t, err)
+}
+
+func TestConvertValue(t *testing.T) {
+	type count int64
+	v, err := ConvertValue(reflect.ValueOf(3), reflect.TypeOf(count(0)))
+	require.NoError(t, err)
+	assert.Equal(t, count(3), v.Interface())
+
+	_, err = ConvertValue(reflect.ValueOf(65), reflect.TypeOf(""))
+	assert.EqualError(t, err, "value of type int is incompatible with type string")
+	_, err = ConvertValue(reflect.ValueOf(true), reflect.TypeOf(0))
+	assert.EqualError(t, err, "value of type bool is incompatible with type int")
+}
+
+func TestSetField(t *testing.T) {
+	var s struct {
+		Ptr *int
+		Val int
+	}
+	sv := reflect.ValueOf(&s).Elem()
+	SetField(sv.Field(0), reflect.ValueOf(1))
+	SetField(sv.Field(1), reflect.ValueOf(2))
+	require.NotNil(t, s.Ptr)
+	assert.Equal(t, 1, *s.Ptr)
+	assert.Equal(t, 2, s.Val)
+}
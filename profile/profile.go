@@ -0,0 +1,148 @@
+// Package profile lets one configuration artifact carry the variants for
+// several environments (e.g. dev, staging and prod). A config file can hold
+// profile-scoped sections, which Decoder composes over the rest of the file
+// for the selected profile:
+//
+//	timeout: 5s
+//	log_level: debug
+//	profiles:
+//	  prod:
+//	    timeout: 30s
+//	    log_level: info
+//
+// Alternatively, each profile's overrides can live in a file of their own
+// next to the base file (see Path). The profile is usually selected at
+// startup by a command-line flag or an environment variable (see Select).
+package profile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+)
+
+// DefaultSection is the key holding the profile sections if Decoder.Section
+// is empty.
+const DefaultSection = "profiles"
+
+// Decoder wraps another decoder, composing the section for Profile (if any)
+// over the rest of the decoded file, so fields set in the profile's section
+// override those set at the top level. The configuration struct must not
+// have a field named like the Section key.
+type Decoder struct {
+	// Inner decodes the file.
+	Inner dials.Decoder
+	// Profile is the selected profile. If it's empty, or the file has no
+	// section for it, the profile sections are ignored.
+	Profile string
+	// Section is the key holding the profile sections, keyed by profile
+	// name (DefaultSection if empty).
+	Section string
+}
+
+var _ dials.ContextDecoder = (*Decoder)(nil)
+
+// NewDecoder returns a Decoder composing the section for profile over the
+// rest of the files inner decodes.
+func NewDecoder(inner dials.Decoder, profile string) *Decoder {
+	return &Decoder{Inner: inner, Profile: profile}
+}
+
+// Decode decodes the file read from r with Inner, composing the section for
+// Profile over the rest of it.
+func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but passes ctx to the inner decoder (see
+// dials.ContextDecoder).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("error reading raw bytes: %w", err)
+	}
+	base, err := dials.Decode(ctx, d.Inner, bytes.NewReader(data), t)
+	if err != nil || d.Profile == "" {
+		return base, err
+	}
+
+	section := d.Section
+	if section == "" {
+		section = DefaultSection
+	}
+	// decode just the selected profile's section, as a struct of the
+	// configuration's type nested within it
+	selected := reflect.StructOf([]reflect.StructField{{
+		Name: "Selected",
+		Type: reflect.PtrTo(t.Type()),
+		Tag:  tag(d.Profile),
+	}})
+	sections := reflect.StructOf([]reflect.StructField{{
+		Name: "Profiles",
+		Type: reflect.PtrTo(selected),
+		Tag:  tag(section),
+	}})
+	sv, err := dials.Decode(ctx, d.Inner, bytes.NewReader(data), dials.NewType(sections))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode the section for profile %q: %w", d.Profile, err)
+	}
+	profileVal := sv.Field(0)
+	if profileVal.IsNil() || profileVal.Elem().Field(0).IsNil() {
+		return base, nil
+	}
+	return dials.ComposeValues(t, base, profileVal.Elem().Field(0))
+}
+
+func tag(name string) reflect.StructTag {
+	return reflect.StructTag(fmt.Sprintf("%s:%q", common.DialsTagName, name))
+}
+
+// Path returns the path of the file holding profile's overrides for the file
+// at path: the profile's name is inserted before the extension, so the
+// overrides for config.yaml in the prod profile are in config.prod.yaml.
+func Path(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// Select returns the profile named by the flag flagName in args (typically
+// os.Args[1:]), or, if it isn't there, by the environment variable envVar.
+// The flag may be given in any of the forms the flag package accepts (e.g.
+// "-profile prod" or "--profile=prod"), and args are scanned up to a "--"
+// argument. Select doesn't register the flag, so it must also be defined on
+// the FlagSet that parses args (e.g. with flag.String) if one does.
+func Select(args []string, flagName, envVar string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		name, value, hasValue := strings.Cut(name, "=")
+		if name != flagName {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+		break
+	}
+	if envVar == "" {
+		return ""
+	}
+	return os.Getenv(envVar)
+}
@@ -0,0 +1,106 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/toml"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type config struct {
+	Timeout  time.Duration `dials:"timeout"`
+	LogLevel string        `dials:"log_level"`
+	DB       struct {
+		Host string `dials:"host"`
+		Port int    `dials:"port"`
+	} `dials:"db"`
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+	for name, tbl := range map[string]struct {
+		data string
+		dec  dials.Decoder
+	}{
+		"yaml": {dec: &yaml.Decoder{}, data: `
+timeout: 5s
+log_level: debug
+db: {host: localhost, port: 5432}
+profiles:
+  prod:
+    timeout: 30s
+    db: {host: db.prod}
+  staging:
+    log_level: info
+`},
+		"json": {dec: &json.Decoder{}, data: `{
+			"timeout": "5s", "log_level": "debug", "db": {"host": "localhost", "port": 5432},
+			"profiles": {"prod": {"timeout": "30s", "db": {"host": "db.prod"}}, "staging": {"log_level": "info"}}
+		}`},
+		"toml": {dec: &toml.Decoder{}, data: `
+timeout = "5s"
+log_level = "debug"
+[db]
+host = "localhost"
+port = 5432
+[profiles.prod]
+timeout = "30s"
+[profiles.prod.db]
+host = "db.prod"
+[profiles.staging]
+log_level = "info"
+`},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			for profile, expected := range map[string]func(*config){
+				"":        func(*config) {},
+				"prod":    func(c *config) { c.Timeout, c.DB.Host = 30*time.Second, "db.prod" },
+				"staging": func(c *config) { c.LogLevel = "info" },
+				"dev":     func(*config) {},
+			} {
+				want := &config{Timeout: 5 * time.Second, LogLevel: "debug"}
+				want.DB.Host, want.DB.Port = "localhost", 5432
+				expected(want)
+
+				d, err := dials.Config(ctx, &config{}, &static.StringSource{Data: tbl.data, Decoder: NewDecoder(tbl.dec, profile)})
+				require.NoError(t, err, profile)
+				assert.Equal(t, want, d.View(), profile)
+			}
+		})
+	}
+}
+
+func TestDecoderSection(t *testing.T) {
+	t.Parallel()
+	dec := &Decoder{Inner: &yaml.Decoder{}, Profile: "prod", Section: "environments"}
+	d, err := dials.Config(context.Background(), &config{}, &static.StringSource{
+		Data:    "log_level: debug\nenvironments: {prod: {log_level: warn}}\n",
+		Decoder: dec,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "warn", d.View().LogLevel)
+}
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "/etc/app/config.prod.yaml", Path("/etc/app/config.yaml", "prod"))
+	assert.Equal(t, "config.dev", Path("config", "dev"))
+}
+
+func TestSelect(t *testing.T) {
+	t.Setenv("TEST_PROFILE", "staging")
+	assert.Equal(t, "prod", Select([]string{"-port", "80", "--profile=prod"}, "profile", "TEST_PROFILE"))
+	assert.Equal(t, "dev", Select([]string{"-profile", "dev", "serve"}, "profile", "TEST_PROFILE"))
+	assert.Equal(t, "staging", Select([]string{"serve", "--", "--profile=prod"}, "profile", "TEST_PROFILE"))
+	assert.Equal(t, "", Select(nil, "profile", ""))
+}
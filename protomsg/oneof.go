@@ -0,0 +1,205 @@
+package protomsg
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// findOneofs records the members of the oneofs of the messages reachable from
+// the type t.
+func (m *Mangler) findOneofs(t reflect.Type, seen map[reflect.Type]struct{}) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || ptrify.IsScalarStruct(t) {
+		return nil
+	}
+	if _, ok := seen[t]; ok {
+		return nil
+	}
+	seen[t] = struct{}{}
+
+	var oneofFields []int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		switch {
+		case !sf.IsExported():
+		case ptrify.IsProtoOneof(sf):
+			oneofFields = append(oneofFields, i)
+		default:
+			if err := m.findOneofs(sf.Type, seen); err != nil {
+				return err
+			}
+		}
+	}
+	if len(oneofFields) == 0 {
+		return nil
+	}
+
+	members, err := oneofMembers(t, oneofFields)
+	if err != nil {
+		return fmt.Errorf("failed to find the members of the oneofs of %s: %w", t, err)
+	}
+	for _, idx := range oneofFields {
+		iface := t.Field(idx).Type
+		m.oneofs[iface] = members[iface]
+		for _, member := range members[iface] {
+			if err := m.findOneofs(member.Elem().Field(0).Type, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// oneofMembers returns the (pointer) types of the members of the oneofs of the
+// message type t, keyed by the types of the oneof fields (whose indices are in
+// oneofFields).
+func oneofMembers(t reflect.Type, oneofFields []int) (map[reflect.Type][]reflect.Type, error) {
+	members := make(map[reflect.Type][]reflect.Type, len(oneofFields))
+	add := func(member reflect.Type) {
+		for _, idx := range oneofFields {
+			iface := t.Field(idx).Type
+			if !member.Implements(iface) {
+				continue
+			}
+			for _, existing := range members[iface] {
+				if existing == member {
+					return
+				}
+			}
+			members[iface] = append(members[iface], member)
+			return
+		}
+	}
+
+	msg := reflect.New(t)
+	if wrappers := msg.MethodByName("XXX_OneofWrappers"); wrappers.IsValid() {
+		ws, ok := wrappers.Call(nil)[0].Interface().([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("XXX_OneofWrappers returned %s, not []interface{}", wrappers.Type().Out(0))
+		}
+		for _, w := range ws {
+			add(reflect.TypeOf(w))
+		}
+		return members, nil
+	}
+	if !msg.MethodByName("ProtoReflect").IsValid() {
+		return nil, fmt.Errorf("%s has oneof fields, but neither an XXX_OneofWrappers nor a ProtoReflect method", t)
+	}
+	err := reflectOneofFields(msg, func() {
+		for _, idx := range oneofFields {
+			if f := msg.Elem().Field(idx); !f.IsNil() {
+				add(f.Elem().Type())
+			}
+		}
+	})
+	return members, err
+}
+
+// reflectOneofFields sets each of the fields of the oneofs of msg (a pointer to
+// a generated message) in turn through its ProtoReflect method, calling
+// inspect while each is set. The methods are called by name, so that this
+// package doesn't depend on the protoreflect package.
+func reflectOneofFields(msg reflect.Value, inspect func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("protoreflect call panicked: %v", r)
+		}
+	}()
+	call := func(v reflect.Value, method string, args ...reflect.Value) reflect.Value {
+		return v.MethodByName(method).Call(args)[0]
+	}
+
+	pr := call(msg, "ProtoReflect")
+	oneofs := call(call(pr, "Descriptor"), "Oneofs")
+	for i := 0; i < int(call(oneofs, "Len").Int()); i++ {
+		fields := call(call(oneofs, "Get", reflect.ValueOf(i)), "Fields")
+		for j := 0; j < int(call(fields, "Len").Int()); j++ {
+			fd := call(fields, "Get", reflect.ValueOf(j))
+			pr.MethodByName("Set").Call([]reflect.Value{fd, call(pr, "NewField", fd)})
+			inspect()
+			pr.MethodByName("Clear").Call([]reflect.Value{fd})
+		}
+	}
+	return nil
+}
+
+// isSet reports whether v, the value of a pointerified oneof member, was set
+// by a source. Some sources (e.g. env) allocate every nested struct, so
+// members holding messages are only set if any of their fields are.
+func isSet(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+		if v.Elem().Kind() == reflect.Struct && !ptrify.IsScalarStruct(v.Elem().Type()) {
+			return isSet(v.Elem())
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if isSet(v.Field(i)) {
+				return true
+			}
+		}
+		return false
+	case reflect.Map, reflect.Slice, reflect.Interface:
+		return !v.IsNil()
+	default:
+		return !v.IsZero()
+	}
+}
+
+// assign sets dst, a field of a generated message, to src, the corresponding
+// field of the pointerified message, leaving dst unset if src is nil.
+func assign(dst, src reflect.Value) error {
+	switch src.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if src.IsNil() {
+			return nil
+		}
+	}
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case src.Kind() == reflect.Ptr && src.Type().Elem().AssignableTo(dst.Type()):
+		dst.Set(src.Elem())
+	case dst.Kind() == reflect.Ptr:
+		v := reflect.New(dst.Type().Elem())
+		if err := assign(v.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(v)
+	case src.Kind() == reflect.Ptr:
+		return assign(dst, src.Elem())
+	case src.Kind() == reflect.Struct && dst.Kind() == reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			name := src.Type().Field(i).Name
+			df := dst.FieldByName(name)
+			if !df.IsValid() {
+				return fmt.Errorf("field %q not found in %s", name, dst.Type())
+			}
+			if err := assign(df, src.Field(i)); err != nil {
+				return fmt.Errorf("field %q: %w", name, err)
+			}
+		}
+	case src.Kind() == reflect.Map && dst.Kind() == reflect.Map:
+		out := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(elem, iter.Value()); err != nil {
+				return fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		dst.Set(out)
+	default:
+		return fmt.Errorf("unable to assign %s to %s", src.Type(), dst.Type())
+	}
+	return nil
+}
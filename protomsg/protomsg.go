@@ -0,0 +1,171 @@
+// Package protomsg lets a message generated by protoc-gen-go be used as a
+// dials config type, for programs whose config schema is defined in a .proto
+// file.
+//
+// Most of a generated message needs no special handling: its internal fields
+// (state, sizeCache and unknownFields) are unexported, so dials ignores them,
+// and the well-known types durationpb.Duration, timestamppb.Timestamp and
+// the wrapperspb types are treated as scalars by every source (see
+// parse.RegisterType). Wrapping sources with Source (and decoders with
+// Decoder) handles the rest:
+//   - fields are named by the names in their .proto file (e.g. listen_addr),
+//     rather than their Go names
+//   - the members of oneofs can be set, with the fields of a oneof read as if
+//     they were fields of the message containing it, as in protobuf's JSON
+//     mapping
+//
+// A oneof set by a source replaces its value from lower-precedence sources
+// (and the template) as a whole, rather than being merged with it, so a
+// higher-precedence source can switch it to another member.
+//
+// This package doesn't depend on google.golang.org/protobuf; messages are
+// recognized by the tags and methods protoc-gen-go generates.
+package protomsg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/sourcewrap"
+	"github.com/vimeo/dials/transform"
+)
+
+// ProtobufTagName is the name of the tag protoc-gen-go puts on the fields of
+// messages.
+const ProtobufTagName = "protobuf"
+
+// Mangler implements the transform.Mangler interface for (pointerified)
+// generated messages:
+//   - a field with a `protobuf` tag, but no `dials` tag, gets a `dials` tag
+//     with its name from the .proto file
+//   - a oneof field is replaced by a field for each of the oneof's members,
+//     at most one of which may be set
+//
+// Construct one with NewMangler.
+type Mangler struct {
+	// oneofs maps the interface types of the oneof fields of the config
+	// type's messages to the (pointer) types of the oneofs' members.
+	oneofs map[reflect.Type][]reflect.Type
+	err    error
+}
+
+// NewMangler returns a Mangler for the config type T, which should be a
+// generated message, or a struct containing them. The members of T's oneofs
+// are found with the XXX_OneofWrappers methods generated by older versions of
+// protoc-gen-go, or with the messages' ProtoReflect methods.
+func NewMangler[T any]() *Mangler {
+	m := &Mangler{oneofs: map[reflect.Type][]reflect.Type{}}
+	m.err = m.findOneofs(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]struct{}{})
+	return m
+}
+
+// Mangle adds the `dials` tag derived from the field's `protobuf` tag, or
+// replaces a oneof field with fields for its members.
+func (m *Mangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if !ptrify.IsProtoOneof(sf) {
+		return []reflect.StructField{withDialsName(sf)}, nil
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	members, ok := m.oneofs[sf.Type]
+	if !ok {
+		return nil, fmt.Errorf("no members found for oneof %q (field %s)",
+			sf.Tag.Get(ptrify.ProtobufOneofTagName), sf.Name)
+	}
+	out := make([]reflect.StructField, len(members))
+	for i, member := range members {
+		// a member is a struct with a single field, which is pointerified
+		// like any other
+		out[i] = withDialsName(ptrify.Pointerify(member.Elem(), reflect.Value{}).Field(0))
+	}
+	return out, nil
+}
+
+// Unmangle returns the value of the field, or, for a oneof, the member that's
+// set.
+func (m *Mangler) Unmangle(sf reflect.StructField, vs []transform.FieldValueTuple) (reflect.Value, error) {
+	if !ptrify.IsProtoOneof(sf) {
+		// we return exactly one field from Mangle, so we can always
+		// return vs[0].Value (after a type conversion)
+		if vs[0].Value.Kind() == reflect.Struct {
+			return vs[0].Value.Convert(sf.Type), nil
+		}
+		return vs[0].Value, nil
+	}
+
+	out := reflect.New(sf.Type).Elem()
+	setIdx := -1
+	for i, fv := range vs {
+		if !isSet(fv.Value) {
+			continue
+		}
+		if setIdx >= 0 {
+			return reflect.Value{}, fmt.Errorf("oneof %q has more than one member set: %s and %s",
+				sf.Tag.Get(ptrify.ProtobufOneofTagName), vs[setIdx].Field.Name, fv.Field.Name)
+		}
+		setIdx = i
+	}
+	if setIdx < 0 {
+		return out, nil
+	}
+	member := reflect.New(m.oneofs[sf.Type][setIdx].Elem())
+	if err := assign(member.Elem().Field(0), vs[setIdx].Value); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to set oneof member %s: %w", vs[setIdx].Field.Name, err)
+	}
+	out.Set(member)
+	return out, nil
+}
+
+// ShouldRecurse always returns true so the fields of nested messages are
+// mangled too.
+func (m *Mangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+// withDialsName returns sf with a `dials` tag naming it by its protobuf name,
+// unless it already has one, or has no `protobuf` tag.
+func withDialsName(sf reflect.StructField) reflect.StructField {
+	if _, ok := sf.Tag.Lookup(common.DialsTagName); ok {
+		return sf
+	}
+	name := protoName(sf.Tag.Get(ProtobufTagName))
+	if name == "" {
+		return sf
+	}
+	newTags := string(sf.Tag)
+	if len(newTags) > 0 {
+		newTags += " "
+	}
+	sf.Tag = reflect.StructTag(newTags + common.DialsTagName + ":" + strconv.Quote(name))
+	return sf
+}
+
+// protoName returns the field name from the value of a `protobuf` tag (e.g.
+// "bytes,1,opt,name=listen_addr,json=listenAddr,proto3").
+func protoName(tagVal string) string {
+	for _, opt := range strings.Split(tagVal, ",") {
+		if key, name, ok := strings.Cut(opt, "="); ok && key == "name" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Source wraps inner so it reads the generated message T (or a struct
+// containing generated messages) as described in the package documentation.
+func Source[T any](inner dials.Source) dials.Source {
+	return sourcewrap.NewTransformingSource(inner, NewMangler[T]())
+}
+
+// Decoder wraps dec so it decodes the generated message T (or a struct
+// containing generated messages) as described in the package documentation,
+// e.g. for use with the file source.
+func Decoder[T any](dec dials.Decoder) dials.Decoder {
+	return sourcewrap.NewTransformingDecoder(dec, NewMangler[T]())
+}
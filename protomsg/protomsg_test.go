@@ -0,0 +1,249 @@
+package protomsg
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/static"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The types below mimic the code protoc-gen-go generates for:
+//
+//	message ServerConfig {
+//	  string listen_addr = 1;
+//	  int32 max_conns = 2;
+//	  TLSConfig tls = 3;
+//	  oneof backend {
+//	    RedisConfig redis = 4;
+//	    string in_memory_name = 5;
+//	  }
+//	}
+//
+//	message TLSConfig {
+//	  oneof source {
+//	    string cert_file = 1;
+//	    AcmeConfig acme = 2;
+//	  }
+//	}
+//
+// ServerConfig's oneof members are listed by an XXX_OneofWrappers method (as
+// generated by older versions of protoc-gen-go), and TLSConfig's are found
+// through a fake of its ProtoReflect method.
+
+type messageState struct {
+	noCopy            [0]sync.Mutex
+	atomicMessageInfo *int
+}
+
+type ServerConfig struct {
+	state         messageState
+	sizeCache     int32
+	unknownFields []byte
+
+	ListenAddr string     `protobuf:"bytes,1,opt,name=listen_addr,json=listenAddr,proto3" json:"listen_addr,omitempty"`
+	MaxConns   int32      `protobuf:"varint,2,opt,name=max_conns,json=maxConns,proto3" json:"max_conns,omitempty"`
+	Tls        *TLSConfig `protobuf:"bytes,3,opt,name=tls,proto3" json:"tls,omitempty"`
+	// Types that are assignable to Backend:
+	//
+	//	*ServerConfig_Redis
+	//	*ServerConfig_InMemoryName
+	Backend isServerConfig_Backend `protobuf_oneof:"backend"`
+}
+
+type isServerConfig_Backend interface {
+	isServerConfig_Backend()
+}
+
+type ServerConfig_Redis struct {
+	Redis *RedisConfig `protobuf:"bytes,4,opt,name=redis,proto3,oneof"`
+}
+
+type ServerConfig_InMemoryName struct {
+	InMemoryName string `protobuf:"bytes,5,opt,name=in_memory_name,json=inMemoryName,proto3,oneof"`
+}
+
+func (*ServerConfig_Redis) isServerConfig_Backend() {}
+
+func (*ServerConfig_InMemoryName) isServerConfig_Backend() {}
+
+func (*ServerConfig) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*ServerConfig_Redis)(nil),
+		(*ServerConfig_InMemoryName)(nil),
+	}
+}
+
+type RedisConfig struct {
+	state         messageState
+	sizeCache     int32
+	unknownFields []byte
+
+	Addr     string `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	PoolSize int32  `protobuf:"varint,2,opt,name=pool_size,json=poolSize,proto3" json:"pool_size,omitempty"`
+}
+
+type TLSConfig struct {
+	state         messageState
+	sizeCache     int32
+	unknownFields []byte
+
+	// Types that are assignable to Source:
+	//
+	//	*TLSConfig_CertFile
+	//	*TLSConfig_Acme
+	Source isTLSConfig_Source `protobuf_oneof:"source"`
+}
+
+type isTLSConfig_Source interface {
+	isTLSConfig_Source()
+}
+
+type TLSConfig_CertFile struct {
+	CertFile string `protobuf:"bytes,1,opt,name=cert_file,json=certFile,proto3,oneof"`
+}
+
+type TLSConfig_Acme struct {
+	Acme *AcmeConfig `protobuf:"bytes,2,opt,name=acme,proto3,oneof"`
+}
+
+func (*TLSConfig_CertFile) isTLSConfig_Source() {}
+
+func (*TLSConfig_Acme) isTLSConfig_Source() {}
+
+type AcmeConfig struct {
+	state         messageState
+	sizeCache     int32
+	unknownFields []byte
+
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+}
+
+// fakeMessage and the types it returns implement the parts of the
+// protoreflect interfaces that are used to find the members of oneofs.
+type fakeMessage struct {
+	x *TLSConfig
+}
+
+type fakeDescriptor struct{}
+
+type fakeOneofs struct{}
+
+type fakeOneof struct{}
+
+type fakeFields []fakeField
+
+type fakeField string
+
+func (x *TLSConfig) ProtoReflect() fakeMessage { return fakeMessage{x: x} }
+
+func (fakeMessage) Descriptor() fakeDescriptor { return fakeDescriptor{} }
+
+func (fakeDescriptor) Oneofs() fakeOneofs { return fakeOneofs{} }
+
+func (fakeOneofs) Len() int { return 1 }
+
+func (fakeOneofs) Get(int) fakeOneof { return fakeOneof{} }
+
+func (fakeOneof) Fields() fakeFields { return fakeFields{"cert_file", "acme"} }
+
+func (f fakeFields) Len() int { return len(f) }
+
+func (f fakeFields) Get(i int) fakeField { return f[i] }
+
+func (fakeMessage) NewField(fakeField) int { return 0 }
+
+func (m fakeMessage) Set(fd fakeField, _ int) {
+	switch fd {
+	case "cert_file":
+		m.x.Source = &TLSConfig_CertFile{}
+	case "acme":
+		m.x.Source = &TLSConfig_Acme{Acme: &AcmeConfig{}}
+	}
+}
+
+func (m fakeMessage) Clear(fakeField) { m.x.Source = nil }
+
+func TestNewManglerFindsOneofs(t *testing.T) {
+	m := NewMangler[ServerConfig]()
+	require.NoError(t, m.err)
+	assert.Len(t, m.oneofs, 2)
+}
+
+func TestMessageFromYAMLAndEnv(t *testing.T) {
+	ctx := context.Background()
+	file := &static.StringSource{
+		Data: `
+listen_addr: ":8080"
+max_conns: 100
+redis:
+  addr: "redis:6379"
+  pool_size: 4
+tls:
+  acme:
+    domain: example.com
+`,
+		Decoder: Decoder[ServerConfig](&yaml.Decoder{}),
+	}
+	envSrc := Source[ServerConfig](&env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"MAX_CONNS": "200",
+	})})
+
+	d, err := dials.Config(ctx, &ServerConfig{}, file, envSrc)
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, ":8080", cfg.ListenAddr)
+	assert.EqualValues(t, 200, cfg.MaxConns)
+	require.IsType(t, &ServerConfig_Redis{}, cfg.Backend)
+	redis := cfg.Backend.(*ServerConfig_Redis).Redis
+	assert.Equal(t, "redis:6379", redis.Addr)
+	assert.EqualValues(t, 4, redis.PoolSize)
+	require.NotNil(t, cfg.Tls)
+	require.IsType(t, &TLSConfig_Acme{}, cfg.Tls.Source)
+	assert.Equal(t, "example.com", cfg.Tls.Source.(*TLSConfig_Acme).Acme.Domain)
+}
+
+func TestOneofMemberOverridesDefault(t *testing.T) {
+	ctx := context.Background()
+	defaults := &ServerConfig{Backend: &ServerConfig_Redis{Redis: &RedisConfig{Addr: "localhost:6379"}}}
+	envSrc := Source[ServerConfig](&env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"IN_MEMORY_NAME": "cache",
+		"TLS_CERT_FILE":  "/etc/tls.pem",
+	})})
+
+	d, err := dials.Config(ctx, defaults, envSrc)
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, &ServerConfig_InMemoryName{InMemoryName: "cache"}, cfg.Backend)
+	require.NotNil(t, cfg.Tls)
+	assert.Equal(t, &TLSConfig_CertFile{CertFile: "/etc/tls.pem"}, cfg.Tls.Source)
+}
+
+func TestOneofReplacedAsAWhole(t *testing.T) {
+	ctx := context.Background()
+	defaults := &ServerConfig{Backend: &ServerConfig_Redis{Redis: &RedisConfig{Addr: "localhost:6379", PoolSize: 4}}}
+	envSrc := Source[ServerConfig](&env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"REDIS_ADDR": "redis:6379",
+	})})
+
+	d, err := dials.Config(ctx, defaults, envSrc)
+	require.NoError(t, err)
+	assert.Equal(t, &ServerConfig_Redis{Redis: &RedisConfig{Addr: "redis:6379"}}, d.View().Backend)
+}
+
+func TestOneofWithSeveralMembersSet(t *testing.T) {
+	ctx := context.Background()
+	file := &static.StringSource{
+		Data:    "redis: {addr: \"redis:6379\"}\nin_memory_name: cache\n",
+		Decoder: Decoder[ServerConfig](&yaml.Decoder{}),
+	}
+	_, err := dials.Config(ctx, &ServerConfig{}, file)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `oneof "backend" has more than one member set`)
+}
@@ -0,0 +1,103 @@
+package dials
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Provenance returns the source that supplied the value of each field in the
+// current configuration, keyed by the path of Go field names joined with dots
+// (e.g. "Database.Port"). Only leaf fields are included; nested structs are
+// described by their fields. Fields that weren't set by any source (so they
+// have the default value from the template passed to Config) are omitted.
+//
+// If several sources set a field, the highest-precedence (last) one is
+// reported, even for slice fields whose values are appended (see
+// [SliceMergeTag]). Every field of a nested struct that's replaced as a whole
+// (see SliceMergeTag) is attributed to the source that replaced it, as the
+// fields it doesn't set are zeroed.
+func (d *Dials[T]) Provenance() map[string]Source {
+	return provenance(d.loadVersion().sources)
+}
+
+// snapshotSources returns a copy of sources, which the monitor goroutine
+// updates in place.
+func snapshotSources(sources []sourceValue) []sourceValue {
+	return append([]sourceValue(nil), sources...)
+}
+
+// provenance returns the highest-precedence source that set each of the
+// leaf fields in sources' values, keyed by their dot-joined paths.
+func provenance(sources []sourceValue) map[string]Source {
+	prov := map[string]Source{}
+	for _, sv := range sources {
+		if sv.disabled {
+			continue
+		}
+		v := sv.value
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		recordProvenance(prov, nil, v, sv.source)
+	}
+	return prov
+}
+
+// recordProvenance records s as the source of every field that's set in the
+// (pointerified) struct v, whose fields are at path.
+func recordProvenance(prov map[string]Source, path []string, v reflect.Value, s Source) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		f := v.Field(i)
+		if kindNilable(f.Kind()) && f.IsNil() {
+			continue
+		}
+		st := f.Type()
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if st.Kind() == reflect.Struct && !ptrify.IsScalarStruct(st) {
+			if replace, _ := fieldStructReplace(sf); replace && anySet(f) {
+				recordLeaves(prov, fieldPath, st, s)
+				continue
+			}
+			recordProvenance(prov, fieldPath, reflect.Indirect(f), s)
+			continue
+		}
+		prov[strings.Join(fieldPath, ".")] = s
+	}
+}
+
+// recordLeaves records s as the source of every leaf field of the
+// (pointerified) struct type t, whose fields are at path.
+func recordLeaves(prov map[string]Source, path []string, t reflect.Type, s Source) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], sf.Name)
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if st.Kind() == reflect.Struct && !ptrify.IsScalarStruct(st) {
+			recordLeaves(prov, fieldPath, st, s)
+			continue
+		}
+		prov[strings.Join(fieldPath, ".")] = s
+	}
+}
@@ -0,0 +1,71 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		Port int
+		Host string
+	}
+	type testConfig struct {
+		Name      string
+		Inner     inner
+		Deadline  time.Time
+		Untouched int
+	}
+	// nested structs are pointerified to unnamed types
+	type ptrifiedInner = struct {
+		Port *int
+		Host *string
+	}
+	type ptrifiedConfig struct {
+		Name      *string
+		Inner     *ptrifiedInner
+		Deadline  *time.Time
+		Untouched *int
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	name, host, port := "name", "host", 80
+	deadline := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := &fakeSource{outVal: ptrifiedConfig{
+		Name:  &name,
+		Inner: &ptrifiedInner{Port: &port, Host: &host},
+	}}
+	second := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{
+		Inner:    &ptrifiedInner{Port: &port},
+		Deadline: &deadline,
+	}}}
+	d, err := Config(ctx, &testConfig{}, first, second)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]Source{
+		"Name":       first,
+		"Inner.Port": second,
+		"Inner.Host": first,
+		"Deadline":   second,
+	}, d.Provenance())
+
+	// the returned map is a copy
+	d.Provenance()["Untouched"] = first
+	assert.NotContains(t, d.Provenance(), "Untouched")
+
+	// a new value from the watching source updates the provenance
+	second.send(ctx, reflect.ValueOf(ptrifiedConfig{Name: &name}))
+	<-d.Events()
+	assert.Equal(t, map[string]Source{
+		"Name":       second,
+		"Inner.Port": first,
+		"Inner.Host": first,
+	}, d.Provenance())
+}
@@ -0,0 +1,96 @@
+package ptrify
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CycleError is returned by CheckCycles for config types that contain
+// themselves (through pointers, slices, arrays or maps), which can't be
+// pointerified, as the pointerified type would need to refer to itself.
+type CycleError struct {
+	// Type is the struct type that contains itself
+	Type reflect.Type
+	// Path holds the names of the fields leading from Type back to itself
+	Path []string
+	// Prefix holds the names of the fields leading from the root config
+	// type to Type (empty if Type is the root config type)
+	Prefix []string
+}
+
+func (c *CycleError) Error() string {
+	at := ""
+	if len(c.Prefix) > 0 {
+		at = " at " + strings.Join(c.Prefix, ".")
+	}
+	return fmt.Sprintf("recursive config type: field path %s of type %s%s leads back to %s; "+
+		"tag one of those fields with `dials:\"-\"` to ignore it",
+		strings.Join(c.Path, "."), c.Type, at, c.Type)
+}
+
+// CheckCycles returns a *CycleError if the struct type t contains itself, or
+// contains a struct type that contains itself, in any of the fields that
+// Pointerify recurses into (fields ignored with `dials:"-"`, unexported
+// fields and scalar structs aren't followed). Pointerify doesn't terminate
+// for such types, so it should be called first with types that aren't known
+// to be acyclic.
+func CheckCycles(t reflect.Type) error {
+	c := cycleChecker{checked: map[reflect.Type]struct{}{}, inProgress: map[reflect.Type]int{}}
+	return c.check(t)
+}
+
+type cycleChecker struct {
+	// checked holds the struct types known not to contain themselves
+	checked map[reflect.Type]struct{}
+	// inProgress maps the struct types being checked to the length of
+	// path where they were entered
+	inProgress map[reflect.Type]int
+	path       []string
+}
+
+func (c *cycleChecker) check(t reflect.Type) error {
+	for isContainer(t.Kind()) {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if _, ok := c.checked[t]; ok || IsScalarStruct(t) {
+		return nil
+	}
+	if start, ok := c.inProgress[t]; ok {
+		return &CycleError{
+			Type:   t,
+			Path:   append([]string(nil), c.path[start:]...),
+			Prefix: append([]string(nil), c.path[:start]...),
+		}
+	}
+	c.inProgress[t] = len(c.path)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if OmitField(sf) {
+			continue
+		}
+		c.path = append(c.path, sf.Name)
+		err := c.check(sf.Type)
+		c.path = c.path[:len(c.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	delete(c.inProgress, t)
+	c.checked[t] = struct{}{}
+	return nil
+}
+
+// isContainer indicates whether values of kind k contain values of their
+// type's element type.
+func isContainer(k reflect.Kind) bool {
+	switch k {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
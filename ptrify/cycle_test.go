@@ -0,0 +1,102 @@
+package ptrify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listNode struct {
+	Val  int
+	Next *listNode
+}
+
+type treeNode struct {
+	Name     string
+	Children []treeNode
+}
+
+type graphNode struct {
+	Edges map[string]*graphEdge
+}
+
+type graphEdge struct {
+	Weight float64
+	To     graphNode
+}
+
+type ignoredCycle struct {
+	Name   string
+	Parent *ignoredCycle `dials:"-"`
+	next   *ignoredCycle
+}
+
+func TestCheckCycles(t *testing.T) {
+	t.Parallel()
+	type shared struct{ A int }
+	for name, tbl := range map[string]struct {
+		i      interface{}
+		path   []string
+		prefix []string
+		typ    reflect.Type
+	}{
+		"acyclic": {
+			i: struct {
+				A, B shared
+				C    *shared
+				D    []shared
+				E    map[string]shared
+				T    time.Time
+			}{},
+		},
+		"pointer": {
+			i:    listNode{},
+			path: []string{"Next"},
+			typ:  reflect.TypeOf(listNode{}),
+		},
+		"slice": {
+			i:    treeNode{},
+			path: []string{"Children"},
+			typ:  reflect.TypeOf(treeNode{}),
+		},
+		"nested_map": {
+			i: struct {
+				Name  string
+				Graph *graphNode
+			}{},
+			path:   []string{"Edges", "To"},
+			prefix: []string{"Graph"},
+			typ:    reflect.TypeOf(graphNode{}),
+		},
+		"ignored": {
+			i: ignoredCycle{},
+		},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckCycles(reflect.TypeOf(tbl.i))
+			if tbl.typ == nil {
+				assert.NoError(t, err)
+				return
+			}
+			cycleErr := &CycleError{}
+			require.True(t, errors.As(err, &cycleErr), "unexpected error: %v", err)
+			assert.Equal(t, tbl.typ, cycleErr.Type)
+			assert.Equal(t, tbl.path, cycleErr.Path)
+			assert.Equal(t, tbl.prefix, cycleErr.Prefix)
+		})
+	}
+}
+
+func TestCycleErrorMessage(t *testing.T) {
+	t.Parallel()
+	err := CheckCycles(reflect.TypeOf(struct{ Graph graphNode }{}))
+	require.Error(t, err)
+	assert.Equal(t, "recursive config type: field path Edges.To of type ptrify.graphNode at Graph "+
+		"leads back to ptrify.graphNode; tag one of those fields with `dials:\"-\"` to ignore it", err.Error())
+}
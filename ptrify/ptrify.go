@@ -2,10 +2,15 @@ package ptrify
 
 import (
 	"encoding"
+	"encoding/json"
+	"flag"
 	"go/ast"
+	"net"
+	"net/url"
 	"reflect"
 
 	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
 )
 
 // Note: this looks weird because it is, you need to call TypeOf on a nil
@@ -13,6 +18,36 @@ import (
 // that's not useful (it actually generates a panic when it's used further down).
 var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 
+var flagValue = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// stringScalarStructs are the standard library struct types that don't
+// implement encoding.TextUnmarshaler, but which dials parses from strings
+// (see parse.String).
+var stringScalarStructs = map[reflect.Type]struct{}{
+	reflect.TypeOf(url.URL{}):   {},
+	reflect.TypeOf(net.IPNet{}): {},
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// yaml.v3's Node type is identified by name, so ptrify doesn't depend on
+// yaml.v3.
+const (
+	yamlNodePkgPath = "gopkg.in/yaml.v3"
+	yamlNodeName    = "Node"
+)
+
+// deferred.Section is identified by name, since the deferred package depends
+// on dials.
+const (
+	deferredSectionPkgPath = "github.com/vimeo/dials/deferred"
+	deferredSectionName    = "Section"
+)
+
+// ProtobufOneofTagName is the name of the tag protoc-gen-go puts on the
+// fields holding oneofs.
+const ProtobufOneofTagName = "protobuf_oneof"
+
 // Pointerify takes a type and returns another type with all its members
 // set to pointers of their respective types
 func Pointerify(original reflect.Type, tmpl reflect.Value) reflect.Type {
@@ -68,7 +103,19 @@ func pointerifyField(originalField reflect.StructField, tmplFieldVal reflect.Val
 		Anonymous: originalField.Anonymous,
 	}
 	switch ft.Kind() {
-	case reflect.Map, reflect.Slice:
+	case reflect.Map:
+		// Maps with struct values get pointerified values, so
+		// sources can set individual fields of an entry (see
+		// StructMapElem).
+		if elem, ok := StructMapElem(ft); ok {
+			newSF := originalField
+			newSF.Type = reflect.MapOf(ft.Key(), reflect.PtrTo(Pointerify(elem, reflect.Value{})))
+			return &newSF
+		}
+		// Other maps are already nil-able, so use the field rather
+		// than the pointer-ized field.
+		return &originalField
+	case reflect.Slice:
 		// These are already nil-able, so use the field rather
 		// than the pointer-ized field.
 		return &originalField
@@ -76,7 +123,9 @@ func pointerifyField(originalField reflect.StructField, tmplFieldVal reflect.Val
 		// check whether the field is nil in the
 		// template/default struct. (we'll devirtualize down to
 		// the concrete type if it is there).
-		if !tmplFieldVal.IsValid() || tmplFieldVal.IsNil() {
+		// Protobuf oneofs are left alone, since sources may set a
+		// different member than the default's.
+		if !tmplFieldVal.IsValid() || tmplFieldVal.IsNil() || IsProtoOneof(originalField) {
 			// If it's nil, we have to preserve the original field
 			// as-is.
 			return &originalField
@@ -104,29 +153,43 @@ func pointerifyField(originalField reflect.StructField, tmplFieldVal reflect.Val
 		}
 		return &originalField
 	case reflect.Ptr:
-		// These are already pointers or nil-able, so use the
-		// field rather than the pointer-ized field.
-		if ft.Elem().Kind() != reflect.Struct {
+		// Pointers to pointers (e.g. a *T field of a generic type
+		// instantiated with a pointer type) are pointerified as a
+		// single pointer to the innermost type, which is all sources
+		// need to distinguish unset fields.
+		elem := ft.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			if ft.Elem().Kind() == reflect.Ptr {
+				sf.Type = reflect.PtrTo(elem)
+				return &sf
+			}
+			// These are already pointers or nil-able, so use the
+			// field rather than the pointer-ized field.
 			return &originalField
 		}
 		// it's a pointer to a struct, so we need to
 		// pointerize the pointee, just update the type and
 		// fallthrough
-		sf.Type = ft
-		ft = ft.Elem()
+		sf.Type = reflect.PtrTo(elem)
+		ft = elem
 		// if it's a valid pointer that's non-nil, dereference it so it
 		// can be used in the fallthrough
-		if tmplFieldVal.Kind() == reflect.Ptr && !tmplFieldVal.IsNil() {
+		for tmplFieldVal.Kind() == reflect.Ptr {
+			if tmplFieldVal.IsNil() {
+				tmplFieldVal = reflect.Value{}
+				break
+			}
 			tmplFieldVal = tmplFieldVal.Elem()
-		} else {
-			tmplFieldVal = reflect.Value{}
 		}
 		fallthrough
 	case reflect.Struct:
 		// first check whether this implements
-		// `TextUnmarshaler`, in which case we'll pointerify
-		// this field and leave its type alone.
-		if IsTextUnmarshalerStruct(ft) {
+		// `TextUnmarshaler` (or is otherwise a scalar), in which
+		// case we'll pointerify this field and leave its type alone.
+		if IsScalarStruct(ft) {
 			return &sf
 		}
 		// It's a struct without an UnmarshalText method, we
@@ -150,6 +213,25 @@ func pointerifyField(originalField reflect.StructField, tmplFieldVal reflect.Val
 	}
 }
 
+// StructMapElem returns the struct type of the values of the map type t, if
+// they're structs (or pointers to structs) that consist of fields, rather
+// than scalars (see IsScalarStruct) or empty structs (as used by sets).
+// Pointerify converts the values of such maps to pointers to pointerified
+// structs, so each entry's fields may be set individually.
+func StructMapElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Map {
+		return nil, false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct || elem.NumField() == 0 || IsScalarStruct(elem) {
+		return nil, false
+	}
+	return elem, true
+}
+
 // IsTextUnmarshalerStruct indicates whether a struct-type implements
 // encoding.TextUnmarshaler either directly or via its pointer-type
 func IsTextUnmarshalerStruct(t reflect.Type) bool {
@@ -159,3 +241,91 @@ func IsTextUnmarshalerStruct(t reflect.Type) bool {
 	return (t.Implements(textUnmarshaler) ||
 		reflect.PtrTo(t).Implements(textUnmarshaler))
 }
+
+// IsFlagValueStruct indicates whether a struct-type implements flag.Value
+// either directly or via its pointer-type, so the flag and env sources set it
+// as a whole with its Set method.
+func IsFlagValueStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return t.Implements(flagValue) || reflect.PtrTo(t).Implements(flagValue)
+}
+
+// IsStringScalarStruct indicates whether a struct-type is one of the
+// standard library types that don't implement encoding.TextUnmarshaler, but
+// which dials parses from strings (url.URL and net.IPNet), or a struct-type
+// registered with parse.RegisterType (such as sql.NullString).
+func IsStringScalarStruct(t reflect.Type) bool {
+	if _, ok := stringScalarStructs[t]; ok {
+		return true
+	}
+	return t.Kind() == reflect.Struct && parse.Registered(t)
+}
+
+// IsYAMLNode indicates whether t is yaml.v3's Node type.
+func IsYAMLNode(t reflect.Type) bool {
+	return t.PkgPath() == yamlNodePkgPath && t.Name() == yamlNodeName
+}
+
+// IsDeferredSection indicates whether t is the deferred package's Section
+// type, which holds the raw contents of a config section from each source.
+func IsDeferredSection(t reflect.Type) bool {
+	return t.PkgPath() == deferredSectionPkgPath && t.Name() == deferredSectionName
+}
+
+// IsProtoOneof indicates whether sf is the interface field protoc-gen-go
+// generates to hold the value of a oneof, which is tagged with its name.
+func IsProtoOneof(sf reflect.StructField) bool {
+	_, ok := sf.Tag.Lookup(ProtobufOneofTagName)
+	return ok && sf.Type.Kind() == reflect.Interface
+}
+
+// IsPassThrough indicates whether values of type t are carried through
+// pointerification and composition unchanged, rather than recursed into or
+// merged, so applications can defer decoding them until they know what
+// they contain: interfaces, json.RawMessage, yaml.v3's Node and deferred
+// sections (see IsDeferredSection), whose values from each source are
+// accumulated rather than replaced.
+func IsPassThrough(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface || t == rawMessageType || IsYAMLNode(t) || IsDeferredSection(t)
+}
+
+// IsOpaqueStruct indicates whether a struct-type has fields, but none that
+// are exported, so its value can only be handled as a whole (like
+// time.Time).
+func IsOpaqueStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.NumField() == 0 {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if ast.IsExported(t.Field(i).Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDecodeHookStruct indicates whether a struct-type has a decode hook
+// registered with parse.RegisterDecodeHook, which decoders use to set it as
+// a whole.
+func IsDecodeHookStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := parse.LookupDecodeHook(t)
+	return ok
+}
+
+// IsScalarStruct indicates whether a struct-type is a single value in
+// configs, rather than a set of fields: either it implements
+// encoding.TextUnmarshaler (see IsTextUnmarshalerStruct) or flag.Value (see
+// IsFlagValueStruct), dials parses it from strings (see
+// IsStringScalarStruct), it has a decode hook (see
+// IsDecodeHookStruct), it's opaque (see IsOpaqueStruct), so recursing into
+// it would drop its value, or it's yaml.v3's Node, which is passed through
+// (see IsPassThrough).
+func IsScalarStruct(t reflect.Type) bool {
+	return IsStringScalarStruct(t) || IsTextUnmarshalerStruct(t) || IsFlagValueStruct(t) ||
+		IsDecodeHookStruct(t) || IsOpaqueStruct(t) || IsYAMLNode(t)
+}
@@ -1,13 +1,25 @@
 package ptrify
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
+type genericCfg[T any] struct {
+	V T
+	P *T
+	N genericInner[string]
+}
+
+type genericInner[T any] struct {
+	V T
+}
+
 func TestPointerify(t *testing.T) {
 	type sInt struct{ J int }
 	type sIntPtr struct{ J *int }
@@ -151,6 +163,64 @@ func TestPointerify(t *testing.T) {
 				B *struct{ L *struct{ S *int32 } }
 			}{},
 		},
+		"opaque_structs": {
+			i: struct {
+				T time.Time
+				O struct{ a, b int }
+				P *struct{ a int }
+				E struct{}
+			}{},
+			expected: struct {
+				T *time.Time
+				O *struct{ a, b int }
+				P *struct{ a int }
+				E *struct{}
+			}{},
+		},
+		"pass_through": {
+			i: struct {
+				N yaml.Node
+				R json.RawMessage
+				I interface{}
+			}{},
+			expected: struct {
+				N *yaml.Node
+				R json.RawMessage
+				I interface{}
+			}{},
+		},
+		"map_of_structs": {
+			i: struct {
+				M map[string]sInt
+				P map[string]*sInt
+				T map[string]time.Time
+			}{},
+			expected: struct {
+				M map[string]*struct{ J *int }
+				P map[string]*struct{ J *int }
+				T map[string]time.Time
+			}{},
+		},
+		"pointers_to_pointers": {
+			i: struct {
+				I **int
+				S **sInt
+				T **time.Time
+			}{},
+			expected: struct {
+				I *int
+				S *struct{ J *int }
+				T *time.Time
+			}{},
+		},
+		"generic_instantiations": {
+			i: genericCfg[*sInt]{},
+			expected: struct {
+				V *struct{ J *int }
+				P *struct{ J *int }
+				N *struct{ V *string }
+			}{},
+		},
 		"three_deep_with_hypen": {
 			i: struct {
 				I struct {
@@ -0,0 +1,90 @@
+package ptrify
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PointerifyValue converts v (a struct, or pointer to a struct, of the type
+// originally passed to Pointerify) into a value of the pointerified type
+// ptrType. Every field is populated (so zero-values in v are preserved),
+// except for nil maps, slices, pointers and interfaces, which are left nil.
+func PointerifyValue(ptrType reflect.Type, v reflect.Value) (reflect.Value, error) {
+	out := reflect.New(ptrType).Elem()
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return out, nil
+		}
+		v = v.Elem()
+	}
+	if err := fillStruct(out, v); err != nil {
+		return reflect.Value{}, err
+	}
+	return out, nil
+}
+
+// fillStruct populates the pointerified struct dst from the original struct src.
+func fillStruct(dst, src reflect.Value) error {
+	for i := 0; i < dst.NumField(); i++ {
+		df := dst.Field(i)
+		name := dst.Type().Field(i).Name
+		sf := src.FieldByName(name)
+		if !sf.IsValid() {
+			return fmt.Errorf("field %q not found in %s", name, src.Type())
+		}
+		if err := fillField(df, sf); err != nil {
+			return fmt.Errorf("field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func fillField(df, sf reflect.Value) error {
+	switch {
+	case sf.Type().AssignableTo(df.Type()):
+		df.Set(sf)
+		return nil
+	case sf.Kind() == reflect.Interface:
+		if sf.IsNil() {
+			return nil
+		}
+		return fillField(df, sf.Elem())
+	case sf.Kind() == reflect.Map && df.Kind() == reflect.Map:
+		// maps of structs have pointerified values
+		if sf.IsNil() {
+			return nil
+		}
+		m := reflect.MakeMapWithSize(df.Type(), sf.Len())
+		iter := sf.MapRange()
+		for iter.Next() {
+			elem := reflect.New(df.Type().Elem()).Elem()
+			if err := fillField(elem, iter.Value()); err != nil {
+				return fmt.Errorf("entry %v: %w", iter.Key(), err)
+			}
+			m.SetMapIndex(iter.Key().Convert(df.Type().Key()), elem)
+		}
+		df.Set(m)
+		return nil
+	case df.Kind() != reflect.Ptr:
+		return fmt.Errorf("unable to assign %s to %s", sf.Type(), df.Type())
+	case sf.Type().AssignableTo(df.Type().Elem()):
+		ptr := reflect.New(df.Type().Elem())
+		ptr.Elem().Set(sf)
+		df.Set(ptr)
+		return nil
+	case sf.Kind() == reflect.Ptr:
+		if sf.IsNil() {
+			return nil
+		}
+		return fillField(df, sf.Elem())
+	case sf.Kind() == reflect.Struct && df.Type().Elem().Kind() == reflect.Struct:
+		ptr := reflect.New(df.Type().Elem())
+		if err := fillStruct(ptr.Elem(), sf); err != nil {
+			return err
+		}
+		df.Set(ptr)
+		return nil
+	default:
+		return fmt.Errorf("unable to assign %s to %s", sf.Type(), df.Type())
+	}
+}
@@ -0,0 +1,73 @@
+package ptrify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointerifyValue(t *testing.T) {
+	type inner struct {
+		A int
+		B string
+	}
+	type outer struct {
+		Inner   inner
+		Ptr     *inner
+		NilPtr  *inner
+		Slice   []string
+		Iface   interface{}
+		Timeout time.Duration
+		Ignored string `dials:"-"`
+	}
+	in := outer{
+		Inner:   inner{A: 1},
+		Ptr:     &inner{B: "b"},
+		Slice:   []string{"x"},
+		Iface:   3,
+		Timeout: time.Second,
+		Ignored: "ignored",
+	}
+	ptrType := Pointerify(reflect.TypeOf(in), reflect.ValueOf(in))
+
+	out, err := PointerifyValue(ptrType, reflect.ValueOf(&in))
+	require.NoError(t, err)
+
+	innerVal := out.FieldByName("Inner").Elem()
+	assert.Equal(t, 1, innerVal.FieldByName("A").Elem().Interface())
+	// zero-values are populated
+	assert.Equal(t, "", innerVal.FieldByName("B").Elem().Interface())
+	assert.Equal(t, "b", out.FieldByName("Ptr").Elem().FieldByName("B").Elem().Interface())
+	assert.True(t, out.FieldByName("NilPtr").IsNil())
+	assert.Equal(t, []string{"x"}, out.FieldByName("Slice").Interface())
+	assert.Equal(t, 3, out.FieldByName("Iface").Elem().Interface())
+	assert.Equal(t, time.Second, out.FieldByName("Timeout").Elem().Interface())
+	assert.False(t, out.FieldByName("Ignored").IsValid())
+
+	nilOut, err := PointerifyValue(ptrType, reflect.ValueOf((*outer)(nil)))
+	require.NoError(t, err)
+	assert.True(t, nilOut.FieldByName("Inner").IsNil())
+}
+
+func TestPointerifyValueStructMap(t *testing.T) {
+	type entry struct {
+		N int
+	}
+	type cfg struct {
+		Entries map[string]*entry
+		Nil     map[string]entry
+	}
+	in := cfg{Entries: map[string]*entry{"a": {N: 1}, "b": nil}}
+	ptrType := Pointerify(reflect.TypeOf(in), reflect.ValueOf(in))
+
+	out, err := PointerifyValue(ptrType, reflect.ValueOf(in))
+	require.NoError(t, err)
+	entries := out.FieldByName("Entries")
+	require.Equal(t, 2, entries.Len())
+	assert.Equal(t, 1, entries.MapIndex(reflect.ValueOf("a")).Elem().FieldByName("N").Elem().Interface())
+	assert.True(t, entries.MapIndex(reflect.ValueOf("b")).IsNil())
+	assert.True(t, out.FieldByName("Nil").IsNil())
+}
@@ -0,0 +1,109 @@
+package dials
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitParams configures a token-bucket limit on how frequently new
+// configuration versions from watching sources are installed and published.
+//
+// The bucket holds up to Burst tokens, and gains one every Interval.
+// Installing a version takes a token; while the bucket is empty, new values
+// are held, and once a token is available a single version composed from all
+// of them is installed. No values are dropped, so the installed
+// configuration always catches up with the sources.
+type RateLimitParams struct {
+	// Interval is the period after which a token is added to the bucket.
+	// A non-positive Interval disables rate-limiting.
+	Interval time.Duration
+	// Burst is the capacity of the bucket (treated as 1 if less), i.e. the
+	// number of versions that may be installed in quick succession after a
+	// quiet period.
+	Burst int
+}
+
+// updateLimiter implements Params.UpdateRateLimit. It's owned by the monitor
+// goroutine.
+type updateLimiter struct {
+	params RateLimitParams
+	tokens float64
+	last   time.Time
+	// held contains the updates awaiting a token, in the order they were
+	// reported
+	held []*valueUpdate
+	// waiting is true while a rateLimitTick is scheduled
+	waiting bool
+	now     func() time.Time
+}
+
+// rateLimitTick is sent by an updateLimiter's timer when a token is available.
+type rateLimitTick struct{}
+
+func (*rateLimitTick) isStatusReport() {}
+
+// newUpdateLimiter returns nil if rate-limiting is disabled.
+func newUpdateLimiter(params RateLimitParams) *updateLimiter {
+	if params.Interval <= 0 {
+		return nil
+	}
+	if params.Burst < 1 {
+		params.Burst = 1
+	}
+	return &updateLimiter{params: params, tokens: float64(params.Burst), last: time.Now(), now: time.Now}
+}
+
+// refill adds the tokens accumulated since the last refill, returning the
+// time until the next token is available (or 0 if there's one now).
+func (l *updateLimiter) refill() time.Duration {
+	now := l.now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.params.Interval)
+	if burst := float64(l.params.Burst); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - l.tokens) * float64(l.params.Interval))
+}
+
+// add holds updates, returning the held updates (and taking a token) if they
+// may be installed now. Otherwise, a rateLimitTick is scheduled on c.
+func (l *updateLimiter) add(ctx context.Context, updates []*valueUpdate, c chan<- watchStatusUpdate) []*valueUpdate {
+	l.held = append(l.held, updates...)
+	if l.waiting {
+		return nil
+	}
+	return l.take(ctx, c)
+}
+
+// take returns the held updates (taking a token) if a token is available, or
+// schedules a rateLimitTick on c for when one is.
+func (l *updateLimiter) take(ctx context.Context, c chan<- watchStatusUpdate) []*valueUpdate {
+	wait := l.refill()
+	if wait > 0 {
+		l.waiting = true
+		time.AfterFunc(wait, func() {
+			select {
+			case <-ctx.Done():
+			case c <- &rateLimitTick{}:
+			}
+		})
+		return nil
+	}
+	l.tokens--
+	held := l.held
+	l.held = nil
+	return held
+}
+
+// tick handles a rateLimitTick, returning the held updates if they may be
+// installed now.
+func (l *updateLimiter) tick(ctx context.Context, c chan<- watchStatusUpdate) []*valueUpdate {
+	l.waiting = false
+	if len(l.held) == 0 {
+		return nil
+	}
+	return l.take(ctx, c)
+}
@@ -0,0 +1,57 @@
+package dials
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLimiterTokens(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, newUpdateLimiter(RateLimitParams{}))
+
+	now := time.Now()
+	l := newUpdateLimiter(RateLimitParams{Interval: time.Second, Burst: 2})
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	assert.Zero(t, l.refill())
+	l.tokens -= 2
+	assert.Equal(t, time.Second, l.refill())
+	now = now.Add(time.Second / 4)
+	assert.Equal(t, 3*time.Second/4, l.refill())
+	// the bucket never exceeds the burst
+	now = now.Add(time.Hour)
+	assert.Zero(t, l.refill())
+	assert.Equal(t, 2.0, l.tokens)
+}
+
+func TestUpdateRateLimit(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 50 * time.Millisecond
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{
+		UpdateRateLimit: RateLimitParams{Interval: interval},
+	}.Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	start := time.Now()
+	w.send(ctx, rollbackValue("b"))
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+
+	// the bucket is empty, so these are held and combined
+	w.send(ctx, rollbackValue("c"))
+	w.send(ctx, rollbackValue("d"))
+	assert.Equal(t, "d", (<-d.Events()).Addr)
+	assert.GreaterOrEqual(t, time.Since(start), interval)
+
+	h := d.History()
+	require.Len(t, h, 3)
+	assert.Equal(t, []Source{&w}, h[2].Sources)
+}
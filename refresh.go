@@ -0,0 +1,146 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Refresh calls Value on every source again and installs the configuration
+// composed from the results, so a SIGHUP handler or admin endpoint can force
+// a reload of sources that aren't watched (e.g. files read once at startup).
+// The new version is verified (unless verification is delayed, see
+// [Params].DelayInitialVerification) and offered to AcceptConfig (if set)
+// like an update from a watching source, and Refresh returns once it's been
+// installed, or with the error that prevented it.
+//
+// If any sources fail, nothing is installed and a [*ConfigErrors] describing
+// every failure is returned. Failures of [Params].OptionalSources are
+// reported as warnings instead, and those sources' previous values are kept.
+//
+// Without watching sources, callbacks aren't called for the new version, but
+// it's published on Events.
+func (d *Dials[T]) Refresh(ctx context.Context) (*T, CfgSerial[T], error) {
+	if d.closer.isClosing() {
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	sources, statuses := d.sources.snapshot()
+	updates := make([]*valueUpdate, 0, len(sources))
+	sourceErrs := []*ConfigError{}
+	for i, res := range d.params.fetchValues(ctx, sources, d.typ) {
+		s, v, err := sources[i], res.value, res.err
+		if err != nil {
+			d.params.Metrics.SourceError(s, err)
+			statuses[i].recordError(err)
+			if containsSource(d.params.OptionalSources, s) {
+				optionalSourceFailed(ctx, d.warnings, s, err)
+			} else {
+				sourceErrs = append(sourceErrs, sourceErrors(s, err)...)
+			}
+			continue
+		}
+		statuses[i].recordUpdate()
+		updates = append(updates, &valueUpdate{source: s, value: v})
+	}
+	if len(sourceErrs) > 0 {
+		return nil, CfgSerial[T]{}, &ConfigErrors{Errors: sourceErrs}
+	}
+	if d.versionCtl == nil || d.monitorExited() {
+		return d.refreshUnwatched(ctx, updates)
+	}
+
+	// the monitor goroutine reports the result for the first update
+	installed := make(chan error, 1)
+	if len(updates) > 0 {
+		updates[0].installed = installed
+	} else {
+		installed <- nil
+	}
+	if _, _, err := d.versionRequest(ctx, versionReq[T]{kind: versionRefresh, updates: updates}); err != nil {
+		if errors.Is(err, errMonitorExited) {
+			return d.refreshUnwatched(ctx, updates)
+		}
+		return nil, CfgSerial[T]{}, err
+	}
+	var installErr error
+	select {
+	case installErr = <-installed:
+	case <-d.closer.done:
+		// the monitor may have installed the values before exiting
+		select {
+		case installErr = <-installed:
+		default:
+			if d.closer.isClosing() {
+				return nil, CfgSerial[T]{}, ErrClosed
+			}
+			return d.refreshUnwatched(ctx, updates)
+		}
+	case <-ctx.Done():
+		return nil, CfgSerial[T]{}, fmt.Errorf("context expired while awaiting refresh: %w", ctx.Err())
+	}
+	if installErr != nil {
+		return nil, CfgSerial[T]{}, fmt.Errorf("refresh failed: %w", installErr)
+	}
+	cfg, tok := d.ViewVersion()
+	return cfg, tok, nil
+}
+
+// refreshUnwatched installs the values from updates when there's no monitor
+// goroutine (because no sources are watching).
+func (d *Dials[T]) refreshUnwatched(ctx context.Context, updates []*valueUpdate) (*T, CfgSerial[T], error) {
+	cfg, tok, err := d.restackUnwatched(ctx, VersionRefresh, updateSources(updates),
+		func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+			applyUpdates(sourceValues, updates)
+			return sourceValues, true, nil
+		})
+	if err != nil {
+		return nil, CfgSerial[T]{}, fmt.Errorf("refresh failed: %w", err)
+	}
+	return cfg, tok, nil
+}
+
+// restackUnwatched installs a configuration composed from the source values
+// of the current version, as modified by edit (see editSources), when
+// there's no monitor goroutine.
+func (d *Dials[T]) restackUnwatched(
+	ctx context.Context,
+	cause VersionCause,
+	triggers []Source,
+	edit func(sourceValues []sourceValue) ([]sourceValue, bool, error),
+) (*T, CfgSerial[T], error) {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	if d.closer.isClosing() {
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+
+	old := d.loadVersion()
+	sourceValues, changed, err := edit(snapshotSources(old.sources))
+	if err != nil || !changed {
+		cfg, tok := d.ViewVersion()
+		if err != nil {
+			return nil, CfgSerial[T]{}, err
+		}
+		return cfg, tok, nil
+	}
+	skipVerify := d.verificationDelayed()
+	newInterface, err, vfErr := d.restack(ctx, nil, old.template, skipVerify, sourceValues)
+	if err == nil {
+		err = vfErr
+	}
+	if err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	newVers := d.wrap(newInterface)
+	if d.params.AcceptConfig != nil {
+		if err := d.params.AcceptConfig(ctx, old.cfg, newVers); err != nil {
+			return nil, CfgSerial[T]{}, fmt.Errorf("configuration rejected: %w", err)
+		}
+	}
+	d.installValue(ctx, &versionedConfig[T]{
+		cfg: newVers, template: old.template, sources: sourceValues,
+		cause: cause, triggers: triggers, unverified: skipVerify,
+	}, nil)
+	cfg, tok := d.ViewVersion()
+	return cfg, tok, nil
+}
@@ -0,0 +1,125 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySource returns err from Value if it's set
+type flakySource struct {
+	fakeSource
+	err error
+}
+
+func (f *flakySource) Value(ctx context.Context, t *Type) (reflect.Value, error) {
+	if f.err != nil {
+		return reflect.Value{}, f.err
+	}
+	return f.fakeSource.Value(ctx, t)
+}
+
+func TestRefreshUnwatched(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	addr := "b"
+	src := &fakeSource{outVal: ptrifiedRollbackConfig{}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, src)
+	require.NoError(t, err)
+
+	src.outVal = ptrifiedRollbackConfig{Addr: &addr}
+	cfg, tok, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Addr)
+	assert.Equal(t, uint64(1), tok.Generation())
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+
+	h := d.History()
+	require.Len(t, h, 2)
+	assert.Equal(t, VersionRefresh, h[1].Cause)
+	assert.Equal(t, []Source{src}, h[1].Sources)
+
+	require.NoError(t, d.Close(ctx))
+	_, _, err = d.Refresh(ctx)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestRefreshWatched(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := "b"
+	static := &flakySource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, static, &w)
+	require.NoError(t, err)
+
+	static.outVal = ptrifiedRollbackConfig{Addr: &addr}
+	cfg, tok, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Addr)
+	assert.Equal(t, uint64(1), tok.Generation())
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+	assert.Equal(t, []Source{static, &w}, d.History()[1].Sources)
+
+	// the failure of every source is reported, and nothing is installed
+	errBroken := errors.New("broken")
+	static.err = errBroken
+	_, _, err = d.Refresh(ctx)
+	require.Error(t, err)
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	assert.Len(t, ce.Errors, 1)
+	assert.ErrorIs(t, err, errBroken)
+	assert.Equal(t, "b", d.View().Addr)
+}
+
+type delayedVerifyConfig struct {
+	N int
+}
+
+func (c *delayedVerifyConfig) Verify() error {
+	if c.N > 5 {
+		return errFailVerifier
+	}
+	return nil
+}
+
+type ptrifiedDelayedVerifyConfig struct {
+	N *int
+}
+
+func TestRefreshUnwatchedAfterEnableVerification(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	n := 10
+	src := &fakeSource{outVal: ptrifiedDelayedVerifyConfig{N: &n}}
+	d, err := Params[delayedVerifyConfig]{DelayInitialVerification: true}.Config(ctx, &delayedVerifyConfig{N: 1}, src)
+	require.NoError(t, err)
+	// verification is delayed, so the invalid value is installed
+	assert.Equal(t, 10, d.View().N)
+	cfg, _, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.N)
+
+	_, _, err = d.EnableVerification(ctx)
+	assert.ErrorIs(t, err, errFailVerifier)
+
+	n = 3
+	_, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	_, _, err = d.EnableVerification(ctx)
+	require.NoError(t, err)
+
+	n = 10
+	_, _, err = d.Refresh(ctx)
+	assert.ErrorIs(t, err, errFailVerifier)
+	assert.Equal(t, 3, d.View().N)
+}
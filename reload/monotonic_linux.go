@@ -0,0 +1,18 @@
+package reload
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// clockMonotonic is CLOCK_MONOTONIC, from <time.h>
+const clockMonotonic = 1
+
+// monotonicUsec returns the time of CLOCK_MONOTONIC in microseconds.
+func monotonicUsec() (uint64, bool) {
+	ts := syscall.Timespec{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0, false
+	}
+	return uint64(ts.Nano() / 1000), true
+}
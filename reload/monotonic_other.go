@@ -0,0 +1,8 @@
+//go:build !linux
+
+package reload
+
+// monotonicUsec returns false, as systemd only runs on Linux.
+func monotonicUsec() (uint64, bool) {
+	return 0, false
+}
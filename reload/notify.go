@@ -0,0 +1,68 @@
+package reload
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// NotifySocketEnv is the environment variable systemd sets to the address of
+// the socket that services send notifications to (see sd_notify(3)).
+const NotifySocketEnv = "NOTIFY_SOCKET"
+
+// Notifier sends service state notifications to systemd, like sd_notify(3).
+// A Notifier without a Socket (e.g. because the process wasn't started by
+// systemd) discards them.
+type Notifier struct {
+	// Socket is the address of the notification socket: a path, or a name
+	// in the abstract namespace if it starts with "@".
+	Socket string
+}
+
+// NewNotifier returns a Notifier for the socket named by NotifySocketEnv.
+func NewNotifier() *Notifier {
+	return &Notifier{Socket: os.Getenv(NotifySocketEnv)}
+}
+
+// Notify sends state, a newline-separated list of assignments (e.g.
+// "READY=1\nSTATUS=serving"), unless n has no Socket.
+func (n *Notifier) Notify(state string) error {
+	if n == nil || n.Socket == "" {
+		return nil
+	}
+	name := n.Socket
+	if strings.HasPrefix(name, "@") {
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to notification socket %q: %w", n.Socket, err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	return nil
+}
+
+// Ready notifies systemd that the service has finished starting up, or
+// reloading its configuration.
+func (n *Notifier) Ready() error {
+	return n.Notify("READY=1")
+}
+
+// Reloading notifies systemd that the service is reloading its
+// configuration, with the current time of the monotonic clock (where it's
+// available), as services with Type=notify-reload must.
+func (n *Notifier) Reloading() error {
+	if usec, ok := monotonicUsec(); ok {
+		return n.Notify(fmt.Sprintf("RELOADING=1\nMONOTONIC_USEC=%d", usec))
+	}
+	return n.Notify("RELOADING=1")
+}
+
+// Stopping notifies systemd that the service is shutting down.
+func (n *Notifier) Stopping() error {
+	return n.Notify("STOPPING=1")
+}
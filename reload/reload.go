@@ -0,0 +1,94 @@
+// Package reload makes services using dials follow the standard Unix reload
+// conventions: the configuration is reloaded (see dials.Dials.Refresh) when
+// the process receives SIGHUP, and systemd is notified that the service is
+// reloading, and ready again once it's done, as services with
+// Type=notify-reload must (see sd_notify(3)):
+//
+//	d, err := dials.Config(ctx, &cfg, fileSrc, envSrc)
+//	stop := reload.Handle(ctx, d, reload.Params{})
+//	defer stop()
+//	reload.NewNotifier().Ready()
+//
+// Notifications are discarded if the process wasn't started by systemd, so
+// the same code works elsewhere.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/vimeo/dials"
+)
+
+// Params configures Handle.
+type Params struct {
+	// Signals are the signals that trigger a reload. If empty, SIGHUP
+	// does.
+	Signals []os.Signal
+	// Notifier is notified around each reload. If nil, NewNotifier()
+	// is used.
+	Notifier *Notifier
+	// OnError, if non-nil, is called with the error returned by each
+	// Reload that fails. The previous configuration remains installed if
+	// the reload itself failed.
+	OnError func(ctx context.Context, err error)
+}
+
+// Handle reloads the configuration of d with Reload each time the process
+// receives one of p.Signals, until ctx is done or the returned function is
+// called. The returned function waits for a reload in progress to finish.
+// Signals received during a reload trigger (at most) one more.
+func Handle[T any](ctx context.Context, d *dials.Dials[T], p Params) (stop func()) {
+	signals := p.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	n := p.Notifier
+	if n == nil {
+		n = NewNotifier()
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+			}
+			if err := Reload(ctx, d, n); err != nil && p.OnError != nil {
+				p.OnError(ctx, err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Reload notifies n that the service is reloading, refreshes the
+// configuration of d (see dials.Dials.Refresh), and notifies n that it's
+// ready again, even if the refresh failed (as the previous configuration
+// remains installed). It returns the refresh's error, if any, or else the
+// first notification's. It's useful for reloads triggered by something other
+// than a signal (e.g. an admin endpoint).
+func Reload[T any](ctx context.Context, d *dials.Dials[T], n *Notifier) error {
+	notifyErr := n.Reloading()
+	_, _, err := d.Refresh(ctx)
+	if readyErr := n.Ready(); notifyErr == nil {
+		notifyErr = readyErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+	return notifyErr
+}
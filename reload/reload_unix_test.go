@@ -0,0 +1,99 @@
+//go:build !windows
+
+package reload
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/file"
+)
+
+type config struct {
+	Name string `dials:"name"`
+}
+
+// listen returns a Notifier for a new notification socket, and the socket.
+func listen(t *testing.T) (*Notifier, *net.UnixConn) {
+	t.Helper()
+	// socket paths are limited to about 100 bytes, which t.TempDir's may
+	// exceed
+	dir, err := os.MkdirTemp("", "reload")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &Notifier{Socket: path}, conn
+}
+
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+	n, conn := listen(t)
+	require.NoError(t, n.Ready())
+	assert.Equal(t, "READY=1", receive(t, conn))
+	require.NoError(t, n.Reloading())
+	assert.Regexp(t, regexp.MustCompile(`^RELOADING=1(\nMONOTONIC_USEC=\d+)?$`), receive(t, conn))
+	require.NoError(t, n.Stopping())
+	assert.Equal(t, "STOPPING=1", receive(t, conn))
+
+	// without a socket, notifications are discarded
+	assert.NoError(t, (&Notifier{}).Ready())
+	assert.Error(t, (&Notifier{Socket: filepath.Join(t.TempDir(), "missing")}).Ready())
+}
+
+func TestHandle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: first"), 0o600))
+	src, err := file.NewSource(path, &yaml.Decoder{})
+	require.NoError(t, err)
+	d, err := dials.Config(ctx, &config{}, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	n, conn := listen(t)
+	errs := make(chan error, 1)
+	stop := Handle(ctx, d, Params{
+		Notifier: n,
+		OnError:  func(_ context.Context, err error) { errs <- err },
+	})
+	defer stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("name: second"), 0o600))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Contains(t, receive(t, conn), "RELOADING=1")
+	assert.Equal(t, "READY=1", receive(t, conn))
+	assert.Equal(t, "second", d.View().Name)
+
+	// a failed reload keeps the previous configuration, and is still
+	// followed by READY=1
+	require.NoError(t, os.WriteFile(path, []byte("name: [third"), 0o600))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Contains(t, receive(t, conn), "RELOADING=1")
+	assert.Equal(t, "READY=1", receive(t, conn))
+	assert.ErrorContains(t, <-errs, "failed to reload configuration")
+	assert.Equal(t, "second", d.View().Name)
+}
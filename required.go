@@ -0,0 +1,93 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// RequiredTag is the name of the struct tag marking a field as required
+// (e.g. `dialsrequired:"true"`). A required field must either be set by a
+// source or have a non-zero default in the template passed to Config. On a
+// nested struct field, it applies to every field within.
+//
+// Required fields are checked whenever the configuration would be verified
+// (see [VerifiedConfig]), before calling Verify, and a [*ConfigErrors] with an
+// error wrapping [ErrMissingRequired] for every missing field is returned (or
+// passed to OnWatchedError).
+const RequiredTag = "dialsrequired"
+
+// ErrMissingRequired is wrapped by the errors for required fields (see
+// [RequiredTag]) missing from a configuration.
+var ErrMissingRequired = errors.New("required field is not set")
+
+// checkRequired returns an error for each required field of cfg (the composed
+// configuration) that's zero and wasn't set by any of sources, or a non-nil
+// second error if a RequiredTag is invalid.
+func checkRequired(cfg interface{}, sources []sourceValue) ([]*ConfigError, error) {
+	root := derefConfig(reflect.ValueOf(cfg).Elem())
+	missing := []*ConfigError{}
+	var walk func(path []string, v reflect.Value, required bool) error
+	walk = func(path []string, v reflect.Value, required bool) error {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if ptrify.OmitField(sf) {
+				continue
+			}
+			fieldRequired := required
+			if tag, ok := sf.Tag.Lookup(RequiredTag); ok {
+				r, err := strconv.ParseBool(tag)
+				if err != nil {
+					return fmt.Errorf("invalid %s tag %q on field %s: %w", RequiredTag, tag, sf.Name, err)
+				}
+				fieldRequired = r
+			}
+			fieldPath := append(path[:len(path):len(path)], sf.Name)
+			f := v.Field(i)
+			if s, ok := structOf(reflect.Value{}, f); ok {
+				if err := walk(fieldPath, s.new, fieldRequired); err != nil {
+					return err
+				}
+				continue
+			}
+			if !fieldRequired || !f.IsZero() || setBySource(fieldPath, sources) {
+				continue
+			}
+			missing = append(missing, &ConfigError{Path: strings.Join(fieldPath, "."), Err: ErrMissingRequired})
+		}
+		return nil
+	}
+	if err := walk(nil, root, false); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// setBySource returns true if any of sources set the field at path.
+func setBySource(path []string, sources []sourceValue) bool {
+	for _, sv := range sources {
+		if !sv.disabled && fieldByPath(sv.value, path, true).IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyInstalled checks the required and validated fields of the installed
+// configuration and calls its Verify method (if any).
+func (d *Dials[T]) verifyInstalled(ctx context.Context) error {
+	v := d.loadVersion()
+	if err := checkFields(v.cfg, v.sources); err != nil {
+		return &VerificationError{Err: err}
+	}
+	if err := verifyConfig(ctx, v.cfg, d.params.VerificationTimeout); err != nil {
+		return &VerificationError{Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requiredConfig struct {
+	Name     string `dialsrequired:"true"`
+	Optional string
+	Port     int `dialsrequired:"true"`
+	Database struct {
+		Host string
+		User string
+	} `dialsrequired:"true"`
+}
+
+type ptrifiedRequiredConfig struct {
+	Name     *string
+	Optional *string
+	Port     *int
+	Database *struct {
+		Host *string
+		User *string
+	}
+}
+
+func TestRequiredMissing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	name := "svc"
+	_, err := Config(ctx, &requiredConfig{}, &fakeSource{outVal: ptrifiedRequiredConfig{Name: &name}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrMissingRequired)
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	assert.Equal(t, []*ConfigError{
+		{Path: "Port", Err: ErrMissingRequired},
+		{Path: "Database.Host", Err: ErrMissingRequired},
+		{Path: "Database.User", Err: ErrMissingRequired},
+	}, ce.Errors)
+	assert.Contains(t, err.Error(), "3 configuration errors: Port: required field is not set; Database.Host: ")
+}
+
+func TestRequiredSatisfied(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// Port has a non-zero default, and the source explicitly sets Name
+	// and Database.User to their zero values.
+	tmpl := requiredConfig{Port: 8080}
+	tmpl.Database.Host = "db"
+	empty, port := "", 0
+	src := ptrifiedRequiredConfig{Name: &empty, Port: &port}
+	src.Database = &struct {
+		Host *string
+		User *string
+	}{User: &empty}
+
+	d, err := Config(ctx, &tmpl, &fakeSource{outVal: src})
+	require.NoError(t, err)
+	assert.Equal(t, 0, d.View().Port)
+	assert.Equal(t, "db", d.View().Database.Host)
+}
+
+func TestRequiredInvalidTag(t *testing.T) {
+	t.Parallel()
+	type badConfig struct {
+		Name string `dialsrequired:"sure"`
+	}
+	type ptrifiedBadConfig struct {
+		Name *string
+	}
+
+	_, err := Config(context.Background(), &badConfig{}, &fakeSource{outVal: ptrifiedBadConfig{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid dialsrequired tag "sure" on field Name`)
+}
+
+func TestRequiredWatchedUpdate(t *testing.T) {
+	t.Parallel()
+	type watchedConfig struct {
+		Name string `dialsrequired:"true"`
+	}
+	type ptrifiedWatchedConfig struct {
+		Name *string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	name := "svc"
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedWatchedConfig{Name: &name}}}
+	d, err := Params[watchedConfig]{
+		OnWatchedError: func(_ context.Context, err error, _, _ *watchedConfig) {
+			errs <- err
+		},
+	}.Config(ctx, &watchedConfig{}, &w)
+	require.NoError(t, err)
+
+	// unsetting the only required field is rejected
+	w.send(ctx, reflect.ValueOf(ptrifiedWatchedConfig{}))
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(<-errs, &ce))
+	assert.Equal(t, []*ConfigError{{Path: "Name", Err: ErrMissingRequired}}, ce.Errors)
+	assert.Equal(t, "svc", d.View().Name)
+}
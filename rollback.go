@@ -0,0 +1,212 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultVersionHistorySize is the number of installed configuration versions
+// retained for [Dials.Rollback], [Dials.PinVersion] and [Dials.History] if
+// [Params].VersionHistorySize is not positive.
+const DefaultVersionHistorySize = 8
+
+// ErrVersionNotRetained is returned by Rollback and PinVersion when the
+// requested version is no longer (or was never) retained.
+var ErrVersionNotRetained = errors.New("configuration version not retained")
+
+// errMonitorExited is returned by versionRequest for refresh and
+// source-editing requests if the monitor goroutine has exited (see
+// monitorExited), so the caller installs the version itself.
+var errMonitorExited = errors.New("monitor goroutine exited")
+
+// ErrVersionPinned is wrapped by the error returned from
+// BlockingReportNewValue when the new value isn't installed because a version
+// is pinned (see [Dials.PinVersion]).
+var ErrVersionPinned = errors.New("configuration version pinned")
+
+type versionReqKind uint8
+
+const (
+	versionRollback versionReqKind = iota
+	versionPin
+	versionUnpin
+	versionRefresh
+	versionEditSources
+)
+
+// versionReq is the payload type for the channel used to signal the monitor
+// goroutine to roll back, pin or unpin a version, install refreshed values,
+// or edit the sources.
+type versionReq[T any] struct {
+	kind       versionReqKind
+	generation uint64
+	// updates contains the values for versionRefresh
+	updates []*valueUpdate
+	// edit, cause, triggers, installed and noInstall describe a
+	// versionEditSources request (see editSources)
+	edit      func(sourceValues []sourceValue) ([]sourceValue, bool, error)
+	cause     VersionCause
+	triggers  []Source
+	installed chan<- error
+	// noInstall applies the edit without installing a new version
+	noInstall bool
+	// resp must have capacity 1
+	resp chan<- versionResp[T]
+}
+
+type versionResp[T any] struct {
+	v   *T
+	tok CfgSerial[T]
+	err error
+}
+
+// Rollback reinstalls the configuration version that the current one replaced,
+// as a new version (with a new generation), and publishes it like any other
+// new version. Calling it repeatedly walks further back through the retained
+// versions (see [Params].VersionHistorySize), so an
+// operator can revert a "valid" but bad configuration even after it's been
+// replaced. Returns ErrVersionNotRetained if there's no earlier version.
+//
+// Subsequent updates from watching sources are installed as usual; to keep
+// an earlier version installed, use PinVersion.
+func (d *Dials[T]) Rollback(ctx context.Context) (*T, CfgSerial[T], error) {
+	return d.versionRequest(ctx, versionReq[T]{kind: versionRollback})
+}
+
+// PinVersion reinstalls the retained configuration version with the given
+// generation (see [CfgSerial.Generation]) as a new version, and keeps it
+// installed until Unpin is called: updates from watching sources are
+// recorded, but not installed. Returns ErrVersionNotRetained if the version
+// is no longer retained (see [Params].VersionHistorySize).
+func (d *Dials[T]) PinVersion(ctx context.Context, generation uint64) (*T, CfgSerial[T], error) {
+	return d.versionRequest(ctx, versionReq[T]{kind: versionPin, generation: generation})
+}
+
+// Unpin resumes installing updates from watching sources after PinVersion,
+// immediately installing a configuration composed from the latest values
+// (subject to verification, and AcceptConfig if set). It's a no-op if no
+// version is pinned.
+func (d *Dials[T]) Unpin(ctx context.Context) error {
+	_, _, err := d.versionRequest(ctx, versionReq[T]{kind: versionUnpin})
+	return err
+}
+
+func (d *Dials[T]) versionRequest(ctx context.Context, req versionReq[T]) (*T, CfgSerial[T], error) {
+	if d.versionCtl == nil || d.monitorExited() {
+		// versions installed by Refresh and the source-editing methods
+		// are retained without a monitor goroutine too
+		return d.versionRequestUnwatched(ctx, req)
+	}
+	// must have capacity 1
+	resp := make(chan versionResp[T], 1)
+	req.resp = resp
+	select {
+	case d.versionCtl <- req:
+	case <-d.closer.done:
+		if d.closer.isClosing() {
+			return nil, CfgSerial[T]{}, ErrClosed
+		}
+		return d.versionRequestUnwatched(ctx, req)
+	case <-ctx.Done():
+		return nil, CfgSerial[T]{}, fmt.Errorf("context expired while signaling: %w", ctx.Err())
+	}
+
+	select {
+	case r := <-resp:
+		return r.v, r.tok, r.err
+	case <-d.closer.done:
+		// the monitor may have responded before exiting
+		select {
+		case r := <-resp:
+			return r.v, r.tok, r.err
+		default:
+		}
+		if d.closer.isClosing() {
+			return nil, CfgSerial[T]{}, ErrClosed
+		}
+		return d.versionRequestUnwatched(ctx, req)
+	case <-ctx.Done():
+		return nil, CfgSerial[T]{}, fmt.Errorf("context expired while awaiting response: %w", ctx.Err())
+	}
+}
+
+// versionRequestUnwatched handles req when there's no monitor goroutine
+// (because no sources are watching, or they've all stopped; see
+// monitorExited), reinstalling the retained version targeted by a
+// rollback or pin request itself. Refresh and source-editing requests fail
+// with errMonitorExited, for their callers to install with
+// restackUnwatched.
+func (d *Dials[T]) versionRequestUnwatched(ctx context.Context, req versionReq[T]) (*T, CfgSerial[T], error) {
+	switch req.kind {
+	case versionRefresh, versionEditSources:
+		return nil, CfgSerial[T]{}, errMonitorExited
+	case versionUnpin:
+		// with no updates to hold back, there's nothing to resume
+		cfg, tok := d.ViewVersion()
+		return cfg, tok, nil
+	}
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	if d.closer.isClosing() {
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	vc, err := d.retainedVersion(req)
+	if err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	d.installValue(ctx, reinstallation(vc, req.kind == versionPin), nil)
+	cfg, tok := d.ViewVersion()
+	return cfg, tok, nil
+}
+
+// reinstallation returns the version reinstalling the retained version vc,
+// for PinVersion if pin is set, and otherwise for Rollback.
+func reinstallation[T any](vc *versionedConfig[T], pin bool) *versionedConfig[T] {
+	reinstall := &versionedConfig[T]{
+		cfg: vc.cfg, template: vc.template, sources: vc.sources, cause: VersionPin,
+		unverified: vc.unverified,
+	}
+	if !pin {
+		// rolling back again continues from where vc would have
+		reinstall.cause = VersionRollback
+		reinstall.rollbackTo, reinstall.canRollback = vc.rollbackTo, vc.canRollback
+	}
+	return reinstall
+}
+
+// recordVersion adds vc to the retained versions. It's only called by the
+// monitor goroutine (or Config, before it starts).
+func (d *Dials[T]) recordVersion(vc *versionedConfig[T]) {
+	size := d.params.VersionHistorySize
+	if size < 1 {
+		size = DefaultVersionHistorySize
+	}
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+	d.history = append(d.history, vc)
+	if len(d.history) > size {
+		d.history = append(d.history[:0:0], d.history[len(d.history)-size:]...)
+	}
+}
+
+// retainedVersion returns the retained version targeted by req (a rollback or
+// pin request).
+func (d *Dials[T]) retainedVersion(req versionReq[T]) (*versionedConfig[T], error) {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+	generation := req.generation
+	if req.kind == versionRollback {
+		cur := d.history[len(d.history)-1]
+		if !cur.canRollback {
+			return nil, ErrVersionNotRetained
+		}
+		generation = cur.rollbackTo
+	}
+	for _, vc := range d.history {
+		if vc.serial == generation {
+			return vc, nil
+		}
+	}
+	return nil, ErrVersionNotRetained
+}
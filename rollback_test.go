@@ -0,0 +1,137 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rollbackConfig struct {
+	Addr string
+}
+
+type ptrifiedRollbackConfig struct {
+	Addr *string
+}
+
+func rollbackValue(addr string) reflect.Value {
+	return reflect.ValueOf(ptrifiedRollbackConfig{Addr: &addr})
+}
+
+func TestRollback(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	_, _, err = d.Rollback(ctx)
+	assert.ErrorIs(t, err, ErrVersionNotRetained)
+
+	for _, addr := range []string{"b", "c"} {
+		w.send(ctx, rollbackValue(addr))
+		assert.Equal(t, addr, (<-d.Events()).Addr)
+	}
+
+	cfg, tok, err := d.Rollback(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Addr)
+	assert.Equal(t, uint64(3), tok.Generation())
+	assert.Equal(t, "b", (<-d.Events()).Addr)
+
+	// rolling back again walks further back
+	cfg, tok, err = d.Rollback(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", cfg.Addr)
+	assert.Equal(t, uint64(4), tok.Generation())
+	<-d.Events()
+
+	_, _, err = d.Rollback(ctx)
+	assert.ErrorIs(t, err, ErrVersionNotRetained)
+
+	// later updates are installed as usual
+	w.send(ctx, rollbackValue("d"))
+	assert.Equal(t, "d", (<-d.Events()).Addr)
+}
+
+func TestPinVersion(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{VersionHistorySize: 2}.Config(ctx, &rollbackConfig{Addr: "a"}, &w)
+	require.NoError(t, err)
+
+	w.send(ctx, rollbackValue("b"))
+	<-d.Events()
+
+	cfg, tok, err := d.PinVersion(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "a", cfg.Addr)
+	assert.Equal(t, uint64(2), tok.Generation())
+	<-d.Events()
+
+	// updates aren't installed while pinned
+	err = w.args.BlockingReportNewValue(ctx, rollbackValue("c").Convert(w.t.t))
+	assert.ErrorIs(t, err, ErrVersionPinned)
+	assert.Equal(t, "a", d.View().Addr)
+
+	// unpinning installs the latest values
+	require.NoError(t, d.Unpin(ctx))
+	assert.Equal(t, "c", (<-d.Events()).Addr)
+	assert.Equal(t, "c", d.View().Addr)
+
+	// only the two most recent versions are retained
+	_, _, err = d.PinVersion(ctx, 1)
+	assert.ErrorIs(t, err, ErrVersionNotRetained)
+}
+
+func TestRollbackWithoutWatchers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, &fakeSource{outVal: ptrifiedRollbackConfig{}})
+	require.NoError(t, err)
+
+	_, _, err = d.Rollback(ctx)
+	assert.ErrorIs(t, err, ErrVersionNotRetained)
+	cfg, _, err := d.PinVersion(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "a", cfg.Addr)
+	assert.NoError(t, d.Unpin(ctx))
+}
+
+func TestRollbackAfterRefreshWithoutWatchers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	src := &fakeSource{outVal: ptrifiedRollbackConfig{}}
+	d, err := Config(ctx, &rollbackConfig{Addr: "a"}, src)
+	require.NoError(t, err)
+
+	addr := "b"
+	src.outVal = ptrifiedRollbackConfig{Addr: &addr}
+	_, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	require.Len(t, d.History(), 2)
+
+	cfg, tok, err := d.Rollback(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", cfg.Addr)
+	assert.Equal(t, uint64(2), tok.Generation())
+	assert.Equal(t, VersionRollback, d.History()[2].Cause)
+
+	cfg, tok, err = d.PinVersion(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Addr)
+	assert.Equal(t, uint64(3), tok.Generation())
+	require.NoError(t, d.Unpin(ctx))
+
+	cfg, _, err = d.PinVersion(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "a", cfg.Addr)
+}
@@ -0,0 +1,130 @@
+// Package rollout ramps configuration changes gradually: for each request (or
+// user, or host) key, it deterministically selects whether the old or the new
+// value of a field applies, according to a rollout fraction that's itself
+// part of the configuration. Raising the fraction only moves keys from the old
+// value to the new one, so a change can be canaried on a small share of
+// traffic and then ramped up (or rolled back) by watched updates.
+//
+// Keys are assigned with featureflag.Bucket, so a key sees the same value in
+// every process.
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/featureflag"
+)
+
+// Selected reports whether key is among the fraction (between 0 and 1) of keys
+// that see the new value, with keys assigned independently for each salt.
+func Selected(salt, key string, fraction float64) bool {
+	return featureflag.Bucket(salt, key) < fraction
+}
+
+// Rollout is a configuration section holding both values of a field being
+// rolled out, so the rollout survives restarts.
+type Rollout[V any] struct {
+	Old V
+	New V
+	// Fraction is the fraction of keys (between 0 and 1) that see New.
+	Fraction float64
+	// Salt is hashed along with keys to assign them; rollouts with
+	// different salts assign keys independently.
+	Salt string
+}
+
+// Validate returns an error if Fraction isn't between 0 and 1. It's suitable
+// for calling from a configuration's Verify() method.
+func (r *Rollout[V]) Validate() error {
+	if r.Fraction < 0 || r.Fraction > 1 {
+		return fmt.Errorf("rollout fraction %g is not between 0 and 1", r.Fraction)
+	}
+	return nil
+}
+
+// Select returns the value key sees: New if it's among the first Fraction of
+// keys, and Old otherwise.
+func (r *Rollout[V]) Select(key string) V {
+	if Selected(r.Salt, key, r.Fraction) {
+		return r.New
+	}
+	return r.Old
+}
+
+// Canary tracks a field of a watched configuration, and ramps each change to
+// its value: until the rollout fraction reaches 1, only that fraction of keys
+// see the new value, while the others keep seeing the value that was last
+// fully rolled out.
+//
+// The value that was last fully rolled out is only known to the process (it's
+// the field's value when Watch was called, or when the fraction last reached
+// 1), so a process started mid-rollout treats the new value as fully rolled
+// out. Use Rollout, which holds both values in the configuration, where that
+// matters.
+type Canary[V any] struct {
+	salt string
+
+	mu sync.Mutex
+	// stable is the value that was last fully rolled out, and candidate
+	// the current value of the field.
+	stable, candidate V
+	fraction          float64
+}
+
+// Watch returns a Canary tracking the field of d's configuration selected by
+// sel, with the rollout fraction selected by fraction. To ramp a change,
+// update the field and lower the fraction below 1 in the same configuration
+// version; a change made while the fraction is 1 applies to every key at once.
+//
+// Updates are tracked with a callback that's unregistered when ctx is
+// canceled. Configuration updates are only observed if d has at least one
+// watching source.
+func Watch[T, V any](ctx context.Context, d *dials.Dials[T], sel func(*T) V, fraction func(*T) float64, salt string) *Canary[V] {
+	cfg, serial := d.ViewVersion()
+	c := &Canary[V]{
+		salt:      salt,
+		stable:    sel(cfg),
+		candidate: sel(cfg),
+		fraction:  fraction(cfg),
+	}
+	unregister := d.RegisterCallback(ctx, serial, func(_ context.Context, _, newCfg *T) {
+		c.update(sel(newCfg), fraction(newCfg))
+	})
+	if unregister != nil {
+		go func() {
+			<-ctx.Done()
+			unregister(context.Background())
+		}()
+	}
+	return c
+}
+
+func (c *Canary[V]) update(val V, fraction float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.candidate, c.fraction = val, fraction
+	if fraction >= 1 {
+		c.stable = c.candidate
+	}
+}
+
+// Select returns the value key sees.
+func (c *Canary[V]) Select(key string) V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if Selected(c.salt, key, c.fraction) {
+		return c.candidate
+	}
+	return c.stable
+}
+
+// Values returns the value that was last fully rolled out, the current value
+// of the field, and the fraction of keys that see the latter.
+func (c *Canary[V]) Values() (stable, candidate V, fraction float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stable, c.candidate, c.fraction
+}
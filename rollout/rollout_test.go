@@ -0,0 +1,90 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
+)
+
+func TestRolloutSelect(t *testing.T) {
+	t.Parallel()
+	r := Rollout[string]{Old: "v1", New: "v2", Fraction: 0.2, Salt: "release-42"}
+	require.NoError(t, r.Validate())
+
+	// raising the fraction only moves keys to the new value
+	wider := r
+	wider.Fraction = 0.6
+	newCount := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("req-%d", i)
+		v := r.Select(key)
+		assert.Equal(t, v, r.Select(key))
+		if v == "v2" {
+			newCount++
+			assert.Equal(t, "v2", wider.Select(key), key)
+		}
+	}
+	assert.InDelta(t, 200, newCount, 50)
+
+	assert.Equal(t, "v1", (&Rollout[string]{Old: "v1", New: "v2"}).Select("any"))
+	assert.Equal(t, "v2", (&Rollout[string]{Old: "v1", New: "v2", Fraction: 1}).Select("any"))
+	assert.Error(t, (&Rollout[string]{Fraction: 1.5}).Validate())
+}
+
+type config struct {
+	Backend  string
+	Fraction float64
+}
+
+func TestCanaryRampsWatchedChanges(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	src := dialstest.NewSource(&config{Backend: "old", Fraction: 1})
+	d, err := dials.Config(ctx, &config{}, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	c := Watch(ctx, d, func(c *config) string { return c.Backend },
+		func(c *config) float64 { return c.Fraction }, "backend")
+	awaitValues := func(stable, candidate string, fraction float64) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			s, cand, f := c.Values()
+			return s == stable && cand == candidate && f == fraction
+		}, 5*time.Second, time.Millisecond)
+	}
+	assert.Equal(t, "old", c.Select("req-1"))
+
+	require.NoError(t, src.Push(ctx, &config{Backend: "new", Fraction: 0.25}))
+	awaitValues("old", "new", 0.25)
+	onNew := map[string]bool{}
+	for i := 0; i < 400; i++ {
+		key := fmt.Sprintf("req-%d", i)
+		onNew[key] = c.Select(key) == "new"
+		assert.Equal(t, Selected("backend", key, 0.25), onNew[key])
+	}
+
+	// ramping up keeps the keys already on the new value there
+	require.NoError(t, src.Push(ctx, &config{Backend: "new", Fraction: 0.75}))
+	awaitValues("old", "new", 0.75)
+	for key, wasNew := range onNew {
+		if wasNew {
+			assert.Equal(t, "new", c.Select(key), key)
+		}
+	}
+
+	// once fully rolled out, the new value becomes the stable one
+	require.NoError(t, src.Push(ctx, &config{Backend: "new", Fraction: 1}))
+	awaitValues("new", "new", 1)
+	require.NoError(t, src.Push(ctx, &config{Backend: "newer", Fraction: 0}))
+	awaitValues("new", "newer", 0)
+	assert.Equal(t, "new", c.Select("req-1"))
+}
@@ -0,0 +1,295 @@
+// Package schedule provides a configuration type for values that vary with
+// the time of day and day of the week (e.g. a rate-limit that's higher during
+// business hours), along with a helper for tracking the active value as the
+// configuration and the wall-clock change.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials"
+)
+
+// TimeOfDayLayout is the layout used for the Start and End fields of a Window.
+const TimeOfDayLayout = "15:04"
+
+// Window is a recurring interval during which a Schedule's value is
+// overridden.
+type Window[V any] struct {
+	// Days restricts the window to intervals starting on the named days of
+	// the week. Both abbreviated ("Mon") and full ("Monday") names are
+	// accepted (case-insensitive). An empty Days matches every day.
+	Days []string
+	// Start is the (inclusive) time of day at which this window begins, in
+	// 24-hour "15:04" format. The empty string is equivalent to "00:00".
+	Start string
+	// End is the (exclusive) time of day at which this window ends, in
+	// 24-hour "15:04" format. If End is not after Start, the window wraps
+	// past midnight and ends on the following day. The empty string is
+	// equivalent to "00:00".
+	End string
+	// Value is the value of the schedule while this window is active.
+	Value V
+}
+
+// Schedule is a configuration value that varies over time. Windows are
+// evaluated in order, with the first one active at a given time providing the
+// value. When no window is active, Default is used.
+//
+// The Windows field is tagged so the flag sources don't attempt to register
+// a flag for it.
+type Schedule[V any] struct {
+	Default V
+	Windows []Window[V] `dialsflag:"-" dialspflag:"-"`
+	// Location is the IANA name of the time zone in which windows are
+	// evaluated (e.g. "America/New_York"). Defaults to the local time zone.
+	Location string
+}
+
+type parsedWindow struct {
+	// days is a bitmask indexed by time.Weekday, zero matches all days.
+	days       uint8
+	start, end time.Duration
+}
+
+func (p *parsedWindow) matchesDay(d time.Weekday) bool {
+	return p.days == 0 || p.days&(1<<uint(d)) != 0
+}
+
+// interval returns the instants at which the window starting on the date of
+// day begins and ends.
+func (p *parsedWindow) interval(day time.Time) (time.Time, time.Time) {
+	y, m, d := day.Date()
+	loc := day.Location()
+	startH, startM := int(p.start/time.Hour), int((p.start%time.Hour)/time.Minute)
+	endH, endM := int(p.end/time.Hour), int((p.end%time.Hour)/time.Minute)
+	start := time.Date(y, m, d, startH, startM, 0, 0, loc)
+	endDay := d
+	if p.end <= p.start {
+		endDay++
+	}
+	end := time.Date(y, m, endDay, endH, endM, 0, 0, loc)
+	return start, end
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	ls := strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if ls == name || ls == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day of the week %q", s)
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(TimeOfDayLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (expected %q format): %w", s, TimeOfDayLayout, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (s *Schedule[V]) parse() ([]parsedWindow, *time.Location, error) {
+	loc := time.Local
+	if s.Location != "" {
+		l, locErr := time.LoadLocation(s.Location)
+		if locErr != nil {
+			return nil, nil, fmt.Errorf("invalid location %q: %w", s.Location, locErr)
+		}
+		loc = l
+	}
+	out := make([]parsedWindow, len(s.Windows))
+	for i, w := range s.Windows {
+		for _, day := range w.Days {
+			d, dayErr := parseWeekday(day)
+			if dayErr != nil {
+				return nil, nil, fmt.Errorf("window %d: %w", i, dayErr)
+			}
+			out[i].days |= 1 << uint(d)
+		}
+		start, startErr := parseTimeOfDay(w.Start)
+		if startErr != nil {
+			return nil, nil, fmt.Errorf("window %d start: %w", i, startErr)
+		}
+		end, endErr := parseTimeOfDay(w.End)
+		if endErr != nil {
+			return nil, nil, fmt.Errorf("window %d end: %w", i, endErr)
+		}
+		out[i].start, out[i].end = start, end
+	}
+	return out, loc, nil
+}
+
+// Validate returns an error if any of the windows have unparseable days or
+// times of day, or if the Location is unknown. It's suitable for calling
+// from a configuration's Verify() method.
+func (s *Schedule[V]) Validate() error {
+	_, _, err := s.parse()
+	return err
+}
+
+// Active returns the value that's active at time t.
+func (s *Schedule[V]) Active(t time.Time) (V, error) {
+	windows, loc, err := s.parse()
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	t = t.In(loc)
+	for i := range windows {
+		w := &windows[i]
+		// A window starting yesterday may still be active if it wraps
+		// past midnight.
+		for _, dayOffset := range [...]int{0, -1} {
+			day := t.AddDate(0, 0, dayOffset)
+			if !w.matchesDay(day.Weekday()) {
+				continue
+			}
+			start, end := w.interval(day)
+			if !t.Before(start) && t.Before(end) {
+				return s.Windows[i].Value, nil
+			}
+		}
+	}
+	return s.Default, nil
+}
+
+// NextBoundary returns the first instant strictly after t at which a window
+// starts or ends (and the active value may change). The second return value
+// is false if there are no windows.
+func (s *Schedule[V]) NextBoundary(t time.Time) (time.Time, bool, error) {
+	windows, loc, err := s.parse()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	t = t.In(loc)
+	next := time.Time{}
+	found := false
+	for i := range windows {
+		w := &windows[i]
+		// Every window recurs at least weekly, so looking at the
+		// intervals starting between yesterday and a week from now
+		// is sufficient.
+		for dayOffset := -1; dayOffset <= 7; dayOffset++ {
+			day := t.AddDate(0, 0, dayOffset)
+			if !w.matchesDay(day.Weekday()) {
+				continue
+			}
+			start, end := w.interval(day)
+			for _, b := range [...]time.Time{start, end} {
+				if b.After(t) && (!found || b.Before(next)) {
+					next = b
+					found = true
+				}
+			}
+		}
+	}
+	return next, found, nil
+}
+
+// clock is the wall-clock Watch uses to find the active value and await
+// window boundaries, replaced by tests.
+type clock interface {
+	Now() time.Time
+	// NewTimer returns a channel that receives once d has elapsed, and a
+	// function that stops the timer (see time.Timer.Stop).
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+	return t.C, t.Stop
+}
+
+// ActiveValueHandler is called by Watch when the active value of a
+// schedule changes.
+type ActiveValueHandler[V any] func(ctx context.Context, oldVal, newVal V)
+
+// Watch tracks the active value of the schedule selected from d's
+// configuration by sel, calling cb whenever it changes, either because a
+// window boundary was crossed or because a new configuration was installed.
+// It returns the currently active value.
+//
+// Watch spawns a goroutine that exits when ctx is canceled. Configuration
+// updates are only observed if d has at least one watching source.
+func Watch[T, V any](ctx context.Context, d *dials.Dials[T], sel func(*T) *Schedule[V], cb ActiveValueHandler[V]) (V, error) {
+	return watch(ctx, realClock{}, d, sel, cb)
+}
+
+// watch implements Watch, with the wall-clock clk.
+func watch[T, V any](
+	ctx context.Context,
+	clk clock,
+	d *dials.Dials[T],
+	sel func(*T) *Schedule[V],
+	cb ActiveValueHandler[V],
+) (V, error) {
+	cfg, serial := d.ViewVersion()
+	sched := sel(cfg)
+	cur, err := sched.Active(clk.Now())
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	newCfgs := make(chan *T, 1)
+	unregister := d.RegisterCallback(ctx, serial, func(ctx context.Context, _, newCfg *T) {
+		// only the latest config matters, so replace anything that's
+		// still pending.
+		for {
+			select {
+			case newCfgs <- newCfg:
+				return
+			case <-newCfgs:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	go func() {
+		if unregister != nil {
+			defer unregister(context.Background())
+		}
+		for {
+			// without a boundary, only a new configuration can
+			// change the active value
+			var fired <-chan time.Time
+			stop := func() bool { return false }
+			now := clk.Now()
+			if next, ok, nbErr := sched.NextBoundary(now); nbErr == nil && ok {
+				fired, stop = clk.NewTimer(next.Sub(now))
+			}
+			select {
+			case <-ctx.Done():
+				stop()
+				return
+			case c := <-newCfgs:
+				sched = sel(c)
+			case <-fired:
+			}
+			stop()
+			newVal, activeErr := sched.Active(clk.Now())
+			if activeErr != nil || reflect.DeepEqual(cur, newVal) {
+				continue
+			}
+			oldVal := cur
+			cur = newVal
+			cb(ctx, oldVal, newVal)
+		}
+	}()
+	return cur, nil
+}
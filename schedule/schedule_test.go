@@ -0,0 +1,217 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+func TestScheduleActive(t *testing.T) {
+	t.Parallel()
+	s := Schedule[int]{
+		Default: 20,
+		Windows: []Window[int]{
+			{Days: []string{"Mon", "tuesday", "Wed", "Thu", "Fri"}, Start: "09:00", End: "17:00", Value: 100},
+			{Start: "22:00", End: "06:00", Value: 5},
+		},
+		Location: "UTC",
+	}
+	require.NoError(t, s.Validate())
+
+	for name, tc := range map[string]struct {
+		at       time.Time
+		expected int
+	}{
+		"weekday_business_hours":    {at: time.Date(2023, time.July, 24, 10, 0, 0, 0, time.UTC), expected: 100},
+		"weekday_start_inclusive":   {at: time.Date(2023, time.July, 24, 9, 0, 0, 0, time.UTC), expected: 100},
+		"weekday_end_exclusive":     {at: time.Date(2023, time.July, 24, 17, 0, 0, 0, time.UTC), expected: 20},
+		"weekend_daytime":           {at: time.Date(2023, time.July, 23, 10, 0, 0, 0, time.UTC), expected: 20},
+		"overnight_before_midnight": {at: time.Date(2023, time.July, 23, 23, 0, 0, 0, time.UTC), expected: 5},
+		"overnight_after_midnight":  {at: time.Date(2023, time.July, 24, 2, 0, 0, 0, time.UTC), expected: 5},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			v, err := s.Active(tc.at)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestScheduleNextBoundary(t *testing.T) {
+	t.Parallel()
+	s := Schedule[int]{
+		Windows: []Window[int]{
+			{Days: []string{"Mon"}, Start: "09:00", End: "17:00", Value: 100},
+		},
+		Location: "UTC",
+	}
+	// Sunday
+	next, ok, err := s.NextBoundary(time.Date(2023, time.July, 23, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.July, 24, 9, 0, 0, 0, time.UTC), next)
+
+	next, ok, err = s.NextBoundary(time.Date(2023, time.July, 24, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.July, 24, 17, 0, 0, 0, time.UTC), next)
+
+	_, ok, err = (&Schedule[int]{}).NextBoundary(time.Now())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	t.Parallel()
+	assert.Error(t, (&Schedule[int]{Windows: []Window[int]{{Days: []string{"Caturday"}}}}).Validate())
+	assert.Error(t, (&Schedule[int]{Windows: []Window[int]{{Start: "9am"}}}).Validate())
+	assert.Error(t, (&Schedule[int]{Location: "Nowhere/Special"}).Validate())
+}
+
+func TestScheduleFromConfig(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		RateLimit Schedule[int]
+	}
+	src := static.StringSource{
+		Data: `
+ratelimit:
+  default: 20
+  location: UTC
+  windows:
+    - start: "00:00"
+      end: "00:00"
+      value: 100
+`,
+		Decoder: &yaml.Decoder{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := dials.Config(ctx, &config{}, &src)
+	require.NoError(t, err)
+
+	// A window from midnight to midnight is active all day.
+	v, err := Watch(ctx, d, func(c *config) *Schedule[int] { return &c.RateLimit },
+		func(context.Context, int, int) {})
+	require.NoError(t, err)
+	assert.Equal(t, 100, v)
+}
+
+// fakeClock is a clock whose time only changes when advance is called.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{deadline: f.now.Add(d), c: make(chan time.Time, 1)}
+	f.timers[t] = struct{}{}
+	return t.c, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		_, pending := f.timers[t]
+		delete(f.timers, t)
+		return pending
+	}
+}
+
+// pending returns the number of timers that haven't fired or been stopped.
+func (f *fakeClock) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// advance moves the time forward by d, firing the timers that are due.
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	for t := range f.timers {
+		if !t.deadline.After(f.now) {
+			t.c <- f.now
+			delete(f.timers, t)
+		}
+	}
+}
+
+func TestWatchBoundaries(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		RateLimit Schedule[int]
+	}
+	sched := Schedule[int]{
+		Default: 20,
+		Windows: []Window[int]{
+			{Days: []string{"Mon"}, Start: "09:00", End: "17:00", Value: 100},
+		},
+		Location: "UTC",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d, err := dials.Config(ctx, &config{RateLimit: sched})
+	require.NoError(t, err)
+
+	// a minute before the window starts, on a Monday
+	clk := &fakeClock{
+		now:    time.Date(2023, time.July, 24, 8, 59, 0, 0, time.UTC),
+		timers: map[*fakeTimer]struct{}{},
+	}
+	type change struct{ oldVal, newVal int }
+	changes := make(chan change, 2)
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	v, err := watch(watchCtx, clk, d, func(c *config) *Schedule[int] { return &c.RateLimit },
+		func(_ context.Context, oldVal, newVal int) { changes <- change{oldVal, newVal} })
+	require.NoError(t, err)
+	assert.Equal(t, 20, v)
+
+	awaitTimer := func() {
+		require.Eventually(t, func() bool { return clk.pending() == 1 }, 5*time.Second, time.Millisecond)
+	}
+
+	// the window's value is used from its start
+	awaitTimer()
+	clk.advance(59 * time.Second)
+	assert.Equal(t, 1, clk.pending())
+	clk.advance(time.Second)
+	assert.Equal(t, change{20, 100}, <-changes)
+
+	// and the default is restored at its end
+	awaitTimer()
+	clk.advance(8 * time.Hour)
+	assert.Equal(t, change{100, 20}, <-changes)
+
+	// canceling the context stops the goroutine (and its timer), so later
+	// boundaries are ignored
+	awaitTimer()
+	stopWatching()
+	require.Eventually(t, func() bool { return clk.pending() == 0 }, 5*time.Second, time.Millisecond)
+	clk.advance(7 * 24 * time.Hour)
+	select {
+	case c := <-changes:
+		t.Fatalf("unexpected change after cancellation: %+v", c)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,125 @@
+package dials
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// SecretTag is the name of the struct tag marking a field as secret (e.g.
+// `dialssecret:"true"` on a password or token). On a nested struct field, it
+// applies to every field within. An invalid value is treated as "true".
+//
+// The values of secret fields (and those for which [Params].Redact returns
+// true) are replaced by RedactedValue wherever dials renders them: the
+// explanation written by [WriteExplanation], conflict warnings, the String
+// method of [FieldChange], [Dials.ExpvarFunc], [Dials.DebugHandler] and
+// [RedactedString]. Programmatic accessors such as [Dials.Explain] and [Diff]
+// still return the values, but mark them as secret.
+const SecretTag = "dialssecret"
+
+// secretTag returns true if sf is tagged with a SecretTag that isn't false.
+func secretTag(sf reflect.StructField) bool {
+	tag, ok := sf.Tag.Lookup(SecretTag)
+	if !ok {
+		return false
+	}
+	s, err := strconv.ParseBool(tag)
+	return err != nil || s
+}
+
+// secretPath returns true if the field at path in the struct type t, or any
+// struct containing it, is tagged as secret.
+func secretPath(t reflect.Type, path []string) bool {
+	for _, name := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			return false
+		}
+		if secretTag(sf) {
+			return true
+		}
+		t = sf.Type
+	}
+	return false
+}
+
+// redacts returns true if the field at path should be redacted, because it's
+// secret or Params.Redact returns true for it.
+func (d *Dials[T]) redacts(path []string) bool {
+	if redactedPath(d.params.Redact, path) {
+		return true
+	}
+	v := derefConfig(reflect.ValueOf(d.View()).Elem())
+	return secretPath(v.Type(), path)
+}
+
+// redactedPath returns true if redact (if non-nil) returns true for path or
+// any of the structs containing it.
+func redactedPath(redact func(fieldPath []string) bool, path []string) bool {
+	if redact == nil {
+		return false
+	}
+	for i := range path {
+		if redact(path[:i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedString formats cfg like the %+v verb of the fmt package (e.g.
+// `{Addr:localhost DB:{User:app Password:<redacted>}}`), but with the values
+// of secret fields (see [SecretTag]) replaced by RedactedValue, so it's safe
+// to log.
+func RedactedString[T any](cfg *T) string {
+	if cfg == nil {
+		return "<nil>"
+	}
+	v := derefConfig(reflect.ValueOf(cfg).Elem())
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprintf("%+v", v.Interface())
+	}
+	b := strings.Builder{}
+	writeRedacted(&b, v)
+	return b.String()
+}
+
+// writeRedacted writes the struct v to b for RedactedString.
+func writeRedacted(b *strings.Builder, v reflect.Value) {
+	t := v.Type()
+	b.WriteByte('{')
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if ptrify.OmitField(sf) {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(sf.Name)
+		b.WriteByte(':')
+		f := v.Field(i)
+		if secretTag(sf) {
+			b.WriteString(RedactedValue)
+			continue
+		}
+		if s, ok := structOf(reflect.Value{}, f); ok {
+			writeRedacted(b, s.new)
+			continue
+		}
+		fmt.Fprintf(b, "%+v", f.Interface())
+	}
+	b.WriteByte('}')
+}
@@ -0,0 +1,77 @@
+package dials
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretDBConfig struct {
+	User     string
+	Password string `dialssecret:"true"`
+}
+
+type secretConfig struct {
+	Addr  string
+	DB    secretDBConfig
+	Token struct {
+		Value string
+	} `dialssecret:"true"`
+}
+
+type ptrifiedSecretConfig struct {
+	Addr *string
+	DB   *struct {
+		User     *string
+		Password *string
+	}
+	Token *struct {
+		Value *string
+	}
+}
+
+func TestRedactedString(t *testing.T) {
+	t.Parallel()
+	cfg := secretConfig{Addr: "a", DB: secretDBConfig{User: "u", Password: "hunter2"}}
+	cfg.Token.Value = "t0k3n"
+	assert.Equal(t, "{Addr:a DB:{User:u Password:<redacted>} Token:<redacted>}", RedactedString(&cfg))
+	assert.Equal(t, "<nil>", RedactedString((*secretConfig)(nil)))
+}
+
+func TestSecretRedaction(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	password := "swordfish"
+	src := &fakeSource{outVal: ptrifiedSecretConfig{DB: &struct {
+		User     *string
+		Password *string
+	}{Password: &password}}}
+	initial := secretConfig{Addr: "a", DB: secretDBConfig{User: "u", Password: "hunter2"}}
+	d, err := Params[secretConfig]{
+		Redact: func(fieldPath []string) bool { return fieldPath[0] == "Addr" },
+	}.Config(ctx, &initial, src)
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteExplanation(&buf, d.Explain()))
+	assert.Equal(t, `Addr = <redacted> (default)
+DB.User = "u" (default)
+DB.Password = <redacted> (from *dials.fakeSource)
+	overrides <redacted> (default)
+Token.Value = <redacted> (default)
+`, buf.String())
+
+	changes := Diff(&initial, d.View())
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Secret)
+	assert.Equal(t, "swordfish", changes[0].New)
+	assert.Equal(t, "DB.Password: <redacted> -> <redacted>", changes[0].String())
+
+	out := d.ExpvarFunc().String()
+	assert.NotContains(t, out, "swordfish")
+	assert.NotContains(t, out, `"a"`)
+}
@@ -0,0 +1,285 @@
+// Package secretref implements Kubernetes-style `valueFrom` indirection for
+// configuration values, allowing a configuration document to reference a
+// secret held elsewhere rather than embedding it.
+//
+// A field of type Value may be populated in YAML as either
+//
+//	password:
+//	  value: hunter2
+//
+// or
+//
+//	password:
+//	  valueFrom:
+//	    secretKeyRef:
+//	      name: db-credentials
+//	      key: password
+//
+// Sources wrapped with NewSource resolve any references using the Resolver
+// registered for the reference's kind ("secretKeyRef" above) before the
+// value is composed with other sources.
+package secretref
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// KeyRef identifies a single value within a named collection (e.g. a key
+// within a Kubernetes Secret).
+type KeyRef struct {
+	Name string `dials:"name"`
+	Key  string `dials:"key"`
+}
+
+// Value is a string configuration value that's either provided inline or
+// referenced through ValueFrom.
+type Value struct {
+	// Value is the inline value. After resolution, it contains the
+	// referenced value.
+	Value string `dials:"value"`
+	// ValueFrom maps the kind of a reference (e.g. "secretKeyRef") to the
+	// reference itself. At most one entry is allowed.
+	ValueFrom map[string]KeyRef `dials:"valueFrom" dialsflag:"-" dialspflag:"-"`
+}
+
+// String returns the (possibly resolved) value.
+func (v *Value) String() string {
+	return v.Value
+}
+
+// Resolver looks up the value referenced by a KeyRef.
+type Resolver interface {
+	Resolve(ctx context.Context, ref KeyRef) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ctx context.Context, ref KeyRef) (string, error)
+
+// Resolve implements Resolver.
+func (r ResolverFunc) Resolve(ctx context.Context, ref KeyRef) (string, error) {
+	return r(ctx, ref)
+}
+
+// DirResolver resolves references by reading the file Dir/Name/Key, matching
+// the layout of Kubernetes secrets mounted as volumes under a common
+// directory. Trailing newlines are stripped from the file's contents.
+type DirResolver struct {
+	Dir string
+}
+
+// Resolve implements Resolver.
+func (d *DirResolver) Resolve(_ context.Context, ref KeyRef) (string, error) {
+	for _, component := range [...]string{ref.Name, ref.Key} {
+		if component == "" || strings.ContainsRune(component, filepath.Separator) || component == ".." {
+			return "", fmt.Errorf("invalid path component %q in reference %+v", component, ref)
+		}
+	}
+	contents, err := os.ReadFile(filepath.Join(d.Dir, ref.Name, ref.Key))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+// Resolvers maps reference kinds (the keys of Value.ValueFrom) to the
+// Resolver for that kind.
+type Resolvers map[string]Resolver
+
+// ResolveError is returned when a reference cannot be resolved.
+type ResolveError struct {
+	// Path is the dotted path of struct field names leading to the Value.
+	Path string
+	Kind string
+	Ref  KeyRef
+	Err  error
+}
+
+func (r *ResolveError) Error() string {
+	return fmt.Sprintf("failed to resolve %s reference (name %q, key %q) for field %q: %s",
+		r.Kind, r.Ref.Name, r.Ref.Key, r.Path, r.Err)
+}
+
+func (r *ResolveError) Unwrap() error {
+	return r.Err
+}
+
+var (
+	stringPtrType = reflect.TypeOf((*string)(nil))
+	// refMapType is the pointerified form of Value's ValueFrom field, whose
+	// entries are pointers to pointerified KeyRefs.
+	refMapType = reflect.MapOf(reflect.TypeOf(""),
+		reflect.PtrTo(ptrify.Pointerify(reflect.TypeOf(KeyRef{}), reflect.Value{})))
+)
+
+// isPointerifiedValue checks whether t is the pointerified form of Value.
+func isPointerifiedValue(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	v, hasValue := t.FieldByName("Value")
+	vf, hasValueFrom := t.FieldByName("ValueFrom")
+	return hasValue && hasValueFrom && v.Type == stringPtrType && vf.Type == refMapType
+}
+
+// Resolve walks v (a value produced by a dials.Source), replacing any
+// references with their resolved values.
+func (r Resolvers) Resolve(ctx context.Context, v reflect.Value) error {
+	return r.resolve(ctx, v, nil)
+}
+
+func (r Resolvers) resolve(ctx context.Context, v reflect.Value, path []string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.resolve(ctx, v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.resolve(ctx, v.Index(i), append(path, fmt.Sprintf("[%d]", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	if isPointerifiedValue(v.Type()) {
+		return r.resolveValue(ctx, v, path)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if err := r.resolve(ctx, v.Field(i), append(path, sf.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Resolvers) resolveValue(ctx context.Context, v reflect.Value, path []string) error {
+	refs := map[string]KeyRef{}
+	iter := v.FieldByName("ValueFrom").MapRange()
+	for iter.Next() {
+		refs[iter.Key().String()] = keyRef(iter.Value())
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	pathStr := strings.Join(path, ".")
+	if len(refs) > 1 {
+		kinds := make([]string, 0, len(refs))
+		for k := range refs {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		return fmt.Errorf("field %q has multiple references (%s); at most one is allowed",
+			pathStr, strings.Join(kinds, ", "))
+	}
+	for kind, ref := range refs {
+		resolver, ok := r[kind]
+		if !ok {
+			return &ResolveError{Path: pathStr, Kind: kind, Ref: ref,
+				Err: fmt.Errorf("no resolver registered for kind %q", kind)}
+		}
+		resolved, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return &ResolveError{Path: pathStr, Kind: kind, Ref: ref, Err: err}
+		}
+		valField := v.FieldByName("Value")
+		if !valField.CanSet() {
+			return fmt.Errorf("field %q is not settable; unable to store resolved reference", pathStr)
+		}
+		valField.Set(reflect.ValueOf(&resolved))
+	}
+	return nil
+}
+
+// keyRef converts v, a pointer to a pointerified KeyRef, to a KeyRef.
+func keyRef(v reflect.Value) KeyRef {
+	ref := KeyRef{}
+	if v.IsNil() {
+		return ref
+	}
+	if name := v.Elem().FieldByName("Name"); !name.IsNil() {
+		ref.Name = name.Elem().String()
+	}
+	if key := v.Elem().FieldByName("Key"); !key.IsNil() {
+		ref.Key = key.Elem().String()
+	}
+	return ref
+}
+
+// NewSource wraps inner, resolving references in the values it returns (or
+// reports, if it implements dials.Watcher) with the passed resolvers.
+func NewSource(inner dials.Source, resolvers Resolvers) dials.Source {
+	s := source{inner: inner, resolvers: resolvers}
+	if w, ok := inner.(dials.Watcher); ok {
+		return &watchingSource{source: s, watcher: w}
+	}
+	return &s
+}
+
+type source struct {
+	inner     dials.Source
+	resolvers Resolvers
+}
+
+func (s *source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	v, err := s.inner.Value(ctx, t)
+	if err != nil {
+		return v, err
+	}
+	if resolveErr := s.resolvers.Resolve(ctx, v); resolveErr != nil {
+		return reflect.Value{}, resolveErr
+	}
+	return v, nil
+}
+
+type watchingSource struct {
+	source
+	watcher dials.Watcher
+}
+
+func (w *watchingSource) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	return w.watcher.Watch(ctx, t, &resolvingWatchArgs{WatchArgs: args, ctx: ctx, resolvers: w.resolvers})
+}
+
+type resolvingWatchArgs struct {
+	dials.WatchArgs
+	// ctx is the context passed to Watch
+	ctx       context.Context
+	resolvers Resolvers
+}
+
+// Go starts f with the wrapped WatchArgs (see dials.WatchGo).
+func (r *resolvingWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(r.ctx, r.WatchArgs, f)
+}
+
+func (r *resolvingWatchArgs) ReportNewValue(ctx context.Context, val reflect.Value) error {
+	if err := r.resolvers.Resolve(ctx, val); err != nil {
+		return r.WatchArgs.ReportError(ctx, err)
+	}
+	return r.WatchArgs.ReportNewValue(ctx, val)
+}
+
+func (r *resolvingWatchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Value) error {
+	if err := r.resolvers.Resolve(ctx, val); err != nil {
+		return err
+	}
+	return r.WatchArgs.BlockingReportNewValue(ctx, val)
+}
@@ -0,0 +1,91 @@
+package secretref
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type dbConfig struct {
+	User     string
+	Password Value
+}
+
+type config struct {
+	DB       dbConfig
+	Replicas []dbConfig
+}
+
+func TestResolvingSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db-credentials"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db-credentials", "password"), []byte("hunter2\n"), 0o600))
+
+	src := static.StringSource{
+		Data: `
+db:
+  user: admin
+  password:
+    valueFrom:
+      secretKeyRef:
+        name: db-credentials
+        key: password
+replicas:
+  - user: reader
+    password:
+      value: inline
+`,
+		Decoder: &yaml.Decoder{},
+	}
+	d, err := dials.Config(context.Background(), &config{},
+		NewSource(&src, Resolvers{"secretKeyRef": &DirResolver{Dir: dir}}))
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, "admin", cfg.DB.User)
+	assert.Equal(t, "hunter2", cfg.DB.Password.String())
+	require.Len(t, cfg.Replicas, 1)
+	assert.Equal(t, "inline", cfg.Replicas[0].Password.Value)
+}
+
+func TestResolvingSourceErrors(t *testing.T) {
+	t.Parallel()
+	src := static.StringSource{
+		Data: `
+db:
+  password:
+    valueFrom:
+      vaultRef:
+        name: db
+        key: password
+`,
+		Decoder: &yaml.Decoder{},
+	}
+
+	_, err := dials.Config(context.Background(), &config{}, NewSource(&src, Resolvers{}))
+	var resolveErr *ResolveError
+	require.True(t, errors.As(err, &resolveErr), "unexpected error: %v", err)
+	assert.Equal(t, "DB.Password", resolveErr.Path)
+	assert.Equal(t, "vaultRef", resolveErr.Kind)
+
+	backendErr := errors.New("vault sealed")
+	_, err = dials.Config(context.Background(), &config{}, NewSource(&src, Resolvers{
+		"vaultRef": ResolverFunc(func(context.Context, KeyRef) (string, error) { return "", backendErr }),
+	}))
+	assert.ErrorIs(t, err, backendErr)
+}
+
+func TestDirResolverRejectsTraversal(t *testing.T) {
+	t.Parallel()
+	r := DirResolver{Dir: t.TempDir()}
+	_, err := r.Resolve(context.Background(), KeyRef{Name: "..", Key: "passwd"})
+	assert.Error(t, err)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/vimeo/dials"
 	"github.com/vimeo/dials/common"
@@ -13,12 +15,59 @@ import (
 	"github.com/vimeo/dials/transform"
 )
 
-const envTagName = "dialsenv"
+const (
+	envTagName = "dialsenv"
+	// fieldPathTagName is the tag set by the FlattenMangler with the
+	// names of the fields leading to a flattened field.
+	fieldPathTagName = "dialsfieldpath"
+)
+
+// DeprecatedAliasTag is the name of the struct tag listing (comma-separated)
+// old names of the environment variable for a field. They're still read if
+// the current variable isn't set, but using one reports a
+// dials.WarningDeprecated warning, so variables can be renamed without
+// breaking existing deployments. Prefix applies to the aliases too.
+const DeprecatedAliasTag = "dialsenvdeprecated"
 
 // Source implements the dials.Source interface to set configuration from
 // environment variables.
 type Source struct {
+	// Prefix namespaces all environment variable names, so multiple
+	// libraries using dials in the same process don't collide. With a
+	// Prefix of "MYAPP", the field ListenAddr is read from
+	// MYAPP_LISTEN_ADDR. The separating underscore is always added, so
+	// a Prefix of "MYAPP_" reads MYAPP__LISTEN_ADDR.
+	// The prefix applies to names from `dialsenv` and `dials` tags too.
 	Prefix string
+
+	// NameFunc, if non-nil, overrides the built-in convention for naming
+	// the environment variable for a field without a `dialsenv` tag.
+	// fieldPath contains the Go names of the fields leading to the field,
+	// starting at the top-level config struct (e.g. ["Database",
+	// "Password"]); the helpers in the caseconversion package may be
+	// useful for combining them. Prefix is still applied to the result.
+	NameFunc func(fieldPath []string) string
+
+	// LookupEnv, if non-nil, is used instead of os.LookupEnv to read
+	// environment variables, so values can come from somewhere other than
+	// the process environment (e.g. a container's metadata, or a map in a
+	// test). See MapLookup.
+	LookupEnv func(name string) (string, bool)
+
+	// DeprecatedAliasMessage, if non-nil, formats the message of the
+	// dials.WarningDeprecated warning reported (see dials.ReportWarning)
+	// when a deprecated alias declared with the dialsenvdeprecated tag is
+	// used.
+	DeprecatedAliasMessage func(alias, name string) string
+}
+
+// MapLookup returns a function suitable for Source's LookupEnv field that
+// reads variables from m.
+func MapLookup(m map[string]string) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := m[name]
+		return v, ok
+	}
 }
 
 var _ dials.Source = (*Source)(nil)
@@ -29,38 +78,31 @@ var _ dials.Source = (*Source)(nil)
 // present, and finally its name. If the struct field's name is used, Value
 // assumes the name is in Go-style camelCase (e.g., "JSONFilePath") and converts
 // it to UPPER_SNAKE_CASE. (The casing of `dialsenv` and `dials` tags is left
-// unchanged.)
-func (e *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
-	// flatten the nested fields
-	flattenMangler := transform.NewFlattenMangler(common.DialsTagName, caseconversion.EncodeUpperCamelCase, caseconversion.EncodeUpperCamelCase)
-	// reformat the tags so they are SCREAMING_SNAKE_CASE
-	reformatTagMangler := tagformat.NewTagReformattingMangler(common.DialsTagName, caseconversion.DecodeGoTags, caseconversion.EncodeUpperSnakeCase)
-	// copy tags from "dials" to "dialsenv" tag
-	tagCopyingMangler := &tagformat.TagCopyingMangler{SrcTag: common.DialsTagName, NewTag: envTagName}
-	// convert all the fields in the flattened struct to string type so the environment variables can be set
-	stringCastingMangler := &transform.StringCastingMangler{}
-	tfmr := transform.NewTransformer(t.Type(), flattenMangler, reformatTagMangler, tagCopyingMangler, stringCastingMangler)
+// unchanged.) If that variable isn't set, the deprecated aliases in the
+// field's `dialsenvdeprecated` tag are tried in order.
+//
+// If ctx requests it (see dials.DetectingUnusedConfig), Value also reports a
+// dials.WarningUnknownKey warning for each variable in the process
+// environment that starts with Prefix (and its underscore) but isn't read
+// into any field. It can't tell which variables are meant for the config
+// without a Prefix, or enumerate them if LookupEnv is set, so it doesn't look
+// for them then.
+func (e *Source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	tfmr := newTransformer(t.Type())
 
 	val, err := tfmr.Translate()
 	if err != nil {
 		return reflect.Value{}, err
 	}
 
+	lookupEnv := os.LookupEnv
+	if e.LookupEnv != nil {
+		lookupEnv = e.LookupEnv
+	}
+
 	valType := val.Type()
 	for i := 0; i < val.NumField(); i++ {
-		sf := valType.Field(i)
-		envTagVal := sf.Tag.Get(envTagName)
-		if envTagVal == "" {
-			// dialsenv tag should be populated because dials tag is populated
-			// after flatten mangler and we copy from dials to dialsenv tag
-			panic(fmt.Errorf("empty %s tag for field name %s", envTagName, sf.Name))
-		}
-
-		if e.Prefix != "" {
-			envTagVal = e.Prefix + "_" + envTagVal
-		}
-
-		if envVarVal, ok := os.LookupEnv(envTagVal); ok {
+		if envVarVal, ok := e.lookup(ctx, lookupEnv, t.Type(), valType.Field(i)); ok {
 			// The StringCastingMangler has transformed all the fields on the
 			// dials.Type into *string types, so that they can be set here as
 			// strings (and when ReverseTranslate is called, cast into the
@@ -68,6 +110,151 @@ func (e *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error)
 			val.Field(i).Set(reflect.ValueOf(&envVarVal))
 		}
 	}
+	if e.Prefix != "" && e.LookupEnv == nil && dials.DetectingUnusedConfig(ctx) {
+		e.reportUnknown(ctx, t.Type(), valType)
+	}
 
 	return tfmr.ReverseTranslate(val)
 }
+
+// reportUnknown reports a dials.WarningUnknownKey warning for each variable in
+// the process environment with the source's prefix that isn't read into any
+// field of valType, the struct produced by the Transformer from
+// newTransformer for the config type t.
+func (e *Source) reportUnknown(ctx context.Context, t, valType reflect.Type) {
+	known := map[string]struct{}{}
+	for i := 0; i < valType.NumField(); i++ {
+		sf := valType.Field(i)
+		known[e.envVarName(t, sf)] = struct{}{}
+		if aliases := sf.Tag.Get(DeprecatedAliasTag); aliases != "" {
+			for _, alias := range strings.Split(aliases, ",") {
+				known[e.prefixed(strings.TrimSpace(alias))] = struct{}{}
+			}
+		}
+	}
+	prefix := e.prefixed("")
+	unknown := []string{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := known[name]; !ok && strings.HasPrefix(name, prefix) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		dials.ReportWarning(ctx, dials.Warning{
+			Kind:    dials.WarningUnknownKey,
+			Source:  e,
+			Message: fmt.Sprintf("environment variable %s doesn't correspond to any field", name),
+		})
+	}
+}
+
+// FieldNames returns the name of the environment variable read for each leaf
+// field of the (pointerified) config type t, keyed by the path of Go field
+// names joined with dots (e.g. "Database.Password"). It's useful for
+// generating documentation.
+func (e *Source) FieldNames(t *dials.Type) (map[string]string, error) {
+	val, err := newTransformer(t.Type()).Translate()
+	if err != nil {
+		return nil, err
+	}
+	valType := val.Type()
+	names := make(map[string]string, valType.NumField())
+	for i := 0; i < valType.NumField(); i++ {
+		sf := valType.Field(i)
+		path := strings.ReplaceAll(sf.Tag.Get(fieldPathTagName), ",", ".")
+		names[path] = e.envVarName(t.Type(), sf)
+	}
+	return names, nil
+}
+
+// newTransformer constructs the Transformer that maps the (pointerified)
+// config type t to a flat struct with `dialsenv` tags and string fields.
+func newTransformer(t reflect.Type) *transform.Transformer {
+	// flatten the nested fields
+	flattenMangler := transform.NewFlattenMangler(common.DialsTagName, caseconversion.EncodeUpperCamelCase, caseconversion.EncodeUpperCamelCase)
+	// reformat the tags so they are SCREAMING_SNAKE_CASE
+	reformatTagMangler := tagformat.NewTagReformattingMangler(common.DialsTagName, caseconversion.DecodeGoTags, caseconversion.EncodeUpperSnakeCase)
+	// copy tags from "dials" to "dialsenv" tag
+	tagCopyingMangler := &tagformat.TagCopyingMangler{SrcTag: common.DialsTagName, NewTag: envTagName}
+	// convert all the fields in the flattened struct to string type so the environment variables can be set
+	stringCastingMangler := &transform.StringCastingMangler{}
+	// parse sizes with units for fields tagged `dialsunit:"bytes"`
+	unitMangler := &transform.UnitMangler{}
+	return transform.NewTransformer(t, flattenMangler, reformatTagMangler, tagCopyingMangler, unitMangler, stringCastingMangler)
+}
+
+// envVarName returns the name of the environment variable for a field of the
+// struct produced by the Transformer from newTransformer for the config type
+// t.
+func (e *Source) envVarName(t reflect.Type, sf reflect.StructField) string {
+	if e.NameFunc != nil {
+		fieldPath := strings.Split(sf.Tag.Get(fieldPathTagName), ",")
+		if _, explicit := leafField(t, fieldPath).Tag.Lookup(envTagName); !explicit {
+			return e.prefixed(e.NameFunc(fieldPath))
+		}
+	}
+	envTagVal := sf.Tag.Get(envTagName)
+	if envTagVal == "" {
+		// dialsenv tag should be populated because dials tag is populated
+		// after flatten mangler and we copy from dials to dialsenv tag
+		panic(fmt.Errorf("empty %s tag for field name %s", envTagName, sf.Name))
+	}
+
+	return e.prefixed(envTagVal)
+}
+
+// lookup returns the value of the environment variable for the field sf,
+// falling back to its deprecated aliases (reporting the use of one).
+func (e *Source) lookup(ctx context.Context, lookupEnv func(string) (string, bool), t reflect.Type, sf reflect.StructField) (string, bool) {
+	name := e.envVarName(t, sf)
+	if v, ok := lookupEnv(name); ok {
+		return v, true
+	}
+	aliases := sf.Tag.Get(DeprecatedAliasTag)
+	if aliases == "" {
+		return "", false
+	}
+	for _, alias := range strings.Split(aliases, ",") {
+		alias = e.prefixed(strings.TrimSpace(alias))
+		if v, ok := lookupEnv(alias); ok {
+			e.warnDeprecated(ctx, alias, name)
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (e *Source) warnDeprecated(ctx context.Context, alias, name string) {
+	msg := fmt.Sprintf("environment variable %s is deprecated; use %s instead", alias, name)
+	if e.DeprecatedAliasMessage != nil {
+		msg = e.DeprecatedAliasMessage(alias, name)
+	}
+	dials.ReportWarning(ctx, dials.Warning{
+		Kind:    dials.WarningDeprecated,
+		Source:  e,
+		Message: msg,
+	})
+}
+
+func (e *Source) prefixed(name string) string {
+	if e.Prefix == "" {
+		return name
+	}
+	return e.Prefix + "_" + name
+}
+
+// leafField returns the field in t (a struct, or pointer to a struct) found
+// by following the field names in fieldPath.
+func leafField(t reflect.Type, fieldPath []string) reflect.StructField {
+	var sf reflect.StructField
+	for _, name := range fieldPath {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		sf, _ = t.FieldByName(name)
+		t = sf.Type
+	}
+	return sf
+}
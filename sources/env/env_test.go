@@ -2,7 +2,9 @@ package env
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -139,6 +141,38 @@ func TestEnv(t *testing.T) {
 			Source:      Source{Prefix: "PREFIX"},
 			Expected:    &struct{ EnvVar string }{EnvVar: "asdf"},
 		},
+		"prefixed_string_trailing_underscore": {
+			Run: func(ctx context.Context, src *Source) (any, error) {
+				cfg := struct{ EnvVar string }{}
+				return testSafeDialsRet(dials.Config(context.Background(), &cfg, src))
+			},
+			EnvVarName:  "MYAPP__ENV_VAR",
+			EnvVarValue: "asdf",
+			Source:      Source{Prefix: "MYAPP_"},
+			Expected:    &struct{ EnvVar string }{EnvVar: "asdf"},
+		},
+		"prefixed_string_with_env_tag": {
+			Run: func(ctx context.Context, src *Source) (any, error) {
+				cfg := struct {
+					EnvVar string `dialsenv:"ENVIRONMENT_VARIABLE"`
+				}{}
+				return testSafeDialsRet(dials.Config(context.Background(), &cfg, src))
+			},
+			EnvVarName:  "MYAPP_ENVIRONMENT_VARIABLE",
+			EnvVarValue: "asdf",
+			Source:      Source{Prefix: "MYAPP"},
+			Expected:    &struct{ EnvVar string }{EnvVar: "asdf"},
+		},
+		"prefixed_unprefixed_var_ignored": {
+			Run: func(ctx context.Context, src *Source) (any, error) {
+				cfg := struct{ EnvVar string }{}
+				return testSafeDialsRet(dials.Config(context.Background(), &cfg, src))
+			},
+			EnvVarName:  "ENV_VAR",
+			EnvVarValue: "asdf",
+			Source:      Source{Prefix: "MYAPP"},
+			Expected:    &struct{ EnvVar string }{EnvVar: ""},
+		},
 		"zero_value_string": {
 			Run: func(ctx context.Context, src *Source) (any, error) {
 				cfg := struct{ EnvVar string }{}
@@ -283,3 +317,154 @@ func TestEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvNameFunc(t *testing.T) {
+	type Database struct {
+		Host string
+		Port int `dialsenv:"DB_PORT"`
+	}
+	type Config struct {
+		Name     string
+		Database Database
+	}
+	t.Setenv("APP__NAME", "widget")
+	t.Setenv("APP__DATABASE__HOST", "db.local")
+	t.Setenv("APP_DB_PORT", "5432")
+	// the built-in convention is ignored
+	t.Setenv("APP_DATABASE_HOST", "ignored")
+
+	src := Source{
+		Prefix: "APP",
+		NameFunc: func(fieldPath []string) string {
+			return "_" + strings.ToUpper(strings.Join(fieldPath, "__"))
+		},
+	}
+	d, err := dials.Config(context.Background(), &Config{}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Name: "widget", Database: Database{Host: "db.local", Port: 5432}}, d.View())
+}
+
+func TestEnvLookupEnv(t *testing.T) {
+	type Config struct {
+		Hello string
+		Count int
+	}
+	// the process environment is ignored
+	t.Setenv("HELLO", "from process")
+
+	src := Source{LookupEnv: MapLookup(map[string]string{
+		"HELLO": "from map",
+		"COUNT": "3",
+	})}
+	d, err := dials.Config(context.Background(), &Config{}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Hello: "from map", Count: 3}, d.View())
+}
+
+func TestEnvTime(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		End   time.Time `dialstimelayout:"DateOnly|RFC3339"`
+	}
+	src := Source{LookupEnv: MapLookup(map[string]string{
+		"START": "2020-01-02T03:04:05Z",
+		"END":   "2021-06-07",
+	})}
+	d, err := dials.Config(context.Background(), &Config{}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Start: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		End:   time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC),
+	}, d.View())
+
+	src = Source{LookupEnv: MapLookup(map[string]string{"END": "06/07/2021"})}
+	_, err = dials.Config(context.Background(), &Config{}, &src)
+	assert.Error(t, err)
+}
+
+func TestEnvStructMap(t *testing.T) {
+	type Backend struct {
+		Host string
+		Port int
+	}
+	type Config struct {
+		Backends map[string]Backend `dialsmerge:"merge"`
+	}
+	src := Source{LookupEnv: MapLookup(map[string]string{
+		"BACKENDS": "primary.Port:6432,replica.Host:db2",
+	})}
+	d, err := dials.Config(context.Background(), &Config{Backends: map[string]Backend{
+		"primary": {Host: "db1", Port: 5432},
+	}}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Backend{
+		"primary": {Host: "db1", Port: 6432},
+		"replica": {Host: "db2"},
+	}, d.View().Backends)
+}
+
+func TestEnvRawJSON(t *testing.T) {
+	type Config struct {
+		Plugin json.RawMessage
+	}
+	src := Source{LookupEnv: MapLookup(map[string]string{"PLUGIN": `{"addr": "localhost:6379"}`})}
+	d, err := dials.Config(context.Background(), &Config{}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{"addr": "localhost:6379"}`), d.View().Plugin)
+
+	src = Source{LookupEnv: MapLookup(map[string]string{"PLUGIN": `{"addr"`})}
+	_, err = dials.Config(context.Background(), &Config{}, &src)
+	assert.Error(t, err)
+}
+
+func TestEnvDeprecatedAlias(t *testing.T) {
+	ctx := context.Background()
+	type Database struct {
+		Host string `dialsenvdeprecated:"DB_HOSTNAME"`
+	}
+	type Config struct {
+		ListenAddr string `dialsenvdeprecated:"ADDR, LISTEN"`
+		Database   Database
+	}
+	var warnings []dials.Warning
+	params := dials.Params[Config]{
+		OnWarning: func(_ context.Context, w dials.Warning) { warnings = append(warnings, w) },
+	}
+
+	src := &Source{Prefix: "APP", LookupEnv: MapLookup(map[string]string{
+		"APP_LISTEN":      ":8080",
+		"APP_DB_HOSTNAME": "db1",
+	})}
+	d, err := params.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{ListenAddr: ":8080", Database: Database{Host: "db1"}}, d.View())
+	assert.ElementsMatch(t, []dials.Warning{{
+		Kind:    dials.WarningDeprecated,
+		Source:  src,
+		Message: "environment variable APP_LISTEN is deprecated; use APP_LISTEN_ADDR instead",
+	}, {
+		Kind:    dials.WarningDeprecated,
+		Source:  src,
+		Message: "environment variable APP_DB_HOSTNAME is deprecated; use APP_DATABASE_HOST instead",
+	}}, warnings)
+
+	// the current name takes precedence, without any warning
+	warnings = nil
+	src.LookupEnv = MapLookup(map[string]string{
+		"APP_LISTEN_ADDR": ":9090",
+		"APP_ADDR":        ":8080",
+	})
+	d, err = params.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", d.View().ListenAddr)
+	assert.Empty(t, warnings)
+
+	// the message is configurable
+	src.LookupEnv = MapLookup(map[string]string{"APP_ADDR": ":8080"})
+	src.DeprecatedAliasMessage = func(alias, name string) string { return alias + " will be removed; set " + name }
+	d, err = params.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", d.View().ListenAddr)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "APP_ADDR will be removed; set APP_LISTEN_ADDR", warnings[0].Message)
+}
@@ -0,0 +1,163 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/transform"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// EnvironOpt is an option for Environ.
+type EnvironOpt func(*environOpts)
+
+type environOpts struct {
+	redact func(fieldPath []string) bool
+}
+
+// WithRedaction omits the variables for fields for which redact returns true
+// (e.g. secrets that shouldn't be passed to a subprocess). fieldPath contains
+// the Go names of the fields leading to the field, starting at the top-level
+// config struct (e.g. ["Database", "Password"]).
+func WithRedaction(redact func(fieldPath []string) bool) EnvironOpt {
+	return func(o *environOpts) {
+		o.redact = redact
+	}
+}
+
+// Environ renders cfg (a pointer to a config struct) as environment
+// variables in "NAME=value" form (as used by os/exec.Cmd's Env field), using
+// the same names and formats that Value reads. This keeps the configuration
+// of exec'd subprocesses that read their configuration with an identically
+// configured Source consistent with the parent's.
+//
+// Every field is rendered (including zero-values), in the order the fields
+// appear in the struct.
+func (e *Source) Environ(cfg interface{}, opts ...EnvironOpt) ([]string, error) {
+	o := environOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config of type %T is not a pointer to a struct", cfg)
+	}
+	if err := ptrify.CheckCycles(v.Elem().Type()); err != nil {
+		return nil, err
+	}
+	ptrType := ptrify.Pointerify(v.Elem().Type(), v.Elem())
+	tfmr := newTransformer(ptrType)
+	val, err := tfmr.Translate()
+	if err != nil {
+		return nil, err
+	}
+
+	valType := val.Type()
+	out := make([]string, 0, valType.NumField())
+	for i := 0; i < valType.NumField(); i++ {
+		sf := valType.Field(i)
+		if o.redact != nil && o.redact(strings.Split(sf.Tag.Get(fieldPathTagName), ",")) {
+			continue
+		}
+		str, fmtErr := formatValue(transform.GetField(sf, v))
+		if fmtErr != nil {
+			return nil, fmt.Errorf("failed to render field %q: %w", sf.Tag.Get(fieldPathTagName), fmtErr)
+		}
+		out = append(out, e.envVarName(ptrType, sf)+"="+str)
+	}
+	return out, nil
+}
+
+// formatValue formats v so it's parsed back into the same value by
+// parse.String.
+func formatValue(v reflect.Value) (string, error) {
+	if s, ok := parse.Format(v); ok {
+		return s, nil
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Complex64:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 64), nil
+	case reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 128), nil
+	case reflect.Slice:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			s, err := formatValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			elems[i] = strconv.Quote(s)
+		}
+		return strings.Join(elems, ","), nil
+	case reflect.Map:
+		return formatMap(v)
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func formatMap(v reflect.Value) (string, error) {
+	type kv struct{ k, v string }
+	pairs := make([]kv, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := formatValue(iter.Key())
+		if err != nil {
+			return "", err
+		}
+		switch val := iter.Value(); {
+		case val.Kind() == reflect.Struct && val.NumField() == 0:
+			// sets are represented as a list of keys
+			pairs = append(pairs, kv{k: strconv.Quote(k)})
+		case val.Kind() == reflect.Slice:
+			// map[string][]string repeats the key for each value
+			for i := 0; i < val.Len(); i++ {
+				s, err := formatValue(val.Index(i))
+				if err != nil {
+					return "", err
+				}
+				pairs = append(pairs, kv{k: strconv.Quote(k), v: strconv.Quote(s)})
+			}
+		default:
+			s, err := formatValue(val)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, kv{k: strconv.Quote(k), v: strconv.Quote(s)})
+		}
+	}
+	// sort for deterministic output (stable, to preserve slice order)
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].k < pairs[j].k })
+	elems := make([]string, len(pairs))
+	for i, p := range pairs {
+		if v.Type().Elem().Kind() == reflect.Struct {
+			elems[i] = p.k
+		} else {
+			elems[i] = p.k + ":" + p.v
+		}
+	}
+	return strings.Join(elems, ","), nil
+}
@@ -0,0 +1,82 @@
+package env
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+func TestEnvironRoundTrip(t *testing.T) {
+	type Database struct {
+		Host     string
+		Port     uint16
+		Password string
+	}
+	type Config struct {
+		Name     string
+		Verbose  bool
+		Ratio    float64
+		Timeout  time.Duration
+		Tags     []string
+		Ports    []int
+		Labels   map[string]string
+		Features map[string]struct{}
+		Groups   map[string][]string
+		Database Database
+		Renamed  string `dialsenv:"CUSTOM_NAME"`
+	}
+	cfg := Config{
+		Name:     `quoted "name", with comma`,
+		Verbose:  true,
+		Ratio:    0.25,
+		Timeout:  90 * time.Second,
+		Tags:     []string{"a", "b,c"},
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"team": "core", "tier": "1"},
+		Features: map[string]struct{}{"x": {}, "y": {}},
+		Groups:   map[string][]string{"admins": {"alice", "bob"}},
+		Database: Database{Host: "db", Port: 5432, Password: "hunter2"},
+		Renamed:  "custom",
+	}
+
+	src := Source{Prefix: "CHILD"}
+	environ, err := src.Environ(&cfg)
+	require.NoError(t, err)
+	assert.Contains(t, environ, "CHILD_TIMEOUT=1m30s")
+	assert.Contains(t, environ, "CHILD_DATABASE_PORT=5432")
+	assert.Contains(t, environ, "CHILD_CUSTOM_NAME=custom")
+	assert.Contains(t, environ, `CHILD_LABELS="team":"core","tier":"1"`)
+
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	d, err := dials.Config(context.Background(), &Config{}, &src)
+	require.NoError(t, err)
+	assert.Equal(t, &cfg, d.View())
+}
+
+func TestEnvironRedaction(t *testing.T) {
+	type Database struct {
+		Host     string
+		Password string
+	}
+	type Config struct {
+		Database Database
+	}
+
+	environ, err := (&Source{}).Environ(&Config{Database: Database{Host: "db", Password: "hunter2"}},
+		WithRedaction(func(path []string) bool {
+			return path[len(path)-1] == "Password"
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DATABASE_HOST=db"}, environ)
+
+	_, err = (&Source{}).Environ(Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandPath expands path the way a shell would expand a path passed on the
+// command line, so user-supplied paths (e.g. from a --config flag) behave as
+// expected: a leading `~` (or `~user`) is replaced by the home directory
+// (of that user), environment variables referenced as $VAR or ${VAR} are
+// replaced by their values, and a path that's still relative is resolved
+// against baseDir (or the working directory, if baseDir is empty).
+// Referencing an unset environment variable is an error. The returned path
+// is absolute and clean.
+func ExpandPath(path, baseDir string) (string, error) {
+	expanded, err := expandTilde(path)
+	if err != nil {
+		return "", err
+	}
+	var unset []string
+	expanded = os.Expand(expanded, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return v
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("path %q references unset environment variable(s) %s",
+			path, strings.Join(unset, ", "))
+	}
+	if !filepath.IsAbs(expanded) && baseDir != "" {
+		expanded = filepath.Join(baseDir, expanded)
+	}
+	absPath, absErr := filepath.Abs(expanded)
+	if absErr != nil {
+		return "", fmt.Errorf("failed to make path %q absolute: %s", expanded, absErr)
+	}
+	return absPath, nil
+}
+
+// expandTilde replaces a leading `~` or `~user` in path with the home
+// directory of the current or named user.
+func expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, "/"+string(filepath.Separator)); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	if name == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %q: %w", path, err)
+		}
+		return home + rest, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %q: %w", path, err)
+	}
+	return u.HomeDir + rest, nil
+}
@@ -0,0 +1,56 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("DIALS_TEST_ENV", "production")
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	base := filepath.Join(home, "etc")
+
+	for _, tbl := range []struct {
+		name    string
+		path    string
+		baseDir string
+		want    string
+	}{
+		{name: "tilde", path: "~/config.yaml", want: filepath.Join(home, "config.yaml")},
+		{name: "bare_tilde", path: "~", want: home},
+		{name: "home_var", path: "$HOME/.svc/config.yaml", want: filepath.Join(home, ".svc", "config.yaml")},
+		{name: "braced_var", path: "~/${DIALS_TEST_ENV}.yaml", want: filepath.Join(home, "production.yaml")},
+		{name: "relative", path: "config.yaml", want: filepath.Join(wd, "config.yaml")},
+		{name: "relative_base_dir", path: "svc/$DIALS_TEST_ENV.yaml", baseDir: base,
+			want: filepath.Join(base, "svc", "production.yaml")},
+		{name: "absolute_ignores_base_dir", path: filepath.Join(home, "a", "..", "b.yaml"), baseDir: base,
+			want: filepath.Join(home, "b.yaml")},
+		{name: "tilde_ignores_base_dir", path: "~/b.yaml", baseDir: base, want: filepath.Join(home, "b.yaml")},
+		{name: "tilde_not_leading", path: "a/~/b.yaml", baseDir: base, want: filepath.Join(base, "a", "~", "b.yaml")},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			got, err := ExpandPath(tbl.path, tbl.baseDir)
+			require.NoError(t, err)
+			assert.Equal(t, tbl.want, got)
+		})
+	}
+
+	_, err = ExpandPath("$DIALS_TEST_UNSET/config.yaml", "")
+	assert.ErrorContains(t, err, "DIALS_TEST_UNSET")
+
+	_, err = ExpandPath("~dials-test-no-such-user/config.yaml", "")
+	assert.Error(t, err)
+
+	src, err := NewWatchingSource("$DIALS_TEST_ENV.json", nil, WithBaseDir(base))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(base, "production.json"), src.path)
+}
@@ -14,6 +14,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,19 +22,33 @@ import (
 	"github.com/vimeo/dials"
 )
 
-// NewSource converts path to an absolute path and returns a source for that file.
+// NewSource expands path (see ExpandPath) into an absolute path and returns a
+// source for that file.
 func NewSource(path string, decoder dials.Decoder) (*Source, error) {
-	absPath, absErr := filepath.Abs(path)
-	if absErr != nil {
-		return nil, fmt.Errorf("failed to make path %q absolute: %s", path, absErr)
+	absPath, expandErr := ExpandPath(path, "")
+	if expandErr != nil {
+		return nil, expandErr
 	}
 	return &Source{path: absPath, decoder: decoder}, nil
 }
 
 // Source is a raw file source.
 // Errors reported by the wrapped decoder will be reported wrapped in a
-// DecoderErr with the error and file-path populated.
+// DecoderErr with the error and file-path populated, and failures to open
+// the file in a dials.UnavailableError.
 type Source struct {
+	// Includes enables including other files: the files listed under
+	// the top-level IncludeKey (a file name or a list of them) are read
+	// with the same decoder, and the values they set are overridden by
+	// those set by the including file (and later files in the list
+	// override earlier ones). Decoders' own include mechanisms (such as
+	// the YAML decoder's `!include` tag) are enabled too. Relative names
+	// are resolved against the directory of the including file, included
+	// files may include others, and include cycles are reported as
+	// errors (see dials.IncludeCycleError). A WatchingSource watches the
+	// included files as well.
+	Includes bool
+
 	path    string
 	decoder dials.Decoder
 	// We use a random HMAC key for each run since it's not much more
@@ -44,8 +59,26 @@ type Source struct {
 	hmacKey        []byte
 	lastHMACSHA256 []byte
 	hmacMu         sync.Mutex
+
+	// included holds the paths of the files included by the last call
+	// to Value
+	included   []string
+	includedMu sync.Mutex
+}
+
+// IncludeKey is the top-level key listing the files included by a config
+// file read by a Source with Includes enabled, e.g. `"include":
+// ["base.json", "db.json"]` in JSON or `include: base.yaml` in YAML.
+const IncludeKey = "include"
+
+// includeDirective is decoded from files read by Sources with Includes
+// enabled to find the included files.
+type includeDirective struct {
+	Include interface{} `dials:"include"`
 }
 
+var includeDirectiveType = dials.NewType(reflect.TypeOf(includeDirective{}))
+
 var _ dials.Source = (*Source)(nil)
 
 func (s *Source) initKey() error {
@@ -108,17 +141,38 @@ func (d *DecoderErr) Unwrap() error {
 }
 
 // Value opens the file and passes it to the Decoder.
-func (s *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+func (s *Source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
 	f, openErr := os.Open(s.path)
 	if openErr != nil {
-		return reflect.Value{}, openErr
+		return reflect.Value{}, &dials.UnavailableError{Err: openErr}
 	}
 	defer f.Close()
 
 	r, csummer := s.hmacReader(f)
-	decoded, decErr := s.decoder.Decode(r, t)
+	var decoded reflect.Value
+	var decErr error
+	if s.Includes {
+		opener := includeOpener{h: csummer}
+		decoded, decErr = s.decodeIncluding(dials.ContextWithFile(ctx, s.path, opener.open), t, s.path, r)
+		s.includedMu.Lock()
+		s.included = opener.paths
+		s.includedMu.Unlock()
+	} else {
+		decoded, decErr = dials.Decode(ctx, s.decoder, r, t)
+		if decErr != nil {
+			decErr = &DecoderErr{Err: decErr, Path: s.path, Decoder: s.decoder}
+		}
+	}
 	if decErr != nil {
-		return decoded, &DecoderErr{Err: decErr, Path: s.path, Decoder: s.decoder}
+		return decoded, decErr
+	}
+	if csummer == nil {
+		return decoded, nil
+	}
+	// Make sure the checksum covers the whole file, even if the decoder
+	// stopped reading early.
+	if _, drainErr := io.Copy(io.Discard, r); drainErr != nil {
+		return decoded, nil
 	}
 	csum := csummer.Sum(nil)
 	if s.lastHMACNew(csum) {
@@ -127,10 +181,140 @@ func (s *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error)
 	return decoded, nil
 }
 
+// decodeIncluding decodes the file at path (read from r) and the files it
+// includes (see Includes), composing their values.
+func (s *Source) decodeIncluding(ctx context.Context, t *dials.Type, path string, r io.Reader) (reflect.Value, error) {
+	b, readErr := io.ReadAll(r)
+	if readErr != nil {
+		return reflect.Value{}, fmt.Errorf("failed to read %q: %w", path, readErr)
+	}
+	directive, decErr := dials.Decode(ctx, s.decoder, bytes.NewReader(b), includeDirectiveType)
+	if decErr != nil {
+		return reflect.Value{}, &DecoderErr{Err: decErr, Path: path, Decoder: s.decoder}
+	}
+	for directive.Kind() == reflect.Ptr && !directive.IsNil() {
+		directive = directive.Elem()
+	}
+	if directive.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("unexpected %s decoding %q key of %q", directive.Type(), IncludeKey, path)
+	}
+	names, namesErr := includeNames(directive.FieldByName("Include"))
+	if namesErr != nil {
+		return reflect.Value{}, fmt.Errorf("invalid %q key in %q: %w", IncludeKey, path, namesErr)
+	}
+	vals := make([]reflect.Value, 0, len(names)+1)
+	for _, name := range names {
+		inc, incCtx, openErr := dials.OpenIncluded(ctx, name)
+		if openErr != nil {
+			return reflect.Value{}, openErr
+		}
+		incPath := name
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(path), incPath)
+		}
+		v, incErr := s.decodeIncluding(incCtx, t, incPath, inc)
+		inc.Close()
+		if incErr != nil {
+			return reflect.Value{}, incErr
+		}
+		vals = append(vals, v)
+	}
+	decoded, decErr := dials.Decode(ctx, s.decoder, bytes.NewReader(b), t)
+	if decErr != nil {
+		return reflect.Value{}, &DecoderErr{Err: decErr, Path: path, Decoder: s.decoder}
+	}
+	if len(vals) == 0 {
+		return decoded, nil
+	}
+	return dials.ComposeValues(t, append(vals, decoded)...)
+}
+
+// includeNames returns the names of the included files from the (decoded)
+// value of the IncludeKey, which may be a single name or a list of them.
+func includeNames(v reflect.Value) ([]string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []string{v.String()}, nil
+	case reflect.Slice:
+		names := make([]string, v.Len())
+		for i := range names {
+			e := v.Index(i)
+			if e.Kind() == reflect.Interface {
+				e = e.Elem()
+			}
+			if e.Kind() != reflect.String {
+				return nil, fmt.Errorf("element %d is a %s, not a file name", i, e.Kind())
+			}
+			names[i] = e.String()
+		}
+		return names, nil
+	default:
+		return nil, fmt.Errorf("must be a file name or a list of them, not a %s", v.Kind())
+	}
+}
+
+// includeOpener opens the files included by a Source's file, recording their
+// paths and adding their contents to the Source's checksum.
+type includeOpener struct {
+	h     hash.Hash
+	paths []string
+}
+
+func (o *includeOpener) open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// (files may be read more than once, e.g. when a YAML file using
+	// `!include` tags is decoded to find its IncludeKey)
+	seen := false
+	for _, p := range o.paths {
+		seen = seen || p == path
+	}
+	if !seen {
+		o.paths = append(o.paths, path)
+	}
+	if o.h == nil {
+		return f, nil
+	}
+	return &checksummedFile{Reader: io.TeeReader(f, o.h), f: f}, nil
+}
+
+// checksummedFile is an included file whose contents are added to a
+// checksum as they're read.
+type checksummedFile struct {
+	io.Reader
+	f *os.File
+}
+
+// Close reads the rest of the file, so the checksum covers all of it, then
+// closes it.
+func (c *checksummedFile) Close() error {
+	io.Copy(io.Discard, c.Reader)
+	return c.f.Close()
+}
+
+// includedFiles returns the paths of the files included by the last call to
+// Value.
+func (s *Source) includedFiles() []string {
+	s.includedMu.Lock()
+	defer s.includedMu.Unlock()
+	return s.included
+}
+
 // WatchOpts contains options, which can be mutated by a WatchOpt
 type WatchOpts struct {
 	logger       StdLogger
 	pollInterval time.Duration
+	debounce     time.Duration
+	includes     bool
+	baseDir      string
 	sigCh        chan os.Signal
 }
 
@@ -153,6 +337,34 @@ func WithPollInterval(pollInterval time.Duration) WatchOpt {
 	}
 }
 
+// WithDebounce configures the new WatchingSource to wait until no
+// filesystem events have arrived for the debounce interval before rereading
+// the file, so a burst of events (e.g. from an editor or provisioning tool
+// writing the file in several steps) results in a single reread, rather
+// than one per event (each of which may find the file incomplete).
+func WithDebounce(debounce time.Duration) WatchOpt {
+	return func(o *WatchOpts) {
+		o.debounce = debounce
+	}
+}
+
+// WithIncludes enables including other files in the file read by the new
+// WatchingSource (see Source.Includes).
+func WithIncludes() WatchOpt {
+	return func(o *WatchOpts) {
+		o.includes = true
+	}
+}
+
+// WithBaseDir configures the directory that relative paths passed to
+// NewWatchingSource (or NewLayeredSource) are resolved against, instead of
+// the working directory (see ExpandPath).
+func WithBaseDir(dir string) WatchOpt {
+	return func(o *WatchOpts) {
+		o.baseDir = dir
+	}
+}
+
 // WithSignalChannel configures the new WatchingSource to use the provided
 // channel as a manual trigger for rereading the config file (useful with SIGHUP).
 func WithSignalChannel(sigCh chan os.Signal) WatchOpt {
@@ -162,30 +374,32 @@ func WithSignalChannel(sigCh chan os.Signal) WatchOpt {
 }
 
 // NewWatchingSource creates a new file watching source that will reload and
-// notify if the file is updated.
+// notify if the file is updated. path is expanded into an absolute path with
+// ExpandPath.
 func NewWatchingSource(
 	path string,
 	decoder dials.Decoder,
 	opts ...WatchOpt,
 ) (*WatchingSource, error) {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert path (%q) to an absolute path: %s",
-			path, err)
-	}
-
 	o := WatchOpts{}
 
 	for _, opt := range opts {
 		opt(&o)
 	}
 
+	absPath, err := ExpandPath(path, o.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
 	return &WatchingSource{
 		Source: Source{
-			path:    absPath,
-			decoder: decoder,
+			Includes: o.includes,
+			path:     absPath,
+			decoder:  decoder,
 		},
 		PollInterval: o.pollInterval,
+		Debounce:     o.debounce,
 		Reload:       o.sigCh,
 		logger:       logWrapper{log: o.logger},
 	}, nil
@@ -193,18 +407,29 @@ func NewWatchingSource(
 
 // WatchingSource uses fsnotify (inotify, dtrace, etc) to watch for changes to a file
 // Errors reported by the wrapped decoder will be reported wrapped in a
-// DecoderErr with the error and file-path populated.
+// DecoderErr with the error and file-path populated, and failures to open
+// the file in a dials.UnavailableError.
+// New values are only reported if the file's contents have changed (as
+// determined by a checksum), so rewriting the file with the same contents
+// doesn't trigger a restack.
 type WatchingSource struct {
 	Source
 	Reload       chan os.Signal
 	PollInterval time.Duration
-	WG           sync.WaitGroup
-	watcher      *fsnotify.Watcher
-	logger       logWrapper
+	// Debounce, if positive, delays rereading the file after a filesystem
+	// event until no further events have arrived for Debounce (see
+	// WithDebounce). Polling and Reload signals aren't delayed.
+	Debounce time.Duration
+	WG       sync.WaitGroup
+	watcher  *fsnotify.Watcher
+	logger   logWrapper
+	// cancel stops the goroutine started by Watch
+	cancel context.CancelFunc
 }
 
 var _ dials.Source = (*WatchingSource)(nil)
 var _ dials.Watcher = (*WatchingSource)(nil)
+var _ dials.StoppableWatcher = (*WatchingSource)(nil)
 
 // Watch Sets up an fsnotify Watcher and starts a background goroutine for watching changes.
 func (ws *WatchingSource) Watch(
@@ -213,8 +438,9 @@ func (ws *WatchingSource) Watch(
 	args dials.WatchArgs) error {
 	cleanedPath := filepath.Clean(ws.path)
 
-	// remove one-level of symlinks
-	resolvedCfgPath, symlinkErr := filepath.EvalSymlinks(cleanedPath)
+	// resolve the symlinks leading to the file (e.g. the `..data`
+	// symlink of a Kubernetes ConfigMap mount)
+	paths, symlinkErr := resolveWatchedPaths(cleanedPath, ws.includedFiles())
 	if symlinkErr != nil {
 		return fmt.Errorf("failed to follow symlinks from %q: %s",
 			cleanedPath, symlinkErr)
@@ -229,50 +455,158 @@ func (ws *WatchingSource) Watch(
 		return fmt.Errorf("failed to setup watch on file %q: %s",
 			cleanedPath, addErr)
 	}
-	if addErr := ws.watcher.Add(filepath.Dir(cleanedPath)); addErr != nil {
-		return fmt.Errorf("failed to setup watch on directory %q: %s",
-			cleanedPath, addErr)
-	}
-	if cleanedPath != resolvedCfgPath {
-		if addErr := ws.watcher.Add(filepath.Dir(resolvedCfgPath)); addErr != nil {
-			return fmt.Errorf("failed to setup watch on symlink-target dir %q: %s",
-				filepath.Dir(resolvedCfgPath), addErr)
+	for _, dir := range paths.dirs {
+		if addErr := ws.watcher.Add(dir); addErr != nil {
+			return fmt.Errorf("failed to setup watch on directory %q: %s",
+				dir, addErr)
 		}
 	}
 
+	ctx, ws.cancel = context.WithCancel(ctx)
 	ws.WG.Add(1)
-	go ws.watchLoop(ctx, t, cleanedPath, resolvedCfgPath, args)
+	dials.WatchGo(ctx, args, func(context.Context) { ws.watchLoop(ctx, t, cleanedPath, paths, args) })
 	return nil
 }
 
+// StopWatch stops the goroutine started by Watch and closes the underlying
+// fsnotify watcher, waiting until it's exited or ctx expires.
+func (ws *WatchingSource) StopWatch(ctx context.Context) error {
+	if ws.cancel != nil {
+		ws.cancel()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ws.WG.Wait()
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("context expired while awaiting watch goroutine exit: %w", ctx.Err())
+	}
+}
+
 // Kubernetes uses its AtomicWriter for updating configmaps, which has
 // a somewhat unique structure:
 //
 //	It creates a timestamped tempdir named by
 //	`ioutil.TempDir(w.targetDir, time.Now().UTC().Format("..2006_01_02_15_04_05."))`
-//	which contains all the "projected" files, and a `..dir` symlink to that directory.
-//	It then creates symlinks from the user-visible location into the
-//	`..dir` directory, so it can populate a new timestamped dir and do
-//	an atomic rename (from `..data_tmp` to `..data`) of the symlink to
+//	which contains all the "projected" files, and a `..data` symlink to that
+//	directory. The user-visible files are symlinks into the `..data`
+//	directory, so it can populate a new timestamped dir, symlink it as
+//	`..data_tmp`, and atomically rename `..data_tmp` over `..data` to
 //	make all contents of the configmap atomically updatable.
-//	The timestamped directory is then symlinked to `..data_tmp`, which
-//	is then atomically renamed over the `..data` directory.
 //	At this point, we don't care, but it then cleans up the old directory.
 //	doc for the k8s AtomicWriter: https://godoc.org/k8s.io/kubernetes/pkg/volume/util#AtomicWriter
 //
-// The upshot is that we need to watch the parent directory for
-// renames, (which may show up as create/delete pairs) that affect the
-// parent directory after one-level of symlink resolution (not
-// recursively resolved)
+// The upshot is that the file we're watching doesn't change when the
+// configmap is updated: the `..data` symlink it points through does. So we
+// resolve every symlink on the way to the file (not just the last one),
+// watch the directories containing each of them (where renames show up as
+// create/delete pairs), and re-resolve them after every change, moving the
+// watches as the symlinks are retargeted.
 //
 // Note: we can be a bit liberal about watching because we verify
 // content-changes with an HMAC-SHA256 before reporting anything upstream.
-const k8sIntermediateSymlinkDir = "..dir"
+
+// watchedPaths holds the paths relevant to watching a file (and the files it
+// includes).
+type watchedPaths struct {
+	// resolved is the file's path with all symlinks resolved
+	resolved string
+	// names contains the paths whose events may indicate a change to the
+	// file: the file itself (with and without symlinks resolved), every
+	// symlink traversed while resolving it, and the directories in dirs
+	names map[string]struct{}
+	// dirs lists the directories containing those paths, which need to
+	// be watched
+	dirs []string
+}
+
+func resolveWatchedPaths(cleanedPath string, includes []string) (watchedPaths, error) {
+	links, resolved, err := symlinkChain(cleanedPath)
+	if err != nil {
+		return watchedPaths{}, err
+	}
+	wp := watchedPaths{resolved: resolved, names: map[string]struct{}{}}
+	wp.add(append([]string{cleanedPath, resolved}, links...))
+	for _, inc := range includes {
+		// Included files that can't be resolved (e.g. because they
+		// were removed) aren't watched.
+		if incLinks, incResolved, incErr := symlinkChain(inc); incErr == nil {
+			wp.add(append([]string{inc, incResolved}, incLinks...))
+		}
+	}
+	return wp, nil
+}
+
+func (wp *watchedPaths) add(paths []string) {
+	for _, p := range paths {
+		wp.names[p] = struct{}{}
+		dir := filepath.Dir(p)
+		if _, ok := wp.names[dir]; !ok {
+			wp.names[dir] = struct{}{}
+			wp.dirs = append(wp.dirs, dir)
+		}
+	}
+}
+
+// maxSymlinks bounds the number of symlinks symlinkChain follows, so
+// symlink loops fail (like filepath.EvalSymlinks) rather than hang.
+const maxSymlinks = 255
+
+// symlinkChain resolves all the symlinks in path (which must be absolute and
+// clean) like filepath.EvalSymlinks, also returning the path of each
+// symlink it traversed, in order.
+func symlinkChain(path string) ([]string, string, error) {
+	links := []string{}
+	vol := filepath.VolumeName(path)
+	sep := string(filepath.Separator)
+	rest := strings.Split(path[len(vol):], sep)
+	cur := vol + sep
+	for len(rest) > 0 {
+		comp := rest[0]
+		rest = rest[1:]
+		switch comp {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+		next := filepath.Join(cur, comp)
+		fi, err := os.Lstat(next)
+		if err != nil {
+			return nil, "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			cur = next
+			continue
+		}
+		if len(links) == maxSymlinks {
+			return nil, "", fmt.Errorf("too many links resolving %q", path)
+		}
+		links = append(links, next)
+		target, err := os.Readlink(next)
+		if err != nil {
+			return nil, "", err
+		}
+		if filepath.IsAbs(target) {
+			tvol := filepath.VolumeName(target)
+			cur = tvol + sep
+			target = target[len(tvol):]
+		}
+		rest = append(strings.Split(target, sep), rest...)
+	}
+	return links, cur, nil
+}
 
 func (ws *WatchingSource) watchLoop(
 	ctx context.Context,
 	t *dials.Type,
-	cleanedPath, resolvedCfgPath string,
+	cleanedPath string,
+	paths watchedPaths,
 	args dials.WatchArgs,
 ) {
 	defer ws.WG.Done()
@@ -286,10 +620,22 @@ func (ws *WatchingSource) watchLoop(
 		defer ticker.Stop()
 	}
 
+	// While a debounced reread is pending, debounceTimer is its timer.
+	var debounceTimer *time.Timer
+	var debounceChan <-chan time.Time
+	defer func() {
+		if debounceTimer != nil {
+			debounceTimer.Stop()
+		}
+	}()
+
+	// If the circuit-breaker is open, pausedUntil is the end of the
+	// cool-down and resumeChan fires when it elapses.
+	pausedUntil := time.Time{}
+	var resumeChan <-chan time.Time
+
 	watchingFile := true
 	eventNumber := 0
-	cleanedPathDir := filepath.Dir(cleanedPath)
-	cleanedPathDirPlusDir := filepath.Join(cleanedPathDir, k8sIntermediateSymlinkDir)
 MAINLOOP:
 	for {
 		select {
@@ -300,28 +646,43 @@ MAINLOOP:
 				return
 			}
 			eventNumber++
-			// Filter events down to those pointing at the filename
-			// and its parent (both with and without symlinks
-			// resolved.
-			switch ev.Name {
-			case resolvedCfgPath, cleanedPath, cleanedPathDir,
-				cleanedPathDirPlusDir, filepath.Dir(resolvedCfgPath):
-			default:
+			// Filter events down to those pointing at the filename,
+			// the symlinks leading to it and their parents (see
+			// watchedPaths).
+			if _, ok := paths.names[ev.Name]; !ok {
 				continue MAINLOOP
 			}
+			if ws.Debounce > 0 {
+				// (re)start the quiet period
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.NewTimer(ws.Debounce)
+				debounceChan = debounceTimer.C
+				continue MAINLOOP
+			}
+		case <-debounceChan:
+			debounceTimer, debounceChan = nil, nil
 		case _, ok := <-ws.watcher.Errors:
 			if !ok {
 				return
 			}
 			// The only documented error here is an event queue overflow, in which case we missed some events.
 			// Fortunately, we can fall-through and get the config itself back into sync.
+		case <-resumeChan:
+			resumeChan = nil
 		case <-ctx.Done():
 			return
 		}
+		if time.Now().Before(pausedUntil) {
+			// Backing off. A re-read is already scheduled for
+			// the end of the cool-down.
+			continue
+		}
 
 		newVal, parseErr := ws.Value(ctx, t)
 
-		configExists := !os.IsNotExist(parseErr)
+		configExists := !errors.Is(parseErr, os.ErrNotExist)
 		if !configExists {
 			// if the file was renamed or deleted/unlinked,
 			// remove its watch.
@@ -341,10 +702,20 @@ MAINLOOP:
 			// the config doesn't exist; just resume the loop.
 			continue
 		}
-		oldResolvedCfgDir := filepath.Dir(resolvedCfgPath)
-		// If the config exists, update the new symlink-path
-		if newResolvedPath, symlinkErr := filepath.EvalSymlinks(cleanedPath); symlinkErr == nil {
-			resolvedCfgPath = newResolvedPath
+		// If the config exists, re-resolve the symlinks leading to it
+		if newPaths, symlinkErr := resolveWatchedPaths(cleanedPath, ws.includedFiles()); symlinkErr == nil {
+			if newPaths.resolved != paths.resolved && watchingFile {
+				// The watch on the file follows the symlinks
+				// as they were when it was added, so re-add it
+				// to watch the new target.
+				if removeErr := ws.watcher.Remove(cleanedPath); removeErr != nil {
+					ws.logger.Printf("failed to remove watcher for retargeted path %q: %s",
+						cleanedPath, removeErr)
+				}
+				watchingFile = false
+			}
+			ws.updateDirWatches(paths.dirs, newPaths.dirs)
+			paths = newPaths
 		}
 		if !watchingFile {
 			if addErr := ws.watcher.Add(cleanedPath); addErr != nil {
@@ -354,8 +725,8 @@ MAINLOOP:
 				watchingFile = true
 			}
 		}
-		ws.updateDirWatches(oldResolvedCfgDir, filepath.Dir(resolvedCfgPath))
 
+		var reportErr error
 		switch t := parseErr.(type) {
 		case nil:
 			// no error, report upward
@@ -366,29 +737,51 @@ MAINLOOP:
 		case *os.SyscallError:
 			if !errors.Is(t, os.ErrNotExist) {
 				// the file exists, something else failed.
-				args.ReportError(ctx, t)
+				reportErr = args.ReportError(ctx, t)
 			}
 		default:
-			args.ReportError(ctx, t)
+			reportErr = args.ReportError(ctx, t)
+		}
+		var openErr *dials.CircuitOpenError
+		if errors.As(reportErr, &openErr) {
+			pausedUntil = openErr.Until
+			resumeChan = time.After(time.Until(pausedUntil))
 		}
 	}
 
 }
 
-func (ws *WatchingSource) updateDirWatches(oldResolvedCfgDir, resolvedCfgDir string) {
-	if oldResolvedCfgDir == resolvedCfgDir {
-		return
+func (ws *WatchingSource) updateDirWatches(oldDirs, newDirs []string) {
+	watched := make(map[string]struct{}, len(oldDirs))
+	for _, dir := range oldDirs {
+		watched[dir] = struct{}{}
 	}
-	// If the config's resolved directory has changed, make sure we
-	// remove the old watch after the new one is added so we don't lose change notifications
-	if addErr := ws.watcher.Add(resolvedCfgDir); addErr != nil {
-		ws.logger.Printf("failed to add new watch for symlink-resolved directory: %q: %s",
-			resolvedCfgDir, addErr)
+	// If the directories containing the config or its symlinks have
+	// changed, make sure we remove the old watches after the new ones are
+	// added so we don't lose change notifications
+	addFailed := false
+	for _, dir := range newDirs {
+		if _, ok := watched[dir]; ok {
+			delete(watched, dir)
+			continue
+		}
+		if addErr := ws.watcher.Add(dir); addErr != nil {
+			ws.logger.Printf("failed to add new watch for symlink-resolved directory: %q: %s",
+				dir, addErr)
+			addFailed = true
+		}
+	}
+	if addFailed {
 		return
 	}
-	if removeErr := ws.watcher.Remove(oldResolvedCfgDir); removeErr != nil {
-		ws.logger.Printf("failed to remove old watch for old symlink-resolved directory: %q: %s",
-			oldResolvedCfgDir, removeErr)
+	for _, dir := range oldDirs {
+		if _, ok := watched[dir]; !ok {
+			continue
+		}
+		if removeErr := ws.watcher.Remove(dir); removeErr != nil {
+			ws.logger.Printf("failed to remove old watch for old symlink-resolved directory: %q: %s",
+				dir, removeErr)
+		}
 	}
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vimeo/dials"
 	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/dialstest"
 )
 
 type testStdLogger struct {
@@ -94,6 +95,33 @@ func TestWatchingFile(t *testing.T) {
 	assert.Equal(t, 4, c.NumBeatles)
 }
 
+func TestWatchingFileClose(t *testing.T) {
+	// not parallel, so leaked goroutines can be detected
+	dialstest.CheckGoroutines(t)
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	cfgPath := writeTestConfig(t, dir, `{"secretOfLife": 42}`)
+
+	watchingFile, watchingErr := NewWatchingSource(cfgPath, &json.Decoder{}, WithLogger(&testStdLogger{t}))
+	require.NoError(t, watchingErr, "construction failure")
+
+	// Close must stop the watch goroutine without canceling this context
+	ctx := context.Background()
+	d, err := dials.Config(ctx, &config{}, watchingFile)
+	require.NoError(t, err)
+	assert.Equal(t, 42, d.View().SecretOfLife)
+
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, d.Close(closeCtx))
+	// the watch goroutine has exited (as Close waits for it)
+	watchingFile.WG.Wait()
+	_, ok := <-d.Events()
+	assert.False(t, ok)
+}
+
 func TestWatchingFileWithRelativePathAndChdir(t *testing.T) {
 	initWD, wdErr := os.Getwd()
 	require.NoError(t, wdErr, "get working directory")
@@ -285,11 +313,117 @@ func TestWatchingFileWithTrickle(t *testing.T) {
 	assert.Equal(t, 4, c.NumBeatles)
 }
 
+func TestWatchingFileWithDebounce(t *testing.T) {
+	t.Parallel()
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	firstConfig := writeTestConfig(t, dir, `{
+        "secretOfLife": 42,
+        "numBeatles": 4
+    }`)
+	defer os.Remove(firstConfig)
+
+	myConfig := &config{}
+
+	watchingFile, watchingErr := NewWatchingSource(firstConfig, &json.Decoder{},
+		WithLogger(&testStdLogger{t}), WithDebounce(500*time.Millisecond))
+	require.NoError(t, watchingErr, "construction failure")
+	defer watchingFile.WG.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCount := int32(0)
+	d, err := dials.Params[config]{
+		OnWatchedError: func(context.Context, error, *config, *config) {
+			atomic.AddInt32(&errCount, 1)
+		},
+	}.Config(ctx, myConfig, watchingFile)
+	require.NoError(t, err)
+
+	// open and truncate the config file, then write the new configuration
+	// one character at a time: none of the intermediate states should be
+	// read, so no parse errors should be reported.
+	f, err := os.OpenFile(firstConfig, os.O_RDWR|os.O_TRUNC, 0640)
+	require.NoError(t, err)
+	for _, char := range `{"secretOfLife": 11, "numBeatles": 4}` {
+		f.Write([]byte{byte(char)})
+		f.Sync()
+	}
+	f.Close()
+
+	c := <-d.Events()
+	assert.Equal(t, 11, c.SecretOfLife)
+	assert.Equal(t, 4, c.NumBeatles)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&errCount))
+}
+
+func TestWatchingFileUnchangedContents(t *testing.T) {
+	t.Parallel()
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	const contents = `{"secretOfLife": 42, "numBeatles": 4}`
+	firstConfig := writeTestConfig(t, dir, contents)
+	defer os.Remove(firstConfig)
+
+	myConfig := &config{}
+
+	watchingFile, watchingErr := NewWatchingSource(firstConfig, &json.Decoder{}, WithLogger(&testStdLogger{t}))
+	require.NoError(t, watchingErr, "construction failure")
+	defer watchingFile.WG.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := dials.Config(ctx, myConfig, watchingFile)
+	require.NoError(t, err)
+
+	// rewriting the file with identical contents (in place, and by
+	// renaming a copy over it) shouldn't produce a new version
+	require.NoError(t, ioutil.WriteFile(firstConfig, []byte(contents), 0640))
+	require.NoError(t, os.Chmod(firstConfig, 0600))
+	require.NoError(t, os.Rename(writeTestConfig(t, dir, contents), firstConfig))
+
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	select {
+	case c := <-d.Events():
+		t.Errorf("unexpected new version for unchanged contents: %+v", c)
+	case <-timer.C:
+	}
+
+	require.NoError(t, os.Rename(writeTestConfig(t, dir, `{"secretOfLife": 47, "numBeatles": 4}`), firstConfig))
+	c := <-d.Events()
+	assert.Equal(t, 47, c.SecretOfLife)
+}
+
 func TestWatchingFileWithK8SEmulatedAtomicWriter(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("k8s config updates are more complicated on windows skipping for now.")
 	}
 	t.Parallel()
+	for _, tbl := range []struct {
+		name     string
+		dataDir  string
+		cleanOld bool
+	}{
+		{name: "intermediate_dir", dataDir: "..dir"},
+		// The layout of a ConfigMap mount, where the AtomicWriter
+		// also removes each timestamped directory once it's replaced.
+		{name: "configmap_mount", dataDir: "..data", cleanOld: true},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			t.Parallel()
+			testK8SEmulatedAtomicWriter(t, tbl.dataDir, tbl.cleanOld)
+		})
+	}
+}
+
+func testK8SEmulatedAtomicWriter(t *testing.T, subdirPath string, cleanOld bool) {
 
 	const fname = "fimbat.json"
 
@@ -299,8 +433,7 @@ func TestWatchingFileWithK8SEmulatedAtomicWriter(t *testing.T) {
 
 	configPath := filepath.Join(wdir, fname)
 
-	const subdirPath = "..dir"
-	const subdirTmpPath = "..dir_tmp"
+	subdirTmpPath := subdirPath + "_tmp"
 
 	const firstTSDir = "..timestamped_dir-1."
 	symlinkPath := filepath.Join(wdir, subdirPath)
@@ -376,6 +509,10 @@ func TestWatchingFileWithK8SEmulatedAtomicWriter(t *testing.T) {
 
 		require.NoErrorf(t, os.Rename(fullSubdirTmpPath, symlinkPath), "failed to rename from %q to %q", fullSubdirTmpPath, symlinkPath)
 		<-received
+		if cleanOld {
+			prevTSDir := filepath.Join(wdir, fmt.Sprintf("..timestamped_dir-%d.", i-1))
+			require.NoErrorf(t, os.RemoveAll(prevTSDir), "failed to remove old tsdir %q", prevTSDir)
+		}
 	}
 
 	cancel()
@@ -391,6 +528,45 @@ func TestWatchingFileWithK8SEmulatedAtomicWriter(t *testing.T) {
 	assert.Equal(t, 4, c.NumBeatles)
 }
 
+func TestSymlinkChain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on windows")
+	}
+	t.Parallel()
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	// symlinkChain returns paths with the temporary directory's own
+	// symlinks (e.g. /tmp on macOS) resolved
+	dir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	tsDir := filepath.Join(dir, "..2024_01_02_03_04_05.1")
+	require.NoError(t, os.Mkdir(tsDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tsDir, "config.json"), []byte("{}"), 0644))
+	require.NoError(t, os.Symlink(filepath.Base(tsDir), filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink("..data/config.json", filepath.Join(dir, "config.json")))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.Symlink("../config.json", filepath.Join(dir, "sub", "up.json")))
+
+	links, resolved, err := symlinkChain(filepath.Join(dir, "sub", "up.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "sub", "up.json"),
+		filepath.Join(dir, "config.json"),
+		filepath.Join(dir, "..data"),
+	}, links)
+	assert.Equal(t, filepath.Join(tsDir, "config.json"), resolved)
+
+	require.NoError(t, os.Symlink("loop2", filepath.Join(dir, "loop1")))
+	require.NoError(t, os.Symlink("loop1", filepath.Join(dir, "loop2")))
+	_, _, err = symlinkChain(filepath.Join(dir, "loop1"))
+	assert.Error(t, err)
+
+	_, _, err = symlinkChain(filepath.Join(dir, "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
 const watchingFilePattern = "watching-file"
 
 func writeTestConfig(t testing.TB, dir, data string) string {
@@ -401,3 +577,103 @@ func writeTestConfig(t testing.TB, dir, data string) string {
 	f.WriteString(data)
 	return f.Name()
 }
+
+func TestSourceIncludes(t *testing.T) {
+	t.Parallel()
+	type dbConfig struct {
+		Host string
+		Port int
+	}
+	type includeConfig struct {
+		Name  string
+		Debug bool
+		DB    dbConfig
+		Tags  []string
+	}
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	writeFile := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+	writeFile("base.json", `{"name": "base", "debug": true, "tags": ["base"], "db": {"host": "localhost", "port": 5432}}`)
+	// included files' includes are relative to them
+	writeFile("fragments/db.json", `{"include": "../base.json", "db": {"host": "db.example.com"}}`)
+	main := writeFile("config.json", `{"include": ["base.json", "fragments/db.json"], "name": "svc"}`)
+
+	src, err := NewSource(main, &json.Decoder{})
+	require.NoError(t, err)
+	src.Includes = true
+	d, err := dials.Config(context.Background(), &includeConfig{}, src)
+	require.NoError(t, err)
+	// fields set by the including file override those set by included
+	// files, and later includes override earlier ones
+	assert.Equal(t, includeConfig{
+		Name:  "svc",
+		Debug: true,
+		DB:    dbConfig{Host: "db.example.com", Port: 5432},
+		Tags:  []string{"base"},
+	}, *d.View())
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "base.json"),
+		filepath.Join(dir, "fragments", "db.json"),
+	}, src.includedFiles())
+
+	// without Includes, the key is ignored
+	src, err = NewSource(main, &json.Decoder{})
+	require.NoError(t, err)
+	d, err = dials.Config(context.Background(), &includeConfig{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, includeConfig{Name: "svc"}, *d.View())
+
+	writeFile("base.json", `{"include": "config.json"}`)
+	src, err = NewSource(main, &json.Decoder{})
+	require.NoError(t, err)
+	src.Includes = true
+	_, err = dials.Config(context.Background(), &includeConfig{}, src)
+	cycleErr := &dials.IncludeCycleError{}
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{main, filepath.Join(dir, "base.json"), main}, cycleErr.Chain)
+
+	writeFile("base.json", `{"include": 42}`)
+	_, err = dials.Config(context.Background(), &includeConfig{}, src)
+	assert.ErrorContains(t, err, `invalid "include" key`)
+
+	writeFile("base.json", `{"include": "missing.json"}`)
+	_, err = dials.Config(context.Background(), &includeConfig{}, src)
+	assert.ErrorContains(t, err, "missing.json")
+}
+
+func TestWatchingFileIncludes(t *testing.T) {
+	t.Parallel()
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	incDir := filepath.Join(dir, "fragments")
+	require.NoError(t, os.Mkdir(incDir, 0755))
+	included := filepath.Join(incDir, "beatles.json")
+	require.NoError(t, ioutil.WriteFile(included, []byte(`{"numBeatles": 4}`), 0644))
+	main := writeTestConfig(t, dir, `{"include": "fragments/beatles.json", "secretOfLife": 42}`)
+
+	watchingFile, watchingErr := NewWatchingSource(main, &json.Decoder{},
+		WithLogger(&testStdLogger{t}), WithIncludes())
+	require.NoError(t, watchingErr, "construction failure")
+	defer watchingFile.WG.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := dials.Config(ctx, &config{}, watchingFile)
+	require.NoError(t, err)
+	assert.Equal(t, config{SecretOfLife: 42, NumBeatles: 4}, *d.View())
+
+	// changes to the included file are picked up
+	tmp := writeTestConfig(t, incDir, `{"numBeatles": 5}`)
+	require.NoError(t, os.Rename(tmp, included))
+	c := <-d.Events()
+	assert.Equal(t, config{SecretOfLife: 42, NumBeatles: 5}, *c)
+}
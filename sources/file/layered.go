@@ -0,0 +1,149 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vimeo/dials"
+)
+
+// NewLayeredSource creates a LayeredSource stacking the files at paths (in
+// increasing order of precedence), which are read with decoder. The opts
+// configure the watching of each file (see NewWatchingSource).
+func NewLayeredSource(paths []string, decoder dials.Decoder, opts ...WatchOpt) (*LayeredSource, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no files to layer")
+	}
+	layers := make([]*WatchingSource, len(paths))
+	for i, path := range paths {
+		ws, err := NewWatchingSource(path, decoder, opts...)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = ws
+	}
+	return &LayeredSource{layers: layers, values: make([]reflect.Value, len(paths))}, nil
+}
+
+// LayeredSource stacks several config files read with the same decoder (e.g.
+// base.yaml, production.yaml and local-overrides.yaml) into a single source:
+// fields set by later files override those set by earlier ones, as if a
+// Source for each file were passed to dials.Config in order (see
+// dials.ComposeValues). Every file must exist.
+//
+// As a Watcher, it watches each of the files like a WatchingSource, and
+// reports a new value, composed from the latest contents of all the files,
+// whenever any of them changes.
+type LayeredSource struct {
+	layers []*WatchingSource
+
+	// mu serializes composing and reporting values, so a composed value
+	// never replaces a more recent one
+	mu sync.Mutex
+	// values holds the last value read from each layer
+	values []reflect.Value
+	// watching is the number of layers still watching their files
+	watching int
+}
+
+var _ dials.Source = (*LayeredSource)(nil)
+var _ dials.Watcher = (*LayeredSource)(nil)
+var _ dials.StoppableWatcher = (*LayeredSource)(nil)
+
+// Value reads each of the files, and returns the composition of their values.
+func (l *LayeredSource) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	values := make([]reflect.Value, len(l.layers))
+	for i, layer := range l.layers {
+		v, err := layer.Value(ctx, t)
+		unchanged := &unchangedCSumErr{}
+		if err != nil && !errors.As(err, &unchanged) {
+			return reflect.Value{}, err
+		}
+		values[i] = v
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values = values
+	return dials.ComposeValues(t, values...)
+}
+
+// Watch starts watching each of the files (see WatchingSource.Watch).
+func (l *LayeredSource) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	l.mu.Lock()
+	l.watching = len(l.layers)
+	l.mu.Unlock()
+	for i, layer := range l.layers {
+		if err := layer.Watch(ctx, t, &layerWatchArgs{WatchArgs: args, ctx: ctx, l: l, i: i, t: t}); err != nil {
+			for _, started := range l.layers[:i] {
+				started.StopWatch(ctx)
+			}
+			return fmt.Errorf("failed to watch layer %d (%q): %w", i, layer.path, err)
+		}
+	}
+	return nil
+}
+
+// StopWatch stops watching each of the files (see WatchingSource.StopWatch).
+func (l *LayeredSource) StopWatch(ctx context.Context) error {
+	var firstErr error
+	for _, layer := range l.layers {
+		if err := layer.StopWatch(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// layerWatchArgs receives the values of one of a LayeredSource's files, and
+// reports the composed values to the LayeredSource's WatchArgs.
+type layerWatchArgs struct {
+	dials.WatchArgs
+	// ctx is the context passed to the LayeredSource's Watch method
+	ctx context.Context
+	l   *LayeredSource
+	// i is the index of the layer
+	i int
+	t *dials.Type
+}
+
+func (a *layerWatchArgs) report(ctx context.Context, val reflect.Value, blocking bool) error {
+	a.l.mu.Lock()
+	defer a.l.mu.Unlock()
+	a.l.values[a.i] = val
+	composed, err := dials.ComposeValues(a.t, a.l.values...)
+	if err != nil {
+		return a.WatchArgs.ReportError(ctx, err)
+	}
+	if blocking {
+		return a.WatchArgs.BlockingReportNewValue(ctx, composed)
+	}
+	return a.WatchArgs.ReportNewValue(ctx, composed)
+}
+
+// Go starts the layer's goroutines with the LayeredSource's WatchArgs (see
+// dials.WatchGo).
+func (a *layerWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(a.ctx, a.WatchArgs, f)
+}
+
+func (a *layerWatchArgs) ReportNewValue(ctx context.Context, val reflect.Value) error {
+	return a.report(ctx, val, false)
+}
+
+func (a *layerWatchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Value) error {
+	return a.report(ctx, val, true)
+}
+
+// Done reports that the LayeredSource is done once every layer is.
+func (a *layerWatchArgs) Done(ctx context.Context) {
+	a.l.mu.Lock()
+	a.l.watching--
+	done := a.l.watching == 0
+	a.l.mu.Unlock()
+	if done {
+		a.WatchArgs.Done(ctx)
+	}
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+)
+
+func TestLayeredSource(t *testing.T) {
+	t.Parallel()
+	type layeredConfig struct {
+		Name    string
+		Port    int
+		Debug   bool
+		Plugins []string `dialsmerge:"append"`
+	}
+
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+	writeFile := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+	base := writeFile("base.json", `{"name": "base", "port": 80, "plugins": ["metrics"]}`)
+	env := writeFile("production.json", `{"port": 443, "plugins": ["tls"]}`)
+	local := writeFile("local.json", `{"debug": true}`)
+
+	src, err := NewLayeredSource([]string{base, env, local}, &json.Decoder{}, WithLogger(&testStdLogger{t}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := dials.Config(ctx, &layeredConfig{}, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, layeredConfig{
+		Name:    "base",
+		Port:    443,
+		Debug:   true,
+		Plugins: []string{"metrics", "tls"},
+	}, *d.View())
+
+	// a change to any of the files recomposes all of them
+	tmp := writeFile("production.json.tmp", `{"port": 8443}`)
+	require.NoError(t, os.Rename(tmp, env))
+	c := <-d.Events()
+	assert.Equal(t, layeredConfig{
+		Name:    "base",
+		Port:    8443,
+		Debug:   true,
+		Plugins: []string{"metrics"},
+	}, *c)
+
+	tmp = writeFile("base.json.tmp", `{"name": "svc", "port": 80}`)
+	require.NoError(t, os.Rename(tmp, base))
+	c = <-d.Events()
+	assert.Equal(t, layeredConfig{Name: "svc", Port: 8443, Debug: true}, *c)
+
+	_, err = NewLayeredSource(nil, &json.Decoder{})
+	assert.Error(t, err)
+
+	missing, err := NewLayeredSource([]string{base, filepath.Join(dir, "missing.json")}, &json.Decoder{})
+	require.NoError(t, err)
+	_, err = dials.Config(ctx, &layeredConfig{}, missing)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,81 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NotFoundError is returned by Locate when none of the candidate paths is an
+// existing file. It wraps os.ErrNotExist.
+type NotFoundError struct {
+	// Searched lists the (expanded) paths that were searched, in order.
+	Searched []string
+}
+
+func (e *NotFoundError) Error() string {
+	return "no config file found (searched " + strings.Join(e.Searched, ", ") + ")"
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return os.ErrNotExist
+}
+
+// Locate returns the first of paths that's an existing file (or a symlink
+// to one), after expanding it with ExpandPath, so candidates may refer to
+// `~` and environment variables. Candidates referring to unset environment
+// variables (such as $XDG_CONFIG_HOME, if it isn't set) are skipped. If
+// there's no such file, it returns a *NotFoundError.
+//
+// Combine it with StandardPaths to find a config file in the conventional
+// locations, or pass paths in any other order:
+//
+//	path, err := file.Locate(file.StandardPaths("myapp", "config.yaml")...)
+func Locate(paths ...string) (string, error) {
+	searched := make([]string, 0, len(paths))
+	for _, p := range paths {
+		expanded, err := ExpandPath(p, "")
+		if err != nil {
+			continue
+		}
+		searched = append(searched, expanded)
+		if fi, statErr := os.Stat(expanded); statErr == nil && fi.Mode().IsRegular() {
+			return expanded, nil
+		}
+	}
+	return "", &NotFoundError{Searched: searched}
+}
+
+// StandardPaths returns the conventional locations of the config file named
+// fileName (e.g. "config.yaml") for the application app, in the order Locate
+// should search them:
+//   - app with fileName's extension in the working directory (e.g.
+//     ./myapp.yaml)
+//   - fileName in the app directory in $XDG_CONFIG_HOME (or ~/.config if
+//     it's unset), e.g. ~/.config/myapp/config.yaml
+//   - fileName in the app directory in each of the directories in
+//     $XDG_CONFIG_DIRS (or /etc/xdg if it's unset)
+//   - fileName in the app directory in /etc, e.g. /etc/myapp/config.yaml
+//
+// The paths are returned unexpanded, so they can be reordered or
+// supplemented before they're passed to Locate.
+func StandardPaths(app, fileName string) []string {
+	paths := []string{
+		filepath.Join(".", app+filepath.Ext(fileName)),
+	}
+	if _, ok := os.LookupEnv("XDG_CONFIG_HOME"); ok {
+		paths = append(paths, filepath.Join("$XDG_CONFIG_HOME", app, fileName))
+	} else {
+		paths = append(paths, filepath.Join("~", ".config", app, fileName))
+	}
+	configDirs := "/etc/xdg"
+	if dirs, ok := os.LookupEnv("XDG_CONFIG_DIRS"); ok && dirs != "" {
+		configDirs = dirs
+	}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if dir != "" {
+			paths = append(paths, filepath.Join(dir, app, fileName))
+		}
+	}
+	return append(paths, filepath.Join(string(filepath.Separator), "etc", app, fileName))
+}
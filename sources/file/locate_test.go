@@ -0,0 +1,73 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_DIRS", "/opt/xdg"+string(filepath.ListSeparator)+"/usr/local/etc/xdg")
+	t.Setenv("XDG_CONFIG_HOME", "/home/test/.xdg")
+	assert.Equal(t, []string{
+		"myapp.yaml",
+		filepath.Join("$XDG_CONFIG_HOME", "myapp", "config.yaml"),
+		filepath.Join("/opt/xdg", "myapp", "config.yaml"),
+		filepath.Join("/usr/local/etc/xdg", "myapp", "config.yaml"),
+		filepath.Join("/etc", "myapp", "config.yaml"),
+	}, StandardPaths("myapp", "config.yaml"))
+
+	os.Unsetenv("XDG_CONFIG_HOME")
+	os.Unsetenv("XDG_CONFIG_DIRS")
+	assert.Equal(t, []string{
+		"myapp.toml",
+		filepath.Join("~", ".config", "myapp", "config.toml"),
+		filepath.Join("/etc/xdg", "myapp", "config.toml"),
+		filepath.Join("/etc", "myapp", "config.toml"),
+	}, StandardPaths("myapp", "config.toml"))
+}
+
+func TestLocate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	dirs := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", dirs)
+
+	paths := StandardPaths("dials-locate-test", "config.yaml")
+	_, err := Locate(paths...)
+	notFound := &NotFoundError{}
+	require.ErrorAs(t, err, &notFound)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Len(t, notFound.Searched, len(paths))
+
+	write := func(path string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+	}
+	sysPath := filepath.Join(dirs, "dials-locate-test", "config.yaml")
+	write(sysPath)
+	got, err := Locate(paths...)
+	require.NoError(t, err)
+	assert.Equal(t, sysPath, got)
+
+	// the user's config takes precedence over the system-wide one
+	userPath := filepath.Join(home, "xdg", "dials-locate-test", "config.yaml")
+	write(userPath)
+	got, err = Locate(paths...)
+	require.NoError(t, err)
+	assert.Equal(t, userPath, got)
+
+	// directories don't count, and candidates referencing unset
+	// environment variables are skipped
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "dir.yaml"), 0755))
+	got, err = Locate("~/dir.yaml", "$DIALS_TEST_UNSET/config.yaml", sysPath)
+	require.NoError(t, err)
+	assert.Equal(t, sysPath, got)
+}
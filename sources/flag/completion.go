@@ -0,0 +1,181 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shell identifies a shell for which WriteCompletion can generate a script.
+type Shell string
+
+const (
+	// Bash generates a script to be sourced by bash (e.g. from
+	// /etc/bash_completion.d)
+	Bash Shell = "bash"
+	// Zsh generates a completion function to be placed in a file named
+	// _<prog> in a directory on $fpath
+	Zsh Shell = "zsh"
+	// Fish generates a script to be placed in
+	// ~/.config/fish/completions/<prog>.fish
+	Fish Shell = "fish"
+)
+
+// completionFlag describes a flag for completion scripts.
+type completionFlag struct {
+	name        string
+	description string
+	takesValue  bool
+	choices     []string
+}
+
+func (s *Set) completionFlags() []completionFlag {
+	out := []completionFlag{}
+	s.Flags.VisitAll(func(f *flag.Flag) {
+		if _, hidden := s.hidden[f.Name]; hidden {
+			return
+		}
+		_, usage := flag.UnquoteUsage(f)
+		// only the first line is useful as a description
+		usage, _, _ = strings.Cut(usage, "\n")
+		takesValue := true
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			takesValue = false
+		}
+		out = append(out, completionFlag{
+			name:        f.Name,
+			description: usage,
+			takesValue:  takesValue,
+			choices:     s.flagChoices[f.Name],
+		})
+	})
+	return out
+}
+
+// WriteCompletion writes a script that completes the flags registered in the
+// Set's FlagSet (other than hidden ones) for the program named prog in the
+// given shell. Values of flags with an EnumTag are completed too.
+func (s *Set) WriteCompletion(w io.Writer, shell Shell, prog string) error {
+	flags := s.completionFlags()
+	var script string
+	switch shell {
+	case Bash:
+		script = bashCompletion(prog, flags)
+	case Zsh:
+		script = zshCompletion(prog, flags)
+	case Fish:
+		script = fishCompletion(prog, flags)
+	default:
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// shellIdent converts prog to a string usable in shell function names.
+func shellIdent(prog string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, prog)
+}
+
+// singleQuote quotes s for use as a single word in a POSIX shell (or fish).
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func bashCompletion(prog string, flags []completionFlag) string {
+	fn := "_" + shellIdent(prog) + "_completions"
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "# bash completion for %s\n", prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+
+	valueCases := strings.Builder{}
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if !f.takesValue {
+			continue
+		}
+		words := "-f"
+		if len(f.choices) > 0 {
+			words = "-W " + singleQuote(strings.Join(f.choices, " "))
+		}
+		fmt.Fprintf(&valueCases, "        -%[1]s|--%[1]s)\n", f.name)
+		fmt.Fprintf(&valueCases, "            COMPREPLY=($(compgen %s -- \"$val\"))\n", words)
+		valueCases.WriteString("            return 0\n            ;;\n")
+	}
+
+	// bash splits -flag=value into three words by default
+	b.WriteString("    local opt=\"$prev\" val=\"$cur\"\n")
+	b.WriteString("    if [[ \"$prev\" == \"=\" ]]; then\n")
+	b.WriteString("        opt=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	b.WriteString("    elif [[ \"$cur\" == \"=\" ]]; then\n")
+	b.WriteString("        val=\"\"\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case \"$opt\" in\n")
+	b.WriteString(valueCases.String())
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", singleQuote(strings.Join(names, " ")))
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, prog)
+	return b.String()
+}
+
+// zshEscape escapes characters that are special in _arguments specs.
+var zshEscape = strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`, `'`, `'\''`, `\`, `\\`)
+
+func zshCompletion(prog string, flags []completionFlag) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "#compdef %s\n\n", prog)
+	b.WriteString("_arguments \\\n")
+	for _, f := range flags {
+		spec := "-" + f.name
+		if f.takesValue {
+			spec += "="
+		}
+		spec += "[" + zshEscape.Replace(f.description) + "]"
+		if f.takesValue {
+			action := "_default"
+			if len(f.choices) > 0 {
+				escaped := make([]string, len(f.choices))
+				for i, c := range f.choices {
+					escaped[i] = zshEscape.Replace(c)
+				}
+				action = "(" + strings.Join(escaped, " ") + ")"
+			}
+			spec += ":" + zshEscape.Replace(f.name) + ":" + action
+		}
+		fmt.Fprintf(&b, "  '%s' \\\n", spec)
+	}
+	b.WriteString("  '*::arg:_default'\n")
+	return b.String()
+}
+
+func fishCompletion(prog string, flags []completionFlag) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "# fish completion for %s\n", prog)
+	for _, f := range flags {
+		fmt.Fprintf(&b, "complete -c %s -o %s", singleQuote(prog), singleQuote(f.name))
+		if f.description != "" {
+			fmt.Fprintf(&b, " -d %s", singleQuote(f.description))
+		}
+		switch {
+		case len(f.choices) > 0:
+			fmt.Fprintf(&b, " -x -a %s", singleQuote(strings.Join(f.choices, " ")))
+		case f.takesValue:
+			b.WriteString(" -r")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
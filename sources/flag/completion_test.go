@@ -0,0 +1,78 @@
+package flag
+
+import (
+	"bytes"
+	"flag"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type completionConfig struct {
+	Mode    string `dialsenum:"fast,slow" dialsdesc:"the [mode]: it's"`
+	Name    string `dialsdesc:"the name"`
+	Verbose bool   `dialsflag:"verbose,v" dialsdesc:"log verbosely"`
+	Secret  bool   `dialsflag:",hidden"`
+}
+
+func completionScript(t *testing.T, shell Shell) string {
+	t.Helper()
+	fs := flag.NewFlagSet("my-prog", flag.ContinueOnError)
+	src, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &completionConfig{}, fs)
+	require.NoError(t, err)
+	buf := bytes.Buffer{}
+	require.NoError(t, src.WriteCompletion(&buf, shell, "my-prog"))
+	assert.NotContains(t, buf.String(), "secret")
+	return buf.String()
+}
+
+func TestBashCompletion(t *testing.T) {
+	script := completionScript(t, Bash)
+	assert.Contains(t, script, "complete -o default -F _my_prog_completions my-prog\n")
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	for _, tbl := range []struct {
+		words []string
+		want  string
+	}{
+		{words: []string{"my-prog", "-v"}, want: "-v -verbose"},
+		{words: []string{"my-prog", "-mode", "s"}, want: "slow"},
+		{words: []string{"my-prog", "-mode", "=", "f"}, want: "fast"},
+		{words: []string{"my-prog", "-mode", "="}, want: "fast slow"},
+	} {
+		args := append([]string{"-c", script + `
+COMP_WORDS=("$@"); COMP_CWORD=$(($# - 1)); _my_prog_completions; echo -n "${COMPREPLY[@]}"`, "bash"},
+			tbl.words...)
+		cmd := exec.Command(bash, args...)
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, tbl.want, string(out), "words: %q", tbl.words)
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	script := completionScript(t, Zsh)
+	assert.True(t, strings.HasPrefix(script, "#compdef my-prog\n"))
+	assert.Contains(t, script, `'-mode=[the \[mode\]\: it'\''s]:mode:(fast slow)' \`)
+	assert.Contains(t, script, `'-name=[the name]:name:_default' \`)
+	assert.Contains(t, script, `'-verbose[log verbosely (shorthand -v)]' \`)
+}
+
+func TestFishCompletion(t *testing.T) {
+	script := completionScript(t, Fish)
+	assert.Contains(t, script, `complete -c 'my-prog' -o 'mode' -d 'the [mode]: it'\''s' -x -a 'fast slow'`)
+	assert.Contains(t, script, `complete -c 'my-prog' -o 'name' -d 'the name' -r`)
+	assert.Contains(t, script, "complete -c 'my-prog' -o 'v' -d 'shorthand for -verbose'\n")
+}
+
+func TestCompletionUnsupportedShell(t *testing.T) {
+	src, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &completionConfig{}, flag.NewFlagSet("", flag.ContinueOnError))
+	require.NoError(t, err)
+	assert.Error(t, src.WriteCompletion(&bytes.Buffer{}, "csh", "my-prog"))
+}
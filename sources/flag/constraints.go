@@ -0,0 +1,82 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type flagConstraintKind int
+
+const (
+	mutuallyExclusive flagConstraintKind = iota
+	oneRequired
+)
+
+// flagConstraint restricts the combinations of flags that may be set.
+type flagConstraint struct {
+	kind  flagConstraintKind
+	names []string
+}
+
+// MarkMutuallyExclusive declares that at most one of the named flags may be
+// set. Value returns an error if more than one of them was set. Setting a
+// flag's shorthand or deprecated alias counts as setting the flag.
+func (s *Set) MarkMutuallyExclusive(names ...string) error {
+	return s.addConstraint(mutuallyExclusive, names)
+}
+
+// MarkOneRequired declares that at least one of the named flags must be set.
+// Value returns an error if none of them were set. Setting a flag's shorthand
+// or deprecated alias counts as setting the flag.
+func (s *Set) MarkOneRequired(names ...string) error {
+	return s.addConstraint(oneRequired, names)
+}
+
+func (s *Set) addConstraint(kind flagConstraintKind, names []string) error {
+	if len(names) < 1 || (kind == mutuallyExclusive && len(names) < 2) {
+		return fmt.Errorf("too few flags in constraint: %q", names)
+	}
+	for _, name := range names {
+		if s.Flags == nil || s.Flags.Lookup(name) == nil {
+			return fmt.Errorf("flag %q is not registered", name)
+		}
+	}
+	s.constraints = append(s.constraints, flagConstraint{kind: kind, names: names})
+	return nil
+}
+
+// checkConstraints verifies that the parsed flags satisfy the registered
+// constraints.
+func (s *Set) checkConstraints() error {
+	if len(s.constraints) == 0 {
+		return nil
+	}
+	set := map[string]struct{}{}
+	s.Flags.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if long, ok := s.aliasOf[name]; ok {
+			name = long
+		}
+		set[name] = struct{}{}
+	})
+	for _, c := range s.constraints {
+		present := []string{}
+		for _, name := range c.names {
+			if _, ok := set[name]; ok {
+				present = append(present, "-"+name)
+			}
+		}
+		switch c.kind {
+		case mutuallyExclusive:
+			if len(present) > 1 {
+				return fmt.Errorf("flags %s are mutually exclusive", strings.Join(present, ", "))
+			}
+		case oneRequired:
+			if len(present) == 0 {
+				return fmt.Errorf("at least one of the flags -%s is required", strings.Join(c.names, ", -"))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package flag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+func TestFlagConstraints(t *testing.T) {
+	type Config struct {
+		JSON   bool `dials:"json" dialsflag:"json,j"`
+		YAML   bool `dials:"yaml"`
+		Text   bool `dials:"text"`
+		Input  string
+		Stdin  bool
+		Output string
+	}
+	for _, tbl := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "ok", args: []string{"-json", "-input=x"}},
+		{name: "exclusive", args: []string{"-json", "-yaml", "-text", "-stdin"}, wantErr: "flags -json, -yaml, -text are mutually exclusive"},
+		{name: "exclusive_shorthand", args: []string{"-j", "-yaml", "-stdin"}, wantErr: "flags -json, -yaml are mutually exclusive"},
+		{name: "required", args: []string{"-json"}, wantErr: "at least one of the flags -input, -stdin is required"},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, tbl.args)
+			require.NoError(t, err)
+			require.NoError(t, src.MarkMutuallyExclusive("json", "yaml", "text"))
+			require.NoError(t, src.MarkOneRequired("input", "stdin"))
+
+			_, err = dials.Config(context.Background(), &Config{}, src)
+			if tbl.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tbl.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlagConstraintsInvalid(t *testing.T) {
+	type Config struct {
+		A bool
+		B bool
+	}
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, nil)
+	require.NoError(t, err)
+	assert.Error(t, src.MarkMutuallyExclusive("a"))
+	assert.Error(t, src.MarkMutuallyExclusive("a", "c"))
+	assert.Error(t, src.MarkOneRequired())
+}
@@ -0,0 +1,79 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// enumValue wraps the flag.Value of a flag with an EnumTag, rejecting values
+// other than the declared choices.
+type enumValue struct {
+	flag.Value
+	choices []string
+}
+
+func (e *enumValue) Set(s string) error {
+	if err := e.Value.Set(s); err != nil {
+		return err
+	}
+	for _, v := range e.values() {
+		if !e.allowed(v) {
+			return fmt.Errorf("invalid value %q: must be one of %s", v, strings.Join(e.choices, ", "))
+		}
+	}
+	return nil
+}
+
+// values returns the current values to check against the choices; one per
+// element for slice and set flags.
+func (e *enumValue) values() []string {
+	g, ok := e.Value.(flag.Getter)
+	if !ok {
+		return []string{e.Value.String()}
+	}
+	switch v := g.Get().(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case map[string]struct{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	default:
+		return []string{e.Value.String()}
+	}
+}
+
+func (e *enumValue) allowed(v string) bool {
+	for _, c := range e.choices {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Get implements flag.Getter if the wrapped Value does.
+func (e *enumValue) Get() interface{} {
+	if g, ok := e.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return e.Value.String()
+}
+
+// IsBoolFlag forwards to the wrapped Value, so the flag package treats boolean
+// flags correctly.
+func (e *enumValue) IsBoolFlag() bool {
+	bf, ok := e.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+func (e *enumValue) unwrap() flag.Value {
+	return e.Value
+}
@@ -0,0 +1,88 @@
+package flag
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+type enumConfig struct {
+	Mode   string   `dialsflag:"mode,m" dialsenum:"fast,slow" dialsdesc:"operating mode"`
+	Level  int      `dialsenum:"1,2,3"`
+	Colors []string `dialsenum:"red,green,blue"`
+}
+
+func TestEnumFlags(t *testing.T) {
+	ctx := context.Background()
+	for _, tbl := range []struct {
+		name    string
+		args    []string
+		want    enumConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			args: []string{"-mode=slow", "-level=2", "-colors=red,blue"},
+			want: enumConfig{Mode: "slow", Level: 2, Colors: []string{"red", "blue"}},
+		},
+		{
+			name: "shorthand",
+			args: []string{"-m", "fast"},
+			want: enumConfig{Mode: "fast", Level: 1},
+		},
+		{
+			name:    "invalid_string",
+			args:    []string{"-mode=medium"},
+			wantErr: `invalid value "medium" for flag -mode: invalid value "medium": must be one of fast, slow`,
+		},
+		{
+			name:    "invalid_shorthand",
+			args:    []string{"-m=medium"},
+			wantErr: `must be one of fast, slow`,
+		},
+		{
+			name:    "invalid_int",
+			args:    []string{"-level=4"},
+			wantErr: `must be one of 1, 2, 3`,
+		},
+		{
+			name:    "invalid_element",
+			args:    []string{"-colors=red,purple"},
+			wantErr: `invalid value "purple": must be one of red, green, blue`,
+		},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			src, err := NewSetWithArgs(DefaultFlagNameConfig(), &enumConfig{}, tbl.args)
+			require.NoError(t, err)
+			src.Flags.SetOutput(io.Discard)
+			d, err := dials.Config(ctx, &enumConfig{Level: 1}, src)
+			if tbl.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tbl.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &tbl.want, d.View())
+		})
+	}
+}
+
+func TestEnumUsage(t *testing.T) {
+	fs := flag.NewFlagSet("svc", flag.ContinueOnError)
+	_, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &enumConfig{Mode: "fast"}, fs)
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	fs.SetOutput(&buf)
+	fs.Usage()
+	assert.Contains(t, buf.String(),
+		"  -mode string\n    \toperating mode (shorthand -m) (one of: fast, slow) (default \"fast\")\n")
+	assert.Contains(t, buf.String(), "(one of: 1, 2, 3)")
+}
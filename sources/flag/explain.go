@@ -0,0 +1,58 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vimeo/dials"
+)
+
+var _ dials.ExplainRequester = (*Set)(nil)
+
+// DefaultExplainFlagName is the name of the flag registered by
+// RegisterExplainFlag when passed an empty name.
+const DefaultExplainFlagName = "dials-explain"
+
+// RegisterExplainFlag registers a boolean flag named name (or
+// DefaultExplainFlagName if empty) that makes dials.Config print each
+// configuration field's value, the source it came from and the values it
+// overrode, then return dials.ErrExplainRequested (see
+// dials.ExplainRequester and ExitIfExplained).
+func (s *Set) RegisterExplainFlag(name string) error {
+	if name == "" {
+		name = DefaultExplainFlagName
+	}
+	if s.Flags.Lookup(name) != nil {
+		return fmt.Errorf("explain flag %q conflicts with an existing flag", name)
+	}
+	s.explain = s.Flags.Bool(name, false,
+		"print each configuration value and where it came from, then exit")
+	return nil
+}
+
+// ExplainRequested returns true if the flag registered by RegisterExplainFlag
+// was set. It implements dials.ExplainRequester, and must only be called
+// after the flags are parsed (e.g. by Value).
+func (s *Set) ExplainRequested() bool {
+	return s.explain != nil && *s.explain
+}
+
+// exit is os.Exit, replaced by tests
+var exit = os.Exit
+
+// ExitIfExplained exits the process successfully if err is (or wraps)
+// dials.ErrExplainRequested, as the explanation requested by the flag
+// registered by RegisterExplainFlag has been printed, and otherwise returns
+// err:
+//
+//	d, err := dials.Config(ctx, &cfg, fileSrc, flagSet)
+//	if err := flag.ExitIfExplained(err); err != nil {
+//		return err
+//	}
+func ExitIfExplained(err error) error {
+	if errors.Is(err, dials.ErrExplainRequested) {
+		exit(0)
+	}
+	return err
+}
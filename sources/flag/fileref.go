@@ -0,0 +1,50 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileRefValue wraps the flag.Value of a flag with the "file" option, so a
+// value of "@path" is replaced by the contents of the file at path (e.g.
+// `-token=@/run/secrets/token`). A leading "@@" escapes a literal "@".
+type fileRefValue struct {
+	flag.Value
+}
+
+func (f *fileRefValue) Set(s string) error {
+	switch {
+	case strings.HasPrefix(s, "@@"):
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		contents, err := os.ReadFile(s[1:])
+		if err != nil {
+			return fmt.Errorf("failed to read value from file: %w", err)
+		}
+		// files written by editors and most secret stores end with a
+		// newline that isn't part of the value
+		s = strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r")
+	}
+	return f.Value.Set(s)
+}
+
+// Get implements flag.Getter if the wrapped Value does.
+func (f *fileRefValue) Get() interface{} {
+	if g, ok := f.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return f.Value.String()
+}
+
+// IsBoolFlag forwards to the wrapped Value, so the flag package treats boolean
+// flags correctly.
+func (f *fileRefValue) IsBoolFlag() bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+func (f *fileRefValue) unwrap() flag.Value {
+	return f.Value
+}
@@ -5,12 +5,18 @@ import (
 	"encoding"
 	"flag"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/vimeo/dials"
 	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
 	"github.com/vimeo/dials/ptrify"
 	"github.com/vimeo/dials/sources/flag/flaghelper"
 	"github.com/vimeo/dials/tagformat/caseconversion"
@@ -20,6 +26,8 @@ import (
 var (
 	timeTime             = reflect.TypeOf(time.Time{})
 	timeDuration         = reflect.TypeOf(time.Nanosecond)
+	urlType              = reflect.TypeOf(url.URL{})
+	ipNetType            = reflect.TypeOf(net.IPNet{})
 	flagReflectType      = reflect.TypeOf((*flag.Value)(nil)).Elem()
 	stringSlice          = reflect.SliceOf(reflect.TypeOf(""))
 	mapStringStringSlice = reflect.MapOf(reflect.TypeOf(""), stringSlice)
@@ -85,6 +93,9 @@ func ptrified(template interface{}) (reflect.Value, reflect.Type, error) {
 		return reflect.Value{}, nil, fmt.Errorf("pointer-to-non-struct-type passed: %s", val.Type())
 	}
 	typ := val.Type()
+	if err := ptrify.CheckCycles(typ); err != nil {
+		return reflect.Value{}, nil, err
+	}
 	out := ptrify.Pointerify(typ, val)
 	return val, out, nil
 }
@@ -141,6 +152,41 @@ func NewSetWithArgs(cfg *NameConfig, template interface{}, args []string) (*Set,
 	return &s, nil
 }
 
+// NewSetWithFlagSet registers flags for the passed template value in an
+// existing FlagSet. Flags that are already registered in flagset under the
+// name dials would use for a field are left untouched, and their values are
+// mapped onto that field, so programs migrating to dials can keep their
+// existing flag definitions while stacking other sources.
+//
+// flagset must be parsed before the returned Set's Value method is called
+// (usually by dials.Config).
+func NewSetWithFlagSet(cfg *NameConfig, template interface{}, flagset *flag.FlagSet) (*Set, error) {
+	pval, ptyp, ptrifyErr := ptrified(template)
+	if ptrifyErr != nil {
+		return nil, ptrifyErr
+	}
+
+	s := Set{
+		Flags:           flagset,
+		ptrType:         ptyp,
+		flagsRegistered: true,
+		NameCfg:         cfg,
+		flagFieldName:   map[string]string{},
+	}
+
+	if err := s.registerFlags(pval, ptyp); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// NewDefaultSetWithFlagSet is equivalent to NewSetWithFlagSet with the
+// DefaultFlagNameConfig
+func NewDefaultSetWithFlagSet(template interface{}, flagset *flag.FlagSet) (*Set, error) {
+	return NewSetWithFlagSet(DefaultFlagNameConfig(), template, flagset)
+}
+
 // Must is a helper that wraps a call to a function returning (*Set, error)
 // and panics if the error is non-nil. It is intended for use in variable
 // initializations such as
@@ -156,6 +202,36 @@ func Must(s *Set, err error) *Set {
 const (
 	// HelpTextTag is the name of the struct tags for flag descriptions
 	HelpTextTag = "dialsdesc"
+	// DeprecatedAliasTag is the name of the struct tag listing
+	// (comma-separated) old names for a flag. They're still accepted, but
+	// using one reports a dials.WarningDeprecated warning, so flags can be
+	// renamed without breaking existing invocations.
+	DeprecatedAliasTag = "dialsflagdeprecated"
+	// GroupTag is the name of the struct tag naming the group a flag is
+	// listed under in usage output. On a nested struct field, it applies
+	// to all the flags for the fields within (unless they specify their
+	// own).
+	GroupTag = "dialsflaggroup"
+	// ArgTag is the name of the struct tag that binds a field to a
+	// positional argument (one remaining after the flags) instead of a
+	// flag. Its value is the argument's index (starting at 0), optionally
+	// followed by "..." on slice fields to bind that argument and all the
+	// ones after it (e.g. `dialsarg:"1..."`).
+	ArgTag = "dialsarg"
+	// EnumTag is the name of the struct tag listing (comma-separated) the
+	// values a flag accepts. Other values are rejected when the flags are
+	// parsed, and the choices are listed in usage output and offered by
+	// shell completion. For slice and set flags, each element must be one
+	// of the choices.
+	EnumTag = "dialsenum"
+	// KeyValueTag is the name of the struct tag that makes a
+	// map[string]string or map[string][]string flag repeatable: each
+	// occurrence adds a key/value entry (e.g. `-label k1=v1 -label k2=v2`)
+	// rather than replacing the whole map. Its value is the separator
+	// between keys and values, optionally followed by a space and a
+	// separator between entries within a single occurrence (e.g.
+	// `dialsflagkv:"= ,"` also accepts `-label k1=v1,k2=v2`).
+	KeyValueTag = "dialsflagkv"
 	// DefaultFlagHelpText is the default help-text for fields with an
 	// unset dialsdesc tag.
 	DefaultFlagHelpText = "unset description (`" + HelpTextTag + "` struct tag)"
@@ -176,6 +252,37 @@ type Set struct {
 	trnslVal        reflect.Value
 	// Map to store the flag name (key) and field name (value)
 	flagFieldName map[string]string
+
+	// DeprecatedAliasMessage, if non-nil, formats the message of the
+	// dials.WarningDeprecated warning reported (see dials.ReportWarning)
+	// when a deprecated alias declared with the dialsflagdeprecated tag
+	// is used.
+	DeprecatedAliasMessage func(alias, name string) string
+	// Map to store deprecated aliases (key) and the current flag name
+	// (value)
+	deprecatedAliases map[string]string
+	// hidden contains the names of flags omitted from usage output
+	hidden map[string]struct{}
+	// Map to store the flag name (key) and the name of the group it's
+	// listed under in usage output (value)
+	flagGroup map[string]string
+	// Map to store the flag name (key) and the values declared with the
+	// EnumTag (value)
+	flagChoices map[string][]string
+	// Map to store shorthands and deprecated aliases (key) and the flag
+	// name they alias (value)
+	aliasOf map[string]string
+	// Map to store the flag name (key) and its shorthand (value)
+	shorthands map[string]string
+	// usageTmpl is set by SetUsageTemplate
+	usageTmpl *template.Template
+	// constraints are registered by MarkMutuallyExclusive and
+	// MarkOneRequired
+	constraints []flagConstraint
+	// positional contains the fields bound to positional arguments
+	positional []positionalArg
+	// explain is set by RegisterExplainFlag
+	explain *bool
 }
 
 func (s *Set) parse() error {
@@ -189,6 +296,24 @@ func (s *Set) parse() error {
 }
 
 func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
+	if s.deprecatedAliases == nil {
+		s.deprecatedAliases = map[string]string{}
+	}
+	if s.hidden == nil {
+		s.hidden = map[string]struct{}{}
+	}
+	if s.flagGroup == nil {
+		s.flagGroup = map[string]string{}
+	}
+	if s.flagChoices == nil {
+		s.flagChoices = map[string][]string{}
+	}
+	if s.aliasOf == nil {
+		s.aliasOf = map[string]string{}
+	}
+	if s.shorthands == nil {
+		s.shorthands = map[string]string{}
+	}
 	fm := transform.NewFlattenMangler(common.DialsTagName, s.NameCfg.FieldNameEncodeCasing, s.NameCfg.TagEncodeCasing)
 	tfmr := transform.NewTransformer(ptyp, fm)
 	val, TrnslErr := tfmr.Translate()
@@ -207,15 +332,33 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 		k = t.Kind()
 	}
 
+	// shorthands and deprecated aliases are registered after the loop, once
+	// the Values they alias exist.
+	type alias struct{ alias, long, fieldName, usage, kind string }
+	aliases := []alias{}
+	// flags with the "file" option, whose Values are wrapped after the loop
+	fileRefs := []string{}
+
 	// the input kind will be struct after calling Translate on it
 	for i := 0; i < t.NumField(); i++ {
 		sf := t.Field(i)
+		if spec, ok := sf.Tag.Lookup(ArgTag); ok {
+			pa, err := parsePositional(spec, sf)
+			if err != nil {
+				return err
+			}
+			s.positional = append(s.positional, pa)
+			continue
+		}
 		help := DefaultFlagHelpText
 		if x, ok := sf.Tag.Lookup(HelpTextTag); ok {
 			help = x
 		}
 
-		name := s.mkname(sf)
+		name, opts, nameErr := s.mkname(sf)
+		if nameErr != nil {
+			return nameErr
+		}
 		s.flagFieldName[name] = sf.Name
 
 		// if the flag already exists, don't register so the user can override
@@ -231,6 +374,49 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 			continue
 		}
 
+		// opaque structs and pass-through values that can't be parsed
+		// from a flag's value don't get flags
+		if unsettable(sf.Type) {
+			continue
+		}
+
+		if opts.hidden {
+			s.hidden[name] = struct{}{}
+		}
+		if opts.file {
+			help += " (@file reads the value from file)"
+			fileRefs = append(fileRefs, name)
+		}
+		if group := flagGroup(ptyp, sf); group != "" {
+			s.flagGroup[name] = group
+		}
+		if choices, ok := sf.Tag.Lookup(EnumTag); ok && choices != "" {
+			s.flagChoices[name] = strings.Split(choices, ",")
+		}
+		if short := opts.shorthand; short != "" {
+			help += " (shorthand -" + short + ")"
+			s.shorthands[name] = short
+			aliases = append(aliases, alias{
+				alias: short, long: name, fieldName: sf.Name,
+				usage: "shorthand for -" + name, kind: "shorthand",
+			})
+			if opts.hidden {
+				s.hidden[short] = struct{}{}
+			}
+		}
+		if tag, ok := sf.Tag.Lookup(DeprecatedAliasTag); ok && tag != "" {
+			for _, old := range strings.Split(tag, ",") {
+				aliases = append(aliases, alias{
+					alias: old, long: name, fieldName: sf.Name,
+					usage: "deprecated: use -" + name, kind: "deprecated alias",
+				})
+				s.deprecatedAliases[old] = name
+				if opts.hidden {
+					s.hidden[old] = struct{}{}
+				}
+			}
+		}
+
 		ft := sf.Type
 
 		k := ft.Kind()
@@ -244,20 +430,42 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 		// get the concrete value of the field from the template
 		fieldVal := transform.GetField(sf, tmpl)
 
+		if opts.count {
+			switch k {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			default:
+				return fmt.Errorf("count flag %q must have an integer type, not %s", name, ft)
+			}
+			count := int(fieldVal.Int())
+			s.Flags.Var(flaghelper.NewCountFlag(&count), name, help)
+			continue
+		}
+
 		switch {
 		case fieldVal.Type() == timeTime:
 			{
 				newVal := fieldVal.Interface().(time.Time)
+				if layouts, ok := sf.Tag.Lookup(common.TimeLayoutTagName); ok {
+					s.Flags.Var(flaghelper.NewTimeWrapperWithLayouts(newVal, parse.TimeLayouts(layouts)), name, help)
+					continue
+				}
 				s.Flags.Var(flaghelper.NewTimeWrapper(newVal), name, help)
 				continue
 			}
+		case parse.Registered(fieldVal.Type()):
+			s.Flags.Var(flaghelper.NewRegisteredTypeFlag(fieldVal.Addr()), name, help)
+			continue
+		case fieldVal.Type() == urlType:
+			s.Flags.Var(flaghelper.NewURLFlag(fieldVal.Addr().Interface().(*url.URL)), name, help)
+			continue
+		case fieldVal.Type() == ipNetType:
+			s.Flags.Var(flaghelper.NewIPNetFlag(fieldVal.Addr().Interface().(*net.IPNet)), name, help)
+			continue
 		case isValue:
-			{
-
-				newVal := fieldVal.Addr().Interface()
-				s.Flags.Var(newVal.(flag.Value), name, help)
-				continue
-			}
+			// custom flag.Values rarely implement flag.Getter, so
+			// the field is read back through the wrapper
+			s.Flags.Var(flaghelper.NewValueFlag(fieldVal.Addr()), name, help)
+			continue
 		case isTextM:
 			{
 				// Make sure our newVal value actually points to something.
@@ -268,6 +476,9 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 		case fieldVal.Type() == timeDuration:
 			s.Flags.Duration(name, fieldVal.Interface().(time.Duration), help)
 			continue
+		case isByteSize(sf, k):
+			s.Flags.Var(flaghelper.NewByteSizeFlag(fieldVal.Addr()), name, help)
+			continue
 		default:
 		}
 
@@ -305,6 +516,12 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 		case reflect.Uint64:
 			s.Flags.Uint64(name, fieldVal.Convert(uint64Type).Interface().(uint64), help)
 		case reflect.Slice, reflect.Map:
+			if spec, ok := sf.Tag.Lookup(KeyValueTag); ok {
+				if err := s.registerKeyValueFlag(name, help, spec, ft, fieldVal); err != nil {
+					return err
+				}
+				continue
+			}
 			switch ft {
 			case stringSlice:
 				s.Flags.Var(flaghelper.NewStringSliceFlag(fieldVal.Addr().Interface().(*[]string)), name, help)
@@ -315,12 +532,72 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 			case stringSet:
 				s.Flags.Var(flaghelper.NewStringSetFlag(fieldVal.Addr().Interface().(*map[string]struct{})), name, help)
 			default:
+				if _, ok := ptrify.StructMapElem(ft); ok && ft.Elem().Kind() == reflect.Ptr {
+					s.Flags.Var(flaghelper.NewStructMapFlag(reflect.New(ft)), name, help)
+					continue
+				}
 				return fmt.Errorf("unhandled type %s", ft)
 			}
 		default:
 			return fmt.Errorf("unhandled type %s", ft)
 		}
 	}
+
+	for name, choices := range s.flagChoices {
+		if f := s.Flags.Lookup(name); f != nil {
+			f.Value = &enumValue{Value: f.Value, choices: choices}
+		}
+	}
+	// file references are resolved before enum values are validated
+	for _, name := range fileRefs {
+		if f := s.Flags.Lookup(name); f != nil {
+			f.Value = &fileRefValue{Value: f.Value}
+		}
+	}
+
+	for _, a := range aliases {
+		if s.Flags.Lookup(a.alias) != nil {
+			return fmt.Errorf("%s %q for flag %q conflicts with an existing flag", a.kind, a.alias, a.long)
+		}
+		s.Flags.Var(s.Flags.Lookup(a.long).Value, a.alias, a.usage)
+		s.flagFieldName[a.alias] = a.fieldName
+		s.aliasOf[a.alias] = a.long
+		if group, ok := s.flagGroup[a.long]; ok {
+			s.flagGroup[a.alias] = group
+		}
+		if choices, ok := s.flagChoices[a.long]; ok {
+			s.flagChoices[a.alias] = choices
+		}
+	}
+
+	if len(s.hidden) > 0 || len(s.flagGroup) > 0 || len(s.flagChoices) > 0 {
+		s.installUsage()
+	}
+	return nil
+}
+
+// registerKeyValueFlag registers a repeatable map flag for a field with a
+// KeyValueTag.
+func (s *Set) registerKeyValueFlag(name, help, spec string, ft reflect.Type, fieldVal reflect.Value) error {
+	kvSep, pairSep, _ := strings.Cut(spec, " ")
+	if kvSep == "" {
+		return fmt.Errorf("%s tag on flag %q must specify a key/value separator", KeyValueTag, name)
+	}
+	// flag.UnquoteUsage uses the first back-quoted word as the value's
+	// name, so put it in the help text unless there's already one
+	if strings.Contains(help, "`") {
+		help += " (repeatable, key" + kvSep + "value)"
+	} else {
+		help += " (repeatable, `key" + kvSep + "value`)"
+	}
+	switch ft {
+	case mapStringString:
+		s.Flags.Var(flaghelper.NewKeyValueMapFlag(fieldVal.Addr().Interface().(*map[string]string), kvSep, pairSep), name, help)
+	case mapStringStringSlice:
+		s.Flags.Var(flaghelper.NewKeyValueSliceMapFlag(fieldVal.Addr().Interface().(*map[string][]string), kvSep, pairSep), name, help)
+	default:
+		return fmt.Errorf("%s tag on flag %q requires a map[string]string or map[string][]string field, not %s", KeyValueTag, name, ft)
+	}
 	return nil
 }
 
@@ -329,7 +606,7 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 // struct tag if present, then its `dials` tag if present, and finally its name.
 // If the struct has nested fields, Value will flatten the fields so flags can
 // be defined for nested fields.
-func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+func (s *Set) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
 	// Check whether we've gone through the exercise of parsing flags yet
 	// (and types are compatible).
 	if s.ptrType != nil {
@@ -373,13 +650,26 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 			return reflect.Value{}, fmt.Errorf("failed to parse: %s", err)
 		}
 	}
+	if err := s.checkConstraints(); err != nil {
+		return reflect.Value{}, err
+	}
 	var setErr error
 	val := reflect.New(t.Type())
+	// shorthands and deprecated aliases share their flag's Value, so a
+	// field may be visited more than once
+	setFields := map[string]struct{}{}
 	s.Flags.Visit(func(f *flag.Flag) {
 		fieldName, ok := s.flagFieldName[f.Name]
 		if !ok {
 			return
 		}
+		if name, deprecated := s.deprecatedAliases[f.Name]; deprecated {
+			s.warnDeprecated(ctx, f.Name, name)
+		}
+		if _, set := setFields[fieldName]; set {
+			return
+		}
+		setFields[fieldName] = struct{}{}
 
 		ffield := s.trnslVal.FieldByName(fieldName)
 		if !ffield.IsNil() {
@@ -406,8 +696,19 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 		case ffield.Type():
 			ffield.Set(fval)
 			return
+		case ffield.Addr().Type(): // flag is a pointer (*net.IP) and ffield isn't (net.IP)
+			ffield.Set(fval.Elem())
+			return
 		}
 
+		// integers are convertible to strings, but produce a rune rather
+		// than a decimal representation.
+		if !fval.Type().ConvertibleTo(ptrVal.Type().Elem()) ||
+			(ptrVal.Type().Elem().Kind() == reflect.String && fval.Kind() != reflect.String) {
+			setErr = fmt.Errorf("value for flag %q has type %s, which is incompatible with field type %s",
+				f.Name, fval.Type(), ptrVal.Type().Elem())
+			return
+		}
 		if willOverflow(fval, ptrVal.Elem()) {
 			setErr = fmt.Errorf("value for flag %q (%s) would overflow type %s",
 				f.Name, f.Value.String(), ptrVal.Type().Elem())
@@ -426,10 +727,25 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 	if setErr != nil {
 		return val.Elem(), setErr
 	}
+	if err := s.bindPositional(); err != nil {
+		return val.Elem(), err
+	}
 
 	return s.tfmr.ReverseTranslate(s.trnslVal)
 }
 
+func (s *Set) warnDeprecated(ctx context.Context, alias, name string) {
+	msg := fmt.Sprintf("flag -%s is deprecated; use -%s instead", alias, name)
+	if s.DeprecatedAliasMessage != nil {
+		msg = s.DeprecatedAliasMessage(alias, name)
+	}
+	dials.ReportWarning(ctx, dials.Warning{
+		Kind:    dials.WarningDeprecated,
+		Source:  s,
+		Message: msg,
+	})
+}
+
 func stripTypePtr(t reflect.Type) reflect.Type {
 	switch t.Kind() {
 	case reflect.Ptr:
@@ -459,17 +775,64 @@ func willOverflow(val, target reflect.Value) bool {
 
 }
 
+// flagOpts are the options following the name in a dialsflag tag.
+type flagOpts struct {
+	// shorthand is a one-letter alias (e.g. `dialsflag:"verbose,v"`)
+	shorthand string
+	// hidden omits the flag from usage output (`dialsflag:",hidden"`)
+	hidden bool
+	// count makes an integer flag count its occurrences
+	// (`dialsflag:"verbose,v,count"`)
+	count bool
+	// file makes a value of "@path" read the value from the file at path
+	// (`dialsflag:"token,file"`)
+	file bool
+}
+
+func parseFlagOpts(opts string) (flagOpts, error) {
+	fo := flagOpts{}
+	if opts == "" {
+		return fo, nil
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		switch {
+		case opt == "hidden":
+			fo.hidden = true
+		case opt == "count":
+			fo.count = true
+		case opt == "file":
+			fo.file = true
+		case utf8.RuneCountInString(opt) == 1 && fo.shorthand == "":
+			fo.shorthand = opt
+		default:
+			return fo, fmt.Errorf("invalid option %q (options are \"hidden\", \"count\", \"file\" and a single-character shorthand)", opt)
+		}
+	}
+	return fo, nil
+}
+
 // mkname creates a flag name based on the values of the dialsflag/dials tag or
-// decoded field name and converting it into kebab case
-func (s *Set) mkname(sf reflect.StructField) string {
+// decoded field name and converting it into kebab case. Any comma-separated
+// options following the name in the dialsflag tag are also returned.
+func (s *Set) mkname(sf reflect.StructField) (string, flagOpts, error) {
 	// use the name from the dialsflag tag for the flag name
-	if name, ok := sf.Tag.Lookup(dialsFlagTag); ok {
-		return name
+	if tag, ok := sf.Tag.Lookup(dialsFlagTag); ok {
+		name, opts, _ := strings.Cut(tag, ",")
+		fo, err := parseFlagOpts(opts)
+		if err != nil {
+			return "", fo, fmt.Errorf("field %q: %w", sf.Name, err)
+		}
+		if name != "" {
+			return name, fo, nil
+		}
+		// check if the dials tag is populated (it should be once it goes
+		// through the flatten mangler).
+		if name, ok := sf.Tag.Lookup(common.DialsTagName); ok {
+			return name, fo, nil
+		}
 	}
-	// check if the dials tag is populated (it should be once it goes through
-	// the flatten mangler).
 	if name, ok := sf.Tag.Lookup(common.DialsTagName); ok {
-		return name
+		return name, flagOpts{}, nil
 	}
 
 	// panic because flatten mangler should set the dials tag so panic if that
@@ -477,3 +840,36 @@ func (s *Set) mkname(sf reflect.StructField) string {
 	panic(fmt.Errorf("expected dials tag name for struct field %q", sf.Name))
 
 }
+
+// unsettable indicates whether t is an opaque struct (see
+// ptrify.IsOpaqueStruct), a struct only decoders can set (see
+// ptrify.IsDecodeHookStruct) or a pass-through type (see
+// ptrify.IsPassThrough), or a pointer to one, that implements neither
+// flag.Value nor encoding.TextUnmarshaler, and isn't registered with
+// parse.RegisterType.
+func unsettable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !ptrify.IsOpaqueStruct(t) && !ptrify.IsDecodeHookStruct(t) && !ptrify.IsPassThrough(t) {
+		return false
+	}
+	return !parse.Registered(t) && !t.Implements(textMReflectType) && !reflect.PtrTo(t).Implements(textMReflectType) &&
+		!t.Implements(flagReflectType) && !reflect.PtrTo(t).Implements(flagReflectType)
+}
+
+// isByteSize indicates whether sf, of kind k (after dereferencing pointers),
+// is an integer field with a `dialsunit:"bytes"` tag (see
+// common.UnitTagName).
+func isByteSize(sf reflect.StructField, k reflect.Kind) bool {
+	if sf.Tag.Get(common.UnitTagName) != "bytes" {
+		return false
+	}
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
@@ -3,7 +3,11 @@ package flag
 import (
 	"bytes"
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -599,3 +603,366 @@ func TestMust(t *testing.T) {
 		t.Errorf("expected World to be true, got %t", got.World)
 	}
 }
+
+func TestNewSetWithFlagSet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		ListenAddr string
+		Verbose    bool
+		Workers    int
+	}
+
+	// flags the program registered before migrating to dials
+	fs := flag.NewFlagSet("test flags", flag.ContinueOnError)
+	listenAddr := fs.String("listen-addr", ":8080", "address to listen on")
+	fs.Bool("verbose", false, "log verbosely")
+
+	src, err := NewDefaultSetWithFlagSet(&Config{}, fs)
+	require.NoError(t, err)
+	// dials only registers the flag that wasn't already present
+	require.NotNil(t, fs.Lookup("workers"))
+
+	require.NoError(t, fs.Parse([]string{"-listen-addr=:9090", "-workers=4"}))
+
+	d, err := dials.Config(ctx, &Config{ListenAddr: "unset", Verbose: true}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{ListenAddr: ":9090", Verbose: true, Workers: 4}, d.View())
+	assert.Equal(t, ":9090", *listenAddr)
+}
+
+func TestNewSetWithFlagSetMismatchedType(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		Workers int
+	}
+	fs := flag.NewFlagSet("test flags", flag.ContinueOnError)
+	fs.String("workers", "", "number of workers")
+
+	src, err := NewDefaultSetWithFlagSet(&Config{}, fs)
+	require.NoError(t, err)
+	require.NoError(t, fs.Parse([]string{"-workers=four"}))
+
+	_, err = dials.Config(ctx, &Config{}, src)
+	assert.Error(t, err)
+}
+
+func TestShorthandFlags(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		Verbose bool   `dialsflag:"verbose,v" dialsdesc:"log verbosely"`
+		Output  string `dials:"output" dialsflag:",o"`
+		Workers int
+	}
+
+	for _, args := range [][]string{
+		{"-v", "-o=out.txt"},
+		{"--verbose", "--output", "out.txt"},
+	} {
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, args)
+		require.NoError(t, err)
+
+		assert.Equal(t, "log verbosely (shorthand -v)", src.Flags.Lookup("verbose").Usage)
+		assert.Equal(t, "shorthand for -verbose", src.Flags.Lookup("v").Usage)
+
+		d, err := dials.Config(ctx, &Config{Workers: 2}, src)
+		require.NoError(t, err)
+		assert.Equal(t, &Config{Verbose: true, Output: "out.txt", Workers: 2}, d.View(), "args: %q", args)
+	}
+}
+
+func TestShorthandFlagErrors(t *testing.T) {
+	type LongShorthand struct {
+		Verbose bool `dialsflag:"verbose,vv"`
+	}
+	_, err := NewSetWithArgs(DefaultFlagNameConfig(), &LongShorthand{}, nil)
+	assert.Error(t, err)
+
+	type Conflict struct {
+		Verbose bool `dialsflag:"verbose,w"`
+		W       int  `dials:"w"`
+	}
+	_, err = NewSetWithArgs(DefaultFlagNameConfig(), &Conflict{}, nil)
+	assert.Error(t, err)
+}
+
+func TestDeprecatedAliases(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		ListenAddr string `dialsflagdeprecated:"addr,listen"`
+		Workers    int
+	}
+
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"-addr=:9090", "-workers=3"})
+	require.NoError(t, err)
+	assert.Equal(t, "deprecated: use -listen-addr", src.Flags.Lookup("listen").Usage)
+
+	warnings := []dials.Warning{}
+	d, err := dials.Params[Config]{
+		OnWarning: func(_ context.Context, w dials.Warning) { warnings = append(warnings, w) },
+	}.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{ListenAddr: ":9090", Workers: 3}, d.View())
+	assert.Equal(t, []dials.Warning{{
+		Kind:    dials.WarningDeprecated,
+		Source:  src,
+		Message: "flag -addr is deprecated; use -listen-addr instead",
+	}}, warnings)
+
+	// the message is configurable
+	src, err = NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"-listen=:8080"})
+	require.NoError(t, err)
+	src.DeprecatedAliasMessage = func(alias, name string) string {
+		return alias + " will be removed in v2; switch to " + name
+	}
+	d, err = dials.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", d.View().ListenAddr)
+	assert.Equal(t, "listen will be removed in v2; switch to listen-addr", (<-d.Warnings()).Message)
+}
+
+func TestCountFlags(t *testing.T) {
+	ctx := context.Background()
+	type Config struct {
+		Verbosity int8 `dialsflag:"verbose,v,count"`
+		Name      string
+	}
+	for _, tbl := range []struct {
+		args []string
+		want int8
+	}{
+		{args: []string{}, want: 1},
+		{args: []string{"-v"}, want: 2},
+		{args: []string{"-v", "-v", "-verbose"}, want: 4},
+		{args: []string{"-v=false", "-v"}, want: 1},
+		{args: []string{"-verbose=5"}, want: 5},
+	} {
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{Verbosity: 1}, tbl.args)
+		require.NoError(t, err)
+		d, err := dials.Config(ctx, &Config{Verbosity: 1}, src)
+		require.NoError(t, err)
+		assert.Equal(t, tbl.want, d.View().Verbosity, "args: %q", tbl.args)
+	}
+
+	type BadCount struct {
+		Verbose string `dialsflag:"verbose,count"`
+	}
+	_, err := NewSetWithArgs(DefaultFlagNameConfig(), &BadCount{}, nil)
+	assert.Error(t, err)
+}
+
+func TestKeyValueFlags(t *testing.T) {
+	ctx := context.Background()
+	type Config struct {
+		Labels  map[string]string   `dialsflag:"label" dialsflagkv:"=" dialsdesc:"labels to attach"`
+		Headers map[string][]string `dialsflag:"header" dialsflagkv:": ;"`
+	}
+	defaults := func() *Config {
+		return &Config{Labels: map[string]string{"env": "dev"}}
+	}
+
+	for _, tbl := range []struct {
+		args        []string
+		wantLabels  map[string]string
+		wantHeaders map[string][]string
+	}{
+		{args: []string{}, wantLabels: map[string]string{"env": "dev"}},
+		{
+			args:       []string{"-label", "k1=v1", "-label", "k2=a=b"},
+			wantLabels: map[string]string{"k1": "v1", "k2": "a=b"},
+		},
+		{
+			args:        []string{"-header", "Accept:a;Accept:b", "-header", "Origin:c"},
+			wantLabels:  map[string]string{"env": "dev"},
+			wantHeaders: map[string][]string{"Accept": {"a", "b"}, "Origin": {"c"}},
+		},
+	} {
+		tmpl := defaults()
+		defaultLabels := tmpl.Labels
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), tmpl, tbl.args)
+		require.NoError(t, err)
+		d, err := dials.Config(ctx, tmpl, src)
+		require.NoError(t, err)
+		assert.Equal(t, tbl.wantLabels, d.View().Labels, "args: %q", tbl.args)
+		assert.Equal(t, tbl.wantHeaders, d.View().Headers, "args: %q", tbl.args)
+		// the default's map must not be modified
+		assert.Equal(t, map[string]string{"env": "dev"}, defaultLabels)
+	}
+
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), defaults(), []string{"-label", "novalue"})
+	require.NoError(t, err)
+	_, err = dials.Config(ctx, defaults(), src)
+	assert.ErrorContains(t, err, `invalid entry "novalue": expected key=value`)
+
+	buf := &bytes.Buffer{}
+	src.Flags.SetOutput(buf)
+	src.Flags.PrintDefaults()
+	assert.Contains(t, buf.String(), "-label key=value\n    \tlabels to attach (repeatable, key=value) (default env=dev)")
+
+	type BadKeyValue struct {
+		Names []string `dialsflagkv:"="`
+	}
+	_, err = NewSetWithArgs(DefaultFlagNameConfig(), &BadKeyValue{}, nil)
+	assert.Error(t, err)
+}
+
+func TestFileRefFlags(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("s3cret\n"), 0o600))
+
+	type Config struct {
+		Token string `dialsflag:"token,file"`
+		Mode  string `dialsflag:"mode,file" dialsenum:"fast,slow"`
+		Name  string
+	}
+	for _, tbl := range []struct {
+		args []string
+		want Config
+	}{
+		{args: []string{"-token=@" + tokenPath}, want: Config{Token: "s3cret"}},
+		{args: []string{"-token=@@literal"}, want: Config{Token: "@literal"}},
+		{args: []string{"-token=plain"}, want: Config{Token: "plain"}},
+		{args: []string{"-name=@" + tokenPath}, want: Config{Name: "@" + tokenPath}},
+	} {
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, tbl.args)
+		require.NoError(t, err)
+		d, err := dials.Config(ctx, &Config{}, src)
+		require.NoError(t, err)
+		assert.Equal(t, &tbl.want, d.View(), "args: %q", tbl.args)
+	}
+
+	modePath := filepath.Join(dir, "mode")
+	require.NoError(t, os.WriteFile(modePath, []byte("medium"), 0o600))
+	for _, args := range [][]string{
+		{"-token=@" + filepath.Join(dir, "missing")},
+		{"-mode=@" + modePath},
+	} {
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, args)
+		require.NoError(t, err)
+		_, err = dials.Config(ctx, &Config{}, src)
+		assert.Error(t, err, "args: %q", args)
+	}
+}
+
+func TestTimeLayoutFlags(t *testing.T) {
+	ctx := context.Background()
+	type Config struct {
+		Start time.Time
+		End   time.Time `dialstimelayout:"DateOnly|RFC3339"`
+	}
+	tmpl := &Config{End: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), tmpl, []string{"-start=2020-01-02T03:04:05Z", "-end=2021-06-07"})
+	require.NoError(t, err)
+	d, err := dials.Config(ctx, tmpl, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Start: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		End:   time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC),
+	}, d.View())
+
+	// the default is formatted with the first layout
+	assert.Equal(t, "2020-01-01", src.Flags.Lookup("end").DefValue)
+
+	src, err = NewSetWithArgs(DefaultFlagNameConfig(), tmpl, []string{"-end=06/07/2021"})
+	require.NoError(t, err)
+	_, err = dials.Config(ctx, tmpl, src)
+	assert.Error(t, err)
+}
+
+func TestExplainFlag(t *testing.T) {
+	type Config struct {
+		Name string
+	}
+	for _, tbl := range []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"-name=foo"}, want: false},
+		{args: []string{"-dials-explain"}, want: true},
+	} {
+		src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, tbl.args)
+		require.NoError(t, err)
+		require.NoError(t, src.RegisterExplainFlag(""))
+		require.NoError(t, src.ParseFunc())
+		assert.Equal(t, tbl.want, src.ExplainRequested(), "args: %q", tbl.args)
+	}
+
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, src.RegisterExplainFlag("name"), `explain flag "name" conflicts`)
+}
+
+func TestExitIfExplained(t *testing.T) {
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+	defer func() { exit = os.Exit }()
+
+	errOther := errors.New("other")
+	assert.Same(t, errOther, ExitIfExplained(errOther))
+	assert.NoError(t, ExitIfExplained(nil))
+	assert.Equal(t, -1, exitCode)
+
+	ExitIfExplained(fmt.Errorf("config: %w", dials.ErrExplainRequested))
+	assert.Equal(t, 0, exitCode)
+}
+
+func TestStructMapFlags(t *testing.T) {
+	ctx := context.Background()
+	type Backend struct {
+		Host string `dials:"host"`
+		Port int    `dials:"port"`
+	}
+	type Config struct {
+		Backends map[string]*Backend `dialsflag:"backend"`
+	}
+
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{
+		"-backend", "primary.host:db1,primary.port:5432", "-backend", "replica.host:db2",
+	})
+	require.NoError(t, err)
+	d, err := dials.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Backend{
+		"primary": {Host: "db1", Port: 5432},
+		"replica": {Host: "db2"},
+	}, d.View().Backends)
+	assert.Equal(t, `"primary.Host":"db1","primary.Port":"5432","replica.Host":"db2"`,
+		src.Flags.Lookup("backend").Value.String())
+
+	src, err = NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"-backend", "primary.missing:1"})
+	require.NoError(t, err)
+	_, err = dials.Config(ctx, &Config{}, src)
+	assert.Error(t, err)
+}
+
+type opaqueSecret struct{ secret string }
+
+func TestUnsettableFlags(t *testing.T) {
+	ctx := context.Background()
+	type Config struct {
+		Secret opaqueSecret
+		Start  time.Time
+		Name   string
+		Plugin interface{}
+	}
+
+	tmpl := &Config{Secret: opaqueSecret{secret: "hunter2"}}
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), tmpl, []string{
+		"--start", "2020-01-02T03:04:05Z", "--name", "n",
+	})
+	require.NoError(t, err)
+	assert.Nil(t, src.Flags.Lookup("secret"))
+	assert.Nil(t, src.Flags.Lookup("plugin"))
+	d, err := dials.Config(ctx, tmpl, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Secret: opaqueSecret{secret: "hunter2"},
+		Start:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Name:   "n",
+	}, d.View())
+}
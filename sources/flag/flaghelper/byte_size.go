@@ -0,0 +1,67 @@
+package flaghelper
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/bytesize"
+)
+
+// ByteSizeFlag wraps an integer that holds a number of bytes, so it may be
+// set with a unit suffix, like "512MiB" (see bytesize.Parse).
+type ByteSizeFlag struct {
+	v reflect.Value
+}
+
+// NewByteSizeFlag is the constructor for ByteSizeFlag. v is a pointer to the
+// integer.
+func NewByteSizeFlag(v reflect.Value) *ByteSizeFlag {
+	return &ByteSizeFlag{v: v}
+}
+
+// Set implement pflag.Value and flag.Value
+func (b *ByteSizeFlag) Set(s string) error {
+	size, err := bytesize.Parse(s)
+	if err != nil {
+		return err
+	}
+	elem := b.v.Elem()
+	switch elem.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if elem.OverflowUint(uint64(size)) {
+			return fmt.Errorf("size %s overflows %s", s, elem.Type())
+		}
+		elem.SetUint(uint64(size))
+	default:
+		if elem.OverflowInt(int64(size)) {
+			return fmt.Errorf("size %s overflows %s", s, elem.Type())
+		}
+		elem.SetInt(int64(size))
+	}
+	return nil
+}
+
+// Get implements flag.Getter
+func (b *ByteSizeFlag) Get() interface{} {
+	return b.v.Elem().Interface()
+}
+
+// String implements flag.Value and pflag.Value
+func (b *ByteSizeFlag) String() string {
+	// the flag package calls String on a zero value
+	if !b.v.IsValid() {
+		return ""
+	}
+	elem := b.v.Elem()
+	switch elem.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return bytesize.ByteSize(elem.Uint()).String()
+	default:
+		return bytesize.ByteSize(elem.Int()).String()
+	}
+}
+
+// Type implements pflag.Value
+func (b *ByteSizeFlag) Type() string {
+	return "bytesize"
+}
@@ -0,0 +1,61 @@
+package flaghelper
+
+import (
+	"strconv"
+)
+
+// CountFlag is an integer flag that's incremented every time it's set without
+// a value, so "-v -v -v" yields 3 (the usual pattern for verbosity levels).
+// An explicit value ("-v=2") sets the count, and "-v=false" resets it.
+type CountFlag struct {
+	c *int
+}
+
+// NewCountFlag is the constructor for CountFlag
+func NewCountFlag(c *int) *CountFlag {
+	return &CountFlag{c: c}
+}
+
+// Set implements flag.Value and pflag.Value
+func (v *CountFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*v.c++
+		return nil
+	case "false":
+		*v.c = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v.c = n
+	return nil
+}
+
+// Get implements flag.Getter
+func (v *CountFlag) Get() interface{} {
+	if v.c == nil {
+		return 0
+	}
+	return *v.c
+}
+
+// String implements flag.Value and pflag.Value
+func (v *CountFlag) String() string {
+	if v.c == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.c)
+}
+
+// IsBoolFlag allows the flag to be set without a value
+func (v *CountFlag) IsBoolFlag() bool {
+	return true
+}
+
+// Type implements pflag.Value
+func (v *CountFlag) Type() string {
+	return "count"
+}
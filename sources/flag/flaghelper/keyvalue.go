@@ -0,0 +1,144 @@
+package flaghelper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// splitKeyValues splits s into key/value pairs. If pairSep is empty, s is a
+// single pair.
+func splitKeyValues(s, kvSep, pairSep string, add func(k, v string)) error {
+	pairs := []string{s}
+	if pairSep != "" {
+		pairs = strings.Split(s, pairSep)
+	}
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, kvSep)
+		if !ok {
+			return fmt.Errorf("invalid entry %q: expected key%svalue", pair, kvSep)
+		}
+		add(k, v)
+	}
+	return nil
+}
+
+// entrySep returns the separator String uses between entries.
+func entrySep(pairSep string) string {
+	if pairSep != "" {
+		return pairSep
+	}
+	return " "
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KeyValueMapFlag is a wrapper around map[string]string that accumulates an
+// entry every time it's set, so "-label a=1 -label b=2" yields
+// {"a": "1", "b": "2"}. The first Set replaces the default value.
+type KeyValueMapFlag struct {
+	s       *map[string]string
+	kvSep   string
+	pairSep string
+	set     bool
+}
+
+// NewKeyValueMapFlag is the constructor for KeyValueMapFlag. kvSep separates
+// keys from values, and if pairSep is non-empty, each Set may contain several
+// entries separated by it (e.g. "-label a=1,b=2").
+func NewKeyValueMapFlag(m *map[string]string, kvSep, pairSep string) *KeyValueMapFlag {
+	return &KeyValueMapFlag{s: m, kvSep: kvSep, pairSep: pairSep}
+}
+
+// Set implement pflag.Value and flag.Value
+func (v *KeyValueMapFlag) Set(s string) error {
+	if !v.set {
+		// don't modify the default's map, which may be shared
+		*v.s = map[string]string{}
+		v.set = true
+	}
+	return splitKeyValues(s, v.kvSep, v.pairSep, func(key, val string) {
+		(*v.s)[key] = val
+	})
+}
+
+// Get implements flag.Getter
+func (v *KeyValueMapFlag) Get() interface{} {
+	return *v.s
+}
+
+// String implements flag.Value and pflag.Value
+func (v *KeyValueMapFlag) String() string {
+	if v.s == nil || len(*v.s) == 0 {
+		return ""
+	}
+	entries := make([]string, 0, len(*v.s))
+	for _, k := range sortedKeys(*v.s) {
+		entries = append(entries, k+v.kvSep+(*v.s)[k])
+	}
+	return strings.Join(entries, entrySep(v.pairSep))
+}
+
+// Type implements pflag.Value
+func (v *KeyValueMapFlag) Type() string {
+	return fmt.Sprintf("%T", v.s)
+}
+
+// KeyValueSliceMapFlag is a wrapper around map[string][]string that
+// accumulates a value every time it's set, so "-header a=1 -header a=2"
+// yields {"a": ["1", "2"]}. The first Set replaces the default value.
+type KeyValueSliceMapFlag struct {
+	s       *map[string][]string
+	kvSep   string
+	pairSep string
+	set     bool
+}
+
+// NewKeyValueSliceMapFlag is the constructor for KeyValueSliceMapFlag. The
+// separators work as they do for NewKeyValueMapFlag.
+func NewKeyValueSliceMapFlag(m *map[string][]string, kvSep, pairSep string) *KeyValueSliceMapFlag {
+	return &KeyValueSliceMapFlag{s: m, kvSep: kvSep, pairSep: pairSep}
+}
+
+// Set implement pflag.Value and flag.Value
+func (v *KeyValueSliceMapFlag) Set(s string) error {
+	if !v.set {
+		// don't modify the default's map, which may be shared
+		*v.s = map[string][]string{}
+		v.set = true
+	}
+	return splitKeyValues(s, v.kvSep, v.pairSep, func(key, val string) {
+		(*v.s)[key] = append((*v.s)[key], val)
+	})
+}
+
+// Get implements flag.Getter
+func (v *KeyValueSliceMapFlag) Get() interface{} {
+	return *v.s
+}
+
+// String implements flag.Value and pflag.Value
+func (v *KeyValueSliceMapFlag) String() string {
+	if v.s == nil || len(*v.s) == 0 {
+		return ""
+	}
+	entries := []string{}
+	for _, k := range sortedKeys(*v.s) {
+		for _, val := range (*v.s)[k] {
+			entries = append(entries, k+v.kvSep+val)
+		}
+	}
+	return strings.Join(entries, entrySep(v.pairSep))
+}
+
+// Type implements pflag.Value
+func (v *KeyValueSliceMapFlag) Type() string {
+	return fmt.Sprintf("%T", v.s)
+}
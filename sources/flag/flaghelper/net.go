@@ -0,0 +1,85 @@
+package flaghelper
+
+import (
+	"net"
+	"net/url"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// URLFlag is a wrapper around a url.URL
+type URLFlag struct {
+	u *url.URL
+}
+
+// NewURLFlag is the constructor for URLFlag
+func NewURLFlag(u *url.URL) *URLFlag {
+	return &URLFlag{u: u}
+}
+
+// Set implements flag.Value and pflag.Value
+func (v *URLFlag) Set(s string) error {
+	parsed, err := parse.URL(s)
+	if err != nil {
+		return err
+	}
+	*v.u = *parsed
+	return nil
+}
+
+// Get implements flag.Getter
+func (v *URLFlag) Get() interface{} {
+	return v.u
+}
+
+// String implements flag.Value and pflag.Value
+func (v *URLFlag) String() string {
+	if v.u == nil {
+		return ""
+	}
+	return v.u.String()
+}
+
+// Type implements pflag.Value
+func (v *URLFlag) Type() string {
+	return "url"
+}
+
+// IPNetFlag is a wrapper around a net.IPNet, set from CIDR notation
+// (e.g. "192.0.2.0/24")
+type IPNetFlag struct {
+	n *net.IPNet
+}
+
+// NewIPNetFlag is the constructor for IPNetFlag
+func NewIPNetFlag(n *net.IPNet) *IPNetFlag {
+	return &IPNetFlag{n: n}
+}
+
+// Set implements flag.Value and pflag.Value
+func (v *IPNetFlag) Set(s string) error {
+	parsed, err := parse.IPNet(s)
+	if err != nil {
+		return err
+	}
+	*v.n = *parsed
+	return nil
+}
+
+// Get implements flag.Getter
+func (v *IPNetFlag) Get() interface{} {
+	return v.n
+}
+
+// String implements flag.Value and pflag.Value
+func (v *IPNetFlag) String() string {
+	if v.n == nil || v.n.IP == nil {
+		return ""
+	}
+	return v.n.String()
+}
+
+// Type implements pflag.Value
+func (v *IPNetFlag) Type() string {
+	return "cidr"
+}
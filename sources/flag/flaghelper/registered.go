@@ -0,0 +1,49 @@
+package flaghelper
+
+import (
+	"reflect"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// RegisteredTypeFlag wraps a value of a type registered with
+// parse.RegisterType, which it's parsed and formatted with.
+type RegisteredTypeFlag struct {
+	v reflect.Value
+}
+
+// NewRegisteredTypeFlag is the constructor for RegisteredTypeFlag. v is a
+// pointer to the value.
+func NewRegisteredTypeFlag(v reflect.Value) *RegisteredTypeFlag {
+	return &RegisteredTypeFlag{v: v}
+}
+
+// Set implement pflag.Value and flag.Value
+func (r *RegisteredTypeFlag) Set(s string) error {
+	parsed, err := parse.String(s, r.v.Type().Elem())
+	if err != nil {
+		return err
+	}
+	r.v.Elem().Set(parsed.Elem())
+	return nil
+}
+
+// Get implements flag.Getter
+func (r *RegisteredTypeFlag) Get() interface{} {
+	return r.v.Elem().Interface()
+}
+
+// String implements flag.Value and pflag.Value
+func (r *RegisteredTypeFlag) String() string {
+	// the flag package calls String on a zero value
+	if !r.v.IsValid() {
+		return ""
+	}
+	s, _ := parse.Format(r.v.Elem())
+	return s
+}
+
+// Type implements pflag.Value
+func (r *RegisteredTypeFlag) Type() string {
+	return r.v.Type().Elem().String()
+}
@@ -0,0 +1,107 @@
+package flaghelper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// StructMapFlag is a wrapper around a map whose values are pointers to
+// structs with pointer fields (such as a pointerified map of structs). Every
+// time it's set, it sets fields of the map's entries (see
+// parse.StructMapInto), so "-backend primary.port:5432 -backend
+// primary.host:db1" yields a single "primary" entry with both fields set.
+type StructMapFlag struct {
+	m reflect.Value
+}
+
+// NewStructMapFlag is the constructor for StructMapFlag. m is a pointer to
+// the map, which is replaced by a new map the first time the flag is set.
+func NewStructMapFlag(m reflect.Value) *StructMapFlag {
+	return &StructMapFlag{m: m}
+}
+
+// Set implement pflag.Value and flag.Value
+func (v *StructMapFlag) Set(s string) error {
+	if !v.set() {
+		v.m.Elem().Set(reflect.MakeMap(v.m.Type().Elem()))
+	}
+	return parse.StructMapInto(s, v.m.Elem())
+}
+
+func (v *StructMapFlag) set() bool {
+	return v.m.IsValid() && !v.m.IsNil() && !v.m.Elem().IsNil()
+}
+
+// Get implements flag.Getter
+func (v *StructMapFlag) Get() interface{} {
+	return v.m.Elem().Interface()
+}
+
+// String implements flag.Value and pflag.Value
+func (v *StructMapFlag) String() string {
+	// the flag package calls String on a zero value
+	if !v.set() || v.m.Elem().Len() == 0 {
+		return ""
+	}
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	entries := make([]entry, 0, v.m.Elem().Len())
+	iter := v.m.Elem().MapRange()
+	for iter.Next() {
+		entries = append(entries, entry{key: fmt.Sprint(iter.Key().Interface()), val: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	fields := []string{}
+	for _, e := range entries {
+		fields = appendStructFields(fields, e.key, e.val)
+	}
+	return strings.Join(fields, ",")
+}
+
+// appendStructFields appends "prefix.Field:value" to out for each set field
+// of the struct (or pointer to a struct) v.
+func appendStructFields(out []string, prefix string, v reflect.Value) []string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return out
+		}
+		v = v.Elem()
+	}
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		f := v.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			if f.IsNil() {
+				continue
+			}
+		default:
+		}
+		path := prefix + "." + sf.Name
+		if f.Kind() == reflect.Ptr && f.Elem().Kind() == reflect.Struct && !ptrify.IsScalarStruct(f.Elem().Type()) {
+			out = appendStructFields(out, path, f)
+			continue
+		}
+		if f.Kind() == reflect.Ptr {
+			f = f.Elem()
+		}
+		out = append(out, strconv.Quote(path)+":"+strconv.Quote(fmt.Sprint(f.Interface())))
+	}
+	return out
+}
+
+// Type implements pflag.Value
+func (v *StructMapFlag) Type() string {
+	return v.m.Type().Elem().String()
+}
@@ -2,6 +2,8 @@ package flaghelper
 
 import (
 	"time"
+
+	"github.com/vimeo/dials/parse"
 )
 
 // TimeWrapper wraps a time.Time
@@ -10,6 +12,10 @@ import (
 // print or omit the default value in PrintDefaults.
 type TimeWrapper struct {
 	t time.Time
+	// layouts are the layouts accepted by Set, the first of which is used
+	// by String. If empty, Set uses UnmarshalText and String uses
+	// time.RFC3339Nano.
+	layouts []string
 }
 
 // NewTimeWrapper creates a new TimeWrapper for a time.Time
@@ -19,9 +25,26 @@ func NewTimeWrapper(t time.Time) *TimeWrapper {
 	}
 }
 
+// NewTimeWrapperWithLayouts creates a new TimeWrapper for a time.Time that
+// accepts values in any of the layouts (see time.Parse).
+func NewTimeWrapperWithLayouts(t time.Time, layouts []string) *TimeWrapper {
+	return &TimeWrapper{
+		t:       t,
+		layouts: layouts,
+	}
+}
+
 // Set implements flag.Value
 func (tw *TimeWrapper) Set(s string) error {
-	return tw.t.UnmarshalText([]byte(s))
+	if len(tw.layouts) == 0 {
+		return tw.t.UnmarshalText([]byte(s))
+	}
+	t, err := parse.Time(s, tw.layouts)
+	if err != nil {
+		return err
+	}
+	tw.t = t
+	return nil
 }
 
 // Get implements flag.Value
@@ -31,6 +54,9 @@ func (tw *TimeWrapper) Get() interface{} {
 
 // String implements flag.Value
 func (tw *TimeWrapper) String() string {
+	if len(tw.layouts) > 0 {
+		return tw.t.Format(tw.layouts[0])
+	}
 	// This uses the same format as MarshalText but without the date range validation
 	return tw.t.Format(time.RFC3339Nano)
 }
@@ -0,0 +1,43 @@
+package flaghelper
+
+import (
+	"flag"
+	"reflect"
+)
+
+// ValueFlag wraps a field whose type implements flag.Value (via its pointer),
+// adding the Get method the flag source reads the field's value back with,
+// and the Type method pflag.Value requires.
+type ValueFlag struct {
+	v reflect.Value
+}
+
+// NewValueFlag is the constructor for ValueFlag. v is a pointer to the value,
+// which must implement flag.Value.
+func NewValueFlag(v reflect.Value) *ValueFlag {
+	return &ValueFlag{v: v}
+}
+
+// Set implements flag.Value and pflag.Value
+func (f *ValueFlag) Set(s string) error {
+	return f.v.Interface().(flag.Value).Set(s)
+}
+
+// Get implements flag.Getter, returning the value itself.
+func (f *ValueFlag) Get() interface{} {
+	return f.v.Elem().Interface()
+}
+
+// String implements flag.Value and pflag.Value
+func (f *ValueFlag) String() string {
+	// the flag package calls String on a zero value
+	if !f.v.IsValid() {
+		return ""
+	}
+	return f.v.Interface().(flag.Value).String()
+}
+
+// Type implements pflag.Value
+func (f *ValueFlag) Type() string {
+	return f.v.Type().Elem().String()
+}
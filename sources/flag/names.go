@@ -0,0 +1,45 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vimeo/dials"
+)
+
+// FieldNames returns the flag (as written on the command line, e.g.
+// "-database-port") that sets each leaf field of the config type, keyed by
+// the path of Go field names joined with dots (e.g. "Database.Port"). Fields
+// without a flag (including those bound to positional arguments) are omitted.
+// It's useful for generating documentation.
+//
+// The flags must already be registered (by one of the constructors), so t is
+// only used to check compatibility.
+func (s *Set) FieldNames(t *dials.Type) (map[string]string, error) {
+	if !s.flagsRegistered {
+		return nil, fmt.Errorf("flags have not been registered")
+	}
+	if s.ptrType != nil && !t.Type().ConvertibleTo(s.ptrType) {
+		return nil, fmt.Errorf(
+			"incompatible types called with FieldNames() (%s) and constructor for flag Source (%s)",
+			t.Type(), s.ptrType)
+	}
+	typ := s.trnslVal.Type()
+	names := make(map[string]string, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if _, ok := sf.Tag.Lookup(ArgTag); ok {
+			continue
+		}
+		if dft, ok := sf.Tag.Lookup(dialsFlagTag); ok && dft == "-" {
+			continue
+		}
+		name, _, err := s.mkname(sf)
+		if err != nil {
+			return nil, err
+		}
+		path := strings.ReplaceAll(sf.Tag.Get(fieldPathTag), ",", ".")
+		names[path] = "-" + name
+	}
+	return names, nil
+}
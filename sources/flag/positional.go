@@ -0,0 +1,99 @@
+package flag
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// positionalArg binds a field of the flattened struct to positional
+// arguments.
+type positionalArg struct {
+	fieldName string
+	index     int
+	// rest indicates that the field is a slice receiving the arguments
+	// from index onwards
+	rest bool
+}
+
+func parsePositional(spec string, sf reflect.StructField) (positionalArg, error) {
+	rest := strings.HasSuffix(spec, "...")
+	idxStr := strings.TrimSuffix(spec, "...")
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 {
+		return positionalArg{}, fmt.Errorf("field %q: invalid %s tag %q: expected a non-negative index", sf.Name, ArgTag, spec)
+	}
+	if rest && stripTypePtr(sf.Type).Kind() != reflect.Slice {
+		return positionalArg{}, fmt.Errorf("field %q: %s tag %q binds multiple arguments, but the field is not a slice", sf.Name, ArgTag, spec)
+	}
+	return positionalArg{fieldName: sf.Name, index: idx, rest: rest}, nil
+}
+
+// bindPositional sets the fields bound to positional arguments from the
+// arguments remaining after parsing. Fields whose arguments are missing are
+// left unset.
+func (s *Set) bindPositional() error {
+	args := s.Flags.Args()
+	for _, pa := range s.positional {
+		if pa.index >= len(args) {
+			continue
+		}
+		ffield := s.trnslVal.FieldByName(pa.fieldName)
+		ft := stripTypePtr(ffield.Type())
+		var v reflect.Value
+		if pa.rest {
+			v = reflect.MakeSlice(ft, 0, len(args)-pa.index)
+			for i, arg := range args[pa.index:] {
+				ev, err := parseArg(arg, ft.Elem())
+				if err != nil {
+					return fmt.Errorf("invalid positional argument %d (%q): %w", pa.index+i, arg, err)
+				}
+				v = reflect.Append(v, ev)
+			}
+		} else {
+			ev, err := parseArg(args[pa.index], ft)
+			if err != nil {
+				return fmt.Errorf("invalid positional argument %d (%q): %w", pa.index, args[pa.index], err)
+			}
+			v = ev
+		}
+		if ffield.Kind() == reflect.Ptr {
+			ptr := reflect.New(ft)
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+		ffield.Set(v)
+	}
+	return nil
+}
+
+// parseArg parses a single argument into a value of type t.
+func parseArg(arg string, t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t == timeDuration:
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d), nil
+	case reflect.PtrTo(t).Implements(textMReflectType):
+		v := reflect.New(t)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(arg)); err != nil {
+			return reflect.Value{}, err
+		}
+		return v.Elem(), nil
+	}
+	v, err := parse.String(arg, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v.Kind() == reflect.Ptr && t.Kind() != reflect.Ptr {
+		v = v.Elem()
+	}
+	return v.Convert(t), nil
+}
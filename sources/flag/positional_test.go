@@ -0,0 +1,77 @@
+package flag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+func TestPositionalArgs(t *testing.T) {
+	type Config struct {
+		Verbose bool
+		Command string        `dialsarg:"0"`
+		Timeout time.Duration `dialsarg:"1"`
+		Files   []string      `dialsarg:"2..."`
+	}
+	ctx := context.Background()
+
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{},
+		[]string{"-verbose", "copy", "5s", "a.txt", "b.txt"})
+	require.NoError(t, err)
+	// positional fields don't get flags
+	assert.Nil(t, src.Flags.Lookup("command"))
+
+	d, err := dials.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Verbose: true,
+		Command: "copy",
+		Timeout: 5 * time.Second,
+		Files:   []string{"a.txt", "b.txt"},
+	}, d.View())
+
+	// missing arguments leave the fields alone
+	src, err = NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"list"})
+	require.NoError(t, err)
+	d, err = dials.Config(ctx, &Config{Timeout: time.Minute}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Command: "list", Timeout: time.Minute}, d.View())
+
+	src, err = NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"copy", "soon"})
+	require.NoError(t, err)
+	_, err = dials.Config(ctx, &Config{}, src)
+	assert.ErrorContains(t, err, `invalid positional argument 1 ("soon")`)
+}
+
+func TestPositionalArgsNested(t *testing.T) {
+	type Target struct {
+		Ports []int `dialsarg:"1..."`
+	}
+	type Config struct {
+		Host   string `dialsarg:"0"`
+		Target Target
+	}
+	src, err := NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{"example.com", "80", "443"})
+	require.NoError(t, err)
+	d, err := dials.Config(context.Background(), &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Host: "example.com", Target: Target{Ports: []int{80, 443}}}, d.View())
+}
+
+func TestPositionalArgsInvalidTag(t *testing.T) {
+	type BadIndex struct {
+		A string `dialsarg:"first"`
+	}
+	_, err := NewSetWithArgs(DefaultFlagNameConfig(), &BadIndex{}, nil)
+	assert.Error(t, err)
+
+	type NotSlice struct {
+		A string `dialsarg:"0..."`
+	}
+	_, err = NewSetWithArgs(DefaultFlagNameConfig(), &NotSlice{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldPathTag is set by the FlattenMangler to the comma-separated names of
+// the fields leading to a flattened field.
+const fieldPathTag = "dialsfieldpath"
+
+// installUsage replaces the usage function of the Set's FlagSet with Usage,
+// so hidden flags are omitted from the output. For flag.CommandLine, the
+// flag package's Usage variable is replaced instead. Programs with their own
+// usage functions should set them after constructing the Set, and call
+// PrintDefaults from them.
+func (s *Set) installUsage() {
+	if s.Flags == flag.CommandLine {
+		flag.Usage = s.Usage
+		return
+	}
+	s.Flags.Usage = s.Usage
+}
+
+// Usage prints a usage message listing the flags in the Set's FlagSet to the
+// FlagSet's output. If a template was installed with SetUsageTemplate, it's
+// rendered; otherwise the output has the same format as the flag package's
+// default usage message, but omits hidden flags.
+func (s *Set) Usage() {
+	out := s.Flags.Output()
+	if s.usageTmpl != nil {
+		if err := s.usageTmpl.Execute(out, s.UsageData()); err != nil {
+			fmt.Fprintf(out, "failed to render usage: %s\n", err)
+		}
+		return
+	}
+	if name := s.Flags.Name(); name != "" {
+		fmt.Fprintf(out, "Usage of %s:\n", name)
+	} else {
+		fmt.Fprint(out, "Usage:\n")
+	}
+	s.PrintDefaults()
+}
+
+// PrintDefaults is equivalent to the PrintDefaults method on the Set's
+// FlagSet, but omits hidden flags (those with a `dialsflag:",hidden"` tag),
+// and lists flags with a GroupTag in a section per group after the
+// ungrouped flags. Sections are sorted by group name, and flags by name.
+func (s *Set) PrintDefaults() {
+	out := s.Flags.Output()
+	for _, group := range s.UsageData().Groups {
+		if group.Name != "" {
+			fmt.Fprintf(out, "\n%s:\n", group.Name)
+		}
+		for _, f := range group.Flags {
+			fmt.Fprint(out, formatFlag(f), "\n")
+		}
+	}
+}
+
+// formatFlag formats the usage of f the same way as flag.PrintDefaults.
+func formatFlag(f UsageFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  -%s", f.Name) // Two spaces before -; see next two comments.
+	if len(f.ValueName) > 0 {
+		b.WriteString(" ")
+		b.WriteString(f.ValueName)
+	}
+	// Boolean flags of one ASCII letter are so common we
+	// treat them specially, putting their usage on the same line.
+	if b.Len() <= 4 { // space, space, '-', 'x'.
+		b.WriteString("\t")
+	} else {
+		// Four spaces before the tab triggers good alignment
+		// for both 4- and 8-space tab stops.
+		b.WriteString("\n    \t")
+	}
+	b.WriteString(strings.ReplaceAll(f.Usage, "\n", "\n    \t"))
+	if len(f.Choices) > 0 {
+		fmt.Fprintf(&b, " (one of: %s)", strings.Join(f.Choices, ", "))
+	}
+
+	// Print the default value only if it differs to the zero value
+	if f.Default != "" {
+		fmt.Fprintf(&b, " (default %s)", f.Default)
+	}
+	return b.String()
+}
+
+// formatDefault returns the default value of f, quoted if it's a string, or
+// the empty string if it's the zero value for its type.
+func formatDefault(f *flag.Flag) string {
+	if isZeroValue(f) {
+		return ""
+	}
+	if g, ok := f.Value.(flag.Getter); ok {
+		if _, isStr := g.Get().(string); isStr {
+			return fmt.Sprintf("%q", f.DefValue)
+		}
+	}
+	return f.DefValue
+}
+
+// isZeroValue determines whether the default value of f is the zero value
+// for its type.
+func isZeroValue(f *flag.Flag) (isZero bool) {
+	v := unwrapFlag(f).Value
+	typ := reflect.TypeOf(v)
+	var z reflect.Value
+	if typ.Kind() == reflect.Ptr {
+		z = reflect.New(typ.Elem())
+	} else {
+		z = reflect.Zero(typ)
+	}
+	// the String method may panic on a zero value; treat those as
+	// non-zero defaults, as the flag package does.
+	defer func() {
+		if recover() != nil {
+			isZero = false
+		}
+	}()
+	return f.DefValue == z.Interface().(flag.Value).String()
+}
+
+// flagGroup returns the group for the flattened field sf of the pointerified
+// config type ptyp from the GroupTag of the innermost field along its path
+// that has one.
+func flagGroup(ptyp reflect.Type, sf reflect.StructField) string {
+	if group, ok := sf.Tag.Lookup(GroupTag); ok {
+		return group
+	}
+	path := sf.Tag.Get(fieldPathTag)
+	if path == "" {
+		return ""
+	}
+	group := ""
+	t := ptyp
+	for _, name := range strings.Split(path, ",") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			break
+		}
+		if g, ok := f.Tag.Lookup(GroupTag); ok {
+			group = g
+		}
+		t = f.Type
+	}
+	return group
+}
+
+// unwrapFlag returns f, or a copy of f with the Value set to the one wrapped
+// by dials (e.g. for EnumTag validation), since the flag package inspects the
+// concrete types of Values.
+func unwrapFlag(f *flag.Flag) *flag.Flag {
+	w, ok := f.Value.(valueWrapper)
+	if !ok {
+		return f
+	}
+	uf := *f
+	for ok {
+		uf.Value = w.unwrap()
+		w, ok = uf.Value.(valueWrapper)
+	}
+	return &uf
+}
+
+// valueWrapper is implemented by the flag.Values dials wraps around those of
+// some flags.
+type valueWrapper interface {
+	unwrap() flag.Value
+}
@@ -0,0 +1,165 @@
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// UsageFlag describes a flag for usage templates.
+type UsageFlag struct {
+	// Name is the flag's name, without leading dashes.
+	Name string
+	// ValueName is the name of the flag's value (e.g. "string"), as
+	// returned by flag.UnquoteUsage. It's empty for boolean flags.
+	ValueName string
+	// Usage is the flag's help text (from the dialsdesc tag), with any
+	// back-quotes removed.
+	Usage string
+	// Default is the flag's default value as formatted by
+	// flag.PrintDefaults (strings are quoted), or empty if it's the zero
+	// value.
+	Default string
+	// Shorthand is the flag's one-letter shorthand, if any.
+	Shorthand string
+	// AliasOf is the name of the flag this one is a shorthand or
+	// deprecated alias of, if any.
+	AliasOf string
+	// Deprecated is true for deprecated aliases.
+	Deprecated bool
+	// Choices contains the values declared with the EnumTag, if any.
+	Choices []string
+	// FieldPath contains the names of the fields leading to the flag's
+	// field, starting at the top-level config struct (e.g. ["Database",
+	// "Host"]). It's empty for flags dials didn't register.
+	FieldPath []string
+}
+
+// UsageGroup is a named section of flags. See GroupTag.
+type UsageGroup struct {
+	// Name is empty for the section of ungrouped flags.
+	Name  string
+	Flags []UsageFlag
+}
+
+// UsageData is the data passed to usage templates.
+type UsageData struct {
+	// Name is the name of the FlagSet (usually the program's name).
+	Name string
+	// Groups contains the sections of flags, with ungrouped flags first,
+	// followed by the named groups sorted by name. Each group's flags are
+	// sorted by name, and hidden flags are omitted.
+	Groups []UsageGroup
+}
+
+// UsageData returns a description of the flags registered in the Set's
+// FlagSet, for programs that render their own usage output.
+func (s *Set) UsageData() UsageData {
+	fieldPaths := map[string][]string{}
+	if s.trnslVal.IsValid() {
+		tt := s.trnslVal.Type()
+		for flagName, fieldName := range s.flagFieldName {
+			if sf, ok := tt.FieldByName(fieldName); ok {
+				if path := sf.Tag.Get(fieldPathTag); path != "" {
+					fieldPaths[flagName] = strings.Split(path, ",")
+				}
+			}
+		}
+	}
+
+	groups := map[string][]UsageFlag{}
+	s.Flags.VisitAll(func(f *flag.Flag) {
+		if _, hidden := s.hidden[f.Name]; hidden {
+			return
+		}
+		valueName, usage := flag.UnquoteUsage(unwrapFlag(f))
+		_, deprecated := s.deprecatedAliases[f.Name]
+		group := s.flagGroup[f.Name]
+		groups[group] = append(groups[group], UsageFlag{
+			Name:       f.Name,
+			ValueName:  valueName,
+			Usage:      usage,
+			Default:    formatDefault(f),
+			Shorthand:  s.shorthands[f.Name],
+			AliasOf:    s.aliasOf[f.Name],
+			Deprecated: deprecated,
+			Choices:    s.flagChoices[f.Name],
+			FieldPath:  fieldPaths[f.Name],
+		})
+	})
+
+	groupNames := make([]string, 0, len(groups))
+	for name := range groups {
+		groupNames = append(groupNames, name)
+	}
+	// the ungrouped flags sort first, since "" precedes any group name
+	sort.Strings(groupNames)
+
+	data := UsageData{Name: s.Flags.Name(), Groups: make([]UsageGroup, len(groupNames))}
+	for i, name := range groupNames {
+		data.Groups[i] = UsageGroup{Name: name, Flags: groups[name]}
+	}
+	return data
+}
+
+// SetUsageTemplate parses text as a text/template, and installs it as the
+// usage function of the Set's FlagSet (or flag.Usage for flag.CommandLine).
+// The template is executed with a UsageData, so it controls every aspect of
+// the output, including any preamble or epilogue.
+//
+// In addition to the built-in template functions, templates may call
+//   - pad: `pad width s` pads s with spaces to width characters
+//   - wrap: `wrap width indent s` word-wraps s to lines of at most width
+//     characters, indenting all but the first line by indent spaces (the
+//     first line is assumed to start at the same column)
+//   - join: `join elems sep` is strings.Join
+//
+// Functions in funcs (e.g. one mapping a UsageFlag's FieldPath to the name of
+// the equivalent environment variable) are also available, and override the
+// functions above.
+func (s *Set) SetUsageTemplate(text string, funcs template.FuncMap) error {
+	tmpl, err := template.New("usage").Funcs(usageFuncs).Funcs(funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse usage template: %w", err)
+	}
+	s.usageTmpl = tmpl
+	s.installUsage()
+	return nil
+}
+
+var usageFuncs = template.FuncMap{
+	"pad":  pad,
+	"wrap": wrap,
+	"join": strings.Join,
+}
+
+func pad(width int, s string) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func wrap(width, indent int, s string) string {
+	b := strings.Builder{}
+	lineLen := indent
+	for i, word := range strings.Fields(s) {
+		wordLen := utf8.RuneCountInString(word)
+		switch {
+		case i == 0:
+		case lineLen+1+wordLen > width:
+			b.WriteString("\n")
+			b.WriteString(strings.Repeat(" ", indent))
+			lineLen = indent
+		default:
+			b.WriteString(" ")
+			lineLen++
+		}
+		b.WriteString(word)
+		lineLen += wordLen
+	}
+	return b.String()
+}
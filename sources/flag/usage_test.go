@@ -0,0 +1,139 @@
+package flag
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+func TestHiddenFlags(t *testing.T) {
+	type Config struct {
+		Name      string        `dialsdesc:"the service name"`
+		Timeout   time.Duration `dialsdesc:"request timeout"`
+		DebugDump bool          `dialsflag:",hidden" dialsdesc:"dump internal state"`
+		Trace     bool          `dialsflag:"trace,t,hidden"`
+	}
+	fs := flag.NewFlagSet("svc", flag.ContinueOnError)
+	src, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &Config{Name: "widget", Timeout: time.Second}, fs)
+	require.NoError(t, err)
+
+	// hidden flags are still registered
+	require.NotNil(t, fs.Lookup("debug-dump"))
+	require.NotNil(t, fs.Lookup("t"))
+
+	buf := bytes.Buffer{}
+	fs.SetOutput(&buf)
+	fs.Usage()
+	assert.Equal(t, "Usage of svc:\n"+
+		"  -name string\n    \tthe service name (default \"widget\")\n"+
+		"  -timeout duration\n    \trequest timeout (default 1s)\n", buf.String())
+
+	require.NoError(t, fs.Parse([]string{"-debug-dump", "-t"}))
+	d, err := dials.Config(context.Background(), &Config{}, src)
+	require.NoError(t, err)
+	assert.True(t, d.View().DebugDump)
+	assert.True(t, d.View().Trace)
+}
+
+func TestPrintDefaultsMatchesFlagPackage(t *testing.T) {
+	type Config struct {
+		Name    string `dialsdesc:"the \x60service\x60 name"`
+		V       bool
+		Count   int `dialsflag:"count,c"`
+		Ratio   float64
+		Tags    []string
+		Started time.Time
+	}
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	src, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &Config{Name: "widget", Count: 3}, fs)
+	require.NoError(t, err)
+
+	want := bytes.Buffer{}
+	fs.SetOutput(&want)
+	fs.PrintDefaults()
+
+	got := bytes.Buffer{}
+	fs.SetOutput(&got)
+	src.PrintDefaults()
+	assert.Equal(t, want.String(), got.String())
+}
+
+func TestFlagGroups(t *testing.T) {
+	type Database struct {
+		Host     string `dialsdesc:"database host"`
+		Password string `dialsflaggroup:"Secrets" dialsdesc:"database password"`
+	}
+	type Config struct {
+		Name     string   `dialsdesc:"the service name"`
+		Database Database `dialsflaggroup:"Database"`
+		Verbose  bool     `dialsflag:"verbose,v" dialsflaggroup:"Logging" dialsdesc:"log verbosely"`
+	}
+	fs := flag.NewFlagSet("svc", flag.ContinueOnError)
+	_, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &Config{}, fs)
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	fs.SetOutput(&buf)
+	fs.Usage()
+	assert.Equal(t, "Usage of svc:\n"+
+		"  -name string\n    \tthe service name\n"+
+		"\nDatabase:\n"+
+		"  -database-host string\n    \tdatabase host\n"+
+		"\nLogging:\n"+
+		"  -v\tshorthand for -verbose\n"+
+		"  -verbose\n    \tlog verbosely (shorthand -v)\n"+
+		"\nSecrets:\n"+
+		"  -database-password string\n    \tdatabase password\n", buf.String())
+}
+
+func TestUsageTemplate(t *testing.T) {
+	type Database struct {
+		Host string `dialsdesc:"the host of the database server to connect to on startup"`
+	}
+	type Config struct {
+		Mode     string `dialsenum:"fast,slow" dialsdesc:"operating mode"`
+		Verbose  bool   `dialsflag:"verbose,v" dialsdesc:"log verbosely"`
+		Database Database
+	}
+	fs := flag.NewFlagSet("svc", flag.ContinueOnError)
+	src, err := NewSetWithFlagSet(DefaultFlagNameConfig(), &Config{Mode: "fast"}, fs)
+	require.NoError(t, err)
+
+	const tmpl = `svc does things.
+
+Flags:
+{{range .Groups}}{{range .Flags}}{{if not .AliasOf}}  {{if .Shorthand}}-{{.Shorthand}}, {{else}}    {{end}}{{pad 16 (print "-" .Name)}}{{wrap 60 22 .Usage}}
+{{- if .Choices}} (one of: {{join .Choices ", "}}){{end}}
+{{- if .Default}} [default: {{.Default}}]{{end}}
+{{- if .FieldPath}} [env: {{env .FieldPath}}]{{end}}
+{{end}}{{end}}{{end}}
+See the manual for more.
+`
+	require.NoError(t, src.SetUsageTemplate(tmpl, template.FuncMap{
+		"env": func(path []string) string { return strings.ToUpper(strings.Join(path, "_")) },
+	}))
+
+	buf := bytes.Buffer{}
+	fs.SetOutput(&buf)
+	fs.Usage()
+	assert.Equal(t, `svc does things.
+
+Flags:
+      -database-host  the host of the database server to
+                      connect to on startup [env: DATABASE_HOST]
+      -mode           operating mode (one of: fast, slow) [default: "fast"] [env: MODE]
+  -v, -verbose        log verbosely (shorthand -v) [env: VERBOSE]
+
+See the manual for more.
+`, buf.String())
+
+	assert.Error(t, src.SetUsageTemplate("{{", nil))
+}
@@ -0,0 +1,252 @@
+// Package koanf bridges dials and [github.com/knadh/koanf]: Source and Decoder
+// use koanf Providers and Parsers (e.g. for a backend dials has no source
+// for) as a dials Source and Decoder, and NewProvider and NewParser expose
+// dials Sources and Decoders to koanf.
+//
+// This package doesn't depend on koanf; its Providers and Parsers satisfy the
+// Provider and Parser interfaces, and the types returned by NewProvider and
+// NewParser satisfy koanf's.
+package koanf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/sources/viper"
+)
+
+// Provider is koanf's Provider interface.
+type Provider interface {
+	// ReadBytes returns the raw configuration, to be parsed by a Parser.
+	ReadBytes() ([]byte, error)
+	// Read returns the parsed configuration as a (nested) map.
+	Read() (map[string]interface{}, error)
+}
+
+// WatchProvider is implemented by koanf Providers that can watch for changes
+// (e.g. its file provider).
+type WatchProvider interface {
+	Provider
+	// Watch calls cb whenever the configuration changes, or watching it
+	// fails.
+	Watch(cb func(event interface{}, err error)) error
+}
+
+// Parser is koanf's Parser interface.
+type Parser interface {
+	Unmarshal([]byte) (map[string]interface{}, error)
+	Marshal(map[string]interface{}) ([]byte, error)
+}
+
+// Source implements the dials.Source interface, populating fields from the
+// configuration read by a koanf Provider. Keys are matched to fields as they
+// are by the viper source (see [viper.Source]): by the fields' `dials` tags
+// or names, case-insensitively, with nested maps for nested structs.
+type Source struct {
+	Provider Provider
+	// Parser, if non-nil, parses the bytes returned by the Provider's
+	// ReadBytes method. Otherwise, its Read method is used.
+	Parser Parser
+}
+
+var _ dials.Source = (*Source)(nil)
+
+func (s *Source) read() (map[string]interface{}, error) {
+	if s.Parser == nil {
+		return s.Provider.Read()
+	}
+	b, err := s.Provider.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	return s.Parser.Unmarshal(b)
+}
+
+// Value reads the configuration from the Provider.
+func (s *Source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	m, err := s.read()
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to read from koanf provider: %w", err)
+	}
+	return fromMap(ctx, m, t)
+}
+
+// WatchingSource is a Source whose Provider implements WatchProvider, and
+// which reports the new configuration whenever it signals a change.
+type WatchingSource struct {
+	Source
+}
+
+var _ dials.Watcher = (*WatchingSource)(nil)
+
+// Watch starts watching the Provider, which must implement WatchProvider.
+func (ws *WatchingSource) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	wp, ok := ws.Provider.(WatchProvider)
+	if !ok {
+		return fmt.Errorf("koanf provider %T doesn't support watching", ws.Provider)
+	}
+	return wp.Watch(func(_ interface{}, err error) {
+		if err != nil {
+			args.ReportError(ctx, err)
+			return
+		}
+		v, err := ws.Value(ctx, t)
+		if err != nil {
+			args.ReportError(ctx, err)
+			return
+		}
+		args.ReportNewValue(ctx, v)
+	})
+}
+
+// StopWatch stops watching the Provider, if it has an Unwatch method (as
+// koanf's file provider does).
+func (ws *WatchingSource) StopWatch(context.Context) error {
+	if u, ok := ws.Provider.(interface{ Unwatch() error }); ok {
+		return u.Unwatch()
+	}
+	return nil
+}
+
+// Decoder implements the dials.Decoder interface with a koanf Parser, so
+// formats koanf supports can be used with the file source (among others).
+type Decoder struct {
+	Parser Parser
+}
+
+var _ dials.Decoder = (*Decoder)(nil)
+
+// Decode parses the contents of r with the Parser.
+func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but passes ctx through to the conversion of
+// the parsed configuration.
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("error reading: %w", err)
+	}
+	m, err := d.Parser.Unmarshal(b)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return fromMap(ctx, m, t)
+}
+
+// fromMap converts the nested map m into a value of the type t.
+func fromMap(ctx context.Context, m map[string]interface{}, t *dials.Type) (reflect.Value, error) {
+	return (&viper.Source{Viper: mapSettings(m)}).Value(ctx, t)
+}
+
+// mapSettings implements viper.Viper over a nested map, matching keys
+// case-insensitively.
+type mapSettings map[string]interface{}
+
+func (m mapSettings) lookup(key string) (interface{}, bool) {
+	var v interface{} = map[string]interface{}(m)
+	for _, part := range strings.Split(key, ".") {
+		cur, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for k, val := range cur {
+			if strings.EqualFold(k, part) {
+				v, found = val, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func (m mapSettings) IsSet(key string) bool {
+	_, ok := m.lookup(key)
+	return ok
+}
+
+func (m mapSettings) Get(key string) interface{} {
+	v, _ := m.lookup(key)
+	return v
+}
+
+// errUnsupported is returned by the methods of the koanf interfaces that
+// dials can't implement.
+var errUnsupported = errors.New("not supported by dials")
+
+// DialsProvider is a koanf Provider reading a dials Source, returned by
+// NewProvider.
+type DialsProvider[T any] struct {
+	src  dials.Source
+	once sync.Once
+	t    *dials.Type
+}
+
+// NewProvider returns a koanf Provider whose Read method returns the values
+// set by src for the config type T, as a nested map keyed by the fields'
+// `dials` tags or lowercased names. Fields src doesn't set are omitted.
+func NewProvider[T any](src dials.Source) *DialsProvider[T] {
+	return &DialsProvider[T]{src: src}
+}
+
+func (p *DialsProvider[T]) dialsType() *dials.Type {
+	p.once.Do(func() { p.t = pointerifiedType[T]() })
+	return p.t
+}
+
+// ReadBytes isn't supported.
+func (p *DialsProvider[T]) ReadBytes() ([]byte, error) {
+	return nil, errUnsupported
+}
+
+// Read returns the values set by the Source.
+func (p *DialsProvider[T]) Read() (map[string]interface{}, error) {
+	v, err := p.src.Value(context.Background(), p.dialsType())
+	if err != nil {
+		return nil, err
+	}
+	return toMap(v), nil
+}
+
+// DialsParser is a koanf Parser using a dials Decoder, returned by NewParser.
+type DialsParser[T any] struct {
+	dec  dials.Decoder
+	once sync.Once
+	t    *dials.Type
+}
+
+// NewParser returns a koanf Parser whose Unmarshal method decodes its input
+// with dec into the config type T, and returns the values it sets like the
+// Read method of the Provider returned by NewProvider. Its Marshal method
+// isn't supported.
+func NewParser[T any](dec dials.Decoder) *DialsParser[T] {
+	return &DialsParser[T]{dec: dec}
+}
+
+// Unmarshal decodes b.
+func (p *DialsParser[T]) Unmarshal(b []byte) (map[string]interface{}, error) {
+	p.once.Do(func() { p.t = pointerifiedType[T]() })
+	v, err := dials.Decode(context.Background(), p.dec, bytes.NewReader(b), p.t)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(v), nil
+}
+
+// Marshal isn't supported.
+func (p *DialsParser[T]) Marshal(map[string]interface{}) ([]byte, error) {
+	return nil, errUnsupported
+}
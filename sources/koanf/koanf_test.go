@@ -0,0 +1,165 @@
+package koanf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	yamldec "github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/env"
+	"github.com/vimeo/dials/sources/file"
+)
+
+type testConfig struct {
+	Name     string
+	Port     int `dials:"listen_port"`
+	Timeout  time.Duration
+	Database struct {
+		User    string
+		MaxConn int
+	}
+}
+
+// jsonParser mimics koanf's json parser.
+type jsonParser struct{}
+
+func (jsonParser) Unmarshal(b []byte) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	return m, json.Unmarshal(b, &m)
+}
+
+func (jsonParser) Marshal(m map[string]interface{}) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// fakeProvider mimics koanf's rawbytes and confmap providers, and its file
+// provider's Watch and Unwatch methods.
+type fakeProvider struct {
+	raw       []byte
+	m         map[string]interface{}
+	cb        func(event interface{}, err error)
+	unwatched bool
+}
+
+func (p *fakeProvider) ReadBytes() ([]byte, error) { return p.raw, nil }
+
+func (p *fakeProvider) Read() (map[string]interface{}, error) { return p.m, nil }
+
+func (p *fakeProvider) Watch(cb func(event interface{}, err error)) error {
+	p.cb = cb
+	return nil
+}
+
+func (p *fakeProvider) Unwatch() error {
+	p.unwatched = true
+	return nil
+}
+
+func TestSource(t *testing.T) {
+	ctx := context.Background()
+	// env-style string values, and keys that differ in case from the fields
+	p := &fakeProvider{m: map[string]interface{}{
+		"name":        "svc",
+		"listen_port": "8080",
+		"database":    map[string]interface{}{"MaxConn": 5},
+	}}
+	d, err := dials.Config(ctx, &testConfig{Timeout: time.Second}, &Source{Provider: p})
+	require.NoError(t, err)
+	cfg := d.View()
+	assert.Equal(t, "svc", cfg.Name)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, 5, cfg.Database.MaxConn)
+
+	parsed := &Source{Provider: &fakeProvider{raw: []byte(`{"timeout": "5s", "database": {"user": "app"}}`)}, Parser: jsonParser{}}
+	d, err = dials.Config(ctx, &testConfig{}, parsed)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, d.View().Timeout)
+	assert.Equal(t, "app", d.View().Database.User)
+
+	_, err = dials.Config(ctx, &testConfig{}, &Source{Provider: &fakeProvider{raw: []byte(`{`)}, Parser: jsonParser{}})
+	assert.Error(t, err)
+}
+
+func TestWatchingSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := &fakeProvider{m: map[string]interface{}{"name": "before"}}
+	d, err := dials.Config(ctx, &testConfig{}, &WatchingSource{Source{Provider: p}})
+	require.NoError(t, err)
+	require.NotNil(t, p.cb)
+
+	p.m = map[string]interface{}{"name": "after"}
+	p.cb(nil, nil)
+	select {
+	case cfg := <-d.Events():
+		assert.Equal(t, "after", cfg.Name)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the new configuration")
+	}
+
+	p.cb(nil, errors.New("lost the file"))
+	require.NoError(t, d.Close(ctx))
+	assert.True(t, p.unwatched)
+	assert.Equal(t, "lost the file", d.SourceStatus()[0].LastError.Error())
+
+	_, err = dials.Config(ctx, &testConfig{}, &WatchingSource{Source{Provider: nonWatchingProvider{}}})
+	assert.ErrorContains(t, err, "doesn't support watching")
+}
+
+type nonWatchingProvider struct{}
+
+func (nonWatchingProvider) ReadBytes() ([]byte, error)            { return nil, nil }
+func (nonWatchingProvider) Read() (map[string]interface{}, error) { return nil, nil }
+
+func TestDecoder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "file", "listen_port": 9000}`), 0o600))
+	src, err := file.NewSource(path, &Decoder{Parser: jsonParser{}})
+	require.NoError(t, err)
+	d, err := dials.Config(context.Background(), &testConfig{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, "file", d.View().Name)
+	assert.Equal(t, 9000, d.View().Port)
+}
+
+func TestProvider(t *testing.T) {
+	src := &env.Source{LookupEnv: env.MapLookup(map[string]string{
+		"NAME":              "svc",
+		"LISTEN_PORT":       "8080",
+		"DATABASE_MAX_CONN": "3",
+	})}
+	p := NewProvider[testConfig](src)
+	m, err := p.Read()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "svc",
+		"listen_port": 8080,
+		"database":    map[string]interface{}{"maxconn": 3},
+	}, m)
+	_, err = p.ReadBytes()
+	assert.Error(t, err)
+
+	// the map round-trips through Source
+	d, err := dials.Config(context.Background(), &testConfig{}, &Source{Provider: &fakeProvider{m: m}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, d.View().Database.MaxConn)
+}
+
+func TestParser(t *testing.T) {
+	p := NewParser[testConfig](&yamldec.Decoder{})
+	m, err := p.Unmarshal([]byte("name: svc\ntimeout: 1m\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "svc", "timeout": time.Minute}, m)
+	_, err = p.Marshal(m)
+	assert.Error(t, err)
+
+}
@@ -0,0 +1,66 @@
+package koanf
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// pointerifiedType returns the dials.Type sources and decoders fill for the
+// config type T.
+func pointerifiedType[T any]() *dials.Type {
+	var zero T
+	v := reflect.ValueOf(&zero).Elem()
+	return dials.NewType(ptrify.Pointerify(v.Type(), v))
+}
+
+// toMap converts v, a value of a pointerified config type, into a nested map
+// of the fields that are set.
+func toMap(v reflect.Value) map[string]interface{} {
+	out := map[string]interface{}{}
+	addFields(out, v)
+	return out
+}
+
+func addFields(out map[string]interface{}, v reflect.Value) {
+	vt := v.Type()
+	for i := 0; i < vt.NumField(); i++ {
+		sf := vt.Field(i)
+		fv := v.Field(i)
+		if !sf.IsExported() || isNil(fv) {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr {
+			fv = fv.Elem()
+		}
+		nested := fv.Kind() == reflect.Struct && !ptrify.IsScalarStruct(fv.Type())
+		if nested && sf.Anonymous && sf.Tag.Get(common.EmbedTagName) == "inline" {
+			addFields(out, fv)
+			continue
+		}
+		key := strings.ToLower(sf.Name)
+		if name := sf.Tag.Get(common.DialsTagName); name != "" {
+			key = name
+		}
+		if nested {
+			m := toMap(fv)
+			if len(m) > 0 {
+				out[key] = m
+			}
+			continue
+		}
+		out[key] = fv.Interface()
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
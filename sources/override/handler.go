@@ -0,0 +1,108 @@
+package override
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnauthenticated may be wrapped by the errors returned by an Authorizer,
+// so the Handler responds with 401 Unauthorized rather than 403 Forbidden.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Authorizer decides whether the request r may read or modify the overrides,
+// returning nil to allow it, or an error describing why it's refused.
+type Authorizer func(r *http.Request) error
+
+// maxBodyBytes bounds the size of the request bodies read by the Handler.
+const maxBodyBytes = 1 << 20
+
+// Handler returns an http.Handler serving the overrides in s, to be mounted
+// on an internal admin mux:
+//   - GET responds with the current overrides
+//   - PUT replaces the overrides with the JSON object in the request body
+//   - PATCH merges the request body into the overrides as a JSON merge patch
+//     (RFC 7396), so keys set to null are removed
+//   - DELETE removes all the overrides
+//
+// Every request is passed to auth first, and refused if it returns an error
+// (or if auth is nil). Successful modifications respond with the new
+// overrides, once the configuration composed with them has been installed.
+// Overrides that don't match the configuration type are refused with 400 Bad
+// Request, and those that make the configuration fail verification (or that
+// are otherwise rejected) with 422 Unprocessable Entity, with the error in
+// the response body.
+func (s *Source) Handler(auth Authorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth == nil {
+			http.Error(w, "overrides handler has no Authorizer", http.StatusForbidden)
+			return
+		}
+		if err := auth(r); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrUnauthenticated) {
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		var err error
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPatch:
+			doc, decErr := decodeBody(w, r)
+			if decErr != nil {
+				http.Error(w, decErr.Error(), http.StatusBadRequest)
+				return
+			}
+			if r.Method == http.MethodPut {
+				err = s.Set(r.Context(), doc)
+			} else {
+				err = s.Patch(r.Context(), doc)
+			}
+		case http.MethodDelete:
+			err = s.Clear(r.Context())
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, PATCH, DELETE")
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			var decErr *DecodeError
+			if errors.As(err, &decErr) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, fmt.Sprintf("failed to apply overrides: %s", err), http.StatusUnprocessableEntity)
+			return
+		}
+
+		overrides := s.Overrides()
+		if overrides == nil {
+			overrides = map[string]interface{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(overrides); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode overrides: %s", err), http.StatusInternalServerError)
+		}
+	})
+}
+
+// decodeBody decodes the JSON object in r's body.
+func decodeBody(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	// keep large integers exact
+	dec.UseNumber()
+	doc := map[string]interface{}{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("request body must be a JSON object: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("request body must contain a single JSON object")
+	}
+	return doc, nil
+}
@@ -0,0 +1,166 @@
+// Package override provides a Source holding configuration overrides that
+// are applied at runtime, e.g. through the admin endpoint served by its
+// Handler, so every service using dials has a uniform way to tune its
+// configuration without a deploy.
+package override
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vimeo/dials"
+	jsondec "github.com/vimeo/dials/decoders/json"
+)
+
+// Source is a dials.Source (and dials.Watcher) whose value is a set of
+// overrides: a JSON object like the contents of a JSON config file (keyed
+// by the fields' `dials` or `json` tags), which can be replaced and patched
+// at runtime. It should be passed to dials.Config last, so the overrides take
+// precedence over the other sources. Initially, it sets no fields.
+//
+// The zero value is ready to use. A Source must only be passed to one call
+// to Config.
+type Source struct {
+	// mu is held while the overrides are being applied, so they're never
+	// read before they're installed (or rejected)
+	mu   sync.Mutex
+	t    *dials.Type
+	args dials.WatchArgs
+	doc  map[string]interface{}
+}
+
+var _ dials.Source = (*Source)(nil)
+var _ dials.Watcher = (*Source)(nil)
+
+// DecodeError is returned when overrides don't match the configuration type
+// (e.g. they set an unknown field, or a value of the wrong type).
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid overrides: %s", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Value returns the current overrides.
+func (s *Source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t = t
+	return decode(ctx, s.doc, t)
+}
+
+// Watch records args, to report the overrides as they change.
+func (s *Source) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t, s.args = t, args
+	return nil
+}
+
+// Overrides returns a copy of the current overrides.
+func (s *Source) Overrides() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return mergePatch(nil, s.doc)
+}
+
+// Set replaces the overrides with doc, and returns once the configuration
+// composed with them is installed, or with the error that prevented it (in
+// which case the overrides are unchanged). Overrides that don't match the
+// configuration type are rejected with a *DecodeError.
+func (s *Source) Set(ctx context.Context, doc map[string]interface{}) error {
+	return s.apply(ctx, func(map[string]interface{}) map[string]interface{} {
+		return mergePatch(nil, doc)
+	})
+}
+
+// Patch merges patch into the overrides as a JSON merge patch (RFC 7396):
+// keys set to nil are removed, nested objects are merged, and other values
+// replace the existing ones. Otherwise, it's like Set.
+func (s *Source) Patch(ctx context.Context, patch map[string]interface{}) error {
+	return s.apply(ctx, func(cur map[string]interface{}) map[string]interface{} {
+		return mergePatch(cur, patch)
+	})
+}
+
+// Clear removes all the overrides, like Set(ctx, nil).
+func (s *Source) Clear(ctx context.Context) error {
+	return s.Set(ctx, nil)
+}
+
+// apply replaces the overrides with those returned by edit, which is passed
+// the current overrides (which it mustn't modify).
+func (s *Source) apply(ctx context.Context, edit func(cur map[string]interface{}) map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := edit(s.doc)
+	if s.t == nil {
+		// not yet passed to Config
+		s.doc = next
+		return nil
+	}
+	v, err := decode(ctx, next, s.t)
+	if err != nil {
+		return err
+	}
+	if s.args != nil {
+		if err := s.args.BlockingReportNewValue(ctx, v); err != nil {
+			return err
+		}
+	}
+	s.doc = next
+	return nil
+}
+
+// decode decodes the overrides doc into a value of the type t.
+func decode(ctx context.Context, doc map[string]interface{}, t *dials.Type) (reflect.Value, error) {
+	if doc == nil {
+		return reflect.New(t.Type()).Elem(), nil
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return reflect.Value{}, &DecodeError{Err: err}
+	}
+	v, err := dials.Decode(ctx, &jsondec.Decoder{DisallowUnknownFields: true}, bytes.NewReader(b), t)
+	if err != nil {
+		return reflect.Value{}, &DecodeError{Err: err}
+	}
+	return v, nil
+}
+
+// mergePatch returns a new object with patch merged into target as a JSON
+// merge patch. Neither argument is modified.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil && patch == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(target)+len(patch))
+	for k, v := range target {
+		out[k] = v
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(out, k)
+		case map[string]interface{}:
+			tv, _ := out[k].(map[string]interface{})
+			merged := mergePatch(tv, v)
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			out[k] = merged
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package override
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type testConfig struct {
+	Name   string `dials:"name"`
+	Limit  int    `dials:"limit"`
+	Nested struct {
+		Rate float64 `dials:"rate"`
+	} `dials:"nested"`
+}
+
+func (c *testConfig) Verify() error {
+	if c.Limit < 0 {
+		return fmt.Errorf("limit must not be negative; got %d", c.Limit)
+	}
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &Source{}
+	base := &static.StringSource{Data: `{"name": "base", "limit": 10}`, Decoder: &json.Decoder{}}
+	d, err := dials.Config(ctx, &testConfig{}, base, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, "base", d.View().Name)
+
+	h := src.Handler(func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer admin" {
+			return fmt.Errorf("bad credentials: %w", ErrUnauthenticated)
+		}
+		return nil
+	})
+	do := func(method, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/overrides", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	unauthenticated := httptest.NewRecorder()
+	h.ServeHTTP(unauthenticated, httptest.NewRequest(http.MethodPut, "/overrides", strings.NewReader(`{"limit": 1}`)))
+	assert.Equal(t, http.StatusUnauthorized, unauthenticated.Code)
+	assert.Equal(t, 10, d.View().Limit)
+
+	rec := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{}`, rec.Body.String())
+
+	rec = do(http.MethodPut, `{"limit": 20, "nested": {"rate": 1.5}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"limit": 20, "nested": {"rate": 1.5}}`, rec.Body.String())
+	cfg := d.View()
+	assert.Equal(t, "base", cfg.Name)
+	assert.Equal(t, 20, cfg.Limit)
+	assert.Equal(t, 1.5, cfg.Nested.Rate)
+
+	// overrides are validated against the configuration type
+	rec = do(http.MethodPut, `{"limt": 30}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `unknown field "limt"`)
+	rec = do(http.MethodPatch, `{"limit": "lots"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(http.MethodPatch, `[1, 2]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// ...and the resulting configuration is verified
+	rec = do(http.MethodPatch, `{"limit": -1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "limit must not be negative")
+	assert.Equal(t, 20, d.View().Limit)
+
+	rec = do(http.MethodPatch, `{"name": "patched", "nested": {"rate": null}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"limit": 20, "name": "patched", "nested": {}}`, rec.Body.String())
+	cfg = d.View()
+	assert.Equal(t, "patched", cfg.Name)
+	assert.Equal(t, 20, cfg.Limit)
+	assert.Zero(t, cfg.Nested.Rate)
+
+	rec = do(http.MethodPatch, `{"limit": null}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 10, d.View().Limit)
+
+	rec = do(http.MethodDelete, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{}`, rec.Body.String())
+	assert.Equal(t, "base", d.View().Name)
+
+	rec = do(http.MethodPost, `{}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandlerAuth(t *testing.T) {
+	src := &Source{}
+	rec := httptest.NewRecorder()
+	src.Handler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	src.Handler(func(*http.Request) error { return errors.New("read-only") }).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "read-only")
+}
+
+func TestSourceBeforeConfig(t *testing.T) {
+	ctx := context.Background()
+	src := &Source{}
+	require.NoError(t, src.Set(ctx, map[string]interface{}{"name": "early"}))
+
+	d, err := dials.Config(ctx, &testConfig{}, src)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, "early", d.View().Name)
+
+	err = src.Patch(ctx, map[string]interface{}{"name": 42})
+	var decErr *DecodeError
+	assert.ErrorAs(t, err, &decErr)
+	assert.Equal(t, map[string]interface{}{"name": "early"}, src.Overrides())
+}
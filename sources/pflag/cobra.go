@@ -0,0 +1,31 @@
+package pflag
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// Command is the subset of the methods on [github.com/spf13/cobra.Command]
+// used by NewSetWithCommand and NewPersistentSetWithCommand. (so this
+// package doesn't need to depend on cobra)
+type Command interface {
+	Flags() *pflag.FlagSet
+	PersistentFlags() *pflag.FlagSet
+}
+
+// NewSetWithCommand registers flags for the passed template value in cmd's
+// local FlagSet. Flags that cmd already defines under the names dials would
+// use are kept as-is, and their values are used for the corresponding
+// fields.
+//
+// cobra parses the FlagSet before calling cmd's Run (or RunE) function, so
+// the returned Set should be passed to dials.Config from within Run.
+func NewSetWithCommand(cfg *NameConfig, template interface{}, cmd Command) (*Set, error) {
+	return newSet(cfg, template, cmd.Flags(), nil)
+}
+
+// NewPersistentSetWithCommand is like NewSetWithCommand, but registers flags
+// in cmd's persistent FlagSet, so they're also accepted by all of cmd's
+// subcommands.
+func NewPersistentSetWithCommand(cfg *NameConfig, template interface{}, cmd Command) (*Set, error) {
+	return newSet(cfg, template, cmd.PersistentFlags(), nil)
+}
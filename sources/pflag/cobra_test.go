@@ -0,0 +1,92 @@
+package pflag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+// fakeCommand mimics the FlagSet handling of a cobra.Command
+type fakeCommand struct {
+	flags, persistentFlags *pflag.FlagSet
+}
+
+func newFakeCommand() *fakeCommand {
+	return &fakeCommand{
+		flags:           pflag.NewFlagSet("cmd", pflag.ContinueOnError),
+		persistentFlags: pflag.NewFlagSet("cmd-persistent", pflag.ContinueOnError),
+	}
+}
+
+func (f *fakeCommand) Flags() *pflag.FlagSet           { return f.flags }
+func (f *fakeCommand) PersistentFlags() *pflag.FlagSet { return f.persistentFlags }
+
+func TestNewSetWithCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		Verbose  bool
+		Replicas int
+		Region   string
+		Zones    []string
+	}
+
+	cmd := newFakeCommand()
+	// flags registered by the command itself
+	cmd.Flags().IntP("replicas", "r", 1, "number of replicas")
+	cmd.Flags().StringArray("zones", nil, "zones to deploy to")
+
+	src, err := NewSetWithCommand(DefaultFlagNameConfig(), &Config{}, cmd)
+	require.NoError(t, err)
+	require.NotNil(t, cmd.Flags().Lookup("region"))
+
+	require.NoError(t, cmd.Flags().Parse([]string{"-r", "3", "--region=us-east1", "--zones=b", "--zones=c"}))
+	d, err := dials.Config(ctx, &Config{Verbose: true}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Verbose: true, Replicas: 3, Region: "us-east1", Zones: []string{"b", "c"}}, d.View())
+}
+
+func TestNewPersistentSetWithCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		Verbose bool
+		Region  string
+	}
+
+	root := newFakeCommand()
+	src, err := NewPersistentSetWithCommand(DefaultFlagNameConfig(), &Config{}, root)
+	require.NoError(t, err)
+
+	// cobra merges the parent's persistent flags into the subcommand's
+	// FlagSet before parsing it.
+	sub := newFakeCommand()
+	sub.Flags().AddFlagSet(root.PersistentFlags())
+	require.NoError(t, sub.Flags().Parse([]string{"--region=eu-west1"}))
+
+	d, err := dials.Config(ctx, &Config{}, src)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Region: "eu-west1"}, d.View())
+}
+
+func TestNewSetWithCommandMismatchedType(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	type Config struct {
+		Region string
+	}
+
+	cmd := newFakeCommand()
+	cmd.Flags().Int("region", 0, "region number")
+	src, err := NewSetWithCommand(DefaultFlagNameConfig(), &Config{}, cmd)
+	require.NoError(t, err)
+	require.NoError(t, cmd.Flags().Parse([]string{"--region=4"}))
+
+	_, err = dials.Config(ctx, &Config{}, src)
+	assert.Error(t, err)
+}
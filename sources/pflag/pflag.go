@@ -3,13 +3,17 @@ package pflag
 import (
 	"context"
 	"encoding"
+	"flag"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
 	"time"
 
 	"github.com/vimeo/dials"
 	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
 	"github.com/vimeo/dials/ptrify"
 	"github.com/vimeo/dials/sources/flag/flaghelper"
 	"github.com/vimeo/dials/tagformat/caseconversion"
@@ -22,8 +26,11 @@ var (
 	// the following types are unsupported by the pflag package but are supported
 	// in dials pflag package. We check for these types so we can handle them appropriately
 	pflagReflectType     = reflect.TypeOf((*pflag.Value)(nil)).Elem()
+	flagReflectType      = reflect.TypeOf((*flag.Value)(nil)).Elem()
 	textMReflectType     = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 	timeDuration         = reflect.TypeOf(time.Nanosecond)
+	urlType              = reflect.TypeOf(url.URL{})
+	ipNetType            = reflect.TypeOf(net.IPNet{})
 	stringSlice          = reflect.SliceOf(reflect.TypeOf(""))
 	mapStringStringSlice = reflect.MapOf(reflect.TypeOf(""), stringSlice)
 	mapStringString      = reflect.MapOf(reflect.TypeOf(""), reflect.TypeOf(""))
@@ -95,6 +102,9 @@ func ptrified(template interface{}) (reflect.Value, reflect.Type, error) {
 		return reflect.Value{}, nil, fmt.Errorf("pointer-to-non-struct-type passed: %s", val.Type())
 	}
 	typ := val.Type()
+	if err := ptrify.CheckCycles(typ); err != nil {
+		return reflect.Value{}, nil, err
+	}
 	out := ptrify.Pointerify(typ, val)
 	return val, out, nil
 }
@@ -241,6 +251,12 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 			continue
 		}
 
+		// opaque structs and pass-through values that can't be parsed
+		// from a flag's value don't get flags
+		if unsettable(sf.Type) {
+			continue
+		}
+
 		ft := sf.Type
 
 		k := ft.Kind()
@@ -249,6 +265,7 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 			k = ft.Kind()
 		}
 		isValue := ft.Implements(pflagReflectType) || reflect.PtrTo(ft).Implements(pflagReflectType)
+		isStdValue := ft.Implements(flagReflectType) || reflect.PtrTo(ft).Implements(flagReflectType)
 		isTextM := ft.Implements(textMReflectType) || reflect.PtrTo(ft).Implements(textMReflectType)
 
 		// get the concrete value of the field from the template
@@ -257,12 +274,21 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 		var f interface{}
 
 		switch {
+		case parse.Registered(fieldVal.Type()):
+			s.Flags.VarP(flaghelper.NewRegisteredTypeFlag(fieldVal.Addr()), name, shorthand, help)
+			s.flagValues[name] = fieldVal.Addr()
+			continue
 		case isValue:
 			{
 				s.Flags.VarP(fieldVal.Addr().Interface().(pflag.Value), name, shorthand, help)
 				s.flagValues[name] = fieldVal.Addr()
 				continue
 			}
+		case isStdValue:
+			// a flag.Value lacking pflag.Value's Type method
+			s.Flags.VarP(flaghelper.NewValueFlag(fieldVal.Addr()), name, shorthand, help)
+			s.flagValues[name] = fieldVal.Addr()
+			continue
 		case isTextM:
 			{
 				// Make sure our newVal value actually points to something.
@@ -271,10 +297,22 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 				s.flagValues[name] = fieldVal.Addr()
 				continue
 			}
+		case fieldVal.Type() == urlType:
+			s.Flags.VarP(flaghelper.NewURLFlag(fieldVal.Addr().Interface().(*url.URL)), name, shorthand, help)
+			s.flagValues[name] = fieldVal.Addr()
+			continue
+		case fieldVal.Type() == ipNetType:
+			s.Flags.VarP(flaghelper.NewIPNetFlag(fieldVal.Addr().Interface().(*net.IPNet)), name, shorthand, help)
+			s.flagValues[name] = fieldVal.Addr()
+			continue
 		case fieldVal.Type() == timeDuration:
 			f = s.Flags.DurationP(name, shorthand, fieldVal.Interface().(time.Duration), help)
 			s.flagValues[name] = reflect.ValueOf(f)
 			continue
+		case isByteSize(sf, k):
+			s.Flags.VarP(flaghelper.NewByteSizeFlag(fieldVal.Addr()), name, shorthand, help)
+			s.flagValues[name] = fieldVal.Addr()
+			continue
 		default:
 		}
 
@@ -327,6 +365,12 @@ func (s *Set) registerFlags(tmpl reflect.Value, ptyp reflect.Type) error {
 				f = fieldVal.Addr().Interface()
 				s.Flags.VarP(flaghelper.NewStringSetFlag(fieldVal.Addr().Interface().(*map[string]struct{})), name, shorthand, help)
 			default:
+				if _, ok := ptrify.StructMapElem(ft); ok && ft.Elem().Kind() == reflect.Ptr {
+					m := reflect.New(ft)
+					f = m.Interface()
+					s.Flags.VarP(flaghelper.NewStructMapFlag(m), name, shorthand, help)
+					break
+				}
 				return fmt.Errorf("unhandled type %s", ft)
 			}
 
@@ -395,7 +439,13 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 	}
 	var setErr error
 	val := reflect.New(t.Type())
-	s.Flags.Visit(func(f *pflag.Flag) {
+	// Use VisitAll and check Changed rather than Visit, since cobra parses
+	// persistent flags in a subcommand's FlagSet, so they're never marked
+	// as set in the FlagSet they were registered in.
+	s.Flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Changed || setErr != nil {
+			return
+		}
 		fieldName, ok := s.flagFieldName[f.Name]
 		if !ok {
 			return
@@ -413,7 +463,14 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 		ptrVal := reflect.New(stripTypePtr(ffield.Type()))
 		fval, ok := s.flagValues[f.Name]
 		if !ok {
-			return
+			// The flag was registered before dials got to it (e.g.
+			// by a cobra command), so pull its value out of the
+			// FlagSet.
+			var getErr error
+			if fval, getErr = s.preregisteredFlagValue(f); getErr != nil {
+				setErr = getErr
+				return
+			}
 		}
 
 		switch fval.Type() {
@@ -429,7 +486,16 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 			return
 		}
 
-		cfval := fval.Convert(stripTypePtr(ffield.Type()))
+		fieldType := stripTypePtr(ffield.Type())
+		// integers are convertible to strings, but produce a rune rather
+		// than a decimal representation.
+		if !fval.Type().ConvertibleTo(fieldType) ||
+			(fieldType.Kind() == reflect.String && fval.Kind() != reflect.String) {
+			setErr = fmt.Errorf("value for flag %q has type %s, which is incompatible with field type %s",
+				f.Name, fval.Type(), fieldType)
+			return
+		}
+		cfval := fval.Convert(fieldType)
 		switch ffield.Kind() {
 		case reflect.Ptr:
 			// common case
@@ -446,6 +512,57 @@ func (s *Set) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
 	return s.tfmr.ReverseTranslate(s.trnslVal)
 }
 
+// preregisteredFlagValue extracts the value of a flag that was registered
+// outside of dials, using the typed getters on pflag.FlagSet.
+func (s *Set) preregisteredFlagValue(f *pflag.Flag) (reflect.Value, error) {
+	var v interface{}
+	var err error
+	switch typ := f.Value.Type(); typ {
+	case "string":
+		v, err = s.Flags.GetString(f.Name)
+	case "bool":
+		v, err = s.Flags.GetBool(f.Name)
+	case "duration":
+		v, err = s.Flags.GetDuration(f.Name)
+	case "float32":
+		v, err = s.Flags.GetFloat32(f.Name)
+	case "float64":
+		v, err = s.Flags.GetFloat64(f.Name)
+	case "int":
+		v, err = s.Flags.GetInt(f.Name)
+	case "int8":
+		v, err = s.Flags.GetInt8(f.Name)
+	case "int16":
+		v, err = s.Flags.GetInt16(f.Name)
+	case "int32":
+		v, err = s.Flags.GetInt32(f.Name)
+	case "int64":
+		v, err = s.Flags.GetInt64(f.Name)
+	case "uint":
+		v, err = s.Flags.GetUint(f.Name)
+	case "uint8":
+		v, err = s.Flags.GetUint8(f.Name)
+	case "uint16":
+		v, err = s.Flags.GetUint16(f.Name)
+	case "uint32":
+		v, err = s.Flags.GetUint32(f.Name)
+	case "uint64":
+		v, err = s.Flags.GetUint64(f.Name)
+	case "stringSlice":
+		v, err = s.Flags.GetStringSlice(f.Name)
+	case "stringArray":
+		v, err = s.Flags.GetStringArray(f.Name)
+	case "stringToString":
+		v, err = s.Flags.GetStringToString(f.Name)
+	default:
+		return reflect.Value{}, fmt.Errorf("flag %q has unsupported type %q", f.Name, typ)
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to get value of flag %q: %w", f.Name, err)
+	}
+	return reflect.ValueOf(v), nil
+}
+
 func stripTypePtr(t reflect.Type) reflect.Type {
 	switch t.Kind() {
 	case reflect.Ptr:
@@ -473,3 +590,37 @@ func (s *Set) mkname(sf reflect.StructField) string {
 	panic(fmt.Errorf("expected dials tag name for struct field %q", sf.Name))
 
 }
+
+// unsettable indicates whether t is an opaque struct (see
+// ptrify.IsOpaqueStruct), a struct only decoders can set (see
+// ptrify.IsDecodeHookStruct) or a pass-through type (see
+// ptrify.IsPassThrough), or a pointer to one, that implements none of
+// pflag.Value, flag.Value and encoding.TextUnmarshaler, and isn't registered
+// with parse.RegisterType.
+func unsettable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !ptrify.IsOpaqueStruct(t) && !ptrify.IsDecodeHookStruct(t) && !ptrify.IsPassThrough(t) {
+		return false
+	}
+	return !parse.Registered(t) && !t.Implements(textMReflectType) && !reflect.PtrTo(t).Implements(textMReflectType) &&
+		!t.Implements(pflagReflectType) && !reflect.PtrTo(t).Implements(pflagReflectType) &&
+		!t.Implements(flagReflectType) && !reflect.PtrTo(t).Implements(flagReflectType)
+}
+
+// isByteSize indicates whether sf, of kind k (after dereferencing pointers),
+// is an integer field with a `dialsunit:"bytes"` tag (see
+// common.UnitTagName).
+func isByteSize(sf reflect.StructField, k reflect.Kind) bool {
+	if sf.Tag.Get(common.UnitTagName) != "bytes" {
+		return false
+	}
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
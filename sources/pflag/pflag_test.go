@@ -622,3 +622,23 @@ func TestMust(t *testing.T) {
 		t.Errorf("expected World to be true, got %t", got.World)
 	}
 }
+
+func TestStructMapPFlags(t *testing.T) {
+	type Backend struct {
+		Host string
+		Port int
+	}
+	type Config struct {
+		Backends map[string]Backend `dials:"backends"`
+	}
+
+	fs := Must(NewSetWithArgs(DefaultFlagNameConfig(), &Config{}, []string{
+		"--backends=primary.host:db1,primary.port:5432", "--backends=replica.host:db2",
+	}))
+	d, err := dials.Config(context.Background(), &Config{}, fs)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Backend{
+		"primary": {Host: "db1", Port: 5432},
+		"replica": {Host: "db2"},
+	}, d.View().Backends)
+}
@@ -17,7 +17,7 @@ type StringSource struct {
 var _ dials.Source = (*StringSource)(nil)
 
 // Value ...
-func (s *StringSource) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+func (s *StringSource) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
 	reader := strings.NewReader(s.Data)
-	return s.Decoder.Decode(reader, t)
+	return dials.Decode(ctx, s.Decoder, reader, t)
 }
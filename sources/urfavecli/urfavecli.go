@@ -0,0 +1,165 @@
+// Package urfavecli provides a dials Source that reads flag values from a
+// [github.com/urfave/cli] context, so applications built on that framework
+// can layer other dials sources (environment variables, files, etc.) under
+// their command-line flags.
+//
+// This package doesn't depend on urfave/cli; *cli.Context satisfies the
+// Context interface.
+package urfavecli
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/tagformat/caseconversion"
+	"github.com/vimeo/dials/transform"
+)
+
+const dialsCLITag = "dialscli"
+
+// Context is the subset of the methods on *cli.Context used by Source.
+type Context interface {
+	// IsSet reports whether the flag was set on the command-line (or by
+	// the flag's environment variables).
+	IsSet(name string) bool
+	// Value returns the value of the flag.
+	Value(name string) interface{}
+}
+
+// NameConfig defines the parameters for separating components of a flag-name
+type NameConfig struct {
+	// FieldNameEncodeCasing is for the field names used by the flatten mangler
+	FieldNameEncodeCasing caseconversion.EncodeCasingFunc
+	// TagEncodeCasing is for the tag names used by the flatten mangler
+	TagEncodeCasing caseconversion.EncodeCasingFunc
+}
+
+// DefaultFlagNameConfig defines a reasonably-defaulted NameConfig for field
+// names and tags, matching the flag and pflag sources.
+func DefaultFlagNameConfig() *NameConfig {
+	return &NameConfig{
+		FieldNameEncodeCasing: caseconversion.EncodeUpperCamelCase,
+		TagEncodeCasing:       caseconversion.EncodeKebabCase,
+	}
+}
+
+// Source implements the dials.Source interface, populating fields from the
+// flags that are set in Context. Only flags that were explicitly set are
+// used, so flag defaults don't clobber values from lower-precedence sources.
+//
+// Flags are not registered by Source; the application's cli.App or
+// cli.Command should define a flag for each field it wants to expose.
+type Source struct {
+	Context Context
+
+	// NameCfg defines tunables for constructing flag-names. If nil,
+	// DefaultFlagNameConfig is used.
+	NameCfg *NameConfig
+}
+
+var _ dials.Source = (*Source)(nil)
+
+// Value fills in the user-provided config struct using flags. It looks up the
+// flag to read into a given struct field by using that field's `dialscli`
+// struct tag if present, then its `dials` tag if present, and finally its
+// name (converted to kebab-case by default). Nested fields are flattened, so
+// a field Bar within a struct field Foo is read from the flag "foo-bar".
+func (s *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+	nameCfg := s.NameCfg
+	if nameCfg == nil {
+		nameCfg = DefaultFlagNameConfig()
+	}
+	fm := transform.NewFlattenMangler(common.DialsTagName, nameCfg.FieldNameEncodeCasing, nameCfg.TagEncodeCasing)
+	// UnitMangler parses durations and sizes given as strings
+	tfmr := transform.NewTransformer(t.Type(), fm, &transform.UnitMangler{})
+	val, err := tfmr.Translate()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	valType := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		sf := valType.Field(i)
+		name := mkname(sf)
+		if name == "-" || !s.Context.IsSet(name) {
+			continue
+		}
+		if setErr := setField(val.Field(i), s.Context.Value(name)); setErr != nil {
+			return reflect.Value{}, fmt.Errorf("failed to set field %q from flag %q: %w",
+				sf.Name, name, setErr)
+		}
+	}
+
+	return tfmr.ReverseTranslate(val)
+}
+
+func mkname(sf reflect.StructField) string {
+	// use the name from the dialscli tag for the flag name
+	if name, ok := sf.Tag.Lookup(dialsCLITag); ok {
+		return name
+	}
+	// check if the dials tag is populated (it should be once it goes through
+	// the flatten mangler).
+	if name, ok := sf.Tag.Lookup(common.DialsTagName); ok {
+		return name
+	}
+
+	// panic because flatten mangler should set the dials tag so panic if that
+	// wasn't set
+	panic(fmt.Errorf("expected dials tag name for struct field %q", sf.Name))
+}
+
+// unwrapValue returns the result of calling a Value() method with a single
+// return value, if present. urfave/cli's slice and timestamp flag types
+// expose their contents this way.
+func unwrapValue(v reflect.Value) reflect.Value {
+	m := v.MethodByName("Value")
+	if !m.IsValid() && v.Kind() != reflect.Ptr {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		m = ptr.MethodByName("Value")
+	}
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return v
+	}
+	return m.Call(nil)[0]
+}
+
+func setField(field reflect.Value, raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	target := field.Type()
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+
+	v := reflect.ValueOf(raw)
+	// interface fields (from UnitMangler) take the flag's value as-is
+	if str, ok := raw.(string); ok && target.Kind() != reflect.Interface {
+		parsed, err := parse.StringValue(str, target)
+		if err != nil {
+			return err
+		}
+		v = parsed
+	}
+	if !v.Type().ConvertibleTo(target) {
+		v = unwrapValue(v)
+	}
+	if v.Kind() == reflect.Ptr && !v.Type().ConvertibleTo(target) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	cv, err := parse.ConvertValue(v, target)
+	if err != nil {
+		return fmt.Errorf("flag %w", err)
+	}
+	parse.SetField(field, cv)
+	return nil
+}
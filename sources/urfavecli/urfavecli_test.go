@@ -0,0 +1,81 @@
+package urfavecli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/static"
+)
+
+// stringSlice mimics cli.StringSlice, which exposes its contents through a
+// pointer-receiver Value method.
+type stringSlice struct {
+	slice []string
+}
+
+func (s *stringSlice) Value() []string {
+	return s.slice
+}
+
+type fakeContext map[string]interface{}
+
+func (f fakeContext) IsSet(name string) bool {
+	_, ok := f[name]
+	return ok
+}
+
+func (f fakeContext) Value(name string) interface{} {
+	return f[name]
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+	type Database struct {
+		Host string
+		Port uint16
+	}
+	type Config struct {
+		Verbose  bool
+		Timeout  time.Duration
+		Zones    []string
+		Database Database
+		Name     string `dialscli:"app-name"`
+		Ignored  string `dialscli:"-"`
+	}
+
+	cliCtx := fakeContext{
+		"timeout":       5 * time.Second,
+		"zones":         stringSlice{slice: []string{"a", "b"}},
+		"database-port": 5432,
+		"app-name":      "widget",
+		"-":             "nope",
+	}
+	base := static.StringSource{
+		Data:    `{"Verbose": true, "Database": {"Host": "db.local", "Port": 1}, "Name": "default"}`,
+		Decoder: &json.Decoder{},
+	}
+
+	d, err := dials.Config(context.Background(), &Config{}, &base, &Source{Context: cliCtx})
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Verbose:  true,
+		Timeout:  5 * time.Second,
+		Zones:    []string{"a", "b"},
+		Database: Database{Host: "db.local", Port: 5432},
+		Name:     "widget",
+	}, d.View())
+}
+
+func TestSourceMismatchedType(t *testing.T) {
+	t.Parallel()
+	type Config struct {
+		Name string
+	}
+	_, err := dials.Config(context.Background(), &Config{}, &Source{Context: fakeContext{"name": 3}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,304 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Getter provides the most commonly used getter methods of *viper.Viper over
+// the current configuration of a Dials instance, so code written against
+// viper can be handed a Getter (e.g. through an interface with the methods it
+// calls) and migrated to the config struct later.
+//
+// Keys name fields as they do for Source (e.g. "database.port"), and are
+// case-insensitive. Like viper's, the typed getters return the zero value if
+// the key doesn't name a field, or if its value can't be converted.
+type Getter[T any] struct {
+	d *dials.Dials[T]
+}
+
+// NewGetter returns a Getter reading the configuration of d.
+func NewGetter[T any](d *dials.Dials[T]) *Getter[T] {
+	return &Getter[T]{d: d}
+}
+
+// lookup returns the value of the field named by key in the current
+// configuration.
+func (g *Getter[T]) lookup(key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(g.d.View()).Elem()
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		field, ok := structField(v, part)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v = field
+	}
+	return v, true
+}
+
+// structField returns the field of the struct v with the key name, searching
+// the fields of inlined embedded structs too.
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	vt := v.Type()
+	for i := 0; i < vt.NumField(); i++ {
+		sf := vt.Field(i)
+		if !sf.IsExported() || fieldKey(sf) == "-" {
+			continue
+		}
+		if inlined(sf) {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if f, ok := structField(fv, name); ok {
+				return f, true
+			}
+			continue
+		}
+		if fieldKey(sf) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// IsSet reports whether key names a field of the configuration (nil pointers
+// aren't considered set).
+func (g *Getter[T]) IsSet(key string) bool {
+	v, ok := g.lookup(key)
+	return ok && !(v.Kind() == reflect.Ptr && v.IsNil())
+}
+
+// Get returns the value of the field named by key, or nil if there isn't
+// one. Nested structs are returned as they are in the configuration, rather
+// than as maps.
+func (g *Getter[T]) Get(key string) interface{} {
+	v, ok := g.lookup(key)
+	if !ok {
+		return nil
+	}
+	return v.Interface()
+}
+
+// GetString returns the value of the field named by key as a string.
+func (g *Getter[T]) GetString(key string) string {
+	v, ok := g.lookup(key)
+	if !ok {
+		return ""
+	}
+	return toString(v)
+}
+
+// GetBool returns the value of the field named by key as a bool.
+func (g *Getter[T]) GetBool(key string) bool {
+	v, ok := g.lookup(key)
+	if !ok {
+		return false
+	}
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		b, _ := strconv.ParseBool(v.String())
+		return b
+	default:
+		return false
+	}
+}
+
+// GetInt returns the value of the field named by key as an int.
+func (g *Getter[T]) GetInt(key string) int {
+	return int(g.GetInt64(key))
+}
+
+// GetInt64 returns the value of the field named by key as an int64.
+func (g *Getter[T]) GetInt64(key string) int64 {
+	v, ok := g.lookup(key)
+	if !ok {
+		return 0
+	}
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float())
+	case reflect.String:
+		i, _ := strconv.ParseInt(v.String(), 0, 64)
+		return i
+	default:
+		return 0
+	}
+}
+
+// GetFloat64 returns the value of the field named by key as a float64.
+func (g *Getter[T]) GetFloat64(key string) float64 {
+	v, ok := g.lookup(key)
+	if !ok {
+		return 0
+	}
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.String:
+		f, _ := strconv.ParseFloat(v.String(), 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+// GetDuration returns the value of the field named by key as a
+// time.Duration. Strings are parsed with time.ParseDuration, and integers
+// are taken as nanoseconds.
+func (g *Getter[T]) GetDuration(key string) time.Duration {
+	v, ok := g.lookup(key)
+	if !ok {
+		return 0
+	}
+	v = deref(v)
+	if v.Kind() == reflect.String {
+		d, _ := time.ParseDuration(v.String())
+		return d
+	}
+	return time.Duration(g.GetInt64(key))
+}
+
+// GetStringSlice returns the value of the field named by key as a slice of
+// strings. A string value is split on whitespace, as viper does.
+func (g *Getter[T]) GetStringSlice(key string) []string {
+	v, ok := g.lookup(key)
+	if !ok {
+		return nil
+	}
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		out := make([]string, v.Len())
+		for i := range out {
+			out[i] = toString(v.Index(i))
+		}
+		return out
+	case reflect.String:
+		return strings.Fields(v.String())
+	default:
+		return nil
+	}
+}
+
+// AllKeys returns the keys of all the leaf fields of the configuration, in
+// sorted order.
+func (g *Getter[T]) AllKeys() []string {
+	keys := []string{}
+	collectKeys(reflect.TypeOf((*T)(nil)).Elem(), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := fieldKey(sf)
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		key := prefix + name
+		if inlined(sf) {
+			key = strings.TrimSuffix(prefix, ".")
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !ptrify.IsScalarStruct(ft) {
+			nestedPrefix := key + "."
+			if key == "" {
+				nestedPrefix = ""
+			}
+			collectKeys(ft, nestedPrefix, keys)
+			continue
+		}
+		*keys = append(*keys, key)
+	}
+}
+
+// AllSettings returns the configuration as nested maps keyed like a viper
+// instance's settings.
+func (g *Getter[T]) AllSettings() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, key := range g.AllKeys() {
+		v, ok := g.lookup(key)
+		if !ok || (v.Kind() == reflect.Ptr && v.IsNil()) {
+			continue
+		}
+		m := out
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := m[part].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				m[part] = sub
+			}
+			m = sub
+		}
+		m[parts[len(parts)-1]] = v.Interface()
+	}
+	return out
+}
+
+func deref(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// toString formats v like viper's GetString (via spf13/cast).
+func toString(v reflect.Value) string {
+	v = deref(v)
+	if v.Kind() == reflect.Ptr {
+		return ""
+	}
+	switch i := v.Interface().(type) {
+	case string:
+		return i
+	case fmt.Stringer:
+		return i.String()
+	case []byte:
+		return string(i)
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
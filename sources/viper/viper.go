@@ -0,0 +1,244 @@
+// Package viper eases migrating from [github.com/spf13/viper] to dials: Source
+// reads the settings of a viper instance into a dials configuration, so viper
+// can remain one of the sources while its users are converted, and Getter
+// exposes a dials configuration through viper's getter methods, so code that
+// still expects a viper instance can read it.
+//
+// This package doesn't depend on viper; *viper.Viper satisfies the Viper
+// interface.
+package viper
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// Viper is the subset of the methods on *viper.Viper used by Source.
+type Viper interface {
+	// IsSet reports whether the key has a value (from any of viper's
+	// sources, including its defaults).
+	IsSet(key string) bool
+	// Get returns the value of the key.
+	Get(key string) interface{}
+}
+
+// Source implements the dials.Source interface, populating fields from the
+// keys that are set in Viper.
+//
+// Keys are looked up as viper's Unmarshal method would: a field is read from
+// the key in its `dials` tag, or its name (viper's keys are
+// case-insensitive), and the fields of a nested struct are read from keys
+// prefixed with the struct's key and a dot (e.g. "database.port"). Embedded
+// structs are nested under their type's name, unless they're tagged with
+// `dialsembed:"inline"`. To honor the `mapstructure` tags of structs shared
+// with code still using viper, wrap the Source with
+// tagformat.MapstructureSource.
+//
+// Source doesn't watch for changes; to pick them up, call [dials.Dials.Refresh]
+// from the function passed to viper's OnConfigChange method.
+type Source struct {
+	Viper Viper
+}
+
+var _ dials.Source = (*Source)(nil)
+
+// Value fills in the user-provided config struct from the keys set in
+// s.Viper. String values (e.g. from environment variables) are parsed as they
+// would be by the env source.
+func (s *Source) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+	val := reflect.New(t.Type()).Elem()
+	if _, err := s.fill(val, ""); err != nil {
+		return reflect.Value{}, err
+	}
+	return val, nil
+}
+
+// fill sets the fields of the pointerified struct val from the keys
+// beginning with prefix, returning whether any were set.
+func (s *Source) fill(val reflect.Value, prefix string) (bool, error) {
+	set := false
+	vt := val.Type()
+	for i := 0; i < vt.NumField(); i++ {
+		sf := vt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldKey(sf)
+		if name == "-" {
+			continue
+		}
+		key := prefix + name
+		if inlined(sf) {
+			key = strings.TrimSuffix(prefix, ".")
+		}
+
+		field := val.Field(i)
+		if st, ok := nestedStruct(sf.Type); ok {
+			nested := reflect.New(st)
+			nestedPrefix := key + "."
+			if key == "" {
+				nestedPrefix = ""
+			}
+			nestedSet, err := s.fill(nested.Elem(), nestedPrefix)
+			if err != nil {
+				return false, err
+			}
+			if nestedSet {
+				field.Set(nested)
+				set = true
+			}
+			continue
+		}
+
+		if !s.Viper.IsSet(key) {
+			continue
+		}
+		if err := setField(field, s.Viper.Get(key)); err != nil {
+			return false, fmt.Errorf("failed to set field %q from viper key %q: %w", sf.Name, key, err)
+		}
+		set = true
+	}
+	return set, nil
+}
+
+// fieldKey returns the key of the field sf within its struct.
+func fieldKey(sf reflect.StructField) string {
+	if name, ok := sf.Tag.Lookup(common.DialsTagName); ok && name != "" {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(sf.Name)
+}
+
+// inlined reports whether sf is an embedded struct whose fields are promoted.
+func inlined(sf reflect.StructField) bool {
+	return sf.Anonymous && sf.Tag.Get(common.EmbedTagName) == "inline"
+}
+
+// nestedStruct returns the struct type pointed to by the pointerified field
+// type ft, if it's a struct whose fields are read individually.
+func nestedStruct(ft reflect.Type) (reflect.Type, bool) {
+	if ft.Kind() != reflect.Ptr || ft.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	st := ft.Elem()
+	if ptrify.IsScalarStruct(st) {
+		return nil, false
+	}
+	return st, true
+}
+
+// setField sets the pointerified field to raw, a value returned by viper.
+func setField(field reflect.Value, raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	target := field.Type()
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	v, err := convert(raw, target)
+	if err != nil {
+		return err
+	}
+	parse.SetField(field, v)
+	return nil
+}
+
+// convert converts raw, a value returned by viper (possibly an element of a
+// slice or map), to the type target.
+func convert(raw interface{}, target reflect.Type) (reflect.Value, error) {
+	if target.Kind() == reflect.Interface {
+		return reflect.ValueOf(&raw).Elem(), nil
+	}
+	if str, ok := raw.(string); ok {
+		return parse.StringValue(str, target)
+	}
+
+	v := reflect.ValueOf(raw)
+	switch {
+	case v.Kind() == reflect.Slice && target.Kind() == reflect.Slice && !v.Type().ConvertibleTo(target):
+		out := reflect.MakeSlice(target, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := convert(v.Index(i).Interface(), target.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case v.Kind() == reflect.Map && target.Kind() == reflect.Map && !v.Type().ConvertibleTo(target):
+		out := reflect.MakeMapWithSize(target, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := convert(iter.Key().Interface(), target.Key())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			elem, err := convert(iter.Value().Interface(), target.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			out.SetMapIndex(k, elem)
+		}
+		return out, nil
+	case v.Kind() == reflect.Map && target.Kind() == reflect.Struct && !ptrify.IsScalarStruct(target):
+		// e.g. an element of a slice of structs
+		out := reflect.New(target).Elem()
+		if _, err := (&Source{Viper: mapViper{m: v}}).fill(out, ""); err != nil {
+			return reflect.Value{}, err
+		}
+		return out, nil
+	}
+
+	return parse.ConvertValue(v, target)
+}
+
+// mapViper implements Viper over a (nested) map, such as an element of a
+// slice returned by viper.
+type mapViper struct {
+	m reflect.Value
+}
+
+func (m mapViper) lookup(key string) (reflect.Value, bool) {
+	v := m.m
+	for _, part := range strings.Split(key, ".") {
+		for v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Map {
+			return reflect.Value{}, false
+		}
+		found := false
+		iter := v.MapRange()
+		for iter.Next() {
+			if k, ok := iter.Key().Interface().(string); ok && strings.EqualFold(k, part) {
+				v, found = iter.Value(), true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+func (m mapViper) IsSet(key string) bool {
+	_, ok := m.lookup(key)
+	return ok
+}
+
+func (m mapViper) Get(key string) interface{} {
+	v, _ := m.lookup(key)
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
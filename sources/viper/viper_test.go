@@ -0,0 +1,130 @@
+package viper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/sources/static"
+)
+
+// fakeViper mimics a viper instance with settings from a config file (nested
+// maps with lowercased keys) and from environment variables (strings).
+type fakeViper map[string]interface{}
+
+func (f fakeViper) IsSet(key string) bool {
+	return mapViper{m: reflect.ValueOf(map[string]interface{}(f))}.IsSet(key)
+}
+
+func (f fakeViper) Get(key string) interface{} {
+	return mapViper{m: reflect.ValueOf(map[string]interface{}(f))}.Get(key)
+}
+
+type backend struct {
+	Host   string
+	Weight float64
+}
+
+type Common struct {
+	Region string
+}
+
+type testConfig struct {
+	Name     string
+	Port     int `dials:"listen_port"`
+	Timeout  time.Duration
+	Tags     []string
+	Limits   map[string]int
+	Backends []backend
+	Database struct {
+		User    string
+		MaxConn int
+	}
+	Common  `dialsembed:"inline"`
+	Ignored string `dials:"-"`
+}
+
+func TestSource(t *testing.T) {
+	v := fakeViper{
+		"name":        "svc",
+		"listen_port": "8080",
+		"timeout":     "5s",
+		"tags":        []interface{}{"a", "b"},
+		"limits":      map[string]interface{}{"cpu": 2},
+		"backends": []interface{}{
+			map[string]interface{}{"host": "a.example", "weight": 1.5},
+			map[string]interface{}{"host": "b.example"},
+		},
+		"database": map[string]interface{}{"user": "app", "maxconn": 10},
+		"region":   "us-east",
+		"ignored":  "set",
+	}
+	base := &static.StringSource{Data: `{"Database": {"User": "base", "MaxConn": 1}}`, Decoder: &json.Decoder{}}
+	d, err := dials.Config(context.Background(), &testConfig{Name: "default", Ignored: "default"}, base, &Source{Viper: v})
+	require.NoError(t, err)
+
+	cfg := d.View()
+	assert.Equal(t, "svc", cfg.Name)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, map[string]int{"cpu": 2}, cfg.Limits)
+	assert.Equal(t, []backend{{Host: "a.example", Weight: 1.5}, {Host: "b.example"}}, cfg.Backends)
+	assert.Equal(t, "app", cfg.Database.User)
+	assert.Equal(t, 10, cfg.Database.MaxConn)
+	assert.Equal(t, "us-east", cfg.Region)
+	assert.Equal(t, "default", cfg.Ignored)
+
+	// unset keys leave lower-precedence values alone
+	delete(v, "database")
+	cfg, _, err = d.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "base", cfg.Database.User)
+	assert.Equal(t, 1, cfg.Database.MaxConn)
+}
+
+func TestSourceIncompatibleValue(t *testing.T) {
+	_, err := dials.Config(context.Background(), &testConfig{}, &Source{Viper: fakeViper{"listen_port": true}})
+	assert.ErrorContains(t, err, `failed to set field "Port" from viper key "listen_port"`)
+	_, err = dials.Config(context.Background(), &testConfig{}, &Source{Viper: fakeViper{"timeout": "soon"}})
+	assert.Error(t, err)
+}
+
+func TestGetter(t *testing.T) {
+	cfg := &testConfig{Name: "svc", Port: 8080, Timeout: time.Minute, Tags: []string{"a", "b"}}
+	cfg.Database.User = "app"
+	cfg.Region = "us-east"
+	d, err := dials.Config(context.Background(), cfg)
+	require.NoError(t, err)
+	g := NewGetter(d)
+
+	assert.Equal(t, "svc", g.GetString("name"))
+	assert.Equal(t, "8080", g.GetString("LISTEN_PORT"))
+	assert.Equal(t, 8080, g.GetInt("listen_port"))
+	assert.Equal(t, int64(8080), g.GetInt64("listen_port"))
+	assert.Equal(t, 8080.0, g.GetFloat64("listen_port"))
+	assert.Equal(t, time.Minute, g.GetDuration("timeout"))
+	assert.Equal(t, "1m0s", g.GetString("timeout"))
+	assert.Equal(t, []string{"a", "b"}, g.GetStringSlice("tags"))
+	assert.Equal(t, "app", g.GetString("database.user"))
+	assert.Equal(t, "us-east", g.GetString("region"))
+	assert.False(t, g.GetBool("name"))
+	assert.True(t, g.IsSet("database.maxconn"))
+	assert.False(t, g.IsSet("database.missing"))
+	assert.False(t, g.IsSet("ignored"))
+	assert.Nil(t, g.Get("missing"))
+	assert.Zero(t, g.GetInt("missing"))
+
+	assert.Equal(t, []string{
+		"backends", "database.maxconn", "database.user", "limits", "listen_port",
+		"name", "region", "tags", "timeout",
+	}, g.AllKeys())
+	settings := g.AllSettings()
+	assert.Equal(t, "svc", settings["name"])
+	assert.Equal(t, map[string]interface{}{"user": "app", "maxconn": 0}, settings["database"])
+}
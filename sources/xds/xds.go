@@ -0,0 +1,350 @@
+// Package xds implements a source that subscribes to configuration from a
+// central control plane, with a protocol modeled on the incremental ("delta")
+// variant of Envoy's xDS Aggregated Discovery Service: the control plane
+// pushes versioned resources as they change, and the client acknowledges
+// (ACKs) each response once its configuration has been installed, or rejects
+// it (NACKs) with the reason if it couldn't be decoded or the resulting
+// configuration failed verification (see dials.VerifiedConfig), in which case
+// the previous resources remain in effect.
+//
+// The transport is left to the application: Source.Dial opens a Stream (e.g.
+// wrapping a gRPC bidirectional stream), and the messages map onto the fields
+// of envoy.service.discovery.v3's DeltaDiscoveryRequest and
+// DeltaDiscoveryResponse.
+package xds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vimeo/dials"
+)
+
+// DeltaDiscoveryRequest is sent by the client to subscribe to resources and to
+// ACK or NACK responses.
+type DeltaDiscoveryRequest struct {
+	// Node identifies the client to the control plane.
+	Node string
+	// TypeURL is the type of the resources requested.
+	TypeURL string
+	// ResourceNamesSubscribe lists the resources to subscribe to (in the
+	// first request on a stream). If it's empty, the client subscribes to
+	// every resource of the type.
+	ResourceNamesSubscribe []string
+	// InitialResourceVersions holds the versions of the resources the
+	// client already has (in the first request on a stream), so the
+	// control plane needn't resend them.
+	InitialResourceVersions map[string]string
+	// ResponseNonce is the nonce of the response being ACKed or NACKed.
+	ResponseNonce string
+	// ErrorDetail is set when NACKing a response, describing why it was
+	// rejected.
+	ErrorDetail string
+}
+
+// Resource is a named, versioned configuration resource, whose Body is
+// decoded with the Source's Decoder.
+type Resource struct {
+	Name    string
+	Version string
+	Body    []byte
+}
+
+// DeltaDiscoveryResponse is sent by the control plane with the resources that
+// were added or changed, and the names of those that were removed, since its
+// last response.
+type DeltaDiscoveryResponse struct {
+	SystemVersionInfo string
+	TypeURL           string
+	Resources         []Resource
+	RemovedResources  []string
+	// Nonce identifies the response in the request ACKing or NACKing it.
+	Nonce string
+}
+
+// Stream is a bidirectional stream to the control plane.
+type Stream interface {
+	Send(*DeltaDiscoveryRequest) error
+	Recv() (*DeltaDiscoveryResponse, error)
+	// CloseSend closes the client's side of the stream.
+	CloseSend() error
+}
+
+const defaultReconnectInterval = 5 * time.Second
+
+// Source implements the dials.Source and dials.Watcher interfaces, populating
+// the config struct from the resources the control plane provides. Each
+// resource is decoded with Decoder, and the resulting values are composed,
+// with later resources taking precedence: in the order of ResourceNames, or
+// sorted by name when subscribed to every resource.
+type Source struct {
+	// Dial opens a stream to the control plane. The stream should be
+	// closed when ctx is canceled.
+	Dial func(ctx context.Context) (Stream, error)
+	// Node identifies this client to the control plane.
+	Node string
+	// TypeURL is the type of the resources to subscribe to.
+	TypeURL string
+	// ResourceNames lists the resources to subscribe to. If it's empty,
+	// every resource of TypeURL is used.
+	ResourceNames []string
+	// Decoder decodes the bodies of the resources.
+	Decoder dials.Decoder
+	// ReconnectInterval is how long to wait before reopening a stream that
+	// failed while watching (5 seconds if not positive).
+	ReconnectInterval time.Duration
+
+	mu sync.Mutex
+	// accepted holds the resources of the most recently installed
+	// configuration, keyed by name
+	accepted map[string]Resource
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ dials.Source = (*Source)(nil)
+var _ dials.Watcher = (*Source)(nil)
+var _ dials.StoppableWatcher = (*Source)(nil)
+
+// Value opens a stream to the control plane, and returns the configuration
+// from its first response, which is ACKed if it can be decoded, and NACKed
+// otherwise. Failures to reach the control plane are reported with a
+// dials.UnavailableError.
+func (s *Source) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := s.subscribe(ctx)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	defer stream.CloseSend()
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return reflect.Value{}, &dials.UnavailableError{Err: fmt.Errorf("failed to receive from the control plane: %w", err)}
+	}
+	resources := s.apply(resp)
+	v, err := s.compose(ctx, t, resources)
+	if err != nil {
+		if nackErr := s.nack(stream, resp, err); nackErr != nil {
+			return reflect.Value{}, fmt.Errorf("%w (and failed to NACK it: %s)", err, nackErr)
+		}
+		return reflect.Value{}, err
+	}
+	if err := s.ack(stream, resp); err != nil {
+		return reflect.Value{}, err
+	}
+	s.setAccepted(resources)
+	return v, nil
+}
+
+// Watch starts a goroutine receiving updates from the control plane, which
+// reports the configuration from each response, and ACKs it once it's been
+// installed, or NACKs it if it can't be decoded or is rejected (e.g. by the
+// config type's Verify method). If the stream fails, it's reopened after
+// ReconnectInterval, resubscribing with the versions of the accepted
+// resources.
+func (s *Source) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	dials.WatchGo(ctx, args, func(context.Context) { s.watchLoop(ctx, t, args) })
+	return nil
+}
+
+// StopWatch stops the goroutine started by Watch, waiting until it's exited or
+// ctx expires.
+func (s *Source) StopWatch(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.wg.Wait()
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("context expired while awaiting watch goroutine exit: %w", ctx.Err())
+	}
+}
+
+// Versions returns the versions of the resources in the most recently
+// installed configuration, keyed by their names.
+func (s *Source) Versions() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return resourceVersions(s.accepted)
+}
+
+func (s *Source) watchLoop(ctx context.Context, t *dials.Type, args dials.WatchArgs) {
+	defer s.wg.Done()
+	interval := s.ReconnectInterval
+	if interval <= 0 {
+		interval = defaultReconnectInterval
+	}
+	for {
+		err := s.watchStream(ctx, t, args)
+		if ctx.Err() != nil {
+			return
+		}
+		args.ReportError(ctx, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchStream opens a stream, and handles its responses until it fails.
+func (s *Source) watchStream(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := s.subscribe(streamCtx)
+	if err != nil {
+		return err
+	}
+	defer stream.CloseSend()
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return &dials.UnavailableError{Err: fmt.Errorf("failed to receive from the control plane: %w", err)}
+		}
+		resources := s.apply(resp)
+		v, err := s.compose(ctx, t, resources)
+		if err != nil {
+			args.ReportError(ctx, err)
+		} else {
+			// failed verification is reported by dials itself
+			err = args.BlockingReportNewValue(ctx, v)
+			if errors.Is(err, dials.ErrConfigSuperseded) {
+				// a newer configuration (which includes this
+				// value) has been proposed in the meantime
+				err = nil
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if nackErr := s.nack(stream, resp, err); nackErr != nil {
+				return nackErr
+			}
+			continue
+		}
+		s.setAccepted(resources)
+		if ackErr := s.ack(stream, resp); ackErr != nil {
+			return ackErr
+		}
+	}
+}
+
+// subscribe opens a stream and sends the subscription request.
+func (s *Source) subscribe(ctx context.Context) (Stream, error) {
+	stream, err := s.Dial(ctx)
+	if err != nil {
+		return nil, &dials.UnavailableError{Err: fmt.Errorf("failed to connect to the control plane: %w", err)}
+	}
+	s.mu.Lock()
+	versions := resourceVersions(s.accepted)
+	s.mu.Unlock()
+	req := &DeltaDiscoveryRequest{
+		Node:                    s.Node,
+		TypeURL:                 s.TypeURL,
+		ResourceNamesSubscribe:  s.ResourceNames,
+		InitialResourceVersions: versions,
+	}
+	if err := stream.Send(req); err != nil {
+		stream.CloseSend()
+		return nil, fmt.Errorf("failed to subscribe to %s resources: %w", s.TypeURL, err)
+	}
+	return stream, nil
+}
+
+func (s *Source) ack(stream Stream, resp *DeltaDiscoveryResponse) error {
+	if err := stream.Send(&DeltaDiscoveryRequest{
+		Node: s.Node, TypeURL: s.TypeURL, ResponseNonce: resp.Nonce,
+	}); err != nil {
+		return fmt.Errorf("failed to ACK response %q: %w", resp.Nonce, err)
+	}
+	return nil
+}
+
+func (s *Source) nack(stream Stream, resp *DeltaDiscoveryResponse, reason error) error {
+	if err := stream.Send(&DeltaDiscoveryRequest{
+		Node: s.Node, TypeURL: s.TypeURL, ResponseNonce: resp.Nonce, ErrorDetail: reason.Error(),
+	}); err != nil {
+		return fmt.Errorf("failed to NACK response %q: %w", resp.Nonce, err)
+	}
+	return nil
+}
+
+// apply returns the accepted resources updated with the changes in resp
+// (leaving the accepted resources untouched).
+func (s *Source) apply(resp *DeltaDiscoveryResponse) map[string]Resource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Resource, len(s.accepted)+len(resp.Resources))
+	for name, r := range s.accepted {
+		out[name] = r
+	}
+	for _, name := range resp.RemovedResources {
+		delete(out, name)
+	}
+	for _, r := range resp.Resources {
+		out[r.Name] = r
+	}
+	return out
+}
+
+func (s *Source) setAccepted(resources map[string]Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accepted = resources
+}
+
+// compose decodes the resources and composes them in order of precedence.
+func (s *Source) compose(ctx context.Context, t *dials.Type, resources map[string]Resource) (reflect.Value, error) {
+	names := s.ResourceNames
+	if len(names) == 0 {
+		names = make([]string, 0, len(resources))
+		for name := range resources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	vals := make([]reflect.Value, 0, len(names))
+	for _, name := range names {
+		r, ok := resources[name]
+		if !ok {
+			continue
+		}
+		v, err := dials.Decode(ctx, s.Decoder, bytes.NewReader(r.Body), t)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("failed to decode resource %q (version %q): %w", r.Name, r.Version, err)
+		}
+		vals = append(vals, v)
+	}
+	return dials.ComposeValues(t, vals...)
+}
+
+func resourceVersions(resources map[string]Resource) map[string]string {
+	if len(resources) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(resources))
+	for name, r := range resources {
+		out[name] = r.Version
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package xds
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/ptrify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type xdsConfig struct {
+	Name string `dials:"name"`
+	Port int    `dials:"port"`
+}
+
+func (c *xdsConfig) Verify() error {
+	if c.Port < 0 {
+		return errors.New("port must not be negative")
+	}
+	return nil
+}
+
+// fakeStream is one stream to fakeControlPlane.
+type fakeStream struct {
+	ctx  context.Context
+	reqs chan *DeltaDiscoveryRequest
+	resp chan *DeltaDiscoveryResponse
+}
+
+func (s *fakeStream) Send(req *DeltaDiscoveryRequest) error {
+	select {
+	case s.reqs <- req:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *fakeStream) Recv() (*DeltaDiscoveryResponse, error) {
+	select {
+	case r, ok := <-s.resp:
+		if !ok {
+			return nil, errors.New("stream reset")
+		}
+		return r, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *fakeStream) CloseSend() error { return nil }
+
+type fakeControlPlane struct {
+	streams chan *fakeStream
+}
+
+func newFakeControlPlane() *fakeControlPlane {
+	return &fakeControlPlane{streams: make(chan *fakeStream, 4)}
+}
+
+func (f *fakeControlPlane) dial(ctx context.Context) (Stream, error) {
+	s := &fakeStream{
+		ctx:  ctx,
+		reqs: make(chan *DeltaDiscoveryRequest, 8),
+		resp: make(chan *DeltaDiscoveryResponse, 8),
+	}
+	f.streams <- s
+	return s, nil
+}
+
+func (f *fakeControlPlane) nextStream(t *testing.T) *fakeStream {
+	t.Helper()
+	select {
+	case s := <-f.streams:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream")
+		return nil
+	}
+}
+
+func (s *fakeStream) nextRequest(t *testing.T) *DeltaDiscoveryRequest {
+	t.Helper()
+	select {
+	case r := <-s.reqs:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a request")
+		return nil
+	}
+}
+
+func TestSourceACKsAndNACKs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cp := newFakeControlPlane()
+	src := &Source{
+		Dial:              cp.dial,
+		Node:              "test-node",
+		TypeURL:           "type.example.com/Config",
+		ResourceNames:     []string{"base", "override"},
+		Decoder:           &json.Decoder{},
+		ReconnectInterval: time.Millisecond,
+	}
+
+	type result struct {
+		d   *dials.Dials[xdsConfig]
+		err error
+	}
+	res := make(chan result, 1)
+	go func() {
+		d, err := dials.Config(ctx, &xdsConfig{}, src)
+		res <- result{d, err}
+	}()
+
+	s := cp.nextStream(t)
+	sub := s.nextRequest(t)
+	assert.Equal(t, "test-node", sub.Node)
+	assert.Equal(t, []string{"base", "override"}, sub.ResourceNamesSubscribe)
+	assert.Empty(t, sub.InitialResourceVersions)
+	s.resp <- &DeltaDiscoveryResponse{
+		Nonce: "1",
+		Resources: []Resource{
+			{Name: "override", Version: "v1", Body: []byte(`{"port": 8080}`)},
+			{Name: "base", Version: "v1", Body: []byte(`{"name": "svc", "port": 80}`)},
+		},
+	}
+	ack := s.nextRequest(t)
+	assert.Equal(t, "1", ack.ResponseNonce)
+	assert.Empty(t, ack.ErrorDetail)
+
+	r := <-res
+	require.NoError(t, r.err)
+	d := r.d
+	defer d.Close(ctx)
+	assert.Equal(t, &xdsConfig{Name: "svc", Port: 8080}, d.View())
+	assert.Equal(t, map[string]string{"base": "v1", "override": "v1"}, src.Versions())
+
+	// the watch resubscribes with the versions it has
+	w := cp.nextStream(t)
+	sub = w.nextRequest(t)
+	assert.Equal(t, map[string]string{"base": "v1", "override": "v1"}, sub.InitialResourceVersions)
+
+	// an update that fails verification is NACKed, and not installed
+	w.resp <- &DeltaDiscoveryResponse{
+		Nonce:     "2",
+		Resources: []Resource{{Name: "override", Version: "v2", Body: []byte(`{"port": -1}`)}},
+	}
+	nack := w.nextRequest(t)
+	assert.Equal(t, "2", nack.ResponseNonce)
+	assert.Contains(t, nack.ErrorDetail, "port must not be negative")
+	assert.Equal(t, &xdsConfig{Name: "svc", Port: 8080}, d.View())
+	assert.Equal(t, "v1", src.Versions()["override"])
+
+	// so are ones that can't be decoded
+	w.resp <- &DeltaDiscoveryResponse{
+		Nonce:     "3",
+		Resources: []Resource{{Name: "base", Version: "v2", Body: []byte(`{"name": `)}},
+	}
+	nack = w.nextRequest(t)
+	assert.Equal(t, "3", nack.ResponseNonce)
+	assert.Contains(t, nack.ErrorDetail, `failed to decode resource "base"`)
+
+	// removing the override is ACKed, and falls back to the base
+	w.resp <- &DeltaDiscoveryResponse{Nonce: "4", RemovedResources: []string{"override"}}
+	ack = w.nextRequest(t)
+	assert.Equal(t, "4", ack.ResponseNonce)
+	assert.Empty(t, ack.ErrorDetail)
+	assert.Equal(t, &xdsConfig{Name: "svc", Port: 80}, d.View())
+	assert.Equal(t, map[string]string{"base": "v1"}, src.Versions())
+
+	// after the stream fails, the source reconnects
+	close(w.resp)
+	w = cp.nextStream(t)
+	sub = w.nextRequest(t)
+	assert.Equal(t, map[string]string{"base": "v1"}, sub.InitialResourceVersions)
+}
+
+func TestSourceValueNACKsUndecodable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cp := newFakeControlPlane()
+	src := &Source{Dial: cp.dial, TypeURL: "type.example.com/Config", Decoder: &json.Decoder{}}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := src.Value(ctx, dials.NewType(ptrify.Pointerify(reflect.TypeOf(xdsConfig{}), reflect.Value{})))
+		errCh <- err
+	}()
+	s := cp.nextStream(t)
+	assert.Empty(t, s.nextRequest(t).ResourceNamesSubscribe)
+	s.resp <- &DeltaDiscoveryResponse{Nonce: "a", Resources: []Resource{{Name: "cfg", Body: []byte(`[`)}}}
+	nack := s.nextRequest(t)
+	assert.Equal(t, "a", nack.ResponseNonce)
+	assert.NotEmpty(t, nack.ErrorDetail)
+	assert.Error(t, <-errCh)
+	assert.Empty(t, src.Versions())
+}
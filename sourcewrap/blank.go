@@ -2,6 +2,7 @@ package sourcewrap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
@@ -10,8 +11,9 @@ import (
 )
 
 // Blank operates as a blank Source in its default state. It provides a
-// SetSource method for updating the inner Source later and uses the
-// dials.Watcher interface to update the View it's inserted into.
+// SetSource method for updating the inner Source later (and RemoveSource for
+// returning to the blank state) and uses the dials.Watcher interface to
+// update the View it's inserted into.
 //
 // Blanks cannot be reused as they have to be aware of parameters of a
 // particular View.
@@ -35,6 +37,10 @@ type Blank struct {
 	watchCtx context.Context
 	wa       dials.WatchArgs
 	t        *dials.Type
+
+	// innerWA and cancelInner are set while the inner Source is a Watcher
+	innerWA     *blankWatchArgs
+	cancelInner context.CancelFunc
 }
 
 var _ dials.Source = (*Blank)(nil)
@@ -84,14 +90,19 @@ func (w *wrappedErr) Unwrap() error {
 	return w.err
 }
 
+// ErrDetachedSource is returned by the WatchArgs methods passed to a
+// Watcher-implementing inner Source after it has been replaced or removed.
+var ErrDetachedSource = errors.New("source has been detached from its Blank")
+
 // SetSource sets the wrapped source, switching the Blank into full delegation mode.
 // The new Source's Value() method will be called, and the return value will be
 // pushed to the view via the asynchronous watch interface.
 // If the new Source implements dials.Watcher, its Watch method will be called.
 //
-// It is safe to call SetSource multiple times with different Sources, however,
-// once a Watcher is set as the inner source, it takes over ownership of the
-// state for the slot and cannot be replaced.
+// It is safe to call SetSource multiple times with different Sources. If the
+// Source being replaced implements dials.Watcher, the context passed to its
+// Watch method is canceled (so it can shut down its goroutines), and any
+// further values or errors it reports are discarded.
 func (b *Blank) SetSource(ctx context.Context, s dials.Source) error {
 	if s == nil {
 		return fmt.Errorf("cannot pass a nil source to *Blank.SetSource with type %s",
@@ -100,47 +111,142 @@ func (b *Blank) SetSource(ctx context.Context, s dials.Source) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if b.inner != nil {
-		if _, isWatcher := b.inner.(dials.Watcher); isWatcher {
-			return fmt.Errorf("disallowed attempt to replace Watcher Source: %T",
-				b.inner)
-		}
-	}
-
 	v, err := s.Value(ctx, b.t)
 	if err != nil {
 		return &wrappedErr{prefix: "initial call to Value failed: ", err: err}
 	}
+	b.detachInner()
 	b.inner = s
 	if newValErr := b.wa.BlockingReportNewValue(ctx, v); newValErr != nil {
 		return fmt.Errorf("failed to propagate change: %w", newValErr)
 	}
 
 	if w, ok := s.(dials.Watcher); ok {
-		wErr := w.Watch(b.watchCtx, b.t, b.wa)
+		innerCtx, cancel := context.WithCancel(b.watchCtx)
+		b.innerWA = &blankWatchArgs{WatchArgs: b.wa, ctx: innerCtx}
+		b.cancelInner = cancel
+		wErr := w.Watch(innerCtx, b.t, b.innerWA)
 		if wErr != nil {
+			b.detachInner()
 			return &wrappedErr{prefix: "call to Watch failed: ", err: wErr}
 		}
 	}
 	return nil
 }
 
+// RemoveSource removes the wrapped source, returning the Blank to its
+// default state, in which it provides an empty value. As with SetSource,
+// if the removed Source implements dials.Watcher, its watch is canceled.
+func (b *Blank) RemoveSource(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inner == nil {
+		return nil
+	}
+	b.detachInner()
+	b.inner = nil
+	if newValErr := b.wa.BlockingReportNewValue(ctx, reflect.New(b.t.Type())); newValErr != nil {
+		return fmt.Errorf("failed to propagate change: %w", newValErr)
+	}
+	return nil
+}
+
+// detachInner cancels the inner Watcher's watch (if any), and discards any
+// subsequent reports from it.
+// b.mu must be held.
+func (b *Blank) detachInner() {
+	if b.innerWA == nil {
+		return
+	}
+	b.innerWA.detach()
+	b.cancelInner()
+	b.innerWA = nil
+	b.cancelInner = nil
+}
+
 // Done instructs Dials that this Blank source will never be used in a watching
 // mode ever again (allowing Dials to shutdown a goroutine once all other
 // sources implementing Watcher have called Done()).
-// This call is a nop if a source implementing the Watcher interface is present
-// within Blank. (Semantically, the WatchArgs are no longer the Blank's once a
-// Watcher-implementing Source is set)
+// If a source implementing the Watcher interface is present within Blank, its
+// watch is canceled, and the last value it reported remains in place.
+//
+// Note that calls to Done by an inner Watcher are not propagated, since the
+// Blank may still receive a new Source.
 func (b *Blank) Done(ctx context.Context) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	switch b.inner.(type) {
-	case dials.Watcher:
-		return
-	default:
-	}
 	if b.wa == nil {
 		return
 	}
+	b.detachInner()
 	b.wa.Done(ctx)
 }
+
+// blankWatchArgs wraps the WatchArgs passed to an inner Watcher, so it can be
+// detached when replaced.
+type blankWatchArgs struct {
+	dials.WatchArgs
+	// ctx is the context passed to the inner Watcher's Watch method,
+	// which is canceled when it's detached
+	ctx context.Context
+
+	// mu is held for reading while forwarding reports, so once detach
+	// returns, no more reports will be delivered.
+	mu       sync.RWMutex
+	detached bool
+}
+
+func (b *blankWatchArgs) detach() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.detached = true
+}
+
+func (b *blankWatchArgs) ReportNewValue(ctx context.Context, val reflect.Value) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.detached {
+		return ErrDetachedSource
+	}
+	return b.WatchArgs.ReportNewValue(ctx, val)
+}
+
+func (b *blankWatchArgs) BlockingReportNewValue(ctx context.Context, val reflect.Value) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.detached {
+		return ErrDetachedSource
+	}
+	return b.WatchArgs.BlockingReportNewValue(ctx, val)
+}
+
+// Go runs f on a goroutine belonging to the Blank's watch, with a context
+// that's also canceled when the inner Watcher is detached.
+func (b *blankWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(b.ctx, b.WatchArgs, func(ctx context.Context) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			// exits when either context is canceled, which f
+			// returning guarantees
+			select {
+			case <-b.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		f(ctx)
+	})
+}
+
+func (b *blankWatchArgs) ReportError(ctx context.Context, err error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.detached {
+		return ErrDetachedSource
+	}
+	return b.WatchArgs.ReportError(ctx, err)
+}
+
+// Done is a no-op; the Blank's slot remains active until Blank.Done is called.
+func (b *blankWatchArgs) Done(context.Context) {}
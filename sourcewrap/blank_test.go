@@ -6,8 +6,12 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/dialstest"
 )
 
 // returns an empty value, but increments a counter for every call to Value()
@@ -274,3 +278,115 @@ func TestBlankSourceErrorWatcher(t *testing.T) {
 		}
 	}
 }
+
+// ctxRecordingWatchingSource provides a value with A set to a, and records
+// the arguments to Watch.
+type ctxRecordingWatchingSource struct {
+	a        int
+	watchCtx context.Context
+	args     dials.WatchArgs
+	typ      *dials.Type
+}
+
+func (c *ctxRecordingWatchingSource) Value(_ context.Context, typ *dials.Type) (reflect.Value, error) {
+	v := reflect.New(typ.Type()).Elem()
+	a := c.a
+	v.FieldByName("A").Set(reflect.ValueOf(&a))
+	return v, nil
+}
+
+func (c *ctxRecordingWatchingSource) Watch(ctx context.Context, typ *dials.Type, args dials.WatchArgs) error {
+	c.watchCtx = ctx
+	c.args = args
+	c.typ = typ
+	return nil
+}
+
+func TestBlankSourceReplaceAndRemoveWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := Blank{}
+	type basicConf struct {
+		A int
+		C string
+	}
+	d, err := dials.Config(ctx, &basicConf{A: 3, C: "fob"}, &b)
+	require.NoError(t, err)
+
+	first := ctxRecordingWatchingSource{a: 1}
+	require.NoError(t, b.SetSource(ctx, &first))
+	assert.Equal(t, &basicConf{A: 1, C: "fob"}, d.View())
+
+	first.a = 2
+	v, _ := first.Value(ctx, first.typ)
+	require.NoError(t, first.args.BlockingReportNewValue(ctx, v))
+	assert.Equal(t, 2, d.View().A)
+
+	// replacing the watcher cancels its watch and detaches it
+	second := ctxRecordingWatchingSource{a: 10}
+	require.NoError(t, b.SetSource(ctx, &second))
+	assert.Equal(t, 10, d.View().A)
+	assert.Error(t, first.watchCtx.Err())
+	assert.NoError(t, second.watchCtx.Err())
+	assert.ErrorIs(t, first.args.ReportNewValue(ctx, v), ErrDetachedSource)
+	assert.ErrorIs(t, first.args.ReportError(ctx, errors.New("stale")), ErrDetachedSource)
+	// done calls from inner watchers are ignored
+	second.args.Done(ctx)
+
+	// replacing with a non-watching source and then removing it returns to
+	// the defaults
+	require.NoError(t, b.SetSource(ctx, &trivalCountingSource{}))
+	assert.Error(t, second.watchCtx.Err())
+	require.NoError(t, b.SetSource(ctx, &ctxRecordingWatchingSource{a: 20}))
+	assert.Equal(t, 20, d.View().A)
+	require.NoError(t, b.RemoveSource(ctx))
+	assert.Equal(t, &basicConf{A: 3, C: "fob"}, d.View())
+}
+
+// goroutineWatchingSource starts a goroutine with dials.WatchGo, which closes
+// exited once its context is canceled.
+type goroutineWatchingSource struct {
+	trivalCountingSource
+	exited chan struct{}
+}
+
+func (g *goroutineWatchingSource) Watch(ctx context.Context, _ *dials.Type, args dials.WatchArgs) error {
+	dials.WatchGo(ctx, args, func(ctx context.Context) {
+		defer close(g.exited)
+		<-ctx.Done()
+	})
+	return nil
+}
+
+func TestBlankSourceWatcherGoroutines(t *testing.T) {
+	dialstest.CheckGoroutines(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b := Blank{}
+	type basicConf struct {
+		A int
+	}
+	d, err := dials.Config(ctx, &basicConf{}, &b)
+	require.NoError(t, err)
+
+	// the goroutines of a replaced watcher are stopped
+	first := &goroutineWatchingSource{exited: make(chan struct{})}
+	require.NoError(t, b.SetSource(ctx, first))
+	second := &goroutineWatchingSource{exited: make(chan struct{})}
+	require.NoError(t, b.SetSource(ctx, second))
+	select {
+	case <-first.exited:
+	case <-ctx.Done():
+		t.Fatal("the replaced watcher's goroutine didn't exit")
+	}
+
+	// and Close waits for the current one's
+	require.NoError(t, d.Close(ctx))
+	select {
+	case <-second.exited:
+	default:
+		t.Error("Close returned before the watcher's goroutine exited")
+	}
+}
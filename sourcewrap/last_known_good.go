@@ -0,0 +1,231 @@
+package sourcewrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vimeo/dials"
+)
+
+// RecoveredError is reported through the dials.WatchArgs ReportError method
+// (and consequently OnWatchedError callbacks) when a LastKnownGood source that
+// started up using its cached value successfully fetches a value from the
+// wrapped source. It's informational; the new value is reported immediately
+// afterwards.
+type RecoveredError struct {
+	// StartupErr is the error returned by the wrapped source at startup.
+	StartupErr error
+}
+
+func (r *RecoveredError) Error() string {
+	return fmt.Sprintf("wrapped source recovered after serving cached value (startup error: %s)", r.StartupErr)
+}
+
+func (r *RecoveredError) Unwrap() error {
+	return r.StartupErr
+}
+
+// LastKnownGood wraps another Source (usually one that fetches from a remote
+// system), persisting every value it successfully provides to a local file.
+// If the wrapped source fails at startup, the persisted value is used
+// instead, and the wrapped source is retried in the background until it
+// recovers.
+//
+// Values are persisted as JSON, so fields that are omitted from JSON encoding
+// (e.g. `json:"-"`) are not cached. Failing to persist a value doesn't
+// reject it; the failure is reported as a dials.WarningLastKnownGoodFailed
+// warning (or, for values reported by a watch, through ReportError).
+//
+// Like Blank, LastKnownGood instances cannot be reused across calls to Config.
+type LastKnownGood struct {
+	inner         dials.Source
+	cachePath     string
+	retryInterval time.Duration
+
+	mu sync.Mutex
+	// startupErr is the error returned by the wrapped source's Value
+	// method if the cached value is being served.
+	startupErr error
+}
+
+var _ dials.Source = (*LastKnownGood)(nil)
+var _ dials.Watcher = (*lastKnownGoodWatcher)(nil)
+
+// NewLastKnownGood constructs a LastKnownGood wrapping inner, and persisting
+// values to cachePath. If the cached value is used at startup and inner does
+// not implement dials.Watcher, inner's Value method is retried every
+// retryInterval until it succeeds. (a non-positive retryInterval disables
+// retries)
+//
+// The returned source only implements dials.Watcher if inner does, or
+// retries are enabled, so the Dials instance doesn't treat it as watching
+// when it will never report anything.
+func NewLastKnownGood(inner dials.Source, cachePath string, retryInterval time.Duration) dials.Source {
+	l := &LastKnownGood{
+		inner:         inner,
+		cachePath:     cachePath,
+		retryInterval: retryInterval,
+	}
+	if _, ok := inner.(dials.Watcher); ok || retryInterval > 0 {
+		return &lastKnownGoodWatcher{LastKnownGood: l}
+	}
+	return l
+}
+
+func (l *LastKnownGood) persist(v reflect.Value) error {
+	b, marshalErr := json.Marshal(v.Interface())
+	if marshalErr != nil {
+		return fmt.Errorf("failed to encode value for cache: %w", marshalErr)
+	}
+	// write to a temporary file and rename so concurrent readers never
+	// see a partial file.
+	tmp, tmpErr := os.CreateTemp(filepath.Dir(l.cachePath), filepath.Base(l.cachePath)+".tmp*")
+	if tmpErr != nil {
+		return fmt.Errorf("failed to create temporary cache file: %w", tmpErr)
+	}
+	defer os.Remove(tmp.Name())
+	if _, writeErr := tmp.Write(b); writeErr != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", writeErr)
+	}
+	if closeErr := tmp.Close(); closeErr != nil {
+		return fmt.Errorf("failed to close cache file: %w", closeErr)
+	}
+	if renameErr := os.Rename(tmp.Name(), l.cachePath); renameErr != nil {
+		return fmt.Errorf("failed to install cache file: %w", renameErr)
+	}
+	return nil
+}
+
+func (l *LastKnownGood) load(t *dials.Type) (reflect.Value, error) {
+	b, readErr := os.ReadFile(l.cachePath)
+	if readErr != nil {
+		return reflect.Value{}, readErr
+	}
+	v := reflect.New(t.Type())
+	if unmarshalErr := json.Unmarshal(b, v.Interface()); unmarshalErr != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode cache file %q: %w", l.cachePath, unmarshalErr)
+	}
+	return v.Elem(), nil
+}
+
+// Value implements dials.Source, returning the wrapped source's value, or
+// the cached value if the wrapped source fails.
+func (l *LastKnownGood) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	v, err := l.inner.Value(ctx, t)
+	if err == nil {
+		// a value that can't be cached is still good
+		if persistErr := l.persist(v); persistErr != nil {
+			dials.ReportWarning(ctx, dials.Warning{
+				Kind:    dials.WarningLastKnownGoodFailed,
+				Source:  l,
+				Message: persistErr.Error(),
+			})
+		}
+		return v, nil
+	}
+	cached, loadErr := l.load(t)
+	if loadErr != nil {
+		return reflect.Value{}, fmt.Errorf("wrapped source failed (%w) and cache is unavailable: %s", err, loadErr)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.startupErr = err
+	return cached, nil
+}
+
+// takeStartupErr returns the startup error (if any) and clears it.
+func (l *LastKnownGood) takeStartupErr() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.startupErr
+	l.startupErr = nil
+	return err
+}
+
+// lastKnownGoodWatcher is a LastKnownGood whose wrapped source implements
+// dials.Watcher, or which retries the wrapped source.
+type lastKnownGoodWatcher struct {
+	*LastKnownGood
+}
+
+// Watch implements dials.Watcher, delegating to the wrapped source if it
+// implements dials.Watcher, and otherwise retrying the wrapped source in the
+// background if the cached value was used at startup (and otherwise
+// reporting that it's done).
+func (l *lastKnownGoodWatcher) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	wa := &lkgWatchArgs{WatchArgs: args, ctx: ctx, l: l.LastKnownGood}
+	if w, ok := l.inner.(dials.Watcher); ok {
+		return w.Watch(ctx, t, wa)
+	}
+
+	l.mu.Lock()
+	serveCached := l.startupErr != nil
+	l.mu.Unlock()
+
+	// with the wrapped source's value in use, there's nothing to retry,
+	// so the watch is done. (Watch is called before the Dials instance's
+	// monitor goroutine starts, so Done is called on another goroutine)
+	if !serveCached {
+		dials.WatchGo(ctx, args, args.Done)
+		return nil
+	}
+	dials.WatchGo(ctx, args, func(ctx context.Context) { l.retryLoop(ctx, t, wa) })
+	return nil
+}
+
+func (l *LastKnownGood) retryLoop(ctx context.Context, t *dials.Type, wa *lkgWatchArgs) {
+	defer wa.Done(ctx)
+	ticker := time.NewTicker(l.retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		v, err := l.inner.Value(ctx, t)
+		if err != nil {
+			continue
+		}
+		wa.ReportNewValue(ctx, v)
+		return
+	}
+}
+
+type lkgWatchArgs struct {
+	dials.WatchArgs
+	// ctx is the context passed to Watch
+	ctx context.Context
+	l   *LastKnownGood
+}
+
+// Go starts f with the wrapped WatchArgs (see dials.WatchGo).
+func (w *lkgWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(w.ctx, w.WatchArgs, f)
+}
+
+func (w *lkgWatchArgs) notify(ctx context.Context, v reflect.Value) {
+	if persistErr := w.l.persist(v); persistErr != nil {
+		w.WatchArgs.ReportError(ctx, persistErr)
+	}
+	if startupErr := w.l.takeStartupErr(); startupErr != nil {
+		w.WatchArgs.ReportError(ctx, &RecoveredError{StartupErr: startupErr})
+	}
+}
+
+func (w *lkgWatchArgs) ReportNewValue(ctx context.Context, v reflect.Value) error {
+	w.notify(ctx, v)
+	return w.WatchArgs.ReportNewValue(ctx, v)
+}
+
+func (w *lkgWatchArgs) BlockingReportNewValue(ctx context.Context, v reflect.Value) error {
+	w.notify(ctx, v)
+	return w.WatchArgs.BlockingReportNewValue(ctx, v)
+}
@@ -0,0 +1,134 @@
+package sourcewrap
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+type lkgConfig struct {
+	Addr string
+	Port int
+}
+
+// flakySource fails until healthy is set, after which it returns a config
+// with Addr set to addr.
+type flakySource struct {
+	mu      sync.Mutex
+	healthy bool
+	addr    string
+}
+
+var errUnreachable = errors.New("unreachable")
+
+func (f *flakySource) set(healthy bool, addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.healthy = healthy
+	f.addr = addr
+}
+
+func (f *flakySource) Value(_ context.Context, t *dials.Type) (reflect.Value, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.healthy {
+		return reflect.Value{}, errUnreachable
+	}
+	v := reflect.New(t.Type()).Elem()
+	addr := f.addr
+	v.FieldByName("Addr").Set(reflect.ValueOf(&addr))
+	return v, nil
+}
+
+func TestLastKnownGood(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	inner := flakySource{healthy: true, addr: "first"}
+
+	// populate the cache
+	d, err := dials.Config(ctx, &lkgConfig{Port: 80}, NewLastKnownGood(&inner, cachePath, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "first", d.View().Addr)
+
+	// the remote is down, the cached value should be served.
+	inner.set(false, "")
+	errs := make(chan error, 4)
+	d, err = dials.Params[lkgConfig]{
+		OnWatchedError: func(_ context.Context, err error, _, _ *lkgConfig) { errs <- err },
+	}.Config(ctx, &lkgConfig{Port: 80}, NewLastKnownGood(&inner, cachePath, time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, &lkgConfig{Addr: "first", Port: 80}, d.View())
+
+	// once the remote recovers, the new value should be installed after
+	// an informational error.
+	inner.set(true, "second")
+	recoveredErr := <-errs
+	var recovered *RecoveredError
+	require.True(t, errors.As(recoveredErr, &recovered), "unexpected error: %v", recoveredErr)
+	assert.ErrorIs(t, recovered, errUnreachable)
+	newCfg := <-d.Events()
+	assert.Equal(t, "second", newCfg.Addr)
+}
+
+func TestLastKnownGoodNoCache(t *testing.T) {
+	t.Parallel()
+	inner := flakySource{}
+	_, err := dials.Config(context.Background(), &lkgConfig{},
+		NewLastKnownGood(&inner, filepath.Join(t.TempDir(), "missing.json"), 0))
+	assert.ErrorIs(t, err, errUnreachable)
+}
+
+func TestLastKnownGoodUnwritableCache(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the cache's directory doesn't exist, so it can't be written
+	cachePath := filepath.Join(t.TempDir(), "missing", "cache.json")
+	inner := flakySource{healthy: true, addr: "first"}
+	warnings := make(chan dials.Warning, 1)
+	d, err := dials.Params[lkgConfig]{
+		OnWarning: func(_ context.Context, w dials.Warning) { warnings <- w },
+	}.Config(ctx, &lkgConfig{Port: 80}, NewLastKnownGood(&inner, cachePath, 0))
+	require.NoError(t, err)
+	assert.Equal(t, &lkgConfig{Addr: "first", Port: 80}, d.View())
+	w := <-warnings
+	assert.Equal(t, dials.WarningLastKnownGoodFailed, w.Kind)
+	assert.Contains(t, w.Message, "failed to create temporary cache file")
+}
+
+func TestLastKnownGoodNotWatching(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	inner := flakySource{healthy: true, addr: "first"}
+	src := NewLastKnownGood(&inner, filepath.Join(t.TempDir(), "cache.json"), 0)
+	_, isWatcher := src.(dials.Watcher)
+	assert.False(t, isWatcher)
+
+	// with retries enabled, a healthy source has nothing to retry, so its
+	// watch is done straight away
+	inner2 := flakySource{healthy: true, addr: "first"}
+	d, err := dials.Config(ctx, &lkgConfig{},
+		NewLastKnownGood(&inner2, filepath.Join(t.TempDir(), "cache.json"), time.Millisecond))
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	require.Eventually(t, func() bool { return !d.SourceStatus()[0].Watching },
+		5*time.Second, time.Millisecond)
+	inner2.set(true, "second")
+	cfg, _, err := d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "second", cfg.Addr)
+}
@@ -0,0 +1,126 @@
+package sourcewrap
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vimeo/dials"
+)
+
+// RetryPolicy configures how a source wrapped with NewRetrying is retried.
+type RetryPolicy struct {
+	// Timeout bounds each call to the wrapped source's Value method. A
+	// non-positive Timeout disables the per-call timeout (the context
+	// passed to Value still applies).
+	Timeout time.Duration
+	// MaxAttempts is the maximum number of calls to the wrapped source's
+	// Value (or Watch) method before giving up. Values less than 1 are
+	// treated as 1 (no retries).
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// Multiplier scales the delay after each retry. Values less than 1
+	// are treated as 2.
+	Multiplier float64
+	// MaxBackoff caps the delay between retries. A non-positive
+	// MaxBackoff leaves the delay uncapped.
+	MaxBackoff time.Duration
+}
+
+// RetryError is returned when every attempt allowed by a RetryPolicy fails.
+type RetryError struct {
+	Attempts int
+	// Err is the error from the last attempt.
+	Err error
+}
+
+func (r *RetryError) Error() string {
+	return fmt.Sprintf("wrapped source failed after %d attempts: %s", r.Attempts, r.Err)
+}
+
+func (r *RetryError) Unwrap() error {
+	return r.Err
+}
+
+// NewRetrying wraps inner, retrying its Value method with exponential backoff
+// (and bounding each call with a timeout) according to policy, so a briefly
+// unavailable remote source doesn't fail Config. If inner implements
+// dials.Watcher, establishing its watch is retried the same way.
+func NewRetrying(inner dials.Source, policy RetryPolicy) dials.Source {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	r := retrying{inner: inner, policy: policy}
+	if w, ok := inner.(dials.Watcher); ok {
+		return &retryingWatcher{retrying: r, watcher: w}
+	}
+	return &r
+}
+
+type retrying struct {
+	inner  dials.Source
+	policy RetryPolicy
+}
+
+// retry calls attempt until it succeeds, ctx expires, or the policy's
+// attempts are exhausted.
+func (r *retrying) retry(ctx context.Context, attempt func(ctx context.Context) error) error {
+	backoff := r.policy.InitialBackoff
+	var err error
+	for i := 1; ; i++ {
+		if err = attempt(ctx); err == nil {
+			return nil
+		}
+		if i >= r.policy.MaxAttempts {
+			return &RetryError{Attempts: i, Err: err}
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("context expired while retrying (after %d attempts, last error: %s): %w", i, err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = time.Duration(float64(backoff) * r.policy.Multiplier)
+		if r.policy.MaxBackoff > 0 && backoff > r.policy.MaxBackoff {
+			backoff = r.policy.MaxBackoff
+		}
+	}
+}
+
+// Value implements dials.Source.
+func (r *retrying) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	var v reflect.Value
+	err := r.retry(ctx, func(ctx context.Context) error {
+		if r.policy.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, r.policy.Timeout)
+			defer cancel()
+		}
+		var err error
+		v, err = r.inner.Value(ctx, t)
+		return err
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return v, nil
+}
+
+type retryingWatcher struct {
+	retrying
+	watcher dials.Watcher
+}
+
+// Watch implements dials.Watcher. The per-call timeout doesn't apply, since
+// ctx must remain valid for the lifetime of the watch.
+func (r *retryingWatcher) Watch(ctx context.Context, t *dials.Type, args dials.WatchArgs) error {
+	return r.retry(ctx, func(ctx context.Context) error {
+		return r.watcher.Watch(ctx, t, args)
+	})
+}
@@ -0,0 +1,67 @@
+package sourcewrap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+)
+
+// recoveringSource fails the first failures calls to Value, recording how
+// many calls were made.
+type recoveringSource struct {
+	flakySource
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (r *recoveringSource) Value(ctx context.Context, t *dials.Type) (reflect.Value, error) {
+	r.mu.Lock()
+	r.calls++
+	r.flakySource.set(r.calls > r.failures, "recovered")
+	r.mu.Unlock()
+	return r.flakySource.Value(ctx, t)
+}
+
+func TestRetrying(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	inner := recoveringSource{failures: 2}
+	d, err := dials.Config(ctx, &lkgConfig{}, NewRetrying(&inner, policy))
+	require.NoError(t, err)
+	assert.Equal(t, "recovered", d.View().Addr)
+	assert.Equal(t, 3, inner.calls)
+
+	inner = recoveringSource{failures: 3}
+	_, err = dials.Config(ctx, &lkgConfig{}, NewRetrying(&inner, policy))
+	require.Error(t, err)
+	retryErr := &RetryError{}
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.ErrorIs(t, err, errUnreachable)
+}
+
+// hangingSource blocks in Value until the context expires.
+type hangingSource struct{}
+
+func (hangingSource) Value(ctx context.Context, _ *dials.Type) (reflect.Value, error) {
+	<-ctx.Done()
+	return reflect.Value{}, ctx.Err()
+}
+
+func TestRetryingTimeout(t *testing.T) {
+	t.Parallel()
+	src := NewRetrying(hangingSource{}, RetryPolicy{Timeout: time.Millisecond, MaxAttempts: 2})
+	_, err := dials.Config(context.Background(), &lkgConfig{}, src)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -24,6 +24,10 @@ type transformingDecoder struct {
 }
 
 func (t *transformingDecoder) Decode(reader io.Reader, typ *dials.Type) (reflect.Value, error) {
+	return t.DecodeContext(context.Background(), reader, typ)
+}
+
+func (t *transformingDecoder) DecodeContext(ctx context.Context, reader io.Reader, typ *dials.Type) (reflect.Value, error) {
 	tfm := transform.NewTransformer(typ.Type(), t.manglers...)
 	transformedVal, transformErr := tfm.TranslateType()
 	if transformErr != nil {
@@ -31,7 +35,7 @@ func (t *transformingDecoder) Decode(reader io.Reader, typ *dials.Type) (reflect
 	}
 	innerTyp := dials.NewType(transformedVal)
 
-	srcVal, srcErr := t.inner.Decode(reader, innerTyp)
+	srcVal, srcErr := dials.Decode(ctx, t.inner, reader, innerTyp)
 	if srcErr != nil {
 		return reflect.Value{}, &wrappedErr{prefix: "inner source failed: ", err: srcErr}
 	}
@@ -91,9 +95,16 @@ func (t *transformingSourceNoWatch) Value(ctx context.Context, typ *dials.Type)
 
 type wrappedWatchArgs struct {
 	dials.WatchArgs
+	// ctx is the context passed to Watch
+	ctx context.Context
 	tfm *transform.Transformer
 }
 
+// Go starts f with the wrapped WatchArgs (see dials.WatchGo).
+func (w *wrappedWatchArgs) Go(f func(ctx context.Context)) {
+	dials.WatchGo(w.ctx, w.WatchArgs, f)
+}
+
 func (w *wrappedWatchArgs) NewValue(ctx context.Context, val reflect.Value) error {
 	unmangledVal, unmangleErr := w.tfm.ReverseTranslate(val)
 	if unmangleErr != nil {
@@ -114,7 +125,7 @@ func (t *transformingSourceWithWatch) Watch(ctx context.Context, typ *dials.Type
 	if transformErr != nil {
 		return &wrappedErr{prefix: "transform failed: ", err: transformErr}
 	}
-	wrappedCB := wrappedWatchArgs{WatchArgs: args, tfm: tfm}
+	wrappedCB := wrappedWatchArgs{WatchArgs: args, ctx: ctx, tfm: tfm}
 	innerTyp := dials.NewType(transformedVal)
 	srcWatchErr := t.src.Watch(ctx, innerTyp, &wrappedCB)
 	if srcWatchErr != nil {
@@ -0,0 +1,470 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownSource is returned when a source that isn't one of a Dials
+// instance's sources is passed to a method like [Dials.DisableSource].
+var ErrUnknownSource = errors.New("unknown source")
+
+// DisableSource stops the source s from contributing to the configuration, as
+// if it set no fields, and installs a configuration composed from the other
+// sources, e.g. to shut out a remote source that's pushing bad values during
+// an incident. A disabled watching source keeps watching, so once it's
+// re-enabled with EnableSource, its latest value is used.
+//
+// The new version is verified (unless verification is delayed) and offered to
+// AcceptConfig (if set) like an update from a watching source, and
+// DisableSource returns once it's installed, or with the error that
+// prevented it (in which case s isn't disabled). Disabling a disabled source
+// installs nothing.
+// Returns ErrUnknownSource if s isn't one of the sources.
+func (d *Dials[T]) DisableSource(ctx context.Context, s Source) (*T, CfgSerial[T], error) {
+	return d.setSourceDisabled(ctx, s, true)
+}
+
+// EnableSource re-enables a source disabled by DisableSource, installing a
+// configuration composed with its latest value, like DisableSource.
+// Enabling a source that isn't disabled installs nothing. Returns
+// ErrUnknownSource if s isn't one of the sources.
+func (d *Dials[T]) EnableSource(ctx context.Context, s Source) (*T, CfgSerial[T], error) {
+	return d.setSourceDisabled(ctx, s, false)
+}
+
+func (d *Dials[T]) setSourceDisabled(ctx context.Context, s Source, disabled bool) (*T, CfgSerial[T], error) {
+	return d.editSources(ctx, []Source{s}, func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+		i := sourceIndex(sourceValues, s)
+		if i < 0 {
+			return nil, false, fmt.Errorf("%w of type %T", ErrUnknownSource, s)
+		}
+		if sourceValues[i].disabled == disabled {
+			return sourceValues, false, nil
+		}
+		sourceValues[i].disabled = disabled
+		return sourceValues, true, nil
+	})
+}
+
+// AddSource adds s to a live Dials instance, as the source with the highest
+// precedence (see ReorderSources), e.g. for plugins loaded after Config was
+// called. It calls s's Value method, installs a configuration composed with
+// its value (verified, and offered to AcceptConfig, like DisableSource), and
+// then starts watching s if it's a [Watcher]. Returns an error if s is
+// already one of the sources, or the new version can't be installed, in
+// which case s isn't added.
+//
+// Watching sources can only be added if some of the sources passed to Config
+// were watching (e.g. a [github.com/vimeo/dials/sourcewrap.Blank]), as
+// their updates are installed by the same goroutine.
+func (d *Dials[T]) AddSource(ctx context.Context, s Source) (*T, CfgSerial[T], error) {
+	if d.closer.isClosing() {
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	if d.sources.contains(s) {
+		return nil, CfgSerial[T]{}, fmt.Errorf("source of type %T is already one of the sources", s)
+	}
+	w, isWatcher := s.(Watcher)
+	if isWatcher && d.versionCtl == nil {
+		return nil, CfgSerial[T]{}, fmt.Errorf(
+			"can't add watching source of type %T: none of the sources passed to Config were watching", s)
+	}
+	if isWatcher && d.monitorExited() {
+		return nil, CfgSerial[T]{}, fmt.Errorf(
+			"can't add watching source of type %T: every watching source has stopped watching", s)
+	}
+
+	valueCtx := context.WithValue(ctx, warningSinkCtxKey{}, d.warnings)
+	v, err := tracedValue(valueCtx, d.params.Tracer, s, d.typ, d.params.SlowSourceThreshold)
+	if err != nil {
+		d.params.Metrics.SourceError(s, err)
+		return nil, CfgSerial[T]{}, &ConfigErrors{Errors: sourceErrors(s, err)}
+	}
+	status := newSourceStatus(s)
+	status.recordUpdate()
+	cfg, tok, err := d.editSources(ctx, []Source{s}, func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+		if sourceIndex(sourceValues, s) >= 0 {
+			return nil, false, fmt.Errorf("source of type %T is already one of the sources", s)
+		}
+		return append(sourceValues, sourceValue{source: s, value: v, watching: isWatcher}), true, nil
+	})
+	if err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	d.sources.add(s, status)
+	if !isWatcher {
+		return cfg, tok, nil
+	}
+
+	// start watching once s is among the monitor goroutine's sources, so
+	// its updates aren't discarded
+	sw := newWatch(d.sources.watchCtx)
+	if err := w.Watch(sw.ctx, d.typ, d.params.newWatchArgs(d.sources.watcherChan, s, status, sw)); err != nil {
+		sw.stop()
+		d.params.Metrics.SourceError(s, err)
+		watchErr := fmt.Errorf("failed to watch source of type %T: %w", s, err)
+		if _, _, rmErr := d.RemoveSource(ctx, s); rmErr != nil {
+			return nil, CfgSerial[T]{}, fmt.Errorf("%w (and failed to remove it: %s)", watchErr, rmErr)
+		}
+		return nil, CfgSerial[T]{}, watchErr
+	}
+	stoppable, isStoppable := w.(StoppableWatcher)
+	if !d.sources.setWatch(s, sw) {
+		// Close was called while s was being added, and didn't see its
+		// watch
+		sw.stop()
+		if isStoppable {
+			if err := stoppable.StopWatch(ctx); err != nil {
+				return nil, CfgSerial[T]{}, fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+			}
+		}
+		if err := sw.wait(ctx); err != nil {
+			return nil, CfgSerial[T]{}, fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+		}
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	status.setWatching(true)
+	d.params.Logger.Debug("dials: watching source", "source", sourceName(s))
+	if isStoppable && !d.closer.addStoppable(stoppable) {
+		// Close was called while s was being added
+		if err := stoppable.StopWatch(ctx); err != nil {
+			return nil, CfgSerial[T]{}, fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+		}
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	return cfg, tok, nil
+}
+
+// RemoveSource removes s from a live Dials instance, installing a
+// configuration composed from the other sources (like DisableSource), and
+// then stops watching s (if it's watching) by canceling the context passed
+// to its Watch method, calling StopWatch if it's a [StoppableWatcher], and
+// waiting for the goroutines it started with [WatchGo] to exit.
+// Values and errors s reports after it's removed are ignored. Returns
+// ErrUnknownSource if s isn't one of the sources. Members of
+// [Params].UpdateGroups can't be removed.
+//
+// If the new version can't be installed, RemoveSource returns the error and s
+// remains one of the sources. If stopping its watch fails, s is removed
+// regardless.
+func (d *Dials[T]) RemoveSource(ctx context.Context, s Source) (*T, CfgSerial[T], error) {
+	for _, g := range d.params.UpdateGroups {
+		if containsSource(g, s) {
+			return nil, CfgSerial[T]{}, fmt.Errorf("can't remove source of type %T, which is in an update group", s)
+		}
+	}
+	cfg, tok, err := d.editSources(ctx, []Source{s}, func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+		i := sourceIndex(sourceValues, s)
+		if i < 0 {
+			return nil, false, fmt.Errorf("%w of type %T", ErrUnknownSource, s)
+		}
+		return append(sourceValues[:i], sourceValues[i+1:]...), true, nil
+	})
+	if err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	status, w := d.sources.remove(s)
+	if status != nil {
+		status.setWatching(false)
+	}
+	if w == nil {
+		return cfg, tok, nil
+	}
+	w.stop()
+	if sw, ok := s.(StoppableWatcher); ok && d.closer.removeStoppable(s) {
+		if err := sw.StopWatch(ctx); err != nil {
+			return nil, CfgSerial[T]{}, fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+		}
+	}
+	if err := w.wait(ctx); err != nil {
+		return nil, CfgSerial[T]{}, fmt.Errorf("failed to stop watching source of type %T: %w", s, err)
+	}
+	d.params.Logger.Debug("dials: source removed", "source", sourceName(s))
+	return cfg, tok, nil
+}
+
+// ReorderSources changes the precedence of the sources of a live Dials
+// instance to that of order, which must contain each of the sources (see
+// SourceStatus) exactly once, with later sources taking precedence over
+// earlier ones (as with Config), and installs a configuration composed in
+// the new order (like DisableSource). For instance, during an incident, an
+// emergency-override source can be moved to the end of the order so it
+// takes precedence over everything else. Reordering the sources into their
+// current order installs nothing.
+func (d *Dials[T]) ReorderSources(ctx context.Context, order ...Source) (*T, CfgSerial[T], error) {
+	cfg, tok, err := d.editSources(ctx, order, func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+		if len(order) != len(sourceValues) {
+			return nil, false, fmt.Errorf("can't reorder %d sources into %d", len(sourceValues), len(order))
+		}
+		reordered := make([]sourceValue, len(order))
+		changed := false
+		for i, s := range order {
+			j := sourceIndex(sourceValues, s)
+			if j < 0 {
+				return nil, false, fmt.Errorf("%w of type %T", ErrUnknownSource, s)
+			}
+			if containsSource(order[:i], s) {
+				return nil, false, fmt.Errorf("source of type %T appears more than once", s)
+			}
+			reordered[i] = sourceValues[j]
+			changed = changed || i != j
+		}
+		return reordered, changed, nil
+	})
+	if err != nil {
+		return nil, CfgSerial[T]{}, err
+	}
+	d.sources.reorder(order)
+	return cfg, tok, nil
+}
+
+// editSources installs a VersionSourcesChanged version composed from the
+// source values edited by edit, which is passed a copy of the current values
+// it may modify, and returns the edited values (or an error), and whether
+// they changed (otherwise nothing is installed). triggers lists the sources
+// the edit concerns. If the version can't be installed, the edit is undone.
+func (d *Dials[T]) editSources(
+	ctx context.Context,
+	triggers []Source,
+	edit func(sourceValues []sourceValue) ([]sourceValue, bool, error),
+) (*T, CfgSerial[T], error) {
+	if d.closer.isClosing() {
+		return nil, CfgSerial[T]{}, ErrClosed
+	}
+	if d.versionCtl == nil || d.monitorExited() {
+		// restackUnwatched only keeps the edit if it installs a version
+		return d.restackUnwatched(ctx, VersionSourcesChanged, triggers, edit)
+	}
+	// before is set by the monitor goroutine, before it reports on
+	// installed
+	var before []sourceValue
+	req := versionReq[T]{
+		kind: versionEditSources, cause: VersionSourcesChanged, triggers: triggers,
+		edit: func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+			before = snapshotSources(sourceValues)
+			return edit(sourceValues)
+		},
+	}
+	if err := d.awaitEdit(ctx, req); err != nil {
+		if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+			return nil, CfgSerial[T]{}, err
+		}
+		if before != nil {
+			undo := versionReq[T]{
+				kind: versionEditSources, noInstall: true,
+				edit: func(sourceValues []sourceValue) ([]sourceValue, bool, error) {
+					return restoreLayout(sourceValues, before), true, nil
+				},
+			}
+			if undoErr := d.awaitEdit(ctx, undo); undoErr != nil {
+				return nil, CfgSerial[T]{}, fmt.Errorf("%w (and failed to undo the change: %s)", err, undoErr)
+			}
+		}
+		return nil, CfgSerial[T]{}, err
+	}
+	cfg, tok := d.ViewVersion()
+	return cfg, tok, nil
+}
+
+// awaitEdit sends the versionEditSources request req to the monitor
+// goroutine, and waits for the result of installing the version.
+func (d *Dials[T]) awaitEdit(ctx context.Context, req versionReq[T]) error {
+	// the monitor goroutine reports the result (or nil if nothing changed)
+	installed := make(chan error, 1)
+	req.installed = installed
+	if _, _, err := d.versionRequest(ctx, req); err != nil {
+		if errors.Is(err, errMonitorExited) {
+			return d.editUnwatched(ctx, req)
+		}
+		return err
+	}
+	select {
+	case err := <-installed:
+		return err
+	case <-d.closer.done:
+		// the monitor may have installed the version before exiting
+		select {
+		case err := <-installed:
+			return err
+		default:
+		}
+		if d.closer.isClosing() {
+			return ErrClosed
+		}
+		return d.editUnwatched(ctx, req)
+	case <-ctx.Done():
+		return fmt.Errorf("context expired while awaiting installation: %w", ctx.Err())
+	}
+}
+
+// editUnwatched applies the versionEditSources request req once the monitor
+// goroutine has exited (see monitorExited). As restackUnwatched only keeps
+// edits that install a version, there's nothing to undo, so requests with
+// noInstall set are no-ops.
+func (d *Dials[T]) editUnwatched(ctx context.Context, req versionReq[T]) error {
+	if req.noInstall {
+		return nil
+	}
+	_, _, err := d.restackUnwatched(ctx, req.cause, req.triggers, req.edit)
+	return err
+}
+
+// restoreLayout returns the sources in before, with the latest values (and
+// watching states) of those still in sourceValues, undoing an edit that
+// added, removed, disabled or moved sources.
+func restoreLayout(sourceValues, before []sourceValue) []sourceValue {
+	out := make([]sourceValue, len(before))
+	for i, sv := range before {
+		if j := sourceIndex(sourceValues, sv.source); j >= 0 {
+			sv.value, sv.watching = sourceValues[j].value, sourceValues[j].watching
+		}
+		out[i] = sv
+	}
+	return out
+}
+
+// editUpdates returns updates recording triggers (with their values from
+// sourceValues, if present) as the sources that triggered a version, the
+// first of which is notified on installed.
+func editUpdates(sourceValues []sourceValue, triggers []Source, installed chan<- error) []*valueUpdate {
+	updates := make([]*valueUpdate, len(triggers))
+	for i, s := range triggers {
+		u := &valueUpdate{source: s}
+		if j := sourceIndex(sourceValues, s); j >= 0 {
+			u.value = sourceValues[j].value
+		}
+		updates[i] = u
+	}
+	if len(updates) > 0 {
+		updates[0].installed = installed
+	} else {
+		installed <- nil
+	}
+	return updates
+}
+
+// sourceIndex returns the index of s in sourceValues, or -1.
+func sourceIndex(sourceValues []sourceValue, s Source) int {
+	for i, sv := range sourceValues {
+		if sv.source == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// sourceSet holds the sources of a Dials instance, in order of precedence,
+// which AddSource and RemoveSource modify.
+type sourceSet struct {
+	mu       sync.Mutex
+	sources  []Source
+	statuses []*sourceStatus
+	// watches contains the watches of the watching sources. Once closed
+	// is set (by Close), no more are added.
+	watches map[Source]*watch
+	closed  bool
+
+	// watchCtx and watcherChan are passed to the watching sources added
+	// by AddSource. They're nil without a monitor goroutine, and aren't
+	// modified once it's started.
+	watchCtx    context.Context
+	watcherChan chan watchStatusUpdate
+}
+
+func newSourceSet(sources []Source) *sourceSet {
+	statuses := make([]*sourceStatus, len(sources))
+	for i, s := range sources {
+		statuses[i] = newSourceStatus(s)
+	}
+	return &sourceSet{
+		sources:  append([]Source(nil), sources...),
+		statuses: statuses,
+		watches:  map[Source]*watch{},
+	}
+}
+
+// snapshot returns the sources and their statuses.
+func (s *sourceSet) snapshot() ([]Source, []*sourceStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sources, s.statuses
+}
+
+func (s *sourceSet) contains(src Source) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return containsSource(s.sources, src)
+}
+
+func (s *sourceSet) add(src Source, status *sourceStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the slices are replaced rather than modified, as snapshots share them
+	s.sources = append(s.sources[:len(s.sources):len(s.sources)], src)
+	s.statuses = append(s.statuses[:len(s.statuses):len(s.statuses)], status)
+}
+
+// remove removes src, returning its status and its watch (if any).
+func (s *sourceSet) remove(src Source) (*sourceStatus, *watch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.watches[src]
+	delete(s.watches, src)
+	for i, existing := range s.sources {
+		if existing == src {
+			status := s.statuses[i]
+			s.sources = append(s.sources[:i:i], s.sources[i+1:]...)
+			s.statuses = append(s.statuses[:i:i], s.statuses[i+1:]...)
+			return status, w
+		}
+	}
+	return nil, w
+}
+
+// setWatch records the watch of src, returning false if the watches have
+// been closed.
+func (s *sourceSet) setWatch(src Source, w *watch) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.watches[src] = w
+	return true
+}
+
+// closeWatches stops every watch, and returns them so their goroutines can
+// be awaited. setWatch fails afterwards.
+func (s *sourceSet) closeWatches() []*watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	watches := make([]*watch, 0, len(s.watches))
+	for _, w := range s.watches {
+		w.stop()
+		watches = append(watches, w)
+	}
+	return watches
+}
+
+// reorder puts the sources in the order of order, which contains the same
+// sources.
+func (s *sourceSet) reorder(order []Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sources := make([]Source, 0, len(order))
+	statuses := make([]*sourceStatus, 0, len(order))
+	for _, src := range order {
+		for i, existing := range s.sources {
+			if existing == src {
+				sources = append(sources, src)
+				statuses = append(statuses, s.statuses[i])
+				break
+			}
+		}
+	}
+	s.sources, s.statuses = sources, statuses
+}
@@ -0,0 +1,293 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisableSource(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+		Bar string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+		Bar *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	static := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("static"), Bar: strPtr("static")}}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{Foo: strPtr("remote")}}}
+	d, err := Config(ctx, &testConfig{}, &static, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, &testConfig{Foo: "remote", Bar: "static"}, d.View())
+
+	cfg, tok, err := d.DisableSource(ctx, &w)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Foo: "static", Bar: "static"}, cfg)
+	assert.Equal(t, uint64(1), tok.Generation())
+	assert.Equal(t, &static, d.Provenance()["Foo"])
+	hist := d.History()
+	assert.Equal(t, VersionSourcesChanged, hist[len(hist)-1].Cause)
+	assert.Equal(t, []Source{&w}, hist[len(hist)-1].Sources)
+
+	// disabling it again is a no-op
+	_, tok, err = d.DisableSource(ctx, &w)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), tok.Generation())
+
+	// the disabled source's new values are retained, but not used
+	require.NoError(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("fixed")}).Convert(w.t.t)))
+	assert.Equal(t, &testConfig{Foo: "static", Bar: "static"}, d.View())
+
+	cfg, _, err = d.EnableSource(ctx, &w)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Foo: "fixed", Bar: "static"}, cfg)
+	assert.Equal(t, &w, d.Provenance()["Foo"])
+
+	_, _, err = d.DisableSource(ctx, &fakeSource{})
+	assert.ErrorIs(t, err, ErrUnknownSource)
+}
+
+type verifiedFooConfig struct {
+	Foo string
+}
+
+func (v *verifiedFooConfig) Verify() error {
+	if v.Foo == "" {
+		return errors.New("foo must be set")
+	}
+	return nil
+}
+
+func TestDisableSourceUnwatched(t *testing.T) {
+	t.Parallel()
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx := context.Background()
+	base := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("base")}}
+	override := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("override")}}
+	d, err := Config(ctx, &verifiedFooConfig{}, &base, &override)
+	require.NoError(t, err)
+	assert.Equal(t, "override", d.View().Foo)
+
+	cfg, _, err := d.DisableSource(ctx, &override)
+	require.NoError(t, err)
+	assert.Equal(t, "base", cfg.Foo)
+
+	// refreshing keeps the source disabled
+	override.outVal = ptrifiedConfig{Foo: strPtr("refreshed")}
+	cfg, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "base", cfg.Foo)
+
+	// disabling every source that sets Foo fails verification
+	_, _, err = d.DisableSource(ctx, &base)
+	assert.ErrorContains(t, err, "foo must be set")
+	assert.Equal(t, "base", d.View().Foo)
+
+	cfg, tok, err := d.EnableSource(ctx, &override)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", cfg.Foo)
+	assert.Equal(t, uint64(3), tok.Generation())
+}
+
+func TestAddRemoveSource(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+		Bar string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+		Bar *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	base := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{Foo: strPtr("base")}}}
+	d, err := Config(ctx, &testConfig{}, &base)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	plugin := fakeSource{outVal: ptrifiedConfig{Bar: strPtr("plugin")}}
+	cfg, _, err := d.AddSource(ctx, &plugin)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Foo: "base", Bar: "plugin"}, cfg)
+	require.Len(t, d.SourceStatus(), 2)
+	assert.Equal(t, &plugin, d.SourceStatus()[1].Source)
+
+	_, _, err = d.AddSource(ctx, &plugin)
+	assert.ErrorContains(t, err, "already one of the sources")
+
+	// added watching sources are watched, with the highest precedence
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	_, _, err = d.AddSource(ctx, &w)
+	require.NoError(t, err)
+	assert.True(t, d.SourceStatus()[2].Connected)
+	require.NoError(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("watched")}).Convert(w.t.t)))
+	assert.Equal(t, &testConfig{Foo: "watched", Bar: "plugin"}, d.View())
+	hist := d.History()
+	assert.Equal(t, VersionUpdate, hist[len(hist)-1].Cause)
+
+	// a Refresh includes the added sources
+	plugin.outVal = ptrifiedConfig{Bar: strPtr("refreshed")}
+	cfg, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", cfg.Bar)
+
+	cfg, _, err = d.RemoveSource(ctx, &w)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Foo: "base", Bar: "refreshed"}, cfg)
+	assert.Len(t, d.SourceStatus(), 2)
+	hist = d.History()
+	assert.Equal(t, VersionSourcesChanged, hist[len(hist)-1].Cause)
+	assert.Equal(t, []Source{&w}, hist[len(hist)-1].Sources)
+
+	// values from removed sources are ignored
+	assert.ErrorIs(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("ignored")}).Convert(w.t.t)), ErrUnknownSource)
+	assert.Equal(t, "base", d.View().Foo)
+
+	_, _, err = d.RemoveSource(ctx, &w)
+	assert.ErrorIs(t, err, ErrUnknownSource)
+
+	sw := stoppableWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	_, _, err = d.AddSource(ctx, &sw)
+	require.NoError(t, err)
+	_, _, err = d.RemoveSource(ctx, &sw)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sw.stopped)
+	require.NoError(t, d.Close(ctx))
+	assert.Equal(t, 1, sw.stopped)
+}
+
+func TestAddSourceVerificationFailure(t *testing.T) {
+	t.Parallel()
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{Foo: strPtr("base")}}}
+	d, err := Config(ctx, &verifiedFooConfig{}, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	bad := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("")}}
+	_, _, err = d.AddSource(ctx, &bad)
+	assert.ErrorContains(t, err, "foo must be set")
+	assert.Len(t, d.SourceStatus(), 1)
+
+	// the failed addition was undone, so later updates don't include it
+	require.NoError(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("updated")}).Convert(w.t.t)))
+	assert.Equal(t, "updated", d.View().Foo)
+
+	// the same goes for a failed removal
+	_, _, err = d.RemoveSource(ctx, &w)
+	assert.ErrorContains(t, err, "foo must be set")
+	require.NoError(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("again")}).Convert(w.t.t)))
+	assert.Equal(t, "again", d.View().Foo)
+}
+
+func TestAddSourceUnwatched(t *testing.T) {
+	t.Parallel()
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx := context.Background()
+	base := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("base")}}
+	d, err := Config(ctx, &verifiedFooConfig{}, &base)
+	require.NoError(t, err)
+
+	override := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("override")}}
+	cfg, _, err := d.AddSource(ctx, &override)
+	require.NoError(t, err)
+	assert.Equal(t, "override", cfg.Foo)
+
+	_, _, err = d.AddSource(ctx, &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}})
+	assert.ErrorContains(t, err, "none of the sources passed to Config were watching")
+
+	cfg, _, err = d.RemoveSource(ctx, &base)
+	require.NoError(t, err)
+	assert.Equal(t, "override", cfg.Foo)
+	_, _, err = d.RemoveSource(ctx, &override)
+	assert.ErrorContains(t, err, "foo must be set")
+	assert.Equal(t, &override, d.SourceStatus()[0].Source)
+}
+
+func TestReorderSources(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+		Bar string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+		Bar *string
+	}
+	strPtr := func(s string) *string { return &s }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	emergency := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("emergency")}}
+	base := fakeSource{outVal: ptrifiedConfig{Foo: strPtr("base"), Bar: strPtr("base")}}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{Foo: strPtr("remote")}}}
+	d, err := Config(ctx, &testConfig{}, &emergency, &base, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, &testConfig{Foo: "remote", Bar: "base"}, d.View())
+
+	cfg, tok, err := d.ReorderSources(ctx, &base, &w, &emergency)
+	require.NoError(t, err)
+	assert.Equal(t, &testConfig{Foo: "emergency", Bar: "base"}, cfg)
+	assert.Equal(t, uint64(1), tok.Generation())
+	assert.Equal(t, &emergency, d.Provenance()["Foo"])
+	statuses := d.SourceStatus()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, []Source{&base, &w, &emergency},
+		[]Source{statuses[0].Source, statuses[1].Source, statuses[2].Source})
+
+	// updates are composed in the new order
+	require.NoError(t, w.args.BlockingReportNewValue(ctx,
+		reflect.ValueOf(ptrifiedConfig{Foo: strPtr("bad"), Bar: strPtr("remote")}).Convert(w.t.t)))
+	assert.Equal(t, &testConfig{Foo: "emergency", Bar: "remote"}, d.View())
+
+	// reordering into the current order is a no-op
+	_, tok, err = d.ReorderSources(ctx, &base, &w, &emergency)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), tok.Generation())
+
+	_, _, err = d.ReorderSources(ctx, &base, &w)
+	assert.ErrorContains(t, err, "can't reorder 3 sources into 2")
+	_, _, err = d.ReorderSources(ctx, &base, &w, &w)
+	assert.ErrorContains(t, err, "more than once")
+	_, _, err = d.ReorderSources(ctx, &base, &w, &fakeSource{})
+	assert.ErrorIs(t, err, ErrUnknownSource)
+}
@@ -0,0 +1,108 @@
+package dials
+
+import (
+	"sync"
+	"time"
+)
+
+// SourceStatus describes the health of one of the sources passed to Config,
+// as returned by [Dials.SourceStatus].
+type SourceStatus struct {
+	Source Source
+	// Watcher is true if the source implements [Watcher].
+	Watcher bool
+	// Watching is true if the source's Watch method succeeded, and it
+	// hasn't since reported that it's done (see [WatchArgs].Done), nor
+	// been stopped by [Dials.Close].
+	Watching bool
+	// Connected is true if the source is watching, and its latest report
+	// was a new value rather than an error (or it hasn't reported
+	// anything since it started watching).
+	Connected bool
+	// LastUpdate is when the latest value was obtained from the source,
+	// either from its Value method (in Config or Refresh), or reported
+	// by its watch.
+	LastUpdate time.Time
+	// LastError is the latest error returned or reported by the source
+	// (nil if there hasn't been one), and LastErrorTime is when it
+	// occurred.
+	LastError     error
+	LastErrorTime time.Time
+	// ReconnectAttempts counts the errors the source has reported since
+	// its latest value: for watchers that re-establish a lost watch, the
+	// number of failed attempts to do so.
+	ReconnectAttempts int
+	// Circuit is the state of the source's circuit-breaker (always
+	// CircuitClosed unless [Params].CircuitBreaker is enabled).
+	Circuit CircuitState
+}
+
+// Healthy returns false if the source is a watcher that has stopped watching
+// or whose latest report was an error, or if its latest fetch failed.
+func (s SourceStatus) Healthy() bool {
+	if s.Watcher && !s.Watching {
+		return false
+	}
+	return s.ReconnectAttempts == 0
+}
+
+// sourceStatus tracks the SourceStatus of a source. Its methods are called by
+// Config, Refresh, Close and the source's watchArgs (possibly concurrently).
+type sourceStatus struct {
+	mu sync.Mutex
+	st SourceStatus
+}
+
+func newSourceStatus(s Source) *sourceStatus {
+	_, isWatcher := s.(Watcher)
+	return &sourceStatus{st: SourceStatus{Source: s, Watcher: isWatcher}}
+}
+
+func (s *sourceStatus) recordUpdate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st.LastUpdate = time.Now()
+	s.st.ReconnectAttempts = 0
+	s.st.Connected = s.st.Watching
+}
+
+func (s *sourceStatus) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st.LastError = err
+	s.st.LastErrorTime = time.Now()
+	s.st.ReconnectAttempts++
+	s.st.Connected = false
+}
+
+func (s *sourceStatus) setWatching(watching bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st.Watching = watching
+	s.st.Connected = watching && s.st.ReconnectAttempts == 0
+}
+
+func (s *sourceStatus) setCircuit(state CircuitState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st.Circuit = state
+}
+
+func (s *sourceStatus) snapshot() SourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st
+}
+
+// SourceStatus returns the status of each of the sources (those passed to
+// Config, as modified by AddSource and RemoveSource) in order of precedence,
+// so readiness and health endpoints can detect a watch that has silently
+// died, or a source that keeps failing.
+func (d *Dials[T]) SourceStatus() []SourceStatus {
+	_, statuses := d.sources.snapshot()
+	out := make([]SourceStatus, len(statuses))
+	for i, s := range statuses {
+		out[i] = s.snapshot()
+	}
+	return out
+}
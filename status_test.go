@@ -0,0 +1,106 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceStatus(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	static := fakeSource{outVal: ptrifiedConfig{}}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Params[testConfig]{
+		CircuitBreaker: CircuitBreakerParams{Threshold: 2, CoolDown: time.Hour},
+	}.Config(ctx, &testConfig{Foo: "foo"}, &static, &w)
+	require.NoError(t, err)
+	defer d.Close(ctx)
+
+	statuses := d.SourceStatus()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, &static, statuses[0].Source)
+	assert.False(t, statuses[0].Watcher)
+	assert.False(t, statuses[0].Watching)
+	assert.False(t, statuses[0].Connected)
+	assert.False(t, statuses[0].LastUpdate.Before(start))
+	assert.True(t, statuses[0].Healthy())
+
+	assert.Equal(t, &w, statuses[1].Source)
+	assert.True(t, statuses[1].Watcher)
+	assert.True(t, statuses[1].Watching)
+	assert.True(t, statuses[1].Connected)
+	assert.NoError(t, statuses[1].LastError)
+	assert.True(t, statuses[1].Healthy())
+
+	backendErr := errors.New("backend unavailable")
+	assert.NoError(t, w.args.ReportError(ctx, backendErr))
+	st := d.SourceStatus()[1]
+	assert.False(t, st.Connected)
+	assert.True(t, st.Watching)
+	assert.ErrorIs(t, st.LastError, backendErr)
+	assert.False(t, st.LastErrorTime.Before(start))
+	assert.Equal(t, 1, st.ReconnectAttempts)
+	assert.Equal(t, CircuitClosed, st.Circuit)
+	assert.False(t, st.Healthy())
+
+	assert.Error(t, w.args.ReportError(ctx, backendErr))
+	st = d.SourceStatus()[1]
+	assert.Equal(t, 2, st.ReconnectAttempts)
+	assert.Equal(t, CircuitOpen, st.Circuit)
+
+	barStr := "bar"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &barStr}))
+	assert.Equal(t, "bar", (<-d.Events()).Foo)
+	st = d.SourceStatus()[1]
+	assert.True(t, st.Connected)
+	assert.Zero(t, st.ReconnectAttempts)
+	assert.Equal(t, CircuitClosed, st.Circuit)
+	assert.False(t, st.LastUpdate.Before(st.LastErrorTime))
+	// the latest error is retained after recovering
+	assert.ErrorIs(t, st.LastError, backendErr)
+	assert.True(t, st.Healthy())
+
+	// a watch that stops is unhealthy
+	w.args.Done(ctx)
+	st = d.SourceStatus()[1]
+	assert.False(t, st.Watching)
+	assert.False(t, st.Connected)
+	assert.False(t, st.Healthy())
+}
+
+func TestSourceStatusClose(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+
+	ctx := context.Background()
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{}, &w)
+	require.NoError(t, err)
+	assert.True(t, d.SourceStatus()[0].Connected)
+
+	require.NoError(t, d.Close(ctx))
+	st := d.SourceStatus()[0]
+	assert.False(t, st.Watching)
+	assert.False(t, st.Connected)
+}
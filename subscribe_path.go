@@ -0,0 +1,69 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SubscribePath registers the callback cb (like RegisterCallback) to receive
+// notifications only when a new configuration changes the value of the field
+// at path: the Go names of the fields leading to it, joined with dots (e.g.
+// "Server.TLS"). For a nested struct, that's whenever any field within it
+// changes, so consumers that only care about one block of the configuration
+// needn't compare the whole struct themselves.
+//
+// Returns an error if there's no field at path in T. If there are no watching
+// sources, callbacks are never called, so the returned UnregisterCBFunc is a
+// no-op.
+//
+// May return a nil [UnregisterCBFunc] (and nil error) if the context expires.
+func (d *Dials[T]) SubscribePath(ctx context.Context, path string, cb NewConfigHandler[T]) (UnregisterCBFunc, error) {
+	names := strings.Split(path, ".")
+	if err := checkFieldPath(reflect.TypeOf((*T)(nil)).Elem(), names); err != nil {
+		return nil, err
+	}
+	if d.cbch == nil {
+		return func(context.Context) bool { return true }, nil
+	}
+	_, serial := d.ViewVersion()
+	return d.RegisterCallback(ctx, serial, func(ctx context.Context, oldConfig, newConfig *T) {
+		if pathChanged(oldConfig, newConfig, names) {
+			cb(ctx, oldConfig, newConfig)
+		}
+	}), nil
+}
+
+// checkFieldPath returns an error if the struct type t has no field at the
+// path of field names.
+func checkFieldPath(t reflect.Type, names []string) error {
+	for i, name := range names {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("%s is not a struct", strings.Join(names[:i], "."))
+		}
+		sf, ok := t.FieldByName(name)
+		if !ok || !sf.IsExported() {
+			return fmt.Errorf("no field %q in %s", strings.Join(names[:i+1], "."), t)
+		}
+		t = sf.Type
+	}
+	return nil
+}
+
+// pathChanged returns true if the values of the field at the path of field
+// names differ between oldConfig and newConfig.
+func pathChanged[T any](oldConfig, newConfig *T, names []string) bool {
+	if oldConfig == nil {
+		return true
+	}
+	o := fieldByPath(reflect.ValueOf(oldConfig), names, false)
+	n := fieldByPath(reflect.ValueOf(newConfig), names, false)
+	if !o.IsValid() || !n.IsValid() {
+		return o.IsValid() != n.IsValid()
+	}
+	return !reflect.DeepEqual(o.Interface(), n.Interface())
+}
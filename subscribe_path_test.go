@@ -0,0 +1,68 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pathConfig struct {
+	Name   string
+	Server struct {
+		TLS struct {
+			Cert string
+		}
+	}
+}
+
+type ptrifiedPathConfig struct {
+	Name   *string
+	Server *struct {
+		TLS *struct {
+			Cert *string
+		}
+	}
+}
+
+func pathValue(name, cert string) reflect.Value {
+	v := ptrifiedPathConfig{Name: &name}
+	v.Server = &struct {
+		TLS *struct {
+			Cert *string
+		}
+	}{TLS: &struct{ Cert *string }{Cert: &cert}}
+	return reflect.ValueOf(v)
+}
+
+func TestSubscribePath(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: pathValue("a", "cert1").Interface()}}
+	d, err := Config(ctx, &pathConfig{}, &w)
+	require.NoError(t, err)
+
+	_, err = d.SubscribePath(ctx, "Server.Key", func(context.Context, *pathConfig, *pathConfig) {})
+	assert.EqualError(t, err, `no field "Server.Key" in struct { TLS struct { Cert string } }`)
+	_, err = d.SubscribePath(ctx, "Name.Length", func(context.Context, *pathConfig, *pathConfig) {})
+	assert.EqualError(t, err, "Name is not a struct")
+
+	type change struct{ old, new string }
+	changes := make(chan change, 4)
+	unreg, err := d.SubscribePath(ctx, "Server.TLS", func(_ context.Context, oldConfig, newConfig *pathConfig) {
+		changes <- change{old: oldConfig.Server.TLS.Cert, new: newConfig.Server.TLS.Cert}
+	})
+	require.NoError(t, err)
+	require.NotNil(t, unreg)
+
+	// a change outside the subscribed path isn't delivered
+	w.send(ctx, pathValue("b", "cert1"))
+	w.send(ctx, pathValue("b", "cert2"))
+	assert.Equal(t, change{old: "cert1", new: "cert2"}, <-changes)
+
+	assert.True(t, unreg(ctx))
+}
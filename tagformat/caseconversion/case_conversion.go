@@ -238,6 +238,20 @@ func DecodeKebabCase(s string) (DecodedIdentifier, error) {
 	return decodeLowerCaseWithSplitChar('-', "kebab-case", s)
 }
 
+// DecodeFlatCase decodes flatcase (e.g. "flatcase"), which has no word
+// boundaries, into a single lower-cased word
+func DecodeFlatCase(s string) (DecodedIdentifier, error) {
+	for z, char := range s {
+		if !unicode.IsLetter(char) && !(z > 0 && unicode.IsDigit(char)) {
+			return nil, fmt.Errorf("converting case of %q: only letters and (after the first character) digits can appear in flatcase strings: %c at byte-offset %d does not comply", s, char, z)
+		}
+	}
+	if s == "" {
+		return DecodedIdentifier{}, nil
+	}
+	return DecodedIdentifier{strings.ToLower(s)}, nil
+}
+
 // DecodeUpperSnakeCase decodes UPPER_SNAKE_CASE (sometimes called
 // SCREAMING_SNAKE_CASE) into a slice of lower-cased sub-strings
 func DecodeUpperSnakeCase(s string) (DecodedIdentifier, error) {
@@ -368,3 +382,9 @@ func EncodeUpperSnakeCase(words DecodedIdentifier) string {
 func EncodeCasePreservingSnakeCase(words DecodedIdentifier) string {
 	return strings.Join(words, "_")
 }
+
+// EncodeFlatCase encodes a slice of words into flatcase (lower-cased words
+// without separators)
+func EncodeFlatCase(words DecodedIdentifier) string {
+	return strings.ToLower(strings.Join(words, ""))
+}
@@ -32,6 +32,10 @@ var decodeCases = []struct {
 	{"kebab1-case-string", []string{"kebab1", "case", "string"}, DecodeKebabCase, false},
 	{"kebab1-case-string-", []string{"kebab1", "case", "string"}, DecodeKebabCase, false},
 	{"kebab-case-string-u", []string{"kebab", "case", "string", "u"}, DecodeKebabCase, false},
+	{"flatcase", []string{"flatcase"}, DecodeFlatCase, false},
+	{"FlatCase1", []string{"flatcase1"}, DecodeFlatCase, false},
+	{"1flatcase", []string{}, DecodeFlatCase, true},
+	{"flat_case", []string{}, DecodeFlatCase, true},
 
 	{"UPPER_SNAKE_CASE", []string{"upper", "snake", "case"}, DecodeUpperSnakeCase, false},
 	{"1UPPER_SNAKE_CASE", []string{}, DecodeUpperSnakeCase, true},
@@ -99,6 +103,8 @@ var encodeCases = []struct {
 	{[]string{}, "", EncodeLowerCamelCase},
 	{[]string{"kebab", "case", "string"}, "kebab-case-string", EncodeKebabCase},
 	{[]string{}, "", EncodeKebabCase},
+	{[]string{"flat", "case", "string"}, "flatcasestring", EncodeFlatCase},
+	{[]string{"json", "API"}, "jsonapi", EncodeFlatCase},
 	{[]string{"loweR", "SNAKE", "Case"}, "lower_snake_case", EncodeLowerSnakeCase},
 	{[]string{}, "", EncodeLowerSnakeCase},
 	{[]string{"upper", "snake", "case"}, "UPPER_SNAKE_CASE", EncodeUpperSnakeCase},
@@ -0,0 +1,29 @@
+package caseconversion
+
+// Casing pairs the functions that decode and encode identifiers in a case
+// convention, for APIs that convert from one convention to another. Custom
+// conventions can be used by constructing a Casing with other functions
+// (e.g. Casing{Decode: DecodeGoCamelCase, Encode: myEncodeFunc}).
+type Casing struct {
+	Decode DecodeCasingFunc
+	Encode EncodeCasingFunc
+}
+
+// The built-in case conventions.
+var (
+	// GoCamelCase decodes Go identifiers (camelCase with initialisms, like
+	// "JSONAPIDocs") and encodes UpperCamelCase.
+	GoCamelCase = Casing{Decode: DecodeGoCamelCase, Encode: EncodeUpperCamelCase}
+	// GoTags decodes the mix of cases found in Go struct tags (see
+	// DecodeGoTags) and encodes UpperCamelCase.
+	GoTags                  = Casing{Decode: DecodeGoTags, Encode: EncodeUpperCamelCase}
+	UpperCamelCase          = Casing{Decode: DecodeUpperCamelCase, Encode: EncodeUpperCamelCase}
+	LowerCamelCase          = Casing{Decode: DecodeLowerCamelCase, Encode: EncodeLowerCamelCase}
+	LowerSnakeCase          = Casing{Decode: DecodeLowerSnakeCase, Encode: EncodeLowerSnakeCase}
+	UpperSnakeCase          = Casing{Decode: DecodeUpperSnakeCase, Encode: EncodeUpperSnakeCase}
+	CasePreservingSnakeCase = Casing{Decode: DecodeCasePreservingSnakeCase, Encode: EncodeCasePreservingSnakeCase}
+	KebabCase               = Casing{Decode: DecodeKebabCase, Encode: EncodeKebabCase}
+	// FlatCase can only decode a single word, since flatcase has no word
+	// boundaries.
+	FlatCase = Casing{Decode: DecodeFlatCase, Encode: EncodeFlatCase}
+)
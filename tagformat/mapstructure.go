@@ -0,0 +1,101 @@
+package tagformat
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/sourcewrap"
+	"github.com/vimeo/dials/transform"
+)
+
+// MapstructureTagName is the name of the tag used by
+// github.com/mitchellh/mapstructure (and so by viper and koanf).
+const MapstructureTagName = "mapstructure"
+
+// MapstructureMangler implements the transform.Mangler interface, so structs
+// annotated for mapstructure (e.g. because they're shared with code that
+// reads them with viper) name their fields the same way with dials:
+//   - a field with a name in its `mapstructure` tag, but no `dials` tag, gets
+//     a `dials` tag with that name (a name of "-" is copied too, so ignored
+//     fields stay ignored)
+//   - an embedded struct tagged with the ",squash" option, but no
+//     `dialsembed` tag, gets `dialsembed:"inline"`, so its fields are
+//     promoted
+//
+// Existing `dials` and `dialsembed` tags take precedence.
+type MapstructureMangler struct{}
+
+// Mangle adds the `dials` and `dialsembed` tags derived from the field's
+// `mapstructure` tag.
+func (m *MapstructureMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	msTag, ok := sf.Tag.Lookup(MapstructureTagName)
+	if !ok {
+		return []reflect.StructField{sf}, nil
+	}
+
+	newTags := string(sf.Tag)
+	add := func(tag, val string) {
+		if _, ok := sf.Tag.Lookup(tag); ok {
+			return
+		}
+		if len(newTags) > 0 {
+			newTags += " "
+		}
+		newTags += tag + ":" + strconv.Quote(val)
+	}
+	if name := tagName(msTag); name != "" {
+		add(common.DialsTagName, name)
+	}
+	if sf.Anonymous && hasOption(msTag, "squash") {
+		add(common.EmbedTagName, "inline")
+	}
+	sf.Tag = reflect.StructTag(newTags)
+
+	return []reflect.StructField{sf}, nil
+}
+
+// hasOption reports whether the tag value tagVal includes the option opt
+// after its name.
+func hasOption(tagVal, opt string) bool {
+	opts := strings.Split(tagVal, ",")
+	for _, o := range opts[1:] {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// Unmangle is called for every source-field->mangled-field
+// mapping-set, with the mangled-field and its populated value set.
+// This just returns the first field, as Mangle only returns one field at a
+// time.
+func (m *MapstructureMangler) Unmangle(sf reflect.StructField, vs []transform.FieldValueTuple) (reflect.Value, error) {
+	// we always return exactly one field in Mangle, so we can always
+	// return vs[0].Value (after a type conversion) without any issues
+	if vs[0].Value.Kind() == reflect.Struct {
+		return vs[0].Value.Convert(sf.Type), nil
+	}
+	return vs[0].Value, nil
+}
+
+// ShouldRecurse always returns true so the tags of nested struct fields are
+// converted too.
+func (m *MapstructureMangler) ShouldRecurse(_ reflect.StructField) bool {
+	return true
+}
+
+// MapstructureSource wraps inner so it honors `mapstructure` tags on the
+// config type (see MapstructureMangler).
+func MapstructureSource(inner dials.Source) dials.Source {
+	return sourcewrap.NewTransformingSource(inner, &MapstructureMangler{})
+}
+
+// MapstructureDecoder wraps dec so it honors `mapstructure` tags on the
+// config type (see MapstructureMangler), e.g. for use with the file source.
+func MapstructureDecoder(dec dials.Decoder) dials.Decoder {
+	return sourcewrap.NewTransformingDecoder(dec, &MapstructureMangler{})
+}
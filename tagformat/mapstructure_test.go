@@ -0,0 +1,49 @@
+package tagformat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/transform"
+)
+
+type MapstructureCommon struct {
+	Region string `mapstructure:"region"`
+}
+
+type mapstructureConfig struct {
+	MapstructureCommon `mapstructure:",squash"`
+	Name               string `mapstructure:"service_name"`
+	Port               int    `mapstructure:"port,omitempty" dials:"listen_port"`
+	Ignored            string `mapstructure:"-"`
+	Database           struct {
+		MaxConns int `mapstructure:"max_conns"`
+	} `mapstructure:"db"`
+}
+
+func TestMapstructureMangler(t *testing.T) {
+	t.Parallel()
+	cfg := ptrify.Pointerify(reflect.TypeOf(mapstructureConfig{}), reflect.ValueOf(mapstructureConfig{}))
+	tfm := transform.NewTransformer(cfg, &MapstructureMangler{})
+
+	mangledVal, err := tfm.Translate()
+	require.NoError(t, err)
+	mangledType := mangledVal.Type()
+	for field, expected := range map[string]reflect.StructTag{
+		"MapstructureCommon": `mapstructure:",squash" dialsembed:"inline"`,
+		"Name":               `mapstructure:"service_name" dials:"service_name"`,
+		"Port":               `mapstructure:"port,omitempty" dials:"listen_port"`,
+		"Ignored":            `mapstructure:"-" dials:"-"`,
+		"Database":           `mapstructure:"db" dials:"db"`,
+	} {
+		sf, ok := mangledType.FieldByName(field)
+		require.True(t, ok)
+		assert.Equal(t, expected, sf.Tag, field)
+	}
+
+	_, err = tfm.ReverseTranslate(mangledVal)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,99 @@
+package tagformat
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/sourcewrap"
+	"github.com/vimeo/dials/transform"
+)
+
+// TagPropagatingMangler implements the transform.Mangler interface, deriving
+// each of the tags in Tags that a field lacks from the first of them that it
+// has. That way, a struct annotated with only `json` tags can be used with
+// sources that read `yaml`, `toml` or `dialsenv` tags (and vice versa)
+// without repeating every name.
+//
+// Only the name is copied: options following a comma (such as
+// ",omitempty") aren't, since they differ between formats, and tags whose
+// name is empty aren't used as a source. A name of "-" is copied too, so a
+// field ignored in one format is ignored in all of them. Names are copied
+// unchanged; to derive names for environment variables in
+// SCREAMING_SNAKE_CASE, propagate to the `dials` tag instead of `dialsenv`,
+// as the env source reformats `dials` tags.
+type TagPropagatingMangler struct {
+	Tags []string
+}
+
+// NewTagPropagatingMangler constructs a TagPropagatingMangler propagating
+// names between tags (e.g. "json", "yaml", "toml"). Earlier tags take
+// precedence as the source of the names.
+func NewTagPropagatingMangler(tags ...string) *TagPropagatingMangler {
+	return &TagPropagatingMangler{Tags: tags}
+}
+
+// Mangle adds the missing tags to the field.
+func (t *TagPropagatingMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	name := ""
+	for _, tag := range t.Tags {
+		if n := tagName(sf.Tag.Get(tag)); n != "" {
+			name = n
+			break
+		}
+	}
+	if name == "" {
+		return []reflect.StructField{sf}, nil
+	}
+
+	newTags := string(sf.Tag)
+	for _, tag := range t.Tags {
+		if _, ok := sf.Tag.Lookup(tag); ok {
+			continue
+		}
+		if len(newTags) > 0 {
+			newTags += " "
+		}
+		newTags += tag + ":" + strconv.Quote(name)
+	}
+	sf.Tag = reflect.StructTag(newTags)
+
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle is called for every source-field->mangled-field
+// mapping-set, with the mangled-field and its populated value set.
+// This just returns the first field, as Mangle only returns one field at a
+// time.
+func (t *TagPropagatingMangler) Unmangle(sf reflect.StructField, vs []transform.FieldValueTuple) (reflect.Value, error) {
+	// we always return exactly one field in Mangle, so we can always
+	// return vs[0].Value (after a type conversion) without any issues
+	if vs[0].Value.Kind() == reflect.Struct {
+		return vs[0].Value.Convert(sf.Type), nil
+	}
+	return vs[0].Value, nil
+}
+
+// ShouldRecurse always returns true so the tags of nested struct fields are
+// propagated too.
+func (t *TagPropagatingMangler) ShouldRecurse(_ reflect.StructField) bool {
+	return true
+}
+
+// tagName returns the name in the value of a tag, without any options.
+func tagName(tagVal string) string {
+	if i := strings.IndexByte(tagVal, ','); i >= 0 {
+		return tagVal[:i]
+	}
+	return tagVal
+}
+
+// PropagateTagsSource is a convenience function that provides a source
+// that first propagates names between the specified tags on the config type
+// (see TagPropagatingMangler), then calls Value on the wrapped source with
+// the modified struct type passed in. Use sourcewrap.NewTransformingDecoder
+// with a TagPropagatingMangler to do the same for a Decoder.
+func PropagateTagsSource(inner dials.Source, tags ...string) dials.Source {
+	return sourcewrap.NewTransformingSource(inner, NewTagPropagatingMangler(tags...))
+}
@@ -0,0 +1,51 @@
+package tagformat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/transform"
+)
+
+func TestTagPropagatingMangler(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		User string `yaml:"user_name"`
+	}
+	type config struct {
+		Name     string `json:"name,omitempty"`
+		Port     int    `json:"port" yaml:"listen_port"`
+		Ignored  string `json:"-"`
+		Inner    inner  `toml:"inner"`
+		Options  string `json:",omitempty"`
+		Untagged string
+	}
+	cfg := ptrify.Pointerify(reflect.TypeOf(config{}), reflect.ValueOf(config{}))
+	tfm := transform.NewTransformer(cfg, NewTagPropagatingMangler("json", "yaml", "toml"))
+
+	mangledVal, err := tfm.Translate()
+	require.NoError(t, err)
+	mangledType := mangledVal.Type()
+	for field, expected := range map[string]reflect.StructTag{
+		"Name":     `json:"name,omitempty" yaml:"name" toml:"name"`,
+		"Port":     `json:"port" yaml:"listen_port" toml:"port"`,
+		"Ignored":  `json:"-" yaml:"-" toml:"-"`,
+		"Inner":    `toml:"inner" json:"inner" yaml:"inner"`,
+		"Options":  `json:",omitempty"`,
+		"Untagged": ``,
+	} {
+		sf, ok := mangledType.FieldByName(field)
+		require.True(t, ok)
+		assert.Equal(t, expected, sf.Tag, field)
+	}
+	innerField, _ := mangledType.FieldByName("Inner")
+	userField, ok := innerField.Type.Elem().FieldByName("User")
+	require.True(t, ok)
+	assert.Equal(t, reflect.StructTag(`yaml:"user_name" json:"user_name" toml:"user_name"`), userField.Tag)
+
+	_, err = tfm.ReverseTranslate(mangledVal)
+	assert.NoError(t, err)
+}
@@ -30,6 +30,15 @@ func NewTagReformattingMangler(tagName string,
 	}
 }
 
+// NewTagCasingMangler constructs a TagReformattingMangler that rewrites the
+// values of the tag tagName from the case convention from to the one to
+// (e.g. caseconversion.LowerCamelCase to caseconversion.KebabCase). Fields
+// without the tag get one derived from their Go names, so untagged fields
+// follow the convention too.
+func NewTagCasingMangler(tagName string, from, to caseconversion.Casing) *TagReformattingMangler {
+	return NewTagReformattingMangler(tagName, from.Decode, to.Encode)
+}
+
 // Mangle is called for every field in a struct, and returns the value
 // unchanged other than replacing the specified tag.
 func (k *TagReformattingMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
@@ -51,7 +60,7 @@ func (k *TagReformattingMangler) Mangle(sf reflect.StructField) ([]reflect.Struc
 
 	tags, parseErr := structtag.Parse(string(sf.Tag))
 	if parseErr != nil {
-		return nil, err
+		return nil, parseErr
 	}
 	tags.Set(&structtag.Tag{
 		Key:     k.tag,
@@ -95,3 +104,9 @@ func ReformatDialsTagSource(inner dials.Source, encodeFunc caseconversion.Decode
 	})
 
 }
+
+// ReformatDialsTagCasing is like ReformatDialsTagSource, but takes the case
+// conventions to convert between as caseconversion.Casings.
+func ReformatDialsTagCasing(inner dials.Source, from, to caseconversion.Casing) dials.Source {
+	return ReformatDialsTagSource(inner, from.Decode, to.Encode)
+}
@@ -2,6 +2,7 @@ package tagformat
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/vimeo/dials/common"
@@ -32,3 +33,27 @@ func TestNoTag(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, `dials:"field_name"`, string(newSFSlice[0].Tag))
 }
+
+func TestCasingMangler(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		to       caseconversion.Casing
+		expected string
+	}{
+		{"kebab", caseconversion.KebabCase, `dials:"json-api-docs"`},
+		{"screaming_snake", caseconversion.UpperSnakeCase, `dials:"JSON_API_DOCS"`},
+		{"flat", caseconversion.FlatCase, `dials:"jsonapidocs"`},
+		{"custom", caseconversion.Casing{
+			Encode: func(words caseconversion.DecodedIdentifier) string { return strings.Join(words, ".") },
+		}, `dials:"json.api.docs"`},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			trm := NewTagCasingMangler(common.DialsTagName, caseconversion.GoCamelCase, tc.to)
+			newSFSlice, err := trm.Mangle(reflect.StructField{Name: "JSONAPIDocs"})
+			require.NoError(t, err)
+			require.Len(t, newSFSlice, 1)
+			assert.Equal(t, tc.expected, string(newSFSlice[0].Tag))
+		})
+	}
+}
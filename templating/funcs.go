@@ -0,0 +1,170 @@
+package templating
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Funcs returns a copy of the function library available to templates
+// executed by Decoder, e.g. for a Decoder's Funcs to extend. The functions
+// take their arguments in the same order as their Sprig counterparts, so the
+// value being operated on comes last and can be piped in:
+//
+//   - default: `default def v` is v, or def if v is empty
+//   - empty: `empty v` reports whether v is nil or its type's zero value, or
+//     is an empty string, slice or map
+//   - coalesce: `coalesce vs...` is the first non-empty argument
+//   - ternary: `ternary a b cond` is a if cond is true, b otherwise
+//   - required: `required msg v` is v, or fails with msg if v is empty
+//   - env: `env name` is the value of the environment variable name
+//   - expandenv: `expandenv s` replaces $VAR and ${VAR} in s with the values
+//     of environment variables
+//   - b64enc, b64dec: `b64enc s` and `b64dec s` encode and decode s as
+//     standard base64
+//   - trim, trimAll, trimPrefix, trimSuffix: `trim s` trims whitespace,
+//     `trimAll cutset s` trims the characters in cutset, and `trimPrefix
+//     prefix s` and `trimSuffix suffix s` trim prefix and suffix
+//   - upper, lower: change the case of s
+//   - replace: `replace old new s` replaces every old in s with new
+//   - contains, hasPrefix, hasSuffix: `contains sub s` reports whether s
+//     contains sub, and likewise for hasPrefix and hasSuffix
+//   - quote, squote: `quote v` double-quotes (and escapes) v, `squote v`
+//     single-quotes it
+//   - list: `list vs...` is a list of its arguments
+//   - splitList, join: `splitList sep s` splits s around sep, and `join sep
+//     list` joins the elements of list with sep
+//   - indent, nindent: `indent n s` indents every line of s by n spaces, and
+//     nindent also prepends a newline
+//   - toJson: `toJson v` is v encoded as JSON
+//
+// Deliberately, no function reads files or makes network requests.
+func Funcs() template.FuncMap {
+	funcs := make(template.FuncMap, len(library))
+	for name, fn := range library {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+var library = template.FuncMap{
+	"default":    defaultFunc,
+	"empty":      empty,
+	"coalesce":   coalesce,
+	"ternary":    ternary,
+	"required":   required,
+	"env":        os.Getenv,
+	"expandenv":  os.ExpandEnv,
+	"b64enc":     b64enc,
+	"b64dec":     b64dec,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+	"contains":   func(sub, s string) bool { return strings.Contains(s, sub) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+	"squote":     func(v interface{}) string { return "'" + toString(v) + "'" },
+	"list":       func(vs ...interface{}) []interface{} { return vs },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       join,
+	"indent":     indent,
+	"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	"toJson":     toJSON,
+}
+
+func empty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+func defaultFunc(def, v interface{}) interface{} {
+	if empty(v) {
+		return def
+	}
+	return v
+}
+
+func coalesce(vs ...interface{}) interface{} {
+	for _, v := range vs {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func ternary(a, b interface{}, cond bool) interface{} {
+	if cond {
+		return a
+	}
+	return b
+}
+
+func required(msg string, v interface{}) (interface{}, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %w", err)
+	}
+	return string(b), nil
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func join(sep string, list interface{}) (string, error) {
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a list", list)
+	}
+	elems := make([]string, rv.Len())
+	for i := range elems {
+		elems[i] = toString(rv.Index(i).Interface())
+	}
+	return strings.Join(elems, sep), nil
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return string(b), nil
+}
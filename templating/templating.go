@@ -0,0 +1,70 @@
+// Package templating preprocesses config files as text/template templates
+// before decoding them, so a file can pull in environment variables or
+// compute values:
+//
+//	db:
+//	  host: {{ env "DB_HOST" | default "localhost" }}
+//	  password: {{ env "DB_PASSWORD_B64" | b64dec | quote }}
+//
+// Templates can call a vetted library of functions modeled on the commonly
+// used Sprig functions (see Funcs), so templated configs don't each need
+// their own template.FuncMap, as well as any functions registered on the
+// Decoder.
+package templating
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+
+	"github.com/vimeo/dials"
+)
+
+// Decoder wraps another decoder, executing the file as a template before
+// decoding the output with Inner. Referencing a missing key of Data is an
+// error.
+type Decoder struct {
+	// Inner decodes the output of the template.
+	Inner dials.Decoder
+	// Funcs are made available to the template in addition to the library
+	// returned by Funcs, and override functions of the same name in it.
+	Funcs template.FuncMap
+	// Data is passed to the template as dot.
+	Data interface{}
+}
+
+var _ dials.ContextDecoder = (*Decoder)(nil)
+
+// NewDecoder returns a Decoder executing files as templates, with funcs
+// available in addition to the library returned by Funcs, before decoding
+// them with inner.
+func NewDecoder(inner dials.Decoder, funcs template.FuncMap) *Decoder {
+	return &Decoder{Inner: inner, Funcs: funcs}
+}
+
+// Decode executes the template read from r, and decodes its output with
+// Inner.
+func (d *Decoder) Decode(r io.Reader, t *dials.Type) (reflect.Value, error) {
+	return d.DecodeContext(context.Background(), r, t)
+}
+
+// DecodeContext is like Decode, but passes ctx to the inner decoder (see
+// dials.ContextDecoder).
+func (d *Decoder) DecodeContext(ctx context.Context, r io.Reader, t *dials.Type) (reflect.Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("error reading raw bytes: %w", err)
+	}
+	tmpl, err := template.New("config").Option("missingkey=error").Funcs(library).Funcs(d.Funcs).Parse(string(data))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to parse config template: %w", err)
+	}
+	out := bytes.Buffer{}
+	if err := tmpl.Execute(&out, d.Data); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to execute config template: %w", err)
+	}
+	return dials.Decode(ctx, d.Inner, &out, t)
+}
@@ -0,0 +1,98 @@
+package templating
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vimeo/dials"
+	"github.com/vimeo/dials/decoders/json"
+	"github.com/vimeo/dials/decoders/yaml"
+	"github.com/vimeo/dials/sources/static"
+)
+
+type config struct {
+	Timeout  time.Duration `dials:"timeout"`
+	Host     string        `dials:"host"`
+	Password string        `dials:"password"`
+	Tags     []string      `dials:"tags"`
+}
+
+func TestDecoder(t *testing.T) {
+	t.Setenv("TEMPLATING_TEST_PASSWORD", b64enc(`hunter"2`))
+	t.Setenv("TEMPLATING_TEST_HOST", "")
+
+	dec := NewDecoder(&yaml.Decoder{}, template.FuncMap{
+		"region": func() string { return "eu-west-1" },
+		// custom functions override the library
+		"upper": func(s string) string { return "UPPER " + s },
+	})
+	dec.Data = map[string]interface{}{"Timeout": "5s"}
+	d, err := dials.Config(context.Background(), &config{}, &static.StringSource{
+		Data: `
+timeout: {{ .Timeout }}
+host: {{ env "TEMPLATING_TEST_HOST" | default "localhost" }}
+password: {{ env "TEMPLATING_TEST_PASSWORD" | b64dec | quote }}
+tags:{{ list (region) (upper "x") (ternary "canary" "stable" true) | toJson | nindent 2 }}
+`,
+		Decoder: dec,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &config{
+		Timeout:  5 * time.Second,
+		Host:     "localhost",
+		Password: `hunter"2`,
+		Tags:     []string{"eu-west-1", "UPPER x", "canary"},
+	}, d.View())
+}
+
+func TestDecoderErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for data, wantErr := range map[string]string{
+		`{"host": "{{ .Host }"}`:                             "failed to parse config template",
+		`{"host": "{{ .Host }}"}`:                            `failed to execute config template: template: config:1:13: executing "config" at <.Host>: map has no entry for key "Host"`,
+		`{"host": "{{ "" | required "host is required" }}"}`: "host is required",
+		`{"host": "{{ "!" | b64dec }}"}`:                     "failed to decode base64",
+	} {
+		dec := NewDecoder(&json.Decoder{}, nil)
+		dec.Data = map[string]string{}
+		_, err := dials.Config(ctx, &config{}, &static.StringSource{Data: data, Decoder: dec})
+		assert.ErrorContains(t, err, wantErr, data)
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	t.Parallel()
+	for src, want := range map[string]string{
+		`{{ default "d" "" }} {{ default "d" "v" }} {{ default 1 0 }}`:                 "d v 1",
+		`{{ empty nil }} {{ empty (list) }} {{ empty 0 }} {{ empty "x" }}`:             "true true true false",
+		`{{ coalesce "" 0 "first" "second" }}`:                                         "first",
+		`{{ required "missing" "v" }}`:                                                 "v",
+		`{{ "x" | b64enc }} {{ "eA==" | b64dec }}`:                                     "eA== x",
+		`{{ "  x  " | trim }}|{{ "--x--" | trimAll "-" }}`:                             "x|x",
+		`{{ "pre-x-suf" | trimPrefix "pre-" | trimSuffix "-suf" }}`:                    "x",
+		`{{ "aB" | upper }} {{ "aB" | lower }}`:                                        "AB ab",
+		`{{ "a-b-c" | replace "-" "." }}`:                                              "a.b.c",
+		`{{ contains "b" "abc" }} {{ hasPrefix "a" "abc" }} {{ hasSuffix "a" "abc" }}`: "true true false",
+		`{{ quote "a\"b" }} {{ squote 1 }}`:                                            `"a\"b" '1'`,
+		`{{ "a,b,c" | splitList "," | join "+" }}`:                                     "a+b+c",
+		`{{ list 1 "a" true | join " " }}`:                                             "1 a true",
+		`{{ "a\nb" | indent 2 }}`:                                                      "  a\n  b",
+		`{{ toJson (list 1 "a") }}`:                                                    `[1,"a"]`,
+	} {
+		tmpl, err := template.New("").Funcs(Funcs()).Parse(src)
+		require.NoError(t, err, src)
+		out := strings.Builder{}
+		require.NoError(t, tmpl.Execute(&out, nil), src)
+		assert.Equal(t, want, out.String(), src)
+	}
+
+	// Funcs returns a copy
+	Funcs()["upper"] = strings.ToLower
+	assert.Equal(t, "X", library["upper"].(func(string) string)("x"))
+}
@@ -0,0 +1,224 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// TenantsTag is the name of the struct tag marking the field of a
+// configuration that holds per-tenant overrides (e.g.
+// `dialstenants:"true"`), for [Dials.ForTenant]. The field must be a top-level
+// map from tenant names to a struct (or pointer to a struct) whose fields
+// have the same names as fields of the configuration, and either the same
+// types, or pointers to them. Nested structs may be overridden field by field
+// with structs of their own.
+//
+// The overrides are ordinary configuration, so any source can provide them
+// (e.g. a `tenants` section in a config file, keyed by tenant). Tag the map
+// with `dialsmerge:"merge"` to compose each tenant's overrides from several
+// sources.
+const TenantsTag = "dialstenants"
+
+// tenantViews caches the per-tenant views of the installed configuration
+// version.
+type tenantViews[T any] struct {
+	mu     sync.Mutex
+	serial uint64
+	views  map[string]tenantView[T]
+}
+
+type tenantView[T any] struct {
+	cfg *T
+	err error
+}
+
+// ForTenant returns the configuration as seen by tenant: the installed
+// configuration with the fields set in tenant's entry in the field tagged with
+// [TenantsTag] overriding the corresponding fields. Zero values (and nil
+// pointers, maps and slices) in the overrides are treated as unset; use a
+// pointer field to override a field with its zero value. The configuration is
+// returned as-is for tenants without overrides.
+//
+// Each tenant's view is computed (and verified, if the configuration
+// implements [VerifiedConfig] or [ContextVerifiedConfig]) once per installed
+// version, so ForTenant is cheap to call on every request. It returns an
+// error if the configuration has no field tagged with TenantsTag, or if the
+// tenant's view fails verification. Like the configuration returned by View,
+// the view must not be modified.
+func (d *Dials[T]) ForTenant(ctx context.Context, tenant string) (*T, error) {
+	cfg, serial := d.ViewVersion()
+	tv := &d.tenants
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	if tv.views == nil || tv.serial != serial.s {
+		tv.views = map[string]tenantView[T]{}
+		tv.serial = serial.s
+	}
+	if v, ok := tv.views[tenant]; ok {
+		return v.cfg, v.err
+	}
+	view, err := d.tenantView(ctx, cfg, tenant)
+	tv.views[tenant] = tenantView[T]{cfg: view, err: err}
+	return view, err
+}
+
+func (d *Dials[T]) tenantView(ctx context.Context, cfg *T, tenant string) (*T, error) {
+	cv := reflect.ValueOf(cfg).Elem()
+	sf, ok := tenantsField(cv.Type())
+	if !ok {
+		return nil, fmt.Errorf("no field in %s is tagged with %s", cv.Type(), TenantsTag)
+	}
+	overrides := cv.FieldByIndex(sf.Index).MapIndex(reflect.ValueOf(tenant).Convert(sf.Type.Key()))
+	for overrides.IsValid() && overrides.Kind() == reflect.Ptr {
+		if overrides.IsNil() {
+			return cfg, nil
+		}
+		overrides = overrides.Elem()
+	}
+	if !overrides.IsValid() {
+		return cfg, nil
+	}
+	out := deepCopyValue(reflect.ValueOf(cfg))
+	overlayTenant(out.Elem(), overrides)
+	view := out.Interface().(*T)
+	if err := verifyConfig(ctx, view, d.params.VerificationTimeout); err != nil {
+		return nil, fmt.Errorf("configuration for tenant %q failed verification: %w", tenant, err)
+	}
+	return view, nil
+}
+
+// tenantsField returns the field of the struct type t tagged with
+// TenantsTag, if any.
+func tenantsField(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(TenantsTag)
+		if !ok {
+			continue
+		}
+		if on, err := strconv.ParseBool(tag); err == nil && !on {
+			continue
+		}
+		return sf, true
+	}
+	return reflect.StructField{}, false
+}
+
+// checkTenants returns an error if the configuration type t has a field
+// tagged with TenantsTag whose overrides don't match t's fields.
+func checkTenants(t reflect.Type) error {
+	sf, ok := tenantsField(t)
+	if !ok {
+		return nil
+	}
+	if sf.Type.Kind() != reflect.Map || sf.Type.Key().Kind() != reflect.String {
+		return fmt.Errorf("field %s tagged with %s must be a map keyed by tenant names, not %s", sf.Name, TenantsTag, sf.Type)
+	}
+	elem := sf.Type.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("field %s tagged with %s must map to structs, not %s", sf.Name, TenantsTag, sf.Type.Elem())
+	}
+	if err := checkTenantOverrides(t, elem); err != nil {
+		return fmt.Errorf("overrides in field %s: %w", sf.Name, err)
+	}
+	return nil
+}
+
+// checkTenantOverrides returns an error if any field of the struct type
+// overrides can't override the field of the same name in base.
+func checkTenantOverrides(base, overrides reflect.Type) error {
+	for i := 0; i < overrides.NumField(); i++ {
+		of := overrides.Field(i)
+		if !of.IsExported() {
+			continue
+		}
+		bf, ok := base.FieldByName(of.Name)
+		if !ok || !bf.IsExported() {
+			return fmt.Errorf("field %s has no counterpart in %s", of.Name, base)
+		}
+		ot, bt := of.Type, bf.Type
+		if ot == bt || (ot.Kind() == reflect.Ptr && ot.Elem() == bt) {
+			continue
+		}
+		if isNestedStruct(ot) && isNestedStruct(bt) {
+			if err := checkTenantOverrides(derefType(bt), derefType(ot)); err != nil {
+				return fmt.Errorf("field %s: %w", of.Name, err)
+			}
+			continue
+		}
+		return fmt.Errorf("field %s has type %s, which can't override %s", of.Name, ot, bt)
+	}
+	return nil
+}
+
+// overlayTenant sets the fields of the struct base to the set fields of the
+// same names in the struct overrides (whose types were checked by
+// checkTenantOverrides).
+func overlayTenant(base, overrides reflect.Value) {
+	for i := 0; i < overrides.NumField(); i++ {
+		if !overrides.Type().Field(i).IsExported() {
+			continue
+		}
+		of := overrides.Field(i)
+		bf := base.FieldByName(overrides.Type().Field(i).Name)
+		if of.Kind() == reflect.Ptr && of.Type() != bf.Type() {
+			if of.IsNil() {
+				continue
+			}
+			of = of.Elem()
+			if of.Type() == bf.Type() && !isNestedStruct(of.Type()) {
+				// an explicitly set pointer overrides even with a
+				// zero value
+				bf.Set(of)
+				continue
+			}
+		}
+		if isNestedStruct(of.Type()) {
+			for of.Kind() == reflect.Ptr {
+				if of.IsNil() {
+					break
+				}
+				of = of.Elem()
+			}
+			if of.Kind() == reflect.Ptr {
+				continue
+			}
+			target := bf
+			if bf.Kind() == reflect.Ptr {
+				if bf.IsNil() {
+					bf.Set(reflect.New(bf.Type().Elem()))
+				}
+				target = bf.Elem()
+			}
+			overlayTenant(target, of)
+			continue
+		}
+		switch of.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			if of.IsNil() {
+				continue
+			}
+		default:
+			if of.IsZero() {
+				continue
+			}
+		}
+		bf.Set(of)
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
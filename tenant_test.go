@@ -0,0 +1,110 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTenants(t *testing.T) {
+	t.Parallel()
+	type limits struct {
+		RPS int
+	}
+	type base struct {
+		Name   string
+		Limits limits
+		Tags   map[string]string
+	}
+	for name, tbl := range map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"untagged": {cfg: base{}},
+		"ok": {cfg: struct {
+			Name    string
+			Limits  *limits
+			Tenants map[string]struct {
+				Name   *string
+				Limits struct{ RPS int }
+			} `dialstenants:"true"`
+		}{}},
+		"not_a_map": {
+			cfg: struct {
+				Tenants []base `dialstenants:"true"`
+			}{},
+			err: "field Tenants tagged with dialstenants must be a map keyed by tenant names, not []dials.base",
+		},
+		"not_structs": {
+			cfg: struct {
+				Tenants map[string]string `dialstenants:"true"`
+			}{},
+			err: "field Tenants tagged with dialstenants must map to structs, not string",
+		},
+		"unknown_field": {
+			cfg: struct {
+				Name    string
+				Tenants map[string]*struct{ Nmae string } `dialstenants:"true"`
+			}{},
+			err: "overrides in field Tenants: field Nmae has no counterpart in struct { Name string; Tenants map[string]*struct { Nmae string } \"dialstenants:\\\"true\\\"\" }",
+		},
+		"wrong_type": {
+			cfg: struct {
+				Limits  limits
+				Tenants map[string]struct{ Limits struct{ RPS string } } `dialstenants:"true"`
+			}{},
+			err: "overrides in field Tenants: field Limits: field RPS has type string, which can't override int",
+		},
+	} {
+		tbl := tbl
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := checkTenants(reflect.TypeOf(tbl.cfg))
+			if tbl.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tbl.err)
+		})
+	}
+}
+
+func TestOverlayTenant(t *testing.T) {
+	t.Parallel()
+	type limits struct {
+		RPS, Burst int
+	}
+	type config struct {
+		Name   string
+		Debug  bool
+		Limits *limits
+		Tags   []string
+	}
+	type overrides struct {
+		Name   string
+		Debug  *bool
+		Limits struct{ Burst int }
+		Tags   []string
+	}
+	base := config{Name: "base", Debug: true, Limits: &limits{RPS: 1, Burst: 2}}
+	out := deepCopyValue(reflect.ValueOf(&base))
+	off := false
+	overlayTenant(out.Elem(), reflect.ValueOf(overrides{Debug: &off, Limits: struct{ Burst int }{Burst: 5}}))
+	assert.Equal(t, &config{Name: "base", Limits: &limits{RPS: 1, Burst: 5}}, out.Interface())
+	// the base configuration is untouched
+	assert.Equal(t, config{Name: "base", Debug: true, Limits: &limits{RPS: 1, Burst: 2}}, base)
+}
+
+func TestForTenantWithoutTenantsField(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Name string
+	}
+	d, err := Config(context.Background(), &config{})
+	require.NoError(t, err)
+	_, err = d.ForTenant(context.Background(), "acme")
+	assert.ErrorContains(t, err, "is tagged with dialstenants")
+}
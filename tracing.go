@@ -0,0 +1,62 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Names of the spans started with [Params].Tracer.
+const (
+	// SpanConfig covers a call to Config.
+	SpanConfig = "dials.Config"
+	// SpanSourceValue covers a call to a source's Value method (by Config
+	// or Refresh). It has a SourceSpanAttribute.
+	SpanSourceValue = "dials.Source.Value"
+	// SpanRestack covers composing and verifying a new configuration
+	// from updated source values.
+	SpanRestack = "dials.restack"
+)
+
+// SourceSpanAttribute is the key of the attribute holding the type of the
+// source (e.g. "*env.Source") on SpanSourceValue spans.
+const SourceSpanAttribute = "dials.source"
+
+// SpanAttribute is a key-value pair describing a span.
+type SpanAttribute struct {
+	Key   string
+	Value string
+}
+
+// EndSpanFunc ends a span started by a [Tracer], recording err (if non-nil)
+// as the failure of the operation it covers.
+type EndSpanFunc func(err error)
+
+// Tracer starts spans covering dials' operations, so slow startup and slow
+// sources show up in distributed traces. Set [Params].Tracer to enable it;
+// an implementation using OpenTelemetry only needs to wrap a trace.Tracer's
+// Start method.
+type Tracer interface {
+	// Start starts a span with the passed name (one of the Span*
+	// constants) and attributes, as a child of the span in ctx (if any).
+	// It returns a context containing the new span, which is passed to
+	// the operation (e.g. a source's Value method), and a function that
+	// ends the span.
+	Start(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, EndSpanFunc)
+}
+
+// nopTracer is used when Params.Tracer is nil.
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ ...SpanAttribute) (context.Context, EndSpanFunc) {
+	return ctx, func(error) {}
+}
+
+// tracedValue calls timedValue within a SpanSourceValue span.
+func tracedValue(ctx context.Context, tracer Tracer, source Source, t *Type, threshold time.Duration) (reflect.Value, error) {
+	ctx, end := tracer.Start(ctx, SpanSourceValue, SpanAttribute{Key: SourceSpanAttribute, Value: fmt.Sprintf("%T", source)})
+	v, err := timedValue(ctx, source, t, threshold)
+	end(err)
+	return v, err
+}
@@ -0,0 +1,63 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spanParentKey struct{}
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  []SpanAttribute
+	err    error
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, EndSpanFunc) {
+	parent, _ := ctx.Value(spanParentKey{}).(string)
+	return context.WithValue(ctx, spanParentKey{}, name), func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans = append(r.spans, recordedSpan{name: name, parent: parent, attrs: attrs, err: err})
+	}
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	tr := &recordingTracer{}
+	src := &flakySource{fakeSource: fakeSource{outVal: ptrifiedRollbackConfig{}}}
+	d, err := Params[rollbackConfig]{Tracer: tr}.Config(ctx, &rollbackConfig{Addr: "a"}, src)
+	require.NoError(t, err)
+
+	srcAttrs := []SpanAttribute{{Key: SourceSpanAttribute, Value: "*dials.flakySource"}}
+	assert.Equal(t, []recordedSpan{
+		{name: SpanSourceValue, parent: SpanConfig, attrs: srcAttrs},
+		{name: SpanConfig},
+	}, tr.spans)
+
+	tr.spans = nil
+	_, _, err = d.Refresh(ctx)
+	require.NoError(t, err)
+	errBroken := errors.New("broken")
+	src.err = errBroken
+	_, _, err = d.Refresh(ctx)
+	require.Error(t, err)
+	assert.Equal(t, []recordedSpan{
+		{name: SpanSourceValue, attrs: srcAttrs},
+		{name: SpanRestack},
+		{name: SpanSourceValue, attrs: srcAttrs, err: errBroken},
+	}, tr.spans)
+}
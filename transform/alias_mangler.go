@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structtag"
+	"github.com/vimeo/dials/common"
+)
+
+// AliasMangler adds a field for each of the aliases listed in a field's
+// `dialsalias` tag (see common.AliasTagName), with its Tag tag (e.g. "json")
+// set to the alias, so decoders populate it from the alias's key. When
+// unmangling, the field's own value is used if it's set; otherwise the value
+// of the first alias that's set is, and Deprecated (if non-nil) is called
+// with the alias and the field's own key. Other fields are passed through
+// unaltered.
+type AliasMangler struct {
+	Tag        string
+	Deprecated func(alias, name string)
+}
+
+// NewAliasMangler is the constructor for AliasMangler.
+func NewAliasMangler(tag string, deprecated func(alias, name string)) *AliasMangler {
+	return &AliasMangler{Tag: tag, Deprecated: deprecated}
+}
+
+// Mangle returns the field followed by a field for each of its aliases.
+func (a *AliasMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	aliases := fieldAliases(sf)
+	if len(aliases) == 0 {
+		return []reflect.StructField{sf}, nil
+	}
+	out := make([]reflect.StructField, 0, len(aliases)+1)
+	out = append(out, sf)
+	for i, alias := range aliases {
+		tags, err := structtag.Parse(string(sf.Tag))
+		if err != nil {
+			return nil, err
+		}
+		tags.Delete(common.AliasTagName)
+		tags.Set(&structtag.Tag{Key: a.Tag, Name: alias})
+		out = append(out, reflect.StructField{
+			Name: sf.Name + "DialsAlias" + strconv.Itoa(i),
+			Type: sf.Type,
+			Tag:  reflect.StructTag(tags.String()),
+		})
+	}
+	return out, nil
+}
+
+// Unmangle returns the value of the field, or of the first of its aliases
+// that's set if it isn't.
+func (a *AliasMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	if vs[0].Value.IsZero() {
+		for i, alias := range fieldAliases(sf) {
+			if v := vs[i+1].Value; !v.IsZero() {
+				if a.Deprecated != nil {
+					a.Deprecated(alias, a.name(sf))
+				}
+				return v, nil
+			}
+		}
+	}
+	return vs[0].Value, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*AliasMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+// name returns the key of the field sf itself.
+func (a *AliasMangler) name(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get(a.Tag), ","); name != "" {
+		return name
+	}
+	return sf.Name
+}
+
+// fieldAliases returns the aliases listed in the AliasTagName tag of sf.
+func fieldAliases(sf reflect.StructField) []string {
+	tag := sf.Tag.Get(common.AliasTagName)
+	if tag == "" {
+		return nil
+	}
+	aliases := []string{}
+	for _, alias := range strings.Split(tag, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,43 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasMangler(t *testing.T) {
+	type config struct {
+		Name  *string `json:"name,omitempty" dialsalias:"old_name, older_name"`
+		Count *int
+	}
+	type deprecation struct{ alias, name string }
+	var used []deprecation
+	tfmr := NewTransformer(reflect.TypeOf(config{}), NewAliasMangler("json", func(alias, name string) {
+		used = append(used, deprecation{alias, name})
+	}))
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	require.Equal(t, 4, val.NumField())
+	assert.Equal(t, reflect.StructTag(`json:"old_name"`), val.Type().Field(1).Tag)
+	assert.Equal(t, reflect.StructTag(`json:"older_name"`), val.Type().Field(2).Tag)
+
+	older := "older"
+	val.Field(2).Set(reflect.ValueOf(&older))
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	assert.Equal(t, &older, out.Interface().(config).Name)
+	assert.Equal(t, []deprecation{{"older_name", "name"}}, used)
+
+	// the field's own value takes precedence
+	used = nil
+	current := "current"
+	val.Field(0).Set(reflect.ValueOf(&current))
+	out, err = tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	assert.Equal(t, &current, out.Interface().(config).Name)
+	assert.Empty(t, used)
+}
@@ -0,0 +1,100 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Chain is a sequence of Manglers, in the order their Mangle methods are
+// evaluated (Unmangle is evaluated in reverse order).
+type Chain []Mangler
+
+// Append returns a new Chain with the manglers appended to c, leaving c
+// unmodified, so a shared base Chain can be extended by several adapters.
+func (c Chain) Append(manglers ...Mangler) Chain {
+	out := make(Chain, 0, len(c)+len(manglers))
+	out = append(out, c...)
+	return append(out, manglers...)
+}
+
+// Transformer returns a Transformer applying the Manglers in c to the type t.
+func (c Chain) Transformer(t reflect.Type) *Transformer {
+	return NewTransformer(t, c...)
+}
+
+var (
+	manglersMu sync.RWMutex
+	manglers   = map[string]func() Mangler{}
+)
+
+// RegisterMangler makes a Mangler available by name to LookupMangler and
+// ChainOf. newMangler is called for each lookup, so Manglers with state
+// aren't shared between Transformers. It panics if newMangler is nil or if
+// a Mangler is already registered with the same name, so it's intended to
+// be called from init functions.
+func RegisterMangler(name string, newMangler func() Mangler) {
+	if newMangler == nil {
+		panic(fmt.Sprintf("transform: RegisterMangler called with a nil constructor for %q", name))
+	}
+	manglersMu.Lock()
+	defer manglersMu.Unlock()
+	if _, dup := manglers[name]; dup {
+		panic(fmt.Sprintf("transform: RegisterMangler called twice for %q", name))
+	}
+	manglers[name] = newMangler
+}
+
+// LookupMangler returns a new instance of the Mangler registered with the
+// name, and whether one is registered.
+func LookupMangler(name string) (Mangler, bool) {
+	manglersMu.RLock()
+	newMangler, ok := manglers[name]
+	manglersMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return newMangler(), true
+}
+
+// RegisteredManglers returns the sorted names of the registered Manglers.
+func RegisteredManglers() []string {
+	manglersMu.RLock()
+	defer manglersMu.RUnlock()
+	names := make([]string, 0, len(manglers))
+	for name := range manglers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChainOf returns a Chain of new instances of the Manglers registered with
+// the names, in order.
+func ChainOf(names ...string) (Chain, error) {
+	c := make(Chain, 0, len(names))
+	for _, name := range names {
+		m, ok := LookupMangler(name)
+		if !ok {
+			return nil, fmt.Errorf("no Mangler registered as %q", name)
+		}
+		c = append(c, m)
+	}
+	return c, nil
+}
+
+// The Manglers in this package that don't need any parameters are
+// registered by their type's name.
+func init() {
+	RegisterMangler("DecodeHookMangler", func() Mangler { return &DecodeHookMangler{} })
+	RegisterMangler("EmbedMangler", func() Mangler { return &EmbedMangler{} })
+	RegisterMangler("FlattenMangler", func() Mangler { return DefaultFlattenMangler() })
+	RegisterMangler("RawJSONMangler", func() Mangler { return &RawJSONMangler{} })
+	RegisterMangler("SetSliceMangler", func() Mangler { return &SetSliceMangler{} })
+	RegisterMangler("StringCastingMangler", func() Mangler { return &StringCastingMangler{} })
+	RegisterMangler("StringScalarMangler", func() Mangler { return &StringScalarMangler{} })
+	RegisterMangler("TextUnmarshalerMangler", func() Mangler { return &TextUnmarshalerMangler{} })
+	RegisterMangler("TimeLayoutMangler", func() Mangler { return &TimeLayoutMangler{} })
+	RegisterMangler("UnitMangler", func() Mangler { return &UnitMangler{} })
+}
@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperMangler is a Mangler that changes string fields to upper case when
+// unmangling.
+type upperMangler struct{}
+
+func (*upperMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	return []reflect.StructField{sf}, nil
+}
+
+func (*upperMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	s, ok := vs[0].Value.Interface().(*string)
+	if !ok || s == nil {
+		return vs[0].Value, nil
+	}
+	upper := strings.ToUpper(*s)
+	return reflect.ValueOf(&upper), nil
+}
+
+func (*upperMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+func ExampleChain() {
+	type config struct {
+		Name     *string
+		Database *struct {
+			Port *int
+		}
+	}
+	// flatten the struct, and make every field a string, as an
+	// environment-variable source would
+	chain := Chain{DefaultFlattenMangler(), &StringCastingMangler{}}.Append(&upperMangler{})
+	tfmr := chain.Transformer(reflect.TypeOf(config{}))
+	val, err := tfmr.Translate()
+	if err != nil {
+		panic(err)
+	}
+	for i := 0; i < val.NumField(); i++ {
+		fmt.Println(val.Type().Field(i).Name, val.Field(i).Type())
+	}
+	name, port := "widget", "5432"
+	val.Field(0).Set(reflect.ValueOf(&name))
+	val.Field(1).Set(reflect.ValueOf(&port))
+
+	out, err := tfmr.ReverseTranslate(val)
+	if err != nil {
+		panic(err)
+	}
+	cfg := out.Interface().(config)
+	fmt.Println(*cfg.Name, *cfg.Database.Port)
+	// Output:
+	// Name *string
+	// DatabasePort *string
+	// WIDGET 5432
+}
+
+func TestChainAppend(t *testing.T) {
+	base := make(Chain, 1, 4)
+	base[0] = &SetSliceMangler{}
+	a := base.Append(&StringCastingMangler{})
+	b := base.Append(&TimeLayoutMangler{})
+	assert.Len(t, base, 1)
+	assert.IsType(t, &StringCastingMangler{}, a[1])
+	assert.IsType(t, &TimeLayoutMangler{}, b[1])
+}
+
+// the test Mangler is registered once, as the registry is global and rejects
+// duplicates, so tests may be run more than once (e.g. with -count).
+func init() {
+	RegisterMangler("transform_test_upper", func() Mangler { return &upperMangler{} })
+}
+
+func TestRegisterMangler(t *testing.T) {
+	assert.Contains(t, RegisteredManglers(), "transform_test_upper")
+	assert.Contains(t, RegisteredManglers(), "StringCastingMangler")
+
+	m, ok := LookupMangler("transform_test_upper")
+	require.True(t, ok)
+	assert.IsType(t, &upperMangler{}, m)
+	_, ok = LookupMangler("missing")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		RegisterMangler("transform_test_upper", func() Mangler { return &upperMangler{} })
+	})
+	assert.Panics(t, func() { RegisterMangler("transform_test_nil", nil) })
+
+	c, err := ChainOf("FlattenMangler", "StringCastingMangler", "transform_test_upper")
+	require.NoError(t, err)
+	require.Len(t, c, 3)
+	assert.IsType(t, &FlattenMangler{}, c[0])
+
+	_, err = ChainOf("FlattenMangler", "missing")
+	assert.EqualError(t, err, `no Mangler registered as "missing"`)
+}
@@ -0,0 +1,72 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// DecodeHookMangler changes fields whose types have a decode hook registered
+// with parse.RegisterDecodeHook (or pointers to such types) to interface{},
+// so decoders decode whatever the field contains into generic values, and
+// converts those values with the hook when unmangling. Other fields are
+// passed through unaltered.
+type DecodeHookMangler struct {
+	// Lookup, if non-nil, is used to find the decode hooks instead of
+	// parse.LookupDecodeHook, e.g. to add those set for a particular
+	// Dials instance (see dials.DecodeHookLookup).
+	Lookup func(t reflect.Type) (parse.DecodeHook, bool)
+}
+
+// Mangle changes the type of the provided StructField to interface{} if its
+// type has a decode hook.
+func (d *DecodeHookMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if _, ok := d.fieldDecodeHook(sf.Type); ok {
+		sf.Type = emptyInterfaceType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle converts the generic value of a mangled field with the decode
+// hook for the field's type.
+func (d *DecodeHookMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	hook, ok := d.fieldDecodeHook(sf.Type)
+	if !ok {
+		return vs[0].Value, nil
+	}
+	if vs[0].Value.IsNil() {
+		return reflect.Zero(sf.Type), nil
+	}
+	out, err := hook.Convert(jsonCompatible(vs[0].Value.Interface()))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to convert field %s to %s: %w", sf.Name, hook.Target, err)
+	}
+	if out.Type() != hook.Target {
+		return reflect.Value{}, fmt.Errorf("decode hook for %s returned a value of type %s for field %s",
+			hook.Target, out.Type(), sf.Name)
+	}
+	if sf.Type.Kind() != reflect.Ptr {
+		return out, nil
+	}
+	ptr := reflect.New(hook.Target)
+	ptr.Elem().Set(out)
+	return ptr, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*DecodeHookMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+// fieldDecodeHook returns the decode hook for the type t, or the type it
+// points to.
+func (d *DecodeHookMangler) fieldDecodeHook(t reflect.Type) (parse.DecodeHook, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if d.Lookup != nil {
+		return d.Lookup(t)
+	}
+	return parse.LookupDecodeHook(t)
+}
@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vimeo/dials/parse"
+)
+
+type hookTestLevel int
+
+func init() {
+	parse.RegisterDecodeHook(parse.NewDecodeHook(func(v interface{}) (hookTestLevel, error) {
+		switch v {
+		case "low":
+			return 1, nil
+		case "high":
+			return 2, nil
+		default:
+			return 0, fmt.Errorf("unknown level %v", v)
+		}
+	}))
+}
+
+func TestDecodeHookMangler(t *testing.T) {
+	type config struct {
+		Level    hookTestLevel
+		LevelPtr *hookTestLevel
+		Unset    *hookTestLevel
+		Name     *string
+	}
+	tfmr := NewTransformer(reflect.TypeOf(config{}), &DecodeHookMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	// only the hooked fields are mangled
+	assert.Equal(t, emptyInterfaceType, val.Field(0).Type())
+	assert.Equal(t, emptyInterfaceType, val.Field(1).Type())
+	assert.Equal(t, emptyInterfaceType, val.Field(2).Type())
+	assert.Equal(t, reflect.TypeOf((*string)(nil)), val.Field(3).Type())
+
+	val.Field(0).Set(reflect.ValueOf("low"))
+	val.Field(1).Set(reflect.ValueOf("high"))
+
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	cfg := out.Interface().(config)
+	assert.Equal(t, hookTestLevel(1), cfg.Level)
+	require.NotNil(t, cfg.LevelPtr)
+	assert.Equal(t, hookTestLevel(2), *cfg.LevelPtr)
+	assert.Nil(t, cfg.Unset)
+
+	val.Field(1).Set(reflect.ValueOf("medium"))
+	_, err = tfmr.ReverseTranslate(val)
+	assert.ErrorContains(t, err, "failed to convert field LevelPtr to transform.hookTestLevel: unknown level medium")
+}
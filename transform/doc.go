@@ -0,0 +1,29 @@
+// Package transform converts between a (pointerified) config type and other
+// struct types that are easier for a particular source to populate, such as
+// a flat struct of strings for environment variables, or a struct whose
+// tags a decoder understands.
+//
+// A Mangler converts one field at a time: Mangle maps each field of the
+// input type to zero or more fields of the output type, and Unmangle maps
+// the populated values of those fields back to a value of the input field's
+// type. A Transformer applies a sequence of Manglers (see Chain) to a type:
+// Translate returns a zero value of the mangled type for the source to
+// populate, and ReverseTranslate unmangles the populated value back into the
+// original type, applying the Manglers in reverse order.
+//
+// Source adapters typically build a Chain of the Manglers in this package
+// (and tagformat) with their own, e.g.
+//
+//	chain := transform.Chain{
+//		transform.DefaultFlattenMangler(),
+//		&transform.StringCastingMangler{},
+//	}
+//	tfmr := chain.Transformer(t.Type())
+//	val, err := tfmr.Translate()
+//	// ... populate val ...
+//	out, err := tfmr.ReverseTranslate(val)
+//
+// Manglers that are useful across adapters may be registered by name with
+// RegisterMangler, so adapters can build chains from configuration with
+// ChainOf.
+package transform
@@ -0,0 +1,149 @@
+package transform
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+
+	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// values of the common.EmbedTagName tag
+const (
+	embedInline = "inline"
+	embedNested = "nested"
+)
+
+// EmbedMangler applies `dialsembed` tags (see common.EmbedTagName) on
+// embedded struct fields for decoders: the fields of embedded structs tagged
+// "inline" are promoted into the enclosing struct, and embedded structs
+// tagged "nested" become regular fields named after their types. That way,
+// embedded structs are decoded the same way regardless of the conventions of
+// the format's library. Other fields are passed through unaltered.
+//
+// Promoted fields must not have the same names as fields of the enclosing
+// struct.
+type EmbedMangler struct{}
+
+// Mangle promotes the fields of an embedded struct tagged "inline", or
+// un-embeds one tagged "nested".
+func (*EmbedMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	fields, _, err := embedFields(sf, nil)
+	return fields, err
+}
+
+// Unmangle reassembles the embedded struct from the values of its promoted
+// fields. If none of them is set, the field is left nil.
+func (*EmbedMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	mode, err := embedMode(sf)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if mode != embedInline {
+		return vs[0].Value, nil
+	}
+	_, paths, err := embedFields(sf, nil)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	_, t := getUnderlyingKindType(sf.Type)
+	out := reflect.New(t)
+	set := false
+	for i, path := range paths {
+		v := vs[i].Value
+		if v.IsZero() {
+			continue
+		}
+		set = true
+		dst := fieldByIndexAlloc(out.Elem(), path)
+		if !v.Type().ConvertibleTo(dst.Type()) {
+			return reflect.Value{}, fmt.Errorf("cannot set field %s of type %s from %s",
+				vs[i].Field.Name, dst.Type(), v.Type())
+		}
+		dst.Set(v.Convert(dst.Type()))
+	}
+	if !set {
+		return reflect.Zero(sf.Type), nil
+	}
+	if sf.Type.Kind() != reflect.Ptr {
+		return out.Elem(), nil
+	}
+	return out, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*EmbedMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+// embedMode returns the value of the EmbedTagName tag of sf, or an error if
+// it's invalid or on a field that isn't an embedded struct.
+func embedMode(sf reflect.StructField) (string, error) {
+	mode, ok := sf.Tag.Lookup(common.EmbedTagName)
+	if !ok {
+		return "", nil
+	}
+	if k, t := getUnderlyingKindType(sf.Type); !sf.Anonymous || k != reflect.Struct || ptrify.IsScalarStruct(t) {
+		return "", fmt.Errorf("%s tag on field %s, which isn't an embedded struct", common.EmbedTagName, sf.Name)
+	}
+	switch mode {
+	case embedInline, embedNested:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s tag %q on field %s (must be %q or %q)",
+			common.EmbedTagName, mode, sf.Name, embedInline, embedNested)
+	}
+}
+
+// embedFields returns the fields that replace sf after applying its
+// EmbedTagName tag, along with the index of each within sf's (pointer-less)
+// type, prefixed by path.
+func embedFields(sf reflect.StructField, path []int) ([]reflect.StructField, [][]int, error) {
+	mode, err := embedMode(sf)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch mode {
+	case embedNested:
+		sf.Anonymous = false
+	case embedInline:
+		_, t := getUnderlyingKindType(sf.Type)
+		out := []reflect.StructField{}
+		paths := [][]int{}
+		for i := 0; i < t.NumField(); i++ {
+			inner := t.Field(i)
+			if !ast.IsExported(inner.Name) {
+				continue
+			}
+			innerPath := append(path[:len(path):len(path)], i)
+			fields, fieldPaths, err := embedFields(inner, innerPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, fields...)
+			paths = append(paths, fieldPaths...)
+		}
+		return out, paths, nil
+	}
+	sf.Index = nil
+	return []reflect.StructField{sf}, [][]int{path}, nil
+}
+
+// fieldByIndexAlloc returns the field of the struct v at the index path,
+// allocating any nil pointers to structs along the way.
+func fieldByIndexAlloc(v reflect.Value, path []int) reflect.Value {
+	for z, i := range path {
+		v = v.Field(i)
+		if z == len(path)-1 {
+			break
+		}
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+	return v
+}
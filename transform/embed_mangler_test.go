@@ -0,0 +1,56 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedMangler(t *testing.T) {
+	type Auth struct {
+		User *string
+	}
+	type Server struct {
+		*Auth `dialsembed:"inline"`
+		Host  *string
+	}
+	type Database struct {
+		Host *string
+	}
+	type config struct {
+		*Server   `dialsembed:"inline"`
+		*Database `dialsembed:"nested"`
+		Name      *string
+	}
+	tfmr := NewTransformer(reflect.TypeOf(config{}), &EmbedMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	// the inlined fields are promoted recursively, and the nested struct
+	// is no longer embedded
+	require.Equal(t, 4, val.NumField())
+	names := []string{}
+	for i := 0; i < val.NumField(); i++ {
+		names = append(names, val.Type().Field(i).Name)
+		assert.False(t, val.Type().Field(i).Anonymous)
+	}
+	assert.Equal(t, []string{"User", "Host", "Database", "Name"}, names)
+
+	user := "admin"
+	val.Field(0).Set(reflect.ValueOf(&user))
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	cfg := out.Interface().(config)
+	require.NotNil(t, cfg.Server)
+	require.NotNil(t, cfg.Server.Auth)
+	assert.Equal(t, &user, cfg.User)
+	assert.Nil(t, cfg.Server.Host)
+	assert.Nil(t, cfg.Database)
+
+	// embedded structs without any set fields are left nil
+	out, err = tfmr.ReverseTranslate(reflect.New(val.Type()).Elem())
+	require.NoError(t, err)
+	assert.Nil(t, out.Interface().(config).Server)
+}
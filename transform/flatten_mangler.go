@@ -1,13 +1,13 @@
 package transform
 
 import (
-	"encoding"
 	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/fatih/structtag"
 	"github.com/vimeo/dials/common"
+	"github.com/vimeo/dials/ptrify"
 	"github.com/vimeo/dials/tagformat/caseconversion"
 )
 
@@ -17,9 +17,6 @@ const (
 	dialsFieldPathTag = "dialsfieldpath"
 )
 
-// textMReflectType is a reflect.Type of TextUnmarshaler
-var textMReflectType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
-
 // FlattenMangler implements the Mangler interface
 type FlattenMangler struct {
 	tag              string
@@ -71,11 +68,11 @@ func (f *FlattenMangler) Mangle(sf reflect.StructField) ([]reflect.StructField,
 	switch k {
 	case reflect.Struct:
 		// only flatten the struct if it doesn't implement TextUnmarshaler
-		if t.Implements(textMReflectType) || reflect.PtrTo(t).Implements(textMReflectType) {
+		if ptrify.IsScalarStruct(t) {
 			break
 		}
 		fieldPrefix := []string{}
-		if !sf.Anonymous {
+		if !promoted(sf) {
 			fieldPrefix = append(fieldPrefix, sf.Name)
 		}
 		return f.flattenStruct(fieldPrefix, prefixTag, fieldPath, sf)
@@ -111,8 +108,8 @@ func (f *FlattenMangler) flattenStruct(fieldPrefix, tagPrefix, fieldPath []strin
 		flattenedNames := fieldPrefix
 
 		// add the current member name to the list of nested names needed for
-		// flattening if not an embedded field
-		if !nestedsf.Anonymous {
+		// flattening if not an embedded field (or one tagged "nested")
+		if !promoted(nestedsf) {
 			flattenedNames = append(fieldPrefix[:len(fieldPrefix):len(fieldPrefix)], nestedsf.Name)
 		}
 
@@ -133,7 +130,7 @@ func (f *FlattenMangler) flattenStruct(fieldPrefix, tagPrefix, fieldPath []strin
 		switch nestedK {
 		case reflect.Struct:
 			// don't flatten if struct implements TextUnmarshaler
-			if nestedT.Implements(textMReflectType) || reflect.PtrTo(nestedT).Implements(textMReflectType) {
+			if ptrify.IsScalarStruct(nestedT) {
 				break
 			}
 			flattened, err := f.flattenStruct(flattenedNames, flattenedTags, flattenedPath, nestedsf)
@@ -162,14 +159,22 @@ func (f *FlattenMangler) flattenStruct(fieldPrefix, tagPrefix, fieldPath []strin
 // configured EncodingCasing function and fieldName. It returns the new parsed
 // StructTag, the updated slice of tags, and any error encountered
 func (f *FlattenMangler) getTag(sf *reflect.StructField, tags, flattenedPath []string) (reflect.StructTag, []string, error) {
+	mode, modeErr := embedMode(*sf)
+	if modeErr != nil {
+		return sf.Tag, nil, modeErr
+	}
 	tag, ok := sf.Tag.Lookup(f.tag)
 
-	// tag already exists so use the existing tag and append to prefix tags
-	if ok {
+	switch {
+	case mode == embedInline:
+		// the fields of embedded structs tagged "inline" are promoted
+		// without a prefix, even if the embedded field has a tag
+	case ok:
+		// tag already exists so use the existing tag and append to prefix tags
 		tags = append(tags[:len(tags):len(tags)], tag)
-	} else if !sf.Anonymous {
+	case !sf.Anonymous || mode == embedNested:
 		// tag doesn't already exist so use the field name as long as it's not
-		// Anonymous (embedded field)
+		// Anonymous (embedded field), or is tagged to be nested
 
 		// decode the field name assuming it's camel case before appending it
 		decodedField, err := caseconversion.DecodeGoCamelCase(sf.Name)
@@ -177,7 +182,6 @@ func (f *FlattenMangler) getTag(sf *reflect.StructField, tags, flattenedPath []s
 			return sf.Tag, nil, fmt.Errorf("error decoding field name %s: %w", sf.Name, err)
 		}
 		tags = append(tags[:len(tags):len(tags)], decodedField...)
-
 	}
 
 	tagVal := f.tagEncodeCasing(tags)
@@ -229,7 +233,7 @@ func populateStruct(originalVal reflect.Value, vs []FieldValueTuple, inputIndex
 	switch kind {
 	case reflect.Struct:
 		// go through each field if the struct doesn't implement TextUnmarshaler
-		if vt.Implements(textMReflectType) || reflect.PtrTo(vt).Implements(textMReflectType) {
+		if ptrify.IsScalarStruct(vt) {
 			break
 		}
 		// the originalVal is a pointer and to go through the fields, we need
@@ -247,7 +251,7 @@ func populateStruct(originalVal reflect.Value, vs []FieldValueTuple, inputIndex
 			switch kind {
 			case reflect.Struct:
 				// don't flatten if the struct implements TextUnmarshaler
-				if t.Implements(textMReflectType) || reflect.PtrTo(t).Implements(textMReflectType) {
+				if ptrify.IsScalarStruct(t) {
 					break // break out of the case, still stays within the for loop
 				}
 				var err error
@@ -284,6 +288,13 @@ func (f *FlattenMangler) ShouldRecurse(reflect.StructField) bool {
 	return false
 }
 
+// promoted returns whether the fields of the (struct) field sf are promoted
+// into the enclosing struct's namespace, which is the case for embedded
+// fields that aren't tagged to be nested.
+func promoted(sf reflect.StructField) bool {
+	return sf.Anonymous && sf.Tag.Get(common.EmbedTagName) != embedNested
+}
+
 // getUnderlyingKindType strips the pointer from the type to determine the underlying kind
 func getUnderlyingKindType(t reflect.Type) (reflect.Kind, reflect.Type) {
 	k := t.Kind()
@@ -147,8 +147,13 @@ func TestFlattenMangler(t *testing.T) {
 			},
 			modify: func(t testing.TB, val reflect.Value) {},
 			assertion: func(t testing.TB, i interface{}) {
-				// should be empty struct since none of the fields are exposed
-				assert.Equal(t, struct{}{}, *i.(*struct{}))
+				// none of the fields are exposed, so the struct is
+				// opaque, and left unset
+				assert.Nil(t, i.(*struct {
+					testInt    int
+					testString string
+					testBool   bool
+				}))
 			},
 		},
 		{
@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+var (
+	rawMessageType     = reflect.TypeOf(json.RawMessage(nil))
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// RawJSONMangler changes json.RawMessage fields (and pointers to them) to
+// interface{}, so decoders for formats other than JSON decode whatever the
+// field contains into generic values, and re-encodes those values as JSON
+// when unmangling. That lets applications defer decoding such fields until
+// they know what they contain, regardless of the format of the source.
+// deferred.Section fields are handled the same way, with the JSON becoming
+// the section's only layer. Other fields are passed through unaltered.
+type RawJSONMangler struct{}
+
+// Mangle changes the type of the provided StructField to interface{} if it's
+// a json.RawMessage (or a pointer to one) or a deferred.Section.
+func (*RawJSONMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if isRawJSON(sf.Type) {
+		sf.Type = emptyInterfaceType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle encodes the generic value of a mangled field as JSON.
+func (*RawJSONMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	if !isRawJSON(sf.Type) {
+		return vs[0].Value, nil
+	}
+	if vs[0].Value.IsNil() {
+		return reflect.Zero(sf.Type), nil
+	}
+	b, err := json.Marshal(jsonCompatible(vs[0].Value.Interface()))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to encode field %s as JSON: %w", sf.Name, err)
+	}
+	raw := json.RawMessage(b)
+	if ptrify.IsDeferredSection(sf.Type) {
+		sec := reflect.New(sf.Type)
+		if err := sec.Interface().(json.Unmarshaler).UnmarshalJSON(raw); err != nil {
+			return reflect.Value{}, fmt.Errorf("failed to set field %s: %w", sf.Name, err)
+		}
+		return sec.Elem(), nil
+	}
+	if sf.Type == rawMessageType {
+		return reflect.ValueOf(raw), nil
+	}
+	return reflect.ValueOf(&raw), nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*RawJSONMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+func isRawJSON(t reflect.Type) bool {
+	return t == rawMessageType || t == reflect.PtrTo(rawMessageType) || ptrify.IsDeferredSection(t)
+}
+
+// jsonCompatible converts the maps with interface{} keys that some decoders
+// (such as YAML's) produce into maps with string keys, which encoding/json
+// can encode.
+func jsonCompatible(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(tv))
+		for k, e := range tv {
+			out[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(tv))
+		for k, e := range tv {
+			out[k] = jsonCompatible(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(tv))
+		for i, e := range tv {
+			out[i] = jsonCompatible(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
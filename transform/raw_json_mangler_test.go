@@ -0,0 +1,41 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawJSONMangler(t *testing.T) {
+	type config struct {
+		Plugin    json.RawMessage
+		PluginPtr *json.RawMessage
+		Unset     json.RawMessage
+		Name      *string
+	}
+	tfmr := NewTransformer(reflect.TypeOf(config{}), &RawJSONMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	// only the json.RawMessage fields are mangled
+	assert.Equal(t, emptyInterfaceType, val.Field(0).Type())
+	assert.Equal(t, emptyInterfaceType, val.Field(1).Type())
+	assert.Equal(t, reflect.TypeOf((*string)(nil)), val.Field(3).Type())
+
+	// the maps YAML decoders produce have interface{} keys
+	val.Field(0).Set(reflect.ValueOf(map[interface{}]interface{}{
+		"a": []interface{}{1, map[interface{}]interface{}{2: "b"}},
+	}))
+	val.Field(1).Set(reflect.ValueOf("c"))
+
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	cfg := out.Interface().(config)
+	assert.JSONEq(t, `{"a": [1, {"2": "b"}]}`, string(cfg.Plugin))
+	require.NotNil(t, cfg.PluginPtr)
+	assert.Equal(t, `"c"`, string(*cfg.PluginPtr))
+	assert.Nil(t, cfg.Unset)
+}
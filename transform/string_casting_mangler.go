@@ -1,14 +1,24 @@
 package transform
 
 import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"reflect"
+	"time"
 
+	"github.com/vimeo/dials/common"
 	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
 )
 
 var (
 	zeroStr    = ""
 	strPtrType = reflect.TypeOf(&zeroStr)
+	timeType   = reflect.TypeOf(time.Time{})
+
+	flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
 )
 
 // StringCastingMangler mangles config struct fields into string types, then
@@ -41,6 +51,17 @@ func (*StringCastingMangler) Unmangle(sf reflect.StructField, vs []FieldValueTup
 	// we can distinguish nil values from zero values. For all types except
 	// slices and maps, we get the pointed-to concrete type in order to deal
 	// with it rather than pointer types themselves, for readability.
+	// Interface fields (such as those mangled by an earlier UnitMangler)
+	// get the string itself.
+	if sf.Type.Kind() == reflect.Interface {
+		if !strPtrType.Elem().AssignableTo(sf.Type) {
+			return reflect.Value{}, fmt.Errorf("cannot set %s field %s from a string", sf.Type, sf.Name)
+		}
+		v := reflect.New(sf.Type).Elem()
+		v.Set(reflect.ValueOf(str))
+		return v, nil
+	}
+
 	var castTo reflect.Type
 	switch sf.Type.Kind() {
 	case reflect.Slice, reflect.Map:
@@ -49,7 +70,65 @@ func (*StringCastingMangler) Unmangle(sf reflect.StructField, vs []FieldValueTup
 		castTo = sf.Type.Elem()
 	}
 
-	return parse.String(str, castTo)
+	if castTo == timeType {
+		return parseTime(str, sf)
+	}
+	// deferred sections hold the string, which must be JSON, as their only
+	// layer
+	if ptrify.IsDeferredSection(castTo) {
+		if !json.Valid([]byte(str)) {
+			return reflect.Value{}, fmt.Errorf("invalid JSON %q", str)
+		}
+		v := reflect.New(castTo)
+		if err := v.Interface().(json.Unmarshaler).UnmarshalJSON([]byte(str)); err != nil {
+			return reflect.Value{}, err
+		}
+		return v.Elem(), nil
+	}
+	if reflect.PtrTo(castTo).Implements(textUnmarshalerType) {
+		v := reflect.New(castTo)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+			return reflect.Value{}, err
+		}
+		if sf.Type == castTo {
+			// slices and maps aren't pointerified
+			return v.Elem(), nil
+		}
+		return v, nil
+	}
+	// custom flag types are set the way the flag package would
+	if reflect.PtrTo(castTo).Implements(flagValueType) {
+		v := reflect.New(castTo)
+		if err := v.Interface().(flag.Value).Set(str); err != nil {
+			return reflect.Value{}, err
+		}
+		if sf.Type == castTo {
+			return v.Elem(), nil
+		}
+		return v, nil
+	}
+	v, err := parse.String(str, castTo)
+	if err != nil {
+		return v, err
+	}
+	// maps and slices are parsed as values, but pointers to them (e.g. a
+	// *T field of a generic type instantiated with a slice) need a pointer
+	if v.Type() != sf.Type && reflect.PtrTo(v.Type()) == sf.Type {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+	return v, nil
+}
+
+// parseTime parses str with the layouts in sf's dialstimelayout tag, returning
+// a *time.Time.
+func parseTime(str string, sf reflect.StructField) (reflect.Value, error) {
+	t, err := parse.Time(str, parse.TimeLayouts(sf.Tag.Get(common.TimeLayoutTagName)))
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(&t), nil
 }
 
 // ShouldRecurse always returns true in order to walk nested structs.
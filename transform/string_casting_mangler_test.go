@@ -8,8 +8,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vimeo/dials/common"
 	"github.com/vimeo/dials/parse"
 	"github.com/vimeo/dials/ptrify"
+	"github.com/vimeo/dials/tagformat/caseconversion"
 )
 
 func TestStringCastingManglerMangle(t *testing.T) {
@@ -454,3 +456,34 @@ func TestParseOverflow(t *testing.T) {
 		})
 	}
 }
+
+func TestStringCastingManglerMultipleErrors(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		Port int
+	}
+	type config struct {
+		Workers int
+		Name    string
+		Inner   inner
+	}
+	ptrifiedConfigType := ptrify.Pointerify(reflect.TypeOf(config{}), reflect.ValueOf(config{}))
+
+	flattenMangler := NewFlattenMangler(common.DialsTagName, caseconversion.EncodeUpperCamelCase, caseconversion.EncodeUpperCamelCase)
+	tfmr := NewTransformer(ptrifiedConfigType, flattenMangler, &StringCastingMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	bad := "bad"
+	val.Field(0).Set(reflect.ValueOf(&bad))
+	val.Field(2).Set(reflect.ValueOf(&bad))
+
+	_, err = tfmr.ReverseTranslate(val)
+	require.Error(t, err)
+	errs, ok := err.(ReverseTranslateErrors)
+	require.True(t, ok, "unexpected error type %T", err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, []string{"Workers"}, errs[0].FieldPath())
+	assert.Equal(t, []string{"Inner", "Port"}, errs[1].FieldPath())
+	assert.Len(t, errs.Unwrap(), 2)
+}
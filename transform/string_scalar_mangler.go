@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"reflect"
+
+	"github.com/vimeo/dials/parse"
+	"github.com/vimeo/dials/ptrify"
+)
+
+// StringScalarMangler changes fields whose types are scalars in configs, but
+// don't implement encoding.TextUnmarshaler (url.URL and net.IPNet, types
+// registered with parse.RegisterType, or pointers to them), to string, and parses them with parse.String when
+// unmangling. That lets decoders populate them from strings. Other fields are
+// passed through unaltered.
+type StringScalarMangler struct{}
+
+// Mangle changes the type of the provided StructField to string if it's one
+// of the types handled by StringScalarMangler.
+func (*StringScalarMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if isStringScalar(sf.Type) {
+		sf.Type = strPtrType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle parses the string value of a mangled field.
+func (*StringScalarMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	if !isStringScalar(sf.Type) {
+		return vs[0].Value, nil
+	}
+	strPtr := vs[0].Value.Interface().(*string)
+	if strPtr == nil {
+		return reflect.Zero(sf.Type), nil
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// parse.String returns a pointer for these types
+	v, err := parse.String(*strPtr, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if sf.Type.Kind() != reflect.Ptr {
+		return v.Elem(), nil
+	}
+	return v, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*StringScalarMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+func isStringScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return ptrify.IsStringScalarStruct(t) || parse.Registered(t)
+}
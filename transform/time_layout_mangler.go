@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"reflect"
+
+	"github.com/vimeo/dials/common"
+)
+
+// TimeLayoutMangler changes time.Time fields with a dialstimelayout tag (see
+// common.TimeLayoutTagName) to string, and parses them with the tag's
+// layouts when unmangling. Other fields are passed through unaltered, so
+// decoders keep using time.Time's own unmarshaling methods for them.
+type TimeLayoutMangler struct{}
+
+// Mangle changes the type of the provided StructField to string if it's a
+// time.Time (or pointer to one) with a dialstimelayout tag.
+func (*TimeLayoutMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	if hasTimeLayout(sf) {
+		sf.Type = strPtrType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle parses the string value of a mangled field with the layouts from
+// its dialstimelayout tag.
+func (*TimeLayoutMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	if !hasTimeLayout(sf) {
+		return vs[0].Value, nil
+	}
+	strPtr := vs[0].Value.Interface().(*string)
+	if strPtr == nil {
+		return reflect.Zero(sf.Type), nil
+	}
+	t, err := parseTime(*strPtr, sf)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if sf.Type == timeType {
+		return t.Elem(), nil
+	}
+	return t, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*TimeLayoutMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+func hasTimeLayout(sf reflect.StructField) bool {
+	if _, ok := sf.Tag.Lookup(common.TimeLayoutTagName); !ok {
+		return false
+	}
+	return sf.Type == timeType || sf.Type == reflect.PtrTo(timeType)
+}
@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeLayoutMangler(t *testing.T) {
+	type config struct {
+		Start  *time.Time `dialstimelayout:"DateOnly"`
+		End    *time.Time
+		Nested *struct {
+			At *time.Time `dialstimelayout:"Kitchen|TimeOnly"`
+		}
+	}
+	tfmr := NewTransformer(reflect.TypeOf(config{}), &TimeLayoutMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	// only the tagged fields are mangled
+	assert.Equal(t, strPtrType, val.Field(0).Type())
+	assert.Equal(t, reflect.TypeOf(&time.Time{}), val.Field(1).Type())
+
+	start := "2020-01-02"
+	val.Field(0).Set(reflect.ValueOf(&start))
+	end := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	val.Field(1).Set(reflect.ValueOf(&end))
+	nested := reflect.New(val.Field(2).Type().Elem())
+	at := "15:04:05"
+	nested.Elem().Field(0).Set(reflect.ValueOf(&at))
+	val.Field(2).Set(nested)
+
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	cfg := out.Interface().(config)
+	assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), *cfg.Start)
+	assert.Equal(t, end, *cfg.End)
+	assert.Equal(t, time.Date(0, 1, 1, 15, 4, 5, 0, time.UTC), *cfg.Nested.At)
+
+	bad := "noon"
+	val.Field(0).Set(reflect.ValueOf(&bad))
+	_, err = tfmr.ReverseTranslate(val)
+	assert.Error(t, err)
+}
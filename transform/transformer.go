@@ -1,9 +1,13 @@
 package transform
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"reflect"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
 )
 
 // FieldValueTuple ties together the StructField and the value to be converted
@@ -25,6 +29,8 @@ type Mangler interface {
 	// implementation of Unmangle should return a reflect.Value that will
 	// be used for the next mangler or final struct value)
 	// Returned reflect.Value should be convertible to the field's type.
+	// Implementations that left a field unchanged in Mangle should return
+	// the single populated value unchanged.
 	Unmangle(reflect.StructField, []FieldValueTuple) (reflect.Value, error)
 	// ShouldRecurse is called after Mangle for each field so nested struct
 	// fields get iterated over after any transformation done by Mangle().
@@ -134,6 +140,12 @@ func (t *Transformer) maybeRecursivelyMangle(mangler Mangler, state *transformMa
 		case reflect.Ptr, reflect.Array, reflect.Slice:
 			ft = ft.Elem()
 		}
+		// structs like time.Time are scalars in configs (ptrify and the
+		// FlattenMangler leave them alone too), and rebuilding them
+		// would drop their methods.
+		if ptrify.IsScalarStruct(ft) {
+			continue
+		}
 		fieldTransformer := Transformer{
 			manglers: []Mangler{mangler},
 			mState:   nil,
@@ -342,6 +354,9 @@ func (e *UnmangleError) Unwrap() error {
 type ReverseTranslateError struct {
 	Err       error
 	ErrString string
+	// Path contains the names of the fields in the original struct
+	// leading to the field that failed, if known.
+	Path []string
 }
 
 // Error implements the Error interface.
@@ -354,7 +369,53 @@ func (e *ReverseTranslateError) Unwrap() error {
 	return e.Err
 }
 
+// FieldPath returns the names of the fields in the original struct leading to
+// the field that failed, or nil if unknown.
+func (e *ReverseTranslateError) FieldPath() []string {
+	return e.Path
+}
+
+// ReverseTranslateErrors is returned by ReverseTranslate when more than one
+// field fails to unmangle.
+type ReverseTranslateErrors []*ReverseTranslateError
+
+// Error implements the Error interface.
+func (e ReverseTranslateErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors for the individual fields.
+func (e ReverseTranslateErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// unmangleErrPath returns the path in the original struct to the field sf,
+// which failed to unmangle with err.
+func unmangleErrPath(sf reflect.StructField, err error) []string {
+	// flattened fields record their original path
+	if fieldPath, ok := sf.Tag.Lookup(dialsFieldPathTag); ok {
+		return strings.Split(fieldPath, ",")
+	}
+	path := []string{sf.Name}
+	inner := &ReverseTranslateError{}
+	if errors.As(err, &inner) {
+		path = append(path, inner.Path...)
+	}
+	return path
+}
+
 // ReverseTranslate calls each Mangler's Unmangle method in reverse order.
+// If any field fails to unmangle, the remaining fields handled by that Mangler
+// are still unmangled, and either a *ReverseTranslateError or (if more than
+// one field failed) a ReverseTranslateErrors is returned.
 func (t *Transformer) ReverseTranslate(v reflect.Value) (reflect.Value, error) {
 	// iterate through manglers in reverse order passing the value of the struct
 	// field paired with its reflect.StructField as a FieldValueTuple
@@ -364,6 +425,7 @@ func (t *Transformer) ReverseTranslate(v reflect.Value) (reflect.Value, error) {
 	for manglerNum := len(t.manglers) - 1; manglerNum >= 0; manglerNum-- {
 		mangledfieldOffset := 0
 		unmangledLayerVals := make([]FieldValueTuple, len(t.mState[manglerNum]))
+		var fieldErrs ReverseTranslateErrors
 		for srcFieldIdx, srcFieldstate := range t.mState[manglerNum] {
 			// slice down to just the mangled fields we're
 			// interested in for this unmangled field.
@@ -376,7 +438,13 @@ func (t *Transformer) ReverseTranslate(v reflect.Value) (reflect.Value, error) {
 					srcFieldIdx, srcFieldstate.in.Name, manglerNum,
 					t.manglers[manglerNum], unmangleErr)
 
-				return reflect.Value{}, &ReverseTranslateError{Err: unmangleErr, ErrString: errString}
+				fieldErrs = append(fieldErrs, &ReverseTranslateError{
+					Err:       unmangleErr,
+					ErrString: errString,
+					Path:      unmangleErrPath(srcFieldstate.in, unmangleErr),
+				})
+				mangledfieldOffset += len(srcFieldstate.out)
+				continue
 			}
 
 			// set the unmangled value on our field.
@@ -387,6 +455,13 @@ func (t *Transformer) ReverseTranslate(v reflect.Value) (reflect.Value, error) {
 
 			mangledfieldOffset += len(srcFieldstate.out)
 		}
+		switch len(fieldErrs) {
+		case 0:
+		case 1:
+			return reflect.Value{}, fieldErrs[0]
+		default:
+			return reflect.Value{}, fieldErrs
+		}
 		layerMangledVal = unmangledLayerVals
 	}
 
@@ -0,0 +1,154 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/vimeo/dials/bytesize"
+	"github.com/vimeo/dials/common"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// UnitMangler changes time.Duration fields, and integer fields with a
+// `dialsunit:"bytes"` tag (see common.UnitTagName), to interface{}, so
+// decoders can populate them with either numbers or strings with units
+// ("30s", "1GiB"). When unmangling, strings are parsed with
+// time.ParseDuration or bytesize.Parse, and numbers are used as-is (as
+// nanoseconds for durations), so every format accepts the same values rather
+// than depending on how its decoder handles durations. Other fields are
+// passed through unaltered.
+type UnitMangler struct{}
+
+// Mangle changes the type of the provided StructField to interface{} if it's
+// a time.Duration or a tagged integer (or a pointer to one).
+func (*UnitMangler) Mangle(sf reflect.StructField) ([]reflect.StructField, error) {
+	u, err := fieldUnit(sf)
+	if err != nil {
+		return nil, err
+	}
+	if u != unitNone {
+		sf.Type = emptyInterfaceType
+	}
+	return []reflect.StructField{sf}, nil
+}
+
+// Unmangle parses or converts the value of a mangled field.
+func (*UnitMangler) Unmangle(sf reflect.StructField, vs []FieldValueTuple) (reflect.Value, error) {
+	u, err := fieldUnit(sf)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if u == unitNone {
+		return vs[0].Value, nil
+	}
+	if vs[0].Value.IsNil() {
+		return reflect.Zero(sf.Type), nil
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	out := reflect.New(t)
+	if err := setUnitValue(out.Elem(), u, vs[0].Value.Elem()); err != nil {
+		return reflect.Value{}, fmt.Errorf("field %s: %w", sf.Name, err)
+	}
+	if sf.Type.Kind() != reflect.Ptr {
+		return out.Elem(), nil
+	}
+	return out, nil
+}
+
+// ShouldRecurse always returns true in order to walk nested structs.
+func (*UnitMangler) ShouldRecurse(reflect.StructField) bool {
+	return true
+}
+
+type unit int
+
+const (
+	unitNone unit = iota
+	unitDuration
+	unitBytes
+)
+
+// fieldUnit returns the unit of the field sf, or an error if it has an
+// invalid UnitTagName tag.
+func fieldUnit(sf reflect.StructField) (unit, error) {
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tag, ok := sf.Tag.Lookup(common.UnitTagName)
+	switch {
+	case !ok:
+		if t == durationType {
+			return unitDuration, nil
+		}
+		return unitNone, nil
+	case tag != "bytes":
+		return unitNone, fmt.Errorf("invalid %s tag %q on field %s (must be \"bytes\")",
+			common.UnitTagName, tag, sf.Name)
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return unitBytes, nil
+	default:
+		return unitNone, fmt.Errorf("%s tag on field %s of non-integer type %s",
+			common.UnitTagName, sf.Name, sf.Type)
+	}
+}
+
+// setUnitValue sets the integer out from v, a string with a unit or a
+// number.
+func setUnitValue(out reflect.Value, u unit, v reflect.Value) error {
+	var n int64
+	switch v.Kind() {
+	case reflect.String:
+		switch u {
+		case unitDuration:
+			d, err := time.ParseDuration(v.String())
+			if err != nil {
+				return err
+			}
+			n = int64(d)
+		default:
+			b, err := bytesize.Parse(v.String())
+			if err != nil {
+				return err
+			}
+			n = int64(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return fmt.Errorf("value %d overflows int64", v.Uint())
+		}
+		n = int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return fmt.Errorf("value %v is not an integer", f)
+		}
+		n = int64(f)
+	default:
+		return fmt.Errorf("unsupported value %v of type %s", v.Interface(), v.Type())
+	}
+	switch out.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || out.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, out.Type())
+		}
+		out.SetUint(uint64(n))
+	default:
+		if out.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, out.Type())
+		}
+		out.SetInt(n)
+	}
+	return nil
+}
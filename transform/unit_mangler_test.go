@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitMangler(t *testing.T) {
+	type config struct {
+		Timeout *time.Duration
+		Size    *int64 `dialsunit:"bytes"`
+		Count   *int64
+		Nested  *struct {
+			Limit *uint16 `dialsunit:"bytes"`
+		}
+	}
+	tfmr := NewTransformer(reflect.TypeOf(config{}), &UnitMangler{})
+	val, err := tfmr.Translate()
+	require.NoError(t, err)
+
+	// only durations and tagged integers are mangled
+	assert.Equal(t, emptyInterfaceType, val.Field(0).Type())
+	assert.Equal(t, emptyInterfaceType, val.Field(1).Type())
+	assert.Equal(t, reflect.TypeOf((*int64)(nil)), val.Field(2).Type())
+
+	val.Field(0).Set(reflect.ValueOf("1m30s"))
+	val.Field(1).Set(reflect.ValueOf(float64(2048)))
+	nested := reflect.New(val.Field(3).Type().Elem())
+	nested.Elem().Field(0).Set(reflect.ValueOf("2KiB"))
+	val.Field(3).Set(nested)
+
+	out, err := tfmr.ReverseTranslate(val)
+	require.NoError(t, err)
+	cfg := out.Interface().(config)
+	assert.Equal(t, 90*time.Second, *cfg.Timeout)
+	assert.Equal(t, int64(2048), *cfg.Size)
+	assert.Nil(t, cfg.Count)
+	assert.Equal(t, uint16(2048), *cfg.Nested.Limit)
+
+	for name, v := range map[string]interface{}{
+		"fraction": 1.5,
+		"unit":     "2 fortnights",
+		"type":     true,
+	} {
+		val.Field(0).Set(reflect.ValueOf(v))
+		_, err = tfmr.ReverseTranslate(val)
+		assert.Error(t, err, name)
+	}
+	val.Field(0).Set(reflect.Zero(emptyInterfaceType))
+	nested.Elem().Field(0).Set(reflect.ValueOf("64KiB"))
+	_, err = tfmr.ReverseTranslate(val)
+	assert.Error(t, err, "overflow")
+
+	type badTag struct {
+		Size *int64 `dialsunit:"furlongs"`
+	}
+	_, err = NewTransformer(reflect.TypeOf(badTag{}), &UnitMangler{}).Translate()
+	assert.ErrorContains(t, err, `invalid dialsunit tag "furlongs" on field Size (must be "bytes")`)
+}
@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vimeo/dials/ptrify"
+)
+
+// UnknownKeys returns the paths of the keys in v that don't correspond to any
+// field of the (mangled) struct type t, whose fields are named by their
+// tagName tags (or their Go names, if they don't have one). v is the same data
+// the decoder decoded into t, decoded generically instead: maps (with string
+// keys, or keys that are formatted as strings), slices and scalars, as
+// encoding/json and most other format libraries produce when decoding into an
+// interface{}. Keys are matched case-insensitively, like encoding/json matches
+// them, and the fields of embedded structs without a name in their tags (or
+// with an "inline" option) are promoted. Paths join keys with dots, and
+// follow them with the indices of elements of slices of structs in brackets
+// (e.g. "servers[1].hostname"). They're sorted.
+func UnknownKeys(t reflect.Type, tagName string, v interface{}) []string {
+	unknown := []string{}
+	unknownKeys(&unknown, "", t, tagName, reflect.ValueOf(v))
+	sort.Strings(unknown)
+	return unknown
+}
+
+// unknownKeys appends the paths (prefixed by path) of the keys in v that
+// don't correspond to fields of the struct type t to unknown.
+func unknownKeys(unknown *[]string, path string, t reflect.Type, tagName string, v reflect.Value) {
+	v = stripPtrs(v)
+	if !v.IsValid() || v.Kind() != reflect.Map {
+		return
+	}
+	fields := map[string]reflect.Type{}
+	keyedFields(fields, t, tagName)
+	iter := v.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(stripPtrs(iter.Key()).Interface())
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		ft, ok := fields[strings.ToLower(key)]
+		if !ok {
+			*unknown = append(*unknown, keyPath)
+			continue
+		}
+		nestedUnknownKeys(unknown, keyPath, ft, tagName, iter.Value())
+	}
+}
+
+// nestedUnknownKeys appends the paths of the unknown keys in v, the value of
+// the field of type t at path, if t contains structs.
+func nestedUnknownKeys(unknown *[]string, path string, t reflect.Type, tagName string, v reflect.Value) {
+	k, t := getUnderlyingKindType(t)
+	switch k {
+	case reflect.Struct:
+		if !ptrify.IsScalarStruct(t) {
+			unknownKeys(unknown, path, t, tagName, v)
+		}
+	case reflect.Slice, reflect.Array:
+		v = stripPtrs(v)
+		if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			nestedUnknownKeys(unknown, fmt.Sprintf("%s[%d]", path, i), t.Elem(), tagName, v.Index(i))
+		}
+	case reflect.Map:
+		v = stripPtrs(v)
+		if !v.IsValid() || v.Kind() != reflect.Map {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(stripPtrs(iter.Key()).Interface())
+			nestedUnknownKeys(unknown, path+"."+key, t.Elem(), tagName, iter.Value())
+		}
+	default:
+	}
+}
+
+// keyedFields adds the types of the fields of the struct type t to fields,
+// keyed by their lower-cased keys, promoting the fields of inlined embedded
+// structs.
+func keyedFields(fields map[string]reflect.Type, t reflect.Type, tagName string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get(tagName), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if k, et := getUnderlyingKindType(sf.Type); k == reflect.Struct &&
+			((sf.Anonymous && name == "") || hasTagOption(opts, "inline")) {
+			keyedFields(fields, et, tagName)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[strings.ToLower(name)] = sf.Type
+	}
+}
+
+// hasTagOption returns true if the comma-separated options opts include opt.
+func hasTagOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	t.Parallel()
+	type server struct {
+		Host *string `json:"host"`
+	}
+	type Embedded struct {
+		Region *string `json:"region"`
+	}
+	type config struct {
+		Embedded
+		Name    *string            `json:"name"`
+		Server  *server            `json:"server"`
+		Backups []server           `json:"backups"`
+		ByZone  map[string]*server `json:"by_zone"`
+		Raw     interface{}        `json:"raw"`
+		Port    *int
+		Ignored *string `json:"-"`
+	}
+
+	var generic interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"region": "us", "NAME": "svc", "port": 1, "ignored": "x", "legacy": true,
+		"server": {"host": "h", "hostname": "h"},
+		"backups": [{"host": "b"}, {"hots": "b"}],
+		"by_zone": {"a": {"host": "z", "weight": 1}},
+		"raw": {"anything": 1}
+	}`), &generic))
+	assert.Equal(t, []string{"backups[1].hots", "by_zone.a.weight", "ignored", "legacy", "server.hostname"},
+		UnknownKeys(reflect.TypeOf(config{}), "json", generic))
+
+	// yaml.v2 decodes maps with interface{} keys
+	assert.Equal(t, []string{"server.port"}, UnknownKeys(reflect.TypeOf(config{}), "json",
+		map[interface{}]interface{}{"server": map[interface{}]interface{}{"host": "h", "port": 1}}))
+}
@@ -0,0 +1,103 @@
+package dials
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// UnusedConfig is the dead configuration found by [Params].DetectUnusedConfig.
+type UnusedConfig struct {
+	// UnsetFields contains the paths of the leaf fields (like
+	// [FieldExplanation].Path) that no source set in any configuration
+	// installed since Config, so they've always had their defaults from
+	// the template, in field order.
+	UnsetFields []string
+	// UnknownKeys contains the messages of the distinct
+	// [WarningUnknownKey] warnings reported by sources since Config (each
+	// naming a key, variable or flag that doesn't correspond to any
+	// field), sorted.
+	UnknownKeys []string
+}
+
+// UnusedConfig returns the fields that no source has set and the keys in
+// sources that don't correspond to any field, accumulated over every
+// configuration installed since Config. Fields that are only set by some
+// versions of a watched source's value aren't unset. It returns an empty
+// UnusedConfig unless [Params].DetectUnusedConfig is set.
+func (d *Dials[T]) UnusedConfig() UnusedConfig {
+	if d.unused == nil {
+		return UnusedConfig{}
+	}
+	return d.unused.report()
+}
+
+// DetectingUnusedConfig returns true if ctx (the context passed to a
+// source's Value or Watch method, or one derived from it) belongs to a Dials
+// instance with [Params].DetectUnusedConfig set, so sources and decoders
+// should report a [WarningUnknownKey] warning (see ReportWarning) for each
+// key that doesn't correspond to any field. Sources don't look for them
+// otherwise, as that usually requires decoding their data a second time.
+func DetectingUnusedConfig(ctx context.Context) bool {
+	sink, ok := ctx.Value(warningSinkCtxKey{}).(*warningSink)
+	return ok && sink.unused != nil
+}
+
+// unusedTracker accumulates the analysis reported by UnusedConfig.
+type unusedTracker struct {
+	mu sync.Mutex
+	// fields contains the paths of the leaf fields in field order, and set
+	// the ones any source has set
+	fields []string
+	set    map[string]struct{}
+	// unknown contains the messages of the WarningUnknownKey warnings
+	unknown map[string]struct{}
+}
+
+func newUnusedTracker() *unusedTracker {
+	return &unusedTracker{set: map[string]struct{}{}, unknown: map[string]struct{}{}}
+}
+
+// recordInstalled records the fields set in an installed configuration,
+// described by exps.
+func (u *unusedTracker) recordInstalled(exps []FieldExplanation) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fields == nil {
+		u.fields = make([]string, 0, len(exps))
+		for _, exp := range exps {
+			u.fields = append(u.fields, exp.Path)
+		}
+	}
+	for _, exp := range exps {
+		if exp.Source != nil {
+			u.set[exp.Path] = struct{}{}
+		}
+	}
+}
+
+// recordWarning records w if it's a WarningUnknownKey.
+func (u *unusedTracker) recordWarning(w Warning) {
+	if w.Kind != WarningUnknownKey {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.unknown[w.Message] = struct{}{}
+}
+
+func (u *unusedTracker) report() UnusedConfig {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r := UnusedConfig{UnsetFields: []string{}, UnknownKeys: make([]string, 0, len(u.unknown))}
+	for _, path := range u.fields {
+		if _, ok := u.set[path]; !ok {
+			r.UnsetFields = append(r.UnsetFields, path)
+		}
+	}
+	for msg := range u.unknown {
+		r.UnknownKeys = append(r.UnknownKeys, msg)
+	}
+	sort.Strings(r.UnknownKeys)
+	return r
+}
@@ -0,0 +1,75 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unknownKeySource reports an unknown key if the Dials instance is detecting
+// unused configuration.
+type unknownKeySource struct {
+	fakeSource
+}
+
+func (u *unknownKeySource) Value(ctx context.Context, t *Type) (reflect.Value, error) {
+	if DetectingUnusedConfig(ctx) {
+		ReportWarning(ctx, Warning{Kind: WarningUnknownKey, Source: u, Message: `key "legacy" doesn't correspond to any field`})
+	}
+	return u.fakeSource.Value(ctx, t)
+}
+
+func TestUnusedConfig(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type server struct {
+		Host string
+		Port int
+	}
+	type unusedConfig struct {
+		Name   string
+		Server server
+		Debug  bool
+	}
+	type ptrifiedServer = struct {
+		Host *string
+		Port *int
+	}
+	type ptrifiedConfig struct {
+		Name   *string
+		Server *ptrifiedServer
+		Debug  *bool
+	}
+
+	name, port := "svc", 80
+	u := &unknownKeySource{fakeSource{outVal: ptrifiedConfig{Name: &name}}}
+	w := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := New(ctx, &unusedConfig{Debug: true}, WithSources(u, w), WithDetectUnusedConfig())
+	require.NoError(t, err)
+	defer d.Close(ctx)
+	assert.Equal(t, UnusedConfig{
+		UnsetFields: []string{"Server.Host", "Server.Port", "Debug"},
+		UnknownKeys: []string{`key "legacy" doesn't correspond to any field`},
+	}, d.UnusedConfig())
+
+	// fields set by any installed version aren't unset, even if later
+	// versions don't set them
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Server: &ptrifiedServer{Port: &port}}))
+	<-d.Events()
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{}))
+	<-d.Events()
+	assert.Equal(t, []string{"Server.Host", "Debug"}, d.UnusedConfig().UnsetFields)
+
+	// sources don't look for unknown keys unless it's enabled
+	d2, err := Config(ctx, &unusedConfig{}, u)
+	require.NoError(t, err)
+	defer d2.Close(ctx)
+	assert.Equal(t, UnusedConfig{}, d2.UnusedConfig())
+	assert.Empty(t, d2.Warnings())
+}
@@ -0,0 +1,138 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// validateUpdateGroups verifies that every member of groups is one of
+// sources, and that no source is in more than one group.
+func validateUpdateGroups(groups [][]Source, sources []Source) error {
+	known := make(map[Source]struct{}, len(sources))
+	for _, s := range sources {
+		known[s] = struct{}{}
+	}
+	seen := map[Source]int{}
+	for gi, g := range groups {
+		for _, s := range g {
+			if _, ok := known[s]; !ok {
+				return fmt.Errorf("member of update group %d (%T) was not passed to Config", gi, s)
+			}
+			if prev, ok := seen[s]; ok {
+				return fmt.Errorf("source %T is a member of both update groups %d and %d", s, prev, gi)
+			}
+			seen[s] = gi
+		}
+	}
+	return nil
+}
+
+// updateGroup holds the values reported by members of one of the
+// Params.UpdateGroups until they can be installed together. It's owned by the
+// monitor goroutine.
+type updateGroup struct {
+	// waiting contains the members that have neither reported a value
+	// since the last flush, nor stopped watching
+	waiting map[Source]struct{}
+	// stopped contains the members that aren't watching
+	stopped map[Source]struct{}
+	members []Source
+	// pending contains the held updates, in the order they were reported
+	pending []*valueUpdate
+	// gen is incremented every flush, so stale timeouts can be ignored
+	gen   uint64
+	timer *time.Timer
+}
+
+// updateGroupTimeout is sent by an updateGroup's timer when
+// Params.UpdateGroupTimeout expires.
+type updateGroupTimeout struct {
+	g   *updateGroup
+	gen uint64
+}
+
+func (*updateGroupTimeout) isStatusReport() {}
+
+// newUpdateGroups returns the groups indexed by member.
+func newUpdateGroups(groups [][]Source, sourceValues []sourceValue) map[Source]*updateGroup {
+	watching := make(map[Source]bool, len(sourceValues))
+	for _, sv := range sourceValues {
+		watching[sv.source] = sv.watching
+	}
+	out := map[Source]*updateGroup{}
+	for _, members := range groups {
+		g := &updateGroup{stopped: map[Source]struct{}{}, members: members}
+		for _, m := range members {
+			if !watching[m] {
+				g.stopped[m] = struct{}{}
+			}
+			out[m] = g
+		}
+		g.reset()
+	}
+	return out
+}
+
+func (g *updateGroup) reset() {
+	g.waiting = make(map[Source]struct{}, len(g.members))
+	for _, m := range g.members {
+		if _, ok := g.stopped[m]; !ok {
+			g.waiting[m] = struct{}{}
+		}
+	}
+}
+
+// add holds u, returning true if the group is ready to be flushed.
+func (g *updateGroup) add(u *valueUpdate) bool {
+	g.pending = append(g.pending, u)
+	delete(g.waiting, u.source)
+	return len(g.waiting) == 0
+}
+
+// markDone records that s has stopped watching, returning true if the group
+// has pending updates that are now ready to be flushed.
+func (g *updateGroup) markDone(s Source) bool {
+	g.stopped[s] = struct{}{}
+	delete(g.waiting, s)
+	return len(g.pending) > 0 && len(g.waiting) == 0
+}
+
+// flush returns the pending updates and resets the group.
+func (g *updateGroup) flush() []*valueUpdate {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	g.gen++
+	pending := g.pending
+	g.pending = nil
+	g.reset()
+	return pending
+}
+
+// startTimer starts the timer for the current generation (if it hasn't
+// already started), which reports an updateGroupTimeout on c once timeout
+// expires.
+func (g *updateGroup) startTimer(ctx context.Context, timeout time.Duration, c chan<- watchStatusUpdate) {
+	if timeout <= 0 || g.timer != nil {
+		return
+	}
+	ev := &updateGroupTimeout{g: g, gen: g.gen}
+	g.timer = time.AfterFunc(timeout, func() {
+		select {
+		case <-ctx.Done():
+		case c <- ev:
+		}
+	})
+}
+
+// notifyInstalled sends the result of installing updates to the callers of
+// BlockingReportNewValue that are waiting on it.
+func notifyInstalled(updates []*valueUpdate, err error) {
+	for _, u := range updates {
+		if u.installed != nil {
+			u.installed <- err
+		}
+	}
+}
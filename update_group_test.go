@@ -0,0 +1,78 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tlsConfig struct {
+	Cert string
+	Key  string
+}
+
+type ptrTLSConfig struct {
+	Cert *string
+	Key  *string
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestUpdateGroups(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cert := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{Cert: strPtr("cert1")}}}
+	key := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{Key: strPtr("key1")}}}
+	d, err := Params[tlsConfig]{
+		UpdateGroups: [][]Source{{cert, key}},
+	}.Config(ctx, &tlsConfig{}, cert, key)
+	require.NoError(t, err)
+	assert.Equal(t, &tlsConfig{Cert: "cert1", Key: "key1"}, d.View())
+
+	cert.send(ctx, reflect.ValueOf(ptrTLSConfig{Cert: strPtr("cert2")}))
+	// the monitor has received the cert, but holds it until the key arrives
+	assert.Equal(t, &tlsConfig{Cert: "cert1", Key: "key1"}, d.View())
+
+	key.send(ctx, reflect.ValueOf(ptrTLSConfig{Key: strPtr("key2")}))
+	assert.Equal(t, &tlsConfig{Cert: "cert2", Key: "key2"}, <-d.Events())
+	_, serial := d.ViewVersion()
+	assert.EqualValues(t, 1, serial.Generation())
+
+	// once the key source stops watching, cert updates go through alone
+	key.args.Done(ctx)
+	cert.send(ctx, reflect.ValueOf(ptrTLSConfig{Cert: strPtr("cert3")}))
+	assert.Equal(t, &tlsConfig{Cert: "cert3", Key: "key2"}, <-d.Events())
+}
+
+func TestUpdateGroupTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cert := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{Cert: strPtr("cert1")}}}
+	key := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{Key: strPtr("key1")}}}
+	d, err := Params[tlsConfig]{
+		UpdateGroups:       [][]Source{{cert, key}},
+		UpdateGroupTimeout: 10 * time.Millisecond,
+	}.Config(ctx, &tlsConfig{}, cert, key)
+	require.NoError(t, err)
+
+	cert.send(ctx, reflect.ValueOf(ptrTLSConfig{Cert: strPtr("cert2")}))
+	assert.Equal(t, &tlsConfig{Cert: "cert2", Key: "key1"}, <-d.Events())
+}
+
+func TestUpdateGroupsInvalid(t *testing.T) {
+	ctx := context.Background()
+	a := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{}}}
+	b := &fakeWatchingSource{fakeSource: fakeSource{outVal: ptrTLSConfig{}}}
+
+	_, err := Params[tlsConfig]{UpdateGroups: [][]Source{{a, b}}}.Config(ctx, &tlsConfig{}, a)
+	assert.Error(t, err)
+
+	_, err = Params[tlsConfig]{UpdateGroups: [][]Source{{a}, {a, b}}}.Config(ctx, &tlsConfig{}, a, b)
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+package dials
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidateTag is the name of the struct tag holding a comma-separated list of
+// rules that a field's value must satisfy, as a lighter-weight alternative to
+// implementing [VerifiedConfig] (e.g. `dialsvalidate:"min=1,max=65535"`).
+// Supported rules:
+//
+//   - nonzero: the value must not be the zero value
+//   - min=N, max=N: bounds (inclusive) for numeric fields (durations for
+//     time.Duration fields, e.g. "min=1s")
+//   - minlen=N, maxlen=N: bounds (inclusive) on the length of string (in
+//     characters), slice, array and map fields
+//   - regexp=PATTERN: the string field must match PATTERN. Since the pattern
+//     may contain commas, this must be the last rule.
+//
+// Rules are checked along with required fields (see [RequiredTag]), before
+// calling Verify, and a [*ConfigErrors] with an error for every failing rule
+// is returned (or passed to OnWatchedError). Nil pointers only fail nonzero.
+const ValidateTag = "dialsvalidate"
+
+// checkFields checks the required fields (see checkRequired) and the
+// validation rules of cfg, returning a *ConfigErrors describing every problem.
+func checkFields(cfg interface{}, sources []sourceValue) error {
+	missing, err := checkRequired(cfg, sources)
+	if err != nil {
+		return err
+	}
+	invalid, err := validateFields(cfg)
+	if err != nil {
+		return err
+	}
+	if errs := append(missing, invalid...); len(errs) > 0 {
+		return &ConfigErrors{Errors: errs}
+	}
+	return nil
+}
+
+// validateFields returns an error for each rule from a ValidateTag that a
+// leaf field of cfg (the composed configuration) fails, or a non-nil second
+// error if a ValidateTag is invalid.
+func validateFields(cfg interface{}) ([]*ConfigError, error) {
+	root := derefConfig(reflect.ValueOf(cfg).Elem())
+	failed := []*ConfigError{}
+	var tagErr error
+	walkLeaves(nil, root, func(sf reflect.StructField, path []string, v reflect.Value) {
+		tag, ok := sf.Tag.Lookup(ValidateTag)
+		if !ok || tagErr != nil {
+			return
+		}
+		errs, err := validateField(v, tag)
+		if err != nil {
+			tagErr = fmt.Errorf("invalid %s tag %q on field %s: %w", ValidateTag, tag, strings.Join(path, "."), err)
+			return
+		}
+		for _, e := range errs {
+			failed = append(failed, &ConfigError{Path: strings.Join(path, "."), Err: e})
+		}
+	})
+	if tagErr != nil {
+		return nil, tagErr
+	}
+	return failed, nil
+}
+
+// validateField checks v against the rules in tag, returning an error for
+// each failing rule, or a non-nil second error if tag is invalid.
+func validateField(v reflect.Value, tag string) ([]error, error) {
+	errs := []error{}
+	for tag != "" {
+		rule := tag
+		if !strings.HasPrefix(rule, "regexp=") {
+			rule, tag, _ = strings.Cut(tag, ",")
+		} else {
+			tag = ""
+		}
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "nonzero" {
+			if hasArg {
+				return nil, fmt.Errorf("unexpected argument to nonzero")
+			}
+			if v.IsZero() {
+				errs = append(errs, fmt.Errorf("must be non-zero"))
+			}
+			continue
+		}
+		if !hasArg {
+			return nil, fmt.Errorf("missing argument to %q", name)
+		}
+		bound, err := cachedRule(name, arg, v.Type())
+		if err != nil {
+			return nil, err
+		}
+		// the remaining rules don't apply to unset pointers
+		elem := v
+		for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Ptr {
+			continue
+		}
+		if err := checkRule(name, arg, bound, elem); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type ruleKey struct {
+	name, arg string
+	t         reflect.Type
+}
+
+type parsedRule struct {
+	bound interface{}
+	err   error
+}
+
+// parsedRules caches the results of parseRule, so each restack doesn't
+// reparse the tags (and recompile their regular expressions).
+var parsedRules sync.Map
+
+// cachedRule is parseRule, with its results cached in parsedRules.
+func cachedRule(name, arg string, t reflect.Type) (interface{}, error) {
+	key := ruleKey{name: name, arg: arg, t: t}
+	if r, ok := parsedRules.Load(key); ok {
+		pr := r.(parsedRule)
+		return pr.bound, pr.err
+	}
+	bound, err := parseRule(name, arg, t)
+	parsedRules.Store(key, parsedRule{bound: bound, err: err})
+	return bound, err
+}
+
+// parseRule parses the argument of the rule name for a field of type t.
+func parseRule(name, arg string, t reflect.Type) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch name {
+	case "min", "max":
+		switch {
+		case t == durationType:
+			return time.ParseDuration(arg)
+		case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+			return strconv.ParseInt(arg, 10, 64)
+		case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+			return strconv.ParseUint(arg, 10, 64)
+		case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+			return strconv.ParseFloat(arg, 64)
+		default:
+			return nil, fmt.Errorf("%s requires a numeric field, not %s", name, t)
+		}
+	case "minlen", "maxlen":
+		switch t.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return strconv.Atoi(arg)
+		default:
+			return nil, fmt.Errorf("%s requires a string, slice, array or map field, not %s", name, t)
+		}
+	case "regexp":
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("regexp requires a string field, not %s", t)
+		}
+		return regexp.Compile(arg)
+	default:
+		return nil, fmt.Errorf("unknown rule %q", name)
+	}
+}
+
+// checkRule returns an error if the (non-pointer) value v fails the rule
+// name, whose argument arg was parsed into bound by parseRule.
+func checkRule(name, arg string, bound interface{}, v reflect.Value) error {
+	switch name {
+	case "min", "max":
+		var cmp int
+		switch b := bound.(type) {
+		case time.Duration:
+			cmp = compare(time.Duration(v.Int()), b)
+		case int64:
+			cmp = compare(v.Int(), b)
+		case uint64:
+			cmp = compare(v.Uint(), b)
+		case float64:
+			cmp = compare(v.Float(), b)
+		}
+		if name == "min" && cmp < 0 {
+			return fmt.Errorf("must be at least %s", arg)
+		}
+		if name == "max" && cmp > 0 {
+			return fmt.Errorf("must be at most %s", arg)
+		}
+	case "minlen", "maxlen":
+		l := v.Len()
+		if v.Kind() == reflect.String {
+			l = utf8.RuneCountInString(v.String())
+		}
+		if name == "minlen" && l < bound.(int) {
+			return fmt.Errorf("length must be at least %d", bound)
+		}
+		if name == "maxlen" && l > bound.(int) {
+			return fmt.Errorf("length must be at most %d", bound)
+		}
+	case "regexp":
+		if !bound.(*regexp.Regexp).MatchString(v.String()) {
+			return fmt.Errorf("must match %q", arg)
+		}
+	}
+	return nil
+}
+
+func compare[N interface {
+	~int64 | ~uint64 | ~float64
+}](a, b N) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
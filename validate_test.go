@@ -0,0 +1,106 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedConfig struct {
+	Port    int           `dialsvalidate:"min=1,max=65535"`
+	Timeout time.Duration `dialsvalidate:"min=1s"`
+	Name    string        `dialsvalidate:"minlen=2,maxlen=8,regexp=^[a-z]+(,[a-z]+)*$"`
+	Ratio   *float64      `dialsvalidate:"max=1"`
+	Inner   struct {
+		Hosts []string `dialsvalidate:"nonzero,maxlen=2"`
+	}
+}
+
+type ptrifiedValidatedConfig struct {
+	Port    *int
+	Timeout *time.Duration
+	Name    *string
+	Ratio   *float64
+	Inner   *struct {
+		Hosts []string
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	tmpl := validatedConfig{Port: 8080, Timeout: time.Minute, Name: "svc,api"}
+	tmpl.Inner.Hosts = []string{"a"}
+	d, err := Config(ctx, &tmpl, &fakeSource{outVal: ptrifiedValidatedConfig{}})
+	require.NoError(t, err)
+	assert.Equal(t, 8080, d.View().Port)
+
+	port, ratio, name := 0, 1.5, "Svc"
+	src := ptrifiedValidatedConfig{Port: &port, Ratio: &ratio, Name: &name}
+	src.Inner = &struct{ Hosts []string }{Hosts: []string{"a", "b", "c"}}
+	_, err = Config(ctx, &tmpl, &fakeSource{outVal: src})
+	require.Error(t, err)
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	assert.Equal(t, []*ConfigError{
+		{Path: "Port", Err: errors.New("must be at least 1")},
+		{Path: "Name", Err: errors.New(`must match "^[a-z]+(,[a-z]+)*$"`)},
+		{Path: "Ratio", Err: errors.New("must be at most 1")},
+		{Path: "Inner.Hosts", Err: errors.New("length must be at most 2")},
+	}, ce.Errors)
+	assert.Contains(t, err.Error(), "4 configuration errors: Port: must be at least 1; Name: ")
+}
+
+func TestValidateNonzero(t *testing.T) {
+	t.Parallel()
+	tmpl := validatedConfig{Port: 1, Timeout: time.Second, Name: "ab"}
+	_, err := Config(context.Background(), &tmpl, &fakeSource{outVal: ptrifiedValidatedConfig{}})
+	ce := &ConfigErrors{}
+	require.True(t, errors.As(err, &ce))
+	require.Len(t, ce.Errors, 1)
+	assert.Equal(t, "Inner.Hosts: must be non-zero", ce.Errors[0].Error())
+}
+
+func TestValidateInvalidTag(t *testing.T) {
+	t.Parallel()
+	for _, tbl := range []struct {
+		name string
+		cfg  interface{}
+		err  string
+	}{
+		{
+			name: "non_numeric_min",
+			cfg: &struct {
+				S string `dialsvalidate:"min=1"`
+			}{},
+			err: `invalid dialsvalidate tag "min=1" on field S: min requires a numeric field, not string`,
+		},
+		{
+			name: "unknown_rule",
+			cfg: &struct {
+				I int `dialsvalidate:"positive"`
+			}{},
+			err: `invalid dialsvalidate tag "positive" on field I: missing argument to "positive"`,
+		},
+		{
+			name: "bad_regexp",
+			cfg: &struct {
+				S *string `dialsvalidate:"regexp=("`
+			}{},
+			err: `invalid dialsvalidate tag "regexp=(" on field S: error parsing regexp`,
+		},
+	} {
+		tbl := tbl
+		t.Run(tbl.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := validateFields(tbl.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tbl.err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ContextVerifiedConfig is an alternative to [VerifiedConfig] for
+// configurations whose verification may need to observe a deadline (e.g.
+// because it resolves a hostname). If a configuration implements both
+// interfaces, VerifyWithContext takes precedence.
+type ContextVerifiedConfig interface {
+	// VerifyWithContext should return a non-nil error if the configuration
+	// is invalid. The context expires when the
+	// [Params].VerificationTimeout elapses (if set), after which the
+	// configuration is considered invalid regardless of the return value.
+	VerifyWithContext(ctx context.Context) error
+}
+
+// ErrVerificationTimeout is wrapped by the error returned (and passed to
+// OnWatchedError) when a call to Verify or VerifyWithContext does not return
+// within [Params].VerificationTimeout.
+var ErrVerificationTimeout = errors.New("configuration verification timed out")
+
+// isVerifiedConfig returns true if cfg implements either VerifiedConfig or
+// ContextVerifiedConfig
+func isVerifiedConfig(cfg any) bool {
+	switch cfg.(type) {
+	case ContextVerifiedConfig, VerifiedConfig:
+		return true
+	default:
+		return false
+	}
+}
+
+// verifyConfig calls the VerifyWithContext or Verify method on cfg (if
+// present), treating calls that run longer than a positive timeout as failed.
+//
+// Verify methods cannot be interrupted, so a call that exceeds the timeout
+// continues to run on its own goroutine until it returns; its result is
+// discarded.
+func verifyConfig(ctx context.Context, cfg any, timeout time.Duration) error {
+	var verify func(ctx context.Context) error
+	switch v := cfg.(type) {
+	case ContextVerifiedConfig:
+		verify = v.VerifyWithContext
+	case VerifiedConfig:
+		verify = func(context.Context) error { return v.Verify() }
+	default:
+		return nil
+	}
+	if timeout <= 0 {
+		return verify(ctx)
+	}
+
+	vCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// buffered so an abandoned call doesn't leak its goroutine forever
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- verify(vCtx)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-vCtx.Done():
+		if ctx.Err() != nil {
+			return fmt.Errorf("context expired during verification: %w", ctx.Err())
+		}
+		return fmt.Errorf("%w after %s", ErrVerificationTimeout, timeout)
+	}
+}
@@ -0,0 +1,108 @@
+package dials
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingVerifier blocks in Verify() until unblock is closed if Hang is set.
+// As unblock is unexported, it's carried over from the template by each
+// composed configuration.
+type hangingVerifier struct {
+	Hang bool
+	Foo  string
+
+	unblock chan struct{}
+}
+
+func (h hangingVerifier) Verify() error {
+	if h.Hang {
+		<-h.unblock
+	}
+	return nil
+}
+
+var _ VerifiedConfig = (*hangingVerifier)(nil)
+
+func TestVerificationTimeout(t *testing.T) {
+	t.Parallel()
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	type ptrifiedConfig struct {
+		Hang *bool
+		Foo  *string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	params := Params[hangingVerifier]{
+		OnWatchedError:      func(_ context.Context, err error, _, _ *hangingVerifier) { errCh <- err },
+		VerificationTimeout: 10 * time.Millisecond,
+	}
+	w := fakeWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := params.Config(ctx, &hangingVerifier{Foo: "foo", unblock: unblock}, &w)
+	require.NoError(t, err)
+
+	hang := true
+	hangStr := "hang"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Hang: &hang, Foo: &hangStr}))
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, ErrVerificationTimeout)
+	case c := <-d.Events():
+		t.Fatalf("unexpectedly installed config with hanging Verify: %+v", c)
+	}
+	assert.Equal(t, "foo", d.View().Foo)
+
+	// the monitor goroutine should still be processing updates
+	fimStr := "fim"
+	w.send(ctx, reflect.ValueOf(ptrifiedConfig{Foo: &fimStr}))
+	select {
+	case c := <-d.Events():
+		assert.Equal(t, "fim", c.Foo)
+	case err := <-errCh:
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// deadlineVerifier fails if its context doesn't have a deadline.
+type deadlineVerifier struct {
+	Foo string
+}
+
+var errNoDeadline = errors.New("no deadline")
+
+func (deadlineVerifier) VerifyWithContext(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errNoDeadline
+	}
+	return nil
+}
+
+// Verify should never be called, since VerifyWithContext takes precedence.
+func (deadlineVerifier) Verify() error {
+	return errFailVerifier
+}
+
+var _ ContextVerifiedConfig = (*deadlineVerifier)(nil)
+
+func TestVerifyWithContext(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	_, err := Config(ctx, &deadlineVerifier{})
+	assert.ErrorIs(t, err, errNoDeadline)
+
+	d, err := Params[deadlineVerifier]{VerificationTimeout: time.Minute}.Config(ctx, &deadlineVerifier{Foo: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", d.View().Foo)
+}
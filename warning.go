@@ -0,0 +1,173 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vimeo/dials/parse"
+)
+
+// WarningKind classifies a [Warning].
+type WarningKind int
+
+const (
+	// WarningOther is for warnings that don't fit any other kind.
+	WarningOther WarningKind = iota
+	// WarningUnknownKey indicates that a source encountered a key (or
+	// variable, or flag) that doesn't correspond to any field.
+	WarningUnknownKey
+	// WarningDeprecated indicates that a deprecated field or name was used.
+	WarningDeprecated
+	// WarningCoercion indicates that a value couldn't be used as provided,
+	// and a fallback interpretation was used instead.
+	WarningCoercion
+	// WarningSlowSource indicates that a source's Value method took longer
+	// than [Params].SlowSourceThreshold.
+	WarningSlowSource
+	// WarningConflict indicates that sources set a field to different
+	// values, so the lower-precedence value was overridden. It's only
+	// reported if [Params].DetectConflicts is set.
+	WarningConflict
+	// WarningSourceFailed indicates that one of [Params].OptionalSources
+	// failed, so it was ignored.
+	WarningSourceFailed
+	// WarningAuditFailed indicates that [Params].AuditSink failed to record
+	// an installed configuration version.
+	WarningAuditFailed
+	// WarningLastKnownGoodFailed indicates that a sourcewrap.LastKnownGood
+	// couldn't persist a value to its cache file.
+	WarningLastKnownGoodFailed
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarningOther:
+		return "other"
+	case WarningUnknownKey:
+		return "unknown key"
+	case WarningDeprecated:
+		return "deprecated"
+	case WarningCoercion:
+		return "coercion"
+	case WarningSlowSource:
+		return "slow source"
+	case WarningConflict:
+		return "conflict"
+	case WarningSourceFailed:
+		return "source failed"
+	case WarningAuditFailed:
+		return "audit failed"
+	case WarningLastKnownGoodFailed:
+		return "last known good failed"
+	default:
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+}
+
+// Warning describes a non-fatal issue encountered while populating the
+// configuration; one that shouldn't block startup (or the installation of a
+// new version), but that someone should probably know about.
+type Warning struct {
+	Kind WarningKind
+	// Source is the source the warning pertains to, if any.
+	Source Source
+	// Message is a human-readable description of the issue.
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Source == nil {
+		return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+	}
+	return fmt.Sprintf("%s (source %T): %s", w.Kind, w.Source, w.Message)
+}
+
+// WarningHandler is a callback that's called when a [Warning] is reported.
+type WarningHandler func(ctx context.Context, w Warning)
+
+// warningBufferSize is the capacity of the channel returned by
+// Dials.Warnings.
+const warningBufferSize = 16
+
+type warningSink struct {
+	handler WarningHandler
+	ch      chan Warning
+
+	mu      sync.Mutex
+	dropped uint64
+
+	// unused records WarningUnknownKey warnings if
+	// Params.DetectUnusedConfig is set
+	unused *unusedTracker
+
+	// decodeHooks holds Params.DecodeHooks, indexed by their Target types
+	// (see DecodeHookLookup)
+	decodeHooks map[reflect.Type]parse.DecodeHook
+}
+
+func newWarningSink(handler WarningHandler) *warningSink {
+	return &warningSink{handler: handler, ch: make(chan Warning, warningBufferSize)}
+}
+
+func (w *warningSink) report(ctx context.Context, warning Warning) {
+	if w.unused != nil {
+		w.unused.recordWarning(warning)
+	}
+	if w.handler != nil {
+		w.handler(ctx, warning)
+	}
+	select {
+	case w.ch <- warning:
+	default:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.dropped++
+	}
+}
+
+type warningSinkCtxKey struct{}
+
+// ReportWarning reports a non-fatal issue to the Dials instance that's
+// populating the configuration. Sources may call it with the context passed
+// to their Value or Watch methods (or a context derived from either);
+// otherwise it's a no-op.
+func ReportWarning(ctx context.Context, w Warning) {
+	sink, ok := ctx.Value(warningSinkCtxKey{}).(*warningSink)
+	if !ok {
+		return
+	}
+	sink.report(ctx, w)
+}
+
+// timedValue calls source's Value method, reporting a WarningSlowSource if
+// it takes longer than a positive threshold.
+func timedValue(ctx context.Context, source Source, t *Type, threshold time.Duration) (reflect.Value, error) {
+	start := time.Now()
+	v, err := source.Value(ctx, t)
+	if elapsed := time.Since(start); threshold > 0 && elapsed > threshold {
+		ReportWarning(ctx, Warning{
+			Kind:    WarningSlowSource,
+			Source:  source,
+			Message: fmt.Sprintf("Value took %s (threshold %s)", elapsed, threshold),
+		})
+	}
+	return v, err
+}
+
+// Warnings returns a channel that receives every reported [Warning]. The
+// channel is buffered; warnings reported while it's full are dropped (they're
+// still passed to [Params].OnWarning). See DroppedWarnings.
+func (d *Dials[T]) Warnings() <-chan Warning {
+	return d.warnings.ch
+}
+
+// DroppedWarnings returns the number of warnings that weren't delivered on the
+// channel returned by Warnings because it was full.
+func (d *Dials[T]) DroppedWarnings() uint64 {
+	d.warnings.mu.Lock()
+	defer d.warnings.mu.Unlock()
+	return d.warnings.dropped
+}
@@ -0,0 +1,69 @@
+package dials
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warningSource reports a deprecation warning, then sleeps for delay.
+type warningSource struct {
+	delay time.Duration
+}
+
+func (w *warningSource) Value(ctx context.Context, t *Type) (reflect.Value, error) {
+	ReportWarning(ctx, Warning{Kind: WarningDeprecated, Source: w, Message: "field Old is deprecated"})
+	time.Sleep(w.delay)
+	return reflect.New(t.Type()).Elem(), nil
+}
+
+func TestWarnings(t *testing.T) {
+	type config struct {
+		Name string
+	}
+	var mu sync.Mutex
+	handled := []Warning{}
+	src := &warningSource{delay: 10 * time.Millisecond}
+	d, err := Params[config]{
+		OnWarning: func(_ context.Context, w Warning) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, w)
+		},
+		SlowSourceThreshold: time.Millisecond,
+	}.Config(context.Background(), &config{}, src)
+	require.NoError(t, err)
+
+	first := <-d.Warnings()
+	assert.Equal(t, Warning{Kind: WarningDeprecated, Source: src, Message: "field Old is deprecated"}, first)
+	assert.Equal(t, "deprecated (source *dials.warningSource): field Old is deprecated", first.String())
+	slow := <-d.Warnings()
+	assert.Equal(t, WarningSlowSource, slow.Kind)
+	assert.Equal(t, src, slow.Source)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []Warning{first, slow}, handled)
+}
+
+func TestWarningsDropped(t *testing.T) {
+	type config struct {
+		Name string
+	}
+	srcs := make([]Source, warningBufferSize+2)
+	for i := range srcs {
+		srcs[i] = &warningSource{}
+	}
+	d, err := Config(context.Background(), &config{}, srcs...)
+	require.NoError(t, err)
+	assert.Len(t, d.Warnings(), warningBufferSize)
+	assert.EqualValues(t, 2, d.DroppedWarnings())
+
+	// outside of a Value or Watch call, warnings go nowhere
+	ReportWarning(context.Background(), Warning{Message: "ignored"})
+}
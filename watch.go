@@ -0,0 +1,76 @@
+package dials
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// watch tracks the watch of a watching source: the context passed to its
+// Watch method, and the goroutines it started with WatchGo.
+type watch struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	running int
+	stopped bool
+	// idle is closed once the watch is stopped and its goroutines have
+	// exited
+	idle chan struct{}
+}
+
+func newWatch(parent context.Context) *watch {
+	ctx, cancel := context.WithCancel(parent)
+	return &watch{ctx: ctx, cancel: cancel, idle: make(chan struct{})}
+}
+
+// goroutine runs f with the watch's context on a new goroutine, unless the
+// watch has been stopped.
+func (w *watch) goroutine(f func(ctx context.Context)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	w.running++
+	go func() {
+		defer w.exited()
+		f(w.ctx)
+	}()
+}
+
+func (w *watch) exited() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running--
+	if w.stopped && w.running == 0 {
+		close(w.idle)
+	}
+}
+
+// stop cancels the watch's context, after which no more goroutines are
+// started. It's idempotent.
+func (w *watch) stop() {
+	w.cancel()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	if w.running == 0 {
+		close(w.idle)
+	}
+}
+
+// wait waits for the goroutines of a stopped watch to exit, or for ctx to
+// expire.
+func (w *watch) wait(ctx context.Context) error {
+	select {
+	case <-w.idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("context expired while awaiting watch goroutines: %w", ctx.Err())
+	}
+}
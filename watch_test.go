@@ -0,0 +1,112 @@
+package dials
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// goWatchingSource starts a goroutine with WatchGo, which lingers for a
+// moment after its context is canceled.
+type goWatchingSource struct {
+	fakeSource
+	args    WatchArgs
+	running int32
+}
+
+func (g *goWatchingSource) Watch(ctx context.Context, _ *Type, args WatchArgs) error {
+	g.args = args
+	atomic.AddInt32(&g.running, 1)
+	WatchGo(ctx, args, func(ctx context.Context) {
+		defer atomic.AddInt32(&g.running, -1)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+	})
+	return nil
+}
+
+func TestWatchGoroutines(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g1 := &goWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	g2 := &goWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{}, g1, g2)
+	require.NoError(t, err)
+
+	// RemoveSource waits for the removed source's goroutines
+	_, _, err = d.RemoveSource(ctx, g1)
+	require.NoError(t, err)
+	assert.Zero(t, atomic.LoadInt32(&g1.running))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&g2.running))
+
+	// and so does Close, for the rest
+	g3 := &goWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	_, _, err = d.AddSource(ctx, g3)
+	require.NoError(t, err)
+	require.NoError(t, d.Close(ctx))
+	assert.Zero(t, atomic.LoadInt32(&g2.running))
+	assert.Zero(t, atomic.LoadInt32(&g3.running))
+
+	// goroutines aren't started once the watch has stopped
+	ran := make(chan struct{}, 1)
+	g2.args.(WatchGoer).Go(func(context.Context) { ran <- struct{}{} })
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, ran)
+}
+
+func TestWatchGoroutinesCloseTimeout(t *testing.T) {
+	t.Parallel()
+	type testConfig struct {
+		Foo string
+	}
+	type ptrifiedConfig struct {
+		Foo *string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g := &goWatchingSource{fakeSource: fakeSource{outVal: ptrifiedConfig{}}}
+	d, err := Config(ctx, &testConfig{}, g)
+	require.NoError(t, err)
+	release := make(chan struct{})
+	g.args.(WatchGoer).Go(func(context.Context) { <-release })
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	assert.ErrorIs(t, d.Close(shortCtx), context.DeadlineExceeded)
+
+	// Close may be called again to resume waiting
+	close(release)
+	require.NoError(t, d.Close(ctx))
+}
+
+// plainWatchArgs implements WatchArgs, but not WatchGoer
+type plainWatchArgs struct {
+	WatchArgs
+}
+
+func TestWatchGoFallback(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan context.Context, 1)
+	WatchGo(ctx, plainWatchArgs{}, func(ctx context.Context) { got <- ctx })
+	select {
+	case fctx := <-got:
+		cancel()
+		<-fctx.Done()
+	case <-time.After(5 * time.Second):
+		t.Fatal("f wasn't run")
+	}
+}